@@ -0,0 +1,118 @@
+// Package apierror defines the errors handlers return to API clients. Each carries a code
+// that stays the same in every language, the HTTP status it is returned with, and optionally
+// the internal error behind it, which is logged but never sent to the client.
+package apierror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code is a machine-readable error code, which is also the key of the error's message in the
+// i18n catalogs
+type Code string
+
+const (
+	InternalError        Code = "internal_error"
+	InvalidRequestBody   Code = "invalid_request_body"
+	RouteNotFound        Code = "route_not_found"
+	RequestTooLarge      Code = "request_too_large"
+	UnsupportedMediaType Code = "unsupported_media_type"
+	UserNotFound         Code = "user_not_found"
+	InvalidInstanceID    Code = "invalid_instance_id"
+	InstanceNotFound     Code = "instance_not_found"
+	InstanceSuspended    Code = "instance_suspended"
+	InstanceLimitReached Code = "instance_limit_reached"
+	ProvisioningFailed   Code = "provisioning_failed"
+	PaymentRequired      Code = "payment_required"
+)
+
+// statuses holds the HTTP status each code is returned with unless the error overrides it
+var statuses = map[Code]int{
+	InternalError:        http.StatusInternalServerError,
+	InvalidRequestBody:   http.StatusBadRequest,
+	RouteNotFound:        http.StatusNotFound,
+	RequestTooLarge:      http.StatusRequestEntityTooLarge,
+	UnsupportedMediaType: http.StatusUnsupportedMediaType,
+	UserNotFound:         http.StatusUnauthorized,
+	InvalidInstanceID:    http.StatusBadRequest,
+	InstanceNotFound:     http.StatusNotFound,
+	InstanceSuspended:    http.StatusForbidden,
+	InstanceLimitReached: http.StatusForbidden,
+	ProvisioningFailed:   http.StatusInternalServerError,
+	PaymentRequired:      http.StatusPaymentRequired,
+}
+
+// Status returns the HTTP status the code is returned with, 500 for codes without one
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error that is safe to return to API clients
+type Error struct {
+	Code   Code
+	Status int
+	Args   []interface{}          // formatting arguments of the code's message
+	Fields map[string]interface{} // added to the response next to error and code
+	Err    error                  // internal cause, logged but never sent
+}
+
+// New creates an error with the code's status, its message formatted with args
+func New(code Code, args ...interface{}) *Error {
+	return &Error{Code: code, Status: code.Status(), Args: args}
+}
+
+// Wrap creates an error with the code's status for an internal error, which is kept for the
+// logs only
+func Wrap(err error, code Code) *Error {
+	return &Error{Code: code, Status: code.Status(), Err: err}
+}
+
+// From returns err if it is an *Error, and otherwise an internal error wrapping it
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return Wrap(err, InternalError)
+}
+
+// WithStatus returns the error with another HTTP status
+func (e *Error) WithStatus(status int) *Error {
+	e.Status = status
+	return e
+}
+
+// With adds a field to the error's response, e.g. the limit of instance_limit_reached
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Fields == nil {
+		e.Fields = make(map[string]interface{})
+	}
+	e.Fields[key] = value
+	return e
+}
+
+// Error describes the error for logs, with its internal cause
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return string(e.Code) + ": " + e.Err.Error()
+	}
+	return string(e.Code)
+}
+
+// Unwrap returns the internal cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Abort stops the request with an error, which the error handling middleware turns into the
+// response. Errors that are not an *Error are answered as internal errors.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -38,7 +38,7 @@ func main() {
 	
 	// Run migrations
 	logger.Info("Running database migrations...")
-	err = db.RunMigrations()
+	err = db.Migrate()
 	if err != nil {
 		logger.Fatalf("Failed to run migrations: %v", err)
 	}
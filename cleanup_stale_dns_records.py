@@ -22,9 +22,9 @@ logger = logging.getLogger(__name__)
 # Default AdGuard DNS configuration
 DEFAULT_CONFIG = {
     "protocol": os.environ.get("ADGUARD_PROTOCOL", "https"),
-    "host": os.environ.get("ADGUARD_HOST", "dns.srvr.site"),
-    "username": os.environ.get("ADGUARD_USERNAME", "Pi"),
-    "password": os.environ.get("ADGUARD_PASSWORD", "9130458959"),
+    "host": os.environ.get("ADGUARD_HOST", ""),
+    "username": os.environ.get("ADGUARD_USERNAME", ""),
+    "password": os.environ.get("ADGUARD_PASSWORD", ""),
 }
 
 class AdGuardDNSManager:
@@ -33,6 +33,8 @@ class AdGuardDNSManager:
         self.host = host or DEFAULT_CONFIG["host"]
         self.username = username or DEFAULT_CONFIG["username"]
         self.password = password or DEFAULT_CONFIG["password"]
+        if not (self.host and self.username and self.password):
+            raise ValueError("ADGUARD_HOST, ADGUARD_USERNAME and ADGUARD_PASSWORD must be set")
         self.base_url = f"{self.protocol}://{self.host}"
         self.auth_header = self._generate_auth_header()
         
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// errNeedsAPI is returned by the database backend for commands that run on the Docker host
+var errNeedsAPI = errors.New("this command needs the Docker host and is only available through the API")
+
+// apiBackend runs commands through the admin API
+type apiBackend struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func newAPIBackend(baseURL, apiKey string) *apiBackend {
+	return &apiBackend{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		// Followed logs stay open until interrupted, so requests time out through the server
+		client: &http.Client{},
+	}
+}
+
+// List fetches a page of an admin listing, returning its rows and the number of matching rows
+func (a *apiBackend) List(resource string, params map[string]string) ([]map[string]interface{}, int64, error) {
+	query := url.Values{}
+	for name, value := range params {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	resp, err := a.do(http.MethodGet, "/api/v1/admin/"+resource, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	total, err := strconv.ParseInt(resp.Header.Get("X-Total-Count"), 10, 64)
+	if err != nil {
+		total = int64(len(rows))
+	}
+	return rows, total, nil
+}
+
+// Reconcile reconciles an instance, returning the instance and the changes made
+func (a *apiBackend) Reconcile(instanceID string) (map[string]interface{}, error) {
+	return a.post("/api/v1/admin/instances/" + url.PathEscape(instanceID) + "/reconcile")
+}
+
+// Backup queues a manual backup of an instance
+func (a *apiBackend) Backup(instanceID string) (map[string]interface{}, error) {
+	return a.post("/api/v1/admin/instances/" + url.PathEscape(instanceID) + "/backups")
+}
+
+// Logs copies the output of an instance's container to stdout
+func (a *apiBackend) Logs(instanceID string, tail int, follow bool) error {
+	query := url.Values{"tail": {strconv.Itoa(tail)}, "follow": {strconv.FormatBool(follow)}}
+	resp, err := a.do(http.MethodGet, "/api/v1/admin/instances/"+url.PathEscape(instanceID)+"/logs", query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+func (a *apiBackend) post(path string) (map[string]interface{}, error) {
+	resp, err := a.do(http.MethodPost, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result, nil
+}
+
+// do sends an authenticated request, turning error responses into errors
+func (a *apiBackend) do(method, path string, query url.Values) (*http.Response, error) {
+	target := a.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		return nil, fmt.Errorf("%s (HTTP %d)", body.Error, resp.StatusCode)
+	}
+	return nil, fmt.Errorf("%s %s returned HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+}
+
+// databaseBackend runs commands on the database, for when the API is unavailable
+type databaseBackend struct {
+	cfg    *config.Config
+	logger *logrus.Logger
+}
+
+func newDatabaseBackend() (*databaseBackend, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := db.InitDB(cfg); err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	return &databaseBackend{cfg: cfg, logger: logger}, nil
+}
+
+// listSorts are the columns listings can be sorted by, as the admin API allows them
+var listSorts = map[string]map[string]bool{
+	"users":     {"email": true, "plan": true, "created_at": true},
+	"instances": {"name": true, "status": true, "created_at": true, "updated_at": true},
+}
+
+// List loads a page of users or instances with the fields the admin API returns
+func (d *databaseBackend) List(resource string, params map[string]string) ([]map[string]interface{}, int64, error) {
+	opts := db.ListOptions{Filters: map[string]string{}, Search: strings.TrimSpace(params["q"])}
+	var err error
+	if opts.Limit, err = strconv.Atoi(params["limit"]); err != nil || opts.Limit < 1 {
+		return nil, 0, fmt.Errorf("-limit must be positive")
+	}
+	if opts.Offset, err = strconv.Atoi(params["offset"]); err != nil || opts.Offset < 0 {
+		return nil, 0, fmt.Errorf("-offset must not be negative")
+	}
+	sort := params["sort"]
+	if sort == "" {
+		sort = "created_at"
+		if resource == "users" {
+			sort = "-created_at"
+		}
+	}
+	opts.Desc = strings.HasPrefix(sort, "-")
+	opts.Sort = strings.TrimPrefix(sort, "-")
+	if !listSorts[resource][opts.Sort] {
+		return nil, 0, fmt.Errorf("cannot sort %s by %q", resource, opts.Sort)
+	}
+	for _, name := range []string{"plan", "role", "status"} {
+		if params[name] != "" {
+			opts.Filters[name] = params[name]
+		}
+	}
+
+	var rows []map[string]interface{}
+	var total int64
+	if resource == "users" {
+		users, count, err := db.ListUsers(opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, user := range users {
+			instances, err := db.CountInstancesByUserID(user.ID)
+			if err != nil {
+				return nil, 0, err
+			}
+			row := user.ToPublicResponse()
+			row["role"] = user.Role
+			row["instance_count"] = instances
+			row["created_at"] = user.CreatedAt
+			rows = append(rows, row)
+		}
+		total = count
+	} else {
+		instances, count, err := db.ListAllInstances(opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, instance := range instances {
+			row := instance.ToPublicResponse()
+			row["user_id"] = instance.UserID
+			row["container_id"] = instance.ContainerID
+			row["ip_address"] = instance.IPAddress
+			rows = append(rows, row)
+		}
+		total = count
+	}
+
+	// Round-trip through JSON so values print the same as those from the API
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows = nil
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// Reconcile needs the Docker host
+func (d *databaseBackend) Reconcile(instanceID string) (map[string]interface{}, error) {
+	return nil, errNeedsAPI
+}
+
+// Backup records a manual backup and queues its job, which the backend's workers pick up
+func (d *databaseBackend) Backup(instanceID string) (map[string]interface{}, error) {
+	id, err := uuid.Parse(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance ID %q", instanceID)
+	}
+	instance, err := db.GetInstanceByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return nil, fmt.Errorf("instance has no container to back up yet")
+	}
+	active, err := db.HasActiveBackup(instance.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for an unfinished backup: %w", err)
+	}
+	if active {
+		return nil, fmt.Errorf("a backup of this instance is already in progress")
+	}
+
+	store, err := storage.New(d.cfg)
+	if err != nil {
+		return nil, err
+	}
+	// The workers of the running backend run the job; this process only queues it
+	runner := jobs.NewBackups(jobs.NewQueue(d.cfg, d.logger), nil, store, d.cfg, d.logger)
+	backup, err := runner.Create(instance, models.BackupManual)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	return backup.ToPublicResponse(), nil
+}
+
+// Logs needs the Docker host
+func (d *databaseBackend) Logs(instanceID string, tail int, follow bool) error {
+	return errNeedsAPI
+}
@@ -0,0 +1,247 @@
+// Command launchstackctl manages the platform from a terminal: it lists users and
+// instances, reconciles instances with their containers, queues backups and tails the
+// output of instance containers.
+//
+// It talks to the admin API of a running backend with the API key of an admin user. In an
+// emergency, when the API is down, -direct reads and writes the database configured in
+// .env or the environment instead; reconcile and logs need the Docker host and are not
+// available that way, while backups are queued for the backend's job workers.
+//
+// Usage:
+//
+//	launchstackctl [-api http://localhost:8080] [-key lsk_...] [-direct] [-json] <command> [flags] [args]
+//
+// Commands:
+//
+//	users list [-q text] [-plan plan] [-role role] [-sort -created_at] [-limit 100] [-offset 0]
+//	instances list [-q text] [-status status] [-sort created_at] [-limit 100] [-offset 0]
+//	reconcile <instance-id>
+//	backup <instance-id>
+//	logs [-tail 100] [-f] <instance-id>
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// columns lists the fields each listing prints as a table, in order
+var columns = map[string][]string{
+	"users":     {"id", "email", "plan", "role", "instance_count", "created_at"},
+	"instances": {"id", "name", "status", "user_id", "image_tag", "ip_address", "created_at"},
+}
+
+// backend runs the commands, either through the admin API or on the database
+type backend interface {
+	List(resource string, params map[string]string) ([]map[string]interface{}, int64, error)
+	Reconcile(instanceID string) (map[string]interface{}, error)
+	Backup(instanceID string) (map[string]interface{}, error)
+	Logs(instanceID string, tail int, follow bool) error
+}
+
+func main() {
+	apiURL := flag.String("api", envOr("LAUNCHSTACK_API_URL", "http://localhost:8080"), "Base URL of the backend (env LAUNCHSTACK_API_URL)")
+	apiKey := flag.String("key", os.Getenv("LAUNCHSTACK_API_KEY"), "API key of an admin user (env LAUNCHSTACK_API_KEY)")
+	direct := flag.Bool("direct", false, "Use the database configured in .env or the environment instead of the API")
+	asJSON := flag.Bool("json", false, "Print results as JSON")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var b backend
+	if *direct {
+		var err error
+		if b, err = newDatabaseBackend(); err != nil {
+			fail(err)
+		}
+	} else {
+		if *apiKey == "" {
+			fail(fmt.Errorf("set -key or LAUNCHSTACK_API_KEY to the API key of an admin user, or use -direct"))
+		}
+		b = newAPIBackend(strings.TrimRight(*apiURL, "/"), *apiKey)
+	}
+
+	if err := run(b, args, *asJSON); err != nil {
+		fail(err)
+	}
+}
+
+// run parses and runs a command
+func run(b backend, args []string, asJSON bool) error {
+	switch args[0] {
+	case "users", "instances":
+		if len(args) < 2 || args[1] != "list" {
+			return fmt.Errorf("usage: launchstackctl %s list [flags]", args[0])
+		}
+		return list(b, args[0], args[2:], asJSON)
+	case "reconcile":
+		instanceID, err := instanceArg(flag.NewFlagSet("reconcile", flag.ExitOnError), args[1:])
+		if err != nil {
+			return err
+		}
+		result, err := b.Reconcile(instanceID)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(result)
+		}
+		changes, _ := result["changes"].([]interface{})
+		if len(changes) == 0 {
+			fmt.Println("Instance already matches its status; nothing changed")
+			return nil
+		}
+		for _, change := range changes {
+			fmt.Printf("- %v\n", change)
+		}
+		return nil
+	case "backup":
+		instanceID, err := instanceArg(flag.NewFlagSet("backup", flag.ExitOnError), args[1:])
+		if err != nil {
+			return err
+		}
+		backup, err := b.Backup(instanceID)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(backup)
+		}
+		fmt.Printf("Queued backup %v of instance %s\n", backup["id"], instanceID)
+		return nil
+	case "logs":
+		flags := flag.NewFlagSet("logs", flag.ExitOnError)
+		tail := flags.Int("tail", 100, "Number of lines to show from the end of the log")
+		follow := flags.Bool("f", false, "Keep printing new lines until interrupted")
+		instanceID, err := instanceArg(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		return b.Logs(instanceID, *tail, *follow)
+	default:
+		return fmt.Errorf("unknown command %q; run launchstackctl -h for the list of commands", args[0])
+	}
+}
+
+// list prints a page of users or instances
+func list(b backend, resource string, args []string, asJSON bool) error {
+	flags := flag.NewFlagSet(resource+" list", flag.ExitOnError)
+	search := flags.String("q", "", "Only show instances whose name, or users whose email or name, contains this text")
+	sort := flags.String("sort", "", "Column to sort by, prefixed with - for descending order")
+	limit := flags.Int("limit", 100, "Maximum number of rows")
+	offset := flags.Int("offset", 0, "Number of rows to skip")
+	filters := map[string]*string{}
+	if resource == "users" {
+		filters["plan"] = flags.String("plan", "", "Only show users on this plan")
+		filters["role"] = flags.String("role", "", "Only show users with this role")
+	} else {
+		filters["status"] = flags.String("status", "", "Only show instances with this status")
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"q":      *search,
+		"sort":   *sort,
+		"limit":  fmt.Sprint(*limit),
+		"offset": fmt.Sprint(*offset),
+	}
+	for name, value := range filters {
+		params[name] = *value
+	}
+	rows, total, err := b.List(resource, params)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(rows)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns[resource], "\t")))
+	for _, row := range rows {
+		values := make([]string, len(columns[resource]))
+		for i, column := range columns[resource] {
+			values[i] = formatValue(row[column])
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nShowing %d of %d %s\n", len(rows), total, resource)
+	return nil
+}
+
+// instanceArg parses the flags of a command taking a single instance ID
+func instanceArg(flags *flag.FlagSet, args []string) (string, error) {
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 1 {
+		return "", fmt.Errorf("usage: launchstackctl %s [flags] <instance-id>", flags.Name())
+	}
+	return flags.Arg(0), nil
+}
+
+// formatValue formats a field of a listing for a table cell
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.Local().Format("2006-01-02 15:04")
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: launchstackctl [flags] <command> [flags] [args]
+
+Commands:
+  users list        List users with their plan, role and number of instances
+  instances list    List the instances of all users
+  reconcile <id>    Bring an instance's container, network and DNS record in line with its status
+  backup <id>       Queue a manual backup of an instance
+  logs <id>         Print the output of an instance's container; -f keeps following it
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
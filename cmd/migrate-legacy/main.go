@@ -0,0 +1,142 @@
+// Command migrate-legacy imports instances created by the legacy Caddyfile deployment,
+// which proxied each instance domain to a host port and kept instance data in host bind
+// mounts, into the current database, Docker volume and DNS model.
+//
+// Each legacy container is stopped, its data is copied into Docker volumes and it is
+// recreated on them. Only once the new container answers its health check is the
+// instance's Caddyfile site pointed at the container's internal DNS name and Caddy
+// reloaded; otherwise the legacy container is restored.
+//
+// Usage:
+//
+//	migrate-legacy [-caddyfile /etc/caddy/Caddyfile] [-reload "caddy reload ..."] [-container name] [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	caddyfilePath := flag.String("caddyfile", "/etc/caddy/Caddyfile", "Caddyfile written by the legacy deployment")
+	reloadCmd := flag.String("reload", "", "Command that reloads Caddy after the Caddyfile is rewritten (default: caddy reload --config <caddyfile>)")
+	only := flag.String("container", "", "Only migrate the legacy container with this name")
+	dryRun := flag.Bool("dry-run", false, "List what would be migrated without changing anything")
+	flag.Parse()
+
+	if *reloadCmd == "" {
+		*reloadCmd = fmt.Sprintf("caddy reload --config %s", *caddyfilePath)
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := db.InitDB(cfg); err != nil {
+		logger.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	dockerClient, err := container.NewDockerClient(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to Docker; check DOCKER_HOST and the DOCKER_TLS_* settings")
+	}
+	defer dockerClient.Close()
+	manager := container.NewManager(dockerClient, cfg, logger).(*container.DockerManager)
+
+	caddyfile, err := os.ReadFile(*caddyfilePath)
+	if err != nil {
+		logger.Fatalf("Failed to read Caddyfile: %v", err)
+	}
+	sites := map[string]container.LegacySite{}
+	for _, site := range container.ParseLegacyCaddyfile(string(caddyfile)) {
+		sites[site.Host] = site
+	}
+
+	ctx := context.Background()
+	legacyInstances, err := manager.FindLegacyInstances(ctx)
+	if err != nil {
+		logger.Fatalf("Failed to find legacy instances: %v", err)
+	}
+	logger.Infof("Found %d legacy instances and %d Caddyfile sites", len(legacyInstances), len(sites))
+
+	if !*dryRun {
+		backupPath := fmt.Sprintf("%s.pre-migration-%s", *caddyfilePath, time.Now().Format("20060102150405"))
+		if err := os.WriteFile(backupPath, caddyfile, 0644); err != nil {
+			logger.Fatalf("Failed to back up Caddyfile: %v", err)
+		}
+		logger.Infof("Backed up Caddyfile to %s", backupPath)
+	}
+
+	content := string(caddyfile)
+	migrated, failed := 0, 0
+	for _, legacy := range legacyInstances {
+		if *only != "" && legacy.ContainerName != *only {
+			continue
+		}
+		entry := logger.WithFields(logrus.Fields{
+			"container": legacy.ContainerName,
+			"url":       legacy.URL,
+			"data_dir":  legacy.DataDir,
+		})
+		site, hasSite := sites[legacy.URL]
+		if !hasSite {
+			entry.Warn("No Caddyfile site found for this instance; it will be migrated without a proxy cutover")
+		}
+
+		if *dryRun {
+			entry.WithField("upstream", site.Upstream).Info("Would migrate legacy instance")
+			continue
+		}
+
+		instance, err := manager.ImportLegacyInstance(ctx, legacy)
+		if err != nil {
+			entry.WithError(err).Error("Failed to migrate legacy instance; the legacy container was left in place")
+			failed++
+			continue
+		}
+		migrated++
+
+		if !hasSite {
+			continue
+		}
+
+		// Cut over one instance at a time so the proxy never points at a removed container
+		upstream := fmt.Sprintf("http://%s.docker:%d", instance.Host, cfg.Docker.N8NContainerPort)
+		content, _ = container.RewriteLegacyCaddyfileUpstream(content, site.Host, upstream)
+		if err := os.WriteFile(*caddyfilePath, []byte(content), 0644); err != nil {
+			entry.WithError(err).Error("Failed to write Caddyfile; update the site's reverse_proxy manually")
+			continue
+		}
+		if output, err := exec.Command("sh", "-c", *reloadCmd).CombinedOutput(); err != nil {
+			entry.WithError(err).Errorf("Failed to reload Caddy: %s", string(output))
+			continue
+		}
+		entry.WithFields(logrus.Fields{
+			"instance_id": instance.ID,
+			"upstream":    upstream,
+		}).Info("Migrated legacy instance and switched its Caddyfile site")
+	}
+
+	logger.Infof("Migration finished: %d migrated, %d failed", migrated, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,125 @@
+// Command migrate applies, rolls back and checks the versioned migrations of the database
+// configured in .env or the environment. The backend applies pending migrations on start
+// unless DB_MIGRATE says otherwise; this command is for running them as a separate
+// deployment step, inspecting them and writing new ones.
+//
+// SQLite databases are for development only and have no versioned migrations: up migrates
+// them from the models and verify checks them against the models.
+//
+// Usage:
+//
+//	migrate <command> [args]
+//
+// Commands:
+//
+//	up             apply all pending migrations
+//	down           roll back the latest applied migration
+//	redo           roll back the latest applied migration and apply it again
+//	status         list the migrations and when they were applied
+//	verify         fail if migrations are pending or model columns are missing
+//	create <name>  add an empty migration to db/migrations/sql
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/db/migrations"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	db.Logger = logger
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			logger.Fatal("usage: migrate create <name>")
+		}
+		if err := migrations.Create(migrations.Dir, args[1]); err != nil {
+			logger.Fatalf("Failed to create migration: %v", err)
+		}
+		return
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+	cfg, err := config.NewConfig()
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	// The command decides what to migrate, not DB_MIGRATE
+	cfg.Database.Migrate = "off"
+	if err := db.InitDB(cfg); err != nil {
+		logger.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := run(args[0], logger); err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// run runs a command on the database
+func run(command string, logger *logrus.Logger) error {
+	switch command {
+	case "up":
+		return db.Migrate()
+	case "down":
+		return migrations.Down(db.DB, logger)
+	case "redo":
+		return migrations.Redo(db.DB, logger)
+	case "verify":
+		if err := db.VerifyMigrations(); err != nil {
+			return err
+		}
+		logger.Info("Database schema is up to date")
+		return nil
+	case "status":
+		list, err := migrations.List(db.DB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tMIGRATION\tAPPLIED")
+		for _, migration := range list {
+			applied := "pending"
+			if migration.AppliedAt != nil {
+				applied = migration.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", migration.Version, migration.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown command %q; run migrate -h for the list of commands", command)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: migrate <command> [args]
+
+Commands:
+  up             Apply all pending migrations
+  down           Roll back the latest applied migration
+  redo           Roll back the latest applied migration and apply it again
+  status         List the migrations and when they were applied
+  verify         Fail if migrations are pending or columns of the models are missing
+  create <name>  Add an empty migration to %s
+`, migrations.Dir)
+}
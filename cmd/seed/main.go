@@ -0,0 +1,358 @@
+// Command seed fills a development database with realistic fake users, instances,
+// payments and a week of resource usage, so the frontend and the integration suite can
+// run against known data instead of manually created records or the staging database.
+//
+// Seeded users have a Clerk user ID starting with "seed_" and seeded instances a
+// container ID starting with "seed-", which is how -reset finds them again. The
+// development user used by mock authentication also gets a few seeded instances.
+// No containers are created, so the backend should run with the mock container manager.
+//
+// Usage:
+//
+//	seed [-users 12] [-days 7] [-interval 15m] [-random-seed 1] [-reset] [-force]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	seedClerkIDPrefix     = "seed_"
+	seedContainerIDPrefix = "seed-"
+	devUserID             = "f2814e7b-75a0-44d4-b345-e5ef5a84aab3" // see db.CreateDevUser
+)
+
+var (
+	firstNames    = []string{"Ada", "Grace", "Linus", "Margaret", "Alan", "Barbara", "Dennis", "Frances", "Ken", "Radia", "Tim", "Hedy"}
+	lastNames     = []string{"Lovelace", "Hopper", "Torvalds", "Hamilton", "Turing", "Liskov", "Ritchie", "Allen", "Thompson", "Perlman", "Berners-Lee", "Lamarr"}
+	instanceNames = []string{"crm-sync", "lead-router", "invoice-bot", "slack-alerts", "data-pipeline", "support-triage", "newsletter", "inventory"}
+)
+
+// seeder creates the fake records with a deterministic random source
+type seeder struct {
+	cfg      *config.Config
+	rand     *rand.Rand
+	now      time.Time
+	days     int
+	interval time.Duration
+	logger   *logrus.Logger
+}
+
+func main() {
+	users := flag.Int("users", 12, "Number of fake users to create")
+	days := flag.Int("days", 7, "Days of resource usage history to create for each instance")
+	interval := flag.Duration("interval", 15*time.Minute, "Time between resource usage samples")
+	randomSeed := flag.Int64("random-seed", 1, "Seed for the random data, so runs are reproducible")
+	reset := flag.Bool("reset", false, "Delete previously seeded data before seeding")
+	force := flag.Bool("force", false, "Allow seeding when APP_ENV is production")
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.Server.Environment == "production" && !*force {
+		logger.Fatal("Refusing to seed a production database; pass -force if this is intended")
+	}
+	if *interval <= 0 || *days < 0 || *users < 0 {
+		logger.Fatal("-users and -days must not be negative and -interval must be positive")
+	}
+	if err := db.InitDB(cfg); err != nil {
+		logger.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if *reset {
+		if err := deleteSeedData(logger); err != nil {
+			logger.Fatalf("Failed to delete seeded data: %v", err)
+		}
+	} else {
+		var existing int64
+		if err := db.DB.Model(&models.User{}).Where("clerk_user_id LIKE ?", seedClerkIDPrefix+"%").Count(&existing).Error; err != nil {
+			logger.Fatalf("Failed to check for seeded data: %v", err)
+		}
+		if existing > 0 {
+			logger.Fatalf("Found %d seeded users; run with -reset to replace them", existing)
+		}
+	}
+
+	s := &seeder{
+		cfg:      cfg,
+		rand:     rand.New(rand.NewSource(*randomSeed)),
+		now:      time.Now().Truncate(*interval),
+		days:     *days,
+		interval: *interval,
+		logger:   logger,
+	}
+
+	if err := db.CreateDevUser(); err != nil {
+		logger.Fatalf("Failed to create development user: %v", err)
+	}
+	devUser, err := db.GetUserByID(uuid.MustParse(devUserID))
+	if err != nil {
+		logger.Fatalf("Failed to load development user: %v", err)
+	}
+	if err := s.seedInstances(devUser, 3); err != nil {
+		logger.Fatalf("Failed to seed development user instances: %v", err)
+	}
+
+	for i := 0; i < *users; i++ {
+		user, err := s.seedUser(i)
+		if err != nil {
+			logger.Fatalf("Failed to seed user: %v", err)
+		}
+		if err := s.seedPayments(user); err != nil {
+			logger.Fatalf("Failed to seed payments: %v", err)
+		}
+		if err := s.seedInstances(user, 1+s.rand.Intn(min(user.GetInstancesLimit(), 3))); err != nil {
+			logger.Fatalf("Failed to seed instances: %v", err)
+		}
+	}
+
+	logger.Infof("Seeded %d users with instances, payments and %d days of resource usage", *users, *days)
+}
+
+// deleteSeedData removes everything a previous run created
+func deleteSeedData(logger *logrus.Logger) error {
+	var userIDs []uuid.UUID
+	if err := db.DB.Model(&models.User{}).Unscoped().Where("clerk_user_id LIKE ?", seedClerkIDPrefix+"%").Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+	var instanceIDs []uuid.UUID
+	if err := db.DB.Model(&models.Instance{}).Unscoped().Where("container_id LIKE ?", seedContainerIDPrefix+"%").Pluck("id", &instanceIDs).Error; err != nil {
+		return err
+	}
+
+	if len(instanceIDs) > 0 {
+		// Include records the backend's monitors added for the seeded instances
+		for _, record := range []interface{}{&models.ResourceUsage{}, &models.InstanceEvent{}, &models.ProbeResult{}, &models.InstanceCertificate{}} {
+			if err := db.DB.Unscoped().Where("instance_id IN ?", instanceIDs).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+		if err := db.DB.Unscoped().Where("id IN ?", instanceIDs).Delete(&models.Instance{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(userIDs) > 0 {
+		if err := db.DB.Where("user_id IN ?", userIDs).Delete(&models.Payment{}).Error; err != nil {
+			return err
+		}
+		if err := db.DB.Unscoped().Where("id IN ?", userIDs).Delete(&models.User{}).Error; err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("Deleted %d seeded users and %d seeded instances", len(userIDs), len(instanceIDs))
+	return nil
+}
+
+// seedUser creates a user with a plan and subscription state picked to cover the cases the frontend shows
+func (s *seeder) seedUser(index int) (models.User, error) {
+	firstName := firstNames[index%len(firstNames)]
+	lastName := lastNames[s.rand.Intn(len(lastNames))]
+	handle := fmt.Sprintf("%s.%s%d", strings.ToLower(firstName), strings.ToLower(strings.ReplaceAll(lastName, "-", "")), index+1)
+
+	user := models.User{
+		ClerkUserID: fmt.Sprintf("%suser_%03d", seedClerkIDPrefix, index+1),
+		Email:       handle + "@example.com",
+		Username:    handle,
+		FirstName:   firstName,
+		LastName:    lastName,
+		CreatedAt:   s.now.AddDate(0, 0, -30-s.rand.Intn(180)),
+	}
+
+	switch index % 6 {
+	case 0:
+		user.Plan = models.PlanFree
+	case 1:
+		user.Plan = models.PlanStarter
+		user.SubscriptionStatus = models.StatusTrial
+		user.CurrentPeriodEnd = s.now.AddDate(0, 0, 1+s.rand.Intn(6))
+	case 2, 3:
+		user.Plan = models.PlanStarter
+		user.SubscriptionStatus = models.StatusActive
+		user.CurrentPeriodEnd = s.now.AddDate(0, 0, 1+s.rand.Intn(29))
+	case 4:
+		user.Plan = models.PlanPro
+		user.SubscriptionStatus = models.StatusActive
+		user.CurrentPeriodEnd = s.now.AddDate(0, 0, 1+s.rand.Intn(29))
+	case 5:
+		user.Plan = models.PlanPro
+		// Past the billing grace period, so the instances are suspended
+		user.SubscriptionStatus = models.StatusPastDue
+		user.CurrentPeriodEnd = s.now.Add(-s.cfg.Billing.GracePeriod).AddDate(0, 0, -1)
+	}
+	if user.SubscriptionStatus == models.StatusActive || user.SubscriptionStatus == models.StatusPastDue {
+		user.SubscriptionProvider = s.cfg.Payments.Provider
+		user.SubscriptionID = fmt.Sprintf("%ssub_%s", seedClerkIDPrefix, randomHex(s.rand, 12))
+	}
+
+	if err := db.CreateUser(&user); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// seedPayments creates the monthly payment history of a paying user
+func (s *seeder) seedPayments(user models.User) error {
+	if user.SubscriptionID == "" {
+		return nil
+	}
+
+	price := int(math.Round(models.GetPlanPrice(user.Plan, models.BillingMonthly) * 100))
+	months := 1 + s.rand.Intn(6)
+	for month := months; month >= 0; month-- {
+		status := models.PaymentStatusSucceeded
+		if month == 0 && user.SubscriptionStatus == models.StatusPastDue {
+			status = models.PaymentStatusFailed
+		}
+		createdAt := user.CurrentPeriodEnd.AddDate(0, -month-1, 0)
+		if createdAt.After(s.now) {
+			continue
+		}
+		payment := models.Payment{
+			UserID:             user.ID,
+			Amount:             price,
+			Currency:           "usd",
+			Status:             status,
+			Provider:           user.SubscriptionProvider,
+			ProviderCheckoutID: fmt.Sprintf("%scheckout_%s", seedClerkIDPrefix, randomHex(s.rand, 12)),
+			ProviderPaymentID:  fmt.Sprintf("%spay_%s", seedClerkIDPrefix, randomHex(s.rand, 12)),
+			Description:        fmt.Sprintf("Subscription to %s plan", user.Plan),
+			Metadata:           `{"seed": true}`,
+			CreatedAt:          createdAt,
+			UpdatedAt:          createdAt,
+		}
+		if err := db.DB.Create(&payment).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedInstances creates instances for a user, each with its resource usage history
+func (s *seeder) seedInstances(user models.User, count int) error {
+	for i := 0; i < count; i++ {
+		name := instanceNames[s.rand.Intn(len(instanceNames))]
+		suffix := randomHex(s.rand, 4)
+		host := fmt.Sprintf("%s-%s", name, suffix)
+
+		status := models.StatusRunning
+		switch roll := s.rand.Intn(10); {
+		case roll == 0:
+			status = models.StatusError
+		case roll < 3:
+			status = models.StatusStopped
+		}
+		if user.SubscriptionStatus == models.StatusPastDue {
+			status = models.StatusSuspended
+		}
+
+		instance := models.Instance{
+			UserID:       user.ID,
+			Name:         fmt.Sprintf("%s-%s", name, suffix),
+			Description:  fmt.Sprintf("Seeded %s workflows", strings.ReplaceAll(name, "-", " ")),
+			Status:       status,
+			Host:         host,
+			Port:         s.cfg.Docker.N8NContainerPort,
+			URL:          fmt.Sprintf("%s.%s", host, s.cfg.Server.Domain),
+			CPULimit:     user.GetCPULimit(),
+			MemoryLimit:  user.GetMemoryLimit(),
+			StorageLimit: user.GetStorageLimit(),
+			ContainerID:  seedContainerIDPrefix + randomHex(s.rand, 28),
+			IPAddress:    fmt.Sprintf("10.1.2.%d", 10+s.rand.Intn(240)),
+			ImageTag:     models.LatestImageTag,
+			CreatedAt:    s.now.AddDate(0, 0, -s.days-s.rand.Intn(30)),
+		}
+		if status == models.StatusSuspended {
+			instance.SuspendedReason = models.SuspendReasonBilling
+		}
+		if err := db.CreateInstance(&instance); err != nil {
+			return err
+		}
+
+		if err := s.seedResourceUsage(instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedResourceUsage creates samples over the configured history with a daily load cycle,
+// stopping early for instances that are no longer running
+func (s *seeder) seedResourceUsage(instance models.Instance) error {
+	start := s.now.AddDate(0, 0, -s.days)
+	end := s.now
+	if instance.Status != models.StatusRunning {
+		end = start.Add(time.Duration(s.rand.Int63n(int64(s.now.Sub(start)) + 1)))
+	}
+
+	memoryLimit := int64(instance.MemoryLimit) * 1024 * 1024
+	baseLoad := 5 + s.rand.Float64()*20
+	diskUsage := int64(200+s.rand.Intn(800)) * 1024 * 1024
+	var networkIn, networkOut int64
+
+	var samples []models.ResourceUsage
+	for ts := start; !ts.After(end); ts = ts.Add(s.interval) {
+		// Busiest in the afternoon, quiet at night
+		hour := float64(ts.Hour()) + float64(ts.Minute())/60
+		cycle := (1 + math.Sin((hour-9)/24*2*math.Pi)) / 2
+		cpu := math.Min(100, baseLoad*(0.3+cycle)+s.rand.NormFloat64()*3)
+		if cpu < 0.1 {
+			cpu = 0.1
+		}
+
+		memory := int64(float64(memoryLimit) * math.Min(0.95, 0.25+cpu/200+s.rand.Float64()*0.05))
+		diskUsage += int64(s.rand.Intn(64)) * 1024
+		networkIn += int64((cycle + 0.1) * float64(s.rand.Intn(2*1024*1024)))
+		networkOut += int64((cycle + 0.1) * float64(s.rand.Intn(512*1024)))
+
+		samples = append(samples, models.ResourceUsage{
+			InstanceID:       instance.ID,
+			Timestamp:        ts,
+			CPUUsage:         math.Round(cpu*100) / 100,
+			MemoryUsage:      memory,
+			MemoryLimit:      memoryLimit,
+			MemoryPercentage: math.Round(float64(memory)/float64(memoryLimit)*10000) / 100,
+			DiskUsage:        diskUsage,
+			NetworkIn:        networkIn,
+			NetworkOut:       networkOut,
+			CreatedAt:        ts,
+			UpdatedAt:        ts,
+		})
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	return db.DB.CreateInBatches(samples, 500).Error
+}
+
+// randomHex returns n random hexadecimal characters
+func randomHex(r *rand.Rand, n int) string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = digits[r.Intn(len(digits))]
+	}
+	return string(b)
+}
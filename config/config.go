@@ -1,13 +1,31 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/launchstack/backend/models"
 )
 
+// FlagTrialExtensionOffer offers users on a trial a one-time extension they can claim themselves
+const FlagTrialExtensionOffer = "trial_extension_offer"
+
+// FlagAdminTerminal lets admins open a terminal in an instance's container
+const FlagAdminTerminal = "admin_terminal"
+
+// RegistryCredential is the login to an image registry
+type RegistryCredential struct {
+	Username string
+	Password string
+}
+
 // Config holds all configuration for the application
 type Config struct {
 	Server struct {
@@ -17,27 +35,144 @@ type Config struct {
 		BackendURL   string
 		FrontendURL  string
 		Domain       string
+		ShutdownTimeout time.Duration
+		FailFast        bool // refuse to start with validation problems instead of logging them
+	}
+	APIKeys struct {
+		SignatureMaxSkew time.Duration // how far a signed request's timestamp may drift from server time
 	}
 	Database struct {
-		URL string
+		Driver     string // postgres, or sqlite for local development and tests
+		SQLitePath string // database file of the sqlite driver, or :memory:
+		Migrate    string // up to apply pending migrations on connect, verify to only check them, or off
+		MaxOpenConns        int           // connections the pool may open at once
+		MaxIdleConns        int           // connections kept open while unused
+		ConnMaxLifetime     time.Duration // age after which a connection is replaced
+		ConnMaxIdleTime     time.Duration // idle time after which a connection is closed
+		ConnectTimeout      time.Duration // how long to retry the first connection before giving up
+		HealthCheckInterval time.Duration // time between pings of the connection monitor
+		URL      string
+		Host     string
+		Port     string
+		User     string
+		Password string
+		Name     string
 	}
 	Clerk struct {
 		SecretKey        string
 		WebhookSecret    string
+		WebhookTimeout   time.Duration // how long a webhook event is applied inline before it is acknowledged and finished in the background
+		AsyncEvents      map[string]bool // webhook event types applied on the job queue
+		EventRetention   time.Duration   // how long applied webhook events are kept to recognise redeliveries
+		UserCacheTTL     time.Duration   // how long users resolved from session tokens are cached; 0 disables the cache
 		PublishableKey   string
 		Issuer           string
 	}
+	Payments struct {
+		Provider string // paypal or stripe
+	}
 	PayPal struct {
 		DisablePayments  bool
 		APIKey           string
 		Secret           string
 		Mode             string
+		StarterPlanID    string // PayPal billing plan that subscriptions are revised to for the starter plan
+		ProPlanID        string // PayPal billing plan that subscriptions are revised to for the pro plan
+	}
+	Stripe struct {
+		SecretKey      string
+		WebhookSecret  string
+		StarterPriceID string
+		ProPriceID     string
+	}
+	Billing struct {
+		UsageWebhookURL     string // receives closed monthly usage records; empty disables export
+		UsageWebhookSecret  string
+		UsageExportInterval time.Duration
+		EnforcementInterval time.Duration // how often lapsed subscriptions are checked
+		GracePeriod         time.Duration // how long after the period ends instances keep running
+		JournalCheckInterval time.Duration // how often the billing journal's integrity is checked
+		SubscriptionSyncInterval time.Duration // how often subscriptions are compared with the payment provider; 0 disables it
+		UsagePrices struct { // cents per unit of metered usage; 0 leaves the usage free
+			CPUHour      float64
+			MemoryGBHour float64
+			NetworkOutGB float64
+		}
+		SpendingCheckInterval time.Duration // how often users are checked against their spending caps
+	}
+	Plans struct {
+		RefreshInterval time.Duration // how often the plan catalog is reloaded from the database
+	}
+	Trials struct {
+		Plans            map[string]int // plans offering a trial, with the trial's length in days
+		MaxExtensionDays int            // most days a single admin extension may add to a trial
+		OfferDays        int            // days of the one-time extension users can claim themselves
+		SignupPlan       string         // plan whose trial new users start on; empty to start them on the free plan
+		ReminderDays     []int          // days before a trial ends that the user is reminded, e.g. 3 and 1
+		CheckInterval    time.Duration  // how often trials are checked for reminders and expiry
+	}
+	FeatureFlags map[string]bool // features switched on with FEATURE_FLAGS
+	Terminal struct {
+		IdleTimeout time.Duration // how long an admin terminal session may go without input
+		MaxDuration time.Duration // longest an admin terminal session may stay open
+	}
+	Files struct {
+		MaxSizeMB int // largest file that can be uploaded to or downloaded from an instance's files volume
+	}
+	Requests struct {
+		MaxBodyKB        int  // largest body of JSON API requests
+		WebhookMaxBodyKB int  // largest body of webhooks from Clerk, payment providers and instances
+		StrictJSON       bool // reject JSON request bodies with fields the endpoint does not know
 	}
 	Docker struct {
 		Host            string
 		Network         string
 		NetworkSubnet   string
+		NetworkMode     string   // shared: all instances on Network; per_user: each user's instances on a network of their own
+		PlatformContainers []string // containers that must reach every instance, e.g. the reverse proxy, connected to each user network
+		NetworkCheckInterval time.Duration // how often instances are moved to their user's network and user networks repaired
 		N8NContainerPort int
+		TLSVerify       bool
+		TLSCACert       string
+		TLSCert         string
+		TLSKey          string
+		ConnectTimeout  time.Duration
+	}
+	Registry struct {
+		Mirror      string                        // registry host Docker Hub images are pulled through, e.g. mirror.example.com
+		Credentials map[string]RegistryCredential // by registry host; docker.io for Docker Hub
+	}
+	Images struct {
+		PrePullInterval time.Duration // how often the images of the templates are pulled on each host
+		PrePull         []string      // more images kept pulled, e.g. n8n versions instances are upgraded to
+	}
+	Egress struct {
+		Restrict      bool          // deny instances access to private ranges and the platform's hosts
+		HelperImage   string        // image with sh and iptables that writes the rules into each instance's network namespace
+		BlockedHosts  []string      // CIDRs denied on every plan, on top of the Docker and database hosts
+		AllowedCIDRs  []string      // private CIDRs instances may still reach, e.g. a shared SMTP relay
+		CheckInterval time.Duration // how often restarted containers get their rules again
+	}
+	DNS struct {
+		Provider     string // adguard, cloudflare, route53 or none
+		PublicTarget string // public providers: IP address (A record) or hostname (CNAME) instance hostnames point at
+		TTL          int
+		AdGuard      struct {
+			Host     string
+			Username string
+			Password string
+			Protocol string
+			CLIPath  string
+		}
+		Cloudflare struct {
+			APIToken string
+			ZoneID   string
+		}
+		Route53 struct {
+			HostedZoneID    string
+			AccessKeyID     string
+			SecretAccessKey string
+		}
 	}
 	N8N struct {
 		BaseImage      string
@@ -45,19 +180,187 @@ type Config struct {
 		PortRangeStart int
 		PortRangeEnd   int
 		WebhookSecret  string
+		UpgradeHealthTimeout time.Duration
+		RollbackWindow time.Duration // how long after an upgrade the previous version can be restored
+		ExecutionRetention time.Duration // how long workflow executions reported by instances are kept
+		NamePolicy     string // reject or suffix duplicate instance names
+	}
+	Encryption struct {
+		Key               []byte        // AES-256 key sensitive columns are encrypted with at rest
+		PreviousKeys      [][]byte      // retired keys, which decrypt until their values are re-encrypted
+		ReencryptInterval time.Duration // how often values stored in plaintext or with a retired key are re-encrypted
+	}
+	Services struct {
+		Offered []string // service types new instances can be created with, from the templates in container/templates.go
 	}
 	CORS struct {
-		Origins []string
+		AllowAll bool            // "*" was listed: every origin may call the API, without credentials
+		Origins  []OriginPattern // origins browsers may call the API from, with credentials
+		Strict   bool            // reject requests from other origins instead of only leaving out the CORS headers
 	}
 	Monitoring struct {
-		Interval time.Duration
-		LogLevel string
+		Interval           time.Duration
+		LogLevel           string
+		WriteBatchSize     int           // resource usage samples buffered before they are inserted
+		WriteFlushInterval time.Duration // longest time samples stay buffered
+		Retention          time.Duration // how long raw resource usage samples are kept
+		CompressAfter      time.Duration // age at which raw samples are compressed
+		HourlyRetention    time.Duration // how long hourly resource usage aggregates are kept
+	}
+	Storage struct {
+		CheckInterval time.Duration
+		WarnThreshold float64
+	}
+	ObjectStorage struct {
+		Backend       string // local or s3
+		LocalPath     string // directory of the local store
+		S3Endpoint    string // base URL of the S3-compatible service, e.g. https://s3.eu-west-1.amazonaws.com
+		S3Region      string
+		S3Bucket      string
+		S3Prefix      string // key prefix of all objects in the bucket
+		S3AccessKeyID string
+		S3SecretKey   string
+		S3PathStyle   bool          // address the bucket in the path instead of the host name, as MinIO expects
+		URLExpiry     time.Duration // how long presigned download URLs work
+		SigningKey    []byte        // HMAC key signing download URLs of the local store
+	}
+	Badges struct {
+		SigningKey []byte        // signs the tokens of public status badge URLs
+		CacheTTL   time.Duration // how long a rendered badge is served before it is rendered again
+		RateLimit  int           // badge requests a client IP may make per minute
+	}
+	Backups struct {
+		CheckInterval    time.Duration // how often backup schedules are checked for due runs
+		DefaultRetention int           // backups a schedule keeps when none is given
+	}
+	Capacity struct {
+		MaxCPU            float64       // CPU cores instances may be given in total; 0 is unlimited
+		MaxMemoryMB       int           // memory instances may be given in total; 0 is unlimited
+		MaxInstances      int           // instances the host runs at most; 0 is unlimited
+		CPUOvercommit     float64       // default CPU overcommit ratio of new hosts; 0 does not limit allocation
+		MemoryOvercommit  float64       // default memory overcommit ratio of new hosts; 0 does not limit allocation
+		UtilizationAlert  float64       // default share of physical CPU or memory in use that raises an alert
+		HostCheckInterval time.Duration // how often host resources and utilization are checked
+	}
+	Agents struct {
+		TokenTTL    time.Duration // lifetime of host agent tokens; agents rotate them before they expire
+		TokenSecret []byte        // HMAC key signing host agent tokens
+	}
+	Leases struct {
+		ReplicaID   string        // identifies this replica as the holder of singleton loop leases
+		GracePeriod time.Duration // how long past its loop's interval a lease lasts before another replica takes over
+	}
+	Metrics struct {
+		Enabled bool   // serve Prometheus metrics on /metrics
+		Token   string // bearer token required to scrape /metrics; open when empty
+	}
+	GRPC struct {
+		Enabled     bool   // serve the internal container API over gRPC
+		Port        int
+		TLSCert     string // server certificate and key
+		TLSKey      string
+		TLSClientCA string // CA that signs the certificates of allowed clients
+		Insecure    bool   // serve without TLS or client certificates, for local development
+	}
+	Reports struct {
+		Interval      time.Duration // how often the operator report is generated
+		EmailTo       []string      // recipients of the operator report; empty skips email
+		WebhookURL    string        // receives the operator report as JSON; empty skips the webhook
+		WebhookSecret string
+	}
+	SMTP struct {
+		Host     string // empty disables email
+		Port     int
+		Username string
+		Password string
+		From     string
+	}
+	Waitlist struct {
+		AutoProvision     bool          // provision waiting requests once capacity frees up, instead of reserving it
+		ReservationPeriod time.Duration // how long reserved capacity is held for its user to claim
+		CheckInterval     time.Duration
+		WebhookURL        string // notified when a waiting request is provisioned or gets a reservation
+		WebhookSecret     string
+	}
+	Health struct {
+		CheckInterval    time.Duration
+		FailureThreshold int // consecutive failures before an instance is marked as error
+		AutoRestartAfter int // consecutive failures before the container is restarted; 0 disables
+		AutoRollbackAfter  int           // consecutive failures of a recently upgraded instance before it is rolled back; 0 disables
+		AutoRollbackWindow time.Duration // how long after an upgrade failures trigger an automatic rollback
+		Region           string            // name of the probe location this host reports as
+		RemoteProbes     map[string]string // region name to base URL of a remote probe agent
+		ProbeToken       string            // shared secret between this host and remote probe agents; empty disables the agent endpoint
+		ProbeRetention   time.Duration
+	}
+	Sleep struct {
+		Plans         map[string]time.Duration // plans whose idle instances are stopped, with how long they must be idle
+		CheckInterval time.Duration
+		TrafficBytes  int64 // inbound bytes within a monitoring interval that count as HTTP traffic
+	}
+	Provisioning struct {
+		Timeout       time.Duration // limit for provisioning a single instance
+		HealthTimeout time.Duration // how long a new instance's service may take to answer its health check
+	}
+	Webhooks struct {
+		DeliveryRetention time.Duration // how long delivery attempts to users' webhook endpoints are logged
 	}
+	Trash struct {
+		Retention    time.Duration // how long deleted instances keep their volumes and can be restored; 0 deletes immediately
+		ReapInterval time.Duration // how often instances past their retention are deleted for good
+	}
+	AccountDeletion struct {
+		GracePeriod   time.Duration // how long the data of deleted accounts is kept before it is purged
+		PurgeInterval time.Duration // how often accounts past their grace period are purged
+	}
+	Jobs struct {
+		Workers        int           // jobs run concurrently by this host
+		PollInterval   time.Duration // how often idle workers look for due jobs
+		Timeout        time.Duration // limit for a single attempt; longer-running jobs are considered abandoned
+		MaxAttempts    int
+		RetryBaseDelay time.Duration // delay before the first retry, doubled for each further attempt
+		RetryMaxDelay  time.Duration
+	}
+	CPUPinning struct {
+		NUMANodes  [][]int // host CPUs dedicated to pinned instances, grouped by NUMA node; empty disables pinning
+		SharedCPUs string  // cpuset of unpinned instances, e.g. "0-7"; empty leaves them unrestricted
+	}
+	Proxy struct {
+		Provider          string // caddy or traefik; empty disables certificate tracking
+		APIURL            string
+		CertCheckInterval time.Duration
+		CertRenewWindow   time.Duration
+		CertIssueTimeout  time.Duration
+		InternalToken     string // shared secret the proxy presents to internal endpoints such as the on-demand TLS check; empty disables them
+	}
+	SIEM struct {
+		Type              string // syslog, splunk or http; empty disables export
+		Endpoint          string
+		Token             string
+		Network           string
+		IncludeAccessLogs bool
+		BatchSize         int
+		BufferSize        int
+		FlushInterval     time.Duration
+		MaxRetries        int
+	}
+	Branding struct {
+		ProductName    string
+		SupportEmail   string
+		LogoURL        string
+		PrimaryColor   string
+		SecondaryColor string
+		AccentColor    string
+	}
+
+	problems []string        // validation problems, kept when FailFast is disabled
+	derived  map[string]bool // secrets derived from JWT_SECRET because they were not set
 }
 
 // NewConfig creates a new Config struct from environment variables
 func NewConfig() (*Config, error) {
-	config := &Config{}
+	config := &Config{derived: map[string]bool{}}
+	secrets := newSecretSource()
 
 	// Server configuration
 	port, err := strconv.Atoi(getEnv("PORT", "8080"))
@@ -66,40 +369,306 @@ func NewConfig() (*Config, error) {
 	}
 	config.Server.Port = port
 	config.Server.Environment = getEnv("APP_ENV", "development")
-	config.Server.JWTSecret = getEnv("JWT_SECRET", "")
-	if config.Server.JWTSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET is required")
+	production := config.Server.Environment == "production"
+	if secrets.err != nil {
+		return nil, secrets.err
+	}
+	config.Server.JWTSecret, err = secrets.require("JWT_SECRET")
+	if err != nil {
+		return nil, err
 	}
 	config.Server.BackendURL = getEnv("BACKEND_URL", "http://localhost:8080")
 	config.Server.FrontendURL = getEnv("FRONTEND_URL", "http://localhost:3000")
 	config.Server.Domain = getEnv("DOMAIN", "launchstack.io")
 
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT: %w", err)
+	}
+	config.Server.ShutdownTimeout = shutdownTimeout
+	config.Server.FailFast = getEnv("CONFIG_FAIL_FAST", "true") == "true"
+
 	// Database configuration
-	config.Database.URL = getEnv("DATABASE_URL", "")
-	if config.Database.URL == "" {
-		return nil, fmt.Errorf("DATABASE_URL is required")
+	config.Database.Driver = getEnv("DB_DRIVER", "postgres")
+	switch config.Database.Driver {
+	case "postgres":
+		config.Database.URL, err = secrets.require("DATABASE_URL")
+		if err != nil {
+			return nil, err
+		}
+	case "sqlite":
+		config.Database.SQLitePath = getEnv("DB_SQLITE_PATH", "launchstack.db")
+	default:
+		return nil, fmt.Errorf("invalid DB_DRIVER %q: must be postgres or sqlite", config.Database.Driver)
+	}
+	config.Database.Migrate = getEnv("DB_MIGRATE", "up")
+	if config.Database.Migrate != "up" && config.Database.Migrate != "verify" && config.Database.Migrate != "off" {
+		return nil, fmt.Errorf("invalid DB_MIGRATE %q: must be up, verify or off", config.Database.Migrate)
+	}
+	config.Database.Host = getEnv("DB_HOST", "localhost")
+	config.Database.Port = getEnv("DB_PORT", "5432")
+	config.Database.User = getEnv("DB_USER", "postgres")
+	config.Database.Password = secrets.get("DB_PASSWORD", "")
+	config.Database.Name = getEnv("DB_NAME", "launchstack")
+	maxOpenConns, err := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
+	if err != nil || maxOpenConns <= 0 {
+		return nil, fmt.Errorf("invalid DB_MAX_OPEN_CONNS: must be a positive integer")
+	}
+	config.Database.MaxOpenConns = maxOpenConns
+	maxIdleConns, err := strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "10"))
+	if err != nil || maxIdleConns < 0 || maxIdleConns > maxOpenConns {
+		return nil, fmt.Errorf("invalid DB_MAX_IDLE_CONNS: must be a non-negative integer no larger than DB_MAX_OPEN_CONNS")
+	}
+	config.Database.MaxIdleConns = maxIdleConns
+	connMaxLifetime, err := time.ParseDuration(getEnv("DB_CONN_MAX_LIFETIME", "30m"))
+	if err != nil || connMaxLifetime < 0 {
+		return nil, fmt.Errorf("invalid DB_CONN_MAX_LIFETIME: must be a non-negative duration")
+	}
+	config.Database.ConnMaxLifetime = connMaxLifetime
+	connMaxIdleTime, err := time.ParseDuration(getEnv("DB_CONN_MAX_IDLE_TIME", "5m"))
+	if err != nil || connMaxIdleTime < 0 {
+		return nil, fmt.Errorf("invalid DB_CONN_MAX_IDLE_TIME: must be a non-negative duration")
 	}
+	config.Database.ConnMaxIdleTime = connMaxIdleTime
+	connectTimeout, err := time.ParseDuration(getEnv("DB_CONNECT_TIMEOUT", "1m"))
+	if err != nil || connectTimeout < 0 {
+		return nil, fmt.Errorf("invalid DB_CONNECT_TIMEOUT: must be a non-negative duration")
+	}
+	config.Database.ConnectTimeout = connectTimeout
+	healthCheckInterval, err := time.ParseDuration(getEnv("DB_HEALTH_CHECK_INTERVAL", "30s"))
+	if err != nil || healthCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid DB_HEALTH_CHECK_INTERVAL: must be a positive duration")
+	}
+	config.Database.HealthCheckInterval = healthCheckInterval
 
 	// Clerk configuration
-	config.Clerk.SecretKey = getEnv("CLERK_SECRET_KEY", "")
-	if config.Clerk.SecretKey == "" {
-		return nil, fmt.Errorf("CLERK_SECRET_KEY is required")
+	config.Clerk.SecretKey, err = secrets.require("CLERK_SECRET_KEY")
+	if err != nil {
+		return nil, err
+	}
+	config.Clerk.WebhookSecret = secrets.get("CLERK_WEBHOOK_SECRET", "")
+	
+	webhookTimeout, err := time.ParseDuration(getEnv("CLERK_WEBHOOK_TIMEOUT", "5s"))
+	if err != nil || webhookTimeout <= 0 {
+		return nil, fmt.Errorf("invalid CLERK_WEBHOOK_TIMEOUT: must be a positive duration")
+	}
+	config.Clerk.WebhookTimeout = webhookTimeout
+	
+	// Event types whose handling is expensive, e.g. user deletion tearing down instances
+	config.Clerk.AsyncEvents = map[string]bool{}
+	for _, eventType := range strings.Split(getEnv("CLERK_WEBHOOK_ASYNC_EVENTS", "user.deleted"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			config.Clerk.AsyncEvents[eventType] = true
+		}
+	}
+
+	// Clerk retries a delivery for about a day, so applied events are kept well past that
+	eventRetention, err := time.ParseDuration(getEnv("CLERK_WEBHOOK_EVENT_RETENTION", "168h"))
+	if err != nil || eventRetention < 48*time.Hour {
+		return nil, fmt.Errorf("invalid CLERK_WEBHOOK_EVENT_RETENTION: must be a duration of at least 48h")
 	}
-	config.Clerk.WebhookSecret = getEnv("CLERK_WEBHOOK_SECRET", "")
+	config.Clerk.EventRetention = eventRetention
+
+	userCacheTTL, err := time.ParseDuration(getEnv("CLERK_USER_CACHE_TTL", "30s"))
+	if err != nil || userCacheTTL < 0 {
+		return nil, fmt.Errorf("invalid CLERK_USER_CACHE_TTL: must be a duration of 0 or more")
+	}
+	config.Clerk.UserCacheTTL = userCacheTTL
+
+	// Signed API key request configuration
+	signatureMaxSkew, err := time.ParseDuration(getEnv("API_KEY_SIGNATURE_MAX_SKEW", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_SIGNATURE_MAX_SKEW: %w", err)
+	}
+	config.APIKeys.SignatureMaxSkew = signatureMaxSkew
 	config.Clerk.PublishableKey = getEnv("NEXT_PUBLIC_CLERK_PUBLISHABLE_KEY", "")
 	config.Clerk.Issuer = getEnv("CLERK_ISSUER", "glad-starling-70.clerk.accounts.dev")
 
 	// PayPal configuration
 	disablePayments := getEnv("DISABLE_PAYMENTS", "false")
 	config.PayPal.DisablePayments = disablePayments == "true"
-	config.PayPal.APIKey = getEnv("PAYPAL_API_KEY", "")
-	config.PayPal.Secret = getEnv("PAYPAL_SECRET", "")
+	config.PayPal.APIKey = secrets.get("PAYPAL_API_KEY", "")
+	config.PayPal.Secret = secrets.get("PAYPAL_SECRET", "")
 	config.PayPal.Mode = getEnv("PAYPAL_MODE", "sandbox")
+	config.PayPal.StarterPlanID = getEnv("PAYPAL_PLAN_ID_STARTER", "")
+	config.PayPal.ProPlanID = getEnv("PAYPAL_PLAN_ID_PRO", "")
+
+	// Payment provider configuration
+	config.Payments.Provider = getEnv("PAYMENT_PROVIDER", "paypal")
+	config.Stripe.SecretKey = secrets.get("STRIPE_SECRET_KEY", "")
+	config.Stripe.WebhookSecret = secrets.get("STRIPE_WEBHOOK_SECRET", "")
+	config.Stripe.StarterPriceID = getEnv("STRIPE_PRICE_ID_STARTER", "")
+	config.Stripe.ProPriceID = getEnv("STRIPE_PRICE_ID_PRO", "")
+
+	// Usage-based billing export configuration
+	config.Billing.UsageWebhookURL = getEnv("BILLING_USAGE_WEBHOOK_URL", "")
+	config.Billing.UsageWebhookSecret = secrets.get("BILLING_USAGE_WEBHOOK_SECRET", "")
+	usageExportInterval, err := time.ParseDuration(getEnv("BILLING_USAGE_EXPORT_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BILLING_USAGE_EXPORT_INTERVAL: %w", err)
+	}
+	config.Billing.UsageExportInterval = usageExportInterval
+	enforcementInterval, err := time.ParseDuration(getEnv("BILLING_ENFORCEMENT_INTERVAL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BILLING_ENFORCEMENT_INTERVAL: %w", err)
+	}
+	config.Billing.EnforcementInterval = enforcementInterval
+	gracePeriod, err := time.ParseDuration(getEnv("BILLING_GRACE_PERIOD", "72h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BILLING_GRACE_PERIOD: %w", err)
+	}
+	config.Billing.GracePeriod = gracePeriod
+	journalCheckInterval, err := time.ParseDuration(getEnv("BILLING_JOURNAL_CHECK_INTERVAL", "6h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BILLING_JOURNAL_CHECK_INTERVAL: %w", err)
+	}
+	config.Billing.JournalCheckInterval = journalCheckInterval
+	subscriptionSyncInterval, err := time.ParseDuration(getEnv("SUBSCRIPTION_SYNC_INTERVAL", "6h"))
+	if err != nil || subscriptionSyncInterval < 0 {
+		return nil, fmt.Errorf("invalid SUBSCRIPTION_SYNC_INTERVAL: must be a duration of 0 or more")
+	}
+	config.Billing.SubscriptionSyncInterval = subscriptionSyncInterval
+	for name, price := range map[string]*float64{
+		"BILLING_PRICE_CPU_HOUR":       &config.Billing.UsagePrices.CPUHour,
+		"BILLING_PRICE_MEMORY_GB_HOUR": &config.Billing.UsagePrices.MemoryGBHour,
+		"BILLING_PRICE_NETWORK_OUT_GB": &config.Billing.UsagePrices.NetworkOutGB,
+	} {
+		*price, err = strconv.ParseFloat(getEnv(name, "0"), 64)
+		if err != nil || *price < 0 {
+			return nil, fmt.Errorf("invalid %s: must be a non-negative number of cents", name)
+		}
+	}
+	spendingCheckInterval, err := time.ParseDuration(getEnv("BILLING_SPENDING_CHECK_INTERVAL", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BILLING_SPENDING_CHECK_INTERVAL: %w", err)
+	}
+	config.Billing.SpendingCheckInterval = spendingCheckInterval
+
+	// Trials are listed as plan=days pairs, e.g. starter=7,pro=14; other plans have no trial
+	config.Trials.Plans = map[string]int{}
+	for _, entry := range strings.Split(getEnv("TRIAL_PLANS", "starter=7,pro=7"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		plan, value, ok := strings.Cut(entry, "=")
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || strings.TrimSpace(plan) == "" || err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid TRIAL_PLANS entry %q: expected plan=days", entry)
+		}
+		config.Trials.Plans[strings.TrimSpace(plan)] = days
+	}
+	maxExtensionDays, err := strconv.Atoi(getEnv("TRIAL_MAX_EXTENSION_DAYS", "30"))
+	if err != nil || maxExtensionDays <= 0 {
+		return nil, fmt.Errorf("invalid TRIAL_MAX_EXTENSION_DAYS: must be a positive integer")
+	}
+	config.Trials.MaxExtensionDays = maxExtensionDays
+	offerDays, err := strconv.Atoi(getEnv("TRIAL_OFFER_DAYS", "3"))
+	if err != nil || offerDays < 0 {
+		return nil, fmt.Errorf("invalid TRIAL_OFFER_DAYS: must be a non-negative integer")
+	}
+	config.Trials.OfferDays = offerDays
+	config.Trials.SignupPlan = strings.TrimSpace(getEnv("TRIAL_SIGNUP_PLAN", "starter"))
+	if _, ok := config.Trials.Plans[config.Trials.SignupPlan]; config.Trials.SignupPlan != "" && !ok {
+		return nil, fmt.Errorf("invalid TRIAL_SIGNUP_PLAN: %s has no trial in TRIAL_PLANS", config.Trials.SignupPlan)
+	}
+	for _, value := range strings.Split(getEnv("TRIAL_REMINDER_DAYS", "3,1"), ",") {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid TRIAL_REMINDER_DAYS entry %q: must be a positive integer", value)
+		}
+		config.Trials.ReminderDays = append(config.Trials.ReminderDays, days)
+	}
+	trialCheckInterval, err := time.ParseDuration(getEnv("TRIAL_CHECK_INTERVAL", "1h"))
+	if err != nil || trialCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid TRIAL_CHECK_INTERVAL: must be a positive duration")
+	}
+	config.Trials.CheckInterval = trialCheckInterval
+
+	// Plan catalog configuration
+	planRefreshInterval, err := time.ParseDuration(getEnv("PLAN_CATALOG_REFRESH_INTERVAL", "1m"))
+	if err != nil || planRefreshInterval <= 0 {
+		return nil, fmt.Errorf("invalid PLAN_CATALOG_REFRESH_INTERVAL: must be a positive duration")
+	}
+	config.Plans.RefreshInterval = planRefreshInterval
+
+	// Feature flags are a comma-separated list of the flags that are on
+	config.FeatureFlags = map[string]bool{}
+	for _, flag := range strings.Split(getEnv("FEATURE_FLAGS", ""), ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			config.FeatureFlags[flag] = true
+		}
+	}
+
+	// Admin terminal configuration
+	terminalIdleTimeout, err := time.ParseDuration(getEnv("ADMIN_TERMINAL_IDLE_TIMEOUT", "15m"))
+	if err != nil || terminalIdleTimeout <= 0 {
+		return nil, fmt.Errorf("invalid ADMIN_TERMINAL_IDLE_TIMEOUT: must be a positive duration")
+	}
+	config.Terminal.IdleTimeout = terminalIdleTimeout
+	terminalMaxDuration, err := time.ParseDuration(getEnv("ADMIN_TERMINAL_MAX_DURATION", "1h"))
+	if err != nil || terminalMaxDuration <= 0 {
+		return nil, fmt.Errorf("invalid ADMIN_TERMINAL_MAX_DURATION: must be a positive duration")
+	}
+	config.Terminal.MaxDuration = terminalMaxDuration
+
+	// Instance file browser configuration
+	filesMaxSize, err := strconv.Atoi(getEnv("INSTANCE_FILES_MAX_SIZE_MB", "100"))
+	if err != nil || filesMaxSize <= 0 {
+		return nil, fmt.Errorf("invalid INSTANCE_FILES_MAX_SIZE_MB: must be a positive integer")
+	}
+	config.Files.MaxSizeMB = filesMaxSize
+
+	// Request body limits; uploads are limited by INSTANCE_FILES_MAX_SIZE_MB instead
+	maxBody, err := strconv.Atoi(getEnv("REQUEST_MAX_BODY_KB", "256"))
+	if err != nil || maxBody <= 0 {
+		return nil, fmt.Errorf("invalid REQUEST_MAX_BODY_KB: must be a positive integer")
+	}
+	config.Requests.MaxBodyKB = maxBody
+	webhookMaxBody, err := strconv.Atoi(getEnv("REQUEST_WEBHOOK_MAX_BODY_KB", "1024"))
+	if err != nil || webhookMaxBody <= 0 {
+		return nil, fmt.Errorf("invalid REQUEST_WEBHOOK_MAX_BODY_KB: must be a positive integer")
+	}
+	config.Requests.WebhookMaxBodyKB = webhookMaxBody
+	config.Requests.StrictJSON = getEnv("REQUEST_STRICT_JSON", "true") == "true"
 
 	// Docker configuration
-	config.Docker.Host = getEnv("DOCKER_HOST", "http://10.1.1.81:2375")
+	config.Docker.Host = getEnv("DOCKER_HOST", "unix:///var/run/docker.sock")
 	config.Docker.Network = getEnv("DOCKER_NETWORK", "n8n")
 	config.Docker.NetworkSubnet = getEnv("DOCKER_NETWORK_SUBNET", "10.1.2.0/24")
+	config.Docker.NetworkMode = getEnv("DOCKER_NETWORK_MODE", "shared")
+	if config.Docker.NetworkMode != "shared" && config.Docker.NetworkMode != "per_user" {
+		return nil, fmt.Errorf("invalid DOCKER_NETWORK_MODE: must be shared or per_user")
+	}
+	for _, name := range strings.Split(getEnv("DOCKER_PLATFORM_CONTAINERS", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			config.Docker.PlatformContainers = append(config.Docker.PlatformContainers, name)
+		}
+	}
+	networkCheckInterval, err := time.ParseDuration(getEnv("DOCKER_NETWORK_CHECK_INTERVAL", "10m"))
+	if err != nil || networkCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid DOCKER_NETWORK_CHECK_INTERVAL: must be a positive duration")
+	}
+	config.Docker.NetworkCheckInterval = networkCheckInterval
+	
+	// TLS for tcp endpoints, following the Docker CLI's DOCKER_TLS_VERIFY/DOCKER_CERT_PATH conventions
+	config.Docker.TLSVerify = getEnv("DOCKER_TLS_VERIFY", "") != ""
+	certPath := getEnv("DOCKER_CERT_PATH", "")
+	config.Docker.TLSCACert = getEnv("DOCKER_TLS_CA_CERT", joinCertPath(certPath, "ca.pem"))
+	config.Docker.TLSCert = getEnv("DOCKER_TLS_CERT", joinCertPath(certPath, "cert.pem"))
+	config.Docker.TLSKey = getEnv("DOCKER_TLS_KEY", joinCertPath(certPath, "key.pem"))
+	if config.Docker.TLSVerify && (config.Docker.TLSCACert == "" || config.Docker.TLSCert == "" || config.Docker.TLSKey == "") {
+		return nil, fmt.Errorf("DOCKER_TLS_VERIFY requires DOCKER_CERT_PATH or DOCKER_TLS_CA_CERT, DOCKER_TLS_CERT and DOCKER_TLS_KEY")
+	}
+	
+	dockerTimeout, err := time.ParseDuration(getEnv("DOCKER_CONNECT_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_CONNECT_TIMEOUT: %w", err)
+	}
+	config.Docker.ConnectTimeout = dockerTimeout
 	
 	n8nContainerPort, err := strconv.Atoi(getEnv("N8N_CONTAINER_PORT", "5678"))
 	if err != nil {
@@ -107,10 +676,88 @@ func NewConfig() (*Config, error) {
 	}
 	config.Docker.N8NContainerPort = n8nContainerPort
 
+	// Image registries: a pull-through mirror for Docker Hub and credentials for private registries
+	config.Registry.Mirror = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(getEnv("REGISTRY_MIRROR", ""), "https://"), "http://"), "/")
+	if strings.Contains(config.Registry.Mirror, "/") {
+		return nil, fmt.Errorf("invalid REGISTRY_MIRROR: must be a registry host, e.g. mirror.example.com")
+	}
+	registryCredentials, err := parseRegistryCredentials(secrets.get("REGISTRY_CREDENTIALS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REGISTRY_CREDENTIALS: %w", err)
+	}
+	config.Registry.Credentials = registryCredentials
+
+	// Images kept pulled on every host so instances are created without waiting for a pull
+	prePullInterval, err := time.ParseDuration(getEnv("IMAGE_PREPULL_INTERVAL", "6h"))
+	if err != nil || prePullInterval <= 0 {
+		return nil, fmt.Errorf("invalid IMAGE_PREPULL_INTERVAL: must be a positive duration")
+	}
+	config.Images.PrePullInterval = prePullInterval
+	for _, image := range strings.Split(getEnv("IMAGE_PREPULL_IMAGES", ""), ",") {
+		if image = strings.TrimSpace(image); image != "" {
+			config.Images.PrePull = append(config.Images.PrePull, image)
+		}
+	}
+
+	// Egress rules of instance containers, so workflows cannot reach the Docker API, the database
+	// or anything else on the private network
+	config.Egress.Restrict = getEnv("EGRESS_RESTRICT", "false") == "true"
+	config.Egress.HelperImage = getEnv("EGRESS_HELPER_IMAGE", "nicolaka/netshoot:v0.13")
+	if config.Egress.BlockedHosts, err = parseCIDRs(getEnv("EGRESS_BLOCKED_HOSTS", "")); err != nil {
+		return nil, fmt.Errorf("invalid EGRESS_BLOCKED_HOSTS: %w", err)
+	}
+	if config.Egress.AllowedCIDRs, err = parseCIDRs(getEnv("EGRESS_ALLOWED_CIDRS", "")); err != nil {
+		return nil, fmt.Errorf("invalid EGRESS_ALLOWED_CIDRS: %w", err)
+	}
+	egressCheckInterval, err := time.ParseDuration(getEnv("EGRESS_CHECK_INTERVAL", "1m"))
+	if err != nil || egressCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid EGRESS_CHECK_INTERVAL: must be a positive duration")
+	}
+	config.Egress.CheckInterval = egressCheckInterval
+
+	// DNS records of instances: LAN rewrites in AdGuard Home, or public records in Cloudflare or Route53
+	config.DNS.Provider = getEnv("DNS_PROVIDER", "adguard")
+	config.DNS.PublicTarget = getEnv("DNS_PUBLIC_TARGET", "")
+	dnsTTL, err := strconv.Atoi(getEnv("DNS_TTL", "300"))
+	if err != nil || dnsTTL < 1 {
+		return nil, fmt.Errorf("invalid DNS_TTL: must be a positive number of seconds")
+	}
+	config.DNS.TTL = dnsTTL
+	config.DNS.AdGuard.Host = getEnv("ADGUARD_HOST", "")
+	config.DNS.AdGuard.Username = secrets.get("ADGUARD_USERNAME", "")
+	config.DNS.AdGuard.Password = secrets.get("ADGUARD_PASSWORD", "")
+	config.DNS.AdGuard.Protocol = getEnv("ADGUARD_PROTOCOL", "https")
+	config.DNS.AdGuard.CLIPath = getEnv("DNS_CLI_PATH", "./dns-cli")
+	config.DNS.Cloudflare.APIToken = secrets.get("CLOUDFLARE_API_TOKEN", "")
+	config.DNS.Cloudflare.ZoneID = getEnv("CLOUDFLARE_ZONE_ID", "")
+	config.DNS.Route53.HostedZoneID = getEnv("ROUTE53_HOSTED_ZONE_ID", "")
+	config.DNS.Route53.AccessKeyID = getEnv("AWS_ACCESS_KEY_ID", "")
+	config.DNS.Route53.SecretAccessKey = secrets.get("AWS_SECRET_ACCESS_KEY", "")
+	switch config.DNS.Provider {
+	case "adguard", "none":
+	case "cloudflare":
+		if config.DNS.Cloudflare.APIToken == "" || config.DNS.Cloudflare.ZoneID == "" {
+			return nil, fmt.Errorf("DNS_PROVIDER=cloudflare requires CLOUDFLARE_API_TOKEN and CLOUDFLARE_ZONE_ID")
+		}
+	case "route53":
+		if config.DNS.Route53.HostedZoneID == "" || config.DNS.Route53.AccessKeyID == "" || config.DNS.Route53.SecretAccessKey == "" {
+			return nil, fmt.Errorf("DNS_PROVIDER=route53 requires ROUTE53_HOSTED_ZONE_ID, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+	default:
+		return nil, fmt.Errorf("invalid DNS_PROVIDER %q: must be adguard, cloudflare, route53 or none", config.DNS.Provider)
+	}
+	if (config.DNS.Provider == "cloudflare" || config.DNS.Provider == "route53") && config.DNS.PublicTarget == "" {
+		return nil, fmt.Errorf("DNS_PROVIDER=%s requires DNS_PUBLIC_TARGET, the address instance hostnames point at", config.DNS.Provider)
+	}
+
 	// N8N configuration
 	config.N8N.BaseImage = getEnv("N8N_BASE_IMAGE", "n8nio/n8n:latest")
 	config.N8N.DataDir = getEnv("N8N_DATA_DIR", "/opt/n8n/data")
-	config.N8N.WebhookSecret = getEnv("N8N_WEBHOOK_SECRET", "n8n_webhook_" + config.Server.JWTSecret[:8])
+	config.N8N.WebhookSecret = secrets.get("N8N_WEBHOOK_SECRET", "")
+	if config.N8N.WebhookSecret == "" {
+		config.N8N.WebhookSecret = "n8n_webhook_" + truncate(config.Server.JWTSecret, 8)
+		config.derived["N8N_WEBHOOK_SECRET"] = true
+	}
 	portStart, err := strconv.Atoi(getEnv("N8N_PORT_RANGE_START", "5000"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid N8N_PORT_RANGE_START: %w", err)
@@ -122,14 +769,94 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid N8N_PORT_RANGE_END: %w", err)
 	}
 	config.N8N.PortRangeEnd = portEnd
+	
+	upgradeHealthTimeout, err := time.ParseDuration(getEnv("N8N_UPGRADE_HEALTH_TIMEOUT", "3m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid N8N_UPGRADE_HEALTH_TIMEOUT: %w", err)
+	}
+	config.N8N.UpgradeHealthTimeout = upgradeHealthTimeout
+	
+	rollbackWindow, err := time.ParseDuration(getEnv("N8N_ROLLBACK_WINDOW", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid N8N_ROLLBACK_WINDOW: %w", err)
+	}
+	config.N8N.RollbackWindow = rollbackWindow
+	executionRetention, err := time.ParseDuration(getEnv("WORKFLOW_EXECUTION_RETENTION", "720h"))
+	if err != nil || executionRetention <= 0 {
+		return nil, fmt.Errorf("invalid WORKFLOW_EXECUTION_RETENTION: must be a positive duration")
+	}
+	config.N8N.ExecutionRetention = executionRetention
+
+	config.N8N.NamePolicy = getEnv("INSTANCE_NAME_POLICY", "reject")
+	if config.N8N.NamePolicy != "reject" && config.N8N.NamePolicy != "suffix" {
+		return nil, fmt.Errorf("invalid INSTANCE_NAME_POLICY: must be reject or suffix")
+	}
+
+	// Encryption at rest. Stored secrets become unreadable if the key is lost, so it should be
+	// set explicitly. N8N_CREDENTIALS_KEY, or the key derived from the JWT secret, encrypted
+	// instance credentials before there was ENCRYPTION_KEY, and is kept to decrypt them.
+	credentialsKey := sha256.Sum256([]byte("n8n_credentials_" + config.Server.JWTSecret))
+	legacyKey := credentialsKey[:]
+	config.derived["ENCRYPTION_KEY"] = true
+	if value := secrets.get("N8N_CREDENTIALS_KEY", ""); value != "" {
+		config.derived["ENCRYPTION_KEY"] = false
+		if legacyKey, err = parseEncryptionKey(value); err != nil {
+			return nil, fmt.Errorf("invalid N8N_CREDENTIALS_KEY: %w", err)
+		}
+	}
+	config.Encryption.Key = legacyKey
+	if value := secrets.get("ENCRYPTION_KEY", ""); value != "" {
+		config.derived["ENCRYPTION_KEY"] = false
+		if config.Encryption.Key, err = parseEncryptionKey(value); err != nil {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEY: %w", err)
+		}
+	}
+	for _, value := range strings.Split(secrets.get("ENCRYPTION_PREVIOUS_KEYS", ""), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		key, err := parseEncryptionKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENCRYPTION_PREVIOUS_KEYS: %w", err)
+		}
+		config.Encryption.PreviousKeys = append(config.Encryption.PreviousKeys, key)
+	}
+	config.Encryption.PreviousKeys = append(config.Encryption.PreviousKeys, legacyKey)
+	reencryptInterval, err := time.ParseDuration(getEnv("ENCRYPTION_REENCRYPT_INTERVAL", "1h"))
+	if err != nil || reencryptInterval <= 0 {
+		return nil, fmt.Errorf("invalid ENCRYPTION_REENCRYPT_INTERVAL: must be a positive duration")
+	}
+	config.Encryption.ReencryptInterval = reencryptInterval
+
+	// Services offered for new instances; instances of services no longer offered keep running
+	for _, service := range strings.Split(getEnv("SERVICE_TYPES", "n8n,uptime-kuma,nocodb"), ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			config.Services.Offered = append(config.Services.Offered, service)
+		}
+	}
 
-	// CORS configuration
-	corsOrigins := getEnv("CORS_ORIGINS", "*")
-	if corsOrigins == "*" {
-		config.CORS.Origins = []string{"*"}
+	// Host agents authenticate with short-lived tokens signed by this key
+	agentTokenTTL, err := time.ParseDuration(getEnv("AGENT_TOKEN_TTL", "1h"))
+	if err != nil || agentTokenTTL < time.Minute {
+		return nil, fmt.Errorf("invalid AGENT_TOKEN_TTL: must be at least 1m")
+	}
+	config.Agents.TokenTTL = agentTokenTTL
+	if agentSecret := secrets.get("AGENT_TOKEN_SECRET", ""); agentSecret != "" {
+		if len(agentSecret) < 32 {
+			return nil, fmt.Errorf("invalid AGENT_TOKEN_SECRET: must be at least 32 characters")
+		}
+		config.Agents.TokenSecret = []byte(agentSecret)
 	} else {
-		config.CORS.Origins = strings.Split(corsOrigins, ",")
+		key := sha256.Sum256([]byte("agent_tokens_" + config.Server.JWTSecret))
+		config.Agents.TokenSecret = key[:]
+	}
+
+	// CORS configuration; browsers may only call the API from the frontend by default
+	config.CORS.AllowAll, config.CORS.Origins, err = ParseCORSOrigins(getEnv("CORS_ORIGINS", config.Server.FrontendURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_ORIGINS: %w", err)
 	}
+	config.CORS.Strict = getEnv("CORS_STRICT", strconv.FormatBool(production)) == "true"
 
 	// Monitoring configuration
 	monitorInterval, err := time.ParseDuration(getEnv("RESOURCE_MONITOR_INTERVAL", "30s"))
@@ -139,9 +866,593 @@ func NewConfig() (*Config, error) {
 	config.Monitoring.Interval = monitorInterval
 	config.Monitoring.LogLevel = getEnv("LOG_LEVEL", "info")
 
+	// Resource usage samples are written in batches and downsampled by TimescaleDB
+	writeBatchSize, err := strconv.Atoi(getEnv("RESOURCE_USAGE_BATCH_SIZE", "500"))
+	if err != nil || writeBatchSize < 1 {
+		return nil, fmt.Errorf("invalid RESOURCE_USAGE_BATCH_SIZE: must be a positive integer")
+	}
+	config.Monitoring.WriteBatchSize = writeBatchSize
+	writeFlushInterval, err := time.ParseDuration(getEnv("RESOURCE_USAGE_FLUSH_INTERVAL", "10s"))
+	if err != nil || writeFlushInterval <= 0 {
+		return nil, fmt.Errorf("invalid RESOURCE_USAGE_FLUSH_INTERVAL: must be a positive duration")
+	}
+	config.Monitoring.WriteFlushInterval = writeFlushInterval
+	usageRetention, err := time.ParseDuration(getEnv("RESOURCE_USAGE_RETENTION", "720h"))
+	if err != nil || usageRetention < time.Hour {
+		return nil, fmt.Errorf("invalid RESOURCE_USAGE_RETENTION: must be at least 1h")
+	}
+	config.Monitoring.Retention = usageRetention
+	compressAfter, err := time.ParseDuration(getEnv("RESOURCE_USAGE_COMPRESS_AFTER", "24h"))
+	if err != nil || compressAfter < time.Hour || compressAfter >= usageRetention {
+		return nil, fmt.Errorf("invalid RESOURCE_USAGE_COMPRESS_AFTER: must be at least 1h and shorter than RESOURCE_USAGE_RETENTION")
+	}
+	config.Monitoring.CompressAfter = compressAfter
+	hourlyRetention, err := time.ParseDuration(getEnv("RESOURCE_USAGE_HOURLY_RETENTION", "8760h"))
+	if err != nil || hourlyRetention < usageRetention {
+		return nil, fmt.Errorf("invalid RESOURCE_USAGE_HOURLY_RETENTION: must be at least RESOURCE_USAGE_RETENTION")
+	}
+	config.Monitoring.HourlyRetention = hourlyRetention
+
+	// Storage quota configuration
+	storageInterval, err := time.ParseDuration(getEnv("STORAGE_CHECK_INTERVAL", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORAGE_CHECK_INTERVAL: %w", err)
+	}
+	config.Storage.CheckInterval = storageInterval
+
+	warnThreshold, err := strconv.ParseFloat(getEnv("STORAGE_WARN_THRESHOLD", "0.9"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORAGE_WARN_THRESHOLD: %w", err)
+	}
+	config.Storage.WarnThreshold = warnThreshold
+
+	// Object storage of backups and workflow exports
+	config.ObjectStorage.Backend = getEnv("OBJECT_STORAGE_BACKEND", "local")
+	config.ObjectStorage.LocalPath = getEnv("OBJECT_STORAGE_LOCAL_PATH", "/opt/n8n/storage")
+	config.ObjectStorage.S3Endpoint = strings.TrimRight(getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"), "/")
+	config.ObjectStorage.S3Region = getEnv("S3_REGION", "us-east-1")
+	config.ObjectStorage.S3Bucket = getEnv("S3_BUCKET", "")
+	config.ObjectStorage.S3Prefix = strings.Trim(getEnv("S3_PREFIX", ""), "/")
+	config.ObjectStorage.S3AccessKeyID = getEnv("S3_ACCESS_KEY_ID", "")
+	config.ObjectStorage.S3SecretKey = secrets.get("S3_SECRET_ACCESS_KEY", "")
+	config.ObjectStorage.S3PathStyle = getEnv("S3_PATH_STYLE", "false") == "true"
+	switch config.ObjectStorage.Backend {
+	case "local":
+	case "s3":
+		if config.ObjectStorage.S3Bucket == "" || config.ObjectStorage.S3AccessKeyID == "" || config.ObjectStorage.S3SecretKey == "" {
+			return nil, fmt.Errorf("invalid OBJECT_STORAGE_BACKEND: s3 needs S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+		}
+	default:
+		return nil, fmt.Errorf("invalid OBJECT_STORAGE_BACKEND: must be local or s3")
+	}
+
+	urlExpiry, err := time.ParseDuration(getEnv("OBJECT_STORAGE_URL_EXPIRY", "15m"))
+	if err != nil || urlExpiry <= 0 || urlExpiry > 7*24*time.Hour {
+		return nil, fmt.Errorf("invalid OBJECT_STORAGE_URL_EXPIRY: must be a positive duration of at most 168h")
+	}
+	config.ObjectStorage.URLExpiry = urlExpiry
+
+	if signingKey := secrets.get("OBJECT_STORAGE_SIGNING_KEY", ""); signingKey != "" {
+		if len(signingKey) < 32 {
+			return nil, fmt.Errorf("invalid OBJECT_STORAGE_SIGNING_KEY: must be at least 32 characters")
+		}
+		config.ObjectStorage.SigningKey = []byte(signingKey)
+	} else {
+		key := sha256.Sum256([]byte("object_storage_" + config.Server.JWTSecret))
+		config.ObjectStorage.SigningKey = key[:]
+	}
+
+	// Public status badges are embedded with signed URLs, so rotating the key invalidates them
+	if badgeKey := secrets.get("BADGE_SIGNING_KEY", ""); badgeKey != "" {
+		if len(badgeKey) < 32 {
+			return nil, fmt.Errorf("invalid BADGE_SIGNING_KEY: must be at least 32 characters")
+		}
+		config.Badges.SigningKey = []byte(badgeKey)
+	} else {
+		key := sha256.Sum256([]byte("status_badges_" + config.Server.JWTSecret))
+		config.Badges.SigningKey = key[:]
+	}
+	badgeCacheTTL, err := time.ParseDuration(getEnv("BADGE_CACHE_TTL", "5m"))
+	if err != nil || badgeCacheTTL <= 0 {
+		return nil, fmt.Errorf("invalid BADGE_CACHE_TTL: must be a positive duration")
+	}
+	config.Badges.CacheTTL = badgeCacheTTL
+	badgeRateLimit, err := strconv.Atoi(getEnv("BADGE_RATE_LIMIT", "60"))
+	if err != nil || badgeRateLimit <= 0 {
+		return nil, fmt.Errorf("invalid BADGE_RATE_LIMIT: must be a positive integer")
+	}
+	config.Badges.RateLimit = badgeRateLimit
+
+	// Instance backup configuration
+	backupInterval, err := time.ParseDuration(getEnv("BACKUP_CHECK_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BACKUP_CHECK_INTERVAL: %w", err)
+	}
+	config.Backups.CheckInterval = backupInterval
+
+	backupRetention, err := strconv.Atoi(getEnv("BACKUP_DEFAULT_RETENTION", "7"))
+	if err != nil || backupRetention < 1 {
+		return nil, fmt.Errorf("invalid BACKUP_DEFAULT_RETENTION: must be a positive integer")
+	}
+	config.Backups.DefaultRetention = backupRetention
+
+	// Host capacity available to instances
+	capacityCPU, err := strconv.ParseFloat(getEnv("CAPACITY_MAX_CPU", "0"), 64)
+	if err != nil || capacityCPU < 0 {
+		return nil, fmt.Errorf("invalid CAPACITY_MAX_CPU: must be a non-negative number")
+	}
+	config.Capacity.MaxCPU = capacityCPU
+	capacityMemory, err := strconv.Atoi(getEnv("CAPACITY_MAX_MEMORY_MB", "0"))
+	if err != nil || capacityMemory < 0 {
+		return nil, fmt.Errorf("invalid CAPACITY_MAX_MEMORY_MB: must be a non-negative number")
+	}
+	config.Capacity.MaxMemoryMB = capacityMemory
+	capacityInstances, err := strconv.Atoi(getEnv("CAPACITY_MAX_INSTANCES", "0"))
+	if err != nil || capacityInstances < 0 {
+		return nil, fmt.Errorf("invalid CAPACITY_MAX_INSTANCES: must be a non-negative number")
+	}
+	config.Capacity.MaxInstances = capacityInstances
+	cpuOvercommit, err := strconv.ParseFloat(getEnv("CAPACITY_CPU_OVERCOMMIT", "0"), 64)
+	if err != nil || cpuOvercommit < 0 {
+		return nil, fmt.Errorf("invalid CAPACITY_CPU_OVERCOMMIT: must be a non-negative number")
+	}
+	config.Capacity.CPUOvercommit = cpuOvercommit
+	memoryOvercommit, err := strconv.ParseFloat(getEnv("CAPACITY_MEMORY_OVERCOMMIT", "0"), 64)
+	if err != nil || memoryOvercommit < 0 {
+		return nil, fmt.Errorf("invalid CAPACITY_MEMORY_OVERCOMMIT: must be a non-negative number")
+	}
+	config.Capacity.MemoryOvercommit = memoryOvercommit
+	utilizationAlert, err := strconv.ParseFloat(getEnv("CAPACITY_UTILIZATION_ALERT", "0.9"), 64)
+	if err != nil || utilizationAlert <= 0 || utilizationAlert > 1 {
+		return nil, fmt.Errorf("invalid CAPACITY_UTILIZATION_ALERT: must be a number above 0 and at most 1")
+	}
+	config.Capacity.UtilizationAlert = utilizationAlert
+	hostCheckInterval, err := time.ParseDuration(getEnv("CAPACITY_HOST_CHECK_INTERVAL", "1m"))
+	if err != nil || hostCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid CAPACITY_HOST_CHECK_INTERVAL: must be a positive duration")
+	}
+	config.Capacity.HostCheckInterval = hostCheckInterval
+
+	// Singleton background loops run on the replica holding their lease
+	replicaID := getEnv("REPLICA_ID", "")
+	if replicaID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+		replicaID = fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	}
+	config.Leases.ReplicaID = replicaID
+	leaseGrace, err := time.ParseDuration(getEnv("LEASE_GRACE_PERIOD", "1m"))
+	if err != nil || leaseGrace <= 0 {
+		return nil, fmt.Errorf("invalid LEASE_GRACE_PERIOD: must be a positive duration")
+	}
+	config.Leases.GracePeriod = leaseGrace
+
+	// Prometheus metrics
+	config.Metrics.Enabled = getEnv("METRICS_ENABLED", "true") == "true"
+	config.Metrics.Token = secrets.get("METRICS_TOKEN", "")
+
+	// Internal gRPC API for container operations, authenticated with client certificates
+	config.GRPC.Enabled = getEnv("GRPC_ENABLED", "false") == "true"
+	grpcPort, err := strconv.Atoi(getEnv("GRPC_PORT", "9090"))
+	if err != nil || grpcPort < 1 || grpcPort > 65535 {
+		return nil, fmt.Errorf("invalid GRPC_PORT: must be a port number")
+	}
+	config.GRPC.Port = grpcPort
+	config.GRPC.TLSCert = getEnv("GRPC_TLS_CERT", "")
+	config.GRPC.TLSKey = getEnv("GRPC_TLS_KEY", "")
+	config.GRPC.TLSClientCA = getEnv("GRPC_TLS_CLIENT_CA", "")
+	config.GRPC.Insecure = getEnv("GRPC_INSECURE", "false") == "true"
+	if config.GRPC.Enabled {
+		if !config.GRPC.Insecure && (config.GRPC.TLSCert == "" || config.GRPC.TLSKey == "" || config.GRPC.TLSClientCA == "") {
+			return nil, fmt.Errorf("GRPC_ENABLED requires GRPC_TLS_CERT, GRPC_TLS_KEY and GRPC_TLS_CLIENT_CA, or GRPC_INSECURE=true outside production")
+		}
+	}
+
+	// Operator report of database growth, slow endpoints, errors and pending DNS cleanups
+	reportInterval, err := time.ParseDuration(getEnv("OPS_REPORT_INTERVAL", "168h"))
+	if err != nil || reportInterval < time.Hour {
+		return nil, fmt.Errorf("invalid OPS_REPORT_INTERVAL: must be at least 1h")
+	}
+	config.Reports.Interval = reportInterval
+	for _, recipient := range strings.Split(getEnv("OPS_REPORT_EMAIL_TO", ""), ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			config.Reports.EmailTo = append(config.Reports.EmailTo, recipient)
+		}
+	}
+	config.Reports.WebhookURL = getEnv("OPS_REPORT_WEBHOOK_URL", "")
+	config.Reports.WebhookSecret = secrets.get("OPS_REPORT_WEBHOOK_SECRET", "")
+
+	// Outgoing email
+	config.SMTP.Host = getEnv("SMTP_HOST", "")
+	smtpPort, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	if err != nil || smtpPort < 1 || smtpPort > 65535 {
+		return nil, fmt.Errorf("invalid SMTP_PORT: must be a port number")
+	}
+	config.SMTP.Port = smtpPort
+	config.SMTP.Username = getEnv("SMTP_USERNAME", "")
+	config.SMTP.Password = secrets.get("SMTP_PASSWORD", "")
+	config.SMTP.From = getEnv("SMTP_FROM", "")
+	if len(config.Reports.EmailTo) > 0 && (config.SMTP.Host == "" || config.SMTP.From == "") {
+		return nil, fmt.Errorf("OPS_REPORT_EMAIL_TO requires SMTP_HOST and SMTP_FROM")
+	}
+
+	// Requests that found the host at capacity wait for it in line
+	config.Waitlist.AutoProvision = getEnv("WAITLIST_AUTO_PROVISION", "true") == "true"
+	reservationPeriod, err := time.ParseDuration(getEnv("WAITLIST_RESERVATION_PERIOD", "24h"))
+	if err != nil || reservationPeriod <= 0 {
+		return nil, fmt.Errorf("invalid WAITLIST_RESERVATION_PERIOD: must be a positive duration")
+	}
+	config.Waitlist.ReservationPeriod = reservationPeriod
+	waitlistInterval, err := time.ParseDuration(getEnv("WAITLIST_CHECK_INTERVAL", "30s"))
+	if err != nil || waitlistInterval <= 0 {
+		return nil, fmt.Errorf("invalid WAITLIST_CHECK_INTERVAL: must be a positive duration")
+	}
+	config.Waitlist.CheckInterval = waitlistInterval
+	config.Waitlist.WebhookURL = getEnv("WAITLIST_WEBHOOK_URL", "")
+	config.Waitlist.WebhookSecret = secrets.get("WAITLIST_WEBHOOK_SECRET", "")
+
+	// Instance health probing configuration
+	healthInterval, err := time.ParseDuration(getEnv("HEALTH_CHECK_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEALTH_CHECK_INTERVAL: %w", err)
+	}
+	config.Health.CheckInterval = healthInterval
+	
+	failureThreshold, err := strconv.Atoi(getEnv("HEALTH_FAILURE_THRESHOLD", "3"))
+	if err != nil || failureThreshold < 1 {
+		return nil, fmt.Errorf("invalid HEALTH_FAILURE_THRESHOLD: must be a positive integer")
+	}
+	config.Health.FailureThreshold = failureThreshold
+	
+	autoRestartAfter, err := strconv.Atoi(getEnv("HEALTH_AUTO_RESTART_AFTER", "5"))
+	if err != nil || autoRestartAfter < 0 {
+		return nil, fmt.Errorf("invalid HEALTH_AUTO_RESTART_AFTER: must be zero or a positive integer")
+	}
+	config.Health.AutoRestartAfter = autoRestartAfter
+	
+	autoRollbackAfter, err := strconv.Atoi(getEnv("HEALTH_AUTO_ROLLBACK_AFTER", "3"))
+	if err != nil || autoRollbackAfter < 0 {
+		return nil, fmt.Errorf("invalid HEALTH_AUTO_ROLLBACK_AFTER: must be zero or a positive integer")
+	}
+	config.Health.AutoRollbackAfter = autoRollbackAfter
+	
+	autoRollbackWindow, err := time.ParseDuration(getEnv("HEALTH_AUTO_ROLLBACK_WINDOW", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEALTH_AUTO_ROLLBACK_WINDOW: %w", err)
+	}
+	config.Health.AutoRollbackWindow = autoRollbackWindow
+	config.Health.Region = getEnv("HEALTH_PROBE_REGION", "local")
+	config.Health.ProbeToken = secrets.get("HEALTH_PROBE_TOKEN", "")
+	
+	// Remote probes are listed as region=url pairs, e.g. eu-west=https://probe-eu.example.com
+	config.Health.RemoteProbes = map[string]string{}
+	for _, entry := range strings.Split(getEnv("HEALTH_REMOTE_PROBES", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid HEALTH_REMOTE_PROBES entry %q: expected region=url", entry)
+		}
+		config.Health.RemoteProbes[strings.TrimSpace(parts[0])] = strings.TrimRight(strings.TrimSpace(parts[1]), "/")
+	}
+	
+	probeRetention, err := time.ParseDuration(getEnv("HEALTH_PROBE_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEALTH_PROBE_RETENTION: %w", err)
+	}
+	config.Health.ProbeRetention = probeRetention
+	
+	// Idle instances of the sleeping plans are listed as plan=duration pairs, e.g. free=6h
+	config.Sleep.Plans = map[string]time.Duration{}
+	for _, entry := range strings.Split(getEnv("INSTANCE_SLEEP_PLANS", "free=6h"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		plan, value, ok := strings.Cut(entry, "=")
+		idle, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || strings.TrimSpace(plan) == "" || err != nil || idle <= 0 {
+			return nil, fmt.Errorf("invalid INSTANCE_SLEEP_PLANS entry %q: expected plan=duration", entry)
+		}
+		config.Sleep.Plans[strings.TrimSpace(plan)] = idle
+	}
+	sleepCheckInterval, err := time.ParseDuration(getEnv("INSTANCE_SLEEP_CHECK_INTERVAL", "10m"))
+	if err != nil || sleepCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid INSTANCE_SLEEP_CHECK_INTERVAL: must be a positive duration")
+	}
+	config.Sleep.CheckInterval = sleepCheckInterval
+	sleepTrafficBytes, err := strconv.ParseInt(getEnv("INSTANCE_SLEEP_TRAFFIC_BYTES", "65536"), 10, 64)
+	if err != nil || sleepTrafficBytes <= 0 {
+		return nil, fmt.Errorf("invalid INSTANCE_SLEEP_TRAFFIC_BYTES: must be a positive integer")
+	}
+	config.Sleep.TrafficBytes = sleepTrafficBytes
+	
+	// Asynchronous instance provisioning configuration
+	provisioningTimeout, err := time.ParseDuration(getEnv("PROVISIONING_TIMEOUT", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROVISIONING_TIMEOUT: %w", err)
+	}
+	config.Provisioning.Timeout = provisioningTimeout
+	provisioningHealthTimeout, err := time.ParseDuration(getEnv("PROVISIONING_HEALTH_TIMEOUT", "3m"))
+	if err != nil || provisioningHealthTimeout <= 0 {
+		return nil, fmt.Errorf("invalid PROVISIONING_HEALTH_TIMEOUT: must be a positive duration")
+	}
+	config.Provisioning.HealthTimeout = provisioningHealthTimeout
+	
+	// Delivery log of users' webhook endpoints
+	webhookDeliveryRetention, err := time.ParseDuration(getEnv("WEBHOOK_DELIVERY_RETENTION", "720h"))
+	if err != nil || webhookDeliveryRetention <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_DELIVERY_RETENTION: must be a positive duration")
+	}
+	config.Webhooks.DeliveryRetention = webhookDeliveryRetention
+	
+	// Deleted instances are kept in the trash before their volumes are removed
+	trashRetention, err := time.ParseDuration(getEnv("INSTANCE_TRASH_RETENTION", "168h"))
+	if err != nil || trashRetention < 0 {
+		return nil, fmt.Errorf("invalid INSTANCE_TRASH_RETENTION: must be zero or a positive duration")
+	}
+	config.Trash.Retention = trashRetention
+	trashReapInterval, err := time.ParseDuration(getEnv("INSTANCE_TRASH_REAP_INTERVAL", "1h"))
+	if err != nil || trashReapInterval <= 0 {
+		return nil, fmt.Errorf("invalid INSTANCE_TRASH_REAP_INTERVAL: must be a positive duration")
+	}
+	config.Trash.ReapInterval = trashReapInterval
+	accountGracePeriod, err := time.ParseDuration(getEnv("ACCOUNT_DELETION_GRACE_PERIOD", "720h"))
+	if err != nil || accountGracePeriod < 0 {
+		return nil, fmt.Errorf("invalid ACCOUNT_DELETION_GRACE_PERIOD: must be zero or a positive duration")
+	}
+	config.AccountDeletion.GracePeriod = accountGracePeriod
+	accountPurgeInterval, err := time.ParseDuration(getEnv("ACCOUNT_PURGE_INTERVAL", "1h"))
+	if err != nil || accountPurgeInterval <= 0 {
+		return nil, fmt.Errorf("invalid ACCOUNT_PURGE_INTERVAL: must be a positive duration")
+	}
+	config.AccountDeletion.PurgeInterval = accountPurgeInterval
+	
+	// Background job queue configuration
+	jobWorkers, err := strconv.Atoi(getEnv("JOB_WORKERS", "4"))
+	if err != nil || jobWorkers < 1 {
+		return nil, fmt.Errorf("invalid JOB_WORKERS: must be a positive number")
+	}
+	config.Jobs.Workers = jobWorkers
+	jobMaxAttempts, err := strconv.Atoi(getEnv("JOB_MAX_ATTEMPTS", "5"))
+	if err != nil || jobMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid JOB_MAX_ATTEMPTS: must be a positive number")
+	}
+	config.Jobs.MaxAttempts = jobMaxAttempts
+	jobPollInterval, err := time.ParseDuration(getEnv("JOB_POLL_INTERVAL", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JOB_POLL_INTERVAL: %w", err)
+	}
+	config.Jobs.PollInterval = jobPollInterval
+	jobTimeout, err := time.ParseDuration(getEnv("JOB_TIMEOUT", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JOB_TIMEOUT: %w", err)
+	}
+	config.Jobs.Timeout = jobTimeout
+	jobRetryBaseDelay, err := time.ParseDuration(getEnv("JOB_RETRY_BASE_DELAY", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JOB_RETRY_BASE_DELAY: %w", err)
+	}
+	config.Jobs.RetryBaseDelay = jobRetryBaseDelay
+	jobRetryMaxDelay, err := time.ParseDuration(getEnv("JOB_RETRY_MAX_DELAY", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JOB_RETRY_MAX_DELAY: %w", err)
+	}
+	config.Jobs.RetryMaxDelay = jobRetryMaxDelay
+	
+	// Dedicated CPUs for pinned instances are grouped by NUMA node, e.g. "8-15;24-31"
+	dedicated := map[int]bool{}
+	for _, node := range strings.Split(getEnv("CPU_PINNING_CPUS", ""), ";") {
+		if strings.TrimSpace(node) == "" {
+			continue
+		}
+		cpus, err := ParseCPUList(node)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU_PINNING_CPUS: %w", err)
+		}
+		for _, cpu := range cpus {
+			if dedicated[cpu] {
+				return nil, fmt.Errorf("invalid CPU_PINNING_CPUS: CPU %d is listed twice", cpu)
+			}
+			dedicated[cpu] = true
+		}
+		config.CPUPinning.NUMANodes = append(config.CPUPinning.NUMANodes, cpus)
+	}
+	config.CPUPinning.SharedCPUs = strings.TrimSpace(getEnv("CPU_SHARED_CPUS", ""))
+	if config.CPUPinning.SharedCPUs != "" {
+		shared, err := ParseCPUList(config.CPUPinning.SharedCPUs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU_SHARED_CPUS: %w", err)
+		}
+		for _, cpu := range shared {
+			if dedicated[cpu] {
+				return nil, fmt.Errorf("invalid CPU_SHARED_CPUS: CPU %d is dedicated to pinned instances", cpu)
+			}
+		}
+	}
+	
+	// Reverse proxy configuration for TLS certificate tracking
+	config.Proxy.Provider = getEnv("PROXY_PROVIDER", "")
+	config.Proxy.APIURL = getEnv("PROXY_API_URL", "")
+	if config.Proxy.Provider != "" && config.Proxy.APIURL == "" {
+		return nil, fmt.Errorf("PROXY_API_URL is required when PROXY_PROVIDER is set")
+	}
+	config.Proxy.InternalToken = secrets.get("PROXY_INTERNAL_TOKEN", "")
+	
+	certCheckInterval, err := time.ParseDuration(getEnv("CERT_CHECK_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CERT_CHECK_INTERVAL: %w", err)
+	}
+	config.Proxy.CertCheckInterval = certCheckInterval
+	
+	certRenewWindow, err := time.ParseDuration(getEnv("CERT_RENEW_WINDOW", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CERT_RENEW_WINDOW: %w", err)
+	}
+	config.Proxy.CertRenewWindow = certRenewWindow
+	
+	certIssueTimeout, err := time.ParseDuration(getEnv("CERT_ISSUE_TIMEOUT", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CERT_ISSUE_TIMEOUT: %w", err)
+	}
+	config.Proxy.CertIssueTimeout = certIssueTimeout
+	
+	// SIEM export configuration
+	config.SIEM.Type = getEnv("SIEM_EXPORT_TYPE", "")
+	config.SIEM.Endpoint = getEnv("SIEM_ENDPOINT", "")
+	config.SIEM.Token = secrets.get("SIEM_TOKEN", "")
+	config.SIEM.Network = getEnv("SIEM_SYSLOG_NETWORK", "udp")
+	config.SIEM.IncludeAccessLogs = getEnv("SIEM_INCLUDE_ACCESS_LOGS", "true") == "true"
+	if config.SIEM.Type != "" && config.SIEM.Endpoint == "" {
+		return nil, fmt.Errorf("SIEM_ENDPOINT is required when SIEM_EXPORT_TYPE is set")
+	}
+	
+	siemBatchSize, err := strconv.Atoi(getEnv("SIEM_BATCH_SIZE", "100"))
+	if err != nil || siemBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid SIEM_BATCH_SIZE: must be a positive integer")
+	}
+	config.SIEM.BatchSize = siemBatchSize
+	
+	siemBufferSize, err := strconv.Atoi(getEnv("SIEM_BUFFER_SIZE", "10000"))
+	if err != nil || siemBufferSize <= 0 {
+		return nil, fmt.Errorf("invalid SIEM_BUFFER_SIZE: must be a positive integer")
+	}
+	config.SIEM.BufferSize = siemBufferSize
+	
+	siemFlushInterval, err := time.ParseDuration(getEnv("SIEM_FLUSH_INTERVAL", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIEM_FLUSH_INTERVAL: %w", err)
+	}
+	config.SIEM.FlushInterval = siemFlushInterval
+	
+	siemMaxRetries, err := strconv.Atoi(getEnv("SIEM_MAX_RETRIES", "3"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIEM_MAX_RETRIES: %w", err)
+	}
+	config.SIEM.MaxRetries = siemMaxRetries
+
+	// Branding defaults, overridable at runtime through the admin API
+	config.Branding.ProductName = getEnv("BRAND_PRODUCT_NAME", "LaunchStack")
+	config.Branding.SupportEmail = getEnv("BRAND_SUPPORT_EMAIL", "support@"+config.Server.Domain)
+	config.Branding.LogoURL = getEnv("BRAND_LOGO_URL", "")
+	config.Branding.PrimaryColor = getEnv("BRAND_PRIMARY_COLOR", "#6366F1")
+	config.Branding.SecondaryColor = getEnv("BRAND_SECONDARY_COLOR", "#0F172A")
+	config.Branding.AccentColor = getEnv("BRAND_ACCENT_COLOR", "#22D3EE")
+
+	// A secret file or Vault that could not be read fails the configuration
+	if secrets.err != nil {
+		return nil, secrets.err
+	}
+
+	// Settings are checked together once all are loaded, reporting every problem at once.
+	// Problems that leave the backend open to attack fail it even with FailFast disabled.
+	if err := config.Validate(); err != nil {
+		invalid, ok := err.(*ValidationError)
+		if config.Server.FailFast || !ok || invalid.Fatal {
+			return nil, err
+		}
+		config.problems = invalid.Problems
+	}
+
 	return config, nil
 }
 
+// FeatureEnabled checks if a feature flag is switched on
+func (c *Config) FeatureEnabled(flag string) bool {
+	return c.FeatureFlags[flag]
+}
+
+// ParseCPUList parses a Linux CPU list such as "0-3,8,10-11" into CPU numbers
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid CPU %q", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return nil, fmt.Errorf("invalid CPU range %q", part)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("empty CPU list")
+	}
+	return cpus, nil
+}
+
+// parseRegistryCredentials parses comma-separated host=username:password entries. Passwords are
+// registered as secrets so they are redacted on their own as well.
+func parseRegistryCredentials(value string) (map[string]RegistryCredential, error) {
+	credentials := make(map[string]RegistryCredential)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		host, login, ok := strings.Cut(entry, "=")
+		username, password, hasPassword := strings.Cut(login, ":")
+		host = strings.TrimSpace(host)
+		if !ok || !hasPassword || host == "" || username == "" || password == "" {
+			return nil, fmt.Errorf("entries must be host=username:password")
+		}
+		models.RegisterSecretValue(password)
+		credentials[host] = RegistryCredential{Username: username, Password: password}
+	}
+	return credentials, nil
+}
+
+// parseEncryptionKey decodes a base64 encoded AES-256 key
+func parseEncryptionKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("must be 32 bytes, base64 encoded")
+	}
+	return key, nil
+}
+
+// parseCIDRs parses a comma-separated list of IPv4 CIDRs or addresses, which stand for
+// themselves, into CIDRs
+func parseCIDRs(value string) ([]string, error) {
+	var cidrs []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		cidr := entry
+		if !strings.Contains(cidr, "/") {
+			cidr += "/32"
+		}
+		ip, network, err := net.ParseCIDR(cidr)
+		if err != nil || ip.To4() == nil {
+			return nil, fmt.Errorf("%q is not an IPv4 address or CIDR", entry)
+		}
+		cidrs = append(cidrs, network.String())
+	}
+	return cidrs, nil
+}
+
+// joinCertPath returns the path of a certificate file in dir, or "" when dir is unset
+func joinCertPath(dir, file string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, file)
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
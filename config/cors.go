@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OriginPattern is an origin browsers may call the API from, such as https://app.launchstack.io,
+// or all subdomains of a domain, such as https://*.launchstack.io
+type OriginPattern struct {
+	Scheme   string
+	Host     string // without the "*." of wildcard patterns
+	Port     string // empty for the scheme's default port
+	Wildcard bool   // matches subdomains of Host at any depth, but not Host itself
+}
+
+// ParseOriginPattern parses an origin pattern of the form [scheme://][*.]host[:port]. The
+// scheme defaults to https.
+func ParseOriginPattern(pattern string) (OriginPattern, error) {
+	raw := strings.ToLower(strings.TrimSpace(pattern))
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	wildcard := strings.Contains(raw, "://*.")
+	u, err := url.Parse(strings.Replace(raw, "://*.", "://", 1))
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil || strings.Contains(u.Hostname(), "*") {
+		return OriginPattern{}, fmt.Errorf("invalid origin %q", pattern)
+	}
+	return OriginPattern{Scheme: u.Scheme, Host: u.Hostname(), Port: u.Port(), Wildcard: wildcard}, nil
+}
+
+// Matches checks if an Origin header is allowed by the pattern
+func (p OriginPattern) Matches(origin string) bool {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme != p.Scheme || u.Port() != p.Port {
+		return false
+	}
+	if p.Wildcard {
+		return strings.HasSuffix(u.Hostname(), "."+p.Host)
+	}
+	return u.Hostname() == p.Host
+}
+
+// String formats the pattern as it is configured
+func (p OriginPattern) String() string {
+	host := p.Host
+	if p.Wildcard {
+		host = "*." + host
+	}
+	if p.Port != "" {
+		host += ":" + p.Port
+	}
+	return p.Scheme + "://" + host
+}
+
+// ParseCORSOrigins parses a comma-separated list of origin patterns. A "*" entry allows every
+// origin.
+func ParseCORSOrigins(list string) (allowAll bool, origins []OriginPattern, err error) {
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		switch entry {
+		case "":
+			continue
+		case "*":
+			allowAll = true
+			continue
+		}
+		origin, err := ParseOriginPattern(entry)
+		if err != nil {
+			return false, nil, err
+		}
+		origins = append(origins, origin)
+	}
+	return allowAll, origins, nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/launchstack/backend/models"
+)
+
+// Redacted returns the configuration for debugging, with fields named in snake_case and
+// secrets replaced by models.RedactedValue. Secrets that are not set stay empty, so a missing
+// secret can be told apart from a configured one.
+func (c *Config) Redacted() map[string]interface{} {
+	return redactValue("", reflect.ValueOf(*c)).(map[string]interface{})
+}
+
+// redactValue converts a configuration value for Redacted. Secrets are recognised by the name
+// of their field, and by their value in case another field contains one, e.g. a URL with a
+// password; keys held as bytes are always secret.
+func redactValue(name string, v reflect.Value) interface{} {
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case fmt.Stringer:
+		return models.RedactSecretValues(value.String())
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldName := snakeCase(field.Name)
+			fields[fieldName] = redactValue(fieldName, v.Field(i))
+		}
+		return fields
+	case reflect.Map:
+		entries := map[string]interface{}{}
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[fmt.Sprint(iter.Key().Interface())] = redactValue(name, iter.Value())
+		}
+		return entries
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Len() == 0 {
+				return ""
+			}
+			return models.RedactedValue
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = redactValue(name, v.Index(i))
+		}
+		return items
+	case reflect.String:
+		if v.String() != "" && models.IsSecretName(name) {
+			return models.RedactedValue
+		}
+		return models.RedactSecretValues(v.String())
+	default:
+		return v.Interface()
+	}
+}
+
+// snakeCase converts a Go field name to snake_case, keeping initialisms together (e.g.
+// "JWTSecret" to "jwt_secret")
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previousLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/models"
+)
+
+// secretSource reads secrets, in order of precedence, from an environment variable, from the
+// file named by the variable with a _FILE suffix (e.g. DB_PASSWORD_FILE, as used for Docker
+// and Kubernetes secrets), or from a HashiCorp Vault KV secret configured with VAULT_ADDR,
+// VAULT_TOKEN and VAULT_SECRET_PATH. Values read are registered for redaction from logs.
+// The first error is kept, so loading can continue and be checked once.
+type secretSource struct {
+	vaultAddr  string
+	vaultToken string
+	vaultPath  string
+	vault      map[string]string // fields of the Vault secret, read on first use
+	err        error
+}
+
+// newSecretSource creates a secret source, reading the Vault token as a secret itself
+func newSecretSource() *secretSource {
+	s := &secretSource{
+		vaultAddr: strings.TrimRight(getEnv("VAULT_ADDR", ""), "/"),
+		vaultPath: strings.Trim(getEnv("VAULT_SECRET_PATH", ""), "/"),
+	}
+	s.vaultToken = s.fromEnv("VAULT_TOKEN")
+	if s.vaultAddr != "" && (s.vaultToken == "" || s.vaultPath == "") {
+		s.fail(fmt.Errorf("VAULT_ADDR requires VAULT_TOKEN and VAULT_SECRET_PATH"))
+	}
+	return s
+}
+
+// get returns a secret, or defaultValue if no source has it
+func (s *secretSource) get(name, defaultValue string) string {
+	value := s.fromEnv(name)
+	if value == "" && s.vaultAddr != "" {
+		value = s.fromVault(name)
+	}
+	if value == "" {
+		return defaultValue
+	}
+	models.RegisterSecretValue(value)
+	return value
+}
+
+// require returns a secret that must be set
+func (s *secretSource) require(name string) (string, error) {
+	value := s.get(name, "")
+	if s.err != nil {
+		return "", s.err
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	return value, nil
+}
+
+// fromEnv reads a secret from its environment variable or the file named by NAME_FILE
+func (s *secretSource) fromEnv(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		s.fail(fmt.Errorf("failed to read %s_FILE: %w", name, err))
+		return ""
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+// fromVault reads a field of the Vault secret, fetching the secret on first use
+func (s *secretSource) fromVault(name string) string {
+	if s.vault == nil {
+		fields, err := s.readVault()
+		if err != nil {
+			s.fail(fmt.Errorf("failed to read secrets from Vault: %w", err))
+			fields = map[string]string{}
+		}
+		s.vault = fields
+	}
+	return s.vault[name]
+}
+
+// readVault fetches the string fields of the Vault secret. Both KV version 1 and version 2
+// (secret/data/...) paths are supported.
+func (s *secretSource) readVault() (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, s.vaultAddr+"/v1/"+s.vaultPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.vaultToken)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", s.vaultPath, resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, err
+	}
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok && data["metadata"] != nil {
+		data = nested
+	}
+
+	fields := make(map[string]string, len(data))
+	for name, value := range data {
+		if str, ok := value.(string); ok {
+			fields[name] = str
+		}
+	}
+	return fields, nil
+}
+
+// fail keeps the first error of loading secrets
+func (s *secretSource) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// minJWTSecretLength is the shortest JWT_SECRET accepted, and minProductionJWTSecretLength the
+// shortest in production
+const (
+	minJWTSecretLength           = 16
+	minProductionJWTSecretLength = 32
+)
+
+// ValidationError lists every problem found in a configuration, so they can be fixed at once
+// instead of one restart at a time
+type ValidationError struct {
+	Problems []string
+	Fatal    bool // a problem cannot be downgraded to a warning with CONFIG_FAIL_FAST=false
+}
+
+// Error formats the problems one per line
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validation collects the problems found by Validate
+type validation struct {
+	problems []string
+	fatal    bool
+}
+
+// check records a problem unless ok
+func (v *validation) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// require records a problem unless ok, which stops the backend from starting even with
+// CONFIG_FAIL_FAST=false, for settings that would leave it open to attack
+func (v *validation) require(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.check(ok, format, args...)
+		v.fatal = true
+	}
+}
+
+// Validate checks settings that are each valid on their own but not together, and, in
+// production, settings that must not be left at their development defaults. It returns a
+// *ValidationError listing every problem, or nil.
+func (c *Config) Validate() error {
+	v := &validation{}
+	v.check(c.Server.Port >= 1 && c.Server.Port <= 65535, "PORT must be a port number")
+	v.check(len(c.Server.JWTSecret) >= minJWTSecretLength, "JWT_SECRET must be at least %d characters", minJWTSecretLength)
+	v.check(isAbsoluteURL(c.Server.BackendURL), "BACKEND_URL must be an absolute http or https URL")
+	v.check(isAbsoluteURL(c.Server.FrontendURL), "FRONTEND_URL must be an absolute http or https URL")
+	v.check(c.Payments.Provider == "paypal" || c.Payments.Provider == "stripe", "PAYMENT_PROVIDER must be paypal or stripe")
+	v.require(!c.CORS.Strict || !c.CORS.AllowAll, "CORS_ORIGINS cannot allow every origin with \"*\" when CORS_STRICT is enabled")
+	v.check(c.Billing.UsageWebhookURL == "" || c.Billing.UsageWebhookSecret != "", "BILLING_USAGE_WEBHOOK_URL requires BILLING_USAGE_WEBHOOK_SECRET")
+	v.check(!c.Egress.Restrict || c.Egress.HelperImage != "", "EGRESS_RESTRICT requires EGRESS_HELPER_IMAGE")
+
+	if c.Server.Environment == "production" {
+		c.validateProduction(v)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems, Fatal: v.fatal}
+	}
+	return nil
+}
+
+// validateProduction checks what production requires: credentials for every service in use,
+// webhook secrets so callbacks cannot be forged, real CORS origins, and TLS to remote hosts
+func (c *Config) validateProduction(v *validation) {
+	v.check(len(c.Server.JWTSecret) >= minProductionJWTSecretLength, "JWT_SECRET must be at least %d characters in production", minProductionJWTSecretLength)
+	v.check(isPublicHTTPS(c.Server.BackendURL), "BACKEND_URL must be a public https URL in production")
+	v.check(isPublicHTTPS(c.Server.FrontendURL), "FRONTEND_URL must be a public https URL in production")
+
+	v.require(c.Database.Driver != "sqlite", "DB_DRIVER=sqlite is only supported in development")
+	v.require(c.Database.Driver != "postgres" || c.Database.Password != "", "DB_PASSWORD is required in production")
+
+	// Secrets derived from JWT_SECRET change, and make stored values unreadable, when it rotates
+	v.require(c.Clerk.WebhookSecret != "", "CLERK_WEBHOOK_SECRET is required in production")
+	v.require(!c.derived["N8N_WEBHOOK_SECRET"], "N8N_WEBHOOK_SECRET is required in production")
+	v.require(!c.derived["ENCRYPTION_KEY"], "ENCRYPTION_KEY (or N8N_CREDENTIALS_KEY) is required in production")
+
+	if !c.PayPal.DisablePayments {
+		switch c.Payments.Provider {
+		case "stripe":
+			v.check(c.Stripe.SecretKey != "" && c.Stripe.WebhookSecret != "", "PAYMENT_PROVIDER=stripe requires STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET in production")
+		case "paypal":
+			v.check(c.PayPal.APIKey != "" && c.PayPal.Secret != "", "PAYMENT_PROVIDER=paypal requires PAYPAL_API_KEY and PAYPAL_SECRET in production")
+			v.check(c.PayPal.Mode == "live", "PAYPAL_MODE must be live in production")
+		}
+	}
+
+	v.check(len(c.CORS.Origins) > 0, "CORS_ORIGINS must list the frontend's origins in production")
+	for _, origin := range c.CORS.Origins {
+		v.check(origin.Scheme == "https" && !isLocalHost(origin.Host), "CORS_ORIGINS entry %s must be a public https origin in production", origin)
+	}
+
+	if strings.HasPrefix(c.Docker.Host, "tcp://") || strings.HasPrefix(c.Docker.Host, "http://") {
+		v.require(c.Docker.TLSVerify, "DOCKER_HOST %s requires DOCKER_TLS_VERIFY in production", c.Docker.Host)
+	}
+	if c.DNS.Provider == "adguard" {
+		v.require(c.DNS.AdGuard.Host != "" && c.DNS.AdGuard.Username != "" && c.DNS.AdGuard.Password != "",
+			"DNS_PROVIDER=adguard requires ADGUARD_HOST, ADGUARD_USERNAME and ADGUARD_PASSWORD in production")
+	}
+	v.require(!c.GRPC.Enabled || !c.GRPC.Insecure, "GRPC_INSECURE cannot be used in production")
+	v.require(!c.Metrics.Enabled || c.Metrics.Token != "", "METRICS_ENABLED requires METRICS_TOKEN in production")
+}
+
+// Problems returns the validation problems the configuration was loaded with when
+// CONFIG_FAIL_FAST is disabled
+func (c *Config) Problems() []string {
+	return c.problems
+}
+
+// isAbsoluteURL checks if a value is an http or https URL with a host
+func isAbsoluteURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Hostname() != ""
+}
+
+// isPublicHTTPS checks if a value is an https URL of a host other than the local machine
+func isPublicHTTPS(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && u.Scheme == "https" && u.Hostname() != "" && !isLocalHost(u.Hostname())
+}
+
+// isLocalHost checks if a host name is the local machine
+func isLocalHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "localhost" || strings.HasSuffix(host, ".localhost") || host == "127.0.0.1" || host == "::1" || host == "0.0.0.0"
+}
+
+// truncate returns at most the first n bytes of a value
+func truncate(value string, n int) string {
+	if len(value) > n {
+		return value[:n]
+	}
+	return value
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+// envFile is the file environment variables are loaded from, next to the process environment
+const envFile = ".env"
+
+// envSources records where environment variables came from, so a reload can take new values
+// from the .env file without overriding the process environment
+var envSources = struct {
+	sync.Mutex
+	process map[string]bool // set before the .env file was loaded, which it never overrides
+	file    map[string]bool // set from the .env file
+}{}
+
+// LoadEnvFile sets the environment variables of the .env file that the process environment
+// does not set. It returns an error if the file cannot be read, e.g. because there is none.
+func LoadEnvFile() error {
+	envSources.Lock()
+	defer envSources.Unlock()
+	envSources.process = map[string]bool{}
+	for _, entry := range os.Environ() {
+		if name, _, ok := strings.Cut(entry, "="); ok {
+			envSources.process[name] = true
+		}
+	}
+	envSources.file = map[string]bool{}
+	return applyEnvFile()
+}
+
+// applyEnvFile sets the variables of the .env file that the process environment does not
+// set, and unsets those it set before that were removed from the file. Nothing is changed
+// unless LoadEnvFile loaded the file first.
+func applyEnvFile() error {
+	if envSources.process == nil {
+		return nil
+	}
+	values, err := godotenv.Read(envFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for name := range envSources.file {
+		if _, ok := values[name]; !ok {
+			os.Unsetenv(name)
+			delete(envSources.file, name)
+		}
+	}
+	for name, value := range values {
+		if !envSources.process[name] {
+			os.Setenv(name, value)
+			envSources.file[name] = true
+		}
+	}
+	return err
+}
+
+// Subscriber applies the settings of a reloaded configuration to a subsystem
+type Subscriber func(cfg *Config) error
+
+// ReloadResult reports which subsystems a reloaded configuration was applied to
+type ReloadResult struct {
+	Applied  []string          `json:"applied"`
+	Failed   map[string]string `json:"failed"`
+	Problems []string          `json:"problems"` // validation problems, when CONFIG_FAIL_FAST is disabled
+}
+
+// Watcher reloads the configuration at runtime, on SIGHUP or on request, and hands it to the
+// subsystems whose settings can change without a restart. Other settings keep the values the
+// process started with.
+type Watcher struct {
+	logger *logrus.Logger
+
+	mu          sync.Mutex
+	names       []string
+	subscribers map[string]Subscriber
+}
+
+// NewWatcher creates a new configuration watcher
+func NewWatcher(logger *logrus.Logger) *Watcher {
+	return &Watcher{
+		logger:      logger,
+		subscribers: map[string]Subscriber{},
+	}
+}
+
+// Subscribe registers a subsystem to receive reloaded configurations, in the order subscribed
+func (w *Watcher) Subscribe(name string, subscriber Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.subscribers[name]; !ok {
+		w.names = append(w.names, name)
+	}
+	w.subscribers[name] = subscriber
+}
+
+// Reload reads the configuration again, from the .env file, secret files and Vault, and hands
+// it to every subscriber. A configuration that fails to load or validate is not applied at
+// all; a subscriber that fails keeps its previous settings without stopping the others.
+func (w *Watcher) Reload() (*ReloadResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	envSources.Lock()
+	err := applyEnvFile()
+	envSources.Unlock()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", envFile, err)
+	}
+	cfg, err := NewConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{Applied: []string{}, Failed: map[string]string{}, Problems: cfg.Problems()}
+	for _, name := range w.names {
+		if err := w.subscribers[name](cfg); err != nil {
+			w.logger.WithError(err).WithField("subsystem", name).Error("Failed to apply reloaded configuration")
+			result.Failed[name] = err.Error()
+			continue
+		}
+		result.Applied = append(result.Applied, name)
+	}
+	if result.Problems == nil {
+		result.Problems = []string{}
+	}
+	return result, nil
+}
+
+// Start reloads the configuration on every SIGHUP until the context is cancelled
+func (w *Watcher) Start(ctx context.Context) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			w.logger.Info("Reloading configuration on SIGHUP")
+			result, err := w.Reload()
+			if err != nil {
+				w.logger.WithError(err).Error("Failed to reload configuration, keeping the current settings")
+				continue
+			}
+			for _, problem := range result.Problems {
+				w.logger.WithField("problem", problem).Warn("Invalid configuration, reloading anyway because CONFIG_FAIL_FAST is disabled")
+			}
+			w.logger.WithFields(logrus.Fields{
+				"applied": result.Applied,
+				"failed":  len(result.Failed),
+			}).Info("Reloaded configuration")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package container
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+)
+
+// backupManifest describes a backup archive in its manifest.json
+type backupManifest struct {
+	InstanceID  string    `json:"instance_id"`
+	ServiceType string    `json:"service_type"`
+	ImageTag    string    `json:"image_tag"`
+	CreatedAt   time.Time `json:"created_at"`
+	Folders     []string  `json:"folders"`
+}
+
+// ArchiveInstanceData writes a gzipped tar archive of an instance's volumes to w, each in the
+// folder named after the volume in the service's template. The volumes are copied out of the
+// container, which works whether or not it is running.
+func (m *DockerManager) ArchiveInstanceData(ctx context.Context, instanceID uuid.UUID, w io.Writer) error {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return fmt.Errorf("instance has no container ID")
+	}
+
+	template := m.templates.ForInstance(instance)
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	if err := writeBackupManifest(archive, instance, template); err != nil {
+		return err
+	}
+	for _, volume := range template.Volumes {
+		reader, _, err := m.client.CopyFromContainer(ctx, instance.ContainerID, volume.Path)
+		if err != nil {
+			return fmt.Errorf("failed to copy %s from container: %w", volume.Path, err)
+		}
+		err = copyTarEntries(archive, tar.NewReader(reader), volume.Name)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", volume.Path, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeBackupManifest adds the manifest describing a backup as the archive's first entry
+func writeBackupManifest(archive *tar.Writer, instance *models.Instance, template *ServiceTemplate) error {
+	manifest := backupManifest{
+		InstanceID:  instance.ID.String(),
+		ServiceType: string(template.Service),
+		ImageTag:    instance.ImageTag,
+		CreatedAt:   time.Now().UTC(),
+	}
+	for _, volume := range template.Volumes {
+		manifest.Folders = append(manifest.Folders, volume.Name)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := archive.WriteHeader(&tar.Header{
+		Name:    "manifest.json",
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return err
+	}
+	_, err = archive.Write(data)
+	return err
+}
+
+// copyTarEntries copies the entries of a tar stream from Docker into archive under folder.
+// Docker names the entries after the copied directory, so that first path element is replaced.
+func copyTarEntries(archive *tar.Writer, source *tar.Reader, folder string) error {
+	for {
+		header, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(folder, trimFirstElement(header.Name))
+		if header.Typeflag == tar.TypeDir {
+			header.Name += "/"
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(archive, source); err != nil {
+			return err
+		}
+	}
+}
+
+// trimFirstElement removes the first element of a slash-separated path
+func trimFirstElement(name string) string {
+	for i := 0; i < len(name); i++ {
+		if name[i] == '/' {
+			return name[i+1:]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+)
+
+// ErrHostAtCapacity is returned when the host has no room for another instance
+var ErrHostAtCapacity = errors.New("the host is at capacity")
+
+// reservationKey is the context key of the waitlist reservation an instance is created from
+type reservationKey struct{}
+
+// WithReservation marks instances prepared with the context as created from a waitlist
+// reservation, so the capacity held by the reservation is available to them
+func WithReservation(ctx context.Context, entryID uuid.UUID) context.Context {
+	return context.WithValue(ctx, reservationKey{}, entryID)
+}
+
+// HostCapacity is what instances may be allocated in total: the configured limits, lowered to
+// the physical resources of the active hosts times their overcommit ratios. Each is unlimited when 0.
+type HostCapacity struct {
+	MaxCPU       float64
+	MaxMemoryMB  int64
+	MaxInstances int
+}
+
+// Limited checks if any limit applies
+func (c HostCapacity) Limited() bool {
+	return c.MaxCPU > 0 || c.MaxMemoryMB > 0 || c.MaxInstances > 0
+}
+
+// LoadCapacity returns the host capacity from the configuration and the overcommit policies of
+// the hosts checked within the last three host check intervals
+func LoadCapacity(cfg *config.Config) (HostCapacity, error) {
+	capacity := HostCapacity{
+		MaxCPU:       cfg.Capacity.MaxCPU,
+		MaxMemoryMB:  int64(cfg.Capacity.MaxMemoryMB),
+		MaxInstances: cfg.Capacity.MaxInstances,
+	}
+
+	hosts, err := db.GetActiveDockerHosts(time.Now().Add(-3 * cfg.Capacity.HostCheckInterval))
+	if err != nil {
+		return capacity, err
+	}
+	var hostCPU float64
+	var hostMemoryMB int64
+	for _, host := range hosts {
+		hostCPU += host.AllocatableCPU()
+		hostMemoryMB += host.AllocatableMemoryMB()
+	}
+	if hostCPU > 0 && (capacity.MaxCPU == 0 || hostCPU < capacity.MaxCPU) {
+		capacity.MaxCPU = hostCPU
+	}
+	if hostMemoryMB > 0 && (capacity.MaxMemoryMB == 0 || hostMemoryMB < capacity.MaxMemoryMB) {
+		capacity.MaxMemoryMB = hostMemoryMB
+	}
+	return capacity, nil
+}
+
+// HasCapacity checks if another instance with the given limits fits within the host capacity
+// on top of the resources already allocated
+func HasCapacity(capacity HostCapacity, allocated *db.AllocatedResources, cpuLimit float64, memoryLimitMB int) bool {
+	if capacity.MaxInstances > 0 && allocated.Instances >= int64(capacity.MaxInstances) {
+		return false
+	}
+	// Allow for rounding of summed fractional cores
+	if capacity.MaxCPU > 0 && allocated.CPU+cpuLimit > capacity.MaxCPU+0.001 {
+		return false
+	}
+	if capacity.MaxMemoryMB > 0 && allocated.MemoryMB+int64(memoryLimitMB) > capacity.MaxMemoryMB {
+		return false
+	}
+	return true
+}
+
+// checkCapacity returns ErrHostAtCapacity if the host has no room for an instance with the given limits
+func checkCapacity(ctx context.Context, cfg *config.Config, cpuLimit float64, memoryLimitMB int) error {
+	capacity, err := LoadCapacity(cfg)
+	if err != nil {
+		return err
+	}
+	if !capacity.Limited() {
+		return nil
+	}
+	reservation, _ := ctx.Value(reservationKey{}).(uuid.UUID)
+	allocated, err := db.GetAllocatedResources(reservation)
+	if err != nil {
+		return err
+	}
+	if !HasCapacity(capacity, allocated, cpuLimit, memoryLimitMB) {
+		return ErrHostAtCapacity
+	}
+	return nil
+}
+
+// CheckResize returns ErrHostAtCapacity if raising an instance's limits to the given ones does
+// not fit within the host capacity. Lowering limits always fits.
+func CheckResize(cfg *config.Config, instance *models.Instance, cpuLimit float64, memoryLimitMB int) error {
+	capacity, err := LoadCapacity(cfg)
+	if err != nil {
+		return err
+	}
+	if capacity.MaxCPU == 0 && capacity.MaxMemoryMB == 0 {
+		return nil
+	}
+	allocated, err := db.GetAllocatedResources(uuid.Nil)
+	if err != nil {
+		return err
+	}
+	// Allow for rounding of summed fractional cores
+	if growth := cpuLimit - instance.CPULimit; growth > 0 && capacity.MaxCPU > 0 && allocated.CPU+growth > capacity.MaxCPU+0.001 {
+		return ErrHostAtCapacity
+	}
+	if growth := int64(memoryLimitMB - instance.MemoryLimit); growth > 0 && capacity.MaxMemoryMB > 0 && allocated.MemoryMB+growth > capacity.MaxMemoryMB {
+		return ErrHostAtCapacity
+	}
+	return nil
+}
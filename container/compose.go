@@ -0,0 +1,96 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/models"
+)
+
+// composeHostDefaults replaces the platform's hostname settings in exported compose files with
+// defaults that work on the machine running them
+var composeHostDefaults = map[string]string{
+	"N8N_HOST":     "${N8N_HOST:-localhost}",
+	"N8N_PROTOCOL": "${N8N_PROTOCOL:-http}",
+	"WEBHOOK_URL":  "${WEBHOOK_URL:-http://localhost:5678/}",
+}
+
+// ComposeEnv returns the environment of an instance for a self-hosted compose file. Secrets
+// are left out and must be provided with the returned names, e.g. in a .env file, and the
+// platform's hostname settings are replaced with overridable defaults.
+func ComposeEnv(instance *models.Instance) (map[string]string, []string) {
+	env := map[string]string{}
+	if instance.ProvisioningSpec != nil {
+		for name, value := range instance.ProvisioningSpec.Env {
+			env[name] = value
+		}
+	} else {
+		// Instances created before specs were recorded run with the environment of today
+		env = models.RedactEnv(instanceEnv(instance, "", ""))
+	}
+	// A self-hosted instance does not report its workflow events to the platform
+	delete(env, instanceIDEnv)
+	delete(env, webhookSecretEnv)
+
+	secrets := []string{}
+	for name := range env {
+		switch {
+		case models.IsSecretName(name):
+			env[name] = "${" + name + ":?Set " + name + " in .env}"
+			secrets = append(secrets, name)
+		case composeHostDefaults[name] != "":
+			env[name] = composeHostDefaults[name]
+		default:
+			// Compose would interpolate a literal $
+			env[name] = strings.ReplaceAll(env[name], "$", "$$")
+		}
+	}
+	sort.Strings(secrets)
+	return env, secrets
+}
+
+// ComposeFile returns a docker-compose.yml running an instance's service version with its
+// environment and resource limits. Its volumes are bind mounts of the directories of a backup
+// archive extracted next to the file, e.g. data/ and files/ for n8n.
+func ComposeFile(template *ServiceTemplate, instance *models.Instance, exportedAt time.Time) []byte {
+	env, _ := ComposeEnv(instance)
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Exported from instance %s (%s) on %s\n", strconv.Quote(instance.Name), instance.ID, exportedAt.UTC().Format(time.RFC3339))
+	b.WriteString("# Extract a backup archive of the instance next to this file, provide the secrets\n")
+	b.WriteString("# referenced below in a .env file and run: docker compose up -d\n")
+	b.WriteString("services:\n")
+	fmt.Fprintf(&b, "  %s:\n", template.Service)
+	fmt.Fprintf(&b, "    image: %s\n", strconv.Quote(template.ImageFor(instance.ImageTag)))
+	b.WriteString("    restart: unless-stopped\n")
+	b.WriteString("    ports:\n")
+	fmt.Fprintf(&b, "      - \"%d:%d\"\n", template.Port, template.Port)
+	b.WriteString("    environment:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "      %s: %s\n", name, strconv.Quote(env[name]))
+	}
+	b.WriteString("    volumes:\n")
+	for _, volume := range template.Volumes {
+		fmt.Fprintf(&b, "      - ./%s:%s\n", volume.Name, volume.Path)
+	}
+	if instance.CPULimit > 0 || instance.MemoryLimit > 0 {
+		b.WriteString("    deploy:\n")
+		b.WriteString("      resources:\n")
+		b.WriteString("        limits:\n")
+		if instance.CPULimit > 0 {
+			fmt.Fprintf(&b, "          cpus: %s\n", strconv.Quote(strconv.FormatFloat(instance.CPULimit, 'f', -1, 64)))
+		}
+		if instance.MemoryLimit > 0 {
+			fmt.Fprintf(&b, "          memory: %dM\n", instance.MemoryLimit)
+		}
+	}
+	return []byte(b.String())
+}
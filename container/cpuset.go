@@ -0,0 +1,226 @@
+package container
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// CPUSetAllocator hands out the host's dedicated CPUs to pinned instances, keeping each
+// instance on a single NUMA node when one has enough free CPUs
+type CPUSetAllocator struct {
+	mu        sync.Mutex
+	nodes     [][]int
+	allocated map[int]uuid.UUID // CPU -> instance pinned to it
+	logger    *logrus.Logger
+}
+
+// NewCPUSetAllocator creates an allocator for the configured dedicated CPUs, or returns nil
+// if CPU pinning is not configured
+func NewCPUSetAllocator(cfg *config.Config, logger *logrus.Logger) *CPUSetAllocator {
+	if len(cfg.CPUPinning.NUMANodes) == 0 {
+		return nil
+	}
+	return &CPUSetAllocator{
+		nodes:     cfg.CPUPinning.NUMANodes,
+		allocated: make(map[int]uuid.UUID),
+		logger:    logger,
+	}
+}
+
+// Load restores the allocations of instances that are already pinned
+func (a *CPUSetAllocator) Load(instances []models.Instance) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, instance := range instances {
+		cpus, err := config.ParseCPUList(instance.CPUSet)
+		if err != nil {
+			a.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Ignoring invalid CPU set of instance")
+			continue
+		}
+		for _, cpu := range cpus {
+			if owner, taken := a.allocated[cpu]; taken && owner != instance.ID {
+				a.logger.WithFields(logrus.Fields{
+					"instance_id": instance.ID,
+					"owner_id":    owner,
+					"cpu":         cpu,
+				}).Warn("CPU is pinned to more than one instance")
+				continue
+			}
+			a.allocated[cpu] = instance.ID
+		}
+	}
+}
+
+// Sync replaces the allocations with those of the given pinned instances, which may have been
+// changed by other replicas
+func (a *CPUSetAllocator) Sync(instances []models.Instance) {
+	a.mu.Lock()
+	a.allocated = make(map[int]uuid.UUID)
+	a.mu.Unlock()
+	a.Load(instances)
+}
+
+// Allocate reserves count dedicated CPUs for an instance and returns them as a cpuset, e.g.
+// "8-9". An instance that already holds count CPUs keeps them.
+func (a *CPUSetAllocator) Allocate(instanceID uuid.UUID, count int) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if held := a.cpusOf(instanceID); len(held) == count {
+		return formatCPUList(held), nil
+	}
+	a.release(instanceID)
+
+	// Best fit: the NUMA node with the fewest free CPUs that still has enough
+	var best []int
+	var free []int
+	for _, node := range a.nodes {
+		nodeFree := a.freeCPUs(node)
+		free = append(free, nodeFree...)
+		if len(nodeFree) >= count && (best == nil || len(nodeFree) < len(best)) {
+			best = nodeFree
+		}
+	}
+	if best == nil {
+		if len(free) < count {
+			return "", fmt.Errorf("not enough dedicated CPUs: %d requested, %d free", count, len(free))
+		}
+		a.logger.WithFields(logrus.Fields{
+			"instance_id": instanceID,
+			"cpus":        count,
+		}).Warn("No NUMA node has enough free CPUs, spreading instance across nodes")
+		best = free
+	}
+
+	cpus := best[:count]
+	for _, cpu := range cpus {
+		a.allocated[cpu] = instanceID
+	}
+	return formatCPUList(cpus), nil
+}
+
+// Release frees the CPUs pinned to an instance
+func (a *CPUSetAllocator) Release(instanceID uuid.UUID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.release(instanceID)
+}
+
+// release frees an instance's CPUs; the caller must hold the lock
+func (a *CPUSetAllocator) release(instanceID uuid.UUID) {
+	for cpu, owner := range a.allocated {
+		if owner == instanceID {
+			delete(a.allocated, cpu)
+		}
+	}
+}
+
+// cpusOf returns the CPUs pinned to an instance, in order; the caller must hold the lock
+func (a *CPUSetAllocator) cpusOf(instanceID uuid.UUID) []int {
+	var cpus []int
+	for cpu, owner := range a.allocated {
+		if owner == instanceID {
+			cpus = append(cpus, cpu)
+		}
+	}
+	sort.Ints(cpus)
+	return cpus
+}
+
+// freeCPUs returns the CPUs of a NUMA node that are not pinned; the caller must hold the lock
+func (a *CPUSetAllocator) freeCPUs(node []int) []int {
+	var free []int
+	for _, cpu := range node {
+		if _, taken := a.allocated[cpu]; !taken {
+			free = append(free, cpu)
+		}
+	}
+	return free
+}
+
+// formatCPUList formats CPU numbers as a Linux CPU list, collapsing consecutive CPUs into ranges
+func formatCPUList(cpus []int) string {
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// assignCPUSet decides the cpuset of an instance's container for the given CPU limit. Instances
+// of plans with CPU pinning get dedicated CPUs, recorded on the instance; all others share the
+// configured shared CPUs. An empty cpuset leaves the container unrestricted.
+func (m *DockerManager) assignCPUSet(user *models.User, instance *models.Instance, cpuLimit float64) string {
+	if m.cpuSets == nil {
+		return m.config.CPUPinning.SharedCPUs
+	}
+
+	if user != nil && user.HasFeature(models.FeatureCPUPinning) {
+		count := int(math.Ceil(cpuLimit))
+		if count < 1 {
+			count = 1
+		}
+		// Other replicas pin instances too, so the allocation is made from and recorded in
+		// the database
+		cpuSet, err := db.ReserveCPUSet(instance.ID, func(pinned []models.Instance) (string, error) {
+			m.cpuSets.Sync(pinned)
+			return m.cpuSets.Allocate(instance.ID, count)
+		})
+		if err == nil {
+			instance.CPUSet = cpuSet
+			return cpuSet
+		}
+		m.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to pin instance to dedicated CPUs, using shared CPUs")
+	}
+
+	m.cpuSets.Release(instance.ID)
+	instance.CPUSet = ""
+	return m.config.CPUPinning.SharedCPUs
+}
+
+// restoreCPUSet gives an instance back the dedicated CPUs its container still uses after
+// changing them failed
+func (m *DockerManager) restoreCPUSet(instance *models.Instance, cpuSet string) {
+	instance.CPUSet = cpuSet
+	if m.cpuSets == nil {
+		return
+	}
+	m.cpuSets.Release(instance.ID)
+	if cpuSet != "" {
+		m.cpuSets.Load([]models.Instance{*instance})
+	}
+	if err := db.SetInstanceCPUSet(instance.ID, cpuSet); err != nil {
+		m.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to restore CPU set of instance")
+	}
+}
+
+// releaseCPUSet frees the dedicated CPUs of an instance whose container was removed
+func (m *DockerManager) releaseCPUSet(instance *models.Instance) {
+	if m.cpuSets != nil {
+		m.cpuSets.Release(instance.ID)
+	}
+	instance.CPUSet = ""
+}
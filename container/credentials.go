@@ -0,0 +1,213 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrNoCredentials is returned for instances whose basic auth password is not known
+var ErrNoCredentials = errors.New("instance has no stored credentials")
+
+const (
+	basicAuthUserEnv     = "N8N_BASIC_AUTH_USER"
+	basicAuthPasswordEnv = "N8N_BASIC_AUTH_PASSWORD"
+	instanceIDEnv        = "LAUNCHSTACK_INSTANCE_ID"    // instanceId of the instance's n8n webhook events
+	webhookSecretEnv     = "LAUNCHSTACK_WEBHOOK_SECRET" // signs the instance's n8n webhook events
+)
+
+// loadCredentials returns the stored basic auth credentials of an instance with the decrypted password
+func loadCredentials(instanceID uuid.UUID) (*models.InstanceCredential, string, error) {
+	credential, err := db.GetInstanceCredential(instanceID)
+	if err != nil {
+		return nil, "", err
+	}
+	password, err := credential.Password()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+	return credential, password, nil
+}
+
+// storeCredentials encrypts a password and saves the credentials
+func storeCredentials(credential *models.InstanceCredential, password string) error {
+	if err := credential.SetPassword(password); err != nil {
+		return err
+	}
+	if err := db.SaveInstanceCredential(credential); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return nil
+}
+
+// provisioningCredentials returns the basic auth password and webhook secret of an instance
+// being provisioned, generating and storing them on the first attempt so retries reuse them
+func provisioningCredentials(instance *models.Instance) (string, string, error) {
+	credential, password, err := loadCredentials(instance.ID)
+	if err == nil && credential.HasWebhookSecret() {
+		webhookSecret, err := credential.WebhookSecret()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decrypt webhook secret: %w", err)
+		}
+		return password, webhookSecret, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", err
+	}
+
+	if credential == nil {
+		credential = &models.InstanceCredential{InstanceID: instance.ID, Username: instance.Host}
+		if password, err = models.GenerateInstancePassword(); err != nil {
+			return "", "", err
+		}
+	}
+	webhookSecret, err := models.GenerateInstanceWebhookSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := credential.SetWebhookSecret(webhookSecret); err != nil {
+		return "", "", err
+	}
+	if err := storeCredentials(credential, password); err != nil {
+		return "", "", err
+	}
+	return password, webhookSecret, nil
+}
+
+// setEnv sets a variable in a container environment, replacing any previous value
+func setEnv(env []string, name, value string) []string {
+	updated := make([]string, 0, len(env)+1)
+	for _, entry := range env {
+		if !strings.HasPrefix(entry, name+"=") {
+			updated = append(updated, entry)
+		}
+	}
+	return append(updated, name+"="+value)
+}
+
+// GetInstanceCredentials returns the basic auth credentials of an instance with the decrypted
+// password. Instances created before credentials were stored have theirs recovered from the
+// container's environment.
+func (m *DockerManager) GetInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (*models.InstanceCredential, string, error) {
+	credential, password, err := loadCredentials(instanceID)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return credential, password, err
+	}
+
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return nil, "", ErrNoCredentials
+	}
+	inspect, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	credential = &models.InstanceCredential{InstanceID: instance.ID, Username: instance.Host}
+	password = ""
+	for _, entry := range inspect.Config.Env {
+		name, value, _ := strings.Cut(entry, "=")
+		switch name {
+		case basicAuthUserEnv:
+			credential.Username = value
+		case basicAuthPasswordEnv:
+			password = value
+		case webhookSecretEnv:
+			if err := credential.SetWebhookSecret(value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if password == "" {
+		return nil, "", ErrNoCredentials
+	}
+	if err := storeCredentials(credential, password); err != nil {
+		return nil, "", err
+	}
+
+	m.logger.WithField("instance_id", instance.ID).Info("Stored basic auth credentials recovered from container")
+	return credential, password, nil
+}
+
+// RotateInstanceCredentials replaces an instance's basic auth password and webhook secret and
+// recreates its container with the new ones, which also gives instances created before they
+// had a webhook secret their own. The new credentials are stored first so they are never
+// lost, and the previous ones are restored if the container cannot be recreated.
+func (m *DockerManager) RotateInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (*models.InstanceCredential, string, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return nil, "", fmt.Errorf("instance has no container ID")
+	}
+	if !m.templates.ForInstance(instance).Workflows {
+		return nil, "", ErrServiceUnsupported
+	}
+	logger := m.logger.WithField("instance_id", instance.ID)
+
+	credential, _, err := m.GetInstanceCredentials(ctx, instance.ID)
+	if err != nil && !errors.Is(err, ErrNoCredentials) {
+		return nil, "", err
+	}
+	if credential == nil {
+		credential = &models.InstanceCredential{InstanceID: instance.ID, Username: instance.Host}
+	}
+
+	password, err := models.GenerateInstancePassword()
+	if err != nil {
+		return nil, "", err
+	}
+	webhookSecret, err := models.GenerateInstanceWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	previousPassword, previousWebhookSecret, previousRotatedAt := credential.EncryptedPassword, credential.EncryptedWebhookSecret, credential.RotatedAt
+	now := time.Now()
+	credential.RotatedAt = &now
+	if err := credential.SetWebhookSecret(webhookSecret); err != nil {
+		return nil, "", err
+	}
+	if err := storeCredentials(credential, password); err != nil {
+		return nil, "", err
+	}
+
+	logger.Info("Recreating container with rotated credentials")
+	err = m.recreateContainer(ctx, instance, logger, func(config *container.Config, hostConfig *container.HostConfig) {
+		config.Env = setEnv(config.Env, basicAuthUserEnv, credential.Username)
+		config.Env = setEnv(config.Env, basicAuthPasswordEnv, password)
+		config.Env = setEnv(config.Env, instanceIDEnv, instance.ID.String())
+		config.Env = setEnv(config.Env, webhookSecretEnv, webhookSecret)
+	})
+	if err != nil {
+		if previousPassword == "" {
+			if deleteErr := db.DeleteInstanceCredential(instance.ID); deleteErr != nil {
+				logger.WithError(deleteErr).Error("Failed to remove credentials after failed rotation")
+			}
+		} else {
+			credential.EncryptedPassword, credential.EncryptedWebhookSecret, credential.RotatedAt = previousPassword, previousWebhookSecret, previousRotatedAt
+			if saveErr := db.SaveInstanceCredential(credential); saveErr != nil {
+				logger.WithError(saveErr).Error("Failed to restore previous credentials after failed rotation")
+			}
+		}
+		return nil, "", fmt.Errorf("failed to apply rotated credentials: %w", err)
+	}
+
+	if err := db.UpdateInstance(instance); err != nil {
+		return credential, password, fmt.Errorf("rotation succeeded but failed to update instance: %w", err)
+	}
+
+	logger.WithField("container_id", instance.ContainerID).Info("Instance credentials rotated")
+	return credential, password, nil
+}
@@ -1,399 +1,136 @@
 package container
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"os/exec"
+	"net"
 	"strings"
-	"time"
 
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
 )
 
-// DNSRewrite represents a DNS rewrite rule in AdGuard
-type DNSRewrite struct {
-	Domain string `json:"domain"`
+// ErrDNSRecordNotFound is returned when a DNS record does not exist
+var ErrDNSRecordNotFound = errors.New("DNS record not found")
+
+// ErrDNSListingUnsupported is returned when the DNS provider cannot list its records
+var ErrDNSListingUnsupported = errors.New("DNS provider cannot list records")
+
+// DNSRecord is a record pointing a name at an IP address, or at another name
+type DNSRecord struct {
+	Name   string `json:"name"`
 	Answer string `json:"answer"`
 }
 
-// DNSManager manages DNS entries in AdGuard
-type DNSManager struct {
-	logger   *logrus.Logger
-	host     string
-	username string
-	password string
-	protocol string
-	cliPath  string
+// DNSProvider creates the DNS records instances are reached by
+type DNSProvider interface {
+	// Name identifies the provider, e.g. "cloudflare"
+	Name() string
+	// Public reports whether records are public, pointing the instance's hostname at
+	// DNS_PUBLIC_TARGET, rather than LAN rewrites of its .docker name to the container
+	Public() bool
+	// FindRecord returns the record of a name, or ErrDNSRecordNotFound
+	FindRecord(ctx context.Context, name string) (*DNSRecord, error)
+	// SetRecord creates the record of a name or replaces its answer
+	SetRecord(ctx context.Context, name, answer string) error
+	// DeleteRecord removes the record of a name; records that do not exist are ignored
+	DeleteRecord(ctx context.Context, name string) error
 }
 
-// NewDNSManager creates a new DNS manager with credentials from environment variables
-func NewDNSManager(logger *logrus.Logger) *DNSManager {
-	host := getEnv("ADGUARD_HOST", "")
-	username := getEnv("ADGUARD_USERNAME", "")
-	password := getEnv("ADGUARD_PASSWORD", "")
-	
-	if host == "" || username == "" || password == "" {
-		logger.Error("ADGUARD_HOST, ADGUARD_USERNAME, and ADGUARD_PASSWORD environment variables must be set")
-	}
-	
-	return &DNSManager{
-		logger:   logger,
-		host:     host,
-		username: username,
-		password: password,
-		protocol: getEnv("ADGUARD_PROTOCOL", "https"),
-		cliPath:  getEnv("DNS_CLI_PATH", "./dns-cli"),
+// NewDNSProvider creates the DNS provider selected with DNS_PROVIDER
+func NewDNSProvider(cfg *config.Config, logger *logrus.Logger) DNSProvider {
+	switch cfg.DNS.Provider {
+	case "cloudflare":
+		return NewCloudflareDNS(cfg, logger)
+	case "route53":
+		return NewRoute53DNS(cfg, logger)
+	case "none":
+		return noDNS{}
+	default:
+		return NewAdGuardDNS(cfg, logger)
 	}
 }
 
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// instanceDNSRecord returns the record an instance is reached by: its public hostname
+// pointing at DNS_PUBLIC_TARGET on public providers, otherwise {subdomain}.docker pointing at
+// the container's IP address
+func (m *DockerManager) instanceDNSRecord(instance *models.Instance, containerIP string) DNSRecord {
+	if m.dns.Public() {
+		return DNSRecord{Name: instance.URL, Answer: m.config.DNS.PublicTarget}
 	}
-	return value
-}
-
-// createAuthHeader creates the authorization header for AdGuard requests
-func (m *DNSManager) createAuthHeader() string {
-	auth := m.username + ":" + m.password
-	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+	return DNSRecord{Name: fmt.Sprintf("%s.docker", instance.Host), Answer: containerIP}
 }
 
-// runCLICommand runs the DNS CLI tool with the given arguments
-func (m *DNSManager) runCLICommand(args ...string) (string, error) {
-	// Check if CLI tool exists
-	if _, err := os.Stat(m.cliPath); os.IsNotExist(err) {
-		// Fall back to API if CLI tool doesn't exist
-		m.logger.Warn("DNS CLI tool not found, falling back to API methods")
-		return "", fmt.Errorf("DNS CLI tool not found at %s", m.cliPath)
-	}
-	
-	// Prepare command
-	cmd := exec.Command(m.cliPath, args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	
-	// Run command
-	err := cmd.Run()
-	if err != nil {
-		return "", fmt.Errorf("DNS CLI error: %v - %s", err, stderr.String())
-	}
-	
-	return stdout.String(), nil
-}
-
-// GetDNSRewrites fetches all DNS rewrites from AdGuard
-func (m *DNSManager) GetDNSRewrites() ([]DNSRewrite, error) {
-	// Try CLI first
-	output, err := m.runCLICommand("list")
-	if err == nil {
-		// Parse CLI output
-		return m.parseListOutput(output), nil
-	}
-	
-	// Fall back to API
-	m.logger.WithError(err).Warn("Failed to get DNS rewrites via CLI, falling back to API")
-	
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-	url := fmt.Sprintf("%s://%s/control/rewrite/list", m.protocol, m.host)
-	
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	
-	req.Header.Add("Authorization", m.createAuthHeader())
-	
-	resp, err := client.Do(req)
+// StaleDNSRecords lists the .docker records of the DNS provider that belong to no instance,
+// or to an instance whose container has another IP address. Only providers of LAN rewrites
+// can list their records.
+func (m *DockerManager) StaleDNSRecords(ctx context.Context) ([]DNSRecord, error) {
+	lister, ok := m.dns.(interface {
+		ListRecords(ctx context.Context) ([]DNSRecord, error)
+	})
+	if !ok {
+		return nil, ErrDNSListingUnsupported
+	}
+	records, err := lister.ListRecords(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	
-	body, err := io.ReadAll(resp.Body)
+	instances, err := db.GetAllInstances()
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
-	}
-	
-	var rewrites []DNSRewrite
-	err = json.Unmarshal(body, &rewrites)
-	if err != nil {
-		return nil, err
-	}
-	
-	return rewrites, nil
-}
-
-// parseListOutput parses the output of the list command
-func (m *DNSManager) parseListOutput(output string) []DNSRewrite {
-	var rewrites []DNSRewrite
-	lines := strings.Split(output, "\n")
-	
-	// Skip header lines
-	startParsing := false
-	for _, line := range lines {
-		if strings.Contains(line, "-----") {
-			startParsing = true
-			continue
+	expected := map[string]string{}
+	for i := range instances {
+		if instances[i].Status != models.StatusDeleted && instances[i].Host != "" {
+			record := m.instanceDNSRecord(&instances[i], instances[i].IPAddress)
+			expected[record.Name] = record.Answer
 		}
-		
-		if !startParsing || strings.TrimSpace(line) == "" {
+	}
+	stale := []DNSRecord{}
+	for _, record := range records {
+		if !strings.HasSuffix(record.Name, ".docker") {
 			continue
 		}
-		
-		parts := strings.Split(line, "->")
-		if len(parts) != 2 {
-			continue
+		if answer, ok := expected[record.Name]; !ok || (answer != "" && answer != record.Answer) {
+			stale = append(stale, record)
 		}
-		
-		domain := strings.TrimSpace(parts[0])
-		answer := strings.TrimSpace(parts[1])
-		
-		rewrites = append(rewrites, DNSRewrite{
-			Domain: domain,
-			Answer: answer,
-		})
 	}
-	
-	return rewrites
+	return stale, nil
 }
 
-// FindDNSRewrite finds a specific DNS rewrite by domain
-func (m *DNSManager) FindDNSRewrite(domain string) (*DNSRewrite, error) {
-	// Try CLI first
-	output, err := m.runCLICommand("get", "-domain", domain)
-	if err == nil {
-		// Parse CLI output
-		parts := strings.Split(output, "->")
-		if len(parts) == 2 {
-			return &DNSRewrite{
-				Domain: strings.TrimSpace(parts[0]),
-				Answer: strings.TrimSpace(parts[1]),
-			}, nil
-		}
+// dnsRecordType returns the record type of an answer: A or AAAA for IP addresses, otherwise CNAME
+func dnsRecordType(answer string) string {
+	ip := net.ParseIP(answer)
+	switch {
+	case ip == nil:
+		return "CNAME"
+	case ip.To4() == nil:
+		return "AAAA"
+	default:
+		return "A"
 	}
-	
-	// Fall back to API
-	m.logger.WithError(err).Warn("Failed to find DNS rewrite via CLI, falling back to API")
-	
-	rewrites, err := m.GetDNSRewrites()
-	if err != nil {
-		return nil, err
-	}
-	
-	for _, rewrite := range rewrites {
-		if rewrite.Domain == domain {
-			return &rewrite, nil
-		}
-	}
-	
-	return nil, fmt.Errorf("DNS rewrite for domain %s not found", domain)
 }
 
-// AddDNSRewrite adds a DNS rewrite to AdGuard
-func (m *DNSManager) AddDNSRewrite(domain, answer string) error {
-	m.logger.WithFields(logrus.Fields{
-		"domain": domain,
-		"answer": answer,
-	}).Info("Adding DNS rewrite")
-
-	// Try CLI first
-	_, err := m.runCLICommand("add", "-domain", domain, "-answer", answer)
-	if err == nil {
-		return nil
-	}
-	
-	// Fall back to API
-	m.logger.WithError(err).Warn("Failed to add DNS rewrite via CLI, falling back to API")
+// noDNS is the provider of deployments that manage DNS themselves
+type noDNS struct{}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-	url := fmt.Sprintf("%s://%s/control/rewrite/add", m.protocol, m.host)
-	
-	rewrite := DNSRewrite{
-		Domain: domain,
-		Answer: answer,
-	}
-	
-	jsonData, err := json.Marshal(rewrite)
-	if err != nil {
-		return err
-	}
-	
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Add("Authorization", m.createAuthHeader())
-	
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to add DNS rewrite: %s - %s", resp.Status, string(body))
-	}
-	
-	return nil
-}
+// Name identifies the provider
+func (noDNS) Name() string { return "none" }
 
-// DeleteDNSRewrite removes a DNS rewrite from AdGuard
-func (m *DNSManager) DeleteDNSRewrite(domain string) error {
-	m.logger.WithField("domain", domain).Info("Deleting DNS rewrite")
+// Public reports false, so records keep pointing at containers
+func (noDNS) Public() bool { return false }
 
-	// Try CLI first - this approach is more reliable
-	_, err := m.runCLICommand("delete", "-domain", domain)
-	if err == nil {
-		m.logger.WithField("domain", domain).Info("Successfully deleted DNS record via CLI")
-		return nil
-	}
-	
-	// Fall back to API with improved approach
-	m.logger.WithError(err).Warn("Failed to delete DNS rewrite via CLI, falling back to API")
+// FindRecord reports every record as missing
+func (noDNS) FindRecord(ctx context.Context, name string) (*DNSRecord, error) {
+	return nil, ErrDNSRecordNotFound
+}
 
-	// First, get the current value to ensure we have the right answer field
-	existingRewrite, err := m.FindDNSRewrite(domain)
-	if err != nil {
-		m.logger.WithError(err).Warn("Cannot find DNS rewrite before deletion")
-		// Continue with deletion attempt even if we can't find the record
-	}
+// SetRecord does nothing
+func (noDNS) SetRecord(ctx context.Context, name, answer string) error { return nil }
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-	url := fmt.Sprintf("%s://%s/control/rewrite/delete", m.protocol, m.host)
-	
-	var jsonData []byte
-	if existingRewrite != nil {
-		// Use the existing rewrite's domain and answer values
-		rewrite := DNSRewrite{
-			Domain: domain,
-			Answer: existingRewrite.Answer,
-		}
-		
-		m.logger.WithFields(logrus.Fields{
-			"domain": domain,
-			"answer": existingRewrite.Answer,
-		}).Info("Deleting DNS rewrite with full details")
-		
-		jsonData, err = json.Marshal(rewrite)
-		if err != nil {
-			return err
-		}
-	} else {
-		// Fallback to just using the domain
-		data := map[string]string{
-			"domain": domain,
-		}
-		
-		jsonData, err = json.Marshal(data)
-		if err != nil {
-			return err
-		}
-	}
-	
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Add("Authorization", m.createAuthHeader())
-	
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	
-	body, _ := io.ReadAll(resp.Body)
-	
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to delete DNS rewrite: %s - %s", resp.Status, string(body))
-	}
-	
-	// Add a delay after deletion to allow the API to process
-	time.Sleep(1 * time.Second)
-	
-	// Verify deletion
-	verifyRewrites, err := m.GetDNSRewrites()
-	if err != nil {
-		m.logger.WithError(err).Warn("Failed to verify DNS record deletion")
-		return nil // Continue anyway, don't fail because of verification error
-	}
-	
-	recordStillExists := false
-	for _, rewrite := range verifyRewrites {
-		if rewrite.Domain == domain {
-			recordStillExists = true
-			break
-		}
-	}
-	
-	if recordStillExists {
-		// Record still exists, try one more time with alternative method
-		m.logger.Warn("DNS record still exists after deletion attempt, trying alternative method")
-		
-		// Try again with a different payload structure
-		alternativeData := map[string]string{
-			"domain": domain,
-		}
-		
-		altJsonData, _ := json.Marshal(alternativeData)
-		altReq, _ := http.NewRequest("POST", url, bytes.NewBuffer(altJsonData))
-		altReq.Header.Set("Content-Type", "application/json")
-		altReq.Header.Add("Authorization", m.createAuthHeader())
-		
-		altResp, altErr := client.Do(altReq)
-		if altErr != nil {
-			m.logger.WithError(altErr).Warn("Alternative deletion method failed")
-			return nil // Continue anyway, don't fail
-		}
-		defer altResp.Body.Close()
-		
-		// Wait a bit longer after the second attempt
-		time.Sleep(2 * time.Second)
-		
-		// Check once more
-		finalRewrites, finalErr := m.GetDNSRewrites()
-		if finalErr != nil {
-			m.logger.WithError(finalErr).Warn("Failed to verify final DNS record deletion")
-			return nil // Continue anyway
-		}
-		
-		for _, finalRewrite := range finalRewrites {
-			if finalRewrite.Domain == domain {
-				m.logger.WithField("domain", domain).Warn(
-					"Failed to delete DNS record after multiple attempts. Manual cleanup may be required.",
-				)
-				return nil // Continue anyway, but log the warning
-			}
-		}
-		
-		m.logger.WithField("domain", domain).Info("DNS record deleted successfully on second attempt")
-	} else {
-		m.logger.WithField("domain", domain).Info("DNS record deleted successfully")
-	}
-	
-	return nil
-} 
\ No newline at end of file
+// DeleteRecord does nothing
+func (noDNS) DeleteRecord(ctx context.Context, name string) error { return nil }
@@ -0,0 +1,424 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/sirupsen/logrus"
+)
+
+// adGuardRewrite represents a DNS rewrite rule in AdGuard
+type adGuardRewrite struct {
+	Domain string `json:"domain"`
+	Answer string `json:"answer"`
+}
+
+// AdGuardDNS manages DNS rewrites in AdGuard Home, resolving instances' .docker names on
+// the LAN. It uses the DNS CLI tool when present and AdGuard's API otherwise.
+type AdGuardDNS struct {
+	logger   *logrus.Logger
+	host     string
+	username string
+	password string
+	protocol string
+	cliPath  string
+}
+
+// NewAdGuardDNS creates an AdGuard DNS provider with the ADGUARD_* credentials
+func NewAdGuardDNS(cfg *config.Config, logger *logrus.Logger) *AdGuardDNS {
+	if cfg.DNS.AdGuard.Host == "" || cfg.DNS.AdGuard.Username == "" || cfg.DNS.AdGuard.Password == "" {
+		logger.Error("ADGUARD_HOST, ADGUARD_USERNAME, and ADGUARD_PASSWORD environment variables must be set")
+	}
+
+	return &AdGuardDNS{
+		logger:   logger,
+		host:     cfg.DNS.AdGuard.Host,
+		username: cfg.DNS.AdGuard.Username,
+		password: cfg.DNS.AdGuard.Password,
+		protocol: cfg.DNS.AdGuard.Protocol,
+		cliPath:  cfg.DNS.AdGuard.CLIPath,
+	}
+}
+
+// Name identifies AdGuard
+func (m *AdGuardDNS) Name() string {
+	return "adguard"
+}
+
+// Public reports false: rewrites only resolve on the LAN
+func (m *AdGuardDNS) Public() bool {
+	return false
+}
+
+// createAuthHeader creates the authorization header for AdGuard requests
+func (m *AdGuardDNS) createAuthHeader() string {
+	auth := m.username + ":" + m.password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+}
+
+// runCLICommand runs the DNS CLI tool with the given arguments. The tool reads the AdGuard
+// credentials from the ADGUARD_* variables it inherits.
+func (m *AdGuardDNS) runCLICommand(ctx context.Context, args ...string) (string, error) {
+	// Check if CLI tool exists
+	if _, err := os.Stat(m.cliPath); os.IsNotExist(err) {
+		// Fall back to API if CLI tool doesn't exist
+		m.logger.Warn("DNS CLI tool not found, falling back to API methods")
+		return "", fmt.Errorf("DNS CLI tool not found at %s", m.cliPath)
+	}
+	
+	// Prepare command
+	cmd := exec.CommandContext(ctx, m.cliPath, args...)
+	cmd.Env = append(os.Environ(),
+		"ADGUARD_HOST="+m.host,
+		"ADGUARD_USERNAME="+m.username,
+		"ADGUARD_PASSWORD="+m.password,
+		"ADGUARD_PROTOCOL="+m.protocol,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	
+	// Run command
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("DNS CLI error: %v - %s", err, stderr.String())
+	}
+	
+	return stdout.String(), nil
+}
+
+// ListRecords fetches all DNS rewrites from AdGuard
+func (m *AdGuardDNS) ListRecords(ctx context.Context) ([]DNSRecord, error) {
+	// Try CLI first
+	output, err := m.runCLICommand(ctx, "list")
+	if err == nil {
+		// Parse CLI output
+		return m.parseListOutput(output), nil
+	}
+	
+	// Fall back to API
+	m.logger.WithError(err).Warn("Failed to get DNS rewrites via CLI, falling back to API")
+	
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	url := fmt.Sprintf("%s://%s/control/rewrite/list", m.protocol, m.host)
+	
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	
+	req.Header.Add("Authorization", m.createAuthHeader())
+	
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+	}
+	
+	var rewrites []adGuardRewrite
+	err = json.Unmarshal(body, &rewrites)
+	if err != nil {
+		return nil, err
+	}
+	
+	records := make([]DNSRecord, len(rewrites))
+	for i, rewrite := range rewrites {
+		records[i] = DNSRecord{Name: rewrite.Domain, Answer: rewrite.Answer}
+	}
+	return records, nil
+}
+
+// parseListOutput parses the output of the list command
+func (m *AdGuardDNS) parseListOutput(output string) []DNSRecord {
+	var rewrites []DNSRecord
+	lines := strings.Split(output, "\n")
+	
+	// Skip header lines
+	startParsing := false
+	for _, line := range lines {
+		if strings.Contains(line, "-----") {
+			startParsing = true
+			continue
+		}
+		
+		if !startParsing || strings.TrimSpace(line) == "" {
+			continue
+		}
+		
+		parts := strings.Split(line, "->")
+		if len(parts) != 2 {
+			continue
+		}
+		
+		domain := strings.TrimSpace(parts[0])
+		answer := strings.TrimSpace(parts[1])
+		
+		rewrites = append(rewrites, DNSRecord{
+			Name:   domain,
+			Answer: answer,
+		})
+	}
+	
+	return rewrites
+}
+
+// FindRecord finds a specific DNS rewrite by domain
+func (m *AdGuardDNS) FindRecord(ctx context.Context, domain string) (*DNSRecord, error) {
+	// Try CLI first
+	output, err := m.runCLICommand(ctx, "get", "-domain", domain)
+	if err == nil {
+		// Parse CLI output
+		parts := strings.Split(output, "->")
+		if len(parts) == 2 {
+			return &DNSRecord{
+				Name:   strings.TrimSpace(parts[0]),
+				Answer: strings.TrimSpace(parts[1]),
+			}, nil
+		}
+	}
+	
+	// Fall back to API
+	m.logger.WithError(err).Warn("Failed to find DNS rewrite via CLI, falling back to API")
+	
+	rewrites, err := m.ListRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
+	for _, rewrite := range rewrites {
+		if rewrite.Name == domain {
+			return &rewrite, nil
+		}
+	}
+	
+	return nil, fmt.Errorf("DNS rewrite for domain %s: %w", domain, ErrDNSRecordNotFound)
+}
+
+// SetRecord points a domain at answer, replacing a rewrite of the domain to another answer
+func (m *AdGuardDNS) SetRecord(ctx context.Context, domain, answer string) error {
+	existing, err := m.FindRecord(ctx, domain)
+	if err == nil {
+		if existing.Answer == answer {
+			return nil
+		}
+		if err := m.DeleteRecord(ctx, domain); err != nil {
+			return err
+		}
+	}
+	return m.addRewrite(ctx, domain, answer)
+}
+
+// addRewrite adds a DNS rewrite to AdGuard
+func (m *AdGuardDNS) addRewrite(ctx context.Context, domain, answer string) error {
+	m.logger.WithFields(logrus.Fields{
+		"domain": domain,
+		"answer": answer,
+	}).Info("Adding DNS rewrite")
+
+	// Try CLI first
+	_, err := m.runCLICommand(ctx, "add", "-domain", domain, "-answer", answer)
+	if err == nil {
+		return nil
+	}
+	
+	// Fall back to API
+	m.logger.WithError(err).Warn("Failed to add DNS rewrite via CLI, falling back to API")
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	url := fmt.Sprintf("%s://%s/control/rewrite/add", m.protocol, m.host)
+	
+	rewrite := adGuardRewrite{
+		Domain: domain,
+		Answer: answer,
+	}
+	
+	jsonData, err := json.Marshal(rewrite)
+	if err != nil {
+		return err
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", m.createAuthHeader())
+	
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to add DNS rewrite: %s - %s", resp.Status, string(body))
+	}
+	
+	return nil
+}
+
+// DeleteRecord removes a DNS rewrite from AdGuard
+func (m *AdGuardDNS) DeleteRecord(ctx context.Context, domain string) error {
+	m.logger.WithField("domain", domain).Info("Deleting DNS rewrite")
+
+	// Try CLI first - this approach is more reliable
+	_, err := m.runCLICommand(ctx, "delete", "-domain", domain)
+	if err == nil {
+		m.logger.WithField("domain", domain).Info("Successfully deleted DNS record via CLI")
+		return nil
+	}
+	
+	// Fall back to API with improved approach
+	m.logger.WithError(err).Warn("Failed to delete DNS rewrite via CLI, falling back to API")
+
+	// First, get the current value to ensure we have the right answer field
+	existingRewrite, err := m.FindRecord(ctx, domain)
+	if err != nil {
+		m.logger.WithError(err).Warn("Cannot find DNS rewrite before deletion")
+		// Continue with deletion attempt even if we can't find the record
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	url := fmt.Sprintf("%s://%s/control/rewrite/delete", m.protocol, m.host)
+	
+	var jsonData []byte
+	if existingRewrite != nil {
+		// Use the existing rewrite's domain and answer values
+		rewrite := adGuardRewrite{
+			Domain: domain,
+			Answer: existingRewrite.Answer,
+		}
+		
+		m.logger.WithFields(logrus.Fields{
+			"domain": domain,
+			"answer": existingRewrite.Answer,
+		}).Info("Deleting DNS rewrite with full details")
+		
+		jsonData, err = json.Marshal(rewrite)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Fallback to just using the domain
+		data := map[string]string{
+			"domain": domain,
+		}
+		
+		jsonData, err = json.Marshal(data)
+		if err != nil {
+			return err
+		}
+	}
+	
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", m.createAuthHeader())
+	
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	body, _ := io.ReadAll(resp.Body)
+	
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete DNS rewrite: %s - %s", resp.Status, string(body))
+	}
+	
+	// Add a delay after deletion to allow the API to process
+	time.Sleep(1 * time.Second)
+	
+	// Verify deletion
+	verifyRewrites, err := m.ListRecords(ctx)
+	if err != nil {
+		m.logger.WithError(err).Warn("Failed to verify DNS record deletion")
+		return nil // Continue anyway, don't fail because of verification error
+	}
+	
+	recordStillExists := false
+	for _, rewrite := range verifyRewrites {
+		if rewrite.Name == domain {
+			recordStillExists = true
+			break
+		}
+	}
+	
+	if recordStillExists {
+		// Record still exists, try one more time with alternative method
+		m.logger.Warn("DNS record still exists after deletion attempt, trying alternative method")
+		
+		// Try again with a different payload structure
+		alternativeData := map[string]string{
+			"domain": domain,
+		}
+		
+		altJsonData, _ := json.Marshal(alternativeData)
+		altReq, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(altJsonData))
+		altReq.Header.Set("Content-Type", "application/json")
+		altReq.Header.Add("Authorization", m.createAuthHeader())
+		
+		altResp, altErr := client.Do(altReq)
+		if altErr != nil {
+			m.logger.WithError(altErr).Warn("Alternative deletion method failed")
+			return nil // Continue anyway, don't fail
+		}
+		defer altResp.Body.Close()
+		
+		// Wait a bit longer after the second attempt
+		time.Sleep(2 * time.Second)
+		
+		// Check once more
+		finalRewrites, finalErr := m.ListRecords(ctx)
+		if finalErr != nil {
+			m.logger.WithError(finalErr).Warn("Failed to verify final DNS record deletion")
+			return nil // Continue anyway
+		}
+		
+		for _, finalRewrite := range finalRewrites {
+			if finalRewrite.Name == domain {
+				m.logger.WithField("domain", domain).Warn(
+					"Failed to delete DNS record after multiple attempts. Manual cleanup may be required.",
+				)
+				return nil // Continue anyway, but log the warning
+			}
+		}
+		
+		m.logger.WithField("domain", domain).Info("DNS record deleted successfully on second attempt")
+	} else {
+		m.logger.WithField("domain", domain).Info("DNS record deleted successfully")
+	}
+	
+	return nil
+} 
\ No newline at end of file
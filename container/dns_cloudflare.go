@@ -0,0 +1,172 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/sirupsen/logrus"
+)
+
+// cloudflareAPI is the base URL of Cloudflare's v4 API
+const cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+// CloudflareDNS manages public DNS records in a Cloudflare zone, authenticated with an API
+// token allowed to edit the zone's DNS
+type CloudflareDNS struct {
+	logger   *logrus.Logger
+	client   *http.Client
+	apiToken string
+	zoneID   string
+	ttl      int
+}
+
+// cloudflareRecord is a DNS record of Cloudflare's API
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+// cloudflareResponse is the envelope of Cloudflare's API responses
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+// NewCloudflareDNS creates a Cloudflare DNS provider for CLOUDFLARE_ZONE_ID
+func NewCloudflareDNS(cfg *config.Config, logger *logrus.Logger) *CloudflareDNS {
+	return &CloudflareDNS{
+		logger:   logger,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		apiToken: cfg.DNS.Cloudflare.APIToken,
+		zoneID:   cfg.DNS.Cloudflare.ZoneID,
+		ttl:      cfg.DNS.TTL,
+	}
+}
+
+// Name identifies Cloudflare
+func (p *CloudflareDNS) Name() string {
+	return "cloudflare"
+}
+
+// Public reports true: records are public
+func (p *CloudflareDNS) Public() bool {
+	return true
+}
+
+// FindRecord returns the record of a name
+func (p *CloudflareDNS) FindRecord(ctx context.Context, name string) (*DNSRecord, error) {
+	record, err := p.find(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &DNSRecord{Name: record.Name, Answer: record.Content}, nil
+}
+
+// SetRecord creates the record of a name or updates the existing one. Records are not
+// proxied, so requests reach the platform's proxy with their client's address.
+func (p *CloudflareDNS) SetRecord(ctx context.Context, name, answer string) error {
+	record := cloudflareRecord{
+		Type:    dnsRecordType(answer),
+		Name:    name,
+		Content: answer,
+		TTL:     p.ttl,
+	}
+
+	existing, err := p.find(ctx, name)
+	switch {
+	case err == nil:
+		if existing.Type == record.Type && existing.Content == answer && existing.TTL == p.ttl {
+			return nil
+		}
+		return p.do(ctx, http.MethodPut, "/dns_records/"+existing.ID, record, nil)
+	case errors.Is(err, ErrDNSRecordNotFound):
+		return p.do(ctx, http.MethodPost, "/dns_records", record, nil)
+	default:
+		return err
+	}
+}
+
+// DeleteRecord removes the record of a name
+func (p *CloudflareDNS) DeleteRecord(ctx context.Context, name string) error {
+	existing, err := p.find(ctx, name)
+	if errors.Is(err, ErrDNSRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.do(ctx, http.MethodDelete, "/dns_records/"+existing.ID, nil, nil)
+}
+
+// find looks up the A, AAAA or CNAME record of a name
+func (p *CloudflareDNS) find(ctx context.Context, name string) (*cloudflareRecord, error) {
+	var records []cloudflareRecord
+	if err := p.do(ctx, http.MethodGet, "/dns_records?name="+url.QueryEscape(name), nil, &records); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		switch record.Type {
+		case "A", "AAAA", "CNAME":
+			return &record, nil
+		}
+	}
+	return nil, ErrDNSRecordNotFound
+}
+
+// do sends a request for a path of the zone and decodes the result into result, if given
+func (p *CloudflareDNS) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cloudflareAPI+"/zones/"+p.zoneID+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cloudflare %s request failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var envelope cloudflareResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&envelope); err != nil {
+		return fmt.Errorf("cloudflare %s request returned status %d", method, resp.StatusCode)
+	}
+	if !envelope.Success {
+		messages := make([]string, len(envelope.Errors))
+		for i, e := range envelope.Errors {
+			messages[i] = fmt.Sprintf("%d: %s", e.Code, e.Message)
+		}
+		return fmt.Errorf("cloudflare %s request returned status %d: %s", method, resp.StatusCode, strings.Join(messages, "; "))
+	}
+	if result != nil {
+		return json.Unmarshal(envelope.Result, result)
+	}
+	return nil
+}
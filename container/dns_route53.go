@@ -0,0 +1,268 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Route53 is a global service signed in us-east-1
+const (
+	route53Host    = "route53.amazonaws.com"
+	route53Region  = "us-east-1"
+	route53Version = "2013-04-01"
+)
+
+// Route53DNS manages public DNS records in a Route 53 hosted zone. Requests are signed with
+// AWS Signature Version 4 using an access key allowed to change the zone's record sets.
+type Route53DNS struct {
+	logger       *logrus.Logger
+	client       *http.Client
+	hostedZoneID string
+	accessKey    string
+	secretKey    string
+	ttl          int
+}
+
+// route53RecordSet is a resource record set of Route 53's API
+type route53RecordSet struct {
+	Name            string   `xml:"Name"`
+	Type            string   `xml:"Type"`
+	TTL             int      `xml:"TTL"`
+	ResourceRecords []string `xml:"ResourceRecords>ResourceRecord>Value"`
+}
+
+// route53Change is a change of a record set
+type route53Change struct {
+	Action            string           `xml:"Action"`
+	ResourceRecordSet route53RecordSet `xml:"ResourceRecordSet"`
+}
+
+// route53ChangeRequest is the body of ChangeResourceRecordSets
+type route53ChangeRequest struct {
+	XMLName xml.Name        `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+}
+
+// route53ListResponse is the response of ListResourceRecordSets
+type route53ListResponse struct {
+	RecordSets []route53RecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+}
+
+// route53Error is the error response of Route 53's API
+type route53Error struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// NewRoute53DNS creates a Route 53 DNS provider for ROUTE53_HOSTED_ZONE_ID
+func NewRoute53DNS(cfg *config.Config, logger *logrus.Logger) *Route53DNS {
+	return &Route53DNS{
+		logger:       logger,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		hostedZoneID: strings.TrimPrefix(cfg.DNS.Route53.HostedZoneID, "/hostedzone/"),
+		accessKey:    cfg.DNS.Route53.AccessKeyID,
+		secretKey:    cfg.DNS.Route53.SecretAccessKey,
+		ttl:          cfg.DNS.TTL,
+	}
+}
+
+// Name identifies Route 53
+func (p *Route53DNS) Name() string {
+	return "route53"
+}
+
+// Public reports true: records are public
+func (p *Route53DNS) Public() bool {
+	return true
+}
+
+// FindRecord returns the record of a name
+func (p *Route53DNS) FindRecord(ctx context.Context, name string) (*DNSRecord, error) {
+	set, err := p.find(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	answer := ""
+	if len(set.ResourceRecords) > 0 {
+		answer = strings.TrimSuffix(set.ResourceRecords[0], ".")
+	}
+	return &DNSRecord{Name: strings.TrimSuffix(set.Name, "."), Answer: answer}, nil
+}
+
+// SetRecord creates the record of a name or replaces the existing one. A record of another
+// type is deleted in the same change, as a CNAME cannot coexist with other records.
+func (p *Route53DNS) SetRecord(ctx context.Context, name, answer string) error {
+	set := route53RecordSet{
+		Name:            fqdn(name),
+		Type:            dnsRecordType(answer),
+		TTL:             p.ttl,
+		ResourceRecords: []string{answer},
+	}
+
+	existing, err := p.find(ctx, name)
+	if err != nil && !errors.Is(err, ErrDNSRecordNotFound) {
+		return err
+	}
+	var changes []route53Change
+	if existing != nil && existing.Type != set.Type {
+		changes = append(changes, route53Change{Action: "DELETE", ResourceRecordSet: *existing})
+	}
+	changes = append(changes, route53Change{Action: "UPSERT", ResourceRecordSet: set})
+	return p.change(ctx, changes)
+}
+
+// DeleteRecord removes the record of a name. Route 53 deletes a record set only when given its
+// current values, so it is looked up first.
+func (p *Route53DNS) DeleteRecord(ctx context.Context, name string) error {
+	existing, err := p.find(ctx, name)
+	if errors.Is(err, ErrDNSRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.change(ctx, []route53Change{{Action: "DELETE", ResourceRecordSet: *existing}})
+}
+
+// find looks up the A, AAAA or CNAME record set of a name. Listing starts at the name, so
+// the first sets returned are the name's own if it has any.
+func (p *Route53DNS) find(ctx context.Context, name string) (*route53RecordSet, error) {
+	query := url.Values{"name": {fqdn(name)}, "maxitems": {"5"}}
+	var list route53ListResponse
+	if err := p.do(ctx, http.MethodGet, "/rrset", query, nil, &list); err != nil {
+		return nil, err
+	}
+	for _, set := range list.RecordSets {
+		if !strings.EqualFold(set.Name, fqdn(name)) {
+			continue
+		}
+		switch set.Type {
+		case "A", "AAAA", "CNAME":
+			return &set, nil
+		}
+	}
+	return nil, ErrDNSRecordNotFound
+}
+
+// change applies a batch of record set changes
+func (p *Route53DNS) change(ctx context.Context, changes []route53Change) error {
+	payload, err := xml.Marshal(route53ChangeRequest{Changes: changes})
+	if err != nil {
+		return err
+	}
+	return p.do(ctx, http.MethodPost, "/rrset", nil, append([]byte(xml.Header), payload...), nil)
+}
+
+// do sends a signed request for a path of the hosted zone and decodes the XML response into
+// result, if given
+func (p *Route53DNS) do(ctx context.Context, method, path string, query url.Values, body []byte, result interface{}) error {
+	target := url.URL{
+		Scheme:   "https",
+		Host:     route53Host,
+		Path:     "/" + route53Version + "/hostedzone/" + p.hostedZoneID + path,
+		RawQuery: canonicalQuery(query),
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+	p.sign(req, body, time.Now().UTC())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("route53 %s request failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr route53Error
+		if xml.Unmarshal(data, &apiErr) == nil && apiErr.Code != "" {
+			return fmt.Errorf("route53 %s request returned status %d: %s: %s", method, resp.StatusCode, apiErr.Code, apiErr.Message)
+		}
+		return fmt.Errorf("route53 %s request returned status %d", method, resp.StatusCode)
+	}
+	if result != nil {
+		return xml.Unmarshal(data, result)
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 authorization header, signing the payload's hash
+func (p *Route53DNS) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + route53Region + "/route53/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+p.secretKey), date)
+	key = hmacSHA256(key, route53Region)
+	key = hmacSHA256(key, "route53")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name, as Signature Version 4 expects
+func canonicalQuery(query url.Values) string {
+	// url.Values.Encode sorts by name but encodes spaces as +
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// fqdn returns a name with the trailing dot Route 53 uses
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
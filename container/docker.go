@@ -3,13 +3,13 @@ package container
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -17,8 +17,9 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
+	"github.com/docker/docker/errdefs"
 	"github.com/google/uuid"
 	"github.com/launchstack/backend/config"
 	"github.com/launchstack/backend/db"
@@ -35,8 +36,32 @@ type DockerClient interface {
 	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
 	ContainerStats(ctx context.Context, containerID string, stream bool) (types.ContainerStats, error)
 	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerLogs(ctx context.Context, containerID string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+	ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error)
+	ContainerExecResize(ctx context.Context, execID string, options types.ResizeOptions) error
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
 	ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error)
+	ImageTag(ctx context.Context, source, target string) error
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error)
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options types.CopyToContainerOptions) error
+	ContainerStatPath(ctx context.Context, containerID, path string) (types.ContainerPathStat, error)
 	NetworkInspect(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error)
+	NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error)
+	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
+	NetworkDisconnect(ctx context.Context, networkID, containerID string, force bool) error
+	NetworkRemove(ctx context.Context, networkID string) error
+	ContainerRename(ctx context.Context, containerID, newContainerName string) error
+	ContainerUpdate(ctx context.Context, containerID string, updateConfig container.UpdateConfig) (container.ContainerUpdateOKBody, error)
+	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error)
+	DiskUsage(ctx context.Context) (types.DiskUsage, error)
+	VolumeCreate(ctx context.Context, options volumetypes.VolumeCreateBody) (types.Volume, error)
+	VolumeInspect(ctx context.Context, volumeID string) (types.Volume, error)
+	VolumeList(ctx context.Context, filter filters.Args) (volumetypes.VolumeListOKBody, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	Info(ctx context.Context) (types.Info, error)
+	Close() error
 }
 
 // DockerClientWrapper wraps the Docker client to implement our interface
@@ -59,21 +84,57 @@ type DockerManager struct {
 	client     DockerClient
 	config     *config.Config
 	logger     *logrus.Logger
-	dnsManager *DNSManager
+	dns        DNSProvider
+	cpuSets    *CPUSetAllocator // nil when CPU pinning is not configured
+	templates  *TemplateRegistry
+	egressApplied sync.Map // container ID -> start time and script of the egress rules last written
 }
 
-// NewDockerClient creates a new Docker client
-func NewDockerClient(host string) (DockerClient, error) {
-	// Always use the Docker API endpoint
-	dockerHost := "http://10.1.1.81:2375"
-	os.Setenv("DOCKER_HOST", dockerHost)
-	
-	c, err := client.NewClientWithOpts(
-		client.WithHost(dockerHost),
+// NewDockerClient creates a Docker client for the configured host and verifies it can connect.
+// The host may be a unix socket (unix:///var/run/docker.sock) or a tcp endpoint
+// (tcp://host:2376), which is secured with TLS when DOCKER_TLS_VERIFY is set.
+func NewDockerClient(cfg *config.Config) (DockerClient, error) {
+	host := cfg.Docker.Host
+	if host == "" {
+		return nil, fmt.Errorf("docker host is not configured")
+	}
+	
+	// Older configs used http:// for plain tcp endpoints
+	if strings.HasPrefix(host, "http://") {
+		host = "tcp://" + strings.TrimPrefix(host, "http://")
+	}
+	
+	hostURL, err := client.ParseHostURL(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", cfg.Docker.Host, err)
+	}
+	proto := hostURL.Scheme
+	if proto != "unix" && proto != "tcp" {
+		return nil, fmt.Errorf("unsupported docker host %q: must use unix:// or tcp://", cfg.Docker.Host)
+	}
+	
+	opts := []client.Opt{
+		client.WithHost(host),
 		client.WithAPIVersionNegotiation(),
-	)
+	}
+	if cfg.Docker.TLSVerify {
+		if proto != "tcp" {
+			return nil, fmt.Errorf("docker TLS is only supported for tcp:// hosts, got %q", cfg.Docker.Host)
+		}
+		opts = append(opts, client.WithTLSClientConfig(cfg.Docker.TLSCACert, cfg.Docker.TLSCert, cfg.Docker.TLSKey))
+	}
+	
+	c, err := client.NewClientWithOpts(opts...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create docker client for %s: %w", cfg.Docker.Host, err)
+	}
+	
+	// Fail fast at startup rather than on the first container operation
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Docker.ConnectTimeout)
+	defer cancel()
+	if _, err := c.Ping(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("cannot connect to docker at %s: %w", cfg.Docker.Host, err)
 	}
 	
 	return &DockerClientWrapper{Client: c}, nil
@@ -81,202 +142,92 @@ func NewDockerClient(host string) (DockerClient, error) {
 
 // NewManager creates a new Docker container manager
 func NewManager(client DockerClient, cfg *config.Config, logger *logrus.Logger) Manager {
-	// Create a DNS manager
-	dnsManager := NewDNSManager(logger)
+	// Create the DNS provider instances are reached by
+	dns := NewDNSProvider(cfg, logger)
+	
+	// Restore the dedicated CPUs already pinned to instances
+	cpuSets := NewCPUSetAllocator(cfg, logger)
+	if cpuSets != nil {
+		pinned, err := db.GetPinnedInstances()
+		if err != nil {
+			logger.WithError(err).Warn("Failed to load pinned instances, dedicated CPUs may be handed out twice")
+		}
+		cpuSets.Load(pinned)
+	}
 	
 	return &DockerManager{
-		client:     client,
-		config:     cfg,
-		logger:     logger,
-		dnsManager: dnsManager,
+		client:       client,
+		config:       cfg,
+		logger:       logger,
+		dns:          dns,
+		cpuSets:      cpuSets,
+		templates:    NewTemplateRegistry(cfg, logger),
 	}
 }
 
+// Templates returns the service templates instances are created from
+func (m *DockerManager) Templates() *TemplateRegistry {
+	return m.templates
+}
+
 // Using shared implementation from shared.go
 
-// generateVolumeNames creates volume names for an instance
+// generateVolumeNames creates the names of the data and files volumes of an n8n instance
 func (m *DockerManager) generateVolumeNames(containerName string) (string, string) {
-	// Create unique volume names based on container name
-	dataVolume := fmt.Sprintf("%s-data", containerName)
-	filesVolume := fmt.Sprintf("%s-files", containerName)
-	return dataVolume, filesVolume
+	return volumeName(containerName, "data"), volumeName(containerName, "files")
 }
 
-// CreateInstance creates a new n8n instance
-func (m *DockerManager) CreateInstance(ctx context.Context, user models.User, instanceReq models.Instance) (*models.Instance, error) {
-	// Check if user has reached their instance limit
-	instancesLimit := user.GetInstancesLimit()
-	if instancesLimit <= 0 {
-		return nil, fmt.Errorf("user has no instance allocation")
-	}
-	
-	// TODO: Check how many instances the user already has
-	
-	// Generate container name and subdomain
-	containerName := GenerateContainerName(user.ID, instanceReq.Name)
-	subdomain := GenerateEasySubdomain(containerName)
-	
-	// Create instance record
-	instance := &models.Instance{
-		UserID:       user.ID,
-		Name:         instanceReq.Name,
-		Description:  instanceReq.Description,
-		Status:       models.StatusPending,
-		Host:         subdomain,
-		URL:          fmt.Sprintf("%s.%s", subdomain, m.config.Server.Domain),
-		CPULimit:     user.GetCPULimit(),
-		MemoryLimit:  user.GetMemoryLimit(),
-		StorageLimit: user.GetStorageLimit(),
-	}
-	
-	// Generate volume names for this container
-	dataVolume, filesVolume := m.generateVolumeNames(containerName)
-	
-	// Set up container memory and CPU limits
-	memoryLimit := int64(instance.MemoryLimit * 1024 * 1024) // Convert MB to bytes
-	// Convert CPU cores to nano CPUs (1 core = 1000000000 nano CPUs)
-	cpuLimit := int64(instance.CPULimit * 1000000000)
-	
-	// Create host config with resource limits
-	hostConfig := &container.HostConfig{
-		RestartPolicy: container.RestartPolicy{
-			Name: "always",
-		},
-		// Use Docker volumes instead of bind mounts
-		Mounts: []mount.Mount{
-			{
-				Type:     mount.TypeVolume,
-				Source:   dataVolume,
-				Target:   "/home/node/.n8n",
-				ReadOnly: false,
-			},
-			{
-				Type:     mount.TypeVolume,
-				Source:   filesVolume,
-				Target:   "/files",
-				ReadOnly: false,
-			},
-		},
-		Resources: container.Resources{
-			Memory:    memoryLimit,
-			NanoCPUs:  cpuLimit,
-		},
-	}
-	
-	// Pull the latest n8n image
-	m.logger.Debug("Pulling the latest n8n image")
-	reader, err := m.client.ImagePull(ctx, m.config.N8N.BaseImage, types.ImagePullOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to pull image: %w", err)
-	}
-	defer reader.Close()
-	io.Copy(io.Discard, reader) // Discard the output
-	
-	// Set up environment variables for the container
-	env := []string{
-		"NODE_ENV=production",
-		fmt.Sprintf("N8N_HOST=%s", instance.URL),
-		"N8N_PROTOCOL=https",
-		fmt.Sprintf("WEBHOOK_URL=https://%s", instance.URL),
-		"N8N_BASIC_AUTH_ACTIVE=true",
-		fmt.Sprintf("N8N_BASIC_AUTH_USER=%s", subdomain),
-		fmt.Sprintf("N8N_BASIC_AUTH_PASSWORD=%s", uuid.New().String()[:8]),
-	}
-	
-	// Create the container
-	m.logger.WithFields(logrus.Fields{
-		"image":      m.config.N8N.BaseImage,
-		"network":    m.config.Docker.Network,
-		"subnet":     m.config.Docker.NetworkSubnet,
-		"memory_mb":  instance.MemoryLimit,
-		"cpu_limit":  instance.CPULimit,
-		"data_volume": dataVolume,
-		"files_volume": filesVolume,
-	}).Debug("Creating Docker container")
+// volumeName returns the name of a template volume of an instance's container
+func volumeName(containerName, volume string) string {
+	return fmt.Sprintf("%s-%s", containerName, volume)
+}
 
-	resp, err := m.client.ContainerCreate(
-		ctx,
-		&container.Config{
-			Image: m.config.N8N.BaseImage,
-			Env:   env,
-			User:  "root", // Run as root to ensure permission for host bind mounts
-			// Expose the default n8n port (5678)
-			ExposedPorts: map[nat.Port]struct{}{
-				nat.Port("5678/tcp"): {},
-			},
-			Labels: map[string]string{
-				"com.launchstack.instance.id":   instance.ID.String(),
-				"com.launchstack.user.id":       user.ID.String(),
-				"com.launchstack.managed":       "true",
-				// Watchtower labels for automatic updates
-				"com.centurylinklabs.watchtower.enable": "true",
-				"com.centurylinklabs.watchtower.stop-signal": "SIGTERM",
-				"com.centurylinklabs.watchtower.timeout": "60s",
-				"com.centurylinklabs.watchtower.cleanup": "true",
-				"com.centurylinklabs.watchtower.lifecycle.pre-update": "touch /tmp/pre-update",
-				"com.centurylinklabs.watchtower.lifecycle.post-update": "touch /tmp/post-update",
-			},
-		},
-		hostConfig,
-		&network.NetworkingConfig{
-			EndpointsConfig: map[string]*network.EndpointSettings{
-				m.config.Docker.Network: {
-					NetworkID: m.config.Docker.Network,
-				},
-			},
-		},
-		nil,
-		containerName,
-	)
-	if err != nil {
-		m.logger.WithError(err).Error("Failed to create container")
-		return nil, fmt.Errorf("failed to create container: %w", err)
-	}
-	
-	// Update container ID in the instance
-	instance.ContainerID = resp.ID
-	m.logger.WithField("container_id", resp.ID).Info("Container created successfully")
-	
-	// Start the container
-	m.logger.WithField("container_id", resp.ID).Debug("Starting container")
-	if err := m.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-		m.logger.WithError(err).Error("Failed to start container")
-		return nil, fmt.Errorf("failed to start container: %w", err)
-	}
-	m.logger.WithField("container_id", resp.ID).Info("Container started successfully")
-	
-	// Get the container's IP address
-	container, err := m.client.ContainerInspect(ctx, resp.ID)
+// volumeRemoveTimeout is how long removing a volume waits for the container using it to be gone
+const volumeRemoveTimeout = 30 * time.Second
+
+// existingInstanceVolumes returns which of the volumes created for a container exist on the Docker daemon
+func (m *DockerManager) existingInstanceVolumes(ctx context.Context, containerName string) ([]string, error) {
+	list, err := m.client.VolumeList(ctx, filters.NewArgs(filters.Arg("name", containerName)))
 	if err != nil {
-		m.logger.WithError(err).Error("Failed to inspect container")
-		return nil, fmt.Errorf("failed to inspect container: %w", err)
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
 	}
-	
-	// Get the container's IP address in the n8n network
-	containerIP := container.NetworkSettings.Networks[m.config.Docker.Network].IPAddress
-	if containerIP == "" {
-		m.logger.Error("Container IP address not found")
-		return nil, fmt.Errorf("container IP address not found")
+
+	// The name filter matches substrings, so other instances' volumes may be listed too
+	suffixes := m.templates.volumeSuffixes()
+	var volumes []string
+	for _, volume := range list.Volumes {
+		suffix := strings.TrimPrefix(volume.Name, containerName+"-")
+		if suffix != volume.Name && suffixes[suffix] {
+			volumes = append(volumes, volume.Name)
+		}
 	}
-	
-	// Create single DNS record for the container: {subdomain}.docker -> Container IP
-	dockerDNS := fmt.Sprintf("%s.docker", subdomain)
-	
-	// Add DNS record to AdGuard
-	if err := m.dnsManager.AddDNSRewrite(dockerDNS, containerIP); err != nil {
-		m.logger.WithError(err).Error("Failed to add DNS record for Docker name")
-		// Non-fatal error, continue
+	return volumes, nil
+}
+
+// removeVolumes removes Docker volumes through the API, retrying while a volume is still in
+// use by a container that is being removed. Volumes that no longer exist are skipped.
+func (m *DockerManager) removeVolumes(ctx context.Context, volumes []string) error {
+	for _, volume := range volumes {
+		deadline := time.Now().Add(volumeRemoveTimeout)
+		for {
+			err := m.client.VolumeRemove(ctx, volume, false)
+			if err == nil || client.IsErrNotFound(err) {
+				break
+			}
+			if !errdefs.IsConflict(err) || time.Now().After(deadline) {
+				return fmt.Errorf("failed to remove volume %s: %w", volume, err)
+			}
+
+			m.logger.WithField("volume", volume).Debug("Volume still in use, retrying removal")
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("failed to remove volume %s: %w", volume, ctx.Err())
+			case <-time.After(time.Second):
+			}
+		}
+		m.logger.WithField("volume", volume).Info("Removed volume")
 	}
-	
-	m.logger.WithFields(logrus.Fields{
-		"domain": dockerDNS,
-		"ip":     containerIP,
-	}).Info("Created DNS record for container")
-	
-	// Update instance status
-	instance.Status = models.StatusRunning
-	
-	return instance, nil
+	return nil
 }
 
 // StopInstance stops an instance
@@ -339,6 +290,15 @@ func (m *DockerManager) StartInstance(ctx context.Context, instanceID uuid.UUID)
 		return fmt.Errorf("failed to start container: %w", err)
 	}
 	
+	// Rules are lost on restart, and an instance must not run without them
+	if _, err := m.restrictEgress(ctx, instance, instance.ContainerID); err != nil {
+		timeout := 30 * time.Second
+		if stopErr := m.client.ContainerStop(ctx, instance.ContainerID, &timeout); stopErr != nil {
+			m.logger.WithError(stopErr).Error("Failed to stop container without egress rules")
+		}
+		return fmt.Errorf("failed to restrict egress: %w", err)
+	}
+	
 	// Update instance status
 	instance.Status = models.StatusRunning
 	if err := db.UpdateInstance(instance); err != nil {
@@ -368,105 +328,68 @@ func (m *DockerManager) DeleteInstance(ctx context.Context, instanceID uuid.UUID
 		"container_id": instance.ContainerID,
 	}).Info("Deleting container")
 	
-	// Determine the container name (needed for volume names)
-	containerName := fmt.Sprintf("n8n-%s", instance.ID.String()[:8])
-	dataVolume, filesVolume := m.generateVolumeNames(containerName)
+	// Find the volumes from the container's mounts or, once a previous attempt removed the
+	// container, from the volumes left with the names they are created with
+	var volumes []string
+	inspect, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	switch {
+	case err == nil:
+		for _, point := range inspect.Mounts {
+			if point.Type == mount.TypeVolume && point.Name != "" {
+				volumes = append(volumes, point.Name)
+			}
+		}
+	case client.IsErrNotFound(err):
+		volumes, err = m.existingInstanceVolumes(ctx, GenerateContainerName(instance.UserID, instance.Name))
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
 	
-	// Remove the container
+	// Remove the container; it is already gone if a previous attempt got this far
 	m.logger.WithField("container_id", instance.ContainerID).Debug("Removing container")
 	err = m.client.ContainerRemove(ctx, instance.ContainerID, types.ContainerRemoveOptions{
-		RemoveVolumes: false, // We'll handle volume cleanup separately
+		RemoveVolumes: false, // Named volumes are removed below
 		Force:         true,
 	})
-	if err != nil {
+	if err != nil && !client.IsErrNotFound(err) {
 		m.logger.WithError(err).Error("Failed to remove container")
 		return fmt.Errorf("failed to remove container: %w", err)
 	}
 	
+	m.egressApplied.Delete(instance.ContainerID)
+	m.releaseCPUSet(instance)
+	m.removeUnusedInstanceNetwork(ctx, instance.UserID)
+	
 	// Remove the Docker volumes
-	m.logger.WithFields(logrus.Fields{
-		"data_volume": dataVolume,
-		"files_volume": filesVolume,
-	}).Debug("Removing Docker volumes")
-	
-	// Use the Docker command line to remove volumes (since the API doesn't expose this directly)
-	// We'll use a separate goroutine to avoid blocking
-	go func() {
-		// Wait a bit for the container to be fully removed
-		time.Sleep(5 * time.Second)
-		
-		// Remove the data volume
-		cmd := exec.Command("docker", "volume", "rm", dataVolume)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			m.logger.WithFields(logrus.Fields{
-				"error": err.Error(),
-				"output": string(output),
-				"volume": dataVolume,
-			}).Warn("Failed to remove data volume")
-		} else {
-			m.logger.WithField("volume", dataVolume).Info("Successfully removed data volume")
-		}
-		
-		// Remove the files volume
-		cmd = exec.Command("docker", "volume", "rm", filesVolume)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			m.logger.WithFields(logrus.Fields{
-				"error": err.Error(),
-				"output": string(output),
-				"volume": filesVolume,
-			}).Warn("Failed to remove files volume")
-		} else {
-			m.logger.WithField("volume", filesVolume).Info("Successfully removed files volume")
-		}
-	}()
+	if err := m.removeVolumes(ctx, volumes); err != nil {
+		return err
+	}
 	
 	// Delete DNS record
-	subdomain := instance.Host
-	dockerDNS := fmt.Sprintf("%s.docker", subdomain)
-	
-	// Check if DNS record exists before attempting to delete
-	records, listErr := m.dnsManager.GetDNSRewrites()
-	if listErr != nil {
-		m.logger.WithError(listErr).Error("Failed to list DNS records before deletion")
+	dnsName := m.instanceDNSRecord(instance, "").Name
+	record, findErr := m.dns.FindRecord(ctx, dnsName)
+	if errors.Is(findErr, ErrDNSRecordNotFound) {
+		m.logger.WithField("dns_record", dnsName).Warn("DNS record not found before deletion attempt")
+	} else if findErr != nil {
+		m.logger.WithError(findErr).WithField("dns_record", dnsName).Error("Failed to look up DNS record before deletion")
 	} else {
-		recordExists := false
-		var recordIP string
-		for _, record := range records {
-			if record.Domain == dockerDNS {
-				recordExists = true
-				recordIP = record.Answer
-				m.logger.WithFields(logrus.Fields{
-					"domain": record.Domain,
-					"ip": record.Answer,
-				}).Info("Found DNS record that will be deleted")
-				break
-			}
-		}
+		m.logger.WithFields(logrus.Fields{
+			"instance_id":  instance.ID,
+			"dns_provider": m.dns.Name(),
+			"dns_record":   record.Name,
+			"answer":       record.Answer,
+		}).Info("Attempting to delete DNS record")
 		
-		if !recordExists {
-			m.logger.WithField("dns_record", dockerDNS).Warn("DNS record not found before deletion attempt")
-		} else {
-			// Log DNS deletion attempt with more details
+		if err := m.dns.DeleteRecord(ctx, dnsName); err != nil {
 			m.logger.WithFields(logrus.Fields{
-				"instance_id": instance.ID,
-				"subdomain": subdomain,
-				"dns_record": dockerDNS,
-				"ip": recordIP,
-			}).Info("Attempting to delete DNS record")
-			
-			// Try to delete the DNS record
-			err := m.dnsManager.DeleteDNSRewrite(dockerDNS)
-			if err != nil {
-				m.logger.WithFields(logrus.Fields{
-					"error": err.Error(),
-					"dns_record": dockerDNS,
-				}).Warn("Failed to delete DNS record via API, but continuing with instance deletion")
-				
-				// Add a TODO note about this in the logs
-				m.logger.Warn("TODO: Manually clean up DNS record or implement a reliable AdGuard DNS API for deletions")
-			} else {
-				m.logger.WithField("dns_record", dockerDNS).Info("Successfully deleted DNS record")
-			}
+				"error": err.Error(),
+				"dns_record": dnsName,
+			}).Warn("Failed to delete DNS record, but continuing with instance deletion")
+		} else {
+			m.logger.WithField("dns_record", dnsName).Info("Successfully deleted DNS record")
 		}
 	}
 	
@@ -514,6 +437,57 @@ func (m *DockerManager) GetInstanceStats(ctx context.Context, instanceID uuid.UU
 		return nil, fmt.Errorf("failed to decode stats: %w", err)
 	}
 	
+	usage := m.usageFromStats(instance, &statsJSON)
+	
+	// Save the stats to the database
+	if err := db.CreateResourceUsage(usage); err != nil {
+		m.logger.WithError(err).Warn("Failed to save resource usage to database")
+		// Still return the stats even if saving fails
+	}
+	
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"cpu_usage":   fmt.Sprintf("%.2f%%", usage.CPUUsage),
+		"memory_usage": fmt.Sprintf("%.2f MB / %.2f MB (%.2f%%)", 
+			float64(usage.MemoryUsage)/(1024*1024), 
+			float64(usage.MemoryLimit)/(1024*1024),
+			usage.MemoryPercentage),
+	}).Debug("Container stats collected successfully")
+	
+	return usage, nil
+}
+
+// StreamInstanceStats streams the resource usage of an instance's container, about once a
+// second, until the context is cancelled or the container stops. Samples are not saved.
+func (m *DockerManager) StreamInstanceStats(ctx context.Context, instance *models.Instance, onSample func(*models.ResourceUsage)) error {
+	if instance.ContainerID == "" {
+		return fmt.Errorf("instance has no container ID")
+	}
+	
+	stats, err := m.client.ContainerStats(ctx, instance.ContainerID, true)
+	if err != nil {
+		return fmt.Errorf("failed to stream container stats: %w", err)
+	}
+	defer stats.Body.Close()
+	
+	decoder := json.NewDecoder(stats.Body)
+	for {
+		var statsJSON types.StatsJSON
+		if err := decoder.Decode(&statsJSON); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to decode stats: %w", err)
+		}
+		onSample(m.usageFromStats(instance, &statsJSON))
+	}
+}
+
+// usageFromStats turns a Docker stats sample of an instance's container into a resource usage record
+func (m *DockerManager) usageFromStats(instance *models.Instance, statsJSON *types.StatsJSON) *models.ResourceUsage {
 	// Calculate CPU usage percentage
 	// Improved CPU calculation based on Docker stats API
 	var cpuUsage float64
@@ -583,27 +557,64 @@ func (m *DockerManager) GetInstanceStats(ctx context.Context, instanceID uuid.UU
 		MemoryUsage:     int64(memoryUsage),
 		MemoryLimit:     int64(memoryLimit),
 		MemoryPercentage: memoryPercentage,
-		DiskUsage:       0, // Not tracking disk usage as requested
+		DiskUsage:       instance.StorageUsage, // Measured by the storage monitor
 		NetworkIn:       networkIn,
 		NetworkOut:      networkOut,
 	}
 	
-	// Save the stats to the database
-	if err := db.CreateResourceUsage(usage); err != nil {
-		m.logger.WithError(err).Warn("Failed to save resource usage to database")
-		// Still return the stats even if saving fails
+	return usage
+}
+
+// GetStorageUsage measures the disk space used by an instance's Docker volumes
+func (m *DockerManager) GetStorageUsage(ctx context.Context, instanceID uuid.UUID) (int64, error) {
+	// Get the instance from the database
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get instance: %w", err)
+	}
+	
+	// Make sure we have a container ID
+	if instance.ContainerID == "" {
+		return 0, fmt.Errorf("instance has no container ID")
+	}
+	
+	// Find the volumes mounted into the container
+	info, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	
+	volumes := make(map[string]bool)
+	for _, mnt := range info.Mounts {
+		if mnt.Type == mount.TypeVolume {
+			volumes[mnt.Name] = true
+		}
+	}
+	
+	// The system df endpoint is the only API that reports volume sizes
+	usage, err := m.client.DiskUsage(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+	
+	var total int64
+	for _, vol := range usage.Volumes {
+		if vol == nil || !volumes[vol.Name] || vol.UsageData == nil {
+			continue
+		}
+		// Size is -1 when the volume driver does not report usage
+		if vol.UsageData.Size > 0 {
+			total += vol.UsageData.Size
+		}
 	}
 	
 	m.logger.WithFields(logrus.Fields{
 		"instance_id": instance.ID,
-		"cpu_usage":   fmt.Sprintf("%.2f%%", cpuUsage),
-		"memory_usage": fmt.Sprintf("%.2f MB / %.2f MB (%.2f%%)", 
-			float64(memoryUsage)/(1024*1024), 
-			float64(memoryLimit)/(1024*1024),
-			memoryPercentage),
-	}).Debug("Container stats collected successfully")
+		"volumes":     len(volumes),
+		"bytes":       total,
+	}).Debug("Measured instance storage usage")
 	
-	return usage, nil
+	return total, nil
 }
 
 // getVolumeSizeFromAPI gets the volume size using Docker API directly
@@ -671,20 +682,8 @@ func (m *DockerManager) getVolumeSize(volumeName string) int64 {
 	// Use Docker API to inspect the volume first
 	ctx := context.Background()
 	
-	// Create a new Docker client with the same host as the main client
-	// This is a workaround since our DockerClient interface doesn't expose VolumeInspect
-	cli, err := client.NewClientWithOpts(
-		client.WithHost(m.config.Docker.Host),
-		client.WithAPIVersionNegotiation(),
-	)
-	if err != nil {
-		m.logger.WithError(err).Warn("Failed to create Docker client for volume inspection")
-		return estimateVolumeSize(volumeName)
-	}
-	defer cli.Close()
-	
 	// Inspect the volume
-	vol, err := cli.VolumeInspect(ctx, volumeName)
+	vol, err := m.client.VolumeInspect(ctx, volumeName)
 	if err != nil {
 		m.logger.WithFields(logrus.Fields{
 			"volume": volumeName,
@@ -711,7 +710,7 @@ func (m *DockerManager) getVolumeSize(volumeName string) int64 {
 	
 	// Try executing the "du" command inside the container that uses this volume
 	// This requires finding containers that use this volume
-	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	containers, err := m.client.ContainerList(ctx, types.ContainerListOptions{All: true})
 	if err != nil {
 		m.logger.WithError(err).Warn("Failed to list containers for volume size calculation")
 		return estimateVolumeSize(volumeName)
@@ -720,7 +719,7 @@ func (m *DockerManager) getVolumeSize(volumeName string) int64 {
 	// Find containers that use this volume
 	for _, container := range containers {
 		// Get container details
-		info, err := cli.ContainerInspect(ctx, container.ID)
+		info, err := m.client.ContainerInspect(ctx, container.ID)
 		if err != nil {
 			continue
 		}
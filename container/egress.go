@@ -0,0 +1,320 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// egressChain is the iptables chain holding the egress rules in an instance's network namespace
+const egressChain = "LAUNCHSTACK_EGRESS"
+
+// privateRanges are denied to instances of plans without private network access: the RFC 1918
+// ranges, carrier-grade NAT, and link-local, which holds the metadata services of clouds
+var privateRanges = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "169.254.0.0/16"}
+
+// egressRules is what an instance container may reach, as IPv4 CIDRs. Replies on connections
+// made to the container, e.g. by the reverse proxy, are always allowed.
+type egressRules struct {
+	Blocked []string // denied whatever else allows them: the platform's hosts, network gateways and networks shared with other users
+	Local   []string // the network of the owner's instances, with per-user networks
+	Allowed []string // configured exceptions to Denied
+	Denied  []string
+}
+
+// script returns the shell script that replaces the egress chain of a network namespace with
+// the rules and hooks it into OUTPUT
+func (r egressRules) script() string {
+	lines := []string{
+		"set -e",
+		fmt.Sprintf("iptables -N %[1]s 2>/dev/null || iptables -F %[1]s", egressChain),
+		fmt.Sprintf("iptables -C OUTPUT -j %[1]s 2>/dev/null || iptables -I OUTPUT 1 -j %[1]s", egressChain),
+		fmt.Sprintf("iptables -A %s -m conntrack --ctstate ESTABLISHED,RELATED -j RETURN", egressChain),
+	}
+	add := func(cidrs []string, target string) {
+		for _, cidr := range cidrs {
+			lines = append(lines, fmt.Sprintf("iptables -A %s -d %s -j %s", egressChain, cidr, target))
+		}
+	}
+	add(r.Blocked, "REJECT")
+	add(r.Local, "RETURN")
+	add(r.Allowed, "RETURN")
+	add(r.Denied, "REJECT")
+	return strings.Join(lines, "\n")
+}
+
+// egressRulesFor returns the rules of an instance's container. The gateways of its networks are
+// the Docker host itself, so they are blocked like the platform's hosts. Only the network of
+// the owner's instances is allowed, with per-user networks; any other network, e.g. the shared
+// network, holds instances of other users and is blocked even with private network access.
+func (m *DockerManager) egressRulesFor(ctx context.Context, instance *models.Instance, inspected types.ContainerJSON) (egressRules, error) {
+	rules := egressRules{
+		Blocked: append(m.platformHosts(ctx), m.config.Egress.BlockedHosts...),
+		Allowed: m.config.Egress.AllowedCIDRs,
+	}
+	if inspected.NetworkSettings != nil {
+		for name, endpoint := range inspected.NetworkSettings.Networks {
+			if endpoint.Gateway != "" {
+				rules.Blocked = append(rules.Blocked, endpoint.Gateway+"/32")
+			}
+			_, network, err := net.ParseCIDR(fmt.Sprintf("%s/%d", endpoint.IPAddress, endpoint.IPPrefixLen))
+			if err != nil {
+				continue
+			}
+			if m.config.Docker.NetworkMode == NetworkModePerUser && name == m.instanceNetwork(instance.UserID) {
+				rules.Local = append(rules.Local, network.String())
+			} else {
+				rules.Blocked = append(rules.Blocked, network.String())
+			}
+		}
+	}
+
+	user, err := db.GetUserByID(instance.UserID)
+	if err != nil {
+		return rules, fmt.Errorf("failed to get instance owner: %w", err)
+	}
+	if !user.HasFeature(models.FeaturePrivateNetworkAccess) {
+		rules.Denied = privateRanges
+	}
+	return rules, nil
+}
+
+// platformHosts returns the addresses of the Docker API and the database, when they are
+// reached over the network
+func (m *DockerManager) platformHosts(ctx context.Context) []string {
+	var hosts []string
+	if strings.HasPrefix(m.config.Docker.Host, "tcp://") || strings.HasPrefix(m.config.Docker.Host, "http://") {
+		if parsed, err := url.Parse(m.config.Docker.Host); err == nil {
+			hosts = append(hosts, parsed.Hostname())
+		}
+	}
+	if m.config.Database.Driver != "sqlite" {
+		if m.config.Database.URL != "" {
+			if parsed, err := url.Parse(m.config.Database.URL); err == nil {
+				hosts = append(hosts, parsed.Hostname())
+			}
+		} else {
+			hosts = append(hosts, m.config.Database.Host)
+		}
+	}
+
+	var cidrs []string
+	for _, host := range hosts {
+		if host == "" {
+			continue
+		}
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+		if err != nil {
+			m.logger.WithError(err).WithField("host", host).Warn("Failed to resolve platform host for egress rules")
+			continue
+		}
+		for _, ip := range ips {
+			// Loopback inside an instance is the instance itself
+			if !ip.IsLoopback() {
+				cidrs = append(cidrs, ip.String()+"/32")
+			}
+		}
+	}
+	return cidrs
+}
+
+// RestrictEgress writes the egress rules into the network namespace of a running instance's
+// container, unless the container still has the same rules since it last started, and reports
+// whether they were written
+func (m *DockerManager) RestrictEgress(ctx context.Context, instanceID uuid.UUID) (bool, error) {
+	if !m.config.Egress.Restrict {
+		return false, nil
+	}
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return false, nil
+	}
+	return m.restrictEgress(ctx, instance, instance.ContainerID)
+}
+
+// restrictEgress writes the egress rules of an instance into the network namespace of one of
+// its containers. Rules are lost when the container restarts, so what was written is kept by
+// container start time.
+func (m *DockerManager) restrictEgress(ctx context.Context, instance *models.Instance, containerID string) (bool, error) {
+	if !m.config.Egress.Restrict {
+		return false, nil
+	}
+	inspected, err := m.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if inspected.State == nil || !inspected.State.Running {
+		return false, nil
+	}
+
+	rules, err := m.egressRulesFor(ctx, instance, inspected)
+	if err != nil {
+		return false, err
+	}
+	script := rules.script()
+	applied := inspected.State.StartedAt + "\n" + script
+	if previous, ok := m.egressApplied.Load(containerID); ok && previous == applied {
+		return false, nil
+	}
+
+	if err := m.runEgressHelper(ctx, containerID, script); err != nil {
+		return false, err
+	}
+	m.egressApplied.Store(containerID, applied)
+	m.logger.WithFields(logrus.Fields{
+		"instance_id":    instance.ID,
+		"container_id":   containerID,
+		"private_access": rules.Denied == nil,
+	}).Info("Restricted instance egress")
+	return true, nil
+}
+
+// runEgressHelper runs the script in a short-lived helper container that shares the network
+// namespace of the instance's container. Only the helper holds NET_ADMIN, so the instance
+// cannot change its rules.
+func (m *DockerManager) runEgressHelper(ctx context.Context, containerID, script string) error {
+	image := m.config.Egress.HelperImage
+	if err := m.ensureImage(ctx, image); err != nil {
+		return fmt.Errorf("failed to pull egress helper image: %w", err)
+	}
+
+	resp, err := m.client.ContainerCreate(ctx, &container.Config{
+		Image:      image,
+		User:       "root",
+		Entrypoint: []string{"sh", "-c"},
+		Cmd:        []string{script},
+		Labels:     map[string]string{"com.launchstack.egress-helper": containerID},
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + containerID),
+		CapAdd:      []string{"NET_ADMIN"},
+	}, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create egress helper container: %w", err)
+	}
+	defer func() {
+		if err := m.client.ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			m.logger.WithError(err).WithField("container_id", resp.ID).Warn("Failed to remove egress helper container")
+		}
+	}()
+
+	waitCh, errCh := m.client.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := m.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start egress helper container: %w", err)
+	}
+
+	select {
+	case result := <-waitCh:
+		if result.StatusCode != 0 {
+			return fmt.Errorf("writing egress rules failed with exit code %d: %s", result.StatusCode, m.helperOutput(ctx, resp.ID))
+		}
+	case err := <-errCh:
+		return fmt.Errorf("failed to wait for egress helper container: %w", err)
+	}
+	return nil
+}
+
+// helperOutput returns the last output of a helper container, for error messages
+func (m *DockerManager) helperOutput(ctx context.Context, containerID string) string {
+	logs, err := m.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Tail: "5"})
+	if err != nil {
+		return "no output"
+	}
+	defer logs.Close()
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, logs); err != nil {
+		return "no output"
+	}
+	return strings.TrimSpace(output.String())
+}
+
+// EgressEnforcer writes the egress rules of running instances again after their containers
+// restart, e.g. through Docker's restart policy or a restart of the host, which drops them
+type EgressEnforcer struct {
+	manager Manager
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewEgressEnforcer creates a new egress enforcer
+func NewEgressEnforcer(manager Manager, cfg *config.Config, logger *logrus.Logger) *EgressEnforcer {
+	return &EgressEnforcer{
+		manager: manager,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Start enforces the rules right away and then on the configured interval until the context
+// is cancelled. Nothing is done unless egress is restricted.
+func (e *EgressEnforcer) Start(ctx context.Context) {
+	if !e.config.Egress.Restrict {
+		return
+	}
+	interval := e.config.Egress.CheckInterval
+	e.logger.Infof("Starting egress enforcement of instances every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("egress_enforcer", interval)
+	singleton := lease.NewSingleton("egress_enforcer", interval, e.config, e.logger)
+
+	for {
+		if singleton.Acquire() {
+			loop.Run(func() { e.EnforceAll(ctx) })
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnforceAll writes the rules of every running instance whose container lost them. An
+// instance whose rules cannot be written is stopped, as it must not run without them.
+func (e *EgressEnforcer) EnforceAll(ctx context.Context) {
+	instances, err := db.GetInstancesByStatus(models.StatusRunning)
+	if err != nil {
+		e.logger.WithError(err).Error("Failed to fetch instances for egress enforcement")
+		return
+	}
+
+	restricted := 0
+	for _, instance := range instances {
+		restrictCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		ok, err := e.manager.RestrictEgress(restrictCtx, instance.ID)
+		cancel()
+		if err != nil {
+			e.logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to restrict instance egress, stopping the instance")
+			stopCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if stopErr := e.manager.StopInstance(stopCtx, instance.ID); stopErr != nil {
+				e.logger.WithError(stopErr).WithField("instance_id", instance.ID).Error("Failed to stop instance without egress rules")
+			}
+			cancel()
+			continue
+		}
+		if ok {
+			restricted++
+		}
+	}
+	if restricted > 0 {
+		e.logger.WithField("instances", restricted).Info("Restricted egress of instances")
+	}
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/docker/docker/api/types"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+)
+
+// TerminalSize is the size of an exec session's terminal in characters
+type TerminalSize struct {
+	Cols uint
+	Rows uint
+}
+
+// ExecOptions describe a command run in an instance's container and the streams bridged to it
+type ExecOptions struct {
+	Command []string
+	Stdin   io.Reader           // closing it ends the command's input
+	Stdout  io.Writer           // receives the terminal output, with stderr merged in
+	Resize  <-chan TerminalSize // optional; each size resizes the terminal
+	Size    TerminalSize        // initial terminal size, left to Docker when zero
+}
+
+// ExecInstance runs a command in an instance's container with a TTY. The command's input is
+// closed when Stdin ends, and the session is detached when the context is cancelled. The exit
+// code is -1 if the command was still running when the session ended.
+func (m *DockerManager) ExecInstance(ctx context.Context, instanceID uuid.UUID, options ExecOptions) (int, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return -1, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return -1, fmt.Errorf("instance has no container ID")
+	}
+	if len(options.Command) == 0 {
+		return -1, fmt.Errorf("no command to run")
+	}
+
+	exec, err := m.client.ContainerExecCreate(ctx, instance.ContainerID, types.ExecConfig{
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          []string{"TERM=xterm-256color"},
+		Cmd:          options.Command,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to create exec session: %w", err)
+	}
+	attach, err := m.client.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{Tty: true})
+	if err != nil {
+		return -1, fmt.Errorf("failed to attach to exec session: %w", err)
+	}
+	defer attach.Close()
+
+	resize := func(size TerminalSize) {
+		if size.Cols == 0 || size.Rows == 0 {
+			return
+		}
+		if err := m.client.ContainerExecResize(ctx, exec.ID, types.ResizeOptions{Width: size.Cols, Height: size.Rows}); err != nil {
+			m.logger.WithError(err).WithField("instance_id", instanceID).Debug("Failed to resize exec terminal")
+		}
+	}
+	resize(options.Size)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Closing the connection unblocks the output copy below
+			attach.Close()
+		case <-done:
+		}
+	}()
+	if options.Resize != nil {
+		go func() {
+			for {
+				select {
+				case size, ok := <-options.Resize:
+					if !ok {
+						return
+					}
+					resize(size)
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	if options.Stdin != nil {
+		go func() {
+			io.Copy(attach.Conn, options.Stdin)
+			attach.CloseWrite()
+		}()
+	}
+
+	// With a TTY the output arrives as a raw stream rather than multiplexed
+	if _, err := io.Copy(options.Stdout, attach.Reader); err != nil && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+		return -1, fmt.Errorf("failed to copy exec output: %w", err)
+	}
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+
+	inspect, err := m.client.ContainerExecInspect(context.Background(), exec.ID)
+	if err != nil {
+		return -1, fmt.Errorf("failed to inspect exec session: %w", err)
+	}
+	if inspect.Running {
+		return -1, nil
+	}
+	return inspect.ExitCode, nil
+}
@@ -0,0 +1,347 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+)
+
+// filesVolumeName is the template volume the file browser works on
+const filesVolumeName = "files"
+
+// maxFilePathLength is the longest path accepted inside a files volume
+const maxFilePathLength = 1024
+
+var (
+	// ErrFileNotFound is returned for a path that does not exist in the files volume
+	ErrFileNotFound = errors.New("file not found")
+	// ErrInvalidFilePath is returned for a path that is malformed or leads out of the files volume
+	ErrInvalidFilePath = errors.New("path is not inside the instance's files volume")
+	// ErrIsDirectory is returned when reading or replacing a directory as a file
+	ErrIsDirectory = errors.New("path is a directory")
+	// ErrNotDirectory is returned when listing a file as a directory
+	ErrNotDirectory = errors.New("path is not a directory")
+)
+
+// FileEntry describes a file or directory of an instance's files volume
+type FileEntry struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"` // from the root of the volume, e.g. /reports/june.csv
+	Type       string    `json:"type"` // file, directory or symlink
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// CleanFilePath turns a path requested by a user into a path from the root of a files volume,
+// e.g. "reports//../june.csv" into "/june.csv". Traversal cannot leave the root, so the result
+// always stays inside the volume.
+func CleanFilePath(raw string) (string, error) {
+	if strings.ContainsRune(raw, 0) || len(raw) > maxFilePathLength {
+		return "", ErrInvalidFilePath
+	}
+	return path.Clean("/" + raw), nil
+}
+
+// fileType names the type of a file mode
+func fileType(mode os.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	default:
+		return "file"
+	}
+}
+
+// volumeFile is a file requested in an instance's files volume
+type volumeFile struct {
+	containerID string
+	root        string // where the volume is mounted in the container
+	path        string // from the root of the volume
+	fullPath    string // in the container
+}
+
+// resolveFile locates a file in the files volume of an instance's container, or returns
+// ErrServiceUnsupported if the instance's service has no files volume
+func (m *DockerManager) resolveFile(instanceID uuid.UUID, raw string) (*volumeFile, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return nil, fmt.Errorf("instance has no container ID")
+	}
+	file, err := CleanFilePath(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range m.templates.ForInstance(instance).Volumes {
+		if volume.Name == filesVolumeName {
+			return &volumeFile{
+				containerID: instance.ContainerID,
+				root:        volume.Path,
+				path:        file,
+				fullPath:    path.Join(volume.Path, file),
+			}, nil
+		}
+	}
+	return nil, ErrServiceUnsupported
+}
+
+// stat stats a file. Symlinks are only followed within the volume, whether the file or one of
+// its parent directories is the link, so a link cannot expose the rest of the container's
+// filesystem.
+func (m *DockerManager) stat(ctx context.Context, file *volumeFile) (types.ContainerPathStat, error) {
+	if err := m.checkParents(ctx, file); err != nil {
+		return types.ContainerPathStat{}, err
+	}
+	return m.statPath(ctx, file, file.fullPath)
+}
+
+// checkParents refuses a file with a parent directory that is a symlink leading out of the
+// volume. Docker follows the links among the parents of a path silently and only reports the
+// target of its last component, so every parent is stated on its own. Parents that do not
+// exist yet are created as directories by writes.
+func (m *DockerManager) checkParents(ctx context.Context, file *volumeFile) error {
+	parent := file.root
+	for _, name := range strings.Split(strings.Trim(path.Dir(file.path), "/"), "/") {
+		if name == "" {
+			continue
+		}
+		parent = path.Join(parent, name)
+		_, err := m.statPath(ctx, file, parent)
+		if errors.Is(err, ErrFileNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statPath stats a path in the container, refusing a symlink whose target is outside the volume
+func (m *DockerManager) statPath(ctx context.Context, file *volumeFile, fullPath string) (types.ContainerPathStat, error) {
+	stat, err := m.client.ContainerStatPath(ctx, file.containerID, fullPath)
+	if errdefs.IsNotFound(err) {
+		return stat, ErrFileNotFound
+	}
+	if err != nil {
+		return stat, fmt.Errorf("failed to stat %s: %w", fullPath, err)
+	}
+	if stat.LinkTarget != "" && stat.LinkTarget != file.root && !strings.HasPrefix(stat.LinkTarget, file.root+"/") {
+		return stat, ErrInvalidFilePath
+	}
+	return stat, nil
+}
+
+// StatInstanceFile describes a file or directory of an instance's files volume
+func (m *DockerManager) StatInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string) (*FileEntry, error) {
+	file, err := m.resolveFile(instanceID, raw)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := m.stat(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	return &FileEntry{
+		Name:       path.Base(file.path),
+		Path:       file.path,
+		Type:       fileType(stat.Mode),
+		Size:       stat.Size,
+		ModifiedAt: stat.Mtime,
+	}, nil
+}
+
+// ListInstanceFiles lists the entries of a directory of an instance's files volume, sorted as
+// Docker archives them. Docker only copies whole trees, so the listing reads the headers of the
+// directory's archive and stops once it has more than limit entries.
+func (m *DockerManager) ListInstanceFiles(ctx context.Context, instanceID uuid.UUID, raw string, limit int) ([]FileEntry, bool, error) {
+	dir, err := m.resolveFile(instanceID, raw)
+	if err != nil {
+		return nil, false, err
+	}
+	stat, err := m.stat(ctx, dir)
+	if err != nil {
+		return nil, false, err
+	}
+	if !stat.Mode.IsDir() {
+		return nil, false, ErrNotDirectory
+	}
+
+	reader, _, err := m.client.CopyFromContainer(ctx, dir.containerID, dir.fullPath+"/.")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to copy %s from container: %w", dir.fullPath, err)
+	}
+	defer reader.Close()
+
+	entries := []FileEntry{}
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return entries, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read archive of %s: %w", dir.fullPath, err)
+		}
+		// Entries are named after the copied directory, e.g. ./reports/june.csv
+		name := strings.Trim(strings.TrimPrefix(header.Name, "./"), "/")
+		if name == "" || name == "." || strings.Contains(name, "/") {
+			continue
+		}
+		if len(entries) == limit {
+			return entries, true, nil
+		}
+		entries = append(entries, FileEntry{
+			Name:       name,
+			Path:       path.Join(dir.path, name),
+			Type:       fileType(header.FileInfo().Mode()),
+			Size:       header.Size,
+			ModifiedAt: header.ModTime,
+		})
+	}
+}
+
+// ReadInstanceFile writes the content of a file of an instance's files volume to w
+func (m *DockerManager) ReadInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string, w io.Writer) error {
+	file, err := m.resolveFile(instanceID, raw)
+	if err != nil {
+		return err
+	}
+	stat, err := m.stat(ctx, file)
+	if err != nil {
+		return err
+	}
+	if stat.Mode.IsDir() {
+		return ErrIsDirectory
+	}
+
+	reader, _, err := m.client.CopyFromContainer(ctx, file.containerID, file.fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s from container: %w", file.fullPath, err)
+	}
+	defer reader.Close()
+	archive := tar.NewReader(reader)
+	if _, err := archive.Next(); err != nil {
+		return fmt.Errorf("failed to read archive of %s: %w", file.fullPath, err)
+	}
+	if _, err := io.Copy(w, archive); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", file.fullPath, err)
+	}
+	return nil
+}
+
+// WriteInstanceFile streams a file into an instance's files volume as a single-entry archive.
+// Docker creates the missing parent directories when it extracts the archive.
+func (m *DockerManager) WriteInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string, r io.Reader, size int64) error {
+	file, err := m.resolveFile(instanceID, raw)
+	if err != nil {
+		return err
+	}
+	if file.path == "/" {
+		return ErrIsDirectory
+	}
+	stat, err := m.stat(ctx, file)
+	if err != nil && !errors.Is(err, ErrFileNotFound) {
+		return err
+	}
+	if err == nil && stat.Mode.IsDir() {
+		return ErrIsDirectory
+	}
+
+	content, writer := io.Pipe()
+	go func() {
+		archive := tar.NewWriter(writer)
+		err := archive.WriteHeader(&tar.Header{
+			Name:    strings.TrimPrefix(file.path, "/"),
+			Mode:    0644,
+			Size:    size,
+			ModTime: time.Now(),
+		})
+		if err == nil {
+			_, err = io.CopyN(archive, r, size)
+		}
+		if err == nil {
+			err = archive.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	if err := m.client.CopyToContainer(ctx, file.containerID, file.root, content, types.CopyToContainerOptions{}); err != nil {
+		content.CloseWithError(err)
+		return fmt.Errorf("failed to copy %s to container: %w", file.fullPath, err)
+	}
+	return nil
+}
+
+// DeleteInstanceFile removes a file or directory tree of an instance's files volume. The
+// archive API cannot delete, so rm runs in the container, which must be running.
+func (m *DockerManager) DeleteInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string) error {
+	file, err := m.resolveFile(instanceID, raw)
+	if err != nil {
+		return err
+	}
+	if file.path == "/" {
+		return ErrInvalidFilePath
+	}
+	// A symlink is removed itself, wherever it points, but never reached through a parent
+	// directory linking out of the volume
+	if err := m.checkParents(ctx, file); err != nil {
+		return err
+	}
+	if _, err := m.statPath(ctx, file, file.fullPath); err != nil && !errors.Is(err, ErrInvalidFilePath) {
+		return err
+	}
+
+	exitCode, output, err := m.runCommand(ctx, file.containerID, []string{"rm", "-rf", "--", file.fullPath})
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("rm exited with %d: %s", exitCode, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// runCommand runs a command in a container without a TTY and returns its exit code and
+// combined output
+func (m *DockerManager) runCommand(ctx context.Context, containerID string, command []string) (int, string, error) {
+	exec, err := m.client.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          command,
+	})
+	if err != nil {
+		return -1, "", fmt.Errorf("failed to create exec session: %w", err)
+	}
+	attach, err := m.client.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return -1, "", fmt.Errorf("failed to attach to exec session: %w", err)
+	}
+	defer attach.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, attach.Reader); err != nil {
+		return -1, "", fmt.Errorf("failed to read exec output: %w", err)
+	}
+	inspect, err := m.client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return -1, "", fmt.Errorf("failed to inspect exec session: %w", err)
+	}
+	return inspect.ExitCode, output.String(), nil
+}
@@ -0,0 +1,70 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+)
+
+// healthProbeClient is shared by all health probes
+var healthProbeClient = &http.Client{Timeout: 5 * time.Second}
+
+// CheckHealth probes the health endpoint of an instance's service, e.g. n8n's /healthz, through
+// the container's IP on the Docker network
+func (m *DockerManager) CheckHealth(ctx context.Context, instanceID uuid.UUID) error {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return fmt.Errorf("instance has no container ID")
+	}
+
+	// The IP can change when the container is restarted, so look it up on every probe
+	inspected, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if inspected.State == nil || !inspected.State.Running {
+		return fmt.Errorf("container is not running")
+	}
+	ip, err := m.containerIP(inspected, instance.UserID)
+	if err != nil {
+		return err
+	}
+
+	return m.probeHealth(ctx, instance, ip)
+}
+
+// probeHealth makes a single request to the health endpoint of an instance's service
+func (m *DockerManager) probeHealth(ctx context.Context, instance *models.Instance, ip string) error {
+	template := m.templates.ForInstance(instance)
+	_, err := ProbeURL(ctx, fmt.Sprintf("http://%s:%d%s", ip, template.Port, template.HealthPath))
+	return err
+}
+
+// ProbeURL makes a single request to a health endpoint and returns how long it took to answer
+func ProbeURL(ctx context.Context, url string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	resp, err := healthProbeClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("health check request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		return latency, fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return latency, nil
+}
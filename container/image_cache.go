@@ -0,0 +1,204 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrImageNotFound is returned for an image that is not present on the host
+var ErrImageNotFound = errors.New("image is not present on the host")
+
+// imagePullTimeout bounds a single pre-pull, so a stuck registry does not hold up the others
+const imagePullTimeout = 15 * time.Minute
+
+// ImageInfo describes an image present on the host
+type ImageInfo struct {
+	ID     string
+	Digest string // registry digest reference, e.g. n8nio/n8n@sha256:...; empty for local builds
+	Size   int64
+}
+
+// HostName returns the name the Docker engine reports for its host
+func (m *DockerManager) HostName(ctx context.Context) (string, error) {
+	info, err := m.client.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Docker host info: %w", err)
+	}
+	return info.Name, nil
+}
+
+// PullImage pulls an image onto the host, refreshing an older copy of its tag, and describes
+// the image that was pulled
+func (m *DockerManager) PullImage(ctx context.Context, image string) (*ImageInfo, error) {
+	if err := m.pullImage(ctx, image); err != nil {
+		return nil, err
+	}
+	return m.inspectImage(ctx, image)
+}
+
+// inspectImage describes an image present on the host, or returns ErrImageNotFound
+func (m *DockerManager) inspectImage(ctx context.Context, image string) (*ImageInfo, error) {
+	inspected, _, err := m.client.ImageInspectWithRaw(ctx, image)
+	if errdefs.IsNotFound(err) {
+		return nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	repo, _, _ := strings.Cut(image, "@")
+	return &ImageInfo{
+		ID:     inspected.ID,
+		Digest: m.repoDigest(inspected.RepoDigests, strings.TrimSuffix(ImageRef(repo, ""), ":")),
+		Size:   inspected.Size,
+	}, nil
+}
+
+// ensureImage makes sure an image is present on the host before a container is created from
+// it. Images kept pulled by the ImageCache are used as they are, so only images that are not
+// pre-pulled, such as older n8n versions, are pulled during provisioning.
+func (m *DockerManager) ensureImage(ctx context.Context, image string) error {
+	_, err := m.inspectImage(ctx, image)
+	if err == nil {
+		m.logger.WithField("image", image).Debug("Image is present on the host, skipping the pull")
+		return nil
+	}
+	if !errors.Is(err, ErrImageNotFound) {
+		m.logger.WithError(err).WithField("image", image).Warn("Failed to check for image, pulling it")
+	}
+	return m.pullImage(ctx, image)
+}
+
+// ImageCache keeps the images of the service templates, and any configured in addition,
+// pulled on the Docker host, refreshing their tags on the configured interval. Each host
+// records what it holds in cached_images.
+type ImageCache struct {
+	manager Manager
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewImageCache creates a new image cache
+func NewImageCache(manager Manager, cfg *config.Config, logger *logrus.Logger) *ImageCache {
+	return &ImageCache{
+		manager: manager,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Start pulls the images at startup and then on the configured interval until the context is
+// cancelled. One replica per host pulls.
+func (c *ImageCache) Start(ctx context.Context) {
+	hostName, err := c.manager.HostName(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to get Docker host name, images will not be pre-pulled")
+		return
+	}
+
+	c.logger.Infof("Starting image pre-pulling on %s every %v", hostName, c.config.Images.PrePullInterval)
+	ticker := time.NewTicker(c.config.Images.PrePullInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("image_cache", c.config.Images.PrePullInterval)
+	singleton := lease.NewSingleton("image_cache:"+hostName, c.config.Images.PrePullInterval, c.config, c.logger)
+
+	if singleton.Acquire() {
+		loop.Run(func() { c.Refresh(ctx, hostName) })
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { c.Refresh(ctx, hostName) })
+			}
+		}
+	}
+}
+
+// Images returns the images kept pulled: the image of each template, then the configured ones
+// and the egress helper when egress is restricted
+func (c *ImageCache) Images() []string {
+	var candidates []string
+	for _, template := range c.manager.Templates().List() {
+		candidates = append(candidates, template.Image)
+	}
+	candidates = append(candidates, c.config.Images.PrePull...)
+	if c.config.Egress.Restrict {
+		candidates = append(candidates, c.config.Egress.HelperImage)
+	}
+
+	seen := make(map[string]bool)
+	var images []string
+	for _, image := range candidates {
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// Refresh pulls each image on the host and records the outcome, forgetting images that are no
+// longer pre-pulled
+func (c *ImageCache) Refresh(ctx context.Context, hostName string) {
+	images := c.Images()
+	for _, image := range images {
+		if ctx.Err() != nil {
+			return
+		}
+		c.pull(ctx, hostName, image)
+	}
+	if err := db.DeleteStaleCachedImages(hostName, images); err != nil {
+		c.logger.WithError(err).WithField("host", hostName).Error("Failed to forget images that are no longer pre-pulled")
+	}
+}
+
+// pull pulls a single image and records the outcome
+func (c *ImageCache) pull(ctx context.Context, hostName, image string) {
+	logger := c.logger.WithFields(logrus.Fields{
+		"host":  hostName,
+		"image": image,
+	})
+	pullCtx, cancel := context.WithTimeout(ctx, imagePullTimeout)
+	defer cancel()
+
+	start := time.Now()
+	info, err := c.manager.PullImage(pullCtx, image)
+	now := time.Now()
+	cached := &models.CachedImage{
+		HostName:     hostName,
+		Image:        image,
+		PullDuration: now.Sub(start).Milliseconds(),
+		CheckedAt:    now,
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Failed to pre-pull image")
+		cached.Status = models.CachedImageFailed
+		cached.Error = err.Error()
+	} else {
+		logger.WithFields(logrus.Fields{
+			"digest":      info.Digest,
+			"duration_ms": cached.PullDuration,
+		}).Debug("Pre-pulled image")
+		cached.Status = models.CachedImageReady
+		cached.ImageID = info.ID
+		cached.Digest = info.Digest
+		cached.SizeBytes = info.Size
+		cached.PulledAt = &now
+	}
+	if err := db.SaveCachedImage(cached); err != nil {
+		logger.WithError(err).Error("Failed to record pre-pulled image")
+	}
+}
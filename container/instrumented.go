@@ -0,0 +1,170 @@
+package container
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+)
+
+// instrumentedManager records the latency of every operation of the manager it wraps
+type instrumentedManager struct {
+	Manager
+}
+
+// Instrument wraps a manager so the duration and outcome of its operations are exported as metrics
+func Instrument(manager Manager) Manager {
+	return &instrumentedManager{Manager: manager}
+}
+
+func (m *instrumentedManager) PrepareInstance(ctx context.Context, user models.User, instanceReq models.Instance) (instance *models.Instance, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("prepare", start, err) }(time.Now())
+	return m.Manager.PrepareInstance(ctx, user, instanceReq)
+}
+
+func (m *instrumentedManager) ProvisionInstance(ctx context.Context, user models.User, instance *models.Instance, tracker ProvisioningTracker) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("provision", start, err) }(time.Now())
+	return m.Manager.ProvisionInstance(ctx, user, instance, tracker)
+}
+
+func (m *instrumentedManager) DeprovisionInstance(ctx context.Context, instance *models.Instance) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("deprovision", start, err) }(time.Now())
+	return m.Manager.DeprovisionInstance(ctx, instance)
+}
+
+func (m *instrumentedManager) DeleteInstance(ctx context.Context, instanceID uuid.UUID) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("delete", start, err) }(time.Now())
+	return m.Manager.DeleteInstance(ctx, instanceID)
+}
+
+func (m *instrumentedManager) StartInstance(ctx context.Context, instanceID uuid.UUID) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("start", start, err) }(time.Now())
+	return m.Manager.StartInstance(ctx, instanceID)
+}
+
+func (m *instrumentedManager) StopInstance(ctx context.Context, instanceID uuid.UUID) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("stop", start, err) }(time.Now())
+	return m.Manager.StopInstance(ctx, instanceID)
+}
+
+func (m *instrumentedManager) RenameInstance(ctx context.Context, instanceID uuid.UUID, name string) (instance *models.Instance, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("rename", start, err) }(time.Now())
+	return m.Manager.RenameInstance(ctx, instanceID, name)
+}
+
+func (m *instrumentedManager) GetInstanceStats(ctx context.Context, instanceID uuid.UUID) (usage *models.ResourceUsage, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("stats", start, err) }(time.Now())
+	return m.Manager.GetInstanceStats(ctx, instanceID)
+}
+
+// StreamInstanceStats is not timed, as streams stay open for as long as the instance runs
+func (m *instrumentedManager) StreamInstanceStats(ctx context.Context, instance *models.Instance, onSample func(*models.ResourceUsage)) error {
+	return m.Manager.StreamInstanceStats(ctx, instance, onSample)
+}
+
+// StreamInstanceLogs is not timed, as followed logs stay open until the client leaves
+func (m *instrumentedManager) StreamInstanceLogs(ctx context.Context, instanceID uuid.UUID, tail int, follow bool, w io.Writer) error {
+	return m.Manager.StreamInstanceLogs(ctx, instanceID, tail, follow, w)
+}
+
+// ExecInstance is not timed, as terminal sessions stay open until the client leaves
+func (m *instrumentedManager) ExecInstance(ctx context.Context, instanceID uuid.UUID, options ExecOptions) (int, error) {
+	return m.Manager.ExecInstance(ctx, instanceID, options)
+}
+
+func (m *instrumentedManager) StatInstanceFile(ctx context.Context, instanceID uuid.UUID, path string) (entry *FileEntry, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("stat_file", start, err) }(time.Now())
+	return m.Manager.StatInstanceFile(ctx, instanceID, path)
+}
+
+func (m *instrumentedManager) ListInstanceFiles(ctx context.Context, instanceID uuid.UUID, path string, limit int) (entries []FileEntry, truncated bool, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("list_files", start, err) }(time.Now())
+	return m.Manager.ListInstanceFiles(ctx, instanceID, path, limit)
+}
+
+// ReadInstanceFile and WriteInstanceFile are not timed, as they last as long as the transfer
+func (m *instrumentedManager) ReadInstanceFile(ctx context.Context, instanceID uuid.UUID, path string, w io.Writer) error {
+	return m.Manager.ReadInstanceFile(ctx, instanceID, path, w)
+}
+
+func (m *instrumentedManager) WriteInstanceFile(ctx context.Context, instanceID uuid.UUID, path string, r io.Reader, size int64) error {
+	return m.Manager.WriteInstanceFile(ctx, instanceID, path, r, size)
+}
+
+func (m *instrumentedManager) DeleteInstanceFile(ctx context.Context, instanceID uuid.UUID, path string) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("delete_file", start, err) }(time.Now())
+	return m.Manager.DeleteInstanceFile(ctx, instanceID, path)
+}
+
+func (m *instrumentedManager) GetStorageUsage(ctx context.Context, instanceID uuid.UUID) (bytes int64, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("storage_usage", start, err) }(time.Now())
+	return m.Manager.GetStorageUsage(ctx, instanceID)
+}
+
+func (m *instrumentedManager) PullImage(ctx context.Context, image string) (info *ImageInfo, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("pull_image", start, err) }(time.Now())
+	return m.Manager.PullImage(ctx, image)
+}
+
+func (m *instrumentedManager) CheckHealth(ctx context.Context, instanceID uuid.UUID) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("health_check", start, err) }(time.Now())
+	return m.Manager.CheckHealth(ctx, instanceID)
+}
+
+func (m *instrumentedManager) ResizeInstance(ctx context.Context, instanceID uuid.UUID, cpuLimit float64, memoryLimitMB int) (recreated bool, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("resize", start, err) }(time.Now())
+	return m.Manager.ResizeInstance(ctx, instanceID, cpuLimit, memoryLimitMB)
+}
+
+func (m *instrumentedManager) IsolateInstance(ctx context.Context, instanceID uuid.UUID) (moved bool, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("isolate", start, err) }(time.Now())
+	return m.Manager.IsolateInstance(ctx, instanceID)
+}
+
+func (m *instrumentedManager) RestrictEgress(ctx context.Context, instanceID uuid.UUID) (written bool, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("restrict_egress", start, err) }(time.Now())
+	return m.Manager.RestrictEgress(ctx, instanceID)
+}
+
+func (m *instrumentedManager) ReconcileInstance(ctx context.Context, instanceID uuid.UUID) (changes []string, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("reconcile", start, err) }(time.Now())
+	return m.Manager.ReconcileInstance(ctx, instanceID)
+}
+
+func (m *instrumentedManager) UpgradeInstance(ctx context.Context, instanceID uuid.UUID, imageTag string) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("upgrade", start, err) }(time.Now())
+	return m.Manager.UpgradeInstance(ctx, instanceID, imageTag)
+}
+
+func (m *instrumentedManager) RollbackInstance(ctx context.Context, instanceID uuid.UUID) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("rollback", start, err) }(time.Now())
+	return m.Manager.RollbackInstance(ctx, instanceID)
+}
+
+func (m *instrumentedManager) GetInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (credential *models.InstanceCredential, password string, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("get_credentials", start, err) }(time.Now())
+	return m.Manager.GetInstanceCredentials(ctx, instanceID)
+}
+
+func (m *instrumentedManager) RotateInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (credential *models.InstanceCredential, password string, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("rotate_credentials", start, err) }(time.Now())
+	return m.Manager.RotateInstanceCredentials(ctx, instanceID)
+}
+
+func (m *instrumentedManager) ExportWorkflows(ctx context.Context, instanceID uuid.UUID) (bundle *models.WorkflowBundle, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("export_workflows", start, err) }(time.Now())
+	return m.Manager.ExportWorkflows(ctx, instanceID)
+}
+
+func (m *instrumentedManager) ImportWorkflows(ctx context.Context, instanceID uuid.UUID, bundle *models.WorkflowBundle) (result *models.WorkflowImportResult, err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("import_workflows", start, err) }(time.Now())
+	return m.Manager.ImportWorkflows(ctx, instanceID, bundle)
+}
+
+func (m *instrumentedManager) ArchiveInstanceData(ctx context.Context, instanceID uuid.UUID, w io.Writer) (err error) {
+	defer func(start time.Time) { metrics.ObserveContainerOperation("backup", start, err) }(time.Now())
+	return m.Manager.ArchiveInstanceData(ctx, instanceID, w)
+}
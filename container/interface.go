@@ -2,6 +2,7 @@ package container
 
 import (
 	"context"
+	"io"
 
 	"github.com/google/uuid"
 	"github.com/launchstack/backend/models"
@@ -9,8 +10,15 @@ import (
 
 // Manager is the interface for container operations
 type Manager interface {
-	// CreateInstance creates a new instance
-	CreateInstance(ctx context.Context, user models.User, instanceReq models.Instance) (*models.Instance, error)
+	// PrepareInstance builds the record of a new instance without creating any resources
+	PrepareInstance(ctx context.Context, user models.User, instanceReq models.Instance) (*models.Instance, error)
+	
+	// ProvisionInstance creates the resources of a prepared instance, reporting each step to the tracker
+	ProvisionInstance(ctx context.Context, user models.User, instance *models.Instance, tracker ProvisioningTracker) error
+	
+	// DeprovisionInstance removes whatever was created for an instance whose provisioning
+	// failed, returning the resources it could not remove
+	DeprovisionInstance(ctx context.Context, instance *models.Instance) error
 	
 	// DeleteInstance deletes an instance
 	DeleteInstance(ctx context.Context, instanceID uuid.UUID) error
@@ -21,6 +29,94 @@ type Manager interface {
 	// StopInstance stops an instance
 	StopInstance(ctx context.Context, instanceID uuid.UUID) error
 	
+	// RenameInstance changes an instance's display name, applying the naming policy
+	RenameInstance(ctx context.Context, instanceID uuid.UUID, name string) (*models.Instance, error)
+	
 	// GetInstanceStats retrieves resource usage stats for an instance
 	GetInstanceStats(ctx context.Context, instanceID uuid.UUID) (*models.ResourceUsage, error)
+	
+	// StreamInstanceStats passes resource usage samples of a running instance to onSample as they
+	// arrive, until the context is cancelled or the stream ends
+	StreamInstanceStats(ctx context.Context, instance *models.Instance, onSample func(*models.ResourceUsage)) error
+	
+	// StreamInstanceLogs writes the last tail lines of an instance's container output to w and,
+	// if follow is set, new lines as they are written until the context is cancelled
+	StreamInstanceLogs(ctx context.Context, instanceID uuid.UUID, tail int, follow bool, w io.Writer) error
+	
+	// ExecInstance runs a command with a terminal in an instance's container, bridging it to the
+	// streams of the options until it exits or the context is cancelled, and returns its exit code
+	ExecInstance(ctx context.Context, instanceID uuid.UUID, options ExecOptions) (int, error)
+	
+	// StatInstanceFile describes a file or directory of an instance's files volume
+	StatInstanceFile(ctx context.Context, instanceID uuid.UUID, path string) (*FileEntry, error)
+	
+	// ListInstanceFiles lists up to limit entries of a directory of an instance's files volume,
+	// reporting whether there were more
+	ListInstanceFiles(ctx context.Context, instanceID uuid.UUID, path string, limit int) ([]FileEntry, bool, error)
+	
+	// ReadInstanceFile writes the content of a file of an instance's files volume to w
+	ReadInstanceFile(ctx context.Context, instanceID uuid.UUID, path string, w io.Writer) error
+	
+	// WriteInstanceFile creates or replaces a file of an instance's files volume with size bytes
+	// read from r, creating missing parent directories
+	WriteInstanceFile(ctx context.Context, instanceID uuid.UUID, path string, r io.Reader, size int64) error
+	
+	// DeleteInstanceFile removes a file or directory tree of an instance's files volume
+	DeleteInstanceFile(ctx context.Context, instanceID uuid.UUID, path string) error
+	
+	// GetStorageUsage measures the disk space used by an instance's volumes in bytes
+	GetStorageUsage(ctx context.Context, instanceID uuid.UUID) (int64, error)
+	
+	// Templates returns the service templates instances are created from
+	Templates() *TemplateRegistry
+	
+	// HostName returns the name the Docker engine reports for its host
+	HostName(ctx context.Context) (string, error)
+	
+	// PullImage pulls an image onto the host, refreshing an older copy of its tag, and describes
+	// the image that was pulled
+	PullImage(ctx context.Context, image string) (*ImageInfo, error)
+	
+	// CheckHealth probes the health endpoint of the service inside an instance's container
+	CheckHealth(ctx context.Context, instanceID uuid.UUID) error
+	
+	// ResizeInstance changes an instance's CPU and memory limits, reporting whether the container was recreated
+	ResizeInstance(ctx context.Context, instanceID uuid.UUID, cpuLimit float64, memoryLimitMB int) (bool, error)
+	
+	// UpgradeInstance recreates an instance on the given n8n image tag, rolling back if it is unhealthy
+	UpgradeInstance(ctx context.Context, instanceID uuid.UUID, imageTag string) error
+	
+	// RollbackInstance recreates an instance on the image digest it ran before its last upgrade
+	RollbackInstance(ctx context.Context, instanceID uuid.UUID) error
+	
+	// GetInstanceCredentials returns an instance's basic auth credentials and decrypted password
+	GetInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (*models.InstanceCredential, string, error)
+	
+	// RotateInstanceCredentials replaces an instance's basic auth password and restarts it with the new one
+	RotateInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (*models.InstanceCredential, string, error)
+	
+	// ExportWorkflows reads every workflow of an instance through its n8n REST API
+	ExportWorkflows(ctx context.Context, instanceID uuid.UUID) (*models.WorkflowBundle, error)
+	
+	// ImportWorkflows creates the workflows of a bundle on an instance as new, inactive workflows
+	ImportWorkflows(ctx context.Context, instanceID uuid.UUID, bundle *models.WorkflowBundle) (*models.WorkflowImportResult, error)
+	
+	// ArchiveInstanceData writes a gzipped tar archive of an instance's data and files volumes to w
+	ArchiveInstanceData(ctx context.Context, instanceID uuid.UUID, w io.Writer) error
+	
+	// IsolateInstance moves a running instance from the shared Docker network to its user's
+	// network, reporting whether it was moved
+	IsolateInstance(ctx context.Context, instanceID uuid.UUID) (bool, error)
+	
+	// RestrictEgress writes the egress rules into a running instance's container when it does
+	// not have them, reporting whether they were written
+	RestrictEgress(ctx context.Context, instanceID uuid.UUID) (bool, error)
+	
+	// ReconcileInstance brings the container, network and DNS record of an instance in line
+	// with its recorded status, returning a description of each change it made
+	ReconcileInstance(ctx context.Context, instanceID uuid.UUID) ([]string, error)
+	
+	// StaleDNSRecords lists DNS records that are left over from deleted instances or point at a
+	// previous container, or returns ErrDNSListingUnsupported
+	StaleDNSRecords(ctx context.Context) ([]DNSRecord, error)
 } 
\ No newline at end of file
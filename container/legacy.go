@@ -0,0 +1,313 @@
+package container
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// LegacySite is a site block of a Caddyfile written by the legacy deployment, which
+// proxied every instance domain to a host port
+type LegacySite struct {
+	Host     string
+	Upstream string
+}
+
+// ParseLegacyCaddyfile finds the top-level site blocks of a Caddyfile and the upstream
+// their reverse_proxy directive points to
+func ParseLegacyCaddyfile(content string) []LegacySite {
+	var sites []LegacySite
+	var current *LegacySite
+	depth := 0
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if depth == 0 && strings.HasSuffix(line, "{") {
+			host := strings.TrimSpace(strings.TrimSuffix(line, "{"))
+			if host != "" {
+				sites = append(sites, LegacySite{Host: host})
+				current = &sites[len(sites)-1]
+			}
+		} else if depth == 1 && current != nil && strings.HasPrefix(line, "reverse_proxy ") {
+			fields := strings.Fields(line)
+			current.Upstream = fields[1]
+		}
+
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth == 0 {
+			current = nil
+		}
+	}
+
+	return sites
+}
+
+// RewriteLegacyCaddyfileUpstream points the reverse_proxy of a host's site block at a
+// new upstream, reporting whether the site was found
+func RewriteLegacyCaddyfileUpstream(content, host, upstream string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	depth := 0
+	inSite := false
+	found := false
+
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if depth == 0 && strings.HasSuffix(line, "{") {
+			inSite = strings.TrimSpace(strings.TrimSuffix(line, "{")) == host
+		} else if inSite && depth == 1 && strings.HasPrefix(line, "reverse_proxy ") {
+			fields := strings.Fields(line)
+			indent := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+			fields[1] = upstream
+			lines[i] = indent + strings.Join(fields, " ")
+			found = true
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth == 0 {
+			inSite = false
+		}
+	}
+
+	return strings.Join(lines, "\n"), found
+}
+
+// LegacyInstance is an n8n container created by the legacy deployment, which stored
+// instance data in host bind mounts instead of Docker volumes
+type LegacyInstance struct {
+	ContainerID   string
+	ContainerName string
+	UserID        uuid.UUID
+	Name          string
+	URL           string // instance domain, from the container's N8N_HOST
+	Image         string
+	DataDir       string // bind-mounted at /home/node/.n8n
+	FilesDir      string // bind-mounted at /files
+	Running       bool
+}
+
+// FindLegacyInstances lists the managed n8n containers whose data still lives in bind mounts
+func (m *DockerManager) FindLegacyInstances(ctx context.Context) ([]LegacyInstance, error) {
+	containers, err := m.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "com.launchstack.managed=true")),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var legacy []LegacyInstance
+	for _, listed := range containers {
+		inspected, err := m.client.ContainerInspect(ctx, listed.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container %s: %w", listed.ID, err)
+		}
+
+		instance := LegacyInstance{
+			ContainerID:   inspected.ID,
+			ContainerName: strings.TrimPrefix(inspected.Name, "/"),
+			Image:         inspected.Config.Image,
+			Running:       inspected.State != nil && inspected.State.Running,
+		}
+		for _, mnt := range inspected.Mounts {
+			if mnt.Type != mount.TypeBind {
+				continue
+			}
+			switch mnt.Destination {
+			case "/home/node/.n8n":
+				instance.DataDir = mnt.Source
+			case "/files":
+				instance.FilesDir = mnt.Source
+			}
+		}
+		if instance.DataDir == "" {
+			continue
+		}
+
+		instance.UserID, err = uuid.Parse(inspected.Config.Labels["com.launchstack.user.id"])
+		if err != nil {
+			return nil, fmt.Errorf("container %s has no valid user label", instance.ContainerName)
+		}
+		for _, env := range inspected.Config.Env {
+			if strings.HasPrefix(env, "N8N_HOST=") {
+				instance.URL = strings.TrimPrefix(env, "N8N_HOST=")
+			}
+		}
+		if instance.URL == "" {
+			return nil, fmt.Errorf("container %s has no N8N_HOST", instance.ContainerName)
+		}
+
+		// Legacy containers were named n8n-{user prefix}-{instance name}
+		instance.Name = strings.TrimPrefix(instance.ContainerName, fmt.Sprintf("n8n-%s-", instance.UserID.String()[:8]))
+		legacy = append(legacy, instance)
+	}
+
+	return legacy, nil
+}
+
+// ImportLegacyInstance moves a legacy instance onto the current model: its bind-mounted
+// data is copied into Docker volumes, the container is recreated on those volumes with
+// the current labels and plan limits, and the instance is recorded in the database with
+// its DNS record. The instance keeps its domain so that existing webhook URLs keep
+// working. The new container must pass its health check before the legacy one is
+// removed; otherwise the legacy container is restored. The bind-mount directories are
+// left in place.
+func (m *DockerManager) ImportLegacyInstance(ctx context.Context, legacy LegacyInstance) (*models.Instance, error) {
+	logger := m.logger.WithFields(logrus.Fields{
+		"container": legacy.ContainerName,
+		"url":       legacy.URL,
+	})
+
+	user, err := db.GetUserByID(legacy.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("owner %s not found: %w", legacy.UserID, err)
+	}
+
+	// Reuse a database record the legacy deployment may have written for this container
+	instance := &models.Instance{}
+	if err := db.DB.Where("container_id = ?", legacy.ContainerID).First(instance).Error; err != nil {
+		instance = &models.Instance{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			Name:   legacy.Name,
+		}
+	}
+	instance.ContainerID = legacy.ContainerID
+	instance.URL = legacy.URL
+	instance.Host = strings.SplitN(legacy.URL, ".", 2)[0]
+	instance.CPULimit, instance.MemoryLimit, instance.StorageLimit = ResolveResourceLimits(user, models.Instance{})
+	if i := strings.LastIndex(legacy.Image, ":"); i > 0 && !strings.Contains(legacy.Image[i:], "/") && legacy.Image[i+1:] != "latest" {
+		instance.ImageTag = legacy.Image[i+1:]
+	}
+
+	// The legacy container must be stopped so that n8n's database is copied consistently
+	timeout := 30 * time.Second
+	if err := m.client.ContainerStop(ctx, legacy.ContainerID, &timeout); err != nil {
+		return nil, fmt.Errorf("failed to stop legacy container: %w", err)
+	}
+
+	dataVolume, filesVolume := m.generateVolumeNames(legacy.ContainerName)
+	if err := m.copyBindsToVolumes(ctx, legacy, dataVolume, filesVolume); err != nil {
+		if legacy.Running {
+			m.restartContainer(ctx, legacy.ContainerID, logger)
+		}
+		return nil, err
+	}
+
+	var env []string
+	err = m.recreateContainer(ctx, instance, logger, func(config *container.Config, hostConfig *container.HostConfig) {
+		env = config.Env
+		config.ExposedPorts = nat.PortSet{nat.Port(fmt.Sprintf("%d/tcp", m.config.Docker.N8NContainerPort)): {}}
+		config.Labels["com.launchstack.instance.id"] = instance.ID.String()
+		config.Labels["com.launchstack.user.id"] = user.ID.String()
+		config.Labels["com.launchstack.managed"] = "true"
+
+		// Instances are reached over the Docker network instead of published host ports
+		hostConfig.Binds = nil
+		hostConfig.PortBindings = nil
+		hostConfig.Mounts = []mount.Mount{
+			{Type: mount.TypeVolume, Source: dataVolume, Target: "/home/node/.n8n"},
+			{Type: mount.TypeVolume, Source: filesVolume, Target: "/files"},
+		}
+		hostConfig.Resources.Memory = int64(instance.MemoryLimit) * 1024 * 1024
+		hostConfig.Resources.NanoCPUs = int64(instance.CPULimit * 1e9)
+	})
+	if err != nil {
+		if !legacy.Running {
+			// recreateContainer restarts the previous container on failure
+			if stopErr := m.client.ContainerStop(ctx, legacy.ContainerID, &timeout); stopErr != nil {
+				logger.WithError(stopErr).Warn("Failed to stop legacy container again after failed import")
+			}
+		}
+		return nil, err
+	}
+
+	instance.Status = models.StatusRunning
+	if instance.CreatedAt.IsZero() {
+		instance.ProvisioningSpec = newProvisioningSpec(user, instance, m.config.Health.Region, legacy.Image, env)
+		err = db.CreateInstance(instance)
+	} else {
+		err = db.UpdateInstance(instance)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("instance %s was migrated but could not be recorded: %w", instance.ID, err)
+	}
+
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       models.EventLegacyImported,
+		Level:      models.EventLevelInfo,
+		Message:    fmt.Sprintf("Imported from the legacy deployment; data was copied from %s into Docker volumes", legacy.DataDir),
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		logger.WithError(err).Warn("Failed to record instance event")
+	}
+
+	logger.WithField("instance_id", instance.ID).Info("Imported legacy instance")
+	return instance, nil
+}
+
+// copyBindsToVolumes copies the contents of a legacy instance's bind-mount directories
+// into its Docker volumes with a short-lived helper container. Existing volume contents
+// are replaced, so an interrupted import can be retried.
+func (m *DockerManager) copyBindsToVolumes(ctx context.Context, legacy LegacyInstance, dataVolume, filesVolume string) error {
+	mounts := []mount.Mount{
+		{Type: mount.TypeBind, Source: legacy.DataDir, Target: "/legacy/data", ReadOnly: true},
+		{Type: mount.TypeVolume, Source: dataVolume, Target: "/volumes/data"},
+		{Type: mount.TypeVolume, Source: filesVolume, Target: "/volumes/files"},
+	}
+	script := "rm -rf /volumes/data/* /volumes/data/.[!.]* && cp -a /legacy/data/. /volumes/data/"
+	if legacy.FilesDir != "" {
+		mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: legacy.FilesDir, Target: "/legacy/files", ReadOnly: true})
+		script += " && rm -rf /volumes/files/* /volumes/files/.[!.]* && cp -a /legacy/files/. /volumes/files/"
+	}
+
+	// The n8n image is already present and has a shell
+	resp, err := m.client.ContainerCreate(ctx, &container.Config{
+		Image:      legacy.Image,
+		User:       "root",
+		Entrypoint: []string{"sh", "-c"},
+		Cmd:        []string{script},
+		Labels:     map[string]string{"com.launchstack.legacy-import": legacy.ContainerName},
+	}, &container.HostConfig{Mounts: mounts}, nil, nil, legacy.ContainerName+"-import")
+	if err != nil {
+		return fmt.Errorf("failed to create copy container: %w", err)
+	}
+	defer func() {
+		if err := m.client.ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			m.logger.WithError(err).WithField("container_id", resp.ID).Warn("Failed to remove copy container")
+		}
+	}()
+
+	waitCh, errCh := m.client.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := m.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start copy container: %w", err)
+	}
+
+	select {
+	case result := <-waitCh:
+		if result.StatusCode != 0 {
+			return fmt.Errorf("copying %s into volume %s failed with exit code %d", legacy.DataDir, dataVolume, result.StatusCode)
+		}
+	case err := <-errCh:
+		return fmt.Errorf("failed to wait for copy container: %w", err)
+	}
+
+	return nil
+}
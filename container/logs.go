@@ -0,0 +1,47 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+)
+
+// StreamInstanceLogs writes the output of an instance's container to w, with stdout and stderr
+// interleaved as they were written. A negative tail writes the whole log.
+func (m *DockerManager) StreamInstanceLogs(ctx context.Context, instanceID uuid.UUID, tail int, follow bool, w io.Writer) error {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return fmt.Errorf("instance has no container ID")
+	}
+
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     follow,
+		Tail:       "all",
+	}
+	if tail >= 0 {
+		options.Tail = strconv.Itoa(tail)
+	}
+	logs, err := m.client.ContainerLogs(ctx, instance.ContainerID, options)
+	if err != nil {
+		return fmt.Errorf("failed to read container logs: %w", err)
+	}
+	defer logs.Close()
+
+	// Instance containers run without a TTY, so both streams arrive multiplexed
+	if _, err := stdcopy.StdCopy(w, w, logs); err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("failed to copy container logs: %w", err)
+	}
+	return nil
+}
@@ -1,10 +1,19 @@
 package container
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,8 +30,18 @@ type MockManager struct {
 	baseIP net.IP
 	domain string
 	config *config.Config
+	templates *TemplateRegistry
 	// Track allocated IPs
 	allocatedIPs map[string]bool
+	// Files volumes kept in memory, by instance and path
+	filesMu sync.Mutex
+	files   map[uuid.UUID]map[string]mockFile
+}
+
+// mockFile is a file of a mock files volume
+type mockFile struct {
+	data       []byte
+	modifiedAt time.Time
 }
 
 // NewMockManager creates a new mock container manager
@@ -65,10 +84,36 @@ func NewMockManager(logger *logrus.Logger, cfg *config.Config) Manager {
 		baseIP:       baseIP,
 		domain:       domain,
 		config:       cfg,
+		templates:    NewTemplateRegistry(cfg, logger),
 		allocatedIPs: make(map[string]bool),
+		files:        make(map[uuid.UUID]map[string]mockFile),
 	}
 }
 
+// Templates returns the service templates instances are created from
+func (m *MockManager) Templates() *TemplateRegistry {
+	return m.templates
+}
+
+// HostName returns a fixed host name (mock implementation)
+func (m *MockManager) HostName(ctx context.Context) (string, error) {
+	return "mock", nil
+}
+
+// PullImage describes an image by a digest derived from its reference (mock implementation)
+func (m *MockManager) PullImage(ctx context.Context, image string) (*ImageInfo, error) {
+	m.logger.WithField("image", image).Debug("Mock: Pulling image")
+	
+	digest := sha256.Sum256([]byte(image))
+	sum := hex.EncodeToString(digest[:])
+	repo, _, _ := strings.Cut(image, "@")
+	return &ImageInfo{
+		ID:     "sha256:" + sum,
+		Digest: strings.TrimSuffix(ImageRef(repo, ""), ":") + "@sha256:" + sum,
+		Size:   512 * 1024 * 1024,
+	}, nil
+}
+
 // allocateIP allocates a unique IP address from the subnet
 func (m *MockManager) allocateIP() (string, error) {
 	// Parse the subnet
@@ -118,14 +163,14 @@ func incrementIP(ip net.IP) {
 
 // Using shared implementation from shared.go
 
-// CreateInstance creates a new instance (mock implementation)
-func (m *MockManager) CreateInstance(ctx context.Context, user models.User, instanceReq models.Instance) (*models.Instance, error) {
+// PrepareInstance builds the record of a new instance (mock implementation)
+func (m *MockManager) PrepareInstance(ctx context.Context, user models.User, instanceReq models.Instance) (*models.Instance, error) {
 	m.logger.WithFields(logrus.Fields{
 		"user_id":       user.ID.String(),
 		"user_email":    user.Email,
 		"instance_name": instanceReq.Name,
 		"plan":          user.Plan,
-	}).Info("Creating new instance")
+	}).Info("Preparing new instance")
 	
 	// Log user plan limits
 	m.logger.WithFields(logrus.Fields{
@@ -135,38 +180,72 @@ func (m *MockManager) CreateInstance(ctx context.Context, user models.User, inst
 		"max_instances": user.GetInstancesLimit(),
 	}).Info("User resource limits")
 	
+	template, err := m.templates.Offered(instanceReq.ServiceType)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Duplicate names would collide at the container level
+	name, err := resolveInstanceName(m.config.N8N.NamePolicy, user.ID, instanceReq.Name, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Generate unique container ID and container name
 	instanceID := uuid.New()
-	containerName := GenerateContainerName(user.ID, instanceReq.Name)
+	containerName := GenerateContainerName(user.ID, name)
 	
 	// Generate a unique, easy-to-remember subdomain
-	subdomain := GenerateEasySubdomain(containerName)
-	
-	// Create unique URLs
-	url := fmt.Sprintf("https://%s.%s", subdomain, m.domain)
-	
-	// Allocate a unique IP
-	ip, err := m.allocateIP()
+	subdomain, err := uniqueSubdomain(containerName)
 	if err != nil {
-		m.logger.WithError(err).Error("Failed to allocate IP address")
 		return nil, err
 	}
 	
-	// Get the n8n container port from config
-	n8nPort := m.config.Docker.N8NContainerPort
-	if n8nPort == 0 {
-		n8nPort = 5678 // Default n8n port
-	}
+	// Create unique URLs
+	url := fmt.Sprintf("https://%s.%s", subdomain, m.domain)
 	
 	m.logger.WithFields(logrus.Fields{
 		"instance_id":    instanceID,
 		"container_name": containerName,
 		"subdomain":      subdomain,
 		"url":            url,
-		"ip":             ip,
-		"n8n_port":       n8nPort,
+		"service_type":   template.Service,
+		"port":           template.Port,
 	}).Info("Generated instance identifiers")
 	
+	// Create the instance object
+	cpuLimit, memoryLimit, storageLimit := ResolveResourceLimits(user, instanceReq)
+	if err := checkCapacity(ctx, m.config, cpuLimit, memoryLimit); err != nil {
+		return nil, err
+	}
+	instance := &models.Instance{
+		ID:           instanceID,
+		UserID:       user.ID,
+		ProjectID:    instanceReq.ProjectID,
+		OrganizationID: instanceReq.OrganizationID,
+		Name:         name,
+		Description:  instanceReq.Description,
+		ServiceType:  template.Service,
+		Status:       models.StatusPending,
+		Host:         subdomain,
+		Port:         template.Port,
+		URL:          url,
+		ImageTag:     resolveImageTag(instanceReq),
+		CPULimit:     cpuLimit,
+		MemoryLimit:  memoryLimit,
+		StorageLimit: storageLimit,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	instance.ProvisioningSpec = newProvisioningSpec(user, instance, m.config.Health.Region, template.ImageFor(instance.ImageTag), nil)
+	
+	return instance, nil
+}
+
+// ProvisionInstance creates the resources of a prepared instance (mock implementation)
+func (m *MockManager) ProvisionInstance(ctx context.Context, user models.User, instance *models.Instance, tracker ProvisioningTracker) error {
+	containerName := GenerateContainerName(user.ID, instance.Name)
+	
 	// Use container name for both container ID and volume directory
 	// This makes it easier to identify which volumes belong to which container
 	dataDir := fmt.Sprintf("/SSD/LaunchStack/N8N/%s/data", containerName)
@@ -186,73 +265,89 @@ func (m *MockManager) CreateInstance(ctx context.Context, user models.User, inst
 			"-v %s:/files "+
 			"--label com.centurylinklabs.watchtower.enable=true "+
 			"--network n8n "+
-			"--ip %s "+
 			"n8nio/n8n:latest",
 		containerName,
-		subdomain, m.domain,
-		subdomain, m.domain,
+		instance.Host, m.domain,
+		instance.Host, m.domain,
 		dataDir,
 		filesDir,
-		ip,
 	)
 	
-	m.logger.WithFields(logrus.Fields{
-		"docker_cmd": dockerCmd,
-	}).Info("Docker command that would be executed")
-	
-	// In a real implementation, we would now:
-	m.logger.Info("MOCK: Would create data directories")
-	mockCmd := fmt.Sprintf("mkdir -p %s %s", dataDir, filesDir)
-	m.logger.WithField("cmd", mockCmd).Info("Would execute")
-	time.Sleep(100 * time.Millisecond)
+	if err := trackStep(tracker, models.StepCreateVolumes, func() error {
+		m.logger.WithField("cmd", fmt.Sprintf("mkdir -p %s %s", dataDir, filesDir)).Info("MOCK: Would create data directories")
+		return nil
+	}); err != nil {
+		return err
+	}
 	
-	m.logger.Info("MOCK: Would pull Docker image")
-	mockCmd = "docker pull n8nio/n8n:latest"
-	m.logger.WithField("cmd", mockCmd).Info("Would execute")
-	time.Sleep(100 * time.Millisecond)
+	if err := trackStep(tracker, models.StepPullImage, func() error {
+		m.logger.WithField("cmd", "docker pull n8nio/n8n:latest").Info("MOCK: Would pull Docker image")
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}); err != nil {
+		return err
+	}
 	
-	m.logger.Info("MOCK: Would create and start container")
-	m.logger.WithField("cmd", dockerCmd).Info("Would execute")
-	time.Sleep(100 * time.Millisecond)
+	if err := trackStep(tracker, models.StepCreateContainer, func() error {
+		m.logger.WithField("cmd", dockerCmd).Info("MOCK: Would create container")
+		time.Sleep(100 * time.Millisecond)
+		instance.ContainerID = containerName // Use container name as the ID for consistency
+		return nil
+	}); err != nil {
+		return err
+	}
 	
-	m.logger.Info("MOCK: Would configure reverse proxy (Caddy)")
-	mockCmd = fmt.Sprintf("echo '%s.%s { reverse_proxy http://%s:%d }' >> /etc/caddy/Caddyfile", 
-		subdomain, m.domain, ip, n8nPort)
-	m.logger.WithField("cmd", mockCmd).Info("Would execute")
-	time.Sleep(100 * time.Millisecond)
+	if err := trackStep(tracker, models.StepStartContainer, func() error {
+		m.logger.Info("MOCK: Would start container")
+		time.Sleep(100 * time.Millisecond)
+		
+		// Allocate a unique IP
+		ip, err := m.allocateIP()
+		if err != nil {
+			m.logger.WithError(err).Error("Failed to allocate IP address")
+			return err
+		}
+		instance.IPAddress = ip
+		return nil
+	}); err != nil {
+		_ = trackStep(tracker, models.StepCleanup, func() error { return m.DeprovisionInstance(ctx, instance) })
+		return err
+	}
 	
-	m.logger.Info("MOCK: Would reload Caddy")
-	mockCmd = "systemctl reload caddy"
-	m.logger.WithField("cmd", mockCmd).Info("Would execute")
-	time.Sleep(100 * time.Millisecond)
+	if err := trackStep(tracker, models.StepDNS, func() error {
+		m.logger.WithField("domain", instance.Host+".docker").Info("MOCK: Would add DNS record")
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}); err != nil {
+		_ = trackStep(tracker, models.StepCleanup, func() error { return m.DeprovisionInstance(ctx, instance) })
+		return err
+	}
 	
-	// Create the instance object
-	instance := &models.Instance{
-		ID:           instanceID,
-		UserID:       user.ID,
-		Name:         instanceReq.Name,
-		Description:  instanceReq.Description,
-		Status:       models.StatusRunning,
-		Host:         subdomain,
-		Port:         n8nPort,
-		URL:          url,
-		CPULimit:     user.GetCPULimit(),
-		MemoryLimit:  user.GetMemoryLimit(),
-		StorageLimit: user.GetStorageLimit(),
-		ContainerID:  containerName, // Use container name as the ID for consistency
-		IPAddress:    ip,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+	if err := trackStep(tracker, models.StepHealthCheck, func() error {
+		m.logger.WithField("url", instance.URL).Info("MOCK: Would wait for the service to answer its health check")
+		return nil
+	}); err != nil {
+		return err
 	}
 	
 	m.logger.WithFields(logrus.Fields{
 		"instance_id": instance.ID,
-		"status":      instance.Status,
 		"url":         instance.URL,
 		"ip":          instance.IPAddress,
-	}).Info("Instance created successfully")
+	}).Info("Instance provisioned successfully")
 	
-	return instance, nil
+	return nil
+}
+
+// DeprovisionInstance removes the resources of a failed provisioning (mock implementation)
+func (m *MockManager) DeprovisionInstance(ctx context.Context, instance *models.Instance) error {
+	m.logger.WithField("instance_id", instance.ID).Info("MOCK: Would remove container, volumes and DNS record")
+	if instance.IPAddress != "" {
+		delete(m.allocatedIPs, instance.IPAddress)
+	}
+	instance.ContainerID = ""
+	instance.IPAddress = ""
+	return nil
 }
 
 // DeleteInstance deletes an instance (mock implementation)
@@ -369,6 +464,31 @@ func (m *MockManager) GetInstanceStats(ctx context.Context, instanceID uuid.UUID
 	return usage, nil
 }
 
+// StreamInstanceStats generates mock resource usage samples every few seconds
+func (m *MockManager) StreamInstanceStats(ctx context.Context, instance *models.Instance, onSample func(*models.ResourceUsage)) error {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			onSample(&models.ResourceUsage{
+				InstanceID:       instance.ID,
+				Timestamp:        time.Now(),
+				CPUUsage:         randomFloat(5, 15),
+				MemoryUsage:      int64(randomInt(50, 200) * 1024 * 1024),
+				MemoryLimit:      int64(instance.MemoryLimit * 1024 * 1024),
+				MemoryPercentage: randomFloat(10, 40),
+				DiskUsage:        int64(randomInt(10, 100) * 1024 * 1024),
+				NetworkIn:        int64(randomInt(1000, 10000)),
+				NetworkOut:       int64(randomInt(1000, 10000)),
+			})
+		}
+	}
+}
+
 // Helper functions for mock data generation
 func randomFloat(min, max float64) float64 {
 	return min + rand.Float64()*(max-min)
@@ -376,4 +496,469 @@ func randomFloat(min, max float64) float64 {
 
 func randomInt(min, max int) int {
 	return min + rand.Intn(max-min)
-} 
\ No newline at end of file
+} 
+
+// GetStorageUsage returns mock storage usage for an instance
+func (m *MockManager) GetStorageUsage(ctx context.Context, instanceID uuid.UUID) (int64, error) {
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+	}).Debug("Mock: Measuring instance storage usage")
+	
+	return int64(randomInt(10, 100) * 1024 * 1024), nil // Random value between 10-100 MB
+}
+
+// UpgradeInstance switches an instance to a new image tag (mock implementation)
+func (m *MockManager) UpgradeInstance(ctx context.Context, instanceID uuid.UUID, imageTag string) error {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	template := m.templates.ForInstance(instance)
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+		"image":       template.ImageFor(imageTag),
+	}).Info("Mock: Upgrading instance")
+	time.Sleep(100 * time.Millisecond)
+
+	now := time.Now()
+	instance.PreviousImageTag = instance.ImageTag
+	instance.PreviousImageDigest = mockImageDigest(template.Image, instance.ImageTag)
+	instance.UpgradedAt = &now
+	instance.ImageTag = imageTag
+	instance.ImageDigest = mockImageDigest(template.Image, imageTag)
+	instance.Status = models.StatusRunning
+	return db.UpdateInstance(instance)
+}
+
+// RollbackInstance switches an instance back to its previous image tag (mock implementation)
+func (m *MockManager) RollbackInstance(ctx context.Context, instanceID uuid.UUID) error {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.PreviousImageDigest == "" {
+		return fmt.Errorf("instance has no previous image to roll back to")
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+		"image":       instance.PreviousImageDigest,
+	}).Info("Mock: Rolling back instance")
+	time.Sleep(100 * time.Millisecond)
+
+	instance.ImageTag = instance.PreviousImageTag
+	instance.ImageDigest = instance.PreviousImageDigest
+	instance.PreviousImageTag = ""
+	instance.PreviousImageDigest = ""
+	instance.UpgradedAt = nil
+	instance.HealthFailures = 0
+	instance.Status = models.StatusRunning
+	return db.UpdateInstance(instance)
+}
+
+// mockImageDigest derives a stable fake registry digest for an image tag
+func mockImageDigest(baseImage, tag string) string {
+	sum := sha256.Sum256([]byte(ImageRef(baseImage, tag)))
+	return strings.TrimSuffix(ImageRef(baseImage, ""), ":") + "@sha256:" + hex.EncodeToString(sum[:])
+}
+
+// CheckHealth reports every instance as healthy (mock implementation)
+func (m *MockManager) CheckHealth(ctx context.Context, instanceID uuid.UUID) error {
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+	}).Debug("Mock: Probing instance health")
+	
+	return nil
+}
+
+// StaleDNSRecords reports no stale DNS records, as the mock creates none (mock implementation)
+func (m *MockManager) StaleDNSRecords(ctx context.Context) ([]DNSRecord, error) {
+	return []DNSRecord{}, nil
+}
+
+// IsolateInstance reports the instance as not moved, as the mock has no networks (mock implementation)
+func (m *MockManager) IsolateInstance(ctx context.Context, instanceID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+// RestrictEgress reports no rules written, as the mock has no containers (mock implementation)
+func (m *MockManager) RestrictEgress(ctx context.Context, instanceID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+// ReconcileInstance reports no changes, as mock instances always match their status (mock implementation)
+func (m *MockManager) ReconcileInstance(ctx context.Context, instanceID uuid.UUID) ([]string, error) {
+	if _, err := db.GetInstanceByID(instanceID); err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	return []string{}, nil
+}
+
+// StreamInstanceLogs writes a few lines resembling n8n's startup output and, if follow is
+// set, a mock line every few seconds (mock implementation)
+func (m *MockManager) StreamInstanceLogs(ctx context.Context, instanceID uuid.UUID, tail int, follow bool, w io.Writer) error {
+	if _, err := db.GetInstanceByID(instanceID); err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	lines := []string{
+		"Initializing n8n process",
+		"n8n ready on 0.0.0.0, port 5678",
+		"Version: mock",
+		"Editor is now accessible via: http://localhost:5678/",
+	}
+	if tail >= 0 && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	if !follow {
+		return nil
+	}
+	
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if _, err := fmt.Fprintf(w, "Mock: n8n is running (%s)\n", now.Format(time.RFC3339)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ExecInstance echoes the session's input back as its output until the input ends (mock
+// implementation)
+func (m *MockManager) ExecInstance(ctx context.Context, instanceID uuid.UUID, options ExecOptions) (int, error) {
+	if _, err := db.GetInstanceByID(instanceID); err != nil {
+		return -1, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if _, err := fmt.Fprintf(options.Stdout, "Mock terminal: %s\r\n", strings.Join(options.Command, " ")); err != nil {
+		return -1, err
+	}
+	if options.Stdin == nil {
+		return 0, nil
+	}
+	
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(options.Stdout, options.Stdin)
+		copied <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	case err := <-copied:
+		if err != nil {
+			return -1, err
+		}
+		return 0, nil
+	}
+}
+
+// mockVolume returns the in-memory files volume of an instance and the cleaned path of a file
+// in it; callers hold filesMu
+func (m *MockManager) mockVolume(instanceID uuid.UUID, raw string) (map[string]mockFile, string, error) {
+	if _, err := db.GetInstanceByID(instanceID); err != nil {
+		return nil, "", fmt.Errorf("failed to get instance: %w", err)
+	}
+	file, err := CleanFilePath(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	if m.files[instanceID] == nil {
+		m.files[instanceID] = map[string]mockFile{}
+	}
+	return m.files[instanceID], file, nil
+}
+
+// mockIsDir reports whether a path is a directory of a mock files volume, i.e. the root or
+// the parent of a file
+func mockIsDir(volume map[string]mockFile, dir string) bool {
+	if dir == "/" {
+		return true
+	}
+	for name := range volume {
+		if strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// StatInstanceFile describes a file or directory of the in-memory files volume (mock implementation)
+func (m *MockManager) StatInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string) (*FileEntry, error) {
+	m.filesMu.Lock()
+	defer m.filesMu.Unlock()
+	volume, file, err := m.mockVolume(instanceID, raw)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := volume[file]; ok {
+		return &FileEntry{Name: path.Base(file), Path: file, Type: "file", Size: int64(len(f.data)), ModifiedAt: f.modifiedAt}, nil
+	}
+	if mockIsDir(volume, file) {
+		return &FileEntry{Name: path.Base(file), Path: file, Type: "directory"}, nil
+	}
+	return nil, ErrFileNotFound
+}
+
+// ListInstanceFiles lists a directory of the in-memory files volume (mock implementation)
+func (m *MockManager) ListInstanceFiles(ctx context.Context, instanceID uuid.UUID, raw string, limit int) ([]FileEntry, bool, error) {
+	m.filesMu.Lock()
+	defer m.filesMu.Unlock()
+	volume, dir, err := m.mockVolume(instanceID, raw)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, ok := volume[dir]; ok {
+		return nil, false, ErrNotDirectory
+	}
+	if !mockIsDir(volume, dir) {
+		return nil, false, ErrFileNotFound
+	}
+	
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	seen := map[string]bool{}
+	entries := []FileEntry{}
+	names := make([]string, 0, len(volume))
+	for name := range volume {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		child, _, nested := strings.Cut(strings.TrimPrefix(name, prefix), "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		if len(entries) == limit {
+			return entries, true, nil
+		}
+		entry := FileEntry{Name: child, Path: prefix + child, Type: "directory"}
+		if !nested {
+			entry.Type = "file"
+			entry.Size = int64(len(volume[name].data))
+			entry.ModifiedAt = volume[name].modifiedAt
+		}
+		entries = append(entries, entry)
+	}
+	return entries, false, nil
+}
+
+// ReadInstanceFile writes a file of the in-memory files volume to w (mock implementation)
+func (m *MockManager) ReadInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string, w io.Writer) error {
+	m.filesMu.Lock()
+	volume, file, err := m.mockVolume(instanceID, raw)
+	f, ok := volume[file]
+	isDir := err == nil && !ok && mockIsDir(volume, file)
+	m.filesMu.Unlock()
+	switch {
+	case err != nil:
+		return err
+	case isDir:
+		return ErrIsDirectory
+	case !ok:
+		return ErrFileNotFound
+	}
+	_, err = w.Write(f.data)
+	return err
+}
+
+// WriteInstanceFile stores a file in the in-memory files volume (mock implementation)
+func (m *MockManager) WriteInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return io.ErrUnexpectedEOF
+	}
+	
+	m.filesMu.Lock()
+	defer m.filesMu.Unlock()
+	volume, file, err := m.mockVolume(instanceID, raw)
+	if err != nil {
+		return err
+	}
+	if mockIsDir(volume, file) {
+		return ErrIsDirectory
+	}
+	volume[file] = mockFile{data: data, modifiedAt: time.Now()}
+	return nil
+}
+
+// DeleteInstanceFile removes a file or directory of the in-memory files volume (mock implementation)
+func (m *MockManager) DeleteInstanceFile(ctx context.Context, instanceID uuid.UUID, raw string) error {
+	m.filesMu.Lock()
+	defer m.filesMu.Unlock()
+	volume, file, err := m.mockVolume(instanceID, raw)
+	if err != nil {
+		return err
+	}
+	if file == "/" {
+		return ErrInvalidFilePath
+	}
+	_, found := volume[file]
+	delete(volume, file)
+	for name := range volume {
+		if strings.HasPrefix(name, file+"/") {
+			delete(volume, name)
+			found = true
+		}
+	}
+	if !found {
+		return ErrFileNotFound
+	}
+	return nil
+}
+
+// ResizeInstance updates an instance's resource limits (mock implementation)
+func (m *MockManager) ResizeInstance(ctx context.Context, instanceID uuid.UUID, cpuLimit float64, memoryLimitMB int) (bool, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get instance: %w", err)
+	}
+	
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+		"cpu_limit":   cpuLimit,
+		"memory_mb":   memoryLimitMB,
+	}).Info("Mock: Resizing instance")
+	
+	instance.CPULimit = cpuLimit
+	instance.MemoryLimit = memoryLimitMB
+	if err := db.UpdateInstance(instance); err != nil {
+		return false, fmt.Errorf("failed to update instance: %w", err)
+	}
+	return false, nil
+}
+
+// RenameInstance changes an instance's display name (mock implementation)
+func (m *MockManager) RenameInstance(ctx context.Context, instanceID uuid.UUID, name string) (*models.Instance, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	
+	name, err = resolveInstanceName(m.config.N8N.NamePolicy, instance.UserID, name, instance.ID)
+	if err != nil {
+		return nil, err
+	}
+	
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+		"new_name":    name,
+	}).Info("Mock: Renaming instance")
+	
+	instance.Name = name
+	if err := db.UpdateInstance(instance); err != nil {
+		return nil, fmt.Errorf("failed to update instance: %w", err)
+	}
+	return instance, nil
+}
+
+// GetInstanceCredentials returns an instance's basic auth credentials, creating them on first use (mock implementation)
+func (m *MockManager) GetInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (*models.InstanceCredential, string, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get instance: %w", err)
+	}
+	
+	if _, _, err := provisioningCredentials(instance); err != nil {
+		return nil, "", err
+	}
+	return loadCredentials(instance.ID)
+}
+
+// RotateInstanceCredentials stores a new basic auth password for an instance (mock implementation)
+func (m *MockManager) RotateInstanceCredentials(ctx context.Context, instanceID uuid.UUID) (*models.InstanceCredential, string, error) {
+	credential, _, err := m.GetInstanceCredentials(ctx, instanceID)
+	if err != nil {
+		return nil, "", err
+	}
+	
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+	}).Info("Mock: Rotating instance credentials")
+	
+	password, err := models.GenerateInstancePassword()
+	if err != nil {
+		return nil, "", err
+	}
+	webhookSecret, err := models.GenerateInstanceWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := credential.SetWebhookSecret(webhookSecret); err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	credential.RotatedAt = &now
+	if err := storeCredentials(credential, password); err != nil {
+		return nil, "", err
+	}
+	return credential, password, nil
+}
+
+// ExportWorkflows returns an empty workflow bundle, as mock instances run no n8n (mock implementation)
+func (m *MockManager) ExportWorkflows(ctx context.Context, instanceID uuid.UUID) (*models.WorkflowBundle, error) {
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+	}).Info("Mock: Exporting instance workflows")
+	
+	return newWorkflowBundle(instanceID), nil
+}
+
+// ImportWorkflows checks the workflows of a bundle and reports them as imported (mock implementation)
+func (m *MockManager) ImportWorkflows(ctx context.Context, instanceID uuid.UUID, bundle *models.WorkflowBundle) (*models.WorkflowImportResult, error) {
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+		"workflows":   len(bundle.Workflows),
+	}).Info("Mock: Importing instance workflows")
+	
+	result := &models.WorkflowImportResult{Workflows: []models.ImportedWorkflow{}}
+	for _, raw := range bundle.Workflows {
+		_, outcome, err := prepareWorkflowImport(raw)
+		if err != nil {
+			outcome.Error = err.Error()
+			result.Failed++
+		} else {
+			outcome.ID = uuid.New().String()
+			result.Imported++
+		}
+		result.Workflows = append(result.Workflows, outcome)
+	}
+	return result, nil
+}
+
+// ArchiveInstanceData writes an archive holding only the backup manifest, as mock instances
+// have no volumes (mock implementation)
+func (m *MockManager) ArchiveInstanceData(ctx context.Context, instanceID uuid.UUID, w io.Writer) error {
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instanceID,
+	}).Info("Mock: Archiving instance data")
+	
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	if err := writeBackupManifest(archive, instance, m.templates.ForInstance(instance)); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
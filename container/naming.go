@@ -0,0 +1,124 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Instance naming policies for duplicate names
+const (
+	NamePolicyReject = "reject"
+	NamePolicySuffix = "suffix"
+)
+
+// ErrDuplicateInstanceName is returned when a user already has an instance with the same name
+var ErrDuplicateInstanceName = errors.New("an instance with this name already exists")
+
+// resolveInstanceName applies the naming policy to a requested name. Names are compared by
+// their slug, since that is what the container name is built from. excludeID skips the
+// instance being renamed.
+func resolveInstanceName(policy string, userID uuid.UUID, name string, excludeID uuid.UUID) (string, error) {
+	name = strings.TrimSpace(name)
+
+	instances, err := db.GetInstancesByUserID(userID)
+	if err != nil {
+		return "", err
+	}
+	taken := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		if instance.ID == excludeID || instance.Status == models.StatusDeleted {
+			continue
+		}
+		taken[models.InstanceNameSlug(instance.Name)] = true
+	}
+
+	if !taken[models.InstanceNameSlug(name)] {
+		return name, nil
+	}
+	if policy != NamePolicySuffix {
+		return "", ErrDuplicateInstanceName
+	}
+
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf(" %d", n)
+		base := name
+		if len(base)+len(suffix) > models.MaxInstanceNameLength {
+			base = strings.TrimSpace(base[:models.MaxInstanceNameLength-len(suffix)])
+		}
+		candidate := base + suffix
+		if !taken[models.InstanceNameSlug(candidate)] {
+			return candidate, nil
+		}
+	}
+}
+
+// maxSubdomainSuffix bounds the numbered suffixes tried for a subdomain another instance has
+const maxSubdomainSuffix = 50
+
+// uniqueSubdomain returns the subdomain of a new instance's container, adding a numbered
+// suffix such as "brave-oak-2" when another instance has it already. The unique index on
+// the host column settles races between instances created at the same time.
+func uniqueSubdomain(containerName string) (string, error) {
+	base := GenerateEasySubdomain(containerName)
+	for n := 1; n <= maxSubdomainSuffix; n++ {
+		subdomain := base
+		if n > 1 {
+			subdomain = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken, err := db.HostTaken(subdomain)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return subdomain, nil
+		}
+	}
+	return "", fmt.Errorf("no free subdomain like %s: %w", base, db.ErrHostTaken)
+}
+
+// RenameInstance changes an instance's display name and renames its container to match.
+// Docker labels cannot change on an existing container, so the name label is refreshed the
+// next time the container is recreated.
+func (m *DockerManager) RenameInstance(ctx context.Context, instanceID uuid.UUID, name string) (*models.Instance, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	name, err = resolveInstanceName(m.config.N8N.NamePolicy, instance.UserID, name, instance.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.ContainerID != "" {
+		containerName := GenerateContainerName(instance.UserID, name)
+		current, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect container: %w", err)
+		}
+		if strings.TrimPrefix(current.Name, "/") != containerName {
+			if err := m.client.ContainerRename(ctx, instance.ContainerID, containerName); err != nil {
+				return nil, fmt.Errorf("failed to rename container: %w", err)
+			}
+		}
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"old_name":    instance.Name,
+		"new_name":    name,
+	}).Info("Renaming instance")
+
+	instance.Name = name
+	if err := db.UpdateInstance(instance); err != nil {
+		return nil, fmt.Errorf("failed to update instance: %w", err)
+	}
+	return instance, nil
+}
@@ -0,0 +1,272 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Network modes of instance containers, set with DOCKER_NETWORK_MODE. Docker isolates bridge
+// networks from each other, so with per-user networks an instance can only reach the other
+// instances of its owner and the platform containers.
+const (
+	NetworkModeShared  = "shared"
+	NetworkModePerUser = "per_user"
+)
+
+// instanceNetwork returns the name of the Docker network a user's instances are attached to
+func (m *DockerManager) instanceNetwork(userID uuid.UUID) string {
+	if m.config.Docker.NetworkMode != NetworkModePerUser {
+		return m.config.Docker.Network
+	}
+	return fmt.Sprintf("%s-%s", m.config.Docker.Network, userID)
+}
+
+// ensureInstanceNetwork creates the network of a user's instances on first use and connects
+// the platform containers that are not attached to it yet
+func (m *DockerManager) ensureInstanceNetwork(ctx context.Context, userID uuid.UUID) (string, error) {
+	name := m.instanceNetwork(userID)
+	if name == m.config.Docker.Network {
+		return name, nil
+	}
+
+	resource, err := m.client.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if client.IsErrNotFound(err) {
+		_, err = m.client.NetworkCreate(ctx, name, types.NetworkCreate{
+			CheckDuplicate: true,
+			Driver:         "bridge",
+			Labels: map[string]string{
+				"com.launchstack.managed": "true",
+				"com.launchstack.user.id": userID.String(),
+			},
+		})
+		// Another replica may have created it in the meantime
+		if err != nil && !errdefs.IsConflict(err) {
+			return "", fmt.Errorf("failed to create network %s: %w", name, err)
+		}
+		m.logger.WithField("network", name).Info("Created instance network")
+		resource, err = m.client.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect network %s: %w", name, err)
+	}
+
+	connected := map[string]bool{}
+	for _, endpoint := range resource.Containers {
+		connected[endpoint.Name] = true
+	}
+	for _, platform := range m.config.Docker.PlatformContainers {
+		if connected[platform] {
+			continue
+		}
+		if err := m.client.NetworkConnect(ctx, name, platform, nil); err != nil {
+			return "", fmt.Errorf("failed to connect %s to network %s: %w", platform, name, err)
+		}
+	}
+	return name, nil
+}
+
+// containerIP returns the IP address of an instance's container on its user's network or,
+// for containers created before per-user networks were enabled, on the shared network
+func (m *DockerManager) containerIP(inspected types.ContainerJSON, userID uuid.UUID) (string, error) {
+	name := m.instanceNetwork(userID)
+	if inspected.NetworkSettings != nil {
+		for _, network := range []string{name, m.config.Docker.Network} {
+			if endpoint, ok := inspected.NetworkSettings.Networks[network]; ok && endpoint.IPAddress != "" {
+				return endpoint.IPAddress, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("container has no IP address on network %s", name)
+}
+
+// removeUnusedInstanceNetwork removes a user's network once no container of theirs, running
+// or not, is attached to it. The platform containers are disconnected first.
+func (m *DockerManager) removeUnusedInstanceNetwork(ctx context.Context, userID uuid.UUID) {
+	name := m.instanceNetwork(userID)
+	if name == m.config.Docker.Network {
+		return
+	}
+	logger := m.logger.WithField("network", name)
+
+	platform := map[string]bool{}
+	for _, container := range m.config.Docker.PlatformContainers {
+		platform[container] = true
+	}
+	containers, err := m.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("network", name)),
+	})
+	if err != nil {
+		logger.WithError(err).Warn("Failed to list containers of instance network")
+		return
+	}
+	for _, container := range containers {
+		attached := false
+		for _, containerName := range container.Names {
+			attached = attached || platform[containerName[1:]] // names start with a slash
+		}
+		if !attached {
+			return
+		}
+	}
+
+	for _, container := range containers {
+		if err := m.client.NetworkDisconnect(ctx, name, container.ID, true); err != nil && !client.IsErrNotFound(err) {
+			logger.WithError(err).Warn("Failed to disconnect platform container from instance network")
+			return
+		}
+	}
+	if err := m.client.NetworkRemove(ctx, name); err != nil && !client.IsErrNotFound(err) {
+		logger.WithError(err).Warn("Failed to remove instance network")
+		return
+	}
+	logger.Info("Removed network of user without instances")
+}
+
+// IsolateInstance moves the container of a running instance created before per-user networks
+// were enabled from the shared network to its user's network, without restarting it, and
+// reports whether it was moved. The internal DNS record follows the container's new address.
+// Stopped instances are moved once they run again.
+func (m *DockerManager) IsolateInstance(ctx context.Context, instanceID uuid.UUID) (bool, error) {
+	if m.config.Docker.NetworkMode != NetworkModePerUser {
+		return false, nil
+	}
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return false, nil
+	}
+
+	inspected, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if inspected.State == nil || !inspected.State.Running || inspected.NetworkSettings == nil {
+		return false, nil
+	}
+
+	// Also reconnects platform containers that were recreated since the network was created
+	name, err := m.ensureInstanceNetwork(ctx, instance.UserID)
+	if err != nil {
+		return false, err
+	}
+	if _, shared := inspected.NetworkSettings.Networks[m.config.Docker.Network]; !shared {
+		return false, nil
+	}
+	if _, ok := inspected.NetworkSettings.Networks[name]; !ok {
+		if err := m.client.NetworkConnect(ctx, name, instance.ContainerID, nil); err != nil {
+			return false, fmt.Errorf("failed to connect container to network %s: %w", name, err)
+		}
+		if inspected, err = m.client.ContainerInspect(ctx, instance.ContainerID); err != nil {
+			return false, fmt.Errorf("failed to inspect container: %w", err)
+		}
+	}
+	endpoint, ok := inspected.NetworkSettings.Networks[name]
+	if !ok || endpoint.IPAddress == "" {
+		return false, fmt.Errorf("container has no IP address on network %s", name)
+	}
+
+	// Point the internal DNS name at the new address before the old one goes away
+	if !m.dns.Public() {
+		record := m.instanceDNSRecord(instance, endpoint.IPAddress)
+		if err := m.dns.SetRecord(ctx, record.Name, record.Answer); err != nil {
+			return false, fmt.Errorf("failed to update DNS record %s: %w", record.Name, err)
+		}
+	}
+	if err := db.SetInstanceIPAddress(instance.ID, endpoint.IPAddress); err != nil {
+		return false, fmt.Errorf("failed to record IP address: %w", err)
+	}
+	if err := m.client.NetworkDisconnect(ctx, m.config.Docker.Network, instance.ContainerID, false); err != nil {
+		return false, fmt.Errorf("failed to disconnect container from network %s: %w", m.config.Docker.Network, err)
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"network":     name,
+		"ip":          endpoint.IPAddress,
+	}).Info("Moved instance to its user's network")
+	return true, nil
+}
+
+// NetworkIsolator moves instances from the shared network to their user's network and
+// reconnects platform containers, e.g. a recreated reverse proxy, to the user networks
+type NetworkIsolator struct {
+	manager Manager
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewNetworkIsolator creates a new network isolator
+func NewNetworkIsolator(manager Manager, cfg *config.Config, logger *logrus.Logger) *NetworkIsolator {
+	return &NetworkIsolator{
+		manager: manager,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Start isolates instances right away and then on the configured interval until the context
+// is cancelled. Nothing is done with the shared network mode.
+func (n *NetworkIsolator) Start(ctx context.Context) {
+	if n.config.Docker.NetworkMode != NetworkModePerUser {
+		return
+	}
+	interval := n.config.Docker.NetworkCheckInterval
+	n.logger.Infof("Starting network isolation of instances every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("network_isolator", interval)
+	singleton := lease.NewSingleton("network_isolator", interval, n.config, n.logger)
+
+	for {
+		if singleton.Acquire() {
+			loop.Run(func() { n.IsolateAll(ctx) })
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsolateAll isolates every running instance
+func (n *NetworkIsolator) IsolateAll(ctx context.Context) {
+	instances, err := db.GetInstancesByStatus(models.StatusRunning)
+	if err != nil {
+		n.logger.WithError(err).Error("Failed to fetch instances for network isolation")
+		return
+	}
+
+	moved := 0
+	for _, instance := range instances {
+		isolateCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		ok, err := n.manager.IsolateInstance(isolateCtx, instance.ID)
+		cancel()
+		if err != nil {
+			n.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to isolate instance network")
+			continue
+		}
+		if ok {
+			moved++
+		}
+	}
+	if moved > 0 {
+		n.logger.WithField("instances", moved).Info("Moved instances to their user's network")
+	}
+}
@@ -0,0 +1,350 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ProvisioningTracker receives the progress of an instance being provisioned
+type ProvisioningTracker interface {
+	// StepStarted is called before a provisioning step runs
+	StepStarted(step models.ProvisioningStepName)
+	// StepFinished is called after a provisioning step, with the error it failed with if any
+	StepFinished(step models.ProvisioningStepName, err error)
+}
+
+// trackStep runs a provisioning step and reports it to the tracker
+func trackStep(tracker ProvisioningTracker, step models.ProvisioningStepName, run func() error) error {
+	tracker.StepStarted(step)
+	err := run()
+	tracker.StepFinished(step, err)
+	return err
+}
+
+// PrepareInstance builds the record of a new instance without creating any resources
+func (m *DockerManager) PrepareInstance(ctx context.Context, user models.User, instanceReq models.Instance) (*models.Instance, error) {
+	// Check if user has reached their instance limit
+	instancesLimit := user.GetInstancesLimit()
+	if instancesLimit <= 0 {
+		return nil, fmt.Errorf("user has no instance allocation")
+	}
+
+	template, err := m.templates.Offered(instanceReq.ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Duplicate names would collide at the container level
+	name, err := resolveInstanceName(m.config.N8N.NamePolicy, user.ID, instanceReq.Name, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate container name and subdomain
+	containerName := GenerateContainerName(user.ID, name)
+	subdomain, err := uniqueSubdomain(containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	cpuCores, memoryLimitMB, storageLimit := ResolveResourceLimits(user, instanceReq)
+	if err := checkCapacity(ctx, m.config, cpuCores, memoryLimitMB); err != nil {
+		return nil, err
+	}
+	instance := &models.Instance{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		ProjectID:      instanceReq.ProjectID,
+		OrganizationID: instanceReq.OrganizationID,
+		Name:           name,
+		Description:    instanceReq.Description,
+		ServiceType:    template.Service,
+		Status:         models.StatusPending,
+		Host:           subdomain,
+		Port:           template.Port,
+		URL:            fmt.Sprintf("%s.%s", subdomain, m.config.Server.Domain),
+		ImageTag:       resolveImageTag(instanceReq),
+		CPULimit:       cpuCores,
+		MemoryLimit:    memoryLimitMB,
+		StorageLimit:   storageLimit,
+	}
+	instance.ProvisioningSpec = newProvisioningSpec(user, instance, m.config.Health.Region, template.ImageFor(instance.ImageTag), template.Env(instance, "", ""))
+
+	return instance, nil
+}
+
+// instanceEnv returns the environment of a new instance's container with its basic auth
+// password and the secret it signs its n8n webhook events with
+func instanceEnv(instance *models.Instance, password, webhookSecret string) []string {
+	return []string{
+		"NODE_ENV=production",
+		fmt.Sprintf("N8N_HOST=%s", instance.URL),
+		"N8N_PROTOCOL=https",
+		fmt.Sprintf("WEBHOOK_URL=https://%s", instance.URL),
+		"N8N_BASIC_AUTH_ACTIVE=true",
+		fmt.Sprintf("N8N_BASIC_AUTH_USER=%s", instance.Host),
+		fmt.Sprintf("N8N_BASIC_AUTH_PASSWORD=%s", password),
+		fmt.Sprintf("%s=%s", instanceIDEnv, instance.ID),
+		fmt.Sprintf("%s=%s", webhookSecretEnv, webhookSecret),
+	}
+}
+
+// ProvisionInstance pulls the image of the instance's service, creates and starts the
+// container of a prepared instance and adds its DNS record. If a step fails, whatever was
+// created is removed again.
+func (m *DockerManager) ProvisionInstance(ctx context.Context, user models.User, instance *models.Instance, tracker ProvisioningTracker) error {
+	containerName := GenerateContainerName(user.ID, instance.Name)
+	template := m.templates.ForInstance(instance)
+	mounts := make([]mount.Mount, len(template.Volumes))
+	for i, volume := range template.Volumes {
+		mounts[i] = mount.Mount{Type: mount.TypeVolume, Source: volumeName(containerName, volume.Name), Target: volume.Path}
+	}
+	logger := m.logger.WithFields(logrus.Fields{
+		"instance_id":    instance.ID,
+		"container_name": containerName,
+	})
+
+	// A provisioning attempt interrupted by a restart may have left its container behind
+	if err := m.client.ContainerRemove(ctx, containerName, types.ContainerRemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to remove leftover container: %w", err)
+	}
+
+	// Create the volumes up front, labelled with the instance they belong to; volumes left by
+	// an earlier attempt are reused
+	if err := trackStep(tracker, models.StepCreateVolumes, func() error {
+		for _, mnt := range mounts {
+			if _, err := m.client.VolumeCreate(ctx, volumetypes.VolumeCreateBody{
+				Name: mnt.Source,
+				Labels: map[string]string{
+					"com.launchstack.instance.id": instance.ID.String(),
+					"com.launchstack.managed":     "true",
+				},
+			}); err != nil {
+				return fmt.Errorf("failed to create volume %s: %w", mnt.Source, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		m.cleanUpStep(ctx, instance, tracker)
+		return err
+	}
+
+	// Pull the service's image for the requested version, unless it is already on the host
+	image := template.ImageFor(instance.ImageTag)
+	if err := trackStep(tracker, models.StepPullImage, func() error {
+		return m.ensureImage(ctx, image)
+	}); err != nil {
+		m.cleanUpStep(ctx, instance, tracker)
+		return err
+	}
+
+	err := trackStep(tracker, models.StepCreateContainer, func() error {
+		// Only n8n has a basic auth login and reports workflow events
+		var password, webhookSecret string
+		if template.Workflows {
+			var err error
+			if password, webhookSecret, err = provisioningCredentials(instance); err != nil {
+				return err
+			}
+		}
+		networkName, err := m.ensureInstanceNetwork(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		cpuSet := m.assignCPUSet(&user, instance, instance.CPULimit)
+		logger.WithFields(logrus.Fields{
+			"image":        image,
+			"network":      networkName,
+			"memory_mb":    instance.MemoryLimit,
+			"cpu_limit":    instance.CPULimit,
+			"cpu_set":      cpuSet,
+			"service_type": template.Service,
+			"volumes":      len(mounts),
+		}).Debug("Creating Docker container")
+
+		resp, err := m.client.ContainerCreate(
+			ctx,
+			&container.Config{
+				Image: image,
+				Env:   template.Env(instance, password, webhookSecret),
+				User:  template.User,
+				// Expose the port the proxy forwards to
+				ExposedPorts: map[nat.Port]struct{}{
+					nat.Port(fmt.Sprintf("%d/tcp", template.Port)): {},
+				},
+				Labels: map[string]string{
+					"com.launchstack.instance.id":   instance.ID.String(),
+					"com.launchstack.user.id":       user.ID.String(),
+					"com.launchstack.instance.name": instance.Name,
+					"com.launchstack.service.type":  string(template.Service),
+					"com.launchstack.managed":       "true",
+					// Watchtower labels for automatic updates; pinned versions are only changed by upgrades
+					"com.centurylinklabs.watchtower.enable":                strconv.FormatBool(!instance.IsPinned()),
+					"com.centurylinklabs.watchtower.stop-signal":           "SIGTERM",
+					"com.centurylinklabs.watchtower.timeout":               "60s",
+					"com.centurylinklabs.watchtower.cleanup":               "true",
+					"com.centurylinklabs.watchtower.lifecycle.pre-update":  "touch /tmp/pre-update",
+					"com.centurylinklabs.watchtower.lifecycle.post-update": "touch /tmp/post-update",
+				},
+			},
+			&container.HostConfig{
+				RestartPolicy: container.RestartPolicy{
+					Name: "always",
+				},
+				NetworkMode: container.NetworkMode(networkName),
+				// Use Docker volumes instead of bind mounts
+				Mounts: mounts,
+				Resources: container.Resources{
+					Memory: int64(instance.MemoryLimit) * 1024 * 1024, // Convert MB to bytes
+					// Convert CPU cores to nano CPUs (1 core = 1000000000 nano CPUs)
+					NanoCPUs: int64(instance.CPULimit * 1000000000),
+					// Dedicated CPUs for pinned instances, the shared ones for all others
+					CpusetCpus: cpuSet,
+				},
+			},
+			&network.NetworkingConfig{
+				EndpointsConfig: map[string]*network.EndpointSettings{
+					networkName: {
+						NetworkID: networkName,
+					},
+				},
+			},
+			nil,
+			containerName,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create container: %w", err)
+		}
+		instance.ContainerID = resp.ID
+		return nil
+	})
+	if err != nil {
+		m.cleanUpStep(ctx, instance, tracker)
+		return err
+	}
+
+	err = trackStep(tracker, models.StepStartContainer, func() error {
+		if err := m.client.ContainerStart(ctx, instance.ContainerID, types.ContainerStartOptions{}); err != nil {
+			return fmt.Errorf("failed to start container: %w", err)
+		}
+		if _, err := m.restrictEgress(ctx, instance, instance.ContainerID); err != nil {
+			return fmt.Errorf("failed to restrict egress: %w", err)
+		}
+
+		// Get the container's IP address in the n8n network
+		inspect, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+		if err != nil {
+			return fmt.Errorf("failed to inspect container: %w", err)
+		}
+		ip, err := m.containerIP(inspect, instance.UserID)
+		if err != nil {
+			return err
+		}
+		instance.IPAddress = ip
+		return nil
+	})
+	if err != nil {
+		m.cleanUpStep(ctx, instance, tracker)
+		return err
+	}
+
+	// Create the DNS record the instance is reached by
+	record := m.instanceDNSRecord(instance, instance.IPAddress)
+	err = trackStep(tracker, models.StepDNS, func() error {
+		if err := m.dns.SetRecord(ctx, record.Name, record.Answer); err != nil {
+			return fmt.Errorf("failed to add DNS record %s: %w", record.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		m.cleanUpStep(ctx, instance, tracker)
+		return err
+	}
+
+	// The instance only counts as provisioned once its service answers
+	err = trackStep(tracker, models.StepHealthCheck, func() error {
+		return m.waitHealthy(ctx, instance, instance.IPAddress, m.config.Provisioning.HealthTimeout)
+	})
+	if err != nil {
+		m.cleanUpStep(ctx, instance, tracker)
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"container_id": instance.ContainerID,
+		"domain":       record.Name,
+		"ip":           instance.IPAddress,
+	}).Info("Provisioned instance container")
+	return nil
+}
+
+// cleanUpStep removes what a failed provisioning attempt created, reporting it as the cleanup
+// step. The error of the step that failed is what the attempt fails with.
+func (m *DockerManager) cleanUpStep(ctx context.Context, instance *models.Instance, tracker ProvisioningTracker) {
+	_ = trackStep(tracker, models.StepCleanup, func() error {
+		return m.DeprovisionInstance(ctx, instance)
+	})
+}
+
+// DeprovisionInstance removes the container, volumes and DNS record of an instance whose
+// provisioning failed. Resources that were never created are skipped; the error lists those
+// that could not be removed.
+func (m *DockerManager) DeprovisionInstance(ctx context.Context, instance *models.Instance) error {
+	// The provisioning context may already have been cancelled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+	defer cancel()
+
+	containerName := GenerateContainerName(instance.UserID, instance.Name)
+	logger := m.logger.WithFields(logrus.Fields{
+		"instance_id":    instance.ID,
+		"container_name": containerName,
+	})
+
+	var errs []error
+	if err := m.client.ContainerRemove(ctx, containerName, types.ContainerRemoveOptions{Force: true}); err != nil && !client.IsErrNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to remove container: %w", err))
+	}
+
+	// Volumes may not have been created yet
+	volumes, err := m.existingInstanceVolumes(ctx, containerName)
+	if err == nil {
+		err = m.removeVolumes(ctx, volumes)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to remove volumes: %w", err))
+	}
+
+	if instance.IPAddress != "" {
+		expected := m.instanceDNSRecord(instance, instance.IPAddress)
+		if record, err := m.dns.FindRecord(ctx, expected.Name); err == nil && record.Answer == expected.Answer {
+			if err := m.dns.DeleteRecord(ctx, expected.Name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove DNS record %s: %w", expected.Name, err))
+			}
+		}
+	}
+
+	m.removeUnusedInstanceNetwork(ctx, instance.UserID)
+	m.releaseCPUSet(instance)
+	instance.ContainerID = ""
+	instance.IPAddress = ""
+	if err := errors.Join(errs...); err != nil {
+		logger.WithError(err).Warn("Failed to remove some resources of failed provisioning")
+		return err
+	}
+	logger.Info("Removed resources of failed provisioning")
+	return nil
+}
@@ -0,0 +1,134 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+)
+
+// ErrInstanceBusy is returned when an instance is being provisioned, upgraded or deleted, so
+// its container must be left to the job doing so
+var ErrInstanceBusy = errors.New("instance is busy with another operation")
+
+// ReconcileInstance brings the container of an instance in line with its recorded status. The
+// containers of running instances, and of instances marked as unhealthy, are started, moved to
+// their user's network and given their DNS record and IP address again; an unhealthy instance
+// whose n8n responds is marked as running. The containers of stopped, sleeping, suspended,
+// trashed and expired instances are stopped. Containers that no longer exist are not recreated.
+func (m *DockerManager) ReconcileInstance(ctx context.Context, instanceID uuid.UUID) ([]string, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	var wantRunning bool
+	switch instance.Status {
+	case models.StatusRunning, models.StatusError:
+		wantRunning = true
+	case models.StatusStopped, models.StatusSleeping, models.StatusSuspended, models.StatusTrashed, models.InstanceStatusExpired:
+		wantRunning = false
+	default:
+		return nil, fmt.Errorf("%w: instance is %s", ErrInstanceBusy, instance.Status)
+	}
+	if instance.ContainerID == "" {
+		return nil, fmt.Errorf("instance has no container ID")
+	}
+
+	changes := []string{}
+	inspected, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return changes, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	running := inspected.State != nil && inspected.State.Running
+
+	if !wantRunning {
+		if running {
+			timeout := 30 * time.Second
+			if err := m.client.ContainerStop(ctx, instance.ContainerID, &timeout); err != nil {
+				return changes, fmt.Errorf("failed to stop container: %w", err)
+			}
+			changes = append(changes, fmt.Sprintf("stopped the container of the %s instance", instance.Status))
+		}
+		m.logChanges(instance, changes)
+		return changes, nil
+	}
+
+	if !running {
+		if err := m.client.ContainerStart(ctx, instance.ContainerID, types.ContainerStartOptions{}); err != nil {
+			return changes, fmt.Errorf("failed to start container: %w", err)
+		}
+		changes = append(changes, "started the container")
+	}
+	moved, err := m.IsolateInstance(ctx, instance.ID)
+	if err != nil {
+		return changes, err
+	}
+	if moved {
+		changes = append(changes, "moved the container to its user's network")
+	}
+	restricted, err := m.restrictEgress(ctx, instance, instance.ContainerID)
+	if err != nil {
+		return changes, fmt.Errorf("failed to restrict egress: %w", err)
+	}
+	if restricted {
+		changes = append(changes, "wrote the egress rules of the container")
+	}
+
+	if inspected, err = m.client.ContainerInspect(ctx, instance.ContainerID); err != nil {
+		return changes, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	ip, err := m.containerIP(inspected, instance.UserID)
+	if err != nil {
+		return changes, err
+	}
+	if ip != instance.IPAddress {
+		if err := db.SetInstanceIPAddress(instance.ID, ip); err != nil {
+			return changes, fmt.Errorf("failed to record IP address: %w", err)
+		}
+		changes = append(changes, fmt.Sprintf("recorded IP address %s instead of %q", ip, instance.IPAddress))
+		instance.IPAddress = ip
+	}
+
+	// Deployments without a DNS provider manage their records themselves
+	if m.dns.Name() != "none" {
+		record := m.instanceDNSRecord(instance, ip)
+		current, err := m.dns.FindRecord(ctx, record.Name)
+		if err != nil && !errors.Is(err, ErrDNSRecordNotFound) {
+			return changes, fmt.Errorf("failed to look up DNS record %s: %w", record.Name, err)
+		}
+		if current == nil || current.Answer != record.Answer {
+			if err := m.dns.SetRecord(ctx, record.Name, record.Answer); err != nil {
+				return changes, fmt.Errorf("failed to set DNS record %s: %w", record.Name, err)
+			}
+			changes = append(changes, fmt.Sprintf("pointed DNS record %s at %s", record.Name, record.Answer))
+		}
+	}
+
+	if instance.Status == models.StatusError {
+		if err := m.probeHealth(ctx, instance, ip); err == nil {
+			instance.Status = models.StatusRunning
+			instance.HealthFailures = 0
+			if err := db.UpdateInstance(instance); err != nil {
+				return changes, fmt.Errorf("failed to update instance status: %w", err)
+			}
+			changes = append(changes, "marked the instance as running, as its service responds again")
+		}
+	}
+
+	m.logChanges(instance, changes)
+	return changes, nil
+}
+
+// logChanges logs the changes reconciling an instance made, if any
+func (m *DockerManager) logChanges(instance *models.Instance, changes []string) {
+	if len(changes) == 0 {
+		return
+	}
+	m.logger.WithField("instance_id", instance.ID).WithField("changes", changes).Info("Reconciled instance")
+}
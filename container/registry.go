@@ -0,0 +1,66 @@
+package container
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// dockerHub is the registry host of images named without one, e.g. n8nio/n8n
+const dockerHub = "docker.io"
+
+// dockerHubServer is the address Docker expects in credentials for Docker Hub
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// splitImageRegistry splits an image reference into its registry host and the path within
+// it. The first component is a host if it has a dot or port, or is localhost, as in Docker.
+func splitImageRegistry(image string) (string, string) {
+	host, rest, found := strings.Cut(image, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return dockerHub, image
+	}
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		host = dockerHub
+	}
+	return host, rest
+}
+
+// MirrorImage returns the reference to pull a Docker Hub image through a pull-through mirror,
+// e.g. n8nio/n8n:1.0 as mirror.example.com/n8nio/n8n:1.0. Images of other registries and
+// images pinned by digest are returned as they are, as is every image without a mirror.
+func MirrorImage(image, mirror string) string {
+	host, path := splitImageRegistry(image)
+	if mirror == "" || host != dockerHub || strings.Contains(image, "@") {
+		return image
+	}
+	// Official images live under library/, which only Docker Hub fills in by itself
+	if !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return mirror + "/" + path
+}
+
+// registryAuth returns the encoded credentials for the registry of an image, or "" to pull
+// anonymously
+func (m *DockerManager) registryAuth(image string) (string, error) {
+	host, _ := splitImageRegistry(image)
+	credential, ok := m.config.Registry.Credentials[host]
+	if !ok {
+		return "", nil
+	}
+	server := host
+	if host == dockerHub {
+		server = dockerHubServer
+	}
+	encoded, err := json.Marshal(types.AuthConfig{
+		Username:      credential.Username,
+		Password:      credential.Password,
+		ServerAddress: server,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(encoded), nil
+}
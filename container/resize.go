@@ -0,0 +1,75 @@
+package container
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/sirupsen/logrus"
+)
+
+// resourcesFor converts instance limits and the instance's cpuset to Docker resource settings
+func resourcesFor(cpuLimit float64, memoryLimitMB int, cpuSet string) container.Resources {
+	memory := int64(memoryLimitMB) * 1024 * 1024
+	return container.Resources{
+		Memory: memory,
+		// Swap must be at least the memory limit, and would otherwise block lowering it
+		MemorySwap: memory,
+		NanoCPUs:   int64(cpuLimit * 1000000000),
+		CpusetCpus: cpuSet,
+	}
+}
+
+// ResizeInstance changes the CPU and memory limits of an instance. Running containers are
+// updated in place; the container is only recreated if the runtime rejects the live update.
+// It reports whether the container had to be recreated.
+func (m *DockerManager) ResizeInstance(ctx context.Context, instanceID uuid.UUID, cpuLimit float64, memoryLimitMB int) (bool, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	logger := m.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"cpu_limit":   cpuLimit,
+		"memory_mb":   memoryLimitMB,
+	})
+
+	recreated := false
+	if instance.ContainerID != "" {
+		user, err := db.GetUserByID(instance.UserID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get instance owner: %w", err)
+		}
+
+		// Pinned instances get as many dedicated CPUs as their new limit needs
+		previousCPUSet := instance.CPUSet
+		resources := resourcesFor(cpuLimit, memoryLimitMB, m.assignCPUSet(&user, instance, cpuLimit))
+		_, err = m.client.ContainerUpdate(ctx, instance.ContainerID, container.UpdateConfig{Resources: resources})
+		if err != nil {
+			logger.WithError(err).Warn("Live resource update rejected, recreating container")
+			err = m.recreateContainer(ctx, instance, logger, func(config *container.Config, hostConfig *container.HostConfig) {
+				hostConfig.Memory = resources.Memory
+				hostConfig.MemorySwap = resources.MemorySwap
+				hostConfig.NanoCPUs = resources.NanoCPUs
+				hostConfig.CpusetCpus = resources.CpusetCpus
+			})
+			if err != nil {
+				m.restoreCPUSet(instance, previousCPUSet)
+				return false, fmt.Errorf("failed to resize instance: %w", err)
+			}
+			recreated = true
+		}
+	}
+
+	instance.CPULimit = cpuLimit
+	instance.MemoryLimit = memoryLimitMB
+	if err := db.UpdateInstance(instance); err != nil {
+		return recreated, fmt.Errorf("resize succeeded but failed to update instance: %w", err)
+	}
+
+	logger.WithField("recreated", recreated).Info("Instance resources updated")
+	return recreated, nil
+}
@@ -3,15 +3,16 @@ package container
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
 )
 
 // GenerateContainerName creates a unique container name for a user instance
 func GenerateContainerName(userID uuid.UUID, instanceName string) string {
-	// Remove any spaces and special characters from the instance name
-	sanitizedName := strings.ToLower(strings.ReplaceAll(instanceName, " ", "-"))
-	sanitizedName = strings.ReplaceAll(sanitizedName, "_", "-")
+	// Reduce the name to characters Docker allows in container names
+	sanitizedName := models.InstanceNameSlug(instanceName)
 	
 	// Create a unique identifier by combining user ID (first 8 chars) and sanitized name
 	return fmt.Sprintf("n8n-%s-%s", userID.String()[:8], sanitizedName)
@@ -68,4 +69,63 @@ func GenerateEasySubdomain(containerName string) string {
 	subdomain := fmt.Sprintf("%s-%s", firstList[firstIndex], secondList[secondIndex])
 	
 	return subdomain
-} 
\ No newline at end of file
+} 
+// resolveImageTag returns the n8n image tag requested for a new instance
+func resolveImageTag(instanceReq models.Instance) string {
+	if instanceReq.ImageTag == "" {
+		return models.LatestImageTag
+	}
+	return instanceReq.ImageTag
+}
+
+// ImageRef returns the image reference for a tag of the base image, replacing any tag
+// the base image already has
+func ImageRef(baseImage, tag string) string {
+	repo := baseImage
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return repo + ":" + tag
+}
+
+// newProvisioningSpec records the resolved inputs an instance was created from, in the
+// region of the host creating it, redacting secret environment values
+func newProvisioningSpec(user models.User, instance *models.Instance, region, image string, env []string) *models.ProvisioningSpec {
+	return &models.ProvisioningSpec{
+		SpecVersion:  models.ProvisioningSpecVersion,
+		Name:         instance.Name,
+		Description:  instance.Description,
+		ProjectID:    instance.ProjectID,
+		Template:     string(instance.Service()),
+		Region:       region,
+		Image:        image,
+		ImageTag:     instance.ImageTag,
+		CPULimit:     instance.CPULimit,
+		MemoryLimit:  instance.MemoryLimit,
+		StorageLimit: instance.StorageLimit,
+		Env:          models.RedactEnv(env),
+		Plan:         user.Plan,
+		CreatedAt:    time.Now().UTC(),
+	}
+}
+
+// ResolveResourceLimits returns the resource limits for a new instance, using the
+// requested limits when set (e.g. from project defaults) and the plan limits otherwise
+func ResolveResourceLimits(user models.User, instanceReq models.Instance) (float64, int, int) {
+	cpuLimit := user.GetCPULimit()
+	if instanceReq.CPULimit > 0 && instanceReq.CPULimit < cpuLimit {
+		cpuLimit = instanceReq.CPULimit
+	}
+	
+	memoryLimit := user.GetMemoryLimit()
+	if instanceReq.MemoryLimit > 0 && instanceReq.MemoryLimit < memoryLimit {
+		memoryLimit = instanceReq.MemoryLimit
+	}
+	
+	storageLimit := user.GetStorageLimit()
+	if instanceReq.StorageLimit > 0 && instanceReq.StorageLimit < storageLimit {
+		storageLimit = instanceReq.StorageLimit
+	}
+	
+	return cpuLimit, memoryLimit, storageLimit
+}
@@ -0,0 +1,229 @@
+package container
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// UsageRecorder receives the resource usage samples collected from running instances
+type UsageRecorder interface {
+	// Record handles the sample of an instance that covers the monitoring interval
+	Record(instance models.Instance, usage *models.ResourceUsage, interval time.Duration)
+
+	// Forget drops what is kept about an instance that is no longer running
+	Forget(instanceID uuid.UUID)
+}
+
+// statsStream is the open stats stream of a running instance's container
+type statsStream struct {
+	instance models.Instance
+	cancel   context.CancelFunc
+	done     bool
+	started  time.Time
+	latest   *models.ResourceUsage // newest sample not yet collected
+	received time.Time             // when the stream last produced a sample
+}
+
+// StatsCollector keeps a streaming stats connection open to the container of every running
+// instance, and hands the newest sample of each instance to the writer every monitoring
+// interval
+type StatsCollector struct {
+	manager  Manager
+	writer   *db.ResourceUsageWriter
+	recorder UsageRecorder
+	config   *config.Config
+	logger   *logrus.Logger
+
+	interval        atomic.Int64  // monitoring interval, changed by SetInterval
+	intervalChanged chan struct{} // signals Start to reset its ticker
+
+	mu      sync.Mutex
+	streams map[uuid.UUID]*statsStream
+}
+
+// NewStatsCollector creates a new resource usage collector that saves samples with writer
+// and hands them to recorder
+func NewStatsCollector(manager Manager, writer *db.ResourceUsageWriter, recorder UsageRecorder, cfg *config.Config, logger *logrus.Logger) *StatsCollector {
+	s := &StatsCollector{
+		manager:         manager,
+		writer:          writer,
+		recorder:        recorder,
+		config:          cfg,
+		logger:          logger,
+		intervalChanged: make(chan struct{}, 1),
+		streams:         make(map[uuid.UUID]*statsStream),
+	}
+	s.interval.Store(int64(cfg.Monitoring.Interval))
+	return s
+}
+
+// Interval returns the monitoring interval samples are collected on
+func (s *StatsCollector) Interval() time.Duration {
+	return time.Duration(s.interval.Load())
+}
+
+// SetInterval changes the monitoring interval, e.g. when the configuration is reloaded. The
+// next sample is collected one new interval from now.
+func (s *StatsCollector) SetInterval(interval time.Duration) {
+	if interval <= 0 || s.interval.Swap(int64(interval)) == int64(interval) {
+		return
+	}
+	select {
+	case s.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// Start collects samples on the configured interval until the context is cancelled. Only
+// the replica holding the resource_usage lease streams stats.
+func (s *StatsCollector) Start(ctx context.Context) {
+	interval := s.Interval()
+	s.logger.Infof("Starting resource usage monitoring every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("resource_usage", interval)
+	singleton := lease.NewSingleton("resource_usage", interval, s.config, s.logger)
+
+	defer s.closeAll()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping resource usage monitoring")
+			return
+		case <-s.intervalChanged:
+			// The lease lasts as long as the interval, so it is renewed on the new one
+			interval = s.Interval()
+			s.logger.Infof("Changing resource usage monitoring to every %v", interval)
+			ticker.Reset(interval)
+			loop = metrics.NewLoop("resource_usage", interval)
+			singleton = lease.NewSingleton("resource_usage", interval, s.config, s.logger)
+		case <-ticker.C:
+			if !singleton.Acquire() {
+				s.closeAll()
+				continue
+			}
+			loop.Run(func() { s.Collect(ctx) })
+		}
+	}
+}
+
+// Collect opens streams for instances that started running, closes those of instances that
+// stopped, and buffers the newest sample of every running instance for writing
+func (s *StatsCollector) Collect(ctx context.Context) {
+	instances, err := db.GetInstancesByStatus(models.StatusRunning)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to fetch instances for resource monitoring")
+		return
+	}
+
+	running := make(map[uuid.UUID]bool, len(instances))
+	now := time.Now()
+	interval := s.Interval()
+	staleAfter := 2 * interval
+	var batch []models.ResourceUsage
+	var sampled []models.Instance
+	stale := 0
+
+	s.mu.Lock()
+	for _, instance := range instances {
+		if instance.ContainerID == "" {
+			continue
+		}
+		running[instance.ID] = true
+
+		stream, ok := s.streams[instance.ID]
+		if ok && stream.instance.ContainerID != instance.ContainerID {
+			// The container was recreated, e.g. by a resize or upgrade
+			stream.cancel()
+			ok = false
+		}
+		if !ok {
+			s.open(ctx, instance)
+			continue
+		}
+
+		if stream.latest != nil {
+			// The stream's copy of the instance is as old as the stream
+			stream.latest.DiskUsage = instance.StorageUsage
+			batch = append(batch, *stream.latest)
+			sampled = append(sampled, instance)
+			stream.latest = nil
+			continue
+		}
+
+		stale++
+		lastSeen := stream.received
+		if lastSeen.IsZero() {
+			lastSeen = stream.started
+		}
+		if stream.done || now.Sub(lastSeen) > staleAfter {
+			s.logger.WithField("instance_id", instance.ID).Debug("Reopening container stats stream")
+			stream.cancel()
+			s.open(ctx, instance)
+			metrics.StatsStreamRestarted()
+		}
+	}
+
+	for instanceID, stream := range s.streams {
+		if !running[instanceID] {
+			stream.cancel()
+			delete(s.streams, instanceID)
+			s.recorder.Forget(instanceID)
+		}
+	}
+	streams := len(s.streams)
+	s.mu.Unlock()
+
+	// Metering writes to the database, so it runs without holding up the streams
+	for i := range batch {
+		s.recorder.Record(sampled[i], &batch[i], interval)
+	}
+
+	s.writer.Add(batch...)
+	metrics.ObserveStatsCollection(streams, stale, s.writer.Buffered())
+}
+
+// open starts streaming the stats of an instance's container; s.mu must be held
+func (s *StatsCollector) open(ctx context.Context, instance models.Instance) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &statsStream{instance: instance, cancel: cancel, started: time.Now()}
+	s.streams[instance.ID] = stream
+
+	go func() {
+		err := s.manager.StreamInstanceStats(streamCtx, &instance, func(usage *models.ResourceUsage) {
+			s.mu.Lock()
+			stream.latest = usage
+			stream.received = time.Now()
+			s.mu.Unlock()
+		})
+		if err != nil && streamCtx.Err() == nil {
+			s.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Container stats stream ended")
+		}
+
+		s.mu.Lock()
+		stream.done = true
+		s.mu.Unlock()
+	}()
+}
+
+// closeAll closes every stream, e.g. when another replica took over monitoring
+func (s *StatsCollector) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for instanceID, stream := range s.streams {
+		stream.cancel()
+		delete(s.streams, instanceID)
+		s.recorder.Forget(instanceID)
+	}
+}
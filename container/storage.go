@@ -0,0 +1,169 @@
+package container
+
+import (
+	"context"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// StorageMonitor measures instance volume usage and enforces plan storage limits
+type StorageMonitor struct {
+	manager Manager
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewStorageMonitor creates a new storage monitor
+func NewStorageMonitor(manager Manager, cfg *config.Config, logger *logrus.Logger) *StorageMonitor {
+	return &StorageMonitor{
+		manager: manager,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Start runs storage checks on the configured interval until the context is cancelled
+func (s *StorageMonitor) Start(ctx context.Context) {
+	s.logger.Infof("Starting storage quota monitoring every %v", s.config.Storage.CheckInterval)
+	ticker := time.NewTicker(s.config.Storage.CheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("storage_monitor", s.config.Storage.CheckInterval)
+	singleton := lease.NewSingleton("storage_monitor", s.config.Storage.CheckInterval, s.config, s.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { s.CheckAll(ctx) })
+			}
+		}
+	}
+}
+
+// CheckAll measures every running or suspended instance against its owner's plan
+func (s *StorageMonitor) CheckAll(ctx context.Context) {
+	instances, err := db.GetInstancesByStatus(models.StatusRunning, models.StatusSuspended)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to fetch instances for storage monitoring")
+		return
+	}
+
+	for _, instance := range instances {
+		// Only revisit suspended instances that we suspended ourselves
+		if instance.IsSuspended() && instance.SuspendedReason != models.SuspendReasonStorage {
+			continue
+		}
+		s.checkInstance(ctx, instance)
+	}
+}
+
+// checkInstance measures a single instance and warns, suspends or resumes it as needed
+func (s *StorageMonitor) checkInstance(ctx context.Context, instance models.Instance) {
+	logger := s.logger.WithField("instance_id", instance.ID)
+
+	checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	usage, err := s.manager.GetStorageUsage(checkCtx, instance.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to measure instance storage usage")
+		return
+	}
+	// Recorded on the instance so the stats collector sees it on whichever replica it runs
+	if err := db.SetInstanceStorageUsage(instance.ID, usage); err != nil {
+		logger.WithError(err).Warn("Failed to record instance storage usage")
+	}
+
+	// Limits follow the owner's current plan so upgrades take effect immediately
+	user, err := db.GetUserByID(instance.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to fetch instance owner for storage check")
+		return
+	}
+	limit := int64(user.GetStorageLimit()) * 1024 * 1024 * 1024
+	if limit <= 0 {
+		return
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"usage_bytes": usage,
+		"limit_bytes": limit,
+	})
+
+	switch {
+	case usage >= limit && !instance.IsSuspended():
+		s.suspend(ctx, instance, logger)
+	case usage < limit && instance.IsSuspended():
+		s.resume(ctx, instance, logger)
+	case float64(usage) >= float64(limit)*s.config.Storage.WarnThreshold:
+		if instance.StorageWarnedAt == nil {
+			now := time.Now()
+			instance.StorageWarnedAt = &now
+			if err := db.UpdateInstance(&instance); err != nil {
+				logger.WithError(err).Warn("Failed to record storage warning")
+			}
+			logger.Warn("Instance is approaching its storage limit")
+			event := models.NewUsageWebhookEvent(instance.UserID, &instance, models.UsageThresholdStorage, limit, usage)
+			if err := db.QueueWebhookEvent(event, &instance); err != nil {
+				logger.WithError(err).Warn("Failed to queue storage webhook event")
+			}
+		}
+	case instance.StorageWarnedAt != nil:
+		instance.StorageWarnedAt = nil
+		if err := db.UpdateInstance(&instance); err != nil {
+			logger.WithError(err).Warn("Failed to clear storage warning")
+		}
+	}
+}
+
+// suspend stops an instance that has exceeded its storage limit
+func (s *StorageMonitor) suspend(ctx context.Context, instance models.Instance, logger *logrus.Entry) {
+	logger.Warn("Instance exceeded its storage limit, suspending")
+
+	if err := s.manager.StopInstance(ctx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to stop instance over storage limit")
+		return
+	}
+
+	// Reload since the manager may have updated the record
+	updated, err := db.GetInstanceByID(instance.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to reload suspended instance")
+		return
+	}
+	updated.Status = models.StatusSuspended
+	updated.SuspendedReason = models.SuspendReasonStorage
+	if err := db.UpdateInstance(updated); err != nil {
+		logger.WithError(err).Error("Failed to mark instance as suspended")
+	}
+}
+
+// resume restarts an instance whose storage usage is back under its limit
+func (s *StorageMonitor) resume(ctx context.Context, instance models.Instance, logger *logrus.Entry) {
+	logger.Info("Instance storage is back under its limit, resuming")
+
+	if err := s.manager.StartInstance(ctx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to restart suspended instance")
+		return
+	}
+
+	updated, err := db.GetInstanceByID(instance.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to reload resumed instance")
+		return
+	}
+	updated.Status = models.StatusRunning
+	updated.SuspendedReason = ""
+	updated.StorageWarnedAt = nil
+	if err := db.UpdateInstance(updated); err != nil {
+		logger.WithError(err).Error("Failed to mark instance as running")
+	}
+}
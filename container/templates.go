@@ -0,0 +1,173 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnknownService is returned when creating an instance of a service that is not offered
+var ErrUnknownService = errors.New("service is not offered")
+
+// ErrServiceUnsupported is returned for operations the service of an instance does not
+// support, e.g. exporting the workflows of an instance that does not run n8n
+var ErrServiceUnsupported = errors.New("operation is not supported by the instance's service")
+
+// TemplateVolume is a Docker volume of an instance, named after the container with the
+// volume's name as suffix, e.g. "n8n-1a2b3c4d-prod-data"
+type TemplateVolume struct {
+	Name string `json:"name"` // also the folder of the volume in backup archives
+	Path string `json:"path"` // where the volume is mounted in the container
+}
+
+// ServiceTemplate describes how the container of an instance of a service is run. Offering
+// another service only takes adding its template to serviceTemplates.
+type ServiceTemplate struct {
+	Service     models.ServiceType `json:"service_type"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Image       string             `json:"image"`       // instances replace its tag with their image tag
+	Port        int                `json:"port"`        // HTTP port the proxy forwards to
+	HealthPath  string             `json:"health_path"` // answers 200 once the service is up
+	Volumes     []TemplateVolume   `json:"volumes"`
+	Workflows   bool               `json:"workflows"` // runs n8n, with a basic auth login and workflows the platform manages
+	User        string             `json:"-"`         // user the container runs as; empty for the image's default
+
+	// Env returns the environment of an instance's container, with the basic auth password and
+	// webhook secret of services with Workflows
+	Env func(instance *models.Instance, password, webhookSecret string) []string `json:"-"`
+}
+
+// serviceTemplates returns the templates of every service instances can run. Images and ports
+// of n8n come from the configuration.
+func serviceTemplates(cfg *config.Config) []ServiceTemplate {
+	return []ServiceTemplate{
+		{
+			Service:     models.ServiceN8N,
+			Name:        "n8n",
+			Description: "Workflow automation",
+			Image:       cfg.N8N.BaseImage,
+			Port:        cfg.Docker.N8NContainerPort,
+			HealthPath:  "/healthz", // answered without touching the database
+			Volumes: []TemplateVolume{
+				{Name: "data", Path: "/home/node/.n8n"},
+				{Name: "files", Path: "/files"},
+			},
+			Workflows: true,
+			User:      "root", // Run as root to ensure permission for host bind mounts
+			Env:       instanceEnv,
+		},
+		{
+			Service:     models.ServiceUptimeKuma,
+			Name:        "Uptime Kuma",
+			Description: "Uptime monitoring and status pages",
+			Image:       "louislam/uptime-kuma:latest",
+			Port:        3001,
+			HealthPath:  "/",
+			Volumes:     []TemplateVolume{{Name: "data", Path: "/app/data"}},
+			Env: func(instance *models.Instance, _, _ string) []string {
+				return []string{"UPTIME_KUMA_PORT=3001"}
+			},
+		},
+		{
+			Service:     models.ServiceNocoDB,
+			Name:        "NocoDB",
+			Description: "Spreadsheet interface for databases",
+			Image:       "nocodb/nocodb:latest",
+			Port:        8080,
+			HealthPath:  "/api/v1/health",
+			Volumes:     []TemplateVolume{{Name: "data", Path: "/usr/app/data"}},
+			Env: func(instance *models.Instance, _, _ string) []string {
+				return []string{
+					fmt.Sprintf("NC_PUBLIC_URL=https://%s", instance.URL),
+					"NC_DISABLE_TELE=true",
+				}
+			},
+		},
+	}
+}
+
+// TemplateRegistry holds the service templates of the container manager
+type TemplateRegistry struct {
+	templates map[models.ServiceType]*ServiceTemplate
+	offered   []models.ServiceType // in the order of SERVICE_TYPES
+}
+
+// NewTemplateRegistry creates a registry of every service template, offering the services
+// listed in SERVICE_TYPES for new instances
+func NewTemplateRegistry(cfg *config.Config, logger *logrus.Logger) *TemplateRegistry {
+	r := &TemplateRegistry{templates: make(map[models.ServiceType]*ServiceTemplate)}
+	for _, template := range serviceTemplates(cfg) {
+		template := template
+		r.templates[template.Service] = &template
+	}
+	for _, service := range cfg.Services.Offered {
+		if _, ok := r.templates[models.ServiceType(service)]; !ok {
+			logger.WithField("service_type", service).Warn("SERVICE_TYPES lists a service without a template, ignoring it")
+			continue
+		}
+		r.offered = append(r.offered, models.ServiceType(service))
+	}
+	return r
+}
+
+// List returns the templates of the services offered for new instances
+func (r *TemplateRegistry) List() []ServiceTemplate {
+	templates := make([]ServiceTemplate, 0, len(r.offered))
+	for _, service := range r.offered {
+		templates = append(templates, *r.templates[service])
+	}
+	return templates
+}
+
+// Offered returns the template of a service new instances can be created with, or
+// ErrUnknownService. An empty service type means n8n.
+func (r *TemplateRegistry) Offered(service models.ServiceType) (*ServiceTemplate, error) {
+	if service == "" {
+		service = models.ServiceN8N
+	}
+	for _, offered := range r.offered {
+		if offered == service {
+			return r.templates[service], nil
+		}
+	}
+	return nil, ErrUnknownService
+}
+
+// ForInstance returns the template of the service an existing instance runs, whether or not
+// it is still offered. Instances of services without a template are treated as n8n.
+func (r *TemplateRegistry) ForInstance(instance *models.Instance) *ServiceTemplate {
+	if template, ok := r.templates[instance.Service()]; ok {
+		return template
+	}
+	return r.templates[models.ServiceN8N]
+}
+
+// IsHealthPath reports whether a path is the health endpoint of one of the services
+func (r *TemplateRegistry) IsHealthPath(path string) bool {
+	for _, template := range r.templates {
+		if template.HealthPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// volumeSuffixes returns the names of the volumes of every template
+func (r *TemplateRegistry) volumeSuffixes() map[string]bool {
+	suffixes := make(map[string]bool)
+	for _, template := range r.templates {
+		for _, volume := range template.Volumes {
+			suffixes[volume.Name] = true
+		}
+	}
+	return suffixes
+}
+
+// ImageFor returns the image reference of an instance's service at a tag
+func (t *ServiceTemplate) ImageFor(tag string) string {
+	return ImageRef(t.Image, tag)
+}
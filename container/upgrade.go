@@ -0,0 +1,330 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// pullImage pulls an image and waits for the pull to complete. Docker Hub images are pulled
+// through the configured mirror and tagged with their own name, so containers, digests and
+// exports keep referring to the upstream image. If the mirror fails, Docker Hub is used.
+func (m *DockerManager) pullImage(ctx context.Context, image string) error {
+	source := MirrorImage(image, m.config.Registry.Mirror)
+	if source != image {
+		err := m.pullFrom(ctx, source)
+		if err == nil {
+			err = m.client.ImageTag(ctx, source, image)
+		}
+		if err == nil {
+			return nil
+		}
+		m.logger.WithError(err).WithField("image", image).Warn("Failed to pull image through the registry mirror, pulling from Docker Hub")
+	}
+	return m.pullFrom(ctx, image)
+}
+
+// pullFrom pulls an image from its registry, with the registry's credentials if it has any
+func (m *DockerManager) pullFrom(ctx context.Context, image string) error {
+	auth, err := m.registryAuth(image)
+	if err != nil {
+		return fmt.Errorf("failed to encode registry credentials for %s: %w", image, err)
+	}
+	reader, err := m.client.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: auth})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	defer reader.Close()
+
+	// The pull only completes once the progress stream has been consumed
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+// imageDigest returns the registry digest reference of a local image, e.g.
+// "n8nio/n8n@sha256:...", or "" for images that were not pulled from a registry. Digests in
+// the repository of the service's image are preferred.
+func (m *DockerManager) imageDigest(ctx context.Context, template *ServiceTemplate, image string) string {
+	inspected, _, err := m.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		m.logger.WithError(err).WithField("image", image).Warn("Failed to inspect image for its digest")
+		return ""
+	}
+	return m.repoDigest(inspected.RepoDigests, strings.TrimSuffix(template.ImageFor(""), ":"))
+}
+
+// repoDigest picks the digest reference of an image in repo out of the ones it is known by.
+// Images can be known under several repositories; the given one is preferred, then its
+// mirror, whose digests can be pulled again without touching Docker Hub.
+func (m *DockerManager) repoDigest(repoDigests []string, repo string) string {
+	for _, preferred := range []string{repo, MirrorImage(repo, m.config.Registry.Mirror)} {
+		for _, digest := range repoDigests {
+			if strings.HasPrefix(digest, preferred+"@") {
+				return digest
+			}
+		}
+	}
+	if len(repoDigests) > 0 {
+		return repoDigests[0]
+	}
+	return ""
+}
+
+// UpgradeInstance recreates an instance's container on a new n8n image tag. The old
+// container is kept until the new one passes its health check, and is restored if it does not.
+// The digest the instance ran before is kept so the upgrade can be rolled back later.
+func (m *DockerManager) UpgradeInstance(ctx context.Context, instanceID uuid.UUID, imageTag string) error {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return fmt.Errorf("instance has no container ID")
+	}
+
+	template := m.templates.ForInstance(instance)
+	image := template.ImageFor(imageTag)
+	logger := m.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"image":       image,
+	})
+
+	// Instances upgraded before digests were recorded still know their image through the container
+	previousDigest := instance.ImageDigest
+	if previousDigest == "" {
+		if current, err := m.client.ContainerInspect(ctx, instance.ContainerID); err == nil {
+			previousDigest = m.imageDigest(ctx, template, current.Image)
+		}
+	}
+
+	// Pull first so a bad tag fails before the running container is touched
+	logger.Info("Pulling image for instance upgrade")
+	if err := m.pullImage(ctx, image); err != nil {
+		return err
+	}
+	digest := m.imageDigest(ctx, template, image)
+
+	err = m.recreateContainer(ctx, instance, logger, func(config *container.Config, hostConfig *container.HostConfig) {
+		config.Image = image
+		if config.Labels == nil {
+			config.Labels = map[string]string{}
+		}
+		config.Labels["com.centurylinklabs.watchtower.enable"] = strconv.FormatBool(imageTag == models.LatestImageTag)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Without a digest there is no exact image to return to
+	if previousDigest != "" {
+		now := time.Now()
+		instance.PreviousImageTag = instance.ImageTag
+		instance.PreviousImageDigest = previousDigest
+		instance.UpgradedAt = &now
+	} else {
+		instance.PreviousImageTag = ""
+		instance.PreviousImageDigest = ""
+		instance.UpgradedAt = nil
+	}
+	instance.ImageTag = imageTag
+	instance.ImageDigest = digest
+	instance.Status = models.StatusRunning
+	if err := db.UpdateInstance(instance); err != nil {
+		return fmt.Errorf("upgrade succeeded but failed to update instance: %w", err)
+	}
+
+	logger.WithField("digest", digest).Info("Instance upgraded successfully")
+	return nil
+}
+
+// RollbackInstance recreates an instance's container on the image digest it ran before its
+// last upgrade, with the same blue/green rollout as an upgrade. The restored image is pinned
+// by digest, so automatic updates of "latest" instances stay off until the next upgrade.
+func (m *DockerManager) RollbackInstance(ctx context.Context, instanceID uuid.UUID) error {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return fmt.Errorf("instance has no container ID")
+	}
+	if instance.PreviousImageDigest == "" {
+		return fmt.Errorf("instance has no previous image to roll back to")
+	}
+
+	image := instance.PreviousImageDigest
+	logger := m.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"image":       image,
+	})
+
+	// The previous image may have been pruned since; pulling by digest fetches the exact same one
+	logger.Info("Pulling previous image for instance rollback")
+	if err := m.pullImage(ctx, image); err != nil {
+		return err
+	}
+
+	err = m.recreateContainer(ctx, instance, logger, func(config *container.Config, hostConfig *container.HostConfig) {
+		config.Image = image
+		if config.Labels == nil {
+			config.Labels = map[string]string{}
+		}
+		config.Labels["com.centurylinklabs.watchtower.enable"] = "false"
+	})
+	if err != nil {
+		return err
+	}
+
+	instance.ImageTag = instance.PreviousImageTag
+	instance.ImageDigest = instance.PreviousImageDigest
+	instance.PreviousImageTag = ""
+	instance.PreviousImageDigest = ""
+	instance.UpgradedAt = nil
+	instance.HealthFailures = 0
+	instance.Status = models.StatusRunning
+	if err := db.UpdateInstance(instance); err != nil {
+		return fmt.Errorf("rollback succeeded but failed to update instance: %w", err)
+	}
+
+	logger.Info("Instance rolled back successfully")
+	return nil
+}
+
+// recreateContainer replaces an instance's container with a copy changed by configure,
+// keeping its volumes. The old container is kept until the new one passes its health
+// check, and is restored if it does not. On success the instance's container ID and IP
+// address are updated in place; saving them is left to the caller.
+func (m *DockerManager) recreateContainer(ctx context.Context, instance *models.Instance, logger *logrus.Entry, configure func(config *container.Config, hostConfig *container.HostConfig)) error {
+	old, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	containerName := strings.TrimPrefix(old.Name, "/")
+	rollbackName := containerName + "-rollback"
+	// Instances still on the shared network move to their user's network with the new container
+	networkName, err := m.ensureInstanceNetwork(ctx, instance.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Move the old container aside, keeping its volumes for the new one
+	timeout := 30 * time.Second
+	if err := m.client.ContainerStop(ctx, old.ID, &timeout); err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+	if err := m.client.ContainerRename(ctx, old.ID, rollbackName); err != nil {
+		m.restartContainer(ctx, old.ID, logger)
+		return fmt.Errorf("failed to rename container: %w", err)
+	}
+
+	rollback := func(newID string, cause error) error {
+		logger.WithError(cause).Warn("Container recreation failed, rolling back")
+		if newID != "" {
+			if err := m.client.ContainerRemove(ctx, newID, types.ContainerRemoveOptions{Force: true}); err != nil {
+				logger.WithError(err).Error("Failed to remove new container during rollback")
+			}
+		}
+		if err := m.client.ContainerRename(ctx, old.ID, containerName); err != nil {
+			logger.WithError(err).Error("Failed to restore container name during rollback")
+		}
+		m.restartContainer(ctx, old.ID, logger)
+		return cause
+	}
+
+	config := old.Config
+	hostConfig := old.HostConfig
+	configure(config, hostConfig)
+	hostConfig.NetworkMode = container.NetworkMode(networkName)
+	if config.Labels == nil {
+		config.Labels = map[string]string{}
+	}
+	config.Labels["com.launchstack.instance.name"] = instance.Name
+
+	resp, err := m.client.ContainerCreate(ctx, config, hostConfig, &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			networkName: {NetworkID: networkName},
+		},
+	}, nil, containerName)
+	if err != nil {
+		return rollback("", fmt.Errorf("failed to create new container: %w", err))
+	}
+	if err := m.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return rollback(resp.ID, fmt.Errorf("failed to start new container: %w", err))
+	}
+	if _, err := m.restrictEgress(ctx, instance, resp.ID); err != nil {
+		return rollback(resp.ID, fmt.Errorf("failed to restrict egress of new container: %w", err))
+	}
+
+	inspected, err := m.client.ContainerInspect(ctx, resp.ID)
+	if err != nil {
+		return rollback(resp.ID, fmt.Errorf("failed to inspect new container: %w", err))
+	}
+	ip, err := m.containerIP(inspected, instance.UserID)
+	if err != nil {
+		return rollback(resp.ID, fmt.Errorf("new container has no IP address"))
+	}
+
+	if err := m.waitHealthy(ctx, instance, ip, m.config.N8N.UpgradeHealthTimeout); err != nil {
+		return rollback(resp.ID, err)
+	}
+
+	// The new container is healthy, so the old one is no longer needed
+	if err := m.client.ContainerRemove(ctx, old.ID, types.ContainerRemoveOptions{}); err != nil {
+		logger.WithError(err).Warn("Failed to remove previous container")
+	}
+	m.egressApplied.Delete(old.ID)
+
+	// Point the internal DNS name at the new container; public records do not change
+	if !m.dns.Public() {
+		record := m.instanceDNSRecord(instance, ip)
+		if err := m.dns.SetRecord(ctx, record.Name, record.Answer); err != nil {
+			logger.WithError(err).Warn("Failed to update DNS record for new container")
+		}
+	}
+
+	instance.ContainerID = resp.ID
+	instance.IPAddress = ip
+	return nil
+}
+
+// restartContainer starts a container again after a failed upgrade step
+func (m *DockerManager) restartContainer(ctx context.Context, containerID string, logger *logrus.Entry) {
+	if err := m.client.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); err != nil {
+		logger.WithError(err).Error("Failed to restart previous container")
+	}
+}
+
+// waitHealthy polls the health endpoint of an instance's service until it responds or the
+// timeout passes
+func (m *DockerManager) waitHealthy(ctx context.Context, instance *models.Instance, ip string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if err := m.probeHealth(ctx, instance, ip); err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("instance did not become healthy within %v", timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
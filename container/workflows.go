@@ -0,0 +1,204 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+)
+
+// n8nAPIClient is shared by requests to the internal REST API of n8n instances
+var n8nAPIClient = &http.Client{Timeout: 30 * time.Second}
+
+// n8nAPI calls the internal REST API of one instance's n8n over the Docker network, with the
+// instance's basic auth login
+type n8nAPI struct {
+	baseURL  string
+	username string
+	password string
+}
+
+// n8nAPIFor returns the REST API of a running instance's n8n
+func (m *DockerManager) n8nAPIFor(ctx context.Context, instanceID uuid.UUID) (*n8nAPI, error) {
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	if instance.ContainerID == "" {
+		return nil, fmt.Errorf("instance has no container ID")
+	}
+	template := m.templates.ForInstance(instance)
+	if !template.Workflows {
+		return nil, ErrServiceUnsupported
+	}
+	credential, password, err := m.GetInstanceCredentials(ctx, instance.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The IP can change when the container is restarted, so look it up on every call
+	inspected, err := m.client.ContainerInspect(ctx, instance.ContainerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	ip, err := m.containerIP(inspected, instance.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &n8nAPI{
+		baseURL:  fmt.Sprintf("http://%s:%d/rest", ip, template.Port),
+		username: credential.Username,
+		password: password,
+	}, nil
+}
+
+// do sends a request and decodes the "data" field of n8n's response into out
+func (a *n8nAPI) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(a.username, a.password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := n8nAPIClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("n8n request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Data    json.RawMessage `json:"data"`
+		Message string          `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("n8n returned status %d with an unreadable body: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("n8n returned status %d: %s", resp.StatusCode, response.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Data, out)
+}
+
+// workflowID reads a workflow ID, which older n8n versions return as a number
+func workflowID(raw json.RawMessage) string {
+	return strings.Trim(string(raw), `"`)
+}
+
+// ExportWorkflows reads every workflow of an instance from its n8n REST API
+func (m *DockerManager) ExportWorkflows(ctx context.Context, instanceID uuid.UUID) (*models.WorkflowBundle, error) {
+	api, err := m.n8nAPIFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The list leaves out the nodes of each workflow, so every workflow is fetched by ID
+	var list []struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := api.do(ctx, http.MethodGet, "/workflows", nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	bundle := newWorkflowBundle(instanceID)
+	for _, entry := range list {
+		var workflow json.RawMessage
+		if err := api.do(ctx, http.MethodGet, "/workflows/"+workflowID(entry.ID), nil, &workflow); err != nil {
+			return nil, fmt.Errorf("failed to export workflow %s: %w", workflowID(entry.ID), err)
+		}
+		bundle.Workflows = append(bundle.Workflows, workflow)
+	}
+	return bundle, nil
+}
+
+// ImportWorkflows creates the workflows of a bundle on an instance through its n8n REST API.
+// Each workflow is created as a new, inactive workflow; one that n8n rejects does not stop the
+// others from being imported.
+func (m *DockerManager) ImportWorkflows(ctx context.Context, instanceID uuid.UUID, bundle *models.WorkflowBundle) (*models.WorkflowImportResult, error) {
+	api, err := m.n8nAPIFor(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.WorkflowImportResult{Workflows: []models.ImportedWorkflow{}}
+	for _, raw := range bundle.Workflows {
+		workflow, outcome, err := prepareWorkflowImport(raw)
+		if err == nil {
+			var created struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if err = api.do(ctx, http.MethodPost, "/workflows", workflow, &created); err == nil {
+				outcome.ID = workflowID(created.ID)
+			}
+		}
+		if err != nil {
+			outcome.Error = err.Error()
+			result.Failed++
+		} else {
+			result.Imported++
+		}
+		result.Workflows = append(result.Workflows, outcome)
+	}
+	return result, nil
+}
+
+// newWorkflowBundle starts an empty bundle of an instance's workflows
+func newWorkflowBundle(instanceID uuid.UUID) *models.WorkflowBundle {
+	bundle := &models.WorkflowBundle{
+		Version:    models.WorkflowBundleVersion,
+		InstanceID: instanceID.String(),
+		ExportedAt: time.Now().UTC(),
+		Workflows:  []json.RawMessage{},
+	}
+	if instance, err := db.GetInstanceByID(instanceID); err == nil {
+		bundle.ImageTag = instance.ImageTag
+	}
+	return bundle
+}
+
+// prepareWorkflowImport turns an exported workflow into a request creating it as a new
+// workflow. IDs and tags belong to the instance it was exported from, and the workflow stays
+// inactive until its credentials exist on the new instance.
+func prepareWorkflowImport(raw json.RawMessage) (map[string]json.RawMessage, models.ImportedWorkflow, error) {
+	var workflow map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &workflow); err != nil {
+		return nil, models.ImportedWorkflow{}, fmt.Errorf("workflow is not a JSON object")
+	}
+
+	var outcome models.ImportedWorkflow
+	if err := json.Unmarshal(workflow["name"], &outcome.Name); err != nil || outcome.Name == "" {
+		return nil, outcome, fmt.Errorf("workflow has no name")
+	}
+	if len(workflow["nodes"]) == 0 {
+		return nil, outcome, fmt.Errorf("workflow has no nodes")
+	}
+
+	for _, field := range []string{"id", "tags", "versionId", "createdAt", "updatedAt", "shared"} {
+		delete(workflow, field)
+	}
+	workflow["active"] = json.RawMessage("false")
+	return workflow, outcome, nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// userInstanceTables hold rows of a user's instances, keyed by instance_id. They are purged
+// before the instances they reference.
+var userInstanceTables = []string{
+	"instance_certificates",
+	"instance_credentials",
+	"instance_shares",
+	"probe_results",
+	"provisioning_steps",
+	"resource_usages",
+	"workflow_executions",
+}
+
+// userTables hold rows of a user, keyed by user_id, in an order that satisfies the foreign
+// keys between them. The billing journal is append-only and kept for accounting.
+var userTables = []string{
+	"api_keys",
+	"backups",
+	"backup_schedules",
+	"instance_events",
+	"instance_shares",
+	"jobs",
+	"notifications",
+	"payments",
+	"provisioning_jobs",
+	"usage_records",
+	"usage_rollups",
+	"waitlist_entries",
+	"org_memberships",
+	"instances",
+	"projects",
+	"webhook_endpoints",
+}
+
+// GetPurgeableUsers retrieves up to limit users deleted before a time whose instances have all
+// been deleted and who have no jobs still queued or running
+func GetPurgeableUsers(deletedBefore time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := DB.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Where("NOT EXISTS (SELECT 1 FROM instances WHERE instances.user_id = users.id AND instances.deleted_at IS NULL)").
+		Where("NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.user_id = users.id AND jobs.status IN ?)", []models.JobStatus{models.JobQueued, models.JobRunning}).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purgeable users: %w", err)
+	}
+	return users, nil
+}
+
+// GetStoredBackupsByUserID retrieves the backups of a user whose archives are in object storage
+func GetStoredBackupsByUserID(userID uuid.UUID) ([]models.Backup, error) {
+	var backups []models.Backup
+	if err := DB.Where("user_id = ? AND status = ? AND key <> ''", userID, models.BackupSucceeded).Find(&backups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get backups of user: %w", err)
+	}
+	return backups, nil
+}
+
+// PurgeUser hard-deletes a deleted user and every row of theirs and of their instances, except
+// their billing journal entries. Sub-accounts of a purged reseller are detached from them.
+func PurgeUser(userID uuid.UUID) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		instanceIDs := tx.Unscoped().Model(&models.Instance{}).Select("id").Where("user_id = ?", userID)
+		for _, table := range userInstanceTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE instance_id IN (?)", instanceIDs).Error; err != nil {
+				return fmt.Errorf("failed to purge %s: %w", table, err)
+			}
+		}
+
+		endpointIDs := tx.Unscoped().Model(&models.WebhookEndpoint{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Exec("DELETE FROM webhook_deliveries WHERE endpoint_id IN (?)", endpointIDs).Error; err != nil {
+			return fmt.Errorf("failed to purge webhook_deliveries: %w", err)
+		}
+
+		for _, table := range userTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ?", userID).Error; err != nil {
+				return fmt.Errorf("failed to purge %s: %w", table, err)
+			}
+		}
+
+		if err := tx.Model(&models.User{}).Unscoped().Where("reseller_id = ?", userID).Update("reseller_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to detach sub-accounts: %w", err)
+		}
+		if err := tx.Unscoped().Delete(&models.User{}, "id = ?", userID).Error; err != nil {
+			return fmt.Errorf("failed to purge user: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// CreateAPIKey stores a new API key
+func CreateAPIKey(key *models.APIKey) error {
+	if err := DB.Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// FindAPIKeyByHash finds an active API key by the hash of its plaintext value
+func FindAPIKeyByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := DB.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetAPIKeysByProjectID retrieves all active API keys scoped to a project
+func GetAPIKeysByProjectID(projectID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := DB.Where("project_id = ?", projectID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// GetAPIKeysByUserID retrieves all active API keys of a user, scoped to a project or not
+func GetAPIKeysByUserID(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// GetAPIKeyByID retrieves an API key by ID
+func GetAPIKeyByID(keyID uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := DB.Where("id = ?", keyID).First(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey deletes an API key so it can no longer be used
+func RevokeAPIKey(keyID uuid.UUID) error {
+	if err := DB.Delete(&models.APIKey{}, keyID).Error; err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return nil
+}
+
+// TouchAPIKey records that an API key was just used
+func TouchAPIKey(keyID uuid.UUID) error {
+	return DB.Model(&models.APIKey{}).Where("id = ?", keyID).Update("last_used_at", time.Now()).Error
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateBackup stores a new backup run
+func CreateBackup(backup *models.Backup) error {
+	if err := DB.Create(backup).Error; err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	return nil
+}
+
+// GetBackupByID retrieves a backup run
+func GetBackupByID(id uuid.UUID) (*models.Backup, error) {
+	var backup models.Backup
+	if err := DB.Where("id = ?", id).First(&backup).Error; err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// UpdateBackup saves the state of a backup run
+func UpdateBackup(backup *models.Backup) error {
+	if err := DB.Save(backup).Error; err != nil {
+		return fmt.Errorf("failed to update backup: %w", err)
+	}
+	return nil
+}
+
+// GetBackupsByInstanceID retrieves the latest backup runs of an instance, newest first
+func GetBackupsByInstanceID(instanceID uuid.UUID, limit int) ([]models.Backup, error) {
+	var backups []models.Backup
+	if err := DB.Where("instance_id = ?", instanceID).Order("created_at DESC").Limit(limit).Find(&backups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get backups: %w", err)
+	}
+	return backups, nil
+}
+
+// GetLatestSucceededBackup retrieves the newest succeeded backup of an instance kept in a
+// storage target, or nil if there is none
+func GetLatestSucceededBackup(instanceID uuid.UUID, target string) (*models.Backup, error) {
+	var backups []models.Backup
+	err := DB.Where("instance_id = ? AND status = ? AND target = ?", instanceID, models.BackupSucceeded, target).
+		Order("created_at DESC").Limit(1).Find(&backups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest backup: %w", err)
+	}
+	if len(backups) == 0 {
+		return nil, nil
+	}
+	return &backups[0], nil
+}
+
+// HasActiveBackup checks if a backup of an instance is queued or running
+func HasActiveBackup(instanceID uuid.UUID) (bool, error) {
+	var count int64
+	if err := DB.Model(&models.Backup{}).
+		Where("instance_id = ? AND status IN ?", instanceID, []models.BackupStatus{models.BackupQueued, models.BackupRunning}).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check active backups: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetExpiredScheduledBackups retrieves the stored scheduled backups of an instance beyond the
+// newest keep, which the retention policy removes. Manual backups are never expired.
+func GetExpiredScheduledBackups(instanceID uuid.UUID, keep int) ([]models.Backup, error) {
+	var backups []models.Backup
+	if err := DB.Where("instance_id = ? AND trigger = ? AND status = ?", instanceID, models.BackupScheduled, models.BackupSucceeded).
+		Order("created_at DESC").Offset(keep).Find(&backups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expired backups: %w", err)
+	}
+	return backups, nil
+}
+
+// GetBackupSchedule retrieves the backup schedule of an instance, or nil if it has none
+func GetBackupSchedule(instanceID uuid.UUID) (*models.BackupSchedule, error) {
+	var schedule models.BackupSchedule
+	err := DB.Where("instance_id = ?", instanceID).First(&schedule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// SaveBackupSchedule creates or replaces the backup schedule of an instance
+func SaveBackupSchedule(schedule *models.BackupSchedule) error {
+	if err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "instance_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"schedule", "retention", "enabled", "next_run_at", "updated_at"}),
+	}).Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to save backup schedule: %w", err)
+	}
+	return nil
+}
+
+// UpdateBackupSchedule saves the run times of a backup schedule
+func UpdateBackupSchedule(schedule *models.BackupSchedule) error {
+	if err := DB.Save(schedule).Error; err != nil {
+		return fmt.Errorf("failed to update backup schedule: %w", err)
+	}
+	return nil
+}
+
+// DeleteBackupSchedule removes the backup schedule of an instance; its backups are kept
+func DeleteBackupSchedule(instanceID uuid.UUID) error {
+	if err := DB.Where("instance_id = ?", instanceID).Delete(&models.BackupSchedule{}).Error; err != nil {
+		return fmt.Errorf("failed to delete backup schedule: %w", err)
+	}
+	return nil
+}
+
+// GetDueBackupSchedules retrieves the enabled backup schedules whose next run is due
+func GetDueBackupSchedules(now time.Time, limit int) ([]models.BackupSchedule, error) {
+	var schedules []models.BackupSchedule
+	if err := DB.Where("enabled = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").Limit(limit).Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get due backup schedules: %w", err)
+	}
+	return schedules, nil
+}
@@ -0,0 +1,334 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// RecordBillingTransaction appends a balanced transaction to the billing journal. It reports
+// false without writing anything when a transaction with the same reference was already
+// recorded, so replayed webhooks are journaled once. Pass a transaction as tx to record the
+// change together with the billing state it describes.
+func RecordBillingTransaction(tx *gorm.DB, txn models.BillingTransaction) (bool, error) {
+	if err := txn.Validate(); err != nil {
+		return false, err
+	}
+
+	recorded := false
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		// The hash chain needs entries to be appended one transaction at a time
+		if err := lockTransaction(tx, "billing_journal"); err != nil {
+			return fmt.Errorf("failed to lock billing journal: %w", err)
+		}
+
+		var existing int64
+		if err := tx.Model(&models.BillingEntry{}).Where("reference = ?", txn.Reference).Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check billing journal: %w", err)
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		var last models.BillingEntry
+		err := tx.Order("sequence DESC").First(&last).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get last billing entry: %w", err)
+		}
+
+		transactionID := uuid.New()
+		createdAt := time.Now().UTC().Truncate(time.Microsecond) // the precision Postgres keeps
+		for _, line := range txn.Lines {
+			entry := models.BillingEntry{
+				Sequence:      last.Sequence + 1,
+				TransactionID: transactionID,
+				Reference:     txn.Reference,
+				Kind:          txn.Kind,
+				UserID:        txn.UserID,
+				PaymentID:     txn.PaymentID,
+				Account:       line.Account,
+				Amount:        line.Amount,
+				Currency:      line.Currency,
+				Description:   txn.Description,
+				PrevHash:      last.Hash,
+				CreatedAt:     createdAt,
+			}
+			entry.Hash = entry.ComputeHash()
+			if err := tx.Create(&entry).Error; err != nil {
+				return fmt.Errorf("failed to append billing entry: %w", err)
+			}
+			last = entry
+		}
+		recorded = true
+		return nil
+	})
+	return recorded, err
+}
+
+// RecordCharge journals the money collected for a succeeded payment
+func RecordCharge(tx *gorm.DB, payment *models.Payment) (bool, error) {
+	amount := int64(payment.Amount)
+	return RecordBillingTransaction(tx, models.BillingTransaction{
+		Kind:        models.BillingCharge,
+		Reference:   fmt.Sprintf("payment:%s:charge", payment.ID),
+		UserID:      payment.UserID,
+		PaymentID:   &payment.ID,
+		Description: payment.Description,
+		Lines: []models.BillingLine{
+			{Account: models.AccountCash, Amount: amount, Currency: payment.Currency},
+			{Account: models.AccountRevenue, Amount: -amount, Currency: payment.Currency},
+		},
+	})
+}
+
+// RecordRefund journals money returned for a payment. refundID identifies the refund with
+// the provider, so partial refunds of the same payment are recorded separately.
+func RecordRefund(tx *gorm.DB, payment *models.Payment, amount int64, refundID string) (bool, error) {
+	return RecordBillingTransaction(tx, models.BillingTransaction{
+		Kind:        models.BillingRefund,
+		Reference:   fmt.Sprintf("payment:%s:refund:%s", payment.ID, refundID),
+		UserID:      payment.UserID,
+		PaymentID:   &payment.ID,
+		Description: fmt.Sprintf("Refund of %s", payment.Description),
+		Lines: []models.BillingLine{
+			{Account: models.AccountRevenue, Amount: amount, Currency: payment.Currency},
+			{Account: models.AccountCash, Amount: -amount, Currency: payment.Currency},
+		},
+	})
+}
+
+// GetRefundedAmount returns how much of a payment the journal records as refunded
+func GetRefundedAmount(tx *gorm.DB, paymentID uuid.UUID) (int64, error) {
+	var refunded int64
+	err := tx.Model(&models.BillingEntry{}).
+		Select("COALESCE(-SUM(amount), 0)").
+		Where("payment_id = ? AND kind = ? AND account = ?", paymentID, models.BillingRefund, models.AccountCash).
+		Scan(&refunded).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+	return refunded, nil
+}
+
+// RecordCredit journals an amount owed to a user that is settled on a later invoice
+func RecordCredit(tx *gorm.DB, userID uuid.UUID, amount int64, currency, reference, description string) (bool, error) {
+	return RecordBillingTransaction(tx, models.BillingTransaction{
+		Kind:        models.BillingCredit,
+		Reference:   reference,
+		UserID:      userID,
+		Description: description,
+		Lines: []models.BillingLine{
+			{Account: models.AccountRevenue, Amount: amount, Currency: currency},
+			{Account: models.AccountCustomerCredit, Amount: -amount, Currency: currency},
+		},
+	})
+}
+
+// RecordPlanChange journals a user moving from one plan to another. The first plan change
+// of a user also records the plan they started on. Callers only record actual changes, so
+// every change gets a reference of its own.
+func RecordPlanChange(tx *gorm.DB, userID uuid.UUID, from, to models.SubscriptionPlan, description string) (bool, error) {
+	if from == to {
+		return false, nil
+	}
+
+	recorded := false
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		var planEntries int64
+		if err := tx.Model(&models.BillingEntry{}).Where("user_id = ? AND currency = ?", userID, models.CurrencyPlan).Count(&planEntries).Error; err != nil {
+			return fmt.Errorf("failed to check billing journal: %w", err)
+		}
+		if planEntries == 0 {
+			_, err := RecordBillingTransaction(tx, models.BillingTransaction{
+				Kind:        models.BillingOpening,
+				Reference:   fmt.Sprintf("user:%s:opening", userID),
+				UserID:      userID,
+				Description: fmt.Sprintf("Opening plan %s", from),
+				Lines: []models.BillingLine{
+					{Account: models.PlanAccount(from), Amount: 1, Currency: models.CurrencyPlan},
+					{Account: models.AccountPlanOpening, Amount: -1, Currency: models.CurrencyPlan},
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		recorded, err = RecordBillingTransaction(tx, models.BillingTransaction{
+			Kind:        models.BillingPlanChange,
+			Reference:   fmt.Sprintf("user:%s:plan_change:%s", userID, uuid.New()),
+			UserID:      userID,
+			Description: description,
+			Lines: []models.BillingLine{
+				{Account: models.PlanAccount(to), Amount: 1, Currency: models.CurrencyPlan},
+				{Account: models.PlanAccount(from), Amount: -1, Currency: models.CurrencyPlan},
+			},
+		})
+		return err
+	})
+	return recorded, err
+}
+
+// RecordTrialGrant journals trial days granted to a user
+func RecordTrialGrant(tx *gorm.DB, userID uuid.UUID, days int64, reference, description string) (bool, error) {
+	return RecordBillingTransaction(tx, models.BillingTransaction{
+		Kind:        models.BillingTrialGrant,
+		Reference:   reference,
+		UserID:      userID,
+		Description: description,
+		Lines: []models.BillingLine{
+			{Account: models.AccountTrialDays, Amount: days, Currency: models.CurrencyDay},
+			{Account: models.AccountTrialGrants, Amount: -days, Currency: models.CurrencyDay},
+		},
+	})
+}
+
+// HasTrialGrant checks if the journal records a trial granted to a user
+func HasTrialGrant(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := DB.Model(&models.BillingEntry{}).Where("user_id = ? AND kind = ?", userID, models.BillingTrialGrant).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check trial grants: %w", err)
+	}
+	return count > 0, nil
+}
+
+// HasBillingReference checks if a transaction with the reference was already journaled
+func HasBillingReference(reference string) (bool, error) {
+	var count int64
+	if err := DB.Model(&models.BillingEntry{}).Where("reference = ?", reference).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check billing journal: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetBillingEntries retrieves the newest journal entries, optionally of a single user
+func GetBillingEntries(userID *uuid.UUID, limit int) ([]models.BillingEntry, error) {
+	query := DB.Order("sequence DESC").Limit(limit)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	var entries []models.BillingEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get billing entries: %w", err)
+	}
+	return entries, nil
+}
+
+// BackfillBillingJournal journals the charges and refunds of payments settled before the
+// journal existed, returning how many payments it journaled
+func BackfillBillingJournal() (int, error) {
+	var settled []models.Payment
+	err := DB.Where("status IN ?", []models.PaymentStatus{models.PaymentStatusSucceeded, models.PaymentStatusRefunded}).
+		Where("NOT EXISTS (SELECT 1 FROM billing_entries e WHERE e.payment_id = payments.id)").
+		Find(&settled).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unjournaled payments: %w", err)
+	}
+
+	recorded := 0
+	for i := range settled {
+		payment := &settled[i]
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if _, err := RecordCharge(tx, payment); err != nil {
+				return err
+			}
+			if payment.Status == models.PaymentStatusRefunded {
+				if _, err := RecordRefund(tx, payment, int64(payment.Amount), "backfill"); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return recorded, err
+		}
+		recorded++
+	}
+	return recorded, nil
+}
+
+// VerifyBillingJournal checks that every transaction balances, that the hash chain is
+// intact, and that payments and user plans agree with what the journal reconstructs
+func VerifyBillingJournal() (*models.BillingJournalReport, error) {
+	report := &models.BillingJournalReport{
+		CheckedAt:              time.Now(),
+		UnbalancedTransactions: []uuid.UUID{},
+		PaymentMismatches:      []models.PaymentMismatch{},
+		PlanMismatches:         []models.PlanMismatch{},
+	}
+
+	err := DB.Raw(`
+		SELECT DISTINCT transaction_id FROM billing_entries
+		GROUP BY transaction_id, currency
+		HAVING SUM(amount) <> 0`).Scan(&report.UnbalancedTransactions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check journal balances: %w", err)
+	}
+
+	// Every entry must follow the previous one without gaps and match its hash
+	var batch []models.BillingEntry
+	previous := models.BillingEntry{}
+	err = DB.Order("sequence ASC").FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			entry := &batch[i]
+			report.Entries++
+			if report.BrokenChainAt != nil {
+				continue
+			}
+			if entry.Sequence != previous.Sequence+1 || entry.PrevHash != previous.Hash || entry.Hash != entry.ComputeHash() {
+				sequence := entry.Sequence
+				report.BrokenChainAt = &sequence
+			}
+			previous = *entry
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check journal hash chain: %w", err)
+	}
+
+	err = DB.Raw(`
+		SELECT * FROM (
+			SELECT p.id AS payment_id, p.status, p.amount,
+				COALESCE(SUM(e.amount) FILTER (WHERE e.kind = ?), 0) AS charged,
+				COALESCE(-SUM(e.amount) FILTER (WHERE e.kind = ?), 0) AS refunded
+			FROM payments p
+			LEFT JOIN billing_entries e ON e.payment_id = p.id AND e.account = ?
+			GROUP BY p.id, p.status, p.amount
+		) journaled
+		WHERE CASE status
+			WHEN ? THEN charged <> amount OR refunded >= amount
+			WHEN ? THEN charged <> amount OR refunded <> amount
+			ELSE charged <> 0 OR refunded <> 0
+		END`,
+		models.BillingCharge, models.BillingRefund, models.AccountCash,
+		models.PaymentStatusSucceeded, models.PaymentStatusRefunded).Scan(&report.PaymentMismatches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check payments against the journal: %w", err)
+	}
+
+	// A user's plan is the plan account at 1; users without plan entries are not checked
+	err = DB.Raw(`
+		SELECT u.id AS user_id, u.plan, COALESCE(SUBSTR(latest.account, 6), '') AS journal_plan
+		FROM users u
+		JOIN (SELECT DISTINCT user_id FROM billing_entries WHERE currency = ?) journaled ON journaled.user_id = u.id
+		LEFT JOIN (
+			SELECT user_id, account FROM billing_entries
+			WHERE currency = ? AND account LIKE 'plan:%'
+			GROUP BY user_id, account
+			HAVING SUM(amount) = 1
+		) latest ON latest.user_id = u.id
+		WHERE u.deleted_at IS NULL AND (latest.account IS NULL OR latest.account <> 'plan:' || u.plan)`,
+		models.CurrencyPlan, models.CurrencyPlan).Scan(&report.PlanMismatches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check plans against the journal: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetBrandingOverrides retrieves the branding settings stored in the database.
+// It returns an empty branding when none have been saved yet.
+func GetBrandingOverrides() (*models.Branding, error) {
+	var branding models.Branding
+	err := DB.Where("id = ?", models.BrandingSettingsID).First(&branding).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Branding{ID: models.BrandingSettingsID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get branding settings: %w", err)
+	}
+	return &branding, nil
+}
+
+// SaveBrandingOverrides stores the branding settings
+func SaveBrandingOverrides(branding *models.Branding) error {
+	branding.ID = models.BrandingSettingsID
+	if err := DB.Save(branding).Error; err != nil {
+		return fmt.Errorf("failed to save branding settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// SaveCachedImage records the outcome of pre-pulling an image on a host. A failed pull keeps
+// the digest and pull time of the copy that was pulled before.
+func SaveCachedImage(image *models.CachedImage) error {
+	columns := []string{"status", "error", "pull_duration", "checked_at", "updated_at"}
+	if image.Status == models.CachedImageReady {
+		columns = append(columns, "image_id", "digest", "size_bytes", "pulled_at")
+	}
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "host_name"}, {Name: "image"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(image).Error
+	if err != nil {
+		return fmt.Errorf("failed to save cached image: %w", err)
+	}
+	return nil
+}
+
+// GetCachedImages retrieves the images kept pulled on every host
+func GetCachedImages() ([]models.CachedImage, error) {
+	var images []models.CachedImage
+	if err := DB.Order("host_name ASC, image ASC").Find(&images).Error; err != nil {
+		return nil, fmt.Errorf("failed to get cached images: %w", err)
+	}
+	return images, nil
+}
+
+// DeleteStaleCachedImages forgets the images of a host that are no longer pre-pulled. The
+// images themselves are left on the host for Docker's own pruning.
+func DeleteStaleCachedImages(hostName string, keep []string) error {
+	query := DB.Where("host_name = ?", hostName)
+	if len(keep) > 0 {
+		query = query.Where("image NOT IN ?", keep)
+	}
+	if err := query.Delete(&models.CachedImage{}).Error; err != nil {
+		return fmt.Errorf("failed to delete stale cached images: %w", err)
+	}
+	return nil
+}
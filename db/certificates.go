@@ -0,0 +1,32 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetCertificateByInstanceID retrieves the tracked TLS certificate of an instance.
+// It returns nil without an error when the certificate has not been checked yet.
+func GetCertificateByInstanceID(instanceID uuid.UUID) (*models.InstanceCertificate, error) {
+	var cert models.InstanceCertificate
+	err := DB.Where("instance_id = ?", instanceID).First(&cert).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+// SaveCertificate creates or updates the tracked TLS certificate of an instance
+func SaveCertificate(cert *models.InstanceCertificate) error {
+	if err := DB.Save(cert).Error; err != nil {
+		return fmt.Errorf("failed to save certificate: %w", err)
+	}
+	return nil
+}
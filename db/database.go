@@ -2,15 +2,15 @@ package db
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/config"
 	"github.com/launchstack/backend/db/migrations"
 	"github.com/launchstack/backend/models"
-	"github.com/sirupsen/logrus"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -18,34 +18,55 @@ import (
 // DB is the global database connection
 var DB *gorm.DB
 
-// MigrationRecord tracks when migrations have been run
-type MigrationRecord struct {
-	ID        uint      `gorm:"primaryKey"`
-	Name      string    `gorm:"uniqueIndex"`
-	AppliedAt time.Time
+// schemaModels are the models stored in the database. On PostgreSQL their tables are created
+// by the versioned migrations in db/migrations, which must be kept in step with them.
+var schemaModels = []interface{}{
+	&models.User{},
+	&models.Project{},
+	&models.Organization{},
+	&models.OrgMembership{},
+	&models.OrgInvitation{},
+	&models.InstanceShare{},
+	&models.Instance{},
+	&models.ResourceUsage{},
+	&models.Payment{},
+	&models.Invoice{},
+	&models.TerminalSession{},
+	&models.CachedImage{},
+	&models.UsageRollup{},
+	&models.APIKey{},
+	&models.Branding{},
+	&models.InstanceCertificate{},
+	&models.InstanceEvent{},
+	&models.UsageRecord{},
+	&models.ProbeResult{},
+	&models.ProvisioningJob{},
+	&models.ProvisioningStepRecord{},
+	&models.Job{},
+	&models.InstanceCredential{},
+	&models.WaitlistEntry{},
+	&models.HostAgent{},
+	&models.AgentToken{},
+	&models.WebhookEndpoint{},
+	&models.WebhookDelivery{},
+	&models.Lease{},
+	&models.RequestNonce{},
+	&models.BillingEntry{},
+	&models.ReceivedWebhookEvent{},
+	&models.Backup{},
+	&models.BackupSchedule{},
+	&models.Notification{},
+	&models.Plan{},
+	&models.DockerHost{},
+	&models.OperatorReport{},
+	&models.WorkflowExecution{},
 }
 
-// Initialize database connection
-func InitDB() error {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, using environment variables")
-	}
-
-	// Get database connection details from environment variables
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPassword := getEnv("DB_PASSWORD", "npg_eiCzc53PmMRS")
-	dbHost := getEnv("DB_HOST", "10.1.1.82")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbName := getEnv("DB_NAME", "launchstack")
-	
-	// Configure database connection
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
-		dbHost, dbUser, dbPassword, dbName, dbPort)
-	
-	// Configure GORM logger
+// Initialize database connection with the DB_* settings of the configuration
+func InitDB(cfg *config.Config) error {
+	// Configure GORM logger; statements may contain secrets, which are redacted
 	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		log.New(redactingWriter{os.Stdout}, "\r\n", log.LstdFlags),
 		logger.Config{
 			SlowThreshold:             time.Second,
 			LogLevel:                  logger.Info,
@@ -56,168 +77,147 @@ func InitDB() error {
 	
 	// Connect to database
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	DB, err = openWithRetry(cfg, &gorm.Config{
 		Logger: newLogger,
 	})
 	
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if err := configurePool(cfg); err != nil {
+		return fmt.Errorf("failed to configure connection pool: %w", err)
+	}
+	SetUserCacheTTL(cfg.Clerk.UserCacheTTL)
+	if err := models.SetEncryptionKeys(cfg.Encryption.Key, cfg.Encryption.PreviousKeys...); err != nil {
+		return fmt.Errorf("failed to configure encryption keys: %w", err)
+	}
 	
-	log.Println("Connected to TimescaleDB successfully")
-	
-	// Auto migrate schemas
-	err = migrateSchemas()
-	if err != nil {
-		return fmt.Errorf("failed to migrate database schemas: %w", err)
+	if SQLite() {
+		if err := configureSQLite(cfg); err != nil {
+			return fmt.Errorf("failed to configure SQLite: %w", err)
+		}
+		log.Printf("Connected to SQLite database %s", cfg.Database.SQLitePath)
+	} else {
+		log.Println("Connected to TimescaleDB successfully")
 	}
 	
-	log.Println("Database migrations completed successfully")
+	switch cfg.Database.Migrate {
+	case "up":
+		if err := Migrate(); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+	case "verify":
+		if err := VerifyMigrations(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Get environment variable with fallback
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return fallback
+// redactingWriter redacts the platform's secrets from output before writing it
+type redactingWriter struct {
+	w io.Writer
 }
 
-// Migrate database schemas
-func migrateSchemas() error {
-	// Auto migrate models
-	// This creates/updates tables based on your struct definitions
-	// Add all models that need to be migrated here
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Instance{},
-		&models.ResourceUsage{},
-		// Add other models as needed
-	)
-	
-	if err != nil {
-		return err
+// Write redacts secrets from p and writes it
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, models.RedactSecretValues(string(p))); err != nil {
+		return 0, err
 	}
-	
-	// Create hypertable for ResourceUsage if it doesn't exist
-	// This needs to be done after the table is created by GORM
-	DB.Exec("SELECT create_hypertable('resource_usages', 'timestamp', if_not_exists => TRUE)")
-	
-	return nil
+	return len(p), nil
 }
 
-// RunMigrations runs database migrations only if they haven't been run before
-func RunMigrations() error {
-	// First, create the migrations table if it doesn't exist
-	err := DB.AutoMigrate(&MigrationRecord{})
-	if err != nil {
-		return fmt.Errorf("failed to create migration tracking table: %w", err)
+// Migrate brings the schema up to date. On PostgreSQL it applies the pending versioned
+// migrations, first adopting databases whose schema was created by AutoMigrate. SQLite
+// databases are for development only and are migrated from the models with AutoMigrate.
+func Migrate() error {
+	logger := getLogger()
+	if SQLite() {
+		return DB.AutoMigrate(schemaModels...)
 	}
-	
-	// Check if we've run migrations before
-	var count int64
-	DB.Model(&MigrationRecord{}).Count(&count)
-	
-	// If we already have migration records, check if we need to run again
-	if count > 0 {
-		// Get the latest migration record
-		var lastMigration MigrationRecord
-		if err := DB.Order("applied_at DESC").First(&lastMigration).Error; err == nil {
-			// If we've run migrations in the last 24 hours, skip
-			if time.Since(lastMigration.AppliedAt) < 24*time.Hour {
-				// Always run resource usage migration to ensure the column exists
-				if err := migrations.CreateResourceUsageTable(DB); err != nil {
-					return fmt.Errorf("failed to run resource usage migration: %w", err)
-				}
-				return nil
+
+	adopting := !DB.Migrator().HasTable(migrations.TableName) && DB.Migrator().HasTable(&models.User{})
+	if adopting {
+		// Created by AutoMigrate before migrations were versioned. Only tables it lacks
+		// entirely are created from the models, since the migrations after the baseline
+		// change existing tables, e.g. removing duplicate hosts before indexing them, and
+		// skip what already exists.
+		logger.Info("Adopting a database created before versioned migrations")
+		for _, model := range schemaModels {
+			if DB.Migrator().HasTable(model) {
+				continue
+			}
+			if err := DB.AutoMigrate(model); err != nil {
+				return fmt.Errorf("failed to create missing tables: %w", err)
 			}
 		}
+		if err := migrations.MarkBaselineApplied(DB); err != nil {
+			return fmt.Errorf("failed to record the baseline migration: %w", err)
+		}
 	}
-	
-	// Run migrations and record that we did
-	err = DB.AutoMigrate(
-		&models.User{},
-		&models.Instance{},
-		&models.ResourceUsage{},
-		&models.Payment{},
-	)
-	
-	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
-	
-	// Explicitly run resource usage migration to ensure the column exists
-	if err := migrations.CreateResourceUsageTable(DB); err != nil {
-		return fmt.Errorf("failed to run resource usage migration: %w", err)
-	}
-	
-	// Record that we ran migrations
-	migrationRecord := MigrationRecord{
-		Name:      fmt.Sprintf("migration-%s", time.Now().Format("20060102-150405")),
-		AppliedAt: time.Now(),
+
+	if err := migrations.Up(DB, logger); err != nil {
+		return err
 	}
-	
-	if err := DB.Create(&migrationRecord).Error; err != nil {
-		return fmt.Errorf("failed to record migration: %w", err)
+	if adopting {
+		// Databases created by versions older than the baseline may lack some of its columns
+		if err := DB.AutoMigrate(schemaModels...); err != nil {
+			return fmt.Errorf("failed to bring the schema up to the baseline: %w", err)
+		}
 	}
-	
+	logger.Info("Database migrations are up to date")
 	return nil
 }
 
-// RunMigrationsWithLogger runs migrations with logging
-func RunMigrationsWithLogger(logger *logrus.Logger) error {
-	// Check if migrations have been run recently
-	var count int64
-	DB.Model(&MigrationRecord{}).Count(&count)
-	
-	if count > 0 {
-		// Get the latest migration record
-		var lastMigration MigrationRecord
-		if err := DB.Order("applied_at DESC").First(&lastMigration).Error; err == nil {
-			timeSince := time.Since(lastMigration.AppliedAt)
-			if timeSince < 24*time.Hour {
-				logger.Infof("Skipping migrations - last run %s ago", timeSince.Round(time.Second))
-				
-				// Always run our custom migrations
-				logger.Info("Running custom migrations...")
-				// Ensure resource_usages table has memory_limit column
-				if err := migrations.CreateResourceUsageTable(DB); err != nil {
-					logger.Warnf("Failed to run resource usage migration: %v", err)
-				} else {
-					logger.Info("Resource usage migration completed successfully")
-				}
-				
-				if err := RunIPAddressMigration(); err != nil {
-					logger.Warnf("Failed to run IP address migration: %v", err)
-				} else {
-					logger.Info("IP address migration completed successfully")
-				}
-				return nil
+// VerifyMigrations returns an error if migrations are pending or a column of the models is
+// missing from the database, without changing it
+func VerifyMigrations() error {
+	if !SQLite() {
+		pending, err := migrations.Pending(DB)
+		if err != nil {
+			return fmt.Errorf("failed to list migrations: %w", err)
+		}
+		if len(pending) > 0 {
+			names := make([]string, len(pending))
+			for i, migration := range pending {
+				names[i] = migration.Name
 			}
-			logger.Infof("Running migrations - last run %s ago", timeSince.Round(time.Second))
+			return fmt.Errorf("%d migrations are pending: %s", len(pending), strings.Join(names, ", "))
 		}
-	} else {
-		logger.Info("Running migrations for the first time")
 	}
-	
-	// Run the migrations
-	err := RunMigrations()
+
+	missing, err := MissingColumns()
 	if err != nil {
 		return err
 	}
-	
-	// Run our custom migrations
-	logger.Info("Running custom migrations...")
-	if err := RunIPAddressMigration(); err != nil {
-		logger.Warnf("Failed to run IP address migration: %v", err)
-	} else {
-		logger.Info("IP address migration completed successfully")
+	if len(missing) > 0 {
+		return fmt.Errorf("the database is missing columns of the models: %s", strings.Join(missing, ", "))
 	}
-	
 	return nil
 }
 
+// MissingColumns lists the columns of the models, as table.column, that the database lacks
+func MissingColumns() ([]string, error) {
+	var missing []string
+	for _, model := range schemaModels {
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := stmt.Schema.Table
+		if !DB.Migrator().HasTable(table) {
+			missing = append(missing, table+".*")
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !DB.Migrator().HasColumn(model, field.DBName) {
+				missing = append(missing, table+"."+field.DBName)
+			}
+		}
+	}
+	return missing, nil
+}
+
 // Close closes the database connection
 func Close() error {
 	sqlDB, err := DB.DB()
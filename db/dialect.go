@@ -0,0 +1,155 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// openDialector returns the GORM dialector of the configured database driver
+func openDialector(cfg *config.Config) gorm.Dialector {
+	if cfg.Database.Driver == "sqlite" {
+		return sqliteDialector{Dialector: &sqlite.Dialector{DSN: sqliteDSN(cfg.Database.SQLitePath)}}
+	}
+	return postgres.Open(fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+		cfg.Database.Host, cfg.Database.User, cfg.Database.Password, cfg.Database.Name, cfg.Database.Port))
+}
+
+// sqliteDSN builds the connection string of a SQLite database. Transactions take the write
+// lock when they begin, so they are serialized like under the advisory locks of Postgres;
+// writers wait for each other instead of failing, and :memory: is shared by all connections
+// of the pool.
+func sqliteDSN(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_txlock", "immediate")
+	if path == ":memory:" {
+		return "file:launchstack?mode=memory&cache=shared&" + params.Encode()
+	}
+	params.Add("_pragma", "journal_mode(WAL)")
+	return "file:" + path + "?" + params.Encode()
+}
+
+// sqliteDialector adapts the schemas of the models, written for Postgres, to SQLite
+type sqliteDialector struct {
+	*sqlite.Dialector
+}
+
+// Migrator returns a migrator creating columns with the defaults of the models
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{d.Dialector.Migrator(db).(sqlite.Migrator)}
+}
+
+type sqliteMigrator struct {
+	sqlite.Migrator
+}
+
+// FullDataTypeOf wraps the function calls of column defaults in parentheses, which SQLite
+// requires of every default that is not a literal
+func (m sqliteMigrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	expr := m.Migrator.FullDataTypeOf(field)
+	if field.DefaultValueInterface == nil && strings.HasSuffix(field.DefaultValue, ")") {
+		expr.SQL = strings.Replace(expr.SQL, "DEFAULT "+field.DefaultValue, "DEFAULT ("+field.DefaultValue+")", 1)
+	}
+	return expr
+}
+
+func init() {
+	// Defaults of the primary keys, which are usually set by the BeforeCreate hooks
+	gosqlite.MustRegisterScalarFunction("gen_random_uuid", 0, func(*gosqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return uuid.NewString(), nil
+	})
+}
+
+// configureSQLite prepares the connection pool and the process for a SQLite database
+func configureSQLite(cfg *config.Config) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	// An in-memory database is dropped when its last connection closes
+	if cfg.Database.SQLitePath == ":memory:" {
+		sqlDB.SetConnMaxIdleTime(0)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetMaxIdleConns(1)
+	}
+	// SQLite stores times as text and compares them as such, so they must all be written in
+	// the same zone, like the UTC session of Postgres
+	time.Local = time.UTC
+	return nil
+}
+
+// queryTime scans a time computed by a query, which SQLite returns as text or, for the time
+// buckets of the historical stats, as a Unix time. NULL scans as the zero time.
+type queryTime struct {
+	time.Time
+}
+
+// sqliteTimeFormats are the formats SQLite databases store times in, the first being the one
+// of the driver
+var sqliteTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// Scan implements sql.Scanner
+func (t *queryTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case time.Time:
+		t.Time = v
+	case int64:
+		t.Time = time.Unix(v, 0).UTC()
+	case string:
+		for _, format := range sqliteTimeFormats {
+			if parsed, err := time.Parse(format, v); err == nil {
+				t.Time = parsed
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot parse %q as a time", v)
+	default:
+		return fmt.Errorf("cannot scan %T into a time", value)
+	}
+	return nil
+}
+
+// SQLite reports whether the database is SQLite, on which the queries specific to Postgres
+// and TimescaleDB are replaced or skipped
+func SQLite() bool {
+	return DB != nil && DB.Dialector.Name() == "sqlite"
+}
+
+// lockTransaction takes a lock named name until tx ends, serializing the transactions that
+// take it across replicas. SQLite transactions are serialized already.
+func lockTransaction(tx *gorm.DB, name string) error {
+	if SQLite() {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", name).Error
+}
+
+// isDuplicateKey reports whether an error returned by the driver is a violation of a unique
+// index, which both dialects translate to gorm.ErrDuplicatedKey
+func isDuplicateKey(err error) bool {
+	if translator, ok := DB.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
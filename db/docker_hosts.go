@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// RegisterDockerHost records the physical resources of a host as reported by its Docker
+// engine. Hosts seen for the first time get the given default policy; the policy of known
+// hosts is left as admins tuned it.
+func RegisterDockerHost(defaults models.DockerHost) (*models.DockerHost, error) {
+	now := time.Now()
+	defaults.LastSeenAt = now
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"cpu_cores": defaults.CPUCores, "memory_mb": defaults.MemoryMB, "last_seen_at": now, "updated_at": now}),
+	}).Create(&defaults).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to register docker host: %w", err)
+	}
+
+	var host models.DockerHost
+	if err := DB.Where("name = ?", defaults.Name).First(&host).Error; err != nil {
+		return nil, fmt.Errorf("failed to get docker host: %w", err)
+	}
+	return &host, nil
+}
+
+// GetDockerHosts retrieves every known Docker host
+func GetDockerHosts() ([]models.DockerHost, error) {
+	var hosts []models.DockerHost
+	if err := DB.Order("name ASC").Find(&hosts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get docker hosts: %w", err)
+	}
+	return hosts, nil
+}
+
+// GetActiveDockerHosts retrieves the Docker hosts checked since the given time
+func GetActiveDockerHosts(since time.Time) ([]models.DockerHost, error) {
+	var hosts []models.DockerHost
+	if err := DB.Where("last_seen_at > ?", since).Order("name ASC").Find(&hosts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get active docker hosts: %w", err)
+	}
+	return hosts, nil
+}
+
+// GetDockerHost retrieves a Docker host by ID
+func GetDockerHost(id uuid.UUID) (*models.DockerHost, error) {
+	var host models.DockerHost
+	if err := DB.Where("id = ?", id).First(&host).Error; err != nil {
+		return nil, err
+	}
+	return &host, nil
+}
+
+// UpdateDockerHostPolicy stores the overcommit ratios and alert threshold of a host
+func UpdateDockerHostPolicy(host *models.DockerHost) error {
+	err := DB.Model(host).Updates(map[string]interface{}{
+		"cpu_overcommit":    host.CPUOvercommit,
+		"memory_overcommit": host.MemoryOvercommit,
+		"utilization_alert": host.UtilizationAlert,
+		"updated_at":        time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update docker host: %w", err)
+	}
+	return nil
+}
+
+// UpdateDockerHostUtilization stores the utilization measured on a host and whether it is
+// alerting
+func UpdateDockerHostUtilization(host *models.DockerHost) error {
+	err := DB.Model(host).Updates(map[string]interface{}{
+		"cpu_utilization":    host.CPUUtilization,
+		"memory_utilization": host.MemoryUtilization,
+		"alerting_since":     host.AlertingSince,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update docker host utilization: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// reencryptBatchSize is how many values are re-encrypted per query
+const reencryptBatchSize = 100
+
+// EncryptedColumn is a column holding values encrypted at rest with the keyring
+type EncryptedColumn struct {
+	Table     string
+	Column    string
+	Plaintext bool // values were stored in plaintext before the column was encrypted
+}
+
+// EncryptedColumns lists the columns encrypted at rest
+var EncryptedColumns = []EncryptedColumn{
+	{Table: "instance_credentials", Column: "encrypted_password"},
+	{Table: "instance_credentials", Column: "encrypted_webhook_secret"},
+	{Table: "api_keys", Column: "signing_secret", Plaintext: true},
+	{Table: "webhook_endpoints", Column: "secret", Plaintext: true},
+}
+
+// encryptedValue is a stored value of an encrypted column
+type encryptedValue struct {
+	ID    string
+	Value string
+}
+
+// CountStaleEncryptedValues counts the values of a column that are not encrypted with the
+// current key: plaintext, legacy or encrypted with a previous key. Soft-deleted rows count too.
+func CountStaleEncryptedValues(column EncryptedColumn) (int64, error) {
+	var count int64
+	err := DB.Table(column.Table).
+		Where(fmt.Sprintf("%[1]s <> '' AND %[1]s NOT LIKE ?", column.Column), models.EncryptedValuePrefix()+"%").
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count stale values of %s.%s: %w", column.Table, column.Column, err)
+	}
+	return count, nil
+}
+
+// ReencryptColumn encrypts the values of a column that are not encrypted with the current key
+// with it, returning how many were rewritten. Values that cannot be decrypted are left as
+// they are and counted as failed.
+func ReencryptColumn(column EncryptedColumn) (int, int, error) {
+	prefix := models.EncryptedValuePrefix()
+	rewritten, failed := 0, 0
+	lastID := uuid.Nil.String()
+	for {
+		var values []encryptedValue
+		err := DB.Table(column.Table).
+			Select(fmt.Sprintf("id, %s AS value", column.Column)).
+			Where(fmt.Sprintf("%[1]s <> '' AND %[1]s NOT LIKE ? AND id > ?", column.Column), prefix+"%", lastID).
+			Order("id ASC").
+			Limit(reencryptBatchSize).
+			Find(&values).Error
+		if err != nil {
+			return rewritten, failed, fmt.Errorf("failed to read %s.%s: %w", column.Table, column.Column, err)
+		}
+
+		for _, value := range values {
+			lastID = value.ID
+			encrypted, err := models.Reencrypt(value.Value, column.Plaintext)
+			if err != nil {
+				failed++
+				continue
+			}
+			// Only the value is rewritten, without touching updated_at, and only if it did not
+			// change in the meantime
+			result := DB.Table(column.Table).
+				Where(fmt.Sprintf("id = ? AND %s = ?", column.Column), value.ID, value.Value).
+				UpdateColumn(column.Column, encrypted)
+			if result.Error != nil {
+				return rewritten, failed, fmt.Errorf("failed to update %s.%s: %w", column.Table, column.Column, result.Error)
+			}
+			rewritten += int(result.RowsAffected)
+		}
+		if len(values) < reencryptBatchSize {
+			return rewritten, failed, nil
+		}
+	}
+}
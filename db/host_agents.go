@@ -0,0 +1,130 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// CreateHostAgent registers a host agent
+func CreateHostAgent(agent *models.HostAgent) error {
+	if err := DB.Create(agent).Error; err != nil {
+		return fmt.Errorf("failed to create host agent: %w", err)
+	}
+	return nil
+}
+
+// GetHostAgentByID retrieves a host agent
+func GetHostAgentByID(id uuid.UUID) (*models.HostAgent, error) {
+	var agent models.HostAgent
+	if err := DB.Where("id = ?", id).First(&agent).Error; err != nil {
+		return nil, fmt.Errorf("failed to get host agent: %w", err)
+	}
+	return &agent, nil
+}
+
+// GetHostAgentByHost retrieves the agent registered for a host
+func GetHostAgentByHost(host string) (*models.HostAgent, error) {
+	var agent models.HostAgent
+	if err := DB.Where("host = ?", host).First(&agent).Error; err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// GetAllHostAgents retrieves all registered host agents
+func GetAllHostAgents() ([]models.HostAgent, error) {
+	var agents []models.HostAgent
+	if err := DB.Order("host ASC").Find(&agents).Error; err != nil {
+		return nil, fmt.Errorf("failed to get host agents: %w", err)
+	}
+	return agents, nil
+}
+
+// RecordHostAgentCheckIn stores when and from where an agent last checked in
+func RecordHostAgentCheckIn(agentID uuid.UUID, ip, version string, at time.Time) error {
+	updates := map[string]interface{}{
+		"last_check_in_at": at,
+		"last_check_in_ip": ip,
+	}
+	if version != "" {
+		updates["version"] = version
+	}
+	if err := DB.Model(&models.HostAgent{}).Where("id = ?", agentID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to record host agent check-in: %w", err)
+	}
+	return nil
+}
+
+// RevokeHostAgent revokes an agent and all of its tokens
+func RevokeHostAgent(agentID uuid.UUID, at time.Time) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.HostAgent{}).Where("id = ?", agentID).Update("revoked_at", at).Error; err != nil {
+			return fmt.Errorf("failed to revoke host agent: %w", err)
+		}
+		if err := tx.Model(&models.AgentToken{}).Where("agent_id = ? AND revoked_at IS NULL", agentID).
+			Update("revoked_at", at).Error; err != nil {
+			return fmt.Errorf("failed to revoke agent tokens: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateAgentToken records a token issued to an agent
+func CreateAgentToken(token *models.AgentToken) error {
+	if err := DB.Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create agent token: %w", err)
+	}
+	return nil
+}
+
+// GetAgentTokenByID retrieves the record of an agent token by its jti claim
+func GetAgentTokenByID(id uuid.UUID) (*models.AgentToken, error) {
+	var token models.AgentToken
+	if err := DB.Where("id = ?", id).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetActiveAgentTokens retrieves the tokens of an agent that are neither revoked nor expired
+func GetActiveAgentTokens(agentID uuid.UUID, now time.Time) ([]models.AgentToken, error) {
+	var tokens []models.AgentToken
+	if err := DB.Where("agent_id = ? AND revoked_at IS NULL AND expires_at > ?", agentID, now).
+		Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to get agent tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeAgentToken revokes one token of an agent, reporting whether it was still active
+func RevokeAgentToken(agentID, tokenID uuid.UUID, at time.Time) (bool, error) {
+	result := DB.Model(&models.AgentToken{}).
+		Where("id = ? AND agent_id = ? AND revoked_at IS NULL", tokenID, agentID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to revoke agent token: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteExpiredAgentTokens removes the records of tokens that expired before the cutoff;
+// they are rejected on their expiry anyway, so they no longer need to be on the revocation list
+func DeleteExpiredAgentTokens(cutoff time.Time) (int64, error) {
+	result := DB.Where("expires_at < ?", cutoff).Delete(&models.AgentToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired agent tokens: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RestoreHostAgent lifts the revocation of an agent so it can be issued tokens again
+func RestoreHostAgent(agentID uuid.UUID) error {
+	if err := DB.Model(&models.HostAgent{}).Where("id = ?", agentID).Update("revoked_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to restore host agent: %w", err)
+	}
+	return nil
+}
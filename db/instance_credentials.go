@@ -0,0 +1,25 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// GetInstanceCredential retrieves the stored basic auth credentials of an instance
+func GetInstanceCredential(instanceID uuid.UUID) (*models.InstanceCredential, error) {
+	var credential models.InstanceCredential
+	if err := DB.Where("instance_id = ?", instanceID).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// SaveInstanceCredential creates or updates the stored credentials of an instance
+func SaveInstanceCredential(credential *models.InstanceCredential) error {
+	return DB.Save(credential).Error
+}
+
+// DeleteInstanceCredential removes the stored credentials of a deleted instance
+func DeleteInstanceCredential(instanceID uuid.UUID) error {
+	return DB.Where("instance_id = ?", instanceID).Delete(&models.InstanceCredential{}).Error
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// CreateInstanceEvent records an event on an instance
+func CreateInstanceEvent(event *models.InstanceEvent) error {
+	if err := DB.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create instance event: %w", err)
+	}
+	return nil
+}
+
+// GetInstanceEvents retrieves the most recent events of an instance
+func GetInstanceEvents(instanceID uuid.UUID, limit int) ([]models.InstanceEvent, error) {
+	var events []models.InstanceEvent
+	if err := DB.Where("instance_id = ?", instanceID).Order("created_at DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get instance events: %w", err)
+	}
+	return events, nil
+}
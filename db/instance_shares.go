@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// SaveInstanceShare shares an instance with a user, or changes their access if it is already
+// shared with them, and returns the share with its user
+func SaveInstanceShare(share *models.InstanceShare) (*models.InstanceShare, error) {
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "instance_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"access", "updated_at"}),
+	}).Create(share).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save instance share: %w", err)
+	}
+	var saved models.InstanceShare
+	if err := DB.Preload("User").Where("instance_id = ? AND user_id = ?", share.InstanceID, share.UserID).First(&saved).Error; err != nil {
+		return nil, fmt.Errorf("failed to get instance share: %w", err)
+	}
+	return &saved, nil
+}
+
+// GetInstanceShare retrieves the share of an instance with a user, returning
+// gorm.ErrRecordNotFound if the instance is not shared with them
+func GetInstanceShare(instanceID, userID uuid.UUID) (*models.InstanceShare, error) {
+	var share models.InstanceShare
+	if err := DB.Where("instance_id = ? AND user_id = ?", instanceID, userID).First(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// GetInstanceShares retrieves the shares of an instance with their users
+func GetInstanceShares(instanceID uuid.UUID) ([]models.InstanceShare, error) {
+	var shares []models.InstanceShare
+	if err := DB.Preload("User").Where("instance_id = ?", instanceID).Order("created_at").Find(&shares).Error; err != nil {
+		return nil, fmt.Errorf("failed to get instance shares: %w", err)
+	}
+	return shares, nil
+}
+
+// GetInstanceSharesByUserID retrieves the shares of instances with a user, keyed by instance ID
+func GetInstanceSharesByUserID(userID uuid.UUID) (map[uuid.UUID]models.InstanceShare, error) {
+	var shares []models.InstanceShare
+	if err := DB.Where("user_id = ?", userID).Find(&shares).Error; err != nil {
+		return nil, fmt.Errorf("failed to get instance shares: %w", err)
+	}
+	byInstance := make(map[uuid.UUID]models.InstanceShare, len(shares))
+	for _, share := range shares {
+		byInstance[share.InstanceID] = share
+	}
+	return byInstance, nil
+}
+
+// ListInstancesSharedWithUser retrieves a page of the instances shared with a user and the
+// number of matching ones
+func ListInstancesSharedWithUser(userID uuid.UUID, opts ListOptions) ([]models.Instance, int64, error) {
+	shared := DB.Model(&models.InstanceShare{}).Select("instance_id").Where("user_id = ?", userID)
+	return listInstances(DB.Where("id IN (?)", shared), opts)
+}
+
+// DeleteInstanceShare stops sharing an instance with a user. It reports whether the instance
+// was shared with them.
+func DeleteInstanceShare(instanceID, userID uuid.UUID) (bool, error) {
+	result := DB.Where("instance_id = ? AND user_id = ?", instanceID, userID).Delete(&models.InstanceShare{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete instance share: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -1,12 +1,19 @@
 package db
 
 import (
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+// ErrHostTaken is returned when saving an instance whose subdomain another instance has
+var ErrHostTaken = errors.New("subdomain is taken by another instance")
+
 // Logger is a package-level logger that can be set by the caller
 var Logger *logrus.Logger
 
@@ -63,6 +70,33 @@ func GetInstanceByID(instanceID uuid.UUID) (*models.Instance, error) {
 	return &instance, nil
 }
 
+// GetInstanceByHost retrieves an instance by the subdomain it is served on
+func GetInstanceByHost(host string) (*models.Instance, error) {
+	var instance models.Instance
+	if err := DB.Where("host = ?", host).First(&instance).Error; err != nil {
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	return &instance, nil
+}
+
+// HostTaken reports whether an instance that is not deleted has a subdomain
+func HostTaken(host string) (bool, error) {
+	var count int64
+	if err := DB.Model(&models.Instance{}).Where("host = ?", host).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to look up subdomain: %w", err)
+	}
+	return count > 0, nil
+}
+
+// createInstanceRecord inserts an instance, returning ErrHostTaken when its subdomain is taken
+func createInstanceRecord(tx *gorm.DB, instance *models.Instance) error {
+	err := tx.Create(instance).Error
+	if err != nil && isDuplicateKey(err) {
+		return ErrHostTaken
+	}
+	return err
+}
+
 // CreateInstance creates a new instance
 func CreateInstance(instance *models.Instance) error {
 	logger := getLogger()
@@ -72,7 +106,13 @@ func CreateInstance(instance *models.Instance) error {
 		"name":        instance.Name,
 	}).Info("Creating new instance in database")
 	
-	if err := DB.Create(instance).Error; err != nil {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := createInstanceRecord(tx, instance); err != nil {
+			return err
+		}
+		return queueInstanceWebhooks(tx, instance, models.WebhookInstanceCreated, "")
+	})
+	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"instance_id": instance.ID,
 			"user_id":     instance.UserID,
@@ -91,7 +131,7 @@ func CreateInstance(instance *models.Instance) error {
 	return nil
 }
 
-// UpdateInstance updates an existing instance
+// UpdateInstance updates an existing instance, queueing webhook events if its status changed
 func UpdateInstance(instance *models.Instance) error {
 	logger := getLogger()
 	logger.WithFields(logrus.Fields{
@@ -100,7 +140,20 @@ func UpdateInstance(instance *models.Instance) error {
 		"status":      instance.Status,
 	}).Info("Updating instance in database")
 	
-	if err := DB.Save(instance).Error; err != nil {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var previous models.Instance
+		if err := tx.Select("status").Where("id = ?", instance.ID).Take(&previous).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := tx.Save(instance).Error; err != nil {
+			return err
+		}
+		if previous.Status == instance.Status {
+			return nil
+		}
+		return queueInstanceWebhooks(tx, instance, models.InstanceStatusEvent(instance.Status), previous.Status)
+	})
+	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"instance_id": instance.ID,
 			"error":       err.Error(),
@@ -162,4 +215,198 @@ func GetRunningInstances() ([]models.Instance, error) {
 	var instances []models.Instance
 	result := DB.Where("status = ?", models.StatusRunning).Find(&instances)
 	return instances, result.Error
-} 
\ No newline at end of file
+} 
+
+// GetInstancesByStatus retrieves all instances with any of the given statuses
+func GetInstancesByStatus(statuses ...models.InstanceStatus) ([]models.Instance, error) {
+	var instances []models.Instance
+	result := DB.Where("status IN ?", statuses).Find(&instances)
+	return instances, result.Error
+}
+
+// instanceActivityResolution is how stale the recorded activity of an instance may get
+// before it is written again, so busy instances are not updated on every request
+const instanceActivityResolution = 5 * time.Minute
+
+// RecordInstanceActivity notes that an instance was in use at a time
+func RecordInstanceActivity(instanceID uuid.UUID, at time.Time) error {
+	return DB.Model(&models.Instance{}).
+		Where("id = ? AND (last_activity_at IS NULL OR last_activity_at < ?)", instanceID, at.Add(-instanceActivityResolution)).
+		Update("last_activity_at", at).Error
+}
+
+// GetIdleInstances retrieves the running instances of users on a plan whose latest activity,
+// or their creation if they had none, was before a time
+func GetIdleInstances(plan string, before time.Time) ([]models.Instance, error) {
+	var instances []models.Instance
+	result := DB.Where("status = ? AND user_id IN (?) AND COALESCE(last_activity_at, created_at) < ?",
+		models.StatusRunning, DB.Model(&models.User{}).Select("id").Where("plan = ?", plan), before).
+		Find(&instances)
+	return instances, result.Error
+}
+
+// GetExpiredTrashedInstances retrieves trashed instances whose retention ended before a time
+func GetExpiredTrashedInstances(before time.Time) ([]models.Instance, error) {
+	var instances []models.Instance
+	result := DB.Where("status = ? AND purge_at <= ?", models.StatusTrashed, before).Find(&instances)
+	return instances, result.Error
+}
+
+// GetPinnedInstances retrieves all instances pinned to dedicated CPUs
+func GetPinnedInstances() ([]models.Instance, error) {
+	var instances []models.Instance
+	result := DB.Where("cpu_set <> ''").Find(&instances)
+	return instances, result.Error
+}
+
+// ReserveCPUSet records the dedicated CPUs of an instance chosen by choose, which is given
+// all currently pinned instances. Reservations are serialized across replicas with an
+// advisory lock, so two replicas never hand out the same CPUs.
+func ReserveCPUSet(instanceID uuid.UUID, choose func(pinned []models.Instance) (string, error)) (string, error) {
+	var cpuSet string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockTransaction(tx, "cpu_pinning"); err != nil {
+			return fmt.Errorf("failed to lock CPU pinning: %w", err)
+		}
+		var pinned []models.Instance
+		if err := tx.Where("cpu_set <> ''").Find(&pinned).Error; err != nil {
+			return fmt.Errorf("failed to get pinned instances: %w", err)
+		}
+		var err error
+		if cpuSet, err = choose(pinned); err != nil {
+			return err
+		}
+		return tx.Model(&models.Instance{}).Where("id = ?", instanceID).Update("cpu_set", cpuSet).Error
+	})
+	return cpuSet, err
+}
+
+// SetInstanceCPUSet records the dedicated CPUs of an instance without touching its other fields
+func SetInstanceCPUSet(instanceID uuid.UUID, cpuSet string) error {
+	return DB.Model(&models.Instance{}).Where("id = ?", instanceID).Update("cpu_set", cpuSet).Error
+}
+
+// SetInstanceStorageUsage records the last measured volume usage of an instance
+func SetInstanceStorageUsage(instanceID uuid.UUID, bytes int64) error {
+	return DB.Model(&models.Instance{}).Where("id = ?", instanceID).Update("storage_usage", bytes).Error
+}
+
+// SetInstanceIPAddress records the address of an instance's container without touching its other fields
+func SetInstanceIPAddress(instanceID uuid.UUID, ip string) error {
+	return DB.Model(&models.Instance{}).Where("id = ?", instanceID).Update("ip_address", ip).Error
+}
+
+// SetInstanceResourceOverride records whether an instance's CPU and memory limits were set
+// explicitly rather than by its plan
+func SetInstanceResourceOverride(instanceID uuid.UUID, override bool) error {
+	return DB.Model(&models.Instance{}).Where("id = ?", instanceID).Update("resource_override", override).Error
+}
+
+// GetAllInstances retrieves all instances across users ordered by creation date
+func GetAllInstances() ([]models.Instance, error) {
+	var instances []models.Instance
+	result := DB.Order("created_at DESC").Find(&instances)
+	return instances, result.Error
+}
+
+// instanceSearchColumns are the columns instance listings search
+var instanceSearchColumns = []string{"name"}
+
+// ListInstancesByUserID retrieves a page of a user's instances and the number of matching ones
+func ListInstancesByUserID(userID uuid.UUID, opts ListOptions) ([]models.Instance, int64, error) {
+	return listInstances(DB.Where("user_id = ?", userID), opts)
+}
+
+// ListInstancesByProjectID retrieves a page of a project's instances and the number of matching ones
+func ListInstancesByProjectID(projectID uuid.UUID, opts ListOptions) ([]models.Instance, int64, error) {
+	return listInstances(DB.Where("project_id = ?", projectID), opts)
+}
+
+// ListInstancesByOrganizationID retrieves a page of the instances shared with an organization
+// and the number of matching ones
+func ListInstancesByOrganizationID(orgID uuid.UUID, opts ListOptions) ([]models.Instance, int64, error) {
+	return listInstances(DB.Where("organization_id = ?", orgID), opts)
+}
+
+// ListAllInstances retrieves a page of all instances and the number of matching ones
+func ListAllInstances(opts ListOptions) ([]models.Instance, int64, error) {
+	return listInstances(DB, opts)
+}
+
+// GetInstanceListVersionByUserID returns the version of a user's instances matching a listing's filters
+func GetInstanceListVersionByUserID(userID uuid.UUID, opts ListOptions) (ListVersion, error) {
+	return instanceListVersion(DB.Where("user_id = ?", userID), opts)
+}
+
+// GetInstanceListVersionByProjectID returns the version of a project's instances matching a listing's filters
+func GetInstanceListVersionByProjectID(projectID uuid.UUID, opts ListOptions) (ListVersion, error) {
+	return instanceListVersion(DB.Where("project_id = ?", projectID), opts)
+}
+
+func instanceListVersion(query *gorm.DB, opts ListOptions) (ListVersion, error) {
+	version, err := GetListVersion(query.Model(&models.Instance{}), opts, instanceSearchColumns)
+	if err != nil {
+		return version, fmt.Errorf("failed to get instance list version: %w", err)
+	}
+	return version, nil
+}
+
+func listInstances(query *gorm.DB, opts ListOptions) ([]models.Instance, int64, error) {
+	var instances []models.Instance
+	total, err := Paginate(query.Model(&models.Instance{}), opts, instanceSearchColumns, &instances)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list instances: %w", err)
+	}
+	return instances, total, nil
+}
+
+// CountInstancesByStatus counts instances grouped by status
+func CountInstancesByStatus() (map[models.InstanceStatus]int64, error) {
+	var rows []struct {
+		Status models.InstanceStatus
+		Count  int64
+	}
+	if err := DB.Model(&models.Instance{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count instances by status: %w", err)
+	}
+	
+	counts := make(map[models.InstanceStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// AllocatedResources holds the resources given to the instances on the host
+type AllocatedResources struct {
+	Instances int64
+	CPU       float64
+	MemoryMB  int64
+}
+
+// GetAllocatedResources sums the resource limits of all instances that have not been deleted
+// and of the capacity reserved for waitlisted requests, except the given reservation
+func GetAllocatedResources(excludeReservation uuid.UUID) (*AllocatedResources, error) {
+	var allocated AllocatedResources
+	err := DB.Model(&models.Instance{}).
+		Select("COUNT(*) AS instances, COALESCE(SUM(cpu_limit), 0) AS cpu, COALESCE(SUM(memory_limit), 0) AS memory_mb").
+		Where("status <> ?", models.StatusDeleted).
+		Scan(&allocated).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum allocated resources: %w", err)
+	}
+
+	var reserved AllocatedResources
+	err = DB.Model(&models.WaitlistEntry{}).
+		Select("COUNT(*) AS instances, COALESCE(SUM(cpu_limit), 0) AS cpu, COALESCE(SUM(memory_limit), 0) AS memory_mb").
+		Where("status = ? AND reserved_until > ? AND id <> ?", models.WaitlistReserved, time.Now(), excludeReservation).
+		Scan(&reserved).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum reserved resources: %w", err)
+	}
+
+	allocated.Instances += reserved.Instances
+	allocated.CPU += reserved.CPU
+	allocated.MemoryMB += reserved.MemoryMB
+	return &allocated, nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// invoiceNumberAttempts is how often issuing an invoice picks the next number again when a
+// concurrent invoice took it
+const invoiceNumberAttempts = 5
+
+// IssueInvoice issues the invoice of a completed payment, numbered after the last invoice of
+// the year, and points the payment at it. A payment that already has an invoice keeps it.
+func IssueInvoice(tx *gorm.DB, payment *models.Payment, user models.User) (*models.Invoice, error) {
+	invoice := models.NewInvoice(payment, user)
+	prefix := fmt.Sprintf("%s%d-", models.InvoiceNumberPrefix, invoice.IssuedAt.Year())
+
+	for attempt := 0; attempt < invoiceNumberAttempts; attempt++ {
+		if existing, err := getInvoiceByPaymentID(tx, payment.ID); err == nil {
+			return existing, setInvoiceURL(tx, payment)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		var last string
+		if err := tx.Model(&models.Invoice{}).Where("number LIKE ?", prefix+"%").
+			Select("COALESCE(MAX(number), '')").Scan(&last).Error; err != nil {
+			return nil, fmt.Errorf("failed to get last invoice number: %w", err)
+		}
+		sequence, _ := strconv.Atoi(strings.TrimPrefix(last, prefix))
+		invoice.Number = fmt.Sprintf("%s%06d", prefix, sequence+1)
+
+		// A concurrent invoice for the same payment or with the same number wins; the
+		// next attempt returns the former or picks a new number
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(invoice)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to create invoice: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return invoice, setInvoiceURL(tx, payment)
+		}
+	}
+	return nil, fmt.Errorf("failed to pick an invoice number after %d attempts", invoiceNumberAttempts)
+}
+
+// setInvoiceURL points a payment at the download of its invoice
+func setInvoiceURL(tx *gorm.DB, payment *models.Payment) error {
+	payment.InvoiceURL = models.InvoicePath(payment.ID)
+	if err := tx.Model(payment).UpdateColumn("invoice_url", payment.InvoiceURL).Error; err != nil {
+		return fmt.Errorf("failed to set invoice URL: %w", err)
+	}
+	return nil
+}
+
+// getInvoiceByPaymentID retrieves the invoice of a payment
+func getInvoiceByPaymentID(tx *gorm.DB, paymentID uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := tx.Where("payment_id = ?", paymentID).First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetInvoiceByPaymentID retrieves the invoice of a payment
+func GetInvoiceByPaymentID(paymentID uuid.UUID) (*models.Invoice, error) {
+	return getInvoiceByPaymentID(DB, paymentID)
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateJob queues a background job
+func CreateJob(job *models.Job) error {
+	if err := DB.Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+// GetJobByID retrieves a background job
+func GetJobByID(id uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	if err := DB.Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return &job, nil
+}
+
+// ClaimNextJob locks the queued job of one of the given types that has been due the longest,
+// marks it as running and returns it, or returns nil if no job is due. Jobs locked by
+// other workers are skipped, so several workers and hosts can share the queue.
+func ClaimNextJob(types []models.JobType) (*models.Job, error) {
+	var job models.Job
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ? AND type IN ?", models.JobQueued, time.Now(), types).
+			Order("run_at ASC").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.LockedAt = &now
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		return tx.Save(&job).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return &job, nil
+}
+
+// UpdateJob saves the state of a background job
+func UpdateJob(job *models.Job) error {
+	if err := DB.Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	return nil
+}
+
+// RequeueStaleJobs returns running jobs locked before the cutoff to the queue, so jobs
+// whose worker died with its process are retried
+func RequeueStaleJobs(lockedBefore time.Time) (int64, error) {
+	result := DB.Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobRunning, lockedBefore).
+		Updates(map[string]interface{}{"status": models.JobQueued, "locked_at": nil, "run_at": time.Now()})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to requeue stale jobs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
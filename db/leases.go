@@ -0,0 +1,80 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/models"
+)
+
+// AcquireLease takes the named lease for holder, or renews it if holder already has it, so
+// that it expires ttl from now. It reports false while another holder's lease has not
+// expired. Times come from the database clock so that replicas with skewed clocks agree.
+func AcquireLease(name, holder string, ttlSeconds float64) (bool, error) {
+	if SQLite() {
+		return acquireLeaseSQLite(name, holder, ttlSeconds)
+	}
+	result := DB.Exec(`
+		INSERT INTO leases (name, holder, acquired_at, renewed_at, expires_at)
+		VALUES (?, ?, NOW(), NOW(), NOW() + make_interval(secs => ?))
+		ON CONFLICT (name) DO UPDATE SET
+			holder = EXCLUDED.holder,
+			acquired_at = CASE WHEN leases.holder = EXCLUDED.holder AND leases.expires_at > NOW()
+				THEN leases.acquired_at ELSE EXCLUDED.acquired_at END,
+			renewed_at = EXCLUDED.renewed_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE leases.holder = EXCLUDED.holder OR leases.expires_at <= NOW()`,
+		name, holder, ttlSeconds)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// acquireLeaseSQLite is AcquireLease for SQLite, which has a single host and so takes the
+// time from the application's clock
+func acquireLeaseSQLite(name, holder string, ttlSeconds float64) (bool, error) {
+	now := time.Now()
+	result := DB.Exec(`
+		INSERT INTO leases (name, holder, acquired_at, renewed_at, expires_at)
+		VALUES (@name, @holder, @now, @now, @expires_at)
+		ON CONFLICT (name) DO UPDATE SET
+			holder = EXCLUDED.holder,
+			acquired_at = CASE WHEN leases.holder = EXCLUDED.holder AND leases.expires_at > @now
+				THEN leases.acquired_at ELSE EXCLUDED.acquired_at END,
+			renewed_at = EXCLUDED.renewed_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE leases.holder = EXCLUDED.holder OR leases.expires_at <= @now`,
+		sql.Named("name", name), sql.Named("holder", holder), sql.Named("now", now),
+		sql.Named("expires_at", now.Add(time.Duration(ttlSeconds*float64(time.Second)))))
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseLeases lets every lease of holder expire right away, so other replicas take them
+// over without waiting
+func ReleaseLeases(holder string) error {
+	var err error
+	if SQLite() {
+		now := time.Now()
+		err = DB.Exec("UPDATE leases SET expires_at = ? WHERE holder = ? AND expires_at > ?", now, holder, now).Error
+	} else {
+		err = DB.Exec("UPDATE leases SET expires_at = NOW() WHERE holder = ? AND expires_at > NOW()", holder).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release leases: %w", err)
+	}
+	return nil
+}
+
+// GetLeases retrieves all leases by name
+func GetLeases() ([]models.Lease, error) {
+	var leases []models.Lease
+	if err := DB.Order("name ASC").Find(&leases).Error; err != nil {
+		return nil, fmt.Errorf("failed to get leases: %w", err)
+	}
+	return leases, nil
+}
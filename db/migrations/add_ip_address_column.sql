@@ -1,2 +0,0 @@
--- Add IP address column to instances table
-ALTER TABLE instances ADD COLUMN IF NOT EXISTS ip_address VARCHAR(50); 
\ No newline at end of file
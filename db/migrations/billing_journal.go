@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ProtectBillingJournal makes billing_entries append-only by rejecting updates, deletes and
+// truncation in the database itself. It can run on every start.
+func ProtectBillingJournal(db *gorm.DB) error {
+	if db.Dialector.Name() == "sqlite" {
+		return protectBillingJournalSQLite(db)
+	}
+	if err := db.Exec(`
+		CREATE OR REPLACE FUNCTION billing_entries_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'billing_entries is append-only';
+		END;
+		$$ LANGUAGE plpgsql`).Error; err != nil {
+		return fmt.Errorf("failed to create billing journal trigger function: %w", err)
+	}
+
+	for name, trigger := range map[string]string{
+		"billing_entries_no_change":   "BEFORE UPDATE OR DELETE ON billing_entries FOR EACH ROW",
+		"billing_entries_no_truncate": "BEFORE TRUNCATE ON billing_entries FOR EACH STATEMENT",
+	} {
+		if err := db.Exec(fmt.Sprintf("CREATE OR REPLACE TRIGGER %s %s EXECUTE FUNCTION billing_entries_append_only()", name, trigger)).Error; err != nil {
+			return fmt.Errorf("failed to create trigger %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// protectBillingJournalSQLite rejects updates and deletes of billing_entries on SQLite, which
+// has no TRUNCATE
+func protectBillingJournalSQLite(db *gorm.DB) error {
+	for name, event := range map[string]string{
+		"billing_entries_no_update": "UPDATE",
+		"billing_entries_no_delete": "DELETE",
+	} {
+		trigger := fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %s BEFORE %s ON billing_entries
+			BEGIN SELECT RAISE(ABORT, 'billing_entries is append-only'); END`, name, event)
+		if err := db.Exec(trigger).Error; err != nil {
+			return fmt.Errorf("failed to create trigger %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -1,35 +1,150 @@
+// Package migrations versions the database schema. Migrations are the SQL files in sql/,
+// named like 00002_add_instance_tags.sql after their version and holding a -- +goose Up and
+// a -- +goose Down section. goose applies them in order and records them in
+// schema_migrations. Migrations after the baseline create tables, columns and indexes with
+// IF NOT EXISTS, as databases adopted from AutoMigrate may already have them.
+//
+// Runtime settings that depend on the server, such as the TimescaleDB policies and the
+// billing journal triggers, are applied on every start instead.
 package migrations
 
 import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/pressly/goose/v3"
 	"gorm.io/gorm"
 )
 
-// Migration represents a database migration
+// TableName is the table recording the applied migrations
+const TableName = "schema_migrations"
+
+// BaselineVersion is the version of the schema created by AutoMigrate before migrations were
+// versioned
+const BaselineVersion = 1
+
+// Dir is where new migrations are created, relative to the repository root
+const Dir = "db/migrations/sql"
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Migration is a migration and whether it has been applied
 type Migration struct {
-	Name     string
-	Migrate  func(db *gorm.DB) error
-	Rollback func(db *gorm.DB) error
-}
-
-// AllMigrations returns a slice of all migrations
-func AllMigrations() []Migration {
-	return []Migration{
-		// Add migrations in order
-		{
-			Name:     "CreateUsersTable",
-			Migrate:  CreateUsersTable,
-			Rollback: DropUsersTable,
-		},
-		{
-			Name:     "CreateInstancesTable",
-			Migrate:  CreateInstancesTable,
-			Rollback: DropInstancesTable,
-		},
-		{
-			Name:     "CreateResourceUsageTable",
-			Migrate:  CreateResourceUsageTable,
-			Rollback: func(db *gorm.DB) error { return db.Migrator().DropTable("resource_usages") },
-		},
-		// Add more migrations as needed
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Logger receives the progress of goose
+type Logger = goose.Logger
+
+// setup points goose at the embedded migrations of the database's dialect
+func setup(db *gorm.DB, logger Logger) error {
+	if db.Dialector.Name() != "postgres" {
+		return fmt.Errorf("versioned migrations are not supported on %s", db.Dialector.Name())
+	}
+	goose.SetBaseFS(files)
+	goose.SetTableName(TableName)
+	if logger != nil {
+		goose.SetLogger(logger)
+	}
+	return goose.SetDialect("postgres")
+}
+
+// Up applies the pending migrations
+func Up(db *gorm.DB, logger Logger) error {
+	return run(db, logger, func(pool *sql.DB) error { return goose.Up(pool, "sql") })
+}
+
+// Down rolls back the latest applied migration
+func Down(db *gorm.DB, logger Logger) error {
+	return run(db, logger, func(pool *sql.DB) error { return goose.Down(pool, "sql") })
+}
+
+// Redo rolls back the latest applied migration and applies it again
+func Redo(db *gorm.DB, logger Logger) error {
+	return run(db, logger, func(pool *sql.DB) error { return goose.Redo(pool, "sql") })
+}
+
+// List returns every migration in order of version, with the time it was applied
+func List(db *gorm.DB) ([]Migration, error) {
+	var list []Migration
+	err := run(db, nil, func(*sql.DB) error {
+		known, err := goose.CollectMigrations("sql", 0, goose.MaxVersion)
+		if err != nil {
+			return err
+		}
+		applied := map[int64]time.Time{}
+		if db.Migrator().HasTable(TableName) {
+			var rows []struct {
+				VersionID int64
+				Tstamp    time.Time
+			}
+			err := db.Table(TableName).Select("version_id, MAX(tstamp) AS tstamp").
+				Where("is_applied AND version_id > 0").Group("version_id").Scan(&rows).Error
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", TableName, err)
+			}
+			for _, row := range rows {
+				applied[row.VersionID] = row.Tstamp
+			}
+		}
+		for _, m := range known {
+			migration := Migration{Version: m.Version, Name: filepath.Base(m.Source)}
+			if at, ok := applied[m.Version]; ok {
+				migration.AppliedAt = &at
+			}
+			list = append(list, migration)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// Pending returns the migrations that have not been applied
+func Pending(db *gorm.DB) ([]Migration, error) {
+	list, err := List(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range list {
+		if migration.AppliedAt == nil {
+			pending = append(pending, migration)
+		}
 	}
-} 
\ No newline at end of file
+	return pending, nil
+}
+
+// MarkBaselineApplied records the baseline migration as applied without running it, for
+// databases whose schema was created by AutoMigrate
+func MarkBaselineApplied(db *gorm.DB) error {
+	return run(db, nil, func(pool *sql.DB) error {
+		if _, err := goose.EnsureDBVersion(pool); err != nil {
+			return err
+		}
+		return db.Exec("INSERT INTO "+TableName+" (version_id, is_applied) VALUES (?, TRUE)", BaselineVersion).Error
+	})
+}
+
+// Create adds an empty migration named name to dir, versioned after the latest one
+func Create(dir, name string) error {
+	goose.SetSequential(true)
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// run runs fn with the connection pool of db once goose is set up
+func run(db *gorm.DB, logger Logger, fn func(*sql.DB) error) error {
+	if err := setup(db, logger); err != nil {
+		return err
+	}
+	pool, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return fn(pool)
+}
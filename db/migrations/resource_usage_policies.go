@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrTimescaleUnavailable is returned when the database does not have the TimescaleDB extension
+var ErrTimescaleUnavailable = errors.New("the timescaledb extension is not installed")
+
+// ResourceUsagePolicy defines how long resource usage samples are kept and when they are compressed
+type ResourceUsagePolicy struct {
+	Retention       time.Duration // raw samples are dropped after this
+	CompressAfter   time.Duration // raw samples are compressed after this
+	HourlyRetention time.Duration // hourly aggregates are dropped after this
+}
+
+// ConfigureResourceUsageStorage makes resource_usages a hypertable, downsamples it into the
+// resource_usages_hourly continuous aggregate, and applies the retention and compression
+// policies. It can run on every start; policies are replaced so changed settings apply.
+func ConfigureResourceUsageStorage(db *gorm.DB, policy ResourceUsagePolicy) error {
+	if db.Dialector.Name() == "sqlite" {
+		return ErrTimescaleUnavailable
+	}
+	var extensions int64
+	if err := db.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'timescaledb'").Scan(&extensions).Error; err != nil {
+		return fmt.Errorf("failed to check for timescaledb: %w", err)
+	}
+	if extensions == 0 {
+		return ErrTimescaleUnavailable
+	}
+
+	if err := createResourceUsageHypertable(db); err != nil {
+		return err
+	}
+
+	// Hourly downsampling, kept much longer than the raw samples
+	if err := db.Exec(`
+		CREATE MATERIALIZED VIEW IF NOT EXISTS resource_usages_hourly
+		WITH (timescaledb.continuous) AS
+		SELECT
+			instance_id,
+			time_bucket(INTERVAL '1 hour', timestamp) AS bucket,
+			AVG(cpu_usage) AS avg_cpu,
+			MAX(cpu_usage) AS max_cpu,
+			AVG(memory_usage)::BIGINT AS avg_memory,
+			MAX(memory_usage) AS max_memory,
+			AVG(memory_percentage) AS avg_memory_percentage,
+			SUM(network_in) AS total_network_in,
+			SUM(network_out) AS total_network_out,
+			COUNT(*) AS sample_count
+		FROM resource_usages
+		GROUP BY instance_id, bucket
+		WITH NO DATA`).Error; err != nil {
+		return fmt.Errorf("failed to create resource_usages_hourly: %w", err)
+	}
+	if err := db.Exec(`SELECT add_continuous_aggregate_policy('resource_usages_hourly',
+		start_offset => INTERVAL '3 days',
+		end_offset => INTERVAL '1 hour',
+		schedule_interval => INTERVAL '30 minutes',
+		if_not_exists => TRUE)`).Error; err != nil {
+		return fmt.Errorf("failed to add resource_usages_hourly refresh policy: %w", err)
+	}
+
+	// Compress older chunks per instance, which is how they are queried
+	if err := db.Exec(`ALTER TABLE resource_usages SET (
+		timescaledb.compress,
+		timescaledb.compress_segmentby = 'instance_id',
+		timescaledb.compress_orderby = 'timestamp DESC')`).Error; err != nil {
+		return fmt.Errorf("failed to enable resource_usages compression: %w", err)
+	}
+	if err := db.Exec("SELECT remove_compression_policy('resource_usages', if_exists => TRUE)").Error; err != nil {
+		return fmt.Errorf("failed to replace resource_usages compression policy: %w", err)
+	}
+	if err := db.Exec("SELECT add_compression_policy('resource_usages', ?::interval)", interval(policy.CompressAfter)).Error; err != nil {
+		return fmt.Errorf("failed to add resource_usages compression policy: %w", err)
+	}
+
+	for table, retention := range map[string]time.Duration{
+		"resource_usages":        policy.Retention,
+		"resource_usages_hourly": policy.HourlyRetention,
+	} {
+		if err := db.Exec("SELECT remove_retention_policy(?::regclass, if_exists => TRUE)", table).Error; err != nil {
+			return fmt.Errorf("failed to replace %s retention policy: %w", table, err)
+		}
+		if err := db.Exec("SELECT add_retention_policy(?::regclass, ?::interval)", table, interval(retention)).Error; err != nil {
+			return fmt.Errorf("failed to add %s retention policy: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// createResourceUsageHypertable converts resource_usages into a hypertable if it is not one
+// yet. Unique constraints of a hypertable must include the time column, so the primary key
+// is widened to (id, timestamp) first.
+func createResourceUsageHypertable(db *gorm.DB) error {
+	var hypertables int64
+	if err := db.Raw("SELECT COUNT(*) FROM timescaledb_information.hypertables WHERE hypertable_name = 'resource_usages'").Scan(&hypertables).Error; err != nil {
+		return fmt.Errorf("failed to check for the resource_usages hypertable: %w", err)
+	}
+	if hypertables > 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE resource_usages DROP CONSTRAINT IF EXISTS resource_usages_pkey").Error; err != nil {
+			return fmt.Errorf("failed to drop the resource_usages primary key: %w", err)
+		}
+		if err := tx.Exec("ALTER TABLE resource_usages ADD PRIMARY KEY (id, timestamp)").Error; err != nil {
+			return fmt.Errorf("failed to widen the resource_usages primary key: %w", err)
+		}
+		if err := tx.Exec("SELECT create_hypertable('resource_usages', 'timestamp', migrate_data => TRUE)").Error; err != nil {
+			return fmt.Errorf("failed to create the resource_usages hypertable: %w", err)
+		}
+		return nil
+	})
+}
+
+// interval formats a duration as a Postgres interval
+func interval(d time.Duration) string {
+	return fmt.Sprintf("%d seconds", int64(d.Seconds()))
+}
@@ -0,0 +1,592 @@
+-- The schema as GORM's AutoMigrate created it before migrations were versioned. Databases
+-- created that way are marked as migrated to this version instead of running it, and the
+-- columns of it they lack are added by AutoMigrate once the later migrations are applied.
+
+-- +goose Up
+CREATE TABLE "users" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "clerk_user_id" text,
+    "email" text,
+    "username" text,
+    "password_hash" text,
+    "first_name" text,
+    "last_name" text,
+    "plan" varchar(20) DEFAULT 'free',
+    "role" varchar(20) DEFAULT 'user',
+    "reseller_id" uuid,
+    "instance_quota" bigint,
+    "language" varchar(10),
+    "pay_pal_customer_id" text,
+    "subscription_id" text,
+    "subscription_provider" varchar(20),
+    "subscription_status" varchar(50),
+    "current_period_end" timestamptz,
+    "spending_cap" bigint DEFAULT 0,
+    "spending_cap_lifted_for" date,
+    "spending_cap_notified_for" date,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_users_clerk_user_id" ON "users" ("clerk_user_id");
+CREATE INDEX "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX "idx_users_reseller_id" ON "users" ("reseller_id");
+CREATE UNIQUE INDEX "idx_users_username" ON "users" ("username");
+CREATE UNIQUE INDEX "idx_users_email" ON "users" ("email");
+
+CREATE TABLE "projects" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "name" varchar(255) NOT NULL,
+    "description" varchar(1000),
+    "default_cpu_limit" decimal,
+    "default_memory_limit" bigint,
+    "default_storage_limit" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_projects_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_projects_deleted_at" ON "projects" ("deleted_at");
+CREATE INDEX "idx_projects_user_id" ON "projects" ("user_id");
+
+CREATE TABLE "instances" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "project_id" uuid,
+    "name" varchar(255) NOT NULL,
+    "description" varchar(1000),
+    "status" varchar(50) NOT NULL,
+    "host" varchar(255),
+    "port" bigint,
+    "url" varchar(255),
+    "cpu_limit" decimal,
+    "memory_limit" bigint,
+    "storage_limit" bigint,
+    "cpu_set" varchar(255),
+    "resource_override" boolean DEFAULT false,
+    "container_id" varchar(255),
+    "ip_address" varchar(50),
+    "image_tag" varchar(100) DEFAULT 'latest',
+    "image_digest" varchar(255),
+    "previous_image_tag" varchar(100),
+    "previous_image_digest" varchar(255),
+    "upgraded_at" timestamptz,
+    "suspended_reason" varchar(100),
+    "storage_warned_at" timestamptz,
+    "storage_usage" bigint DEFAULT 0,
+    "health_failures" bigint DEFAULT 0,
+    "trashed_at" timestamptz,
+    "purge_at" timestamptz,
+    "provisioning_spec" jsonb,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_instances" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_projects_instances" FOREIGN KEY ("project_id") REFERENCES "projects"("id")
+);
+CREATE INDEX "idx_instances_project_id" ON "instances" ("project_id");
+CREATE INDEX "idx_instances_deleted_at" ON "instances" ("deleted_at");
+CREATE INDEX "idx_instances_purge_at" ON "instances" ("purge_at");
+
+CREATE TABLE "resource_usages" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "timestamp" timestamptz,
+    "cpu_usage" decimal,
+    "memory_usage" bigint,
+    "memory_limit" bigint,
+    "memory_percentage" decimal,
+    "disk_usage" bigint,
+    "network_in" bigint,
+    "network_out" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_instances_resource_usage" FOREIGN KEY ("instance_id") REFERENCES "instances"("id")
+);
+CREATE INDEX "idx_resource_usages_deleted_at" ON "resource_usages" ("deleted_at");
+CREATE INDEX "idx_resource_usages_instance_id" ON "resource_usages" ("instance_id");
+
+CREATE TABLE "payments" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "amount" bigint,
+    "currency" varchar(3) DEFAULT 'usd',
+    "status" varchar(20),
+    "pay_pal_payment_id" text,
+    "pay_pal_order_id" text,
+    "provider" varchar(20),
+    "provider_checkout_id" text,
+    "provider_payment_id" text,
+    "invoice_url" text,
+    "description" text,
+    "metadata" jsonb,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_payments" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_payments_provider_checkout_id" ON "payments" ("provider_checkout_id");
+CREATE INDEX "idx_payments_user_id" ON "payments" ("user_id");
+
+CREATE TABLE "usage_rollups" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "day" date,
+    "instance_hours" decimal,
+    "cpu_hours" decimal,
+    "egress_bytes" bigint,
+    "ingress_bytes" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_usage_rollup_user_day" ON "usage_rollups" ("user_id","day");
+
+CREATE TABLE "api_keys" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "project_id" uuid,
+    "name" varchar(255),
+    "key_hash" varchar(64),
+    "key_prefix" varchar(16),
+    "signing_secret" varchar(80),
+    "require_signature" boolean DEFAULT false,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_api_keys_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_api_keys_deleted_at" ON "api_keys" ("deleted_at");
+CREATE UNIQUE INDEX "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+CREATE INDEX "idx_api_keys_project_id" ON "api_keys" ("project_id");
+CREATE INDEX "idx_api_keys_user_id" ON "api_keys" ("user_id");
+
+CREATE TABLE "branding_settings" (
+    "id" bigserial,
+    "product_name" varchar(255),
+    "domain" varchar(255),
+    "support_email" varchar(255),
+    "logo_url" varchar(1000),
+    "primary_color" varchar(7),
+    "secondary_color" varchar(7),
+    "accent_color" varchar(7),
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "instance_certificates" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "domain" varchar(255),
+    "status" varchar(20) DEFAULT 'pending',
+    "issuer" varchar(255),
+    "not_before" timestamptz,
+    "not_after" timestamptz,
+    "error" varchar(1000),
+    "checked_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_instance_certificates_instance" FOREIGN KEY ("instance_id") REFERENCES "instances"("id")
+);
+CREATE UNIQUE INDEX "idx_instance_certificates_instance_id" ON "instance_certificates" ("instance_id");
+
+CREATE TABLE "instance_events" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "user_id" uuid,
+    "type" varchar(50),
+    "level" varchar(20),
+    "message" varchar(1000),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_instance_events_created_at" ON "instance_events" ("created_at");
+CREATE INDEX "idx_instance_events_user_id" ON "instance_events" ("user_id");
+CREATE INDEX "idx_instance_events_instance_id" ON "instance_events" ("instance_id");
+
+CREATE TABLE "usage_records" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "period" date,
+    "cpu_seconds" decimal,
+    "memory_mb_hours" decimal,
+    "network_in_bytes" bigint,
+    "network_out_bytes" bigint,
+    "exported_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_usage_record_user_period" ON "usage_records" ("user_id","period");
+
+CREATE TABLE "probe_results" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "region" varchar(100),
+    "reachable" boolean,
+    "latency_ms" bigint,
+    "error" varchar(500),
+    "checked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_probe_results_instance_checked" ON "probe_results" ("instance_id","checked_at");
+
+CREATE TABLE "provisioning_jobs" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "job_id" uuid,
+    "user_id" uuid,
+    "idempotency_key" varchar(255),
+    "status" varchar(20),
+    "steps" jsonb,
+    "error" varchar(1000),
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_provisioning_jobs_idempotency" ON "provisioning_jobs" ("user_id","idempotency_key") WHERE idempotency_key <> '';
+CREATE UNIQUE INDEX "idx_provisioning_jobs_instance_id" ON "provisioning_jobs" ("instance_id");
+CREATE INDEX "idx_provisioning_jobs_status" ON "provisioning_jobs" ("status");
+
+CREATE TABLE "jobs" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "type" varchar(50),
+    "user_id" uuid,
+    "instance_id" uuid,
+    "payload" jsonb,
+    "status" varchar(20),
+    "run_at" timestamptz,
+    "attempts" bigint,
+    "max_attempts" bigint,
+    "last_error" varchar(1000),
+    "locked_at" timestamptz,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_jobs_status_run_at" ON "jobs" ("status","run_at");
+CREATE INDEX "idx_jobs_instance_id" ON "jobs" ("instance_id");
+CREATE INDEX "idx_jobs_user_id" ON "jobs" ("user_id");
+CREATE INDEX "idx_jobs_type" ON "jobs" ("type");
+
+CREATE TABLE "instance_credentials" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "username" varchar(255),
+    "encrypted_password" varchar(255),
+    "encrypted_webhook_secret" varchar(255),
+    "rotated_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_instance_credentials_instance_id" ON "instance_credentials" ("instance_id");
+
+CREATE TABLE "waitlist_entries" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "project_id" uuid,
+    "name" varchar(255),
+    "description" varchar(1000),
+    "image_tag" varchar(100),
+    "cpu_limit" decimal,
+    "memory_limit" bigint,
+    "storage_limit" bigint,
+    "status" varchar(20),
+    "reserved_until" timestamptz,
+    "instance_id" uuid,
+    "error" varchar(1000),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_waitlist_entries_user_id" ON "waitlist_entries" ("user_id");
+CREATE INDEX "idx_waitlist_status_created_at" ON "waitlist_entries" ("status","created_at");
+
+CREATE TABLE "host_agents" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "host" varchar(255),
+    "version" varchar(50),
+    "last_check_in_at" timestamptz,
+    "last_check_in_ip" varchar(45),
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_host_agents_host" ON "host_agents" ("host");
+
+CREATE TABLE "agent_tokens" (
+    "id" uuid,
+    "agent_id" uuid,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_agent_tokens_agent" FOREIGN KEY ("agent_id") REFERENCES "host_agents"("id")
+);
+CREATE INDEX "idx_agent_tokens_agent_id" ON "agent_tokens" ("agent_id");
+CREATE INDEX "idx_agent_tokens_expires_at" ON "agent_tokens" ("expires_at");
+
+CREATE TABLE "webhook_endpoints" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid,
+    "instance_id" uuid,
+    "url" varchar(2048),
+    "description" varchar(255),
+    "events" varchar(1000),
+    "secret" varchar(80),
+    "active" boolean DEFAULT true,
+    "last_delivery_at" timestamptz,
+    "last_delivery_status" bigint,
+    "last_delivery_error" varchar(1000),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_webhook_endpoints_deleted_at" ON "webhook_endpoints" ("deleted_at");
+CREATE INDEX "idx_webhook_endpoints_instance_id" ON "webhook_endpoints" ("instance_id");
+CREATE INDEX "idx_webhook_endpoints_user_id" ON "webhook_endpoints" ("user_id");
+
+CREATE TABLE "webhook_deliveries" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "endpoint_id" uuid,
+    "event_id" uuid,
+    "event_type" varchar(100),
+    "test" boolean,
+    "attempt" bigint,
+    "response_status" bigint,
+    "error" varchar(1000),
+    "duration_ms" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_webhook_deliveries_created_at" ON "webhook_deliveries" ("created_at");
+CREATE INDEX "idx_webhook_deliveries_endpoint_created" ON "webhook_deliveries" ("endpoint_id","created_at");
+
+CREATE TABLE "leases" (
+    "name" varchar(100),
+    "holder" varchar(255) NOT NULL,
+    "acquired_at" timestamptz,
+    "renewed_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("name")
+);
+CREATE INDEX "idx_leases_expires_at" ON "leases" ("expires_at");
+
+CREATE TABLE "request_nonces" (
+    "key" varchar(200),
+    "expires_at" timestamptz NOT NULL,
+    PRIMARY KEY ("key")
+);
+CREATE INDEX "idx_request_nonces_expires_at" ON "request_nonces" ("expires_at");
+
+CREATE TABLE "billing_entries" (
+    "id" uuid,
+    "sequence" bigint NOT NULL,
+    "transaction_id" uuid NOT NULL,
+    "reference" varchar(255) NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "user_id" uuid NOT NULL,
+    "payment_id" uuid,
+    "account" varchar(50) NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" varchar(8) NOT NULL,
+    "description" text,
+    "prev_hash" varchar(64),
+    "hash" varchar(64) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_billing_entries_payment_id" ON "billing_entries" ("payment_id");
+CREATE INDEX "idx_billing_entries_user_id" ON "billing_entries" ("user_id");
+CREATE INDEX "idx_billing_entries_reference" ON "billing_entries" ("reference");
+CREATE INDEX "idx_billing_entries_transaction_id" ON "billing_entries" ("transaction_id");
+CREATE UNIQUE INDEX "idx_billing_entries_sequence" ON "billing_entries" ("sequence");
+
+CREATE TABLE "webhook_events" (
+    "id" uuid,
+    "source" varchar(20) NOT NULL,
+    "delivery_id" varchar(255),
+    "type" varchar(100),
+    "status" varchar(20),
+    "payload" jsonb,
+    "attempts" bigint,
+    "error" varchar(1000),
+    "job_id" uuid,
+    "processed_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_webhook_events_delivery" ON "webhook_events" ("source","delivery_id") WHERE delivery_id <> '';
+CREATE INDEX "idx_webhook_events_status" ON "webhook_events" ("status");
+CREATE INDEX "idx_webhook_events_type" ON "webhook_events" ("type");
+
+CREATE TABLE "backups" (
+    "id" uuid,
+    "instance_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "trigger" varchar(20) NOT NULL,
+    "status" varchar(20) NOT NULL,
+    "target" varchar(20),
+    "key" varchar(255),
+    "size_bytes" bigint,
+    "error" varchar(1000),
+    "job_id" uuid,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_backups_status" ON "backups" ("status");
+CREATE INDEX "idx_backups_user_id" ON "backups" ("user_id");
+CREATE INDEX "idx_backups_instance_id" ON "backups" ("instance_id");
+
+CREATE TABLE "backup_schedules" (
+    "id" uuid,
+    "instance_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "schedule" varchar(100) NOT NULL,
+    "retention" bigint NOT NULL,
+    "enabled" boolean DEFAULT true,
+    "next_run_at" timestamptz,
+    "last_run_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_backup_schedules_next_run_at" ON "backup_schedules" ("next_run_at");
+CREATE INDEX "idx_backup_schedules_user_id" ON "backup_schedules" ("user_id");
+CREATE UNIQUE INDEX "idx_backup_schedules_instance_id" ON "backup_schedules" ("instance_id");
+
+CREATE TABLE "notifications" (
+    "id" uuid,
+    "user_id" uuid NOT NULL,
+    "instance_id" uuid,
+    "type" varchar(50) NOT NULL,
+    "level" varchar(20) NOT NULL,
+    "title" varchar(200),
+    "message" varchar(1000),
+    "read_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_notifications_instance_id" ON "notifications" ("instance_id");
+CREATE INDEX "idx_notifications_user_created" ON "notifications" ("user_id","created_at");
+
+CREATE TABLE "plans" (
+    "name" varchar(20),
+    "display_name" varchar(100),
+    "description" varchar(500),
+    "monthly_price" bigint,
+    "yearly_price" bigint,
+    "currency" varchar(3) DEFAULT 'usd',
+    "max_instances" bigint,
+    "cpu_limit" decimal,
+    "memory_limit" bigint,
+    "storage_limit" bigint,
+    "features" jsonb,
+    "public" boolean DEFAULT true,
+    "sort_order" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("name")
+);
+
+CREATE TABLE "docker_hosts" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" varchar(255),
+    "cpu_cores" bigint,
+    "memory_mb" bigint,
+    "cpu_overcommit" decimal,
+    "memory_overcommit" decimal,
+    "utilization_alert" decimal,
+    "cpu_utilization" decimal,
+    "memory_utilization" decimal,
+    "alerting_since" timestamptz,
+    "last_seen_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_docker_hosts_name" ON "docker_hosts" ("name");
+
+CREATE TABLE "operator_reports" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "period_start" timestamptz,
+    "period_end" timestamptz,
+    "data" jsonb,
+    "delivered_to" varchar(255),
+    "delivery_error" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_operator_reports_period_end" ON "operator_reports" ("period_end");
+
+CREATE TABLE "workflow_executions" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "workflow_id" varchar(100),
+    "execution_id" varchar(100),
+    "workflow_name" varchar(255),
+    "mode" varchar(50),
+    "status" varchar(20),
+    "error" varchar(1000),
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "duration_ms" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_workflow_executions_created_at" ON "workflow_executions" ("created_at");
+CREATE INDEX "idx_workflow_executions_instance_created" ON "workflow_executions" ("instance_id","created_at");
+CREATE UNIQUE INDEX "idx_workflow_executions_key" ON "workflow_executions" ("instance_id","workflow_id","execution_id");
+
+-- +goose Down
+DROP TABLE IF EXISTS "workflow_executions";
+DROP TABLE IF EXISTS "operator_reports";
+DROP TABLE IF EXISTS "docker_hosts";
+DROP TABLE IF EXISTS "plans";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "backup_schedules";
+DROP TABLE IF EXISTS "backups";
+DROP TABLE IF EXISTS "webhook_events";
+DROP TABLE IF EXISTS "billing_entries";
+DROP TABLE IF EXISTS "request_nonces";
+DROP TABLE IF EXISTS "leases";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_endpoints";
+DROP TABLE IF EXISTS "agent_tokens";
+DROP TABLE IF EXISTS "host_agents";
+DROP TABLE IF EXISTS "waitlist_entries";
+DROP TABLE IF EXISTS "instance_credentials";
+DROP TABLE IF EXISTS "jobs";
+DROP TABLE IF EXISTS "provisioning_jobs";
+DROP TABLE IF EXISTS "probe_results";
+DROP TABLE IF EXISTS "usage_records";
+DROP TABLE IF EXISTS "instance_events";
+DROP TABLE IF EXISTS "instance_certificates";
+DROP TABLE IF EXISTS "branding_settings";
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "usage_rollups";
+DROP TABLE IF EXISTS "payments";
+DROP TABLE IF EXISTS "resource_usages";
+DROP TABLE IF EXISTS "instances";
+DROP TABLE IF EXISTS "projects";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,7 @@
+-- Instances of plans that sleep when idle are stopped once their latest activity is old enough
+
+-- +goose Up
+ALTER TABLE "instances" ADD COLUMN IF NOT EXISTS "last_activity_at" timestamptz;
+
+-- +goose Down
+ALTER TABLE "instances" DROP COLUMN "last_activity_at";
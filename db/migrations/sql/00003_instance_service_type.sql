@@ -0,0 +1,10 @@
+-- Instances and waitlisted creation requests record the service template they run; every
+-- existing instance runs n8n
+
+-- +goose Up
+ALTER TABLE "instances" ADD COLUMN IF NOT EXISTS "service_type" varchar(50) NOT NULL DEFAULT 'n8n';
+ALTER TABLE "waitlist_entries" ADD COLUMN IF NOT EXISTS "service_type" varchar(50) NOT NULL DEFAULT 'n8n';
+
+-- +goose Down
+ALTER TABLE "waitlist_entries" DROP COLUMN "service_type";
+ALTER TABLE "instances" DROP COLUMN "service_type";
@@ -0,0 +1,19 @@
+-- Subdomains are unique among instances that are not deleted. Instances that were given the
+-- subdomain of an older instance were never reachable under it; they get a numbered suffix,
+-- and the reconciler adds the DNS records of their new subdomains.
+
+-- +goose Up
+UPDATE "instances" SET
+    "host" = "duplicates"."host" || '-' || "duplicates"."rank",
+    "url" = "duplicates"."host" || '-' || "duplicates"."rank" || substr("instances"."url", length("duplicates"."host") + 1)
+FROM (
+    SELECT "id", "host", ROW_NUMBER() OVER (PARTITION BY "host" ORDER BY "created_at", "id") AS "rank"
+    FROM "instances"
+    WHERE "deleted_at" IS NULL AND "host" <> ''
+) AS "duplicates"
+WHERE "instances"."id" = "duplicates"."id" AND "duplicates"."rank" > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_instances_host" ON "instances" ("host") WHERE deleted_at IS NULL AND host <> '';
+
+-- +goose Down
+DROP INDEX IF EXISTS "idx_instances_host";
@@ -0,0 +1,36 @@
+-- Every step of every provisioning attempt is recorded in provisioning_steps, while the steps
+-- column of provisioning_jobs keeps showing the latest attempt. Jobs that already ran count as
+-- one attempt, whose steps are copied over.
+
+-- +goose Up
+ALTER TABLE "provisioning_jobs" ADD COLUMN IF NOT EXISTS "attempts" bigint NOT NULL DEFAULT 0;
+UPDATE "provisioning_jobs" SET "attempts" = 1 WHERE "status" <> 'pending' AND "attempts" = 0;
+
+CREATE TABLE IF NOT EXISTS "provisioning_steps" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "provisioning_job_id" uuid,
+    "instance_id" uuid,
+    "attempt" bigint,
+    "name" varchar(50),
+    "status" varchar(20),
+    "error" varchar(1000),
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_provisioning_steps_attempt_step" ON "provisioning_steps" ("provisioning_job_id","attempt","name");
+CREATE INDEX IF NOT EXISTS "idx_provisioning_steps_instance_id" ON "provisioning_steps" ("instance_id");
+
+INSERT INTO "provisioning_steps" ("provisioning_job_id", "instance_id", "attempt", "name", "status", "error", "started_at", "finished_at", "created_at", "updated_at")
+SELECT "jobs"."id", "jobs"."instance_id", 1, "step"->>'name', "step"->>'status', COALESCE("step"->>'error', ''),
+    ("step"->>'started_at')::timestamptz, ("step"->>'finished_at')::timestamptz, "jobs"."created_at", "jobs"."updated_at"
+FROM "provisioning_jobs" AS "jobs",
+    jsonb_array_elements(CASE WHEN jsonb_typeof("jobs"."steps") = 'array' THEN "jobs"."steps" ELSE '[]' END) AS "step"
+WHERE "jobs"."attempts" > 0 AND "step"->>'status' <> 'pending'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS "provisioning_steps";
+ALTER TABLE "provisioning_jobs" DROP COLUMN "attempts";
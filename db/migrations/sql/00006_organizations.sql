@@ -0,0 +1,57 @@
+-- Organizations share instances among their members according to their roles. Instances and
+-- waitlist entries may belong to one; members join by accepting invitations to their email
+-- address, or follow their memberships of organizations mirrored from Clerk.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "organizations" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" varchar(255) NOT NULL,
+    "clerk_org_id" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_organizations_clerk_org_id" ON "organizations" ("clerk_org_id");
+CREATE INDEX IF NOT EXISTS "idx_organizations_deleted_at" ON "organizations" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "org_memberships" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "organization_id" uuid,
+    "user_id" uuid,
+    "role" varchar(20) NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_org_memberships_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id"),
+    CONSTRAINT "fk_org_memberships_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_org_memberships_org_user" ON "org_memberships" ("organization_id","user_id");
+CREATE INDEX IF NOT EXISTS "idx_org_memberships_user_id" ON "org_memberships" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "org_invitations" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "organization_id" uuid,
+    "email" varchar(255) NOT NULL,
+    "role" varchar(20) NOT NULL,
+    "invited_by_id" uuid,
+    "expires_at" timestamptz,
+    "accepted_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_org_invitations_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_org_invitations_organization_id" ON "org_invitations" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_org_invitations_email" ON "org_invitations" ("email");
+
+ALTER TABLE "instances" ADD COLUMN IF NOT EXISTS "organization_id" uuid;
+CREATE INDEX IF NOT EXISTS "idx_instances_organization_id" ON "instances" ("organization_id");
+ALTER TABLE "waitlist_entries" ADD COLUMN IF NOT EXISTS "organization_id" uuid;
+
+-- +goose Down
+ALTER TABLE "waitlist_entries" DROP COLUMN "organization_id";
+DROP INDEX IF EXISTS "idx_instances_organization_id";
+ALTER TABLE "instances" DROP COLUMN "organization_id";
+DROP TABLE IF EXISTS "org_invitations";
+DROP TABLE IF EXISTS "org_memberships";
+DROP TABLE IF EXISTS "organizations";
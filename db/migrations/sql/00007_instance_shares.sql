@@ -0,0 +1,20 @@
+-- Instance shares give other registered users read-only or operator access to an instance.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "instance_shares" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "user_id" uuid,
+    "access" varchar(20) NOT NULL,
+    "created_by_id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_instance_shares_instance" FOREIGN KEY ("instance_id") REFERENCES "instances"("id"),
+    CONSTRAINT "fk_instance_shares_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_instance_shares_instance_user" ON "instance_shares" ("instance_id","user_id");
+CREATE INDEX IF NOT EXISTS "idx_instance_shares_user_id" ON "instance_shares" ("user_id");
+
+-- +goose Down
+DROP TABLE IF EXISTS "instance_shares";
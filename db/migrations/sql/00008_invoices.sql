@@ -0,0 +1,25 @@
+-- Invoices of completed payments, numbered per year. They are kept for accounting when the
+-- payments of a purged account are deleted, so they have no foreign keys.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "invoices" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "number" varchar(20) NOT NULL,
+    "payment_id" uuid NOT NULL,
+    "user_id" uuid,
+    "amount" bigint,
+    "currency" varchar(3),
+    "description" text,
+    "billing_name" text,
+    "billing_email" text,
+    "provider" varchar(20),
+    "issued_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_number" ON "invoices" ("number");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invoices_payment_id" ON "invoices" ("payment_id");
+CREATE INDEX IF NOT EXISTS "idx_invoices_user_id" ON "invoices" ("user_id");
+
+-- +goose Down
+DROP TABLE IF EXISTS "invoices";
@@ -0,0 +1,7 @@
+-- Users are reminded that their trial ends a few days before it does
+
+-- +goose Up
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "trial_reminded_at" timestamptz;
+
+-- +goose Down
+ALTER TABLE "users" DROP COLUMN "trial_reminded_at";
@@ -0,0 +1,32 @@
+-- Admin terminal sessions in instance containers, kept as an audit trail of what was typed.
+-- They are kept after the instance is deleted, so they have no foreign keys.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "terminal_sessions" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "instance_id" uuid,
+    "admin_id" uuid,
+    "ticket_hash" varchar(64),
+    "command" text,
+    "reason" varchar(500),
+    "status" varchar(20),
+    "client_ip" varchar(45),
+    "transcript" text,
+    "transcript_truncated" boolean,
+    "input_bytes" bigint,
+    "output_bytes" bigint,
+    "exit_code" bigint,
+    "error" text,
+    "expires_at" timestamptz,
+    "started_at" timestamptz,
+    "ended_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_terminal_sessions_instance_id" ON "terminal_sessions" ("instance_id");
+CREATE INDEX IF NOT EXISTS "idx_terminal_sessions_admin_id" ON "terminal_sessions" ("admin_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_terminal_sessions_ticket_hash" ON "terminal_sessions" ("ticket_hash");
+CREATE INDEX IF NOT EXISTS "idx_terminal_sessions_created_at" ON "terminal_sessions" ("created_at");
+
+-- +goose Down
+DROP TABLE IF EXISTS "terminal_sessions";
@@ -0,0 +1,23 @@
+-- Images kept pulled on each Docker host, with the outcome of their last pre-pull.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "cached_images" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "host_name" varchar(255),
+    "image" varchar(255),
+    "status" varchar(20),
+    "image_id" text,
+    "digest" text,
+    "size_bytes" bigint,
+    "pull_duration" bigint,
+    "error" text,
+    "pulled_at" timestamptz,
+    "checked_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_cached_images_host_image" ON "cached_images" ("host_name", "image");
+
+-- +goose Down
+DROP TABLE IF EXISTS "cached_images";
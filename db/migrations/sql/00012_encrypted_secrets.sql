@@ -0,0 +1,11 @@
+-- API key signing secrets and webhook endpoint secrets are encrypted at rest, which makes
+-- them longer than the plaintext they replace
+
+-- +goose Up
+ALTER TABLE "api_keys" ALTER COLUMN "signing_secret" TYPE varchar(255);
+ALTER TABLE "webhook_endpoints" ALTER COLUMN "secret" TYPE varchar(255);
+
+-- +goose Down
+-- Fails while encrypted values are stored; they have to be decrypted first
+ALTER TABLE "webhook_endpoints" ALTER COLUMN "secret" TYPE varchar(80);
+ALTER TABLE "api_keys" ALTER COLUMN "signing_secret" TYPE varchar(80);
@@ -0,0 +1,13 @@
+-- The custom_domains and metrics_export features were seeded into the Pro plan but never gated
+-- anything, and are no longer known features
+
+-- +goose Up
+UPDATE "plans" SET "features" = (
+    SELECT COALESCE(jsonb_agg("feature"), '[]'::jsonb)
+    FROM jsonb_array_elements("features") AS "feature"
+    WHERE "feature" NOT IN ('"custom_domains"'::jsonb, '"metrics_export"'::jsonb)
+) WHERE "features" ?| ARRAY['custom_domains', 'metrics_export'];
+
+-- +goose Down
+-- The features cannot be told apart from plans that never had them, so they are not restored
+SELECT 1;
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// CreateNotification stores a notification for a user
+func CreateNotification(notification *models.Notification) error {
+	if err := DB.Create(notification).Error; err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// GetNotifications retrieves the newest notifications of a user, optionally only unread ones
+func GetNotifications(userID uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error) {
+	query := DB.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// CountUnreadNotifications counts the notifications a user has not read
+func CountUnreadNotifications(userID uuid.UUID) (int64, error) {
+	var count int64
+	if err := DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// GetNotification retrieves a notification of a user
+func GetNotification(id, userID uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	if err := DB.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkNotificationRead marks a notification as read, keeping the time it was first read
+func MarkNotificationRead(notification *models.Notification) error {
+	if notification.ReadAt != nil {
+		return nil
+	}
+	now := time.Now()
+	if err := DB.Model(notification).Update("read_at", now).Error; err != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+	notification.ReadAt = &now
+	return nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of a user as read
+func MarkAllNotificationsRead(userID uuid.UUID) (int64, error) {
+	result := DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
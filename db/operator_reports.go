@@ -0,0 +1,159 @@
+package db
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetDatabaseSize returns the disk space of the current database in bytes
+func GetDatabaseSize() (int64, error) {
+	var size int64
+	if SQLite() {
+		err := DB.Raw("SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&size).Error
+		return size, err
+	}
+	err := DB.Raw("SELECT pg_database_size(current_database())").Scan(&size).Error
+	return size, err
+}
+
+// GetTableSizes returns the size of every table of the public schema, largest first. The
+// size of a hypertable includes its chunks.
+func GetTableSizes() ([]models.TableSize, error) {
+	if SQLite() {
+		return getSQLiteTableSizes()
+	}
+	var tables []models.TableSize
+	err := DB.Raw(`
+		SELECT relname AS "table", pg_total_relation_size(relid) AS bytes, n_live_tup AS rows
+		FROM pg_stat_user_tables
+		WHERE schemaname = 'public'
+	`).Scan(&tables).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hypertables, err := hasTimescaleDB()
+	if err != nil {
+		return nil, err
+	}
+	if hypertables {
+		var sizes []struct {
+			Table string
+			Bytes int64
+		}
+		err := DB.Raw(`
+			SELECT hypertable_name AS "table",
+				hypertable_size(format('%I.%I', hypertable_schema, hypertable_name)::regclass) AS bytes
+			FROM timescaledb_information.hypertables
+			WHERE hypertable_schema = 'public'
+		`).Scan(&sizes).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, size := range sizes {
+			for i := range tables {
+				if tables[i].Table == size.Table {
+					tables[i].Bytes = size.Bytes
+				}
+			}
+		}
+	}
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Bytes > tables[j].Bytes })
+	return tables, nil
+}
+
+// getSQLiteTableSizes returns the tables of a SQLite database with their exact number of
+// rows; SQLite does not report the size of tables, so they are sorted by rows
+func getSQLiteTableSizes() ([]models.TableSize, error) {
+	var tables []models.TableSize
+	err := DB.Raw(`SELECT name AS "table" FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`).
+		Scan(&tables).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range tables {
+		if err := DB.Table(tables[i].Table).Count(&tables[i].Rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Rows > tables[j].Rows })
+	return tables, nil
+}
+
+// GetHypertableChunks counts the chunks of every hypertable, or returns none without TimescaleDB
+func GetHypertableChunks() ([]models.HypertableChunks, error) {
+	hypertables, err := hasTimescaleDB()
+	if err != nil || !hypertables {
+		return []models.HypertableChunks{}, err
+	}
+	chunks := []models.HypertableChunks{}
+	err = DB.Raw(`
+		SELECT h.hypertable_name AS hypertable,
+			COUNT(c.chunk_name) AS chunks,
+			COUNT(c.chunk_name) FILTER (WHERE c.is_compressed) AS compressed_chunks
+		FROM timescaledb_information.hypertables h
+		LEFT JOIN timescaledb_information.chunks c
+			ON c.hypertable_schema = h.hypertable_schema AND c.hypertable_name = h.hypertable_name
+		GROUP BY h.hypertable_name
+		ORDER BY chunks DESC
+	`).Scan(&chunks).Error
+	return chunks, err
+}
+
+// hasTimescaleDB checks if the TimescaleDB extension is installed
+func hasTimescaleDB() (bool, error) {
+	if SQLite() {
+		return false, nil
+	}
+	var extensions int64
+	err := DB.Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'timescaledb'").Scan(&extensions).Error
+	return extensions > 0, err
+}
+
+// CreateOperatorReport stores an operator report
+func CreateOperatorReport(report *models.OperatorReport) error {
+	return DB.Create(report).Error
+}
+
+// GetLatestOperatorReport returns the newest operator report, or nil if there is none
+func GetLatestOperatorReport() (*models.OperatorReport, error) {
+	var report models.OperatorReport
+	err := DB.Order("period_end DESC").First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetOperatorReports returns the newest operator reports, at most limit
+func GetOperatorReports(limit int) ([]models.OperatorReport, error) {
+	var reports []models.OperatorReport
+	err := DB.Order("period_end DESC").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+// GetOperatorReport returns an operator report by ID
+func GetOperatorReport(id uuid.UUID) (*models.OperatorReport, error) {
+	var report models.OperatorReport
+	if err := DB.First(&report, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SetOperatorReportDelivery records where an operator report was delivered and why delivery failed
+func SetOperatorReportDelivery(id uuid.UUID, deliveredTo, deliveryError string) error {
+	return DB.Model(&models.OperatorReport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"delivered_to":   deliveredTo,
+		"delivery_error": deliveryError,
+	}).Error
+}
@@ -0,0 +1,297 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrLastOwner is returned when a change would leave an organization without an owner
+	ErrLastOwner = errors.New("organization must keep at least one owner")
+	// ErrOrganizationHasInstances is returned when deleting an organization that still has instances
+	ErrOrganizationHasInstances = errors.New("organization still has instances")
+	// ErrInvitationNotPending is returned when accepting an invitation that was accepted or expired
+	ErrInvitationNotPending = errors.New("invitation was already accepted or has expired")
+)
+
+// CreateOrganization creates an organization with a user as its owner
+func CreateOrganization(org *models.Organization, ownerID uuid.UUID) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrgMembership{OrganizationID: org.ID, UserID: ownerID, Role: models.OrgRoleOwner}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+	return nil
+}
+
+// GetOrganizationByID retrieves an organization by ID
+func GetOrganizationByID(id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	if err := DB.Where("id = ?", id).First(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetOrganizationByClerkID retrieves the organization mirroring a Clerk organization
+func GetOrganizationByClerkID(clerkOrgID string) (*models.Organization, error) {
+	var org models.Organization
+	if err := DB.Where("clerk_org_id = ?", clerkOrgID).First(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// UpdateOrganization updates an existing organization
+func UpdateOrganization(org *models.Organization) error {
+	if err := DB.Save(org).Error; err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	return nil
+}
+
+// DeleteOrganization deletes an organization with its memberships and invitations. It returns
+// ErrOrganizationHasInstances while instances are shared with it, which have to be deleted or
+// moved out first.
+func DeleteOrganization(orgID uuid.UUID) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var instances int64
+		if err := tx.Model(&models.Instance{}).Where("organization_id = ?", orgID).Count(&instances).Error; err != nil {
+			return fmt.Errorf("failed to count organization instances: %w", err)
+		}
+		if instances > 0 {
+			return ErrOrganizationHasInstances
+		}
+		if err := tx.Where("organization_id = ?", orgID).Delete(&models.OrgInvitation{}).Error; err != nil {
+			return fmt.Errorf("failed to delete organization invitations: %w", err)
+		}
+		if err := tx.Where("organization_id = ?", orgID).Delete(&models.OrgMembership{}).Error; err != nil {
+			return fmt.Errorf("failed to delete organization memberships: %w", err)
+		}
+		if err := tx.Delete(&models.Organization{}, "id = ?", orgID).Error; err != nil {
+			return fmt.Errorf("failed to delete organization: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetMembershipsByUserID retrieves the memberships of a user with their organizations
+func GetMembershipsByUserID(userID uuid.UUID) ([]models.OrgMembership, error) {
+	var memberships []models.OrgMembership
+	err := DB.Joins("Organization").Where("org_memberships.user_id = ?", userID).Order("org_memberships.created_at").Find(&memberships).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memberships: %w", err)
+	}
+	return memberships, nil
+}
+
+// GetOrgMembership retrieves the membership of a user in an organization, returning
+// gorm.ErrRecordNotFound if they are not a member
+func GetOrgMembership(orgID, userID uuid.UUID) (*models.OrgMembership, error) {
+	var membership models.OrgMembership
+	if err := DB.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership).Error; err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// GetOrgMembers retrieves the memberships of an organization with their users
+func GetOrgMembers(orgID uuid.UUID) ([]models.OrgMembership, error) {
+	var members []models.OrgMembership
+	if err := DB.Preload("User").Where("organization_id = ?", orgID).Order("created_at").Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to get organization members: %w", err)
+	}
+	return members, nil
+}
+
+// SaveOrgMembership adds a user to an organization, or changes their role if they are a
+// member. It returns ErrLastOwner if that would demote the last owner.
+func SaveOrgMembership(membership *models.OrgMembership) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if membership.Role != models.OrgRoleOwner {
+			if err := checkNotLastOwner(tx, membership.OrganizationID, membership.UserID); err != nil {
+				return err
+			}
+		}
+		if membership.ID != uuid.Nil {
+			return tx.Save(membership).Error
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+		}).Create(membership).Error
+	})
+}
+
+// RemoveOrgMember removes a user from an organization. It returns ErrLastOwner for its last
+// owner.
+func RemoveOrgMember(orgID, userID uuid.UUID) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkNotLastOwner(tx, orgID, userID); err != nil {
+			return err
+		}
+		return tx.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrgMembership{}).Error
+	})
+}
+
+// DeleteOrgMembership removes a user from an organization without checking for its last owner,
+// for organizations whose members are managed in Clerk
+func DeleteOrgMembership(orgID, userID uuid.UUID) error {
+	if err := DB.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrgMembership{}).Error; err != nil {
+		return fmt.Errorf("failed to delete organization membership: %w", err)
+	}
+	return nil
+}
+
+// checkNotLastOwner returns ErrLastOwner if a user is the only owner of an organization
+func checkNotLastOwner(tx *gorm.DB, orgID, userID uuid.UUID) error {
+	var others int64
+	err := tx.Model(&models.OrgMembership{}).
+		Where("organization_id = ? AND role = ? AND user_id <> ?", orgID, models.OrgRoleOwner, userID).
+		Count(&others).Error
+	if err != nil {
+		return fmt.Errorf("failed to count organization owners: %w", err)
+	}
+	if others > 0 {
+		return nil
+	}
+	var owner int64
+	err = tx.Model(&models.OrgMembership{}).
+		Where("organization_id = ? AND role = ? AND user_id = ?", orgID, models.OrgRoleOwner, userID).
+		Count(&owner).Error
+	if err != nil {
+		return fmt.Errorf("failed to count organization owners: %w", err)
+	}
+	if owner > 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// CreateOrgInvitation records an invitation to an organization
+func CreateOrgInvitation(invitation *models.OrgInvitation) error {
+	if err := DB.Create(invitation).Error; err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+// GetOrgInvitationByID retrieves an invitation with its organization
+func GetOrgInvitationByID(id uuid.UUID) (*models.OrgInvitation, error) {
+	var invitation models.OrgInvitation
+	if err := DB.Joins("Organization").Where("org_invitations.id = ?", id).First(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetPendingOrgInvitations retrieves the invitations to an organization that can still be accepted
+func GetPendingOrgInvitations(orgID uuid.UUID, now time.Time) ([]models.OrgInvitation, error) {
+	var invitations []models.OrgInvitation
+	err := DB.Joins("Organization").
+		Where("org_invitations.organization_id = ? AND org_invitations.accepted_at IS NULL AND org_invitations.expires_at > ?", orgID, now).
+		Order("org_invitations.created_at").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// GetPendingOrgInvitationsByEmail retrieves the invitations of an email address that can
+// still be accepted
+func GetPendingOrgInvitationsByEmail(email string, now time.Time) ([]models.OrgInvitation, error) {
+	var invitations []models.OrgInvitation
+	err := DB.Joins("Organization").
+		Where("org_invitations.email = ? AND org_invitations.accepted_at IS NULL AND org_invitations.expires_at > ?", models.NormalizeEmail(email), now).
+		Order("org_invitations.created_at").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// FindUserByEmail finds the user signed in with an email address, whatever its case
+func FindUserByEmail(email string) (models.User, error) {
+	var user models.User
+	err := DB.Where("LOWER(email) = ?", models.NormalizeEmail(email)).First(&user).Error
+	return user, err
+}
+
+// DeleteOrgInvitation revokes an invitation
+func DeleteOrgInvitation(id uuid.UUID) error {
+	if err := DB.Delete(&models.OrgInvitation{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+	return nil
+}
+
+// AcceptOrgInvitation makes a user a member of the organization an invitation is for. Members
+// keep their role if it allows more than the invitation's. It returns ErrInvitationNotPending
+// if the invitation was accepted in the meantime or has expired.
+func AcceptOrgInvitation(invitation *models.OrgInvitation, userID uuid.UUID) (*models.OrgMembership, error) {
+	now := time.Now()
+	membership := &models.OrgMembership{OrganizationID: invitation.OrganizationID, UserID: userID, Role: invitation.Role}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.OrgInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND expires_at > ?", invitation.ID, now).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationNotPending
+		}
+
+		var existing models.OrgMembership
+		err := tx.Where("organization_id = ? AND user_id = ?", invitation.OrganizationID, userID).First(&existing).Error
+		if err == nil {
+			if existing.Role.AtLeast(invitation.Role) {
+				*membership = existing
+				return nil
+			}
+			existing.Role = invitation.Role
+			*membership = existing
+			return tx.Save(membership).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return tx.Create(membership).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	invitation.AcceptedAt = &now
+	return membership, nil
+}
+
+// SetInstanceOrganization shares an instance with an organization, or stops sharing it when
+// orgID is nil
+func SetInstanceOrganization(instanceID uuid.UUID, orgID *uuid.UUID) error {
+	if err := DB.Model(&models.Instance{}).Where("id = ?", instanceID).Update("organization_id", orgID).Error; err != nil {
+		return fmt.Errorf("failed to update instance organization: %w", err)
+	}
+	return nil
+}
+
+// DetachOrganizationInstances stops sharing all instances of an organization, leaving them to
+// the users who created them
+func DetachOrganizationInstances(orgID uuid.UUID) error {
+	err := DB.Model(&models.Instance{}).Where("organization_id = ?", orgID).Update("organization_id", nil).Error
+	if err != nil {
+		return fmt.Errorf("failed to detach organization instances: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListOptions pages, filters and sorts a listing. Columns are trusted: callers must only
+// pass the columns a listing allows, never user input as is.
+type ListOptions struct {
+	Limit   int
+	Offset  int
+	Sort    string            // column to sort by; rows with equal values are ordered by id
+	Desc    bool              // sort in descending order
+	Filters map[string]string // column to value the rows must have
+	Search  string            // case-insensitive substring one of the search columns must contain
+}
+
+// ListVersion identifies the state of the rows matching a listing's filters. It changes
+// whenever one of them is created, updated or deleted.
+type ListVersion struct {
+	Count     int64
+	UpdatedAt *time.Time // latest update of a matching row; nil without any
+}
+
+// String formats the version, e.g. for ETags
+func (v ListVersion) String() string {
+	if v.UpdatedAt == nil {
+		return fmt.Sprintf("%d", v.Count)
+	}
+	return fmt.Sprintf("%d@%d", v.Count, v.UpdatedAt.UnixNano())
+}
+
+// Paginate applies the filters of a listing to the query, counts the matching rows and loads
+// the requested page of them into dest, returning the total number of matching rows
+func Paginate(query *gorm.DB, opts ListOptions, searchColumns []string, dest interface{}) (int64, error) {
+	query = filterList(query, opts, searchColumns)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	if opts.Sort != "" {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: opts.Sort}, Desc: opts.Desc})
+	}
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: opts.Desc})
+	if err := query.Limit(opts.Limit).Offset(opts.Offset).Find(dest).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetListVersion returns the version of the rows matching the filters of a listing, whose
+// table must have an updated_at column, without loading them
+func GetListVersion(query *gorm.DB, opts ListOptions, searchColumns []string) (ListVersion, error) {
+	return scanListVersion(filterList(query, opts, searchColumns).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at"))
+}
+
+// scanListVersion runs a query selecting the count and updated_at of a ListVersion
+func scanListVersion(query *gorm.DB) (ListVersion, error) {
+	var version ListVersion
+	var updatedAt queryTime
+	if err := query.Row().Scan(&version.Count, &updatedAt); err != nil {
+		return version, err
+	}
+	if !updatedAt.IsZero() {
+		version.UpdatedAt = &updatedAt.Time
+	}
+	return version, nil
+}
+
+// filterList applies the filters and the search of a listing to the query
+func filterList(query *gorm.DB, opts ListOptions, searchColumns []string) *gorm.DB {
+	for column, value := range opts.Filters {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+	}
+	if opts.Search != "" && len(searchColumns) > 0 {
+		pattern := "%" + escapeLike(opts.Search) + "%"
+		// LIKE ignores the case of ASCII letters on SQLite, which has no ILIKE and no
+		// default escape character
+		match := "%s ILIKE ?"
+		if SQLite() {
+			match = `%s LIKE ? ESCAPE '\'`
+		}
+		conditions := make([]string, len(searchColumns))
+		values := make([]interface{}, len(searchColumns))
+		for i, column := range searchColumns {
+			conditions[i] = fmt.Sprintf(match, column)
+			values[i] = pattern
+		}
+		query = query.Where(strings.Join(conditions, " OR "), values...)
+	}
+	return query
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so they match themselves
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// ListPaymentsByUserID retrieves a page of a user's payments and the number of matching ones,
+// searching their description
+func ListPaymentsByUserID(userID uuid.UUID, opts ListOptions) ([]models.Payment, int64, error) {
+	var payments []models.Payment
+	query := DB.Model(&models.Payment{}).Where("user_id = ?", userID)
+	total, err := Paginate(query, opts, []string{"description"}, &payments)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list payments: %w", err)
+	}
+	return payments, total, nil
+}
+
+// GetPaymentByID retrieves a payment of a user
+func GetPaymentByID(userID, paymentID uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	if err := DB.Where("id = ? AND user_id = ?", paymentID, userID).First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
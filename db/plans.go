@@ -0,0 +1,100 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPlanExists is returned when creating a plan whose name is taken
+	ErrPlanExists = errors.New("plan already exists")
+	// ErrPlanInUse is returned when deleting a plan that users are still on
+	ErrPlanInUse = errors.New("plan has users")
+)
+
+// LoadPlanCatalog loads the plans into memory, seeding an empty plans table with the default
+// catalog first
+func LoadPlanCatalog() error {
+	var count int64
+	if err := DB.Model(&models.Plan{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count plans: %w", err)
+	}
+	if count == 0 {
+		// Another replica may be seeding at the same time
+		defaults := models.DefaultPlans()
+		if err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaults).Error; err != nil {
+			return fmt.Errorf("failed to seed plans: %w", err)
+		}
+	}
+
+	plans, err := GetPlans()
+	if err != nil {
+		return err
+	}
+	models.SetPlanCatalog(plans)
+	return nil
+}
+
+// GetPlans retrieves every plan of the catalog
+func GetPlans() ([]models.Plan, error) {
+	var plans []models.Plan
+	if err := DB.Order("sort_order ASC, name ASC").Find(&plans).Error; err != nil {
+		return nil, fmt.Errorf("failed to get plans: %w", err)
+	}
+	return plans, nil
+}
+
+// GetPlan retrieves a plan by name
+func GetPlan(name models.SubscriptionPlan) (*models.Plan, error) {
+	var plan models.Plan
+	if err := DB.Where("name = ?", name).First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// CreatePlan adds a plan to the catalog, failing with ErrPlanExists if the name is taken
+func CreatePlan(plan *models.Plan) error {
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(plan)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create plan: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPlanExists
+	}
+	return nil
+}
+
+// UpdatePlan stores the changes to a plan
+func UpdatePlan(plan *models.Plan) error {
+	if err := DB.Save(plan).Error; err != nil {
+		return fmt.Errorf("failed to update plan: %w", err)
+	}
+	return nil
+}
+
+// DeletePlan removes a plan from the catalog. Plans that users are on are kept and
+// ErrPlanInUse is returned.
+func DeletePlan(name models.SubscriptionPlan) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var users int64
+		if err := tx.Model(&models.User{}).Where("plan = ?", name).Count(&users).Error; err != nil {
+			return fmt.Errorf("failed to count users on plan: %w", err)
+		}
+		if users > 0 {
+			return ErrPlanInUse
+		}
+		result := tx.Where("name = ?", name).Delete(&models.Plan{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete plan: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
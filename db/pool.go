@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// pingTimeout bounds the pings of health checks, so a saturated pool or an unreachable
+	// server reports an error instead of blocking them
+	pingTimeout = 3 * time.Second
+
+	// minRetryDelay and maxRetryDelay bound the backoff between connection attempts
+	minRetryDelay = time.Second
+	maxRetryDelay = 30 * time.Second
+)
+
+// PoolStats describes the connection pool of the database
+type PoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMs     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// openWithRetry connects to the database, retrying with exponential backoff for up to the
+// configured connect timeout, as the database often starts after the backend in deployments
+func openWithRetry(cfg *config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	deadline := time.Now().Add(cfg.Database.ConnectTimeout)
+	delay := minRetryDelay
+	for attempt := 1; ; attempt++ {
+		conn, err := gorm.Open(openDialector(cfg), gormConfig)
+		if err == nil {
+			return conn, nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return nil, fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+		getLogger().WithError(err).WithField("attempt", attempt).Warnf("Failed to connect to database, retrying in %s", delay)
+		time.Sleep(delay)
+		delay = nextRetryDelay(delay)
+	}
+}
+
+// configurePool applies the connection pool settings of the configuration
+func configurePool(cfg *config.Config) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+	return nil
+}
+
+// Ping checks that the database answers, returning how long it took
+func Ping(ctx context.Context) (time.Duration, error) {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	start := time.Now()
+	err = sqlDB.PingContext(ctx)
+	return time.Since(start), err
+}
+
+// GetPoolStats returns the current state of the connection pool
+func GetPoolStats() (PoolStats, error) {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return PoolStats{}, err
+	}
+	stats := sqlDB.Stats()
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
+
+// ConnectionMonitor pings the database on an interval. When a ping fails, for instance
+// because the server restarted or failed over, it drops the idle connections, which are
+// likely broken, so requests get fresh ones, and pings again with backoff until the database
+// answers.
+type ConnectionMonitor struct {
+	interval     time.Duration
+	maxIdleConns int
+	logger       *logrus.Logger
+}
+
+// NewConnectionMonitor creates a new connection monitor
+func NewConnectionMonitor(cfg *config.Config, logger *logrus.Logger) *ConnectionMonitor {
+	return &ConnectionMonitor{
+		interval:     cfg.Database.HealthCheckInterval,
+		maxIdleConns: cfg.Database.MaxIdleConns,
+		logger:       logger,
+	}
+}
+
+// Start monitors the connection until the context is cancelled
+func (m *ConnectionMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := Ping(ctx); err != nil && ctx.Err() == nil {
+				m.reconnect(ctx, err)
+			}
+		}
+	}
+}
+
+// reconnect drops the idle connections and pings with backoff until the database answers or
+// the context is cancelled
+func (m *ConnectionMonitor) reconnect(ctx context.Context, err error) {
+	m.logger.WithError(err).Error("Lost the database connection, reconnecting")
+
+	sqlDB, dbErr := DB.DB()
+	if dbErr != nil {
+		return
+	}
+	delay := minRetryDelay
+	for {
+		// Closes the idle connections; new ones are opened on demand. An in-memory SQLite
+		// database would be dropped with them.
+		if !SQLite() {
+			sqlDB.SetMaxIdleConns(0)
+			sqlDB.SetMaxIdleConns(m.maxIdleConns)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if _, err := Ping(ctx); err != nil {
+			delay = nextRetryDelay(delay)
+			m.logger.WithError(err).Warnf("Database is still unreachable, retrying in %s", delay)
+			continue
+		}
+		m.logger.Info("Database connection restored")
+		return
+	}
+}
+
+// nextRetryDelay doubles a backoff delay up to maxRetryDelay
+func nextRetryDelay(delay time.Duration) time.Duration {
+	if delay *= 2; delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// CreateProbeResults stores the results of one probing round
+func CreateProbeResults(results []models.ProbeResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	if err := DB.Create(&results).Error; err != nil {
+		return fmt.Errorf("failed to create probe results: %w", err)
+	}
+	return nil
+}
+
+// GetProbeResults retrieves the probe results of an instance since the given time, oldest first
+func GetProbeResults(instanceID uuid.UUID, since time.Time) ([]models.ProbeResult, error) {
+	var results []models.ProbeResult
+	if err := DB.Where("instance_id = ? AND checked_at >= ?", instanceID, since).Order("checked_at ASC").Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get probe results: %w", err)
+	}
+	return results, nil
+}
+
+// DeleteProbeResultsBefore removes probe results older than the given time
+func DeleteProbeResultsBefore(before time.Time) (int64, error) {
+	result := DB.Where("checked_at < ?", before).Delete(&models.ProbeResult{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete probe results: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ProbeCounts counts the probes of an instance and how many reached it
+type ProbeCounts struct {
+	InstanceID uuid.UUID
+	Checks     int64
+	Reachable  int64
+}
+
+// GetProbeCounts counts the probe results of instances since the given time, by instance
+func GetProbeCounts(instanceIDs []uuid.UUID, since time.Time) (map[uuid.UUID]ProbeCounts, error) {
+	counts := map[uuid.UUID]ProbeCounts{}
+	if len(instanceIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []ProbeCounts
+	err := DB.Model(&models.ProbeResult{}).
+		Select("instance_id, COUNT(*) AS checks, SUM(CASE WHEN reachable THEN 1 ELSE 0 END) AS reachable").
+		Where("instance_id IN ? AND checked_at >= ?", instanceIDs, since).
+		Group("instance_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count probe results: %w", err)
+	}
+	for _, row := range rows {
+		counts[row.InstanceID] = row
+	}
+	return counts, nil
+}
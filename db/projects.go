@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// GetProjectsByUserID retrieves all projects for a user
+func GetProjectsByUserID(userID uuid.UUID) ([]models.Project, error) {
+	var projects []models.Project
+	if err := DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+	return projects, nil
+}
+
+// GetProjectByID retrieves a project by ID
+func GetProjectByID(projectID uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	if err := DB.Where("id = ?", projectID).First(&project).Error; err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return &project, nil
+}
+
+// CreateProject creates a new project
+func CreateProject(project *models.Project) error {
+	logger := getLogger()
+	if err := DB.Create(project).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"user_id": project.UserID,
+			"error":   err.Error(),
+		}).Error("Failed to create project in database")
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+	
+	logger.WithFields(logrus.Fields{
+		"project_id": project.ID,
+		"user_id":    project.UserID,
+		"name":       project.Name,
+	}).Info("Successfully created project in database")
+	return nil
+}
+
+// UpdateProject updates an existing project
+func UpdateProject(project *models.Project) error {
+	if err := DB.Save(project).Error; err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+	return nil
+}
+
+// DeleteProject deletes a project, detaching its instances and revoking its API keys
+func DeleteProject(projectID uuid.UUID) error {
+	logger := getLogger()
+	
+	tx := DB.Begin()
+	if err := tx.Model(&models.Instance{}).Where("project_id = ?", projectID).Update("project_id", nil).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to detach project instances: %w", err)
+	}
+	if err := tx.Where("project_id = ?", projectID).Delete(&models.APIKey{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to revoke project API keys: %w", err)
+	}
+	if err := tx.Delete(&models.Project{}, projectID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	
+	logger.WithField("project_id", projectID).Info("Successfully deleted project from database")
+	return nil
+}
+
+// GetInstancesByProjectID retrieves all instances in a project
+func GetInstancesByProjectID(projectID uuid.UUID) ([]models.Instance, error) {
+	var instances []models.Instance
+	if err := DB.Where("project_id = ?", projectID).Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("failed to get project instances: %w", err)
+	}
+	return instances, nil
+}
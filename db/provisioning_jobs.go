@@ -0,0 +1,128 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrProvisioningNotFailed is returned when retrying a provisioning job that has not failed
+var ErrProvisioningNotFailed = errors.New("provisioning has not failed")
+
+// CreateInstanceWithProvisioningJob saves a new instance together with its provisioning
+// record and the queue job that provisions it
+func CreateInstanceWithProvisioningJob(instance *models.Instance, provisioning *models.ProvisioningJob, job *models.Job) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := createInstanceRecord(tx, instance); err != nil {
+			return err
+		}
+		if err := queueInstanceWebhooks(tx, instance, models.WebhookInstanceCreated, ""); err != nil {
+			return err
+		}
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		provisioning.InstanceID = instance.ID
+		provisioning.JobID = &job.ID
+		return tx.Create(provisioning).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create instance with provisioning job: %w", err)
+	}
+	return nil
+}
+
+// GetProvisioningJobByInstanceID retrieves the provisioning job of an instance
+func GetProvisioningJobByInstanceID(instanceID uuid.UUID) (*models.ProvisioningJob, error) {
+	var job models.ProvisioningJob
+	if err := DB.Where("instance_id = ?", instanceID).First(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to get provisioning job: %w", err)
+	}
+	return &job, nil
+}
+
+// GetProvisioningJobByIdempotencyKey retrieves the job a user created with an idempotency key,
+// returning nil if there is none
+func GetProvisioningJobByIdempotencyKey(userID uuid.UUID, key string) (*models.ProvisioningJob, error) {
+	var job models.ProvisioningJob
+	err := DB.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provisioning job: %w", err)
+	}
+	return &job, nil
+}
+
+// UpdateProvisioningJob saves the progress of a provisioning job
+func UpdateProvisioningJob(job *models.ProvisioningJob) error {
+	if err := DB.Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update provisioning job: %w", err)
+	}
+	return nil
+}
+
+// SaveProvisioningStep saves the progress of a provisioning job together with the record of
+// the step that changed, so both always show the same state of the step
+func SaveProvisioningStep(job *models.ProvisioningJob, record *models.ProvisioningStepRecord) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(job).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "provisioning_job_id"}, {Name: "attempt"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"status", "error", "started_at", "finished_at", "updated_at"}),
+		}).Create(record).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save provisioning step: %w", err)
+	}
+	return nil
+}
+
+// GetProvisioningStepRecords retrieves the steps of every attempt of a provisioning job, by
+// attempt and in the order they ran
+func GetProvisioningStepRecords(jobID uuid.UUID) ([]models.ProvisioningStepRecord, error) {
+	var records []models.ProvisioningStepRecord
+	if err := DB.Where("provisioning_job_id = ?", jobID).Order("attempt, created_at, id").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to get provisioning steps: %w", err)
+	}
+	return records, nil
+}
+
+// RetryProvisioningJob saves a failed instance and its provisioning job after they were put
+// back to pending, together with the queue job that provisions the instance again
+func RetryProvisioningJob(instance *models.Instance, previous models.InstanceStatus, provisioning *models.ProvisioningJob, job *models.Job) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Only one of concurrent retries may queue a job
+		result := tx.Model(&models.ProvisioningJob{}).
+			Where("id = ? AND status = ?", provisioning.ID, models.ProvisioningFailed).
+			Update("status", provisioning.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrProvisioningNotFailed
+		}
+		if err := tx.Save(instance).Error; err != nil {
+			return err
+		}
+		if err := queueInstanceWebhooks(tx, instance, models.InstanceStatusEvent(instance.Status), previous); err != nil {
+			return err
+		}
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		provisioning.JobID = &job.ID
+		return tx.Save(provisioning).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retry provisioning job: %w", err)
+	}
+	return nil
+}
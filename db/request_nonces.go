@@ -0,0 +1,44 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// UseRequestNonce records a signed request nonce and reports whether it was unused. Nonces
+// are remembered for ttlSeconds; an expired nonce may be used again.
+func UseRequestNonce(key string, ttlSeconds float64) (bool, error) {
+	query := `
+		INSERT INTO request_nonces (key, expires_at)
+		VALUES (?, NOW() + make_interval(secs => ?))
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE request_nonces.expires_at <= NOW()`
+	args := []interface{}{key, ttlSeconds}
+	if SQLite() {
+		now := time.Now()
+		query = `
+		INSERT INTO request_nonces (key, expires_at)
+		VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE request_nonces.expires_at <= ?`
+		args = []interface{}{key, now.Add(time.Duration(ttlSeconds * float64(time.Second))), now}
+	}
+	result := DB.Exec(query, args...)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record request nonce: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteExpiredRequestNonces removes nonces whose replay window has passed
+func DeleteExpiredRequestNonces() (int64, error) {
+	query, args := "DELETE FROM request_nonces WHERE expires_at <= NOW()", []interface{}{}
+	if SQLite() {
+		query, args = "DELETE FROM request_nonces WHERE expires_at <= ?", []interface{}{time.Now()}
+	}
+	result := DB.Exec(query, args...)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired request nonces: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
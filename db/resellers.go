@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// GetSubAccounts retrieves all sub-accounts managed by a reseller
+func GetSubAccounts(resellerID uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	if err := DB.Where("reseller_id = ?", resellerID).Order("created_at ASC").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sub-accounts: %w", err)
+	}
+	return users, nil
+}
+
+// GetSubAccount retrieves a sub-account, only if it is managed by the given reseller
+func GetSubAccount(resellerID, userID uuid.UUID) (models.User, error) {
+	var user models.User
+	if err := DB.Where("id = ? AND reseller_id = ?", userID, resellerID).First(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// SumAllocatedQuota returns the instance quota a reseller has handed out to sub-accounts,
+// ignoring the given sub-account so its allocation can be resized
+func SumAllocatedQuota(resellerID, excludeUserID uuid.UUID) (int, error) {
+	var total int
+	err := DB.Model(&models.User{}).
+		Where("reseller_id = ? AND id <> ?", resellerID, excludeUserID).
+		Select("COALESCE(SUM(instance_quota), 0)").
+		Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum allocated quota: %w", err)
+	}
+	return total, nil
+}
+
+// GetInstancesByUserIDs retrieves all instances owned by any of the given users
+func GetInstancesByUserIDs(userIDs []uuid.UUID) ([]models.Instance, error) {
+	var instances []models.Instance
+	if len(userIDs) == 0 {
+		return instances, nil
+	}
+	if err := DB.Where("user_id IN ?", userIDs).Order("created_at DESC").Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("failed to get instances: %w", err)
+	}
+	return instances, nil
+}
+
+// FindPendingUserByEmail finds a reseller-created account that is waiting for its first sign-in
+func FindPendingUserByEmail(email string) (models.User, error) {
+	var user models.User
+	err := DB.Where("email = ? AND clerk_user_id LIKE ?", email, models.PendingClerkIDPrefix+"%").First(&user).Error
+	return user, err
+}
+
+// DeleteSubAccount deletes a sub-account
+func DeleteSubAccount(userID uuid.UUID) error {
+	if err := DB.Delete(&models.User{}, "id = ?", userID).Error; err != nil {
+		return fmt.Errorf("failed to delete sub-account: %w", err)
+	}
+	InvalidateCachedUserByID(userID)
+	return nil
+}
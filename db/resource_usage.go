@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,14 @@ func CreateResourceUsage(usage *models.ResourceUsage) error {
 	return result.Error
 }
 
+// CreateResourceUsages saves resource usage records in batched inserts
+func CreateResourceUsages(usages []models.ResourceUsage) error {
+	if len(usages) == 0 {
+		return nil
+	}
+	return DB.CreateInBatches(usages, 500).Error
+}
+
 // GetResourceUsageByInstanceID retrieves resource usage records for an instance
 func GetResourceUsageByInstanceID(instanceID uuid.UUID, limit int) ([]models.ResourceUsage, error) {
 	var usages []models.ResourceUsage
@@ -30,6 +39,19 @@ func GetResourceUsageByInstanceID(instanceID uuid.UUID, limit int) ([]models.Res
 	return usages, result.Error
 }
 
+// GetResourceUsageVersion returns the number of resource usage samples of an instance taken
+// since the given time and the time of the latest one, which change whenever the historical
+// stats of the period do
+func GetResourceUsageVersion(instanceID uuid.UUID, since time.Time) (ListVersion, error) {
+	version, err := scanListVersion(DB.Model(&models.ResourceUsage{}).
+		Select("COUNT(*) AS count, MAX(timestamp) AS updated_at").
+		Where("instance_id = ? AND timestamp >= ?", instanceID, since))
+	if err != nil {
+		return version, fmt.Errorf("failed to get resource usage version: %w", err)
+	}
+	return version, nil
+}
+
 // GetResourceUsageHistorical retrieves historical resource usage with TimescaleDB
 func GetResourceUsageHistorical(instanceID uuid.UUID, period time.Duration, resolution string) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
@@ -68,27 +90,32 @@ func GetResourceUsageHistorical(instanceID uuid.UUID, period time.Duration, reso
 		}
 	}
 	
-	// Use time_bucket for proper time-series visualization with even intervals
-	query := `
+	// Use time_bucket for proper time-series visualization with even intervals; SQLite
+	// buckets Unix times instead
+	bucket, args := "time_bucket(?::interval, timestamp)", []interface{}{timeBucket}
+	if SQLite() {
+		bucket, args = fmt.Sprintf("(CAST(strftime('%%s', timestamp) AS INTEGER) / %[1]d) * %[1]d", sqliteBuckets[timeBucket]), nil
+	}
+	query := fmt.Sprintf(`
 		SELECT 
-			time_bucket($1, timestamp) AS time,
+			%s AS time,
 			AVG(cpu_usage) AS cpu_avg,
 			MAX(cpu_usage) AS cpu_max,
-			AVG(memory_usage) AS memory_avg,
+			CAST(AVG(memory_usage) AS BIGINT) AS memory_avg,
 			MAX(memory_usage) AS memory_max,
-			AVG(memory_usage * 100.0 / NULLIF(memory_limit, 0)) AS memory_percentage_avg,
+			COALESCE(AVG(memory_usage * 100.0 / NULLIF(memory_limit, 0)), 0) AS memory_percentage_avg,
 			SUM(network_in) AS network_in_total,
 			SUM(network_out) AS network_out_total,
 			COUNT(*) AS sample_count
-		FROM resource_usage
-		WHERE instance_id = $2 AND timestamp BETWEEN $3 AND $4
+		FROM resource_usages
+		WHERE instance_id = ? AND timestamp BETWEEN ? AND ? AND deleted_at IS NULL
 		GROUP BY time
 		ORDER BY time DESC
 		LIMIT 100
-	`
+	`, bucket)
 	
 	// Execute the query
-	rows, err := DB.Raw(query, timeBucket, instanceID, startTime, endTime).Rows()
+	rows, err := DB.Raw(query, append(args, instanceID, startTime, endTime)...).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +124,7 @@ func GetResourceUsageHistorical(instanceID uuid.UUID, period time.Duration, reso
 	// Process the results
 	for rows.Next() {
 		var (
-			timeVal            time.Time
+			timeVal            queryTime
 			cpuAvg             float64
 			cpuMax             float64
 			memoryAvg          int64
@@ -115,7 +142,7 @@ func GetResourceUsageHistorical(instanceID uuid.UUID, period time.Duration, reso
 		
 		// Format values for frontend display
 		point := map[string]interface{}{
-			"timestamp":         timeVal.Format(time.RFC3339),
+			"timestamp":         timeVal.Time.Format(time.RFC3339),
 			"cpu_avg":           cpuAvg,
 			"cpu_max":           cpuMax,
 			"memory_avg":        memoryAvg,
@@ -132,6 +159,16 @@ func GetResourceUsageHistorical(instanceID uuid.UUID, period time.Duration, reso
 	return results, nil
 }
 
+// sqliteBuckets are the seconds in each time bucket of the historical stats
+var sqliteBuckets = map[string]int{
+	"10 seconds": 10,
+	"1 minute":   60,
+	"5 minutes":  300,
+	"10 minutes": 600,
+	"15 minutes": 900,
+	"1 hour":     3600,
+}
+
 // GetLatestResourceUsage retrieves the most recent resource usage record for an instance
 func GetLatestResourceUsage(instanceID uuid.UUID) (*models.ResourceUsage, error) {
 	var usage models.ResourceUsage
@@ -147,6 +184,44 @@ func GetLatestResourceUsage(instanceID uuid.UUID) (*models.ResourceUsage, error)
 	return &usage, nil
 }
 
+// ResourceUtilization is the CPU and memory instances are using, summed over their latest samples
+type ResourceUtilization struct {
+	CPUCores    float64 // 1 is a full core
+	MemoryBytes int64
+	Instances   int64
+}
+
+// GetCurrentUtilization sums the latest resource usage sample of every instance sampled since
+// the given time
+func GetCurrentUtilization(since time.Time) (*ResourceUtilization, error) {
+	var utilization ResourceUtilization
+	latest := `
+			SELECT DISTINCT ON (instance_id) cpu_usage, memory_usage
+			FROM resource_usages
+			WHERE timestamp > ? AND deleted_at IS NULL
+			ORDER BY instance_id, timestamp DESC`
+	if SQLite() {
+		// SQLite takes the other columns of a row with the MAX of a group from that row
+		latest = `
+			SELECT cpu_usage, memory_usage, MAX(timestamp)
+			FROM resource_usages
+			WHERE timestamp > ? AND deleted_at IS NULL
+			GROUP BY instance_id`
+	}
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(SUM(cpu_usage), 0) / 100 AS cpu_cores,
+			COALESCE(SUM(memory_usage), 0) AS memory_bytes,
+			COUNT(*) AS instances
+		FROM (%s
+		) latest
+	`, latest)
+	if err := DB.Raw(query, since).Scan(&utilization).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum current utilization: %w", err)
+	}
+	return &utilization, nil
+}
+
 // GetResourceUsageAggregates returns hourly aggregated stats for a specified time period
 func GetResourceUsageAggregates(instanceID uuid.UUID, period time.Duration) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
@@ -156,7 +231,7 @@ func GetResourceUsageAggregates(instanceID uuid.UUID, period time.Duration) ([]m
 	startTime := endTime.Add(-period)
 	
 	// For longer time periods, use the continuous aggregate view for better performance
-	if period > 2*time.Hour {
+	if period > 2*time.Hour && !SQLite() {
 		query := `
 			SELECT 
 				bucket AS time,
@@ -166,7 +241,7 @@ func GetResourceUsageAggregates(instanceID uuid.UUID, period time.Duration) ([]m
 				max_memory,
 				total_network_in,
 				total_network_out
-			FROM resource_usage_hourly
+			FROM resource_usages_hourly
 			WHERE instance_id = $1 AND bucket BETWEEN $2 AND $3
 			ORDER BY bucket ASC
 		`
@@ -218,4 +293,140 @@ func GetResourceUsageAggregates(instanceID uuid.UUID, period time.Duration) ([]m
 func PruneResourceUsage(maxRecordsPerInstance int) error {
 	// No longer needed with TimescaleDB retention policy
 	return nil
-} 
\ No newline at end of file
+} 
+// PlatformResourceSummary holds platform-wide resource allocation and usage
+type PlatformResourceSummary struct {
+	AllocatedCPU     float64 `json:"allocated_cpu"`
+	AllocatedMemory  int64   `json:"allocated_memory_mb"`
+	AllocatedStorage int64   `json:"allocated_storage_gb"`
+	AvgCPUUsage      float64 `json:"avg_cpu_usage"`
+	AvgMemoryUsage   float64 `json:"avg_memory_usage"`
+	TotalNetworkIn   int64   `json:"total_network_in"`
+	TotalNetworkOut  int64   `json:"total_network_out"`
+	SampleCount      int64   `json:"sample_count"`
+}
+
+// GetPlatformResourceSummary aggregates resource allocation across all active instances
+// and resource usage samples recorded within the given period
+func GetPlatformResourceSummary(period time.Duration) (*PlatformResourceSummary, error) {
+	var summary PlatformResourceSummary
+	
+	allocationQuery := `
+		SELECT 
+			COALESCE(SUM(cpu_limit), 0) AS allocated_cpu,
+			COALESCE(SUM(memory_limit), 0) AS allocated_memory,
+			COALESCE(SUM(storage_limit), 0) AS allocated_storage
+		FROM instances
+		WHERE status != ? AND deleted_at IS NULL
+	`
+	if err := DB.Raw(allocationQuery, models.StatusDeleted).Scan(&summary).Error; err != nil {
+		return nil, err
+	}
+	
+	usageQuery := `
+		SELECT 
+			COALESCE(AVG(cpu_usage), 0) AS avg_cpu_usage,
+			COALESCE(AVG(memory_usage), 0) AS avg_memory_usage,
+			COALESCE(SUM(network_in), 0) AS total_network_in,
+			COALESCE(SUM(network_out), 0) AS total_network_out,
+			COUNT(*) AS sample_count
+		FROM resource_usages
+		WHERE timestamp > ? AND deleted_at IS NULL
+	`
+	if err := DB.Raw(usageQuery, time.Now().Add(-period)).Scan(&summary).Error; err != nil {
+		return nil, err
+	}
+	
+	return &summary, nil
+}
+
+// ResourceSummary aggregates the resource usage samples of an instance over a period
+type ResourceSummary struct {
+	InstanceID uuid.UUID `json:"-"`
+	AvgCPU     float64   `json:"avg_cpu"`     // percent of one core
+	MaxCPU     float64   `json:"max_cpu"`     // percent of one core
+	AvgMemory  int64     `json:"avg_memory"`  // bytes
+	MaxMemory  int64     `json:"max_memory"`  // bytes
+	NetworkIn  int64     `json:"network_in"`  // bytes received in the period
+	NetworkOut int64     `json:"network_out"` // bytes sent in the period
+	Samples    int64     `json:"samples"`
+}
+
+// hourlySummaryPeriod is the shortest period summarised from the hourly aggregates, which
+// leave out the latest, not yet refreshed hour
+const hourlySummaryPeriod = 24 * time.Hour
+
+// GetResourceSummaries aggregates the resource usage of instances since the given time, by
+// instance. Periods longer than a day are read from the hourly aggregates when TimescaleDB
+// keeps them. Network counters are cumulative and restart from 0 with the container, so traffic
+// is the sum of their growth between consecutive raw samples still kept.
+func GetResourceSummaries(instanceIDs []uuid.UUID, since time.Time) (map[uuid.UUID]ResourceSummary, error) {
+	summaries := map[uuid.UUID]ResourceSummary{}
+	if len(instanceIDs) == 0 {
+		return summaries, nil
+	}
+
+	var rows []ResourceSummary
+	err := DB.Raw(`
+		SELECT
+			instance_id,
+			AVG(cpu_usage) AS avg_cpu,
+			MAX(cpu_usage) AS max_cpu,
+			CAST(AVG(memory_usage) AS BIGINT) AS avg_memory,
+			MAX(memory_usage) AS max_memory,
+			SUM(CASE WHEN previous_in IS NULL THEN 0 WHEN network_in >= previous_in THEN network_in - previous_in ELSE network_in END) AS network_in,
+			SUM(CASE WHEN previous_out IS NULL THEN 0 WHEN network_out >= previous_out THEN network_out - previous_out ELSE network_out END) AS network_out,
+			COUNT(*) AS samples
+		FROM (
+			SELECT
+				instance_id,
+				cpu_usage,
+				memory_usage,
+				network_in,
+				network_out,
+				LAG(network_in) OVER (PARTITION BY instance_id ORDER BY timestamp) AS previous_in,
+				LAG(network_out) OVER (PARTITION BY instance_id ORDER BY timestamp) AS previous_out
+			FROM resource_usages
+			WHERE instance_id IN ? AND timestamp >= ? AND deleted_at IS NULL
+		) samples
+		GROUP BY instance_id
+	`, instanceIDs, since).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarise resource usage: %w", err)
+	}
+	for _, row := range rows {
+		summaries[row.InstanceID] = row
+	}
+
+	hourly, err := hasTimescaleDB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for timescaledb: %w", err)
+	}
+	if !hourly || time.Since(since) <= hourlySummaryPeriod {
+		return summaries, nil
+	}
+
+	// Averages are weighted by the samples of each hour
+	rows = nil
+	err = DB.Raw(`
+		SELECT
+			instance_id,
+			SUM(avg_cpu * sample_count) / NULLIF(SUM(sample_count), 0) AS avg_cpu,
+			MAX(max_cpu) AS max_cpu,
+			CAST(SUM(avg_memory * sample_count) / NULLIF(SUM(sample_count), 0) AS BIGINT) AS avg_memory,
+			MAX(max_memory) AS max_memory,
+			SUM(sample_count) AS samples
+		FROM resource_usages_hourly
+		WHERE instance_id IN ? AND bucket >= ?
+		GROUP BY instance_id
+	`, instanceIDs, since).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarise hourly resource usage: %w", err)
+	}
+	for _, row := range rows {
+		raw := summaries[row.InstanceID]
+		row.NetworkIn, row.NetworkOut = raw.NetworkIn, raw.NetworkOut
+		summaries[row.InstanceID] = row
+	}
+	return summaries, nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// resourceUsageBufferBatches is how many batches of samples may be buffered while writes fail
+// or fall behind; older samples are dropped beyond that
+const resourceUsageBufferBatches = 10
+
+// ResourceUsageWriter buffers resource usage samples and inserts them in batches, once a batch
+// is full or the flush interval passed, whichever comes first
+type ResourceUsageWriter struct {
+	batchSize int
+	interval  time.Duration
+	logger    *logrus.Logger
+
+	mu     sync.Mutex
+	buffer []models.ResourceUsage
+	full   chan struct{}
+}
+
+// NewResourceUsageWriter creates a writer inserting up to batchSize samples at a time
+func NewResourceUsageWriter(batchSize int, interval time.Duration, logger *logrus.Logger) *ResourceUsageWriter {
+	return &ResourceUsageWriter{
+		batchSize: batchSize,
+		interval:  interval,
+		logger:    logger,
+		full:      make(chan struct{}, 1),
+	}
+}
+
+// Start flushes buffered samples until the context is cancelled. Call Flush afterwards to
+// write what is left.
+func (w *ResourceUsageWriter) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Flush()
+		case <-w.full:
+			w.Flush()
+		}
+	}
+}
+
+// Add buffers samples for the next flush, dropping the oldest ones when the buffer is full
+func (w *ResourceUsageWriter) Add(usages ...models.ResourceUsage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buffer = append(w.buffer, usages...)
+	w.trim()
+	if len(w.buffer) >= w.batchSize {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// trim drops the oldest samples beyond the buffer size; w.mu must be held
+func (w *ResourceUsageWriter) trim() {
+	if overflow := len(w.buffer) - w.batchSize*resourceUsageBufferBatches; overflow > 0 {
+		w.buffer = append([]models.ResourceUsage(nil), w.buffer[overflow:]...)
+		w.logger.WithField("samples", overflow).Warn("Resource usage writes are falling behind, dropping samples")
+		metrics.StatsSamplesDropped(overflow)
+	}
+}
+
+// Buffered returns how many samples wait to be written
+func (w *ResourceUsageWriter) Buffered() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.buffer)
+}
+
+// Flush inserts the buffered samples. Samples of a failed insert are put back in front of
+// newer ones and retried on the next tick.
+func (w *ResourceUsageWriter) Flush() {
+	w.mu.Lock()
+	batch := w.buffer
+	w.buffer = nil
+	w.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	start := time.Now()
+	if err := DB.CreateInBatches(batch, w.batchSize).Error; err != nil {
+		w.logger.WithError(err).WithField("samples", len(batch)).Error("Failed to save resource usage")
+		w.mu.Lock()
+		w.buffer = append(batch, w.buffer...)
+		w.trim()
+		w.mu.Unlock()
+		return
+	}
+	metrics.ObserveStatsWrite(start)
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// ErrTerminalTicketInvalid is returned for a terminal ticket that is unknown, expired or used
+var ErrTerminalTicketInvalid = errors.New("terminal ticket is invalid, expired or already used")
+
+// terminalSessionSearchColumns are searched by the q parameter of terminal session listings
+var terminalSessionSearchColumns = []string{"reason", "command"}
+
+// CreateTerminalSession records a pending terminal session
+func CreateTerminalSession(session *models.TerminalSession) error {
+	if err := DB.Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create terminal session: %w", err)
+	}
+	return nil
+}
+
+// ClaimTerminalSession opens the pending session of a ticket that has not expired. The status
+// changes conditionally, so a ticket opens a single session even when used twice at once.
+func ClaimTerminalSession(ticketHash, clientIP string, now time.Time) (*models.TerminalSession, error) {
+	result := DB.Model(&models.TerminalSession{}).
+		Where("ticket_hash = ? AND status = ? AND expires_at > ?", ticketHash, models.TerminalSessionPending, now).
+		Updates(map[string]interface{}{
+			"status":     models.TerminalSessionOpen,
+			"started_at": now,
+			"client_ip":  clientIP,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim terminal session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTerminalTicketInvalid
+	}
+
+	var session models.TerminalSession
+	if err := DB.Where("ticket_hash = ?", ticketHash).First(&session).Error; err != nil {
+		return nil, fmt.Errorf("failed to get terminal session: %w", err)
+	}
+	return &session, nil
+}
+
+// FinishTerminalSession closes a session, saving its transcript, byte counts and outcome
+func FinishTerminalSession(session *models.TerminalSession) error {
+	err := DB.Model(session).Select("status", "transcript", "transcript_truncated", "input_bytes", "output_bytes", "exit_code", "error", "ended_at").
+		Updates(session).Error
+	if err != nil {
+		return fmt.Errorf("failed to finish terminal session: %w", err)
+	}
+	return nil
+}
+
+// ListTerminalSessions returns a page of terminal sessions and the number matching the filters
+func ListTerminalSessions(opts ListOptions) ([]models.TerminalSession, int64, error) {
+	var sessions []models.TerminalSession
+	total, err := Paginate(DB.Model(&models.TerminalSession{}), opts, terminalSessionSearchColumns, &sessions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list terminal sessions: %w", err)
+	}
+	return sessions, total, nil
+}
+
+// GetTerminalSession retrieves a terminal session by ID
+func GetTerminalSession(id uuid.UUID) (*models.TerminalSession, error) {
+	var session models.TerminalSession
+	if err := DB.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, fmt.Errorf("failed to get terminal session: %w", err)
+	}
+	return &session, nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddUsage adds metered usage to a user's record for the record's period,
+// creating the record if this is the first usage of the month
+func AddUsage(record *models.UsageRecord) error {
+	err := DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"cpu_seconds":       gorm.Expr("usage_records.cpu_seconds + EXCLUDED.cpu_seconds"),
+			"memory_mb_hours":   gorm.Expr("usage_records.memory_mb_hours + EXCLUDED.memory_mb_hours"),
+			"network_in_bytes":  gorm.Expr("usage_records.network_in_bytes + EXCLUDED.network_in_bytes"),
+			"network_out_bytes": gorm.Expr("usage_records.network_out_bytes + EXCLUDED.network_out_bytes"),
+			"updated_at":        time.Now(),
+		}),
+	}).Create(record).Error
+	if err != nil {
+		return fmt.Errorf("failed to add usage: %w", err)
+	}
+	return nil
+}
+
+// GetUsageRecords retrieves a user's monthly usage records for periods starting in [from, to)
+func GetUsageRecords(userID uuid.UUID, from, to time.Time) ([]models.UsageRecord, error) {
+	var records []models.UsageRecord
+	result := DB.Where("user_id = ? AND period >= ? AND period < ?", userID, from, to).
+		Order("period ASC").
+		Find(&records)
+	return records, result.Error
+}
+
+// GetUserSpending returns a user's metered charges of the current usage period against their
+// spending cap
+func GetUserSpending(user models.User, prices models.UsagePrices) (models.Spending, error) {
+	record := models.UsageRecord{UserID: user.ID, Period: models.UsagePeriod(time.Now())}
+	err := DB.Where("user_id = ? AND period = ?", user.ID, record.Period).Limit(1).Find(&record).Error
+	if err != nil {
+		return models.Spending{}, fmt.Errorf("failed to get usage record: %w", err)
+	}
+	return models.NewSpending(user, record, prices), nil
+}
+
+// GetUnexportedUsageRecords retrieves the usage records of closed periods, those before
+// the given period, that have not been exported to the payment layer yet
+func GetUnexportedUsageRecords(before time.Time) ([]models.UsageRecord, error) {
+	var records []models.UsageRecord
+	result := DB.Where("period < ? AND exported_at IS NULL", before).
+		Order("period ASC").
+		Find(&records)
+	return records, result.Error
+}
+
+// MarkUsageRecordsExported records that usage records were exported to the payment layer
+func MarkUsageRecordsExported(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return DB.Model(&models.UsageRecord{}).Where("id IN ?", ids).Update("exported_at", time.Now()).Error
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// RollupDailyUsage aggregates raw resource usage samples for the given day into
+// per-user usage rollups. Existing rollups for the day are replaced, so it is safe
+// to run repeatedly.
+func RollupDailyUsage(day time.Time) (int, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+	
+	// Instance-hours count the minutes in which an instance reported at least one sample.
+	// Network counters are cumulative and restart from 0 with the container, so traffic is the
+	// sum of their growth between consecutive samples, taking a counter lower than the sample
+	// before it as restarted.
+	minute := "date_trunc('minute', r.timestamp)"
+	if SQLite() {
+		minute = "strftime('%Y-%m-%d %H:%M', r.timestamp)"
+	}
+	query := fmt.Sprintf(`
+		SELECT 
+			user_id,
+			SUM(minutes) / 60.0 AS instance_hours,
+			SUM(avg_cpu * minutes) / 100.0 / 60.0 AS cpu_hours,
+			SUM(egress) AS egress_bytes,
+			SUM(ingress) AS ingress_bytes
+		FROM (
+			SELECT 
+				user_id,
+				instance_id,
+				COUNT(DISTINCT minute) AS minutes,
+				AVG(cpu_usage) AS avg_cpu,
+				SUM(CASE WHEN previous_out IS NULL THEN 0 WHEN network_out >= previous_out THEN network_out - previous_out ELSE network_out END) AS egress,
+				SUM(CASE WHEN previous_in IS NULL THEN 0 WHEN network_in >= previous_in THEN network_in - previous_in ELSE network_in END) AS ingress
+			FROM (
+				SELECT 
+					i.user_id,
+					r.instance_id,
+					%s AS minute,
+					r.cpu_usage,
+					r.network_out,
+					r.network_in,
+					LAG(r.network_out) OVER (PARTITION BY r.instance_id ORDER BY r.timestamp) AS previous_out,
+					LAG(r.network_in) OVER (PARTITION BY r.instance_id ORDER BY r.timestamp) AS previous_in
+				FROM resource_usages r
+				JOIN instances i ON i.id = r.instance_id
+				WHERE r.timestamp >= ? AND r.timestamp < ? AND r.deleted_at IS NULL
+			) samples
+			GROUP BY user_id, instance_id
+		) per_instance
+		GROUP BY user_id
+	`, minute)
+	
+	var rollups []models.UsageRollup
+	if err := DB.Raw(query, start, end).Scan(&rollups).Error; err != nil {
+		return 0, fmt.Errorf("failed to aggregate usage for %s: %w", start.Format("2006-01-02"), err)
+	}
+	
+	if len(rollups) == 0 {
+		return 0, nil
+	}
+	
+	for i := range rollups {
+		rollups[i].Day = start
+	}
+	
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"instance_hours", "cpu_hours", "egress_bytes", "ingress_bytes", "updated_at"}),
+	}).Create(&rollups).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to save usage rollups for %s: %w", start.Format("2006-01-02"), err)
+	}
+	
+	return len(rollups), nil
+}
+
+// GetUsageRollups retrieves a user's daily usage rollups within a date range
+func GetUsageRollups(userID uuid.UUID, from, to time.Time) ([]models.UsageRollup, error) {
+	var rollups []models.UsageRollup
+	result := DB.Where("user_id = ? AND day >= ? AND day < ?", userID, from, to).
+		Order("day ASC").
+		Find(&rollups)
+	return rollups, result.Error
+}
+
+// GetUsageRollupsForUsers retrieves the daily usage rollups of several users within a date range
+func GetUsageRollupsForUsers(userIDs []uuid.UUID, from, to time.Time) ([]models.UsageRollup, error) {
+	var rollups []models.UsageRollup
+	result := DB.Where("user_id IN ? AND day >= ? AND day < ?", userIDs, from, to).
+		Order("day ASC").
+		Find(&rollups)
+	return rollups, result.Error
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+)
+
+// userCacheSweepSize is the number of cached users above which expired ones are dropped when
+// another is added, so users who stopped making requests do not stay in memory
+const userCacheSweepSize = 10000
+
+// cachedUser is a user in the user cache and when it expires
+type cachedUser struct {
+	user    models.User
+	expires time.Time
+}
+
+// userCache holds the users authenticated requests resolved by their Clerk ID, so that clients
+// polling the API, e.g. for instance stats, do not query the users table on every request.
+// Changes made through this package invalidate the cached user; changes made by other
+// replicas are seen once the entry expires.
+var userCache = struct {
+	sync.RWMutex
+	ttl   time.Duration
+	users map[string]cachedUser
+	// generation counts invalidations, so a user loaded while one happened is not cached
+	generation uint64
+}{users: map[string]cachedUser{}}
+
+// SetUserCacheTTL sets how long users are cached; 0 disables the cache
+func SetUserCacheTTL(ttl time.Duration) {
+	userCache.Lock()
+	userCache.ttl = ttl
+	userCache.users = map[string]cachedUser{}
+	userCache.generation++
+	userCache.Unlock()
+}
+
+// FindCachedUserByClerkID finds a user by their Clerk ID like FindUserByClerkID, returning the
+// cached user if it has not expired
+func FindCachedUserByClerkID(clerkID string) (models.User, error) {
+	userCache.RLock()
+	ttl := userCache.ttl
+	entry, ok := userCache.users[clerkID]
+	generation := userCache.generation
+	userCache.RUnlock()
+	if ttl <= 0 {
+		return FindUserByClerkID(clerkID)
+	}
+
+	now := time.Now()
+	if ok && now.Before(entry.expires) {
+		metrics.ObserveUserCacheLookup(true)
+		return entry.user, nil
+	}
+	metrics.ObserveUserCacheLookup(false)
+
+	user, err := FindUserByClerkID(clerkID)
+	if err != nil {
+		return user, err
+	}
+
+	userCache.Lock()
+	defer userCache.Unlock()
+	if userCache.generation != generation {
+		return user, nil
+	}
+	if len(userCache.users) >= userCacheSweepSize {
+		for id, cached := range userCache.users {
+			if !now.Before(cached.expires) {
+				delete(userCache.users, id)
+			}
+		}
+	}
+	userCache.users[clerkID] = cachedUser{user: user, expires: now.Add(ttl)}
+	return user, nil
+}
+
+// InvalidateCachedUser drops a user from the user cache by their Clerk ID, so the next request
+// loads them from the database
+func InvalidateCachedUser(clerkID string) {
+	userCache.Lock()
+	delete(userCache.users, clerkID)
+	userCache.generation++
+	userCache.Unlock()
+}
+
+// InvalidateCachedUserByID drops a user from the user cache by their ID
+func InvalidateCachedUserByID(userID uuid.UUID) {
+	userCache.Lock()
+	for clerkID, cached := range userCache.users {
+		if cached.user.ID == userID {
+			delete(userCache.users, clerkID)
+		}
+	}
+	userCache.generation++
+	userCache.Unlock()
+}
@@ -2,11 +2,14 @@ package db
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // FindUserByClerkID finds a user by their Clerk ID
@@ -83,6 +86,20 @@ func CreateUser(user *models.User) error {
 	return nil
 }
 
+// CreateClerkUser creates the user of a Clerk user unless one with their Clerk ID exists, and
+// reports whether it was created. Concurrent deliveries of Clerk events for a new user create
+// it once instead of failing on the unique Clerk ID.
+func CreateClerkUser(user *models.User) (bool, error) {
+	result := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "clerk_user_id"}},
+		DoNothing: true,
+	}).Create(user)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // UpdateUser updates an existing user
 func UpdateUser(user *models.User) error {
 	logger := getLogger()
@@ -96,6 +113,7 @@ func UpdateUser(user *models.User) error {
 		logger.WithError(result.Error).Error("Failed to update user")
 		return result.Error
 	}
+	InvalidateCachedUserByID(user.ID)
 	
 	logger.WithFields(logrus.Fields{
 		"user_id": user.ID,
@@ -115,7 +133,151 @@ func DeleteUserByClerkID(clerkID string) error {
 		logger.WithError(result.Error).Error("Failed to delete user")
 		return result.Error
 	}
+	InvalidateCachedUser(clerkID)
 	
 	logger.WithField("clerk_user_id", clerkID).Info("Successfully deleted user")
 	return nil
-} 
\ No newline at end of file
+} 
+// ListUsers retrieves a page of users and the number of matching ones, searching their email,
+// username and name
+func ListUsers(opts ListOptions) ([]models.User, int64, error) {
+	var users []models.User
+	total, err := Paginate(DB.Model(&models.User{}), opts, []string{"email", "username", "first_name", "last_name"}, &users)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// GetAdmins retrieves the users with the admin role
+func GetAdmins() ([]models.User, error) {
+	var admins []models.User
+	if err := DB.Where("role = ?", models.RoleAdmin).Find(&admins).Error; err != nil {
+		return nil, fmt.Errorf("failed to get admins: %w", err)
+	}
+	return admins, nil
+}
+
+// CountUsersByPlan counts users grouped by subscription plan
+func CountUsersByPlan() (map[models.SubscriptionPlan]int64, error) {
+	var rows []struct {
+		Plan  models.SubscriptionPlan
+		Count int64
+	}
+	if err := DB.Model(&models.User{}).Select("plan, COUNT(*) AS count").Group("plan").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users by plan: %w", err)
+	}
+	
+	counts := make(map[models.SubscriptionPlan]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Plan] = row.Count
+	}
+	return counts, nil
+}
+
+// GetUsersWithSubscription retrieves the users with a subscription of a payment provider that
+// has not expired. Subscriptions created before provider support was added are PayPal ones.
+func GetUsersWithSubscription(provider string) ([]models.User, error) {
+	query := DB.Where("subscription_id <> '' AND subscription_status <> ?", models.StatusExpired)
+	if provider == "paypal" {
+		query = query.Where("subscription_provider = ? OR subscription_provider = '' OR subscription_provider IS NULL", provider)
+	} else {
+		query = query.Where("subscription_provider = ?", provider)
+	}
+
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users with a subscription: %w", err)
+	}
+	return users, nil
+}
+
+// GetUsersWithSpendingCap retrieves the users who set a spending cap
+func GetUsersWithSpendingCap() ([]models.User, error) {
+	var users []models.User
+	if err := DB.Where("spending_cap > 0").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users with a spending cap: %w", err)
+	}
+	return users, nil
+}
+
+// SetSpendingCap records a user's spending cap in cents; 0 removes it. The user is notified
+// again when the new cap is reached.
+func SetSpendingCap(userID uuid.UUID, cents int64) error {
+	defer InvalidateCachedUserByID(userID)
+	return DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"spending_cap":              cents,
+		"spending_cap_notified_for": nil,
+	}).Error
+}
+
+// SetSpendingCapLifted records the usage period an admin lifted a user's spending cap for;
+// nil enforces the cap again
+func SetSpendingCapLifted(userID uuid.UUID, period *time.Time) error {
+	defer InvalidateCachedUserByID(userID)
+	return DB.Model(&models.User{}).Where("id = ?", userID).Update("spending_cap_lifted_for", period).Error
+}
+
+// MarkSpendingCapNotified records that a user was told their spending cap was reached in a
+// usage period. It reports false if they already were, so only one replica notifies.
+func MarkSpendingCapNotified(userID uuid.UUID, period time.Time) (bool, error) {
+	result := DB.Model(&models.User{}).
+		Where("id = ? AND (spending_cap_notified_for IS NULL OR spending_cap_notified_for <> ?)", userID, period).
+		Update("spending_cap_notified_for", period)
+	if result.RowsAffected > 0 {
+		InvalidateCachedUserByID(userID)
+	}
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetTrialUsers retrieves the users on a trial
+func GetTrialUsers() ([]models.User, error) {
+	var users []models.User
+	if err := DB.Where("subscription_status = ?", models.StatusTrial).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users on a trial: %w", err)
+	}
+	return users, nil
+}
+
+// MarkTrialReminded records that a user was reminded their trial ends. It reports false if
+// they were already reminded at or after since, so each reminder is sent by one replica only.
+func MarkTrialReminded(userID uuid.UUID, since, now time.Time) (bool, error) {
+	result := DB.Model(&models.User{}).
+		Where("id = ? AND (trial_reminded_at IS NULL OR trial_reminded_at < ?)", userID, since).
+		Update("trial_reminded_at", now)
+	if result.RowsAffected > 0 {
+		InvalidateCachedUserByID(userID)
+	}
+	return result.RowsAffected > 0, result.Error
+}
+
+// EndExpiredTrial moves a user whose trial without a provider subscription ended to the free
+// plan and journals the plan change. It reports false if the trial was extended, paid for or
+// ended by another replica meanwhile.
+func EndExpiredTrial(user models.User, now time.Time) (bool, error) {
+	ended := false
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND subscription_status = ? AND (subscription_id = '' OR subscription_id IS NULL) AND current_period_end <= ?",
+				user.ID, models.StatusTrial, now).
+			Updates(map[string]interface{}{
+				"plan":                models.PlanFree,
+				"subscription_status": "",
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to end trial: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		ended = true
+
+		description := fmt.Sprintf("Trial of %s plan ended, moved to %s plan", user.Plan, models.PlanFree)
+		_, err := RecordPlanChange(tx, user.ID, user.Plan, models.PlanFree, description)
+		return err
+	})
+	if ended && err == nil {
+		InvalidateCachedUserByID(user.ID)
+	}
+	return ended && err == nil, err
+}
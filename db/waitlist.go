@@ -0,0 +1,78 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+)
+
+// CreateWaitlistEntry puts an instance request in line for capacity
+func CreateWaitlistEntry(entry *models.WaitlistEntry) error {
+	if err := DB.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create waitlist entry: %w", err)
+	}
+	return nil
+}
+
+// GetWaitlistEntryByID retrieves a waitlist entry
+func GetWaitlistEntryByID(id uuid.UUID) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	if err := DB.Where("id = ?", id).First(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to get waitlist entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// GetActiveWaitlistEntriesByUserID retrieves the entries of a user that are waiting for, or holding, capacity
+func GetActiveWaitlistEntriesByUserID(userID uuid.UUID) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	if err := DB.Where("user_id = ? AND status IN ?", userID, []models.WaitlistStatus{models.WaitlistWaiting, models.WaitlistReserved}).
+		Order("created_at ASC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get waitlist entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetWaitlistEntriesByStatus retrieves the entries with a status, first in line first
+func GetWaitlistEntriesByStatus(status models.WaitlistStatus) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	if err := DB.Where("status = ?", status).Order("created_at ASC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get waitlist entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetWaitlistPosition returns the place in line of a waiting entry, starting at 1
+func GetWaitlistPosition(entry *models.WaitlistEntry) (int64, error) {
+	var ahead int64
+	if err := DB.Model(&models.WaitlistEntry{}).
+		Where("status = ? AND created_at < ?", models.WaitlistWaiting, entry.CreatedAt).
+		Count(&ahead).Error; err != nil {
+		return 0, fmt.Errorf("failed to get waitlist position: %w", err)
+	}
+	return ahead + 1, nil
+}
+
+// TransitionWaitlistEntry saves an entry only if it still has the given status, so that
+// concurrent workers and requests cannot both act on it, reporting whether it was saved
+func TransitionWaitlistEntry(entry *models.WaitlistEntry, from models.WaitlistStatus) (bool, error) {
+	result := DB.Model(entry).Select("*").Where("status = ?", from).Updates(entry)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update waitlist entry: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ExpireWaitlistReservations releases the capacity of reservations that were not claimed in
+// time, returning how many expired
+func ExpireWaitlistReservations(now time.Time) (int64, error) {
+	result := DB.Model(&models.WaitlistEntry{}).
+		Where("status = ? AND reserved_until <= ?", models.WaitlistReserved, now).
+		Update("status", models.WaitlistExpired)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to expire waitlist reservations: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// CreateReceivedWebhookEvent records a received webhook event and reports whether it is new.
+// A redelivery of an event that was already recorded is not stored again.
+func CreateReceivedWebhookEvent(event *models.ReceivedWebhookEvent) (bool, error) {
+	result := DB.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "source"}, {Name: "delivery_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "delivery_id <> ''"}}},
+		DoNothing:   true,
+	}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetReceivedWebhookEventByDelivery retrieves the event recorded for a delivery ID of a source
+func GetReceivedWebhookEventByDelivery(source, deliveryID string) (*models.ReceivedWebhookEvent, error) {
+	var event models.ReceivedWebhookEvent
+	if err := DB.Where("source = ? AND delivery_id = ?", source, deliveryID).First(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetReceivedWebhookEventByID retrieves a received webhook event by ID
+func GetReceivedWebhookEventByID(id uuid.UUID) (*models.ReceivedWebhookEvent, error) {
+	var event models.ReceivedWebhookEvent
+	if err := DB.First(&event, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// RetryReceivedWebhookEvent sets a failed webhook event to the given status to apply it again,
+// and reports false if it is no longer failed, e.g. because a concurrent redelivery retries it
+func RetryReceivedWebhookEvent(event *models.ReceivedWebhookEvent, status models.WebhookEventStatus) (bool, error) {
+	result := DB.Model(event).Where("status = ?", models.WebhookEventFailed).Updates(map[string]interface{}{
+		"status": status,
+		"error":  "",
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	event.Status = status
+	event.Error = ""
+	return true, nil
+}
+
+// UpdateReceivedWebhookEvent saves the processing state of a received webhook event
+func UpdateReceivedWebhookEvent(event *models.ReceivedWebhookEvent) error {
+	return DB.Save(event).Error
+}
+
+// PruneReceivedWebhookEvents deletes received webhook events that were applied or failed before
+// the given time. Events still queued or being applied are kept.
+func PruneReceivedWebhookEvents(before time.Time) (int64, error) {
+	result := DB.Where("status IN ? AND updated_at < ?", []models.WebhookEventStatus{models.WebhookEventProcessed, models.WebhookEventFailed}, before).
+		Delete(&models.ReceivedWebhookEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune webhook events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// CreateWebhookEndpoint stores a new webhook endpoint
+func CreateWebhookEndpoint(endpoint *models.WebhookEndpoint) error {
+	if err := DB.Create(endpoint).Error; err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookEndpointsByUserID retrieves all webhook endpoints of a user
+func GetWebhookEndpointsByUserID(userID uuid.UUID) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	if err := DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// GetWebhookEndpointByID retrieves a webhook endpoint
+func GetWebhookEndpointByID(id uuid.UUID) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := DB.Where("id = ?", id).First(&endpoint).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return &endpoint, nil
+}
+
+// UpdateWebhookEndpoint saves a webhook endpoint
+func UpdateWebhookEndpoint(endpoint *models.WebhookEndpoint) error {
+	if err := DB.Save(endpoint).Error; err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookEndpoint deletes a webhook endpoint; queued deliveries to it are dropped
+func DeleteWebhookEndpoint(id uuid.UUID) error {
+	if err := DB.Delete(&models.WebhookEndpoint{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// RecordWebhookDelivery stores the outcome of the latest delivery to an endpoint
+func RecordWebhookDelivery(endpointID uuid.UUID, status int, deliveryErr string, at time.Time) error {
+	if err := DB.Model(&models.WebhookEndpoint{}).Where("id = ?", endpointID).Updates(map[string]interface{}{
+		"last_delivery_at":     at,
+		"last_delivery_status": status,
+		"last_delivery_error":  deliveryErr,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// CreateWebhookDelivery records an attempt at delivering an event
+func CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	if err := DB.Create(delivery).Error; err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookDeliveries retrieves the latest delivery attempts to an endpoint, newest first
+func GetWebhookDeliveries(endpointID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	if err := DB.Where("endpoint_id = ?", endpointID).Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// PruneWebhookDeliveries deletes delivery attempts recorded before a time
+func PruneWebhookDeliveries(before time.Time) (int64, error) {
+	result := DB.Where("created_at < ?", before).Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// QueueWebhookEvent queues the delivery of an event to each active endpoint of its user that
+// subscribes to it. instance is the event's instance, or nil for events of the whole account.
+func QueueWebhookEvent(event *models.WebhookEvent, instance *models.Instance) error {
+	if err := queueWebhookEvent(DB, event, instance); err != nil {
+		return fmt.Errorf("failed to queue webhook event: %w", err)
+	}
+	return nil
+}
+
+// queueInstanceWebhooks queues the delivery of an instance event in the transaction that
+// changed the instance
+func queueInstanceWebhooks(tx *gorm.DB, instance *models.Instance, eventType models.WebhookEventType, previous models.InstanceStatus) error {
+	return queueWebhookEvent(tx, models.NewInstanceWebhookEvent(eventType, instance, previous), instance)
+}
+
+// queueWebhookEvent queues the delivery of an event to the user's active endpoints that
+// subscribe to it. Endpoints scoped to an instance are only sent that instance's events.
+func queueWebhookEvent(tx *gorm.DB, event *models.WebhookEvent, instance *models.Instance) error {
+	var endpoints []models.WebhookEndpoint
+	if err := tx.Where("user_id = ? AND active = ? AND (instance_id IS NULL OR instance_id = ?)", event.UserID, true, event.InstanceID).
+		Find(&endpoints).Error; err != nil {
+		return err
+	}
+
+	subdomain := ""
+	if instance != nil {
+		subdomain = instance.URL
+	}
+	for i := range endpoints {
+		if !endpoints[i].Subscribes(event.Type) {
+			continue
+		}
+		job, err := models.NewWebhookDeliveryJob(&endpoints[i], event, subdomain)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// workflowExecutionStatsSelect aggregates workflow executions into models.WorkflowExecutionStats.
+// SQLite has no percentiles, so the 95th percentile is left null there.
+func workflowExecutionStatsSelect() string {
+	p95 := "PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY duration_ms)"
+	if SQLite() {
+		p95 = "NULL"
+	}
+	return `COUNT(*) AS total,
+	COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded,
+	COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+	COUNT(*) FILTER (WHERE status = 'running') AS running,
+	AVG(duration_ms) AS avg_duration_ms,
+	` + p95 + ` AS p95_duration_ms,
+	MAX(duration_ms) AS max_duration_ms`
+}
+
+// RecordWorkflowExecution merges an event into the execution it belongs to, creating the
+// execution on its first event, and returns the execution as stored
+func RecordWorkflowExecution(event *models.WorkflowExecution) (*models.WorkflowExecution, error) {
+	var execution models.WorkflowExecution
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Concurrent events of one execution meet on the unique key and are merged one at a time
+		key := models.WorkflowExecution{
+			InstanceID:  event.InstanceID,
+			WorkflowID:  event.WorkflowID,
+			ExecutionID: event.ExecutionID,
+			Status:      models.ExecutionRunning,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&key).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("instance_id = ? AND workflow_id = ? AND execution_id = ?", event.InstanceID, event.WorkflowID, event.ExecutionID).
+			First(&execution).Error; err != nil {
+			return err
+		}
+		execution.Merge(event)
+		return tx.Save(&execution).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record workflow execution: %w", err)
+	}
+	return &execution, nil
+}
+
+// GetWorkflowExecutions retrieves the latest executions of an instance received since the
+// given time, optionally of one workflow, newest first
+func GetWorkflowExecutions(instanceID uuid.UUID, workflowID string, since time.Time, limit int) ([]models.WorkflowExecution, error) {
+	var executions []models.WorkflowExecution
+	query := DB.Where("instance_id = ? AND created_at >= ?", instanceID, since)
+	if workflowID != "" {
+		query = query.Where("workflow_id = ?", workflowID)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Find(&executions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workflow executions: %w", err)
+	}
+	return executions, nil
+}
+
+// GetWorkflowExecutionStats summarizes the executions of an instance received since the given
+// time, optionally of one workflow, in total and per workflow
+func GetWorkflowExecutionStats(instanceID uuid.UUID, workflowID string, since time.Time) (models.WorkflowExecutionStats, []models.WorkflowExecutionStats, error) {
+	var total models.WorkflowExecutionStats
+	var workflows []models.WorkflowExecutionStats
+
+	query := func() *gorm.DB {
+		q := DB.Model(&models.WorkflowExecution{}).Where("instance_id = ? AND created_at >= ?", instanceID, since)
+		if workflowID != "" {
+			q = q.Where("workflow_id = ?", workflowID)
+		}
+		return q
+	}
+	if err := query().Select(workflowExecutionStatsSelect()).Scan(&total).Error; err != nil {
+		return total, nil, fmt.Errorf("failed to get workflow execution stats: %w", err)
+	}
+	err := query().
+		Select("workflow_id, MAX(workflow_name) AS workflow_name, " + workflowExecutionStatsSelect()).
+		Group("workflow_id").
+		Order("total DESC, workflow_id ASC").
+		Scan(&workflows).Error
+	if err != nil {
+		return total, nil, fmt.Errorf("failed to get workflow execution stats: %w", err)
+	}
+
+	if workflows == nil {
+		workflows = []models.WorkflowExecutionStats{}
+	}
+	total.SetSuccessRate()
+	for i := range workflows {
+		workflows[i].SetSuccessRate()
+	}
+	return total, workflows, nil
+}
+
+// PruneWorkflowExecutions removes executions received before the given time
+func PruneWorkflowExecutions(before time.Time) (int64, error) {
+	result := DB.Where("created_at < ?", before).Delete(&models.WorkflowExecution{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune workflow executions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
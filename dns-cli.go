@@ -27,6 +27,10 @@ type DNSRewrite struct {
 }
 
 func createAuthHeader() string {
+	if host == "" || username == "" || password == "" {
+		fmt.Fprintln(os.Stderr, "Error: set -host, -username and -password, or ADGUARD_HOST, ADGUARD_USERNAME and ADGUARD_PASSWORD")
+		os.Exit(1)
+	}
 	auth := username + ":" + password
 	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
 }
@@ -246,10 +250,10 @@ func printUsage() {
 	fmt.Println("  get       Get a specific DNS rewrite")
 	fmt.Println("")
 	fmt.Println("Options:")
-	fmt.Println("  -host     AdGuard Home host (default: dns.srvr.site)")
-	fmt.Println("  -username AdGuard Home username (default: Pi)")
-	fmt.Println("  -password AdGuard Home password")
-	fmt.Println("  -protocol Protocol (http or https, default: https)")
+	fmt.Println("  -host     AdGuard Home host (default: $ADGUARD_HOST)")
+	fmt.Println("  -username AdGuard Home username (default: $ADGUARD_USERNAME)")
+	fmt.Println("  -password AdGuard Home password (default: $ADGUARD_PASSWORD)")
+	fmt.Println("  -protocol Protocol (http or https, default: $ADGUARD_PROTOCOL or https)")
 	fmt.Println("  -domain   Domain for add/delete/get commands")
 	fmt.Println("  -answer   IP address or value for add command")
 	fmt.Println("")
@@ -262,6 +266,12 @@ func printUsage() {
 }
 
 func main() {
+	// Credentials default to the ADGUARD_* variables the backend uses
+	defaultProtocol := os.Getenv("ADGUARD_PROTOCOL")
+	if defaultProtocol == "" {
+		defaultProtocol = "https"
+	}
+
 	// Setup default values and flags
 	var (
 		domainFlag  string
@@ -277,10 +287,10 @@ func main() {
 	
 	// Common flags for all commands
 	commonFlags := func(fs *flag.FlagSet) {
-		fs.StringVar(&host, "host", "dns.srvr.site", "AdGuard Home host")
-		fs.StringVar(&username, "username", "Pi", "AdGuard Home username")
-		fs.StringVar(&password, "password", "9130458959", "AdGuard Home password")
-		fs.StringVar(&protocol, "protocol", "https", "Protocol (http or https)")
+		fs.StringVar(&host, "host", os.Getenv("ADGUARD_HOST"), "AdGuard Home host")
+		fs.StringVar(&username, "username", os.Getenv("ADGUARD_USERNAME"), "AdGuard Home username")
+		fs.StringVar(&password, "password", os.Getenv("ADGUARD_PASSWORD"), "AdGuard Home password")
+		fs.StringVar(&protocol, "protocol", defaultProtocol, "Protocol (http or https)")
 		fs.BoolVar(&showHelp, "help", false, "Show help")
 	}
 	
@@ -299,10 +309,10 @@ func main() {
 	getCmd.StringVar(&domainFlag, "domain", "", "Domain for DNS rewrite")
 	
 	// Global flags for backwards compatibility
-	flag.StringVar(&host, "host", "dns.srvr.site", "AdGuard Home host")
-	flag.StringVar(&username, "username", "Pi", "AdGuard Home username")
-	flag.StringVar(&password, "password", "9130458959", "AdGuard Home password")
-	flag.StringVar(&protocol, "protocol", "https", "Protocol (http or https)")
+	flag.StringVar(&host, "host", os.Getenv("ADGUARD_HOST"), "AdGuard Home host")
+	flag.StringVar(&username, "username", os.Getenv("ADGUARD_USERNAME"), "AdGuard Home username")
+	flag.StringVar(&password, "password", os.Getenv("ADGUARD_PASSWORD"), "AdGuard Home password")
+	flag.StringVar(&protocol, "protocol", defaultProtocol, "Protocol (http or https)")
 	flag.StringVar(&domainFlag, "domain", "", "Domain for DNS rewrite")
 	flag.StringVar(&answerFlag, "answer", "", "Answer (IP or value) for DNS rewrite")
 	flag.BoolVar(&showHelp, "help", false, "Show help")
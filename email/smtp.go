@@ -0,0 +1,64 @@
+package email
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/config"
+)
+
+// SMTPSender sends plain text email through an SMTP server. The connection is upgraded with
+// STARTTLS when the server offers it; credentials are only sent over TLS.
+type SMTPSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPSender creates a sender from the SMTP_* settings, or returns nil if SMTP_HOST is not set
+func NewSMTPSender(cfg *config.Config) *SMTPSender {
+	if cfg.SMTP.Host == "" {
+		return nil
+	}
+	return &SMTPSender{
+		addr:     net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port)),
+		host:     cfg.SMTP.Host,
+		username: cfg.SMTP.Username,
+		password: cfg.SMTP.Password,
+		from:     cfg.SMTP.From,
+	}
+}
+
+// Send sends a message to the recipients
+func (s *SMTPSender) Send(to []string, subject, body string) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", sanitizeHeader(subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(s.addr, auth, s.from, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email through %s: %w", s.addr, err)
+	}
+	return nil
+}
+
+// sanitizeHeader keeps line breaks out of a header value
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,612 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: container.proto
+
+package containerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ContainerService_ProvisionInstance_FullMethodName   = "/launchstack.container.v1.ContainerService/ProvisionInstance"
+	ContainerService_DeleteInstance_FullMethodName      = "/launchstack.container.v1.ContainerService/DeleteInstance"
+	ContainerService_StartInstance_FullMethodName       = "/launchstack.container.v1.ContainerService/StartInstance"
+	ContainerService_StopInstance_FullMethodName        = "/launchstack.container.v1.ContainerService/StopInstance"
+	ContainerService_RenameInstance_FullMethodName      = "/launchstack.container.v1.ContainerService/RenameInstance"
+	ContainerService_GetInstanceStats_FullMethodName    = "/launchstack.container.v1.ContainerService/GetInstanceStats"
+	ContainerService_GetStorageUsage_FullMethodName     = "/launchstack.container.v1.ContainerService/GetStorageUsage"
+	ContainerService_CheckHealth_FullMethodName         = "/launchstack.container.v1.ContainerService/CheckHealth"
+	ContainerService_ResizeInstance_FullMethodName      = "/launchstack.container.v1.ContainerService/ResizeInstance"
+	ContainerService_UpgradeInstance_FullMethodName     = "/launchstack.container.v1.ContainerService/UpgradeInstance"
+	ContainerService_RollbackInstance_FullMethodName    = "/launchstack.container.v1.ContainerService/RollbackInstance"
+	ContainerService_IsolateInstance_FullMethodName     = "/launchstack.container.v1.ContainerService/IsolateInstance"
+	ContainerService_ListStaleDNSRecords_FullMethodName = "/launchstack.container.v1.ContainerService/ListStaleDNSRecords"
+)
+
+// ContainerServiceClient is the client API for ContainerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ContainerServiceClient interface {
+	// ProvisionInstance creates the resources of a pending instance, streaming each step as it
+	// starts and ends. The last message carries the provisioned instance. Instances with a
+	// provisioning job still queued are left to the job queue.
+	ProvisionInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (ContainerService_ProvisionInstanceClient, error)
+	// DeleteInstance removes the container, volumes and DNS record of an instance
+	DeleteInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// StartInstance starts the container of an instance
+	StartInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// StopInstance stops the container of an instance
+	StopInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// RenameInstance changes the display name of an instance, applying the naming policy
+	RenameInstance(ctx context.Context, in *RenameInstanceRequest, opts ...grpc.CallOption) (*Instance, error)
+	// GetInstanceStats samples the resource usage of an instance's container
+	GetInstanceStats(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*ResourceUsage, error)
+	// GetStorageUsage measures the disk space used by an instance's volumes
+	GetStorageUsage(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*StorageUsage, error)
+	// CheckHealth probes n8n's health endpoint inside an instance's container
+	CheckHealth(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ResizeInstance changes the CPU and memory limits of an instance
+	ResizeInstance(ctx context.Context, in *ResizeInstanceRequest, opts ...grpc.CallOption) (*ResizeInstanceResponse, error)
+	// UpgradeInstance recreates an instance on another n8n image tag, rolling back if it is unhealthy
+	UpgradeInstance(ctx context.Context, in *UpgradeInstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// RollbackInstance recreates an instance on the image it ran before its last upgrade
+	RollbackInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// IsolateInstance moves a running instance from the shared Docker network to its user's network
+	IsolateInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*IsolateInstanceResponse, error)
+	// ListStaleDNSRecords lists DNS records left over from deleted instances or previous containers
+	ListStaleDNSRecords(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListStaleDNSRecordsResponse, error)
+}
+
+type containerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewContainerServiceClient(cc grpc.ClientConnInterface) ContainerServiceClient {
+	return &containerServiceClient{cc}
+}
+
+func (c *containerServiceClient) ProvisionInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (ContainerService_ProvisionInstanceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ContainerService_ServiceDesc.Streams[0], ContainerService_ProvisionInstance_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &containerServiceProvisionInstanceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ContainerService_ProvisionInstanceClient interface {
+	Recv() (*ProvisionInstanceEvent, error)
+	grpc.ClientStream
+}
+
+type containerServiceProvisionInstanceClient struct {
+	grpc.ClientStream
+}
+
+func (x *containerServiceProvisionInstanceClient) Recv() (*ProvisionInstanceEvent, error) {
+	m := new(ProvisionInstanceEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *containerServiceClient) DeleteInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ContainerService_DeleteInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) StartInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ContainerService_StartInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) StopInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ContainerService_StopInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) RenameInstance(ctx context.Context, in *RenameInstanceRequest, opts ...grpc.CallOption) (*Instance, error) {
+	out := new(Instance)
+	err := c.cc.Invoke(ctx, ContainerService_RenameInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) GetInstanceStats(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*ResourceUsage, error) {
+	out := new(ResourceUsage)
+	err := c.cc.Invoke(ctx, ContainerService_GetInstanceStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) GetStorageUsage(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*StorageUsage, error) {
+	out := new(StorageUsage)
+	err := c.cc.Invoke(ctx, ContainerService_GetStorageUsage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) CheckHealth(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ContainerService_CheckHealth_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) ResizeInstance(ctx context.Context, in *ResizeInstanceRequest, opts ...grpc.CallOption) (*ResizeInstanceResponse, error) {
+	out := new(ResizeInstanceResponse)
+	err := c.cc.Invoke(ctx, ContainerService_ResizeInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) UpgradeInstance(ctx context.Context, in *UpgradeInstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ContainerService_UpgradeInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) RollbackInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ContainerService_RollbackInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) IsolateInstance(ctx context.Context, in *InstanceRequest, opts ...grpc.CallOption) (*IsolateInstanceResponse, error) {
+	out := new(IsolateInstanceResponse)
+	err := c.cc.Invoke(ctx, ContainerService_IsolateInstance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *containerServiceClient) ListStaleDNSRecords(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListStaleDNSRecordsResponse, error) {
+	out := new(ListStaleDNSRecordsResponse)
+	err := c.cc.Invoke(ctx, ContainerService_ListStaleDNSRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ContainerServiceServer is the server API for ContainerService service.
+// All implementations must embed UnimplementedContainerServiceServer
+// for forward compatibility
+type ContainerServiceServer interface {
+	// ProvisionInstance creates the resources of a pending instance, streaming each step as it
+	// starts and ends. The last message carries the provisioned instance. Instances with a
+	// provisioning job still queued are left to the job queue.
+	ProvisionInstance(*InstanceRequest, ContainerService_ProvisionInstanceServer) error
+	// DeleteInstance removes the container, volumes and DNS record of an instance
+	DeleteInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error)
+	// StartInstance starts the container of an instance
+	StartInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error)
+	// StopInstance stops the container of an instance
+	StopInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error)
+	// RenameInstance changes the display name of an instance, applying the naming policy
+	RenameInstance(context.Context, *RenameInstanceRequest) (*Instance, error)
+	// GetInstanceStats samples the resource usage of an instance's container
+	GetInstanceStats(context.Context, *InstanceRequest) (*ResourceUsage, error)
+	// GetStorageUsage measures the disk space used by an instance's volumes
+	GetStorageUsage(context.Context, *InstanceRequest) (*StorageUsage, error)
+	// CheckHealth probes n8n's health endpoint inside an instance's container
+	CheckHealth(context.Context, *InstanceRequest) (*emptypb.Empty, error)
+	// ResizeInstance changes the CPU and memory limits of an instance
+	ResizeInstance(context.Context, *ResizeInstanceRequest) (*ResizeInstanceResponse, error)
+	// UpgradeInstance recreates an instance on another n8n image tag, rolling back if it is unhealthy
+	UpgradeInstance(context.Context, *UpgradeInstanceRequest) (*emptypb.Empty, error)
+	// RollbackInstance recreates an instance on the image it ran before its last upgrade
+	RollbackInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error)
+	// IsolateInstance moves a running instance from the shared Docker network to its user's network
+	IsolateInstance(context.Context, *InstanceRequest) (*IsolateInstanceResponse, error)
+	// ListStaleDNSRecords lists DNS records left over from deleted instances or previous containers
+	ListStaleDNSRecords(context.Context, *emptypb.Empty) (*ListStaleDNSRecordsResponse, error)
+	mustEmbedUnimplementedContainerServiceServer()
+}
+
+// UnimplementedContainerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedContainerServiceServer struct {
+}
+
+func (UnimplementedContainerServiceServer) ProvisionInstance(*InstanceRequest, ContainerService_ProvisionInstanceServer) error {
+	return status.Errorf(codes.Unimplemented, "method ProvisionInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) DeleteInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) StartInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) StopInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) RenameInstance(context.Context, *RenameInstanceRequest) (*Instance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) GetInstanceStats(context.Context, *InstanceRequest) (*ResourceUsage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInstanceStats not implemented")
+}
+func (UnimplementedContainerServiceServer) GetStorageUsage(context.Context, *InstanceRequest) (*StorageUsage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStorageUsage not implemented")
+}
+func (UnimplementedContainerServiceServer) CheckHealth(context.Context, *InstanceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckHealth not implemented")
+}
+func (UnimplementedContainerServiceServer) ResizeInstance(context.Context, *ResizeInstanceRequest) (*ResizeInstanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResizeInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) UpgradeInstance(context.Context, *UpgradeInstanceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpgradeInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) RollbackInstance(context.Context, *InstanceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) IsolateInstance(context.Context, *InstanceRequest) (*IsolateInstanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsolateInstance not implemented")
+}
+func (UnimplementedContainerServiceServer) ListStaleDNSRecords(context.Context, *emptypb.Empty) (*ListStaleDNSRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStaleDNSRecords not implemented")
+}
+func (UnimplementedContainerServiceServer) mustEmbedUnimplementedContainerServiceServer() {}
+
+// UnsafeContainerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ContainerServiceServer will
+// result in compilation errors.
+type UnsafeContainerServiceServer interface {
+	mustEmbedUnimplementedContainerServiceServer()
+}
+
+func RegisterContainerServiceServer(s grpc.ServiceRegistrar, srv ContainerServiceServer) {
+	s.RegisterService(&ContainerService_ServiceDesc, srv)
+}
+
+func _ContainerService_ProvisionInstance_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(InstanceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ContainerServiceServer).ProvisionInstance(m, &containerServiceProvisionInstanceServer{stream})
+}
+
+type ContainerService_ProvisionInstanceServer interface {
+	Send(*ProvisionInstanceEvent) error
+	grpc.ServerStream
+}
+
+type containerServiceProvisionInstanceServer struct {
+	grpc.ServerStream
+}
+
+func (x *containerServiceProvisionInstanceServer) Send(m *ProvisionInstanceEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ContainerService_DeleteInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).DeleteInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_DeleteInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).DeleteInstance(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_StartInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).StartInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_StartInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).StartInstance(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_StopInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).StopInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_StopInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).StopInstance(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_RenameInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).RenameInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_RenameInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).RenameInstance(ctx, req.(*RenameInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_GetInstanceStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).GetInstanceStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_GetInstanceStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).GetInstanceStats(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_GetStorageUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).GetStorageUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_GetStorageUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).GetStorageUsage(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_CheckHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).CheckHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_CheckHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).CheckHealth(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_ResizeInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResizeInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).ResizeInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_ResizeInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).ResizeInstance(ctx, req.(*ResizeInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_UpgradeInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpgradeInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).UpgradeInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_UpgradeInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).UpgradeInstance(ctx, req.(*UpgradeInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_RollbackInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).RollbackInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_RollbackInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).RollbackInstance(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_IsolateInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).IsolateInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_IsolateInstance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).IsolateInstance(ctx, req.(*InstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ContainerService_ListStaleDNSRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ContainerServiceServer).ListStaleDNSRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ContainerService_ListStaleDNSRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ContainerServiceServer).ListStaleDNSRecords(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ContainerService_ServiceDesc is the grpc.ServiceDesc for ContainerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ContainerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "launchstack.container.v1.ContainerService",
+	HandlerType: (*ContainerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DeleteInstance",
+			Handler:    _ContainerService_DeleteInstance_Handler,
+		},
+		{
+			MethodName: "StartInstance",
+			Handler:    _ContainerService_StartInstance_Handler,
+		},
+		{
+			MethodName: "StopInstance",
+			Handler:    _ContainerService_StopInstance_Handler,
+		},
+		{
+			MethodName: "RenameInstance",
+			Handler:    _ContainerService_RenameInstance_Handler,
+		},
+		{
+			MethodName: "GetInstanceStats",
+			Handler:    _ContainerService_GetInstanceStats_Handler,
+		},
+		{
+			MethodName: "GetStorageUsage",
+			Handler:    _ContainerService_GetStorageUsage_Handler,
+		},
+		{
+			MethodName: "CheckHealth",
+			Handler:    _ContainerService_CheckHealth_Handler,
+		},
+		{
+			MethodName: "ResizeInstance",
+			Handler:    _ContainerService_ResizeInstance_Handler,
+		},
+		{
+			MethodName: "UpgradeInstance",
+			Handler:    _ContainerService_UpgradeInstance_Handler,
+		},
+		{
+			MethodName: "RollbackInstance",
+			Handler:    _ContainerService_RollbackInstance_Handler,
+		},
+		{
+			MethodName: "IsolateInstance",
+			Handler:    _ContainerService_IsolateInstance_Handler,
+		},
+		{
+			MethodName: "ListStaleDNSRecords",
+			Handler:    _ContainerService_ListStaleDNSRecords_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ProvisionInstance",
+			Handler:       _ContainerService_ProvisionInstance_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "container.proto",
+}
@@ -0,0 +1,152 @@
+// Package grpcapi serves the container operations of instances over gRPC for internal clients,
+// such as worker nodes or an operator CLI, on a port of its own and outside the REST API's
+// authentication. Clients authenticate with certificates signed by GRPC_TLS_CLIENT_CA.
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/grpcapi/containerpb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the internal container API
+type Server struct {
+	containerpb.UnimplementedContainerServiceServer
+	manager container.Manager
+	config  *config.Config
+	logger  *logrus.Logger
+	server  *grpc.Server
+}
+
+// NewServer creates a new gRPC server for the container API, loading its TLS certificates
+// unless GRPC_INSECURE is set
+func NewServer(manager container.Manager, cfg *config.Config, logger *logrus.Logger) (*Server, error) {
+	s := &Server{
+		manager: manager,
+		config:  cfg,
+		logger:  logger,
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.logUnary),
+		grpc.ChainStreamInterceptor(s.logStream),
+	}
+	if cfg.GRPC.Insecure {
+		logger.Warn("Internal gRPC API is served without TLS; any client that can reach its port can manage containers")
+	} else {
+		tlsConfig, err := serverTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s.server = grpc.NewServer(opts...)
+	containerpb.RegisterContainerServiceServer(s.server, s)
+	return s, nil
+}
+
+// serverTLSConfig builds a TLS configuration that requires client certificates signed by the
+// configured client CA
+func serverTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(cfg.GRPC.TLSCert, cfg.GRPC.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC server certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(cfg.GRPC.TLSClientCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gRPC client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("gRPC client CA %s contains no certificates", cfg.GRPC.TLSClientCA)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ListenAndServe serves the API on the configured port until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.config.GRPC.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+	s.logger.Infof("Starting internal gRPC API on port %d...", s.config.GRPC.Port)
+	return s.server.Serve(listener)
+}
+
+// Shutdown stops accepting calls and waits for running ones to finish, cancelling them when
+// the context ends first
+func (s *Server) Shutdown(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+// logUnary logs every unary call with its client, duration and status code
+func (s *Server) logUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// logStream logs every streaming call with its client, duration and status code
+func (s *Server) logStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	s.logCall(stream.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func (s *Server) logCall(ctx context.Context, method string, start time.Time, err error) {
+	entry := s.logger.WithFields(logrus.Fields{
+		"method":   method,
+		"client":   clientName(ctx),
+		"code":     status.Code(err).String(),
+		"duration": time.Since(start),
+	})
+	if err != nil {
+		entry.WithError(err).Warn("gRPC call failed")
+		return
+	}
+	entry.Info("gRPC call")
+}
+
+// clientName returns the common name of the client certificate of a call, or its address
+// when it has none
+func clientName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+		return info.State.PeerCertificates[0].Subject.CommonName
+	}
+	return p.Addr.String()
+}
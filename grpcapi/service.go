@@ -0,0 +1,273 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/grpcapi/containerpb"
+	"github.com/launchstack/backend/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// ProvisionInstance provisions a pending instance, or one whose provisioning failed, and
+// records the outcome on the instance like the job queue does
+func (s *Server) ProvisionInstance(req *containerpb.InstanceRequest, stream containerpb.ContainerService_ProvisionInstanceServer) error {
+	instanceID, err := parseInstanceID(req.InstanceId)
+	if err != nil {
+		return err
+	}
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return statusError(err)
+	}
+	if instance.Status != models.StatusPending && instance.Status != models.StatusError {
+		return status.Errorf(codes.FailedPrecondition, "instance is %s, not pending", instance.Status)
+	}
+	if job, err := db.GetProvisioningJobByInstanceID(instance.ID); err == nil && !job.IsFinished() {
+		return status.Error(codes.FailedPrecondition, "instance is being provisioned by the job queue")
+	}
+	user, err := db.GetUserByID(instance.UserID)
+	if err != nil {
+		return statusError(err)
+	}
+
+	ctx, cancel := context.WithTimeout(stream.Context(), s.config.Provisioning.Timeout)
+	defer cancel()
+	if err := s.manager.ProvisionInstance(ctx, user, instance, &streamTracker{stream: stream}); err != nil {
+		instance.Status = models.StatusError
+		if err := db.UpdateInstance(instance); err != nil {
+			s.logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to save instance after failed provisioning")
+		}
+		return statusError(err)
+	}
+
+	instance.Status = models.StatusRunning
+	if err := db.UpdateInstance(instance); err != nil {
+		return statusError(err)
+	}
+	return stream.Send(&containerpb.ProvisionInstanceEvent{
+		Event: &containerpb.ProvisionInstanceEvent_Instance{Instance: instanceMessage(instance)},
+	})
+}
+
+// streamTracker sends provisioning steps to the client as they start and end
+type streamTracker struct {
+	stream containerpb.ContainerService_ProvisionInstanceServer
+}
+
+// StepStarted sends the start of a step
+func (t *streamTracker) StepStarted(name models.ProvisioningStepName) {
+	t.send(&containerpb.ProvisioningStep{Name: string(name), Status: containerpb.ProvisioningStep_STATUS_STARTED})
+}
+
+// StepFinished sends the end of a step with its error, if it failed
+func (t *streamTracker) StepFinished(name models.ProvisioningStepName, err error) {
+	step := &containerpb.ProvisioningStep{Name: string(name), Status: containerpb.ProvisioningStep_STATUS_SUCCEEDED}
+	if err != nil {
+		step.Status = containerpb.ProvisioningStep_STATUS_FAILED
+		step.Error = err.Error()
+	}
+	t.send(step)
+}
+
+// send drops steps the client can no longer receive, as provisioning goes on without it
+func (t *streamTracker) send(step *containerpb.ProvisioningStep) {
+	_ = t.stream.Send(&containerpb.ProvisionInstanceEvent{
+		Event: &containerpb.ProvisionInstanceEvent_Step{Step: step},
+	})
+}
+
+// DeleteInstance removes the resources of an instance
+func (s *Server) DeleteInstance(ctx context.Context, req *containerpb.InstanceRequest) (*emptypb.Empty, error) {
+	return s.instanceOperation(ctx, req.InstanceId, s.manager.DeleteInstance)
+}
+
+// StartInstance starts an instance
+func (s *Server) StartInstance(ctx context.Context, req *containerpb.InstanceRequest) (*emptypb.Empty, error) {
+	return s.instanceOperation(ctx, req.InstanceId, s.manager.StartInstance)
+}
+
+// StopInstance stops an instance
+func (s *Server) StopInstance(ctx context.Context, req *containerpb.InstanceRequest) (*emptypb.Empty, error) {
+	return s.instanceOperation(ctx, req.InstanceId, s.manager.StopInstance)
+}
+
+// CheckHealth probes the health endpoint of an instance
+func (s *Server) CheckHealth(ctx context.Context, req *containerpb.InstanceRequest) (*emptypb.Empty, error) {
+	return s.instanceOperation(ctx, req.InstanceId, s.manager.CheckHealth)
+}
+
+// RollbackInstance returns an instance to the image it ran before its last upgrade
+func (s *Server) RollbackInstance(ctx context.Context, req *containerpb.InstanceRequest) (*emptypb.Empty, error) {
+	return s.instanceOperation(ctx, req.InstanceId, s.manager.RollbackInstance)
+}
+
+// UpgradeInstance moves an instance to another n8n image tag
+func (s *Server) UpgradeInstance(ctx context.Context, req *containerpb.UpgradeInstanceRequest) (*emptypb.Empty, error) {
+	if !models.ValidImageTag(req.ImageTag) {
+		return nil, status.Error(codes.InvalidArgument, "invalid image tag")
+	}
+	return s.instanceOperation(ctx, req.InstanceId, func(ctx context.Context, instanceID uuid.UUID) error {
+		return s.manager.UpgradeInstance(ctx, instanceID, req.ImageTag)
+	})
+}
+
+// instanceOperation runs an operation on the instance with the given ID
+func (s *Server) instanceOperation(ctx context.Context, id string, operation func(context.Context, uuid.UUID) error) (*emptypb.Empty, error) {
+	instanceID, err := parseInstanceID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := operation(ctx, instanceID); err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// RenameInstance changes the display name of an instance
+func (s *Server) RenameInstance(ctx context.Context, req *containerpb.RenameInstanceRequest) (*containerpb.Instance, error) {
+	instanceID, err := parseInstanceID(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.ValidateInstanceName(req.Name); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	instance, err := s.manager.RenameInstance(ctx, instanceID, req.Name)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return instanceMessage(instance), nil
+}
+
+// GetInstanceStats samples the resource usage of an instance
+func (s *Server) GetInstanceStats(ctx context.Context, req *containerpb.InstanceRequest) (*containerpb.ResourceUsage, error) {
+	instanceID, err := parseInstanceID(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.manager.GetInstanceStats(ctx, instanceID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &containerpb.ResourceUsage{
+		CpuUsage:         usage.CPUUsage,
+		MemoryUsage:      usage.MemoryUsage,
+		MemoryLimit:      usage.MemoryLimit,
+		MemoryPercentage: usage.MemoryPercentage,
+		DiskUsage:        usage.DiskUsage,
+		NetworkIn:        usage.NetworkIn,
+		NetworkOut:       usage.NetworkOut,
+		Timestamp:        timestamppb.New(usage.Timestamp),
+	}, nil
+}
+
+// GetStorageUsage measures the disk space used by an instance
+func (s *Server) GetStorageUsage(ctx context.Context, req *containerpb.InstanceRequest) (*containerpb.StorageUsage, error) {
+	instanceID, err := parseInstanceID(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := s.manager.GetStorageUsage(ctx, instanceID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &containerpb.StorageUsage{Bytes: bytes}, nil
+}
+
+// ResizeInstance changes the CPU and memory limits of an instance
+func (s *Server) ResizeInstance(ctx context.Context, req *containerpb.ResizeInstanceRequest) (*containerpb.ResizeInstanceResponse, error) {
+	instanceID, err := parseInstanceID(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	if req.CpuLimit <= 0 || req.MemoryLimitMb <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "cpu_limit and memory_limit_mb must be positive")
+	}
+	recreated, err := s.manager.ResizeInstance(ctx, instanceID, req.CpuLimit, int(req.MemoryLimitMb))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &containerpb.ResizeInstanceResponse{Recreated: recreated}, nil
+}
+
+// IsolateInstance moves an instance to its user's network
+func (s *Server) IsolateInstance(ctx context.Context, req *containerpb.InstanceRequest) (*containerpb.IsolateInstanceResponse, error) {
+	instanceID, err := parseInstanceID(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	moved, err := s.manager.IsolateInstance(ctx, instanceID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &containerpb.IsolateInstanceResponse{Moved: moved}, nil
+}
+
+// ListStaleDNSRecords lists leftover DNS records
+func (s *Server) ListStaleDNSRecords(ctx context.Context, _ *emptypb.Empty) (*containerpb.ListStaleDNSRecordsResponse, error) {
+	records, err := s.manager.StaleDNSRecords(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &containerpb.ListStaleDNSRecordsResponse{Records: make([]*containerpb.DNSRecord, len(records))}
+	for i, record := range records {
+		response.Records[i] = &containerpb.DNSRecord{Name: record.Name, Answer: record.Answer}
+	}
+	return response, nil
+}
+
+// parseInstanceID parses the instance ID of a request
+func parseInstanceID(id string) (uuid.UUID, error) {
+	instanceID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid instance ID")
+	}
+	return instanceID, nil
+}
+
+// statusError maps the errors of container operations to gRPC status codes. Clients are
+// trusted, so the error messages are passed on as they are.
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, container.ErrDuplicateInstanceName):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, container.ErrHostAtCapacity):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, container.ErrDNSListingUnsupported):
+		return status.Error(codes.Unimplemented, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// instanceMessage converts an instance to its protobuf message
+func instanceMessage(instance *models.Instance) *containerpb.Instance {
+	return &containerpb.Instance{
+		Id:            instance.ID.String(),
+		UserId:        instance.UserID.String(),
+		Name:          instance.Name,
+		Status:        string(instance.Status),
+		Url:           instance.URL,
+		ContainerId:   instance.ContainerID,
+		IpAddress:     instance.IPAddress,
+		ImageTag:      instance.ImageTag,
+		CpuLimit:      instance.CPULimit,
+		MemoryLimitMb: int32(instance.MemoryLimit),
+		CreatedAt:     timestamppb.New(instance.CreatedAt),
+		UpdatedAt:     timestamppb.New(instance.UpdatedAt),
+	}
+}
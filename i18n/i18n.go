@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale is a language user-facing messages are available in
+type Locale string
+
+const (
+	English Locale = "en"
+	Hindi   Locale = "hi"
+)
+
+// Default is used when no supported locale is requested, and for messages missing from a catalog
+const Default = English
+
+// catalogs holds the messages of each supported locale keyed by message code
+var catalogs = map[Locale]map[string]string{
+	English: english,
+	Hindi:   hindi,
+}
+
+// Locales returns the supported locales
+func Locales() []Locale {
+	return []Locale{English, Hindi}
+}
+
+// Parse returns the supported locale of a language tag such as "hi" or "hi-IN"
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := catalogs[Locale(tag)]; ok {
+		return Locale(tag), true
+	}
+	return "", false
+}
+
+// Negotiate picks the supported locale the client prefers most from an Accept-Language
+// header, falling back to the default locale
+func Negotiate(acceptLanguage string) Locale {
+	type candidate struct {
+		locale Locale
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		locale, ok := Parse(fields[0])
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{locale, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// Message returns the message with the given code in a locale, formatted with args. Messages
+// missing from the locale's catalog are returned in the default locale, and unknown codes
+// are returned as is.
+func Message(locale Locale, code string, args ...interface{}) string {
+	format, ok := catalogs[locale][code]
+	if !ok {
+		if format, ok = catalogs[Default][code]; !ok {
+			return code
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
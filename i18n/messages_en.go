@@ -0,0 +1,79 @@
+package i18n
+
+// english is the message catalog every other locale falls back to
+var english = map[string]string{
+	// Errors
+	"unauthorized":                     "Unauthorized",
+	"user_not_authenticated":           "User not authenticated",
+	"user_not_found":                   "User not found",
+	"access_denied":                    "Access denied",
+	"admin_required":                   "Admin access required",
+	"reseller_required":                "Reseller access required",
+	"invalid_request_body":             "Invalid request body",
+	"invalid_request_format":           "Invalid request format",
+	"internal_error":                   "Internal server error",
+	"route_not_found":                  "No API route matches this path",
+	"request_too_large":                "The request body is larger than the %d KB allowed here",
+	"unsupported_media_type":           "The request body must be JSON (Content-Type: application/json)",
+	"unsupported_language":             "Unsupported language",
+	"invalid_instance_id":              "Invalid instance ID",
+	"instance_not_found":               "Instance not found",
+	"instance_access_denied":           "You don't have permission to access this instance",
+	"instance_suspended":               "Instance is suspended",
+	"instance_trashed":                 "Instance is in the trash; restore it first",
+	"instance_not_trashed":             "Instance is not in the trash",
+	"instance_not_sleeping":            "Instance is not sleeping",
+	"instance_limit_reached":           "Instance limit reached",
+	"instance_name_taken":              "An instance with this name already exists",
+	"instance_name_required":           "Instance name is required",
+	"instance_name_too_short":          "Instance name must be at least %d characters",
+	"instance_name_too_long":           "Instance name must be at most %d characters",
+	"instance_name_invalid_characters": "Instance name may only contain letters, digits, spaces, hyphens, underscores and periods",
+	"instance_name_no_alphanumeric":    "Instance name must contain at least one letter or digit",
+	"instance_name_reserved":           "Instance name %q is reserved",
+	"instance_name_blocked":            "Instance name contains a word that is not allowed",
+	"instance_subdomain_taken":         "No free subdomain could be reserved for the instance, please try again",
+	"unknown_service_type":             "This service is not offered; see GET /api/v1/templates",
+	"service_unsupported":              "The instance's service does not support this",
+	"host_at_capacity":                 "No capacity is available for new instances right now, please try again later",
+	"provisioning_not_failed":          "Provisioning of this instance has not failed",
+	"provisioning_failed":              "The instance could not be created, please try again",
+	"project_not_found":                "Project not found",
+	"job_not_found":                    "Job not found",
+	"api_key_not_found":                "API key not found",
+	"webhook_endpoint_not_found":       "Webhook endpoint not found",
+	"waitlist_entry_not_found":         "Waitlist entry not found",
+	"feature_not_in_plan":              "Your plan does not include this feature",
+	"subscription_inactive":            "Your subscription is not active",
+	"payment_required":                 "A payment is due; settle it to continue using this instance",
+	"spending_cap_reached":             "Your usage this month reached your spending cap",
+	"organization_not_found":           "Organization not found",
+	"org_role_insufficient":            "This requires the %s role in the organization",
+	"org_last_owner":                   "The organization must keep at least one owner",
+	"organization_has_instances":       "Delete the organization's instances or move them out of it first",
+	"org_member_not_found":             "Member not found in this organization",
+	"org_invitation_not_found":         "Invitation not found",
+	"org_invitation_not_pending":       "This invitation was already accepted or has expired",
+	"invalid_org_role":                 "Role must be one of owner, admin, member or viewer",
+	"org_managed_by_clerk":             "Members of this organization are managed in Clerk",
+	"share_access_insufficient":        "Your access to this instance does not allow this",
+	"share_owner_only":                 "Only the instance's owner can share it",
+	"invalid_share_access":             "Access must be read_only or operator",
+	"share_user_not_found":             "No registered user has this email address",
+	"share_with_self":                  "You cannot share an instance with its owner",
+	"share_not_found":                  "This instance is not shared with that user",
+	"tls_domain_not_managed":           "This domain is not served by any instance",
+	"cors_origin_denied":               "Requests from this origin are not allowed",
+	"subscription_sync_running":        "A subscription sync is already running",
+	"invalid_payment_id":               "Invalid payment ID",
+	"payment_not_found":                "Payment not found",
+	"payment_not_completed":            "Invoices are only issued for completed payments",
+
+	// Notifications
+	"notification.waitlist_provisioned.subject": "Your instance %s is ready",
+	"notification.waitlist_provisioned.body":    "Capacity freed up and your waitlisted instance %s has been created. It will be available in a few minutes.",
+	"notification.waitlist_reserved.subject":    "Capacity is reserved for your instance %s",
+	"notification.waitlist_reserved.body":       "Capacity for your waitlisted instance %s is reserved for you until %s. Claim it from your dashboard before then.",
+	"notification.waitlist_failed.subject":      "Your instance %s could not be created",
+	"notification.waitlist_failed.body":         "We could not create your waitlisted instance %s. Please try again from your dashboard.",
+}
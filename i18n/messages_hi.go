@@ -0,0 +1,79 @@
+package i18n
+
+// hindi is the Hindi message catalog
+var hindi = map[string]string{
+	// Errors
+	"unauthorized":                     "अनधिकृत",
+	"user_not_authenticated":           "उपयोगकर्ता प्रमाणित नहीं है",
+	"user_not_found":                   "उपयोगकर्ता नहीं मिला",
+	"access_denied":                    "पहुँच अस्वीकृत",
+	"admin_required":                   "एडमिन पहुँच आवश्यक है",
+	"reseller_required":                "रीसेलर पहुँच आवश्यक है",
+	"invalid_request_body":             "अनुरोध का मुख्य भाग अमान्य है",
+	"invalid_request_format":           "अनुरोध का प्रारूप अमान्य है",
+	"internal_error":                   "आंतरिक सर्वर त्रुटि",
+	"route_not_found":                  "इस पथ से कोई API रूट मेल नहीं खाता",
+	"request_too_large":                "अनुरोध का बॉडी यहाँ अनुमत %d KB से बड़ा है",
+	"unsupported_media_type":           "अनुरोध का बॉडी JSON होना चाहिए (Content-Type: application/json)",
+	"unsupported_language":             "यह भाषा समर्थित नहीं है",
+	"invalid_instance_id":              "इंस्टेंस आईडी अमान्य है",
+	"instance_not_found":               "इंस्टेंस नहीं मिला",
+	"instance_access_denied":           "आपको इस इंस्टेंस तक पहुँचने की अनुमति नहीं है",
+	"instance_suspended":               "इंस्टेंस निलंबित है",
+	"instance_trashed":                 "इंस्टेंस ट्रैश में है; पहले इसे पुनर्स्थापित करें",
+	"instance_not_trashed":             "इंस्टेंस ट्रैश में नहीं है",
+	"instance_not_sleeping":            "इंस्टेंस स्लीप मोड में नहीं है",
+	"instance_limit_reached":           "इंस्टेंस की सीमा पूरी हो गई है",
+	"instance_name_taken":              "इस नाम का इंस्टेंस पहले से मौजूद है",
+	"instance_name_required":           "इंस्टेंस का नाम आवश्यक है",
+	"instance_name_too_short":          "इंस्टेंस का नाम कम से कम %d अक्षरों का होना चाहिए",
+	"instance_name_too_long":           "इंस्टेंस का नाम अधिकतम %d अक्षरों का हो सकता है",
+	"instance_name_invalid_characters": "इंस्टेंस के नाम में केवल अक्षर, अंक, स्पेस, हाइफ़न, अंडरस्कोर और पूर्णविराम हो सकते हैं",
+	"instance_name_no_alphanumeric":    "इंस्टेंस के नाम में कम से कम एक अक्षर या अंक होना चाहिए",
+	"instance_name_reserved":           "इंस्टेंस का नाम %q आरक्षित है",
+	"instance_name_blocked":            "इंस्टेंस के नाम में ऐसा शब्द है जिसकी अनुमति नहीं है",
+	"instance_subdomain_taken":         "इंस्टेंस के लिए कोई खाली सबडोमेन आरक्षित नहीं किया जा सका, कृपया फिर से प्रयास करें",
+	"unknown_service_type":             "यह सेवा उपलब्ध नहीं है; GET /api/v1/templates देखें",
+	"service_unsupported":              "इंस्टेंस की सेवा इसका समर्थन नहीं करती",
+	"host_at_capacity":                 "अभी नए इंस्टेंस के लिए क्षमता उपलब्ध नहीं है, कृपया बाद में फिर से प्रयास करें",
+	"provisioning_not_failed":          "इस इंस्टेंस की प्रोविज़निंग विफल नहीं हुई है",
+	"provisioning_failed":              "इंस्टेंस नहीं बनाया जा सका, कृपया फिर से प्रयास करें",
+	"project_not_found":                "प्रोजेक्ट नहीं मिला",
+	"job_not_found":                    "जॉब नहीं मिला",
+	"api_key_not_found":                "API कुंजी नहीं मिली",
+	"webhook_endpoint_not_found":       "वेबहुक एंडपॉइंट नहीं मिला",
+	"waitlist_entry_not_found":         "प्रतीक्षा सूची की प्रविष्टि नहीं मिली",
+	"feature_not_in_plan":              "आपके प्लान में यह सुविधा शामिल नहीं है",
+	"subscription_inactive":            "आपकी सदस्यता सक्रिय नहीं है",
+	"payment_required":                 "भुगतान बकाया है; इस इंस्टेंस का उपयोग जारी रखने के लिए इसे चुकाएँ",
+	"spending_cap_reached":             "इस महीने आपका उपयोग आपकी खर्च सीमा तक पहुँच गया है",
+	"organization_not_found":           "संगठन नहीं मिला",
+	"org_role_insufficient":            "इसके लिए संगठन में %s भूमिका आवश्यक है",
+	"org_last_owner":                   "संगठन में कम से कम एक मालिक होना चाहिए",
+	"organization_has_instances":       "पहले संगठन के इंस्टेंस हटाएँ या उन्हें संगठन से बाहर ले जाएँ",
+	"org_member_not_found":             "इस संगठन में सदस्य नहीं मिला",
+	"org_invitation_not_found":         "आमंत्रण नहीं मिला",
+	"org_invitation_not_pending":       "यह आमंत्रण पहले ही स्वीकार किया जा चुका है या समाप्त हो गया है",
+	"invalid_org_role":                 "भूमिका owner, admin, member या viewer में से एक होनी चाहिए",
+	"org_managed_by_clerk":             "इस संगठन के सदस्य Clerk में प्रबंधित होते हैं",
+	"share_access_insufficient":        "इस इंस्टेंस तक आपकी पहुँच इसकी अनुमति नहीं देती",
+	"share_owner_only":                 "केवल इंस्टेंस का मालिक ही इसे साझा कर सकता है",
+	"invalid_share_access":             "पहुँच read_only या operator होनी चाहिए",
+	"share_user_not_found":             "इस ईमेल पते वाला कोई पंजीकृत उपयोगकर्ता नहीं है",
+	"share_with_self":                  "आप किसी इंस्टेंस को उसके मालिक के साथ साझा नहीं कर सकते",
+	"share_not_found":                  "यह इंस्टेंस उस उपयोगकर्ता के साथ साझा नहीं है",
+	"tls_domain_not_managed":           "यह डोमेन किसी भी इंस्टेंस द्वारा उपयोग नहीं किया जाता",
+	"cors_origin_denied":               "इस ऑरिजिन से अनुरोधों की अनुमति नहीं है",
+	"subscription_sync_running":        "सब्सक्रिप्शन सिंक पहले से चल रहा है",
+	"invalid_payment_id":               "अमान्य भुगतान आईडी",
+	"payment_not_found":                "भुगतान नहीं मिला",
+	"payment_not_completed":            "चालान केवल पूर्ण भुगतानों के लिए जारी किए जाते हैं",
+
+	// Notifications
+	"notification.waitlist_provisioned.subject": "आपका इंस्टेंस %s तैयार है",
+	"notification.waitlist_provisioned.body":    "क्षमता उपलब्ध हो गई है और प्रतीक्षा सूची में मौजूद आपका इंस्टेंस %s बना दिया गया है। यह कुछ ही मिनटों में उपलब्ध होगा।",
+	"notification.waitlist_reserved.subject":    "आपके इंस्टेंस %s के लिए क्षमता आरक्षित है",
+	"notification.waitlist_reserved.body":       "प्रतीक्षा सूची में मौजूद आपके इंस्टेंस %s के लिए क्षमता %s तक आपके लिए आरक्षित है। उससे पहले इसे अपने डैशबोर्ड से प्राप्त करें।",
+	"notification.waitlist_failed.subject":      "आपका इंस्टेंस %s नहीं बनाया जा सका",
+	"notification.waitlist_failed.body":         "हम प्रतीक्षा सूची में मौजूद आपका इंस्टेंस %s नहीं बना सके। कृपया अपने डैशबोर्ड से फिर से प्रयास करें।",
+}
@@ -0,0 +1,110 @@
+// Package invoice renders the invoices of payments as PDF documents
+package invoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/launchstack/backend/models"
+)
+
+// Layout of the page, in points
+const (
+	marginLeft  = 56
+	marginRight = pageWidth - 56
+)
+
+// Render renders an invoice issued by the seller of the branding as a single-page PDF. Refunded
+// invoices are marked as such, so the document doubles as a receipt of what was paid.
+func Render(invoice *models.Invoice, seller models.Branding, status models.PaymentStatus) []byte {
+	p := &page{}
+
+	y := float64(pageHeight - 72)
+	p.text(fontBold, 20, marginLeft, y, seller.ProductName)
+	p.textRight(fontBold, 20, marginRight, y, "INVOICE")
+	y -= 18
+	var contact []string
+	for _, value := range []string{seller.Domain, seller.SupportEmail} {
+		if value != "" {
+			contact = append(contact, value)
+		}
+	}
+	p.text(fontRegular, 10, marginLeft, y, strings.Join(contact, "  |  "))
+
+	y -= 48
+	p.text(fontBold, 10, marginLeft, y, "Bill to")
+	p.text(fontBold, 10, 340, y, "Invoice number")
+	p.textRight(fontRegular, 10, marginRight, y, invoice.Number)
+	y -= 15
+	p.text(fontRegular, 10, marginLeft, y, invoice.BillingName)
+	p.text(fontBold, 10, 340, y, "Date")
+	p.textRight(fontRegular, 10, marginRight, y, invoice.IssuedAt.Format("January 2, 2006"))
+	y -= 15
+	p.text(fontRegular, 10, marginLeft, y, invoice.BillingEmail)
+	p.text(fontBold, 10, 340, y, "Status")
+	p.textRight(fontRegular, 10, marginRight, y, statusLabel(status))
+
+	y -= 48
+	p.text(fontBold, 10, marginLeft, y, "Description")
+	p.textRight(fontBold, 10, marginRight, y, "Amount")
+	y -= 8
+	p.line(marginLeft, y, marginRight, y)
+	y -= 18
+	p.text(fontRegular, 10, marginLeft, y, invoice.Description)
+	p.textRight(fontRegular, 10, marginRight, y, formatAmount(invoice.Amount, invoice.Currency))
+	y -= 10
+	p.line(marginLeft, y, marginRight, y)
+	y -= 20
+	p.text(fontBold, 11, 340, y, "Total")
+	p.textRight(fontBold, 11, marginRight, y, formatAmount(invoice.Amount, invoice.Currency))
+	if status == models.PaymentStatusRefunded {
+		y -= 16
+		p.text(fontRegular, 10, 340, y, "Refunded")
+		p.textRight(fontRegular, 10, marginRight, y, formatAmount(-invoice.Amount, invoice.Currency))
+	}
+
+	y -= 48
+	if invoice.Provider != "" {
+		p.text(fontRegular, 9, marginLeft, y, fmt.Sprintf("Paid with %s. Payment %s.", providerLabel(invoice.Provider), invoice.PaymentID))
+		y -= 13
+	}
+	if seller.SupportEmail != "" {
+		p.text(fontRegular, 9, marginLeft, y, "Questions about this invoice? Contact "+seller.SupportEmail+".")
+	}
+
+	return p.pdf()
+}
+
+// Filename is the name an invoice is downloaded as
+func Filename(invoice *models.Invoice) string {
+	return invoice.Number + ".pdf"
+}
+
+// formatAmount formats an amount in cents with its currency, e.g. 29.00 USD
+func formatAmount(cents int, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, cents/100, cents%100, strings.ToUpper(currency))
+}
+
+// statusLabel describes the payment status of an invoice
+func statusLabel(status models.PaymentStatus) string {
+	if status == models.PaymentStatusRefunded {
+		return "Refunded"
+	}
+	return "Paid"
+}
+
+// providerLabel names a payment provider
+func providerLabel(provider string) string {
+	switch provider {
+	case "paypal":
+		return "PayPal"
+	case "stripe":
+		return "Stripe"
+	}
+	return provider
+}
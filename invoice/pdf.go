@@ -0,0 +1,94 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	pageWidth  = 595
+	pageHeight = 842
+)
+
+// Fonts of the page, by resource name. Both are standard PDF fonts, so no font is embedded.
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// page draws text and lines on a single PDF page
+type page struct {
+	content bytes.Buffer
+}
+
+// text draws a line of text with its baseline starting at x, y
+func (p *page) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, x, y, escapeText(s))
+}
+
+// textRight draws a line of text ending at x. Widths are estimated from the average width of
+// Helvetica's characters, which is close enough to align amounts.
+func (p *page) textRight(font string, size, x, y float64, s string) {
+	width := 0.5 * size * float64(len(encodeText(s)))
+	if font == fontBold {
+		width *= 1.05
+	}
+	p.text(font, size, x-width, y, s)
+}
+
+// line draws a thin gray line from x1, y1 to x2, y2
+func (p *page) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.8 G 0.5 w %.1f %.1f m %.1f %.1f l S 0 G\n", x1, y1, x2, y2)
+}
+
+// pdf returns the page as a PDF document
+func (p *page) pdf() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 5 0 R /%s 6 0 R >> >> /Contents 4 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// encodeText converts text to the Latin-1 subset of WinAnsiEncoding, replacing characters
+// the standard fonts cannot show with "?"
+func encodeText(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// escapeText encodes text for a PDF string literal
+func escapeText(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	return replacer.Replace(string(encodeText(s)))
+}
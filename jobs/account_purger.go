@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// accountPurgeBatch limits the accounts purged per run
+const accountPurgeBatch = 50
+
+// AccountPurger deletes the data of deleted accounts for good once their grace period ends:
+// their backup archives in object storage and every row of theirs and of their instances,
+// except their billing journal entries
+type AccountPurger struct {
+	store  storage.ObjectStore
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewAccountPurger creates a new account purger
+func NewAccountPurger(store storage.ObjectStore, cfg *config.Config, logger *logrus.Logger) *AccountPurger {
+	return &AccountPurger{
+		store:  store,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Start purges deleted accounts on the configured interval until the context is cancelled
+func (p *AccountPurger) Start(ctx context.Context) {
+	interval := p.config.AccountDeletion.PurgeInterval
+	p.logger.Infof("Starting account purger every %v for accounts deleted %v ago", interval, p.config.AccountDeletion.GracePeriod)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("account_purger", interval)
+	singleton := lease.NewSingleton("account_purger", interval, p.config, p.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { p.Purge(ctx) })
+			}
+		}
+	}
+}
+
+// Purge deletes the data of accounts whose grace period ended. Accounts whose instances are
+// still being deleted wait for a later run.
+func (p *AccountPurger) Purge(ctx context.Context) {
+	users, err := db.GetPurgeableUsers(time.Now().Add(-p.config.AccountDeletion.GracePeriod), accountPurgeBatch)
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to get deleted accounts to purge")
+		return
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return
+		}
+		logger := p.logger.WithField("user_id", user.ID)
+		if err := p.deleteBackups(ctx, user.ID); err != nil {
+			logger.WithError(err).Error("Failed to remove backups of deleted account")
+			continue
+		}
+		if err := db.PurgeUser(user.ID); err != nil {
+			logger.WithError(err).Error("Failed to purge deleted account")
+			continue
+		}
+		logger.Info("Purged data of deleted account after its grace period")
+	}
+}
+
+// deleteBackups removes the backup archives of an account from object storage. Archives kept
+// in a store that is no longer configured cannot be reached and are left behind.
+func (p *AccountPurger) deleteBackups(ctx context.Context, userID uuid.UUID) error {
+	backups, err := db.GetStoredBackupsByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		if backup.Target != p.store.Name() {
+			p.logger.WithFields(logrus.Fields{"backup_id": backup.ID, "target": backup.Target}).Warn("Backup of deleted account is in a store that is no longer configured, leaving it")
+			continue
+		}
+		if err := p.store.Delete(ctx, backup.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/launchstack/backend/backups"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Backups archives instance volumes to object storage on the job queue, runs the
+// backup schedules of instances and removes scheduled backups beyond their retention
+type Backups struct {
+	queue   *Queue
+	manager container.Manager
+	store   storage.ObjectStore
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewBackups creates the backup runner and registers its job handler
+func NewBackups(queue *Queue, manager container.Manager, store storage.ObjectStore, cfg *config.Config, logger *logrus.Logger) *Backups {
+	b := &Backups{
+		queue:   queue,
+		manager: manager,
+		store:   store,
+		config:  cfg,
+		logger:  logger,
+	}
+	queue.Register(models.JobBackupInstance, b.backup)
+	return b
+}
+
+// Create records a backup of an instance and queues the job running it
+func (b *Backups) Create(instance *models.Instance, trigger models.BackupTrigger) (*models.Backup, error) {
+	backup := &models.Backup{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Trigger:    trigger,
+		Status:     models.BackupQueued,
+		Target:     b.store.Name(),
+	}
+	if err := db.CreateBackup(backup); err != nil {
+		return nil, err
+	}
+
+	job, err := models.NewInstanceJob(models.JobBackupInstance, instance, models.BackupPayload{BackupID: backup.ID})
+	if err == nil {
+		err = b.queue.Enqueue(job)
+	}
+	if err != nil {
+		b.finish(backup, fmt.Errorf("failed to queue backup: %w", err))
+		return nil, err
+	}
+	backup.JobID = &job.ID
+	if err := db.UpdateBackup(backup); err != nil {
+		b.logger.WithError(err).WithField("backup_id", backup.ID).Warn("Failed to record backup job")
+	}
+	return backup, nil
+}
+
+// backup archives an instance's volumes to a temporary file and stores it, then applies the
+// retention of the instance's schedule. Failed attempts are retried until the job runs out of
+// attempts, when the backup is marked as failed.
+func (b *Backups) backup(ctx context.Context, job *models.Job) error {
+	var payload models.BackupPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid backup payload: %w", err))
+	}
+	backup, err := db.GetBackupByID(payload.BackupID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load backup: %w", err)
+	}
+
+	now := time.Now()
+	backup.Status = models.BackupRunning
+	backup.StartedAt = &now
+	if err := db.UpdateBackup(backup); err != nil {
+		return err
+	}
+
+	err = b.archive(ctx, backup)
+	if err != nil && !finalAttempt(ctx, job) {
+		backup.Status = models.BackupQueued
+		backup.Error = truncate(err.Error(), 1000)
+		if err := db.UpdateBackup(backup); err != nil {
+			b.logger.WithError(err).WithField("backup_id", backup.ID).Warn("Failed to record failed backup attempt")
+		}
+		return err
+	}
+	b.finish(backup, err)
+	if err != nil {
+		return err
+	}
+	if backup.Trigger == models.BackupScheduled {
+		b.prune(ctx, backup)
+	}
+	return nil
+}
+
+// archive writes the archive of a backup's instance to the backup store
+func (b *Backups) archive(ctx context.Context, backup *models.Backup) error {
+	file, err := os.CreateTemp("", "backup-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := b.manager.ArchiveInstanceData(ctx, backup.InstanceID, file); err != nil {
+		return err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	backup.Key = backup.ArchiveKey()
+	backup.SizeBytes = size
+	if err := b.store.Put(ctx, backup.Key, file, size, "application/gzip"); err != nil {
+		return fmt.Errorf("failed to store backup: %w", err)
+	}
+	return nil
+}
+
+// finish records the outcome of a backup
+func (b *Backups) finish(backup *models.Backup, err error) {
+	now := time.Now()
+	backup.FinishedAt = &now
+	logger := b.logger.WithFields(logrus.Fields{
+		"backup_id":   backup.ID,
+		"instance_id": backup.InstanceID,
+		"trigger":     backup.Trigger,
+	})
+	if err != nil {
+		backup.Status = models.BackupFailed
+		backup.Error = truncate(err.Error(), 1000)
+		backup.Key = ""
+		backup.SizeBytes = 0
+		logger.WithError(err).Error("Backup failed")
+	} else {
+		backup.Status = models.BackupSucceeded
+		backup.Error = ""
+		logger.WithField("size_bytes", backup.SizeBytes).Info("Backup completed")
+	}
+	if err := db.UpdateBackup(backup); err != nil {
+		logger.WithError(err).Error("Failed to record backup outcome")
+	}
+	b.notify(backup)
+}
+
+// notify tells the owner of an instance about a failed backup, or a manual one that completed.
+// Completed scheduled backups are not worth a notification each, but every outcome is sent
+// to the owner's webhook endpoints.
+func (b *Backups) notify(backup *models.Backup) {
+	name := backup.InstanceID.String()
+	if instance, err := db.GetInstanceByID(backup.InstanceID); err == nil {
+		name = instance.Name
+		if err := db.QueueWebhookEvent(models.NewBackupWebhookEvent(instance, backup), instance); err != nil {
+			b.logger.WithError(err).WithField("backup_id", backup.ID).Warn("Failed to queue backup webhook event")
+		}
+	}
+	switch {
+	case backup.Status == models.BackupFailed:
+		notifyInstanceOwner(b.logger, backup.InstanceID, backup.UserID, models.NotificationBackupFailed, models.EventLevelError,
+			fmt.Sprintf("Backup of %s failed", name), fmt.Sprintf("The %s backup failed: %s", backup.Trigger, backup.Error))
+	case backup.Trigger == models.BackupManual:
+		notifyInstanceOwner(b.logger, backup.InstanceID, backup.UserID, models.NotificationBackupSucceeded, models.EventLevelInfo,
+			fmt.Sprintf("Backup of %s completed", name), "The backup is ready to download")
+	}
+}
+
+// prune removes the scheduled backups of an instance beyond its schedule's retention from the
+// store. Their records are kept as expired so the listing still shows them.
+func (b *Backups) prune(ctx context.Context, backup *models.Backup) {
+	schedule, err := db.GetBackupSchedule(backup.InstanceID)
+	if err != nil || schedule == nil {
+		return
+	}
+	expired, err := db.GetExpiredScheduledBackups(backup.InstanceID, schedule.Retention)
+	if err != nil {
+		b.logger.WithError(err).WithField("instance_id", backup.InstanceID).Error("Failed to find expired backups")
+		return
+	}
+	for i := range expired {
+		old := &expired[i]
+		if old.Target != b.store.Name() {
+			continue // kept in a store that is no longer configured
+		}
+		if err := b.store.Delete(ctx, old.Key); err != nil {
+			b.logger.WithError(err).WithField("backup_id", old.ID).Warn("Failed to remove expired backup")
+			continue
+		}
+		old.Status = models.BackupExpired
+		if err := db.UpdateBackup(old); err != nil {
+			b.logger.WithError(err).WithField("backup_id", old.ID).Warn("Failed to record expired backup")
+		}
+	}
+}
+
+// Start runs due backup schedules on the configured interval until the context is cancelled
+func (b *Backups) Start(ctx context.Context) {
+	b.logger.Infof("Starting backup scheduler, checking every %v", b.config.Backups.CheckInterval)
+	ticker := time.NewTicker(b.config.Backups.CheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("backups", b.config.Backups.CheckInterval)
+	singleton := lease.NewSingleton("backups", b.config.Backups.CheckInterval, b.config, b.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(b.RunDue)
+			}
+		}
+	}
+}
+
+// RunDue queues a backup for every schedule that is due and moves the schedule to its next
+// run. A schedule whose previous backup is still queued or running skips the run.
+func (b *Backups) RunDue() {
+	now := time.Now()
+	schedules, err := db.GetDueBackupSchedules(now, 100)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to get due backup schedules")
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+		logger := b.logger.WithField("instance_id", schedule.InstanceID)
+
+		if parsed, err := backups.ParseSchedule(schedule.Schedule); err != nil {
+			logger.WithError(err).Error("Disabling backup schedule that cannot be parsed")
+			schedule.Enabled = false
+		} else if next := parsed.Next(now); next.IsZero() {
+			schedule.NextRunAt = nil
+		} else {
+			schedule.NextRunAt = &next
+		}
+		schedule.LastRunAt = &now
+		if err := db.UpdateBackupSchedule(schedule); err != nil {
+			logger.WithError(err).Error("Failed to advance backup schedule")
+			continue
+		}
+		if !schedule.Enabled {
+			continue
+		}
+
+		instance, err := db.GetInstanceByID(schedule.InstanceID)
+		if err != nil {
+			logger.WithError(err).Warn("Skipping scheduled backup of missing instance")
+			continue
+		}
+		if active, err := db.HasActiveBackup(instance.ID); err != nil {
+			logger.WithError(err).Error("Failed to check for an unfinished backup")
+			continue
+		} else if active {
+			logger.Info("Skipping scheduled backup, the previous backup has not finished")
+			continue
+		}
+		if _, err := b.Create(instance, models.BackupScheduled); err != nil {
+			logger.WithError(err).Error("Failed to queue scheduled backup")
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// BillingEnforcer suspends the instances of users whose trial ran out or whose payments
+// failed, and resumes them once the subscription is paid again
+type BillingEnforcer struct {
+	manager container.Manager
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewBillingEnforcer creates a new billing enforcer
+func NewBillingEnforcer(manager container.Manager, cfg *config.Config, logger *logrus.Logger) *BillingEnforcer {
+	return &BillingEnforcer{
+		manager: manager,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Start checks subscriptions at startup and then on the configured interval until the context is cancelled
+func (e *BillingEnforcer) Start(ctx context.Context) {
+	e.logger.Infof("Starting billing enforcement every %v", e.config.Billing.EnforcementInterval)
+	ticker := time.NewTicker(e.config.Billing.EnforcementInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("billing_enforcer", e.config.Billing.EnforcementInterval)
+	singleton := lease.NewSingleton("billing_enforcer", e.config.Billing.EnforcementInterval, e.config, e.logger)
+
+	if singleton.Acquire() {
+		loop.Run(func() { e.CheckAll(ctx) })
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { e.CheckAll(ctx) })
+			}
+		}
+	}
+}
+
+// CheckAll compares every running or sleeping instance, and every instance suspended for billing, against its owner's subscription
+func (e *BillingEnforcer) CheckAll(ctx context.Context) {
+	instances, err := db.GetInstancesByStatus(models.StatusRunning, models.StatusError, models.StatusSleeping, models.StatusSuspended)
+	if err != nil {
+		e.logger.WithError(err).Error("Failed to fetch instances for billing enforcement")
+		return
+	}
+	e.checkInstances(ctx, instances)
+}
+
+// CheckUser applies enforcement right away to the instances of a user and their sub-accounts,
+// so that a recovered payment does not wait for the next scheduled check
+func (e *BillingEnforcer) CheckUser(ctx context.Context, user models.User) {
+	userIDs := []uuid.UUID{user.ID}
+	if user.IsReseller() {
+		subAccounts, err := db.GetSubAccounts(user.ID)
+		if err != nil {
+			e.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to get sub-accounts for billing enforcement")
+		}
+		for _, subAccount := range subAccounts {
+			userIDs = append(userIDs, subAccount.ID)
+		}
+	}
+
+	instances, err := db.GetInstancesByUserIDs(userIDs)
+	if err != nil {
+		e.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to get instances for billing enforcement")
+		return
+	}
+	e.checkInstances(ctx, instances)
+}
+
+// checkInstances suspends or resumes each instance according to its owner's subscription
+func (e *BillingEnforcer) checkInstances(ctx context.Context, instances []models.Instance) {
+	now := time.Now()
+	lapsed := map[uuid.UUID]bool{}
+
+	for _, instance := range instances {
+		if ctx.Err() != nil {
+			return
+		}
+		switch {
+		case instance.IsSuspended() && instance.SuspendedReason != models.SuspendReasonBilling:
+			// Only revisit suspended instances that we suspended ourselves
+			continue
+		case !instance.IsSuspended() && instance.Status != models.StatusRunning && instance.Status != models.StatusError && !instance.IsSleeping():
+			continue
+		}
+
+		ownerLapsed, cached := lapsed[instance.UserID]
+		if !cached {
+			owner, err := db.GetUserByID(instance.UserID)
+			if err != nil {
+				e.logger.WithError(err).WithField("user_id", instance.UserID).Warn("Failed to get instance owner for billing enforcement")
+				continue
+			}
+			ownerLapsed = owner.BillingLapsed(now, e.config.Billing.GracePeriod)
+			lapsed[instance.UserID] = ownerLapsed
+		}
+
+		logger := e.logger.WithFields(logrus.Fields{
+			"instance_id": instance.ID,
+			"user_id":     instance.UserID,
+		})
+		switch {
+		case ownerLapsed && !instance.IsSuspended():
+			e.suspend(ctx, instance, logger)
+		case !ownerLapsed && instance.IsSuspended():
+			e.resume(ctx, instance, logger)
+		}
+	}
+}
+
+// suspend stops an instance whose owner's subscription has lapsed
+func (e *BillingEnforcer) suspend(ctx context.Context, instance models.Instance, logger *logrus.Entry) {
+	logger.Warn("Subscription has lapsed, suspending instance")
+
+	stopCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	if err := e.manager.StopInstance(stopCtx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to stop instance of lapsed subscription")
+		return
+	}
+
+	// Reload since the manager may have updated the record
+	updated, err := db.GetInstanceByID(instance.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to reload suspended instance")
+		return
+	}
+	updated.Status = models.StatusSuspended
+	updated.SuspendedReason = models.SuspendReasonBilling
+	if err := db.UpdateInstance(updated); err != nil {
+		logger.WithError(err).Error("Failed to mark instance as suspended")
+		return
+	}
+
+	message := "Suspended because the trial has ended or the subscription payment failed"
+	e.recordEvent(instance, models.EventSuspended, models.EventLevelWarning, message)
+	notifyInstanceOwner(e.logger, instance.ID, instance.UserID, models.NotificationInstanceSuspended, models.EventLevelWarning,
+		fmt.Sprintf("%s was suspended", instance.Name), message+". It starts again once the subscription is paid.")
+}
+
+// resume restarts an instance whose owner's subscription is paid again
+func (e *BillingEnforcer) resume(ctx context.Context, instance models.Instance, logger *logrus.Entry) {
+	logger.Info("Subscription has recovered, resuming instance")
+
+	startCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	if err := e.manager.StartInstance(startCtx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to restart suspended instance")
+		return
+	}
+
+	updated, err := db.GetInstanceByID(instance.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to reload resumed instance")
+		return
+	}
+	updated.Status = models.StatusRunning
+	updated.SuspendedReason = ""
+	if err := db.UpdateInstance(updated); err != nil {
+		logger.WithError(err).Error("Failed to mark instance as running")
+		return
+	}
+
+	message := "Resumed after the subscription payment recovered"
+	e.recordEvent(instance, models.EventResumed, models.EventLevelInfo, message)
+	notifyInstanceOwner(e.logger, instance.ID, instance.UserID, models.NotificationInstanceResumed, models.EventLevelInfo,
+		fmt.Sprintf("%s is running again", instance.Name), message)
+}
+
+// recordEvent stores an instance event for the instance owner
+func (e *BillingEnforcer) recordEvent(instance models.Instance, eventType models.InstanceEventType, level models.EventLevel, message string) {
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       eventType,
+		Level:      level,
+		Message:    message,
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		e.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record instance event")
+	}
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// BillingJournalAuditor periodically checks that the billing journal is intact and still
+// agrees with the payments table and users' plans
+type BillingJournalAuditor struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewBillingJournalAuditor creates a new billing journal auditor
+func NewBillingJournalAuditor(cfg *config.Config, logger *logrus.Logger) *BillingJournalAuditor {
+	return &BillingJournalAuditor{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Start journals payments settled before the journal existed, then checks the journal at
+// startup and on the configured interval until the context is cancelled
+func (a *BillingJournalAuditor) Start(ctx context.Context) {
+	a.logger.Infof("Starting billing journal checks every %v", a.config.Billing.JournalCheckInterval)
+	ticker := time.NewTicker(a.config.Billing.JournalCheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("billing_journal", a.config.Billing.JournalCheckInterval)
+	singleton := lease.NewSingleton("billing_journal", a.config.Billing.JournalCheckInterval, a.config, a.logger)
+
+	if singleton.Acquire() {
+		if recorded, err := db.BackfillBillingJournal(); err != nil {
+			a.logger.WithError(err).Error("Failed to backfill the billing journal")
+		} else if recorded > 0 {
+			a.logger.WithField("payments", recorded).Info("Backfilled the billing journal")
+		}
+		loop.Run(a.Check)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(a.Check)
+			}
+		}
+	}
+}
+
+// Check verifies the journal and logs every problem found
+func (a *BillingJournalAuditor) Check() {
+	report, err := db.VerifyBillingJournal()
+	if err != nil {
+		a.logger.WithError(err).Error("Failed to check the billing journal")
+		return
+	}
+	metrics.ObserveBillingJournalCheck(report.Discrepancies())
+
+	for _, transactionID := range report.UnbalancedTransactions {
+		a.logger.WithField("transaction_id", transactionID).Error("Billing journal transaction does not balance")
+	}
+	if report.BrokenChainAt != nil {
+		a.logger.WithField("sequence", *report.BrokenChainAt).Error("Billing journal hash chain is broken, entries were changed or removed")
+	}
+	for _, mismatch := range report.PaymentMismatches {
+		a.logger.WithFields(logrus.Fields{
+			"payment_id": mismatch.PaymentID,
+			"status":     mismatch.Status,
+			"amount":     mismatch.Amount,
+			"charged":    mismatch.Charged,
+			"refunded":   mismatch.Refunded,
+		}).Error("Payment disagrees with the billing journal")
+	}
+	for _, mismatch := range report.PlanMismatches {
+		a.logger.WithFields(logrus.Fields{
+			"user_id":      mismatch.UserID,
+			"plan":         mismatch.Plan,
+			"journal_plan": mismatch.JournalPlan,
+		}).Error("User plan disagrees with the billing journal")
+	}
+
+	a.logger.WithFields(logrus.Fields{
+		"entries":       report.Entries,
+		"discrepancies": report.Discrepancies(),
+	}).Info("Checked the billing journal")
+}
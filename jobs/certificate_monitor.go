@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/proxy"
+	"github.com/sirupsen/logrus"
+)
+
+// CertificateMonitor tracks the TLS certificate status of every running instance URL
+type CertificateMonitor struct {
+	checker *proxy.CertificateChecker
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewCertificateMonitor creates a new certificate monitor
+func NewCertificateMonitor(provider proxy.Provider, cfg *config.Config, logger *logrus.Logger) *CertificateMonitor {
+	return &CertificateMonitor{
+		checker: proxy.NewCertificateChecker(provider, cfg.Proxy.CertRenewWindow),
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Start checks certificates on the configured interval until the context is cancelled
+func (m *CertificateMonitor) Start(ctx context.Context) {
+	m.logger.Infof("Starting TLS certificate monitoring every %v", m.config.Proxy.CertCheckInterval)
+	ticker := time.NewTicker(m.config.Proxy.CertCheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("certificate_monitor", m.config.Proxy.CertCheckInterval)
+	singleton := lease.NewSingleton("certificate_monitor", m.config.Proxy.CertCheckInterval, m.config, m.logger)
+
+	if singleton.Acquire() {
+		loop.Run(func() { m.CheckAll(ctx) })
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { m.CheckAll(ctx) })
+			}
+		}
+	}
+}
+
+// CheckAll checks the certificate of every running instance
+func (m *CertificateMonitor) CheckAll(ctx context.Context) {
+	instances, err := db.GetInstancesByStatus(models.StatusRunning)
+	if err != nil {
+		m.logger.WithError(err).Error("Failed to fetch instances for certificate monitoring")
+		return
+	}
+
+	for _, instance := range instances {
+		if ctx.Err() != nil {
+			return
+		}
+		if instance.URL == "" {
+			continue
+		}
+		m.checkInstance(ctx, instance)
+	}
+}
+
+// checkInstance updates an instance's certificate record and records an event when it
+// starts or stops failing
+func (m *CertificateMonitor) checkInstance(ctx context.Context, instance models.Instance) {
+	logger := m.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"domain":      instance.URL,
+	})
+
+	cert, err := db.GetCertificateByInstanceID(instance.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load certificate status")
+		return
+	}
+	if cert == nil || cert.Domain != instance.URL {
+		cert = &models.InstanceCertificate{InstanceID: instance.ID, Domain: instance.URL, CreatedAt: time.Now()}
+	}
+	previous := cert.Status
+
+	checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	info := m.checker.Check(checkCtx, instance.URL)
+
+	// Issuance normally takes minutes; a certificate still missing after the timeout has failed
+	if info.Status == models.CertificatePending && time.Since(cert.CreatedAt) > m.config.Proxy.CertIssueTimeout {
+		info.Status = models.CertificateFailed
+		info.Error = fmt.Sprintf("certificate not issued after %v: %s", m.config.Proxy.CertIssueTimeout, info.Error)
+	}
+
+	cert.Status = info.Status
+	cert.Issuer = info.Issuer
+	cert.NotBefore = info.NotBefore
+	cert.NotAfter = info.NotAfter
+	cert.Error = info.Error
+	cert.CheckedAt = time.Now()
+	if err := db.SaveCertificate(cert); err != nil {
+		logger.WithError(err).Warn("Failed to save certificate status")
+		return
+	}
+
+	switch {
+	case cert.Status == models.CertificateFailed && previous != models.CertificateFailed:
+		logger.WithField("error", cert.Error).Warn("TLS certificate for instance failed")
+		m.recordEvent(instance, models.EventCertificateFailed, models.EventLevelError,
+			fmt.Sprintf("TLS certificate for %s failed: %s", instance.URL, cert.Error))
+	case previous == models.CertificateFailed && cert.Status != models.CertificateFailed:
+		logger.Info("TLS certificate for instance recovered")
+		m.recordEvent(instance, models.EventCertificateIssued, models.EventLevelInfo,
+			fmt.Sprintf("TLS certificate for %s is %s", instance.URL, cert.Status))
+	}
+}
+
+// recordEvent stores an instance event for the instance owner
+func (m *CertificateMonitor) recordEvent(instance models.Instance, eventType models.InstanceEventType, level models.EventLevel, message string) {
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       eventType,
+		Level:      level,
+		Message:    message,
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		m.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record instance event")
+	}
+}
@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// clerkEventPruneInterval is how often old Clerk webhook events are deleted
+const clerkEventPruneInterval = 24 * time.Hour
+
+// ClerkEventHandler applies a verified Clerk webhook event from its raw body
+type ClerkEventHandler func(ctx context.Context, body []byte) error
+
+// ClerkWebhooks records the Clerk webhook events this host receives in the webhook_events
+// table and applies them. Clerk retries deliveries that are not answered quickly, so
+// expensive event types are acknowledged right away and applied on the job queue, and other
+// events that take longer than the webhook timeout finish in the background.
+type ClerkWebhooks struct {
+	queue   *Queue
+	handler ClerkEventHandler
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewClerkWebhooks creates the Clerk webhook processor and registers its job handler
+func NewClerkWebhooks(queue *Queue, handler ClerkEventHandler, cfg *config.Config, logger *logrus.Logger) *ClerkWebhooks {
+	w := &ClerkWebhooks{
+		queue:   queue,
+		handler: handler,
+		config:  cfg,
+		logger:  logger,
+	}
+	queue.Register(models.JobProcessWebhook, w.process)
+	return w
+}
+
+// Start deletes applied and failed events older than the retention daily, until the context is
+// cancelled. Redeliveries are recognised as long as their event is kept.
+func (w *ClerkWebhooks) Start(ctx context.Context) {
+	ticker := time.NewTicker(clerkEventPruneInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("clerk_webhook_event_pruner", clerkEventPruneInterval)
+	singleton := lease.NewSingleton("clerk_webhook_event_pruner", clerkEventPruneInterval, w.config, w.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(w.prune)
+			}
+		}
+	}
+}
+
+// prune deletes applied and failed events older than the retention
+func (w *ClerkWebhooks) prune() {
+	deleted, err := db.PruneReceivedWebhookEvents(time.Now().Add(-w.config.Clerk.EventRetention))
+	if err != nil {
+		w.logger.WithError(err).Error("Failed to prune Clerk webhook events")
+		return
+	}
+	w.logger.WithField("deleted", deleted).Debug("Pruned old Clerk webhook events")
+}
+
+// Receive records a delivery and applies its event, returning the event's status once the
+// delivery can be answered. An error means the event was not applied and Clerk should retry.
+// Redeliveries of an event that was applied or is still being applied are not applied again.
+func (w *ClerkWebhooks) Receive(deliveryID, eventType string, body []byte) (models.WebhookEventStatus, error) {
+	logger := w.logger.WithFields(logrus.Fields{
+		"delivery_id": deliveryID,
+		"event_type":  eventType,
+	})
+
+	async := w.config.Clerk.AsyncEvents[eventType]
+	status := models.WebhookEventProcessing
+	if async {
+		status = models.WebhookEventQueued
+	}
+	event := &models.ReceivedWebhookEvent{
+		Source:     models.WebhookSourceClerk,
+		DeliveryID: deliveryID,
+		Type:       eventType,
+		Status:     status,
+		Payload:    string(body),
+	}
+	created, err := db.CreateReceivedWebhookEvent(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to record webhook event: %w", err)
+	}
+	if !created {
+		if event, err = db.GetReceivedWebhookEventByDelivery(models.WebhookSourceClerk, deliveryID); err != nil {
+			return "", fmt.Errorf("failed to load recorded webhook event: %w", err)
+		}
+		if event.Status != models.WebhookEventFailed {
+			logger.WithField("status", event.Status).Info("Ignoring redelivered Clerk webhook event")
+			return event.Status, nil
+		}
+		retried, err := db.RetryReceivedWebhookEvent(event, status)
+		if err != nil {
+			return "", fmt.Errorf("failed to update webhook event: %w", err)
+		}
+		if !retried {
+			logger.Info("Ignoring redelivered Clerk webhook event that is already being retried")
+			return models.WebhookEventProcessing, nil
+		}
+		logger.Info("Retrying failed Clerk webhook event")
+	}
+
+	if async {
+		if err := w.enqueue(event); err != nil {
+			return "", err
+		}
+		logger.Info("Queued Clerk webhook event")
+		return models.WebhookEventQueued, nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- w.apply(context.Background(), event)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return models.WebhookEventFailed, err
+		}
+		return models.WebhookEventProcessed, nil
+	case <-time.After(w.config.Clerk.WebhookTimeout):
+		logger.Warnf("Clerk webhook event not applied within %v, finishing in the background", w.config.Clerk.WebhookTimeout)
+		// Clerk will not retry an acknowledged delivery, so a failure is retried by the job queue
+		go func() {
+			if err := <-done; err != nil {
+				if err := w.enqueue(event); err != nil {
+					logger.WithError(err).Error("Failed to queue retry of Clerk webhook event")
+				}
+			}
+		}()
+		return models.WebhookEventProcessing, nil
+	}
+}
+
+// enqueue queues a job applying a recorded event
+func (w *ClerkWebhooks) enqueue(event *models.ReceivedWebhookEvent) error {
+	job, err := models.NewWebhookProcessingJob(event)
+	if err != nil {
+		return err
+	}
+	if err := w.queue.Enqueue(job); err != nil {
+		return fmt.Errorf("failed to queue webhook event: %w", err)
+	}
+	event.Status = models.WebhookEventQueued
+	event.JobID = &job.ID
+	if err := db.UpdateReceivedWebhookEvent(event); err != nil {
+		w.logger.WithError(err).WithField("event_id", event.ID).Warn("Failed to record queued webhook event")
+	}
+	return nil
+}
+
+// apply runs the handler of a recorded event and records the outcome
+func (w *ClerkWebhooks) apply(ctx context.Context, event *models.ReceivedWebhookEvent) error {
+	event.Attempts++
+	err := w.handler(ctx, []byte(event.Payload))
+	if err != nil {
+		event.Status = models.WebhookEventFailed
+		event.Error = truncate(err.Error(), 1000)
+	} else {
+		now := time.Now()
+		event.Status = models.WebhookEventProcessed
+		event.Error = ""
+		event.ProcessedAt = &now
+	}
+	if err := db.UpdateReceivedWebhookEvent(event); err != nil {
+		w.logger.WithError(err).WithField("event_id", event.ID).Error("Failed to record webhook event outcome")
+	}
+	return err
+}
+
+// process applies a queued event; failures are retried until the job runs out of attempts
+func (w *ClerkWebhooks) process(ctx context.Context, job *models.Job) error {
+	var payload models.WebhookProcessingPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid webhook processing payload: %w", err))
+	}
+	event, err := db.GetReceivedWebhookEventByID(payload.EventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load webhook event: %w", err)
+	}
+	if event.Status == models.WebhookEventProcessed {
+		return nil
+	}
+	return w.apply(ctx, event)
+}
@@ -0,0 +1,220 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// HealthMonitor probes n8n inside every running instance, so that an instance whose
+// container is up but whose n8n process has crashed is not reported as running
+type HealthMonitor struct {
+	manager      container.Manager
+	instanceJobs *InstanceJobs
+	config       *config.Config
+	logger       *logrus.Logger
+}
+
+// NewHealthMonitor creates a new health monitor that rolls back failing upgrades with instanceJobs
+func NewHealthMonitor(manager container.Manager, instanceJobs *InstanceJobs, cfg *config.Config, logger *logrus.Logger) *HealthMonitor {
+	return &HealthMonitor{
+		manager:      manager,
+		instanceJobs: instanceJobs,
+		config:       cfg,
+		logger:       logger,
+	}
+}
+
+// Start probes instances on the configured interval until the context is cancelled
+func (m *HealthMonitor) Start(ctx context.Context) {
+	m.logger.Infof("Starting instance health probing every %v", m.config.Health.CheckInterval)
+	ticker := time.NewTicker(m.config.Health.CheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("health_monitor", m.config.Health.CheckInterval)
+	singleton := lease.NewSingleton("health_monitor", m.config.Health.CheckInterval, m.config, m.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { m.CheckAll(ctx) })
+			}
+		}
+	}
+}
+
+// CheckAll probes every running instance, and every instance this monitor marked as unhealthy
+func (m *HealthMonitor) CheckAll(ctx context.Context) {
+	instances, err := db.GetInstancesByStatus(models.StatusRunning, models.StatusError)
+	if err != nil {
+		m.logger.WithError(err).Error("Failed to fetch instances for health probing")
+		return
+	}
+
+	for _, instance := range instances {
+		if ctx.Err() != nil {
+			return
+		}
+		// Instances that failed to provision have no n8n to probe
+		if instance.Status == models.StatusError && instance.HealthFailures == 0 {
+			continue
+		}
+		m.checkInstance(ctx, instance)
+	}
+
+	if deleted, err := db.DeleteProbeResultsBefore(time.Now().Add(-m.config.Health.ProbeRetention)); err != nil {
+		m.logger.WithError(err).Warn("Failed to prune old probe results")
+	} else if deleted > 0 {
+		m.logger.WithField("deleted", deleted).Debug("Pruned old probe results")
+	}
+}
+
+// checkInstance probes a single instance from this host and every remote probe location,
+// and updates its failure count and status from the local probe
+func (m *HealthMonitor) checkInstance(ctx context.Context, instance models.Instance) {
+	logger := m.logger.WithField("instance_id", instance.ID)
+
+	probeCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	start := time.Now()
+	err := m.manager.CheckHealth(probeCtx, instance.ID)
+	local := models.ProbeResult{
+		InstanceID: instance.ID,
+		Region:     m.config.Health.Region,
+		Reachable:  err == nil,
+		LatencyMS:  time.Since(start).Milliseconds(),
+		CheckedAt:  time.Now(),
+	}
+	if err != nil {
+		local.Error = err.Error()
+	}
+	cancel()
+	remote := m.probeRemoteRegions(ctx, instance)
+
+	if err := db.CreateProbeResults(append([]models.ProbeResult{local}, remote...)); err != nil {
+		logger.WithError(err).Warn("Failed to record probe results")
+	}
+
+	if err == nil {
+		if instance.HealthFailures == 0 {
+			return
+		}
+		recovered := instance.Status == models.StatusError
+		instance.HealthFailures = 0
+		if recovered {
+			instance.Status = models.StatusRunning
+		}
+		if err := db.UpdateInstance(&instance); err != nil {
+			logger.WithError(err).Warn("Failed to reset instance health failures")
+			return
+		}
+		if recovered {
+			logger.Info("Instance is healthy again")
+			m.recordEvent(instance, models.EventHealthRecovered, models.EventLevelInfo, "n8n is responding again")
+		}
+		return
+	}
+
+	instance.HealthFailures++
+	logger = logger.WithFields(logrus.Fields{
+		"failures": instance.HealthFailures,
+		"error":    err.Error(),
+	})
+	logger.Debug("Instance health probe failed")
+
+	markedUnhealthy := instance.HealthFailures >= m.config.Health.FailureThreshold && instance.Status != models.StatusError
+	if markedUnhealthy {
+		instance.Status = models.StatusError
+	}
+	if err := db.UpdateInstance(&instance); err != nil {
+		logger.WithError(err).Warn("Failed to record instance health failure")
+		return
+	}
+	if markedUnhealthy {
+		message := fmt.Sprintf("n8n stopped responding after %d consecutive health checks: %v", instance.HealthFailures, err)
+		if scope := describeOutageScope(remote); scope != "" {
+			message += "; " + scope
+			logger = logger.WithField("scope", scope)
+		}
+		logger.Warn("Instance failed consecutive health probes, marking as error")
+		m.recordEvent(instance, models.EventHealthFailed, models.EventLevelError, message)
+		notifyInstanceOwner(m.logger, instance.ID, instance.UserID, models.NotificationInstanceUnhealthy, models.EventLevelError,
+			fmt.Sprintf("%s is not responding", instance.Name), message)
+	}
+
+	// A recently upgraded instance that keeps failing most likely broke with the upgrade
+	if m.canAutoRollback(instance) && instance.HealthFailures == m.config.Health.AutoRollbackAfter {
+		m.rollback(instance, logger)
+		return
+	}
+
+	// Restart once per failure streak so a broken instance is not restarted in a loop
+	if m.config.Health.AutoRestartAfter > 0 && instance.HealthFailures == m.config.Health.AutoRestartAfter {
+		m.restart(ctx, instance, logger)
+	}
+}
+
+// canAutoRollback reports whether an instance was upgraded recently enough to be rolled back
+// automatically
+func (m *HealthMonitor) canAutoRollback(instance models.Instance) bool {
+	return m.config.Health.AutoRollbackAfter > 0 &&
+		instance.PreviousImageDigest != "" &&
+		instance.UpgradedAt != nil &&
+		time.Since(*instance.UpgradedAt) <= m.config.Health.AutoRollbackWindow
+}
+
+// rollback queues the return of a failing instance to the version it ran before its upgrade
+func (m *HealthMonitor) rollback(instance models.Instance, logger *logrus.Entry) {
+	logger.Warn("Rolling back recently upgraded instance that fails its health checks")
+	if _, err := m.instanceJobs.Rollback(&instance, true); err != nil {
+		logger.WithError(err).Error("Failed to queue automatic rollback")
+	}
+}
+
+// restart stops and starts an unhealthy instance's container
+func (m *HealthMonitor) restart(ctx context.Context, instance models.Instance, logger *logrus.Entry) {
+	logger.Warn("Restarting unhealthy instance")
+
+	restartCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	if err := m.manager.StopInstance(restartCtx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to stop unhealthy instance")
+	}
+	if err := m.manager.StartInstance(restartCtx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to restart unhealthy instance")
+		message := fmt.Sprintf("Automatic restart after %d failed health checks did not succeed: %v", instance.HealthFailures, err)
+		m.recordEvent(instance, models.EventAutoRestarted, models.EventLevelError, message)
+		notifyInstanceOwner(m.logger, instance.ID, instance.UserID, models.NotificationInstanceRestarted, models.EventLevelError,
+			fmt.Sprintf("%s could not be restarted", instance.Name), message)
+		return
+	}
+
+	message := fmt.Sprintf("Restarted automatically by the health checker after %d failed health checks", instance.HealthFailures)
+	m.recordEvent(instance, models.EventAutoRestarted, models.EventLevelWarning, message)
+	notifyInstanceOwner(m.logger, instance.ID, instance.UserID, models.NotificationInstanceRestarted, models.EventLevelWarning,
+		fmt.Sprintf("%s was restarted", instance.Name), message)
+}
+
+// recordEvent stores an instance event for the instance owner
+func (m *HealthMonitor) recordEvent(instance models.Instance, eventType models.InstanceEventType, level models.EventLevel, message string) {
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       eventType,
+		Level:      level,
+		Message:    message,
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		m.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record instance event")
+	}
+}
@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// HostMonitor records the physical resources of the Docker host in docker_hosts, where
+// admission control applies the host's overcommit ratios to them, and alerts admins when the
+// instances' actual use of the host approaches its physical limits
+type HostMonitor struct {
+	client    container.DockerClient
+	config    *config.Config
+	logger    *logrus.Logger
+	singleton *lease.Singleton // created once the host's name is known
+}
+
+// NewHostMonitor creates a new host monitor
+func NewHostMonitor(client container.DockerClient, cfg *config.Config, logger *logrus.Logger) *HostMonitor {
+	return &HostMonitor{
+		client: client,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Start checks the host at startup and then on the configured interval until the context is cancelled
+func (m *HostMonitor) Start(ctx context.Context) {
+	m.logger.Infof("Starting host monitoring every %v", m.config.Capacity.HostCheckInterval)
+	ticker := time.NewTicker(m.config.Capacity.HostCheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("host_monitor", m.config.Capacity.HostCheckInterval)
+
+	loop.Run(func() { m.Check(ctx) })
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			loop.Run(func() { m.Check(ctx) })
+		}
+	}
+}
+
+// Check registers the host with its current resources and compares the instances' use of it
+// with the alert threshold. Every replica registers the host, keeping it active for admission
+// control; the utilization check runs on one replica per host.
+func (m *HostMonitor) Check(ctx context.Context) {
+	info, err := m.client.Info(ctx)
+	if err != nil {
+		m.logger.WithError(err).Error("Failed to get Docker host info")
+		return
+	}
+
+	host, err := db.RegisterDockerHost(models.DockerHost{
+		Name:             info.Name,
+		CPUCores:         info.NCPU,
+		MemoryMB:         info.MemTotal / 1024 / 1024,
+		CPUOvercommit:    m.config.Capacity.CPUOvercommit,
+		MemoryOvercommit: m.config.Capacity.MemoryOvercommit,
+		UtilizationAlert: m.config.Capacity.UtilizationAlert,
+	})
+	if err != nil {
+		m.logger.WithError(err).WithField("host", info.Name).Error("Failed to register Docker host")
+		return
+	}
+
+	if m.singleton == nil {
+		m.singleton = lease.NewSingleton("host_monitor:"+host.Name, m.config.Capacity.HostCheckInterval, m.config, m.logger)
+	}
+	if !m.singleton.Acquire() {
+		return
+	}
+	m.checkUtilization(host)
+}
+
+// checkUtilization measures the share of the host's CPU and memory in use and alerts admins
+// when it crosses the host's alert threshold, and again once it is back below
+func (m *HostMonitor) checkUtilization(host *models.DockerHost) {
+	logger := m.logger.WithField("host", host.Name)
+	if host.CPUCores <= 0 || host.MemoryMB <= 0 {
+		return
+	}
+
+	// Samples are streamed continuously, so running instances have one within two intervals
+	utilization, err := db.GetCurrentUtilization(time.Now().Add(-2 * m.config.Capacity.HostCheckInterval))
+	if err != nil {
+		logger.WithError(err).Error("Failed to measure host utilization")
+		return
+	}
+	allocated, err := db.GetAllocatedResources(uuid.Nil)
+	if err != nil {
+		logger.WithError(err).Error("Failed to sum allocated resources for host utilization")
+		return
+	}
+
+	host.CPUUtilization = utilization.CPUCores / float64(host.CPUCores)
+	host.MemoryUtilization = float64(utilization.MemoryBytes) / float64(host.MemoryMB*1024*1024)
+	metrics.ObserveHostUtilization(host.Name, host.CPUUtilization, host.MemoryUtilization,
+		allocated.CPU/float64(host.CPUCores), float64(allocated.MemoryMB)/float64(host.MemoryMB))
+
+	fields := logrus.Fields{
+		"cpu_utilization":    host.CPUUtilization,
+		"memory_utilization": host.MemoryUtilization,
+		"allocated_cpu":      allocated.CPU,
+		"allocated_memory":   allocated.MemoryMB,
+		"cpu_overcommit":     host.CPUOvercommit,
+		"memory_overcommit":  host.MemoryOvercommit,
+	}
+	over := host.CPUUtilization >= host.UtilizationAlert || host.MemoryUtilization >= host.UtilizationAlert
+	switch {
+	case over && host.AlertingSince == nil:
+		now := time.Now()
+		host.AlertingSince = &now
+		logger.WithFields(fields).Error("Host utilization is approaching its physical limits")
+		notifyAdmins(m.logger, models.NotificationHostUtilizationHigh, models.EventLevelWarning,
+			fmt.Sprintf("Host %s is running out of resources", host.Name),
+			fmt.Sprintf("Instances use %.0f%% of the host's %d cores and %.0f%% of its %d MB of memory, above the %.0f%% alert threshold. "+
+				"%.1f cores and %d MB are allocated with overcommit ratios of %gx CPU and %gx memory; lower them with PUT /api/v1/admin/hosts/%s to stop admitting instances.",
+				host.CPUUtilization*100, host.CPUCores, host.MemoryUtilization*100, host.MemoryMB, host.UtilizationAlert*100,
+				allocated.CPU, allocated.MemoryMB, host.CPUOvercommit, host.MemoryOvercommit, host.ID))
+	case !over && host.AlertingSince != nil:
+		host.AlertingSince = nil
+		logger.WithFields(fields).Info("Host utilization is back below the alert threshold")
+		notifyAdmins(m.logger, models.NotificationHostUtilizationOK, models.EventLevelInfo,
+			fmt.Sprintf("Host %s is back below the alert threshold", host.Name),
+			fmt.Sprintf("Instances use %.0f%% of the host's CPU and %.0f%% of its memory.", host.CPUUtilization*100, host.MemoryUtilization*100))
+	}
+
+	if err := db.UpdateDockerHostUtilization(host); err != nil {
+		logger.WithError(err).Error("Failed to store host utilization")
+	}
+}
@@ -0,0 +1,236 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// upgradePayload is the payload of an instance upgrade job
+type upgradePayload struct {
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+}
+
+// rollbackPayload is the payload of an instance rollback job
+type rollbackPayload struct {
+	FromVersion string                `json:"from_version"`
+	ToVersion   string                `json:"to_version"`
+	Status      models.InstanceStatus `json:"status"`    // status to return to if the rollback fails
+	Automatic   bool                  `json:"automatic"` // started by the health monitor
+}
+
+// InstanceJobs deletes, upgrades and rolls back existing instances on the job queue
+type InstanceJobs struct {
+	queue   *Queue
+	manager container.Manager
+	logger  *logrus.Logger
+}
+
+// NewInstanceJobs creates the instance job handlers and registers them with the job queue
+func NewInstanceJobs(queue *Queue, manager container.Manager, logger *logrus.Logger) *InstanceJobs {
+	j := &InstanceJobs{
+		queue:   queue,
+		manager: manager,
+		logger:  logger,
+	}
+	queue.Register(models.JobDeleteInstance, j.delete)
+	queue.Register(models.JobUpgradeInstance, j.upgrade)
+	queue.Register(models.JobRollbackInstance, j.rollback)
+	return j
+}
+
+// Delete queues the deletion of an instance's resources and record
+func (j *InstanceJobs) Delete(instance *models.Instance) (*models.Job, error) {
+	job, err := models.NewInstanceJob(models.JobDeleteInstance, instance, nil)
+	if err != nil {
+		return nil, err
+	}
+	return job, j.queue.Enqueue(job)
+}
+
+// Upgrade queues the upgrade of an instance from its current n8n version to another
+func (j *InstanceJobs) Upgrade(instance *models.Instance, fromVersion, toVersion string) (*models.Job, error) {
+	job, err := models.NewInstanceJob(models.JobUpgradeInstance, instance, upgradePayload{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, j.queue.Enqueue(job)
+}
+
+// Rollback marks an instance as upgrading and queues its return to the version it ran before
+// its last upgrade. The instance keeps its status if the job cannot be queued.
+func (j *InstanceJobs) Rollback(instance *models.Instance, automatic bool) (*models.Job, error) {
+	payload := rollbackPayload{
+		FromVersion: instance.ImageTag,
+		ToVersion:   instance.PreviousImageTag,
+		Status:      instance.Status,
+		Automatic:   automatic,
+	}
+	job, err := models.NewInstanceJob(models.JobRollbackInstance, instance, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Status = models.StatusUpgrading
+	if err := db.UpdateInstance(instance); err != nil {
+		instance.Status = payload.Status
+		return nil, fmt.Errorf("failed to update instance status: %w", err)
+	}
+	if err := j.queue.Enqueue(job); err != nil {
+		instance.Status = payload.Status
+		if err := db.UpdateInstance(instance); err != nil {
+			j.logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to restore instance status")
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// delete removes an instance's container, volumes and DNS record, then its record. Each step
+// tolerates resources a previous attempt already removed.
+func (j *InstanceJobs) delete(ctx context.Context, job *models.Job) error {
+	instance, err := db.GetInstanceByID(*job.InstanceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load instance to delete: %w", err)
+	}
+
+	// Instances whose provisioning failed have no container left to delete
+	if instance.ContainerID != "" {
+		if err := j.manager.DeleteInstance(ctx, instance.ID); err != nil {
+			if finalAttempt(ctx, job) {
+				instance.Status = models.StatusError
+				if err := db.UpdateInstance(instance); err != nil {
+					j.logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to mark undeletable instance as errored")
+				}
+			}
+			return err
+		}
+	}
+
+	if err := db.DeleteInstanceCredential(instance.ID); err != nil {
+		return fmt.Errorf("failed to delete instance credentials: %w", err)
+	}
+	return db.DeleteInstance(instance.ID)
+}
+
+// upgrade recreates an instance's container on a new n8n version, recording an event once
+// the upgrade succeeds or runs out of attempts. Failed attempts are rolled back, leaving the
+// previous container running until the next one.
+func (j *InstanceJobs) upgrade(ctx context.Context, job *models.Job) error {
+	var payload upgradePayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid upgrade payload: %w", err))
+	}
+	instance, err := db.GetInstanceByID(*job.InstanceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load instance to upgrade: %w", err)
+	}
+	logger := j.logger.WithFields(logrus.Fields{
+		"instance_id":  instance.ID,
+		"from_version": payload.FromVersion,
+		"to_version":   payload.ToVersion,
+	})
+
+	err = j.manager.UpgradeInstance(ctx, instance.ID, payload.ToVersion)
+	if err == nil {
+		logger.Info("Instance upgrade completed")
+		j.recordEvent(*instance, models.EventUpgradeSucceeded, models.EventLevelInfo,
+			fmt.Sprintf("Upgraded from n8n %s to %s", payload.FromVersion, payload.ToVersion))
+		return nil
+	}
+	if !finalAttempt(ctx, job) {
+		return err
+	}
+
+	logger.WithError(err).Error("Instance upgrade failed and was rolled back")
+	// The previous container is running again after a rollback
+	if current, err := db.GetInstanceByID(instance.ID); err == nil && current.Status == models.StatusUpgrading {
+		current.Status = models.StatusRunning
+		if err := db.UpdateInstance(current); err != nil {
+			logger.WithError(err).Error("Failed to restore instance status after rollback")
+		}
+	}
+	j.recordEvent(*instance, models.EventUpgradeFailed, models.EventLevelError,
+		fmt.Sprintf("Upgrade to n8n %s failed and was rolled back to %s: %v", payload.ToVersion, payload.FromVersion, err))
+	return err
+}
+
+// rollback recreates an instance's container on the image it ran before its last upgrade,
+// recording an event once the rollback succeeds or runs out of attempts
+func (j *InstanceJobs) rollback(ctx context.Context, job *models.Job) error {
+	var payload rollbackPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid rollback payload: %w", err))
+	}
+	instance, err := db.GetInstanceByID(*job.InstanceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Permanent(err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load instance to roll back: %w", err)
+	}
+	logger := j.logger.WithFields(logrus.Fields{
+		"instance_id":  instance.ID,
+		"from_version": payload.FromVersion,
+		"to_version":   payload.ToVersion,
+		"automatic":    payload.Automatic,
+	})
+
+	trigger := ""
+	if payload.Automatic {
+		trigger = " after repeated failed health checks"
+	}
+
+	err = j.manager.RollbackInstance(ctx, instance.ID)
+	if err == nil {
+		logger.Info("Instance rollback completed")
+		j.recordEvent(*instance, models.EventRollbackSucceeded, models.EventLevelWarning,
+			fmt.Sprintf("Rolled back from n8n %s to %s%s", payload.FromVersion, payload.ToVersion, trigger))
+		return nil
+	}
+	if !finalAttempt(ctx, job) {
+		return err
+	}
+
+	logger.WithError(err).Error("Instance rollback failed")
+	// The container the instance ran before the rollback is running again
+	if current, err := db.GetInstanceByID(instance.ID); err == nil && current.Status == models.StatusUpgrading {
+		current.Status = payload.Status
+		if err := db.UpdateInstance(current); err != nil {
+			logger.WithError(err).Error("Failed to restore instance status after failed rollback")
+		}
+	}
+	j.recordEvent(*instance, models.EventRollbackFailed, models.EventLevelError,
+		fmt.Sprintf("Rollback from n8n %s to %s%s failed: %v", payload.FromVersion, payload.ToVersion, trigger, err))
+	return err
+}
+
+// recordEvent stores an instance event for the instance owner
+func (j *InstanceJobs) recordEvent(instance models.Instance, eventType models.InstanceEventType, level models.EventLevel, message string) {
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       eventType,
+		Level:      level,
+		Message:    message,
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		j.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record instance event")
+	}
+}
@@ -0,0 +1,208 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// wakeTimeout limits starting the container of a sleeping instance
+const wakeTimeout = 2 * time.Minute
+
+// wakeCall is a wake of an instance in progress, which later requests for the instance wait for
+type wakeCall struct {
+	done chan struct{}
+	err  error
+}
+
+// InstanceSleeper stops the running instances of the plans in INSTANCE_SLEEP_PLANS that had
+// no workflow executions or HTTP traffic for as long as their plan allows, marking them as
+// sleeping, and starts them again when their URL is requested or their owner wakes them
+type InstanceSleeper struct {
+	manager container.Manager
+	config  *config.Config
+	logger  *logrus.Logger
+
+	mu     sync.Mutex
+	waking map[uuid.UUID]*wakeCall
+}
+
+// NewInstanceSleeper creates a new instance sleeper
+func NewInstanceSleeper(manager container.Manager, cfg *config.Config, logger *logrus.Logger) *InstanceSleeper {
+	return &InstanceSleeper{
+		manager: manager,
+		config:  cfg,
+		logger:  logger,
+		waking:  make(map[uuid.UUID]*wakeCall),
+	}
+}
+
+// Start puts idle instances to sleep on the configured interval until the context is
+// cancelled. It returns right away when no plan sleeps.
+func (s *InstanceSleeper) Start(ctx context.Context) {
+	if len(s.config.Sleep.Plans) == 0 {
+		return
+	}
+	s.logger.Infof("Starting instance sleeper every %v for plans %v", s.config.Sleep.CheckInterval, s.config.Sleep.Plans)
+	ticker := time.NewTicker(s.config.Sleep.CheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("instance_sleeper", s.config.Sleep.CheckInterval)
+	singleton := lease.NewSingleton("instance_sleeper", s.config.Sleep.CheckInterval, s.config, s.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { s.SleepIdle(ctx) })
+			}
+		}
+	}
+}
+
+// SleepIdle puts the idle running instances of every sleeping plan to sleep
+func (s *InstanceSleeper) SleepIdle(ctx context.Context) {
+	now := time.Now()
+	for plan, idle := range s.config.Sleep.Plans {
+		instances, err := db.GetIdleInstances(plan, now.Add(-idle))
+		if err != nil {
+			s.logger.WithError(err).WithField("plan", plan).Error("Failed to fetch idle instances")
+			continue
+		}
+		for _, instance := range instances {
+			if ctx.Err() != nil {
+				return
+			}
+			s.sleep(ctx, instance, idle)
+		}
+	}
+}
+
+// sleep stops an idle instance and marks it as sleeping
+func (s *InstanceSleeper) sleep(ctx context.Context, instance models.Instance, idle time.Duration) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"user_id":     instance.UserID,
+	})
+
+	stopCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	if err := s.manager.StopInstance(stopCtx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to stop idle instance")
+		return
+	}
+
+	// Reload since the manager updated the record
+	updated, err := db.GetInstanceByID(instance.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to reload idle instance")
+		return
+	}
+	updated.Status = models.StatusSleeping
+	if err := db.UpdateInstance(updated); err != nil {
+		logger.WithError(err).Error("Failed to mark instance as sleeping")
+		return
+	}
+
+	logger.Info("Instance was idle, put it to sleep")
+	s.recordEvent(instance, models.EventSlept, fmt.Sprintf("Stopped after %s without workflow executions or traffic; the next request to its URL starts it again", idle))
+}
+
+// Wake starts a sleeping instance and waits until it runs or the context ends. Concurrent
+// wakes of the same instance share one start, which is not cancelled with the context.
+func (s *InstanceSleeper) Wake(ctx context.Context, instanceID uuid.UUID) error {
+	call := s.startWaking(instanceID)
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WakeInBackground starts waking a sleeping instance without waiting for it, e.g. when a
+// visitor of its URL is shown a page that refreshes until it runs
+func (s *InstanceSleeper) WakeInBackground(instanceID uuid.UUID) {
+	s.startWaking(instanceID)
+}
+
+// startWaking returns the wake in progress of an instance, starting one if there is none
+func (s *InstanceSleeper) startWaking(instanceID uuid.UUID) *wakeCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if call, ok := s.waking[instanceID]; ok {
+		return call
+	}
+	call := &wakeCall{done: make(chan struct{})}
+	s.waking[instanceID] = call
+	go func() {
+		call.err = s.wake(instanceID)
+		s.mu.Lock()
+		delete(s.waking, instanceID)
+		s.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+// wake starts the container of a sleeping instance and marks it as running and active.
+// Instances that are no longer sleeping, e.g. because another replica woke them, are left alone.
+func (s *InstanceSleeper) wake(instanceID uuid.UUID) error {
+	logger := s.logger.WithField("instance_id", instanceID)
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		return err
+	}
+	if !instance.IsSleeping() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wakeTimeout)
+	defer cancel()
+	if err := s.manager.StartInstance(ctx, instance.ID); err != nil {
+		logger.WithError(err).Error("Failed to wake sleeping instance")
+		return err
+	}
+
+	updated, err := db.GetInstanceByID(instance.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	updated.Status = models.StatusRunning
+	updated.LastActivityAt = &now
+	if err := db.UpdateInstance(updated); err != nil {
+		logger.WithError(err).Error("Failed to mark woken instance as running")
+		return err
+	}
+
+	logger.Info("Woke sleeping instance")
+	s.recordEvent(*instance, models.EventWoken, "Started again after sleeping")
+	return nil
+}
+
+// recordEvent stores an instance event for the instance owner
+func (s *InstanceSleeper) recordEvent(instance models.Instance, eventType models.InstanceEventType, message string) {
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       eventType,
+		Level:      models.EventLevelInfo,
+		Message:    message,
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		s.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record instance event")
+	}
+}
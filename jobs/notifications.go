@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// notifyInstanceOwner adds a notification about an instance to its owner's notification
+// center. Failures are only logged, since the notification is not essential to the work.
+func notifyInstanceOwner(logger *logrus.Logger, instanceID, userID uuid.UUID, notificationType models.NotificationType, level models.EventLevel, title, message string) {
+	notification := &models.Notification{
+		UserID:     userID,
+		InstanceID: &instanceID,
+		Type:       notificationType,
+		Level:      level,
+		Title:      title,
+		Message:    truncate(message, 1000),
+	}
+	if err := db.CreateNotification(notification); err != nil {
+		logger.WithError(err).WithFields(logrus.Fields{
+			"instance_id": instanceID,
+			"type":        notificationType,
+		}).Warn("Failed to create notification")
+	}
+}
+
+// notifyAdmins adds a notification to the notification center of every admin. Failures are
+// only logged.
+func notifyAdmins(logger *logrus.Logger, notificationType models.NotificationType, level models.EventLevel, title, message string) {
+	admins, err := db.GetAdmins()
+	if err != nil {
+		logger.WithError(err).WithField("type", notificationType).Warn("Failed to get admins to notify")
+		return
+	}
+	for _, admin := range admins {
+		notification := &models.Notification{
+			UserID:  admin.ID,
+			Type:    notificationType,
+			Level:   level,
+			Title:   title,
+			Message: truncate(message, 1000),
+		}
+		if err := db.CreateNotification(notification); err != nil {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"user_id": admin.ID,
+				"type":    notificationType,
+			}).Warn("Failed to create notification")
+		}
+	}
+}
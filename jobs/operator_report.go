@@ -0,0 +1,435 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/email"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Sizes of the lists of an operator report
+const (
+	reportTopTables    = 15
+	reportTopEndpoints = 10
+	reportTopErrors    = 10
+)
+
+// reportCheckInterval is how often the operator report job checks if a report is due
+const reportCheckInterval = time.Hour
+
+// OperatorReportJob generates the operator report on the configured interval: table sizes,
+// hypertable chunk counts, the slowest endpoints, the most frequent error codes and pending
+// DNS cleanups, compared with the previous report. Reports are stored and delivered by
+// email and webhook when configured. Endpoint and error figures come from the metrics of
+// the replica generating the report.
+type OperatorReportJob struct {
+	config           *config.Config
+	containerManager container.Manager
+	mailer           *email.SMTPSender
+	client           *http.Client
+	logger           *logrus.Logger
+	singleton        *lease.Singleton
+
+	mu         sync.Mutex
+	snapshot   metrics.Snapshot // counters at the previous report of this replica
+	snapshotAt time.Time
+}
+
+// NewOperatorReportJob creates a new operator report job. Endpoint figures of the first
+// report count from now.
+func NewOperatorReportJob(containerManager container.Manager, cfg *config.Config, logger *logrus.Logger) *OperatorReportJob {
+	snapshot, err := metrics.TakeSnapshot()
+	if err != nil {
+		logger.WithError(err).Warn("Failed to read metrics for the operator report")
+	}
+	return &OperatorReportJob{
+		config:           cfg,
+		containerManager: containerManager,
+		mailer:           email.NewSMTPSender(cfg),
+		client:           &http.Client{Timeout: 30 * time.Second},
+		logger:           logger,
+		singleton:        lease.NewSingleton("operator_report", reportCheckInterval, cfg, logger),
+		snapshot:         snapshot,
+		snapshotAt:       time.Now(),
+	}
+}
+
+// Start generates a report whenever the newest one is older than the interval, until the
+// context is cancelled
+func (j *OperatorReportJob) Start(ctx context.Context) {
+	j.logger.Infof("Starting operator reports every %v", j.config.Reports.Interval)
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("operator_report", reportCheckInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if j.singleton.Acquire() {
+				loop.Run(func() { j.runIfDue(ctx) })
+			}
+		}
+	}
+}
+
+// runIfDue generates and delivers a report if the interval passed since the newest one
+func (j *OperatorReportJob) runIfDue(ctx context.Context) {
+	latest, err := db.GetLatestOperatorReport()
+	if err != nil {
+		j.logger.WithError(err).Error("Failed to get latest operator report")
+		return
+	}
+	if latest != nil && time.Since(latest.PeriodEnd) < j.config.Reports.Interval {
+		return
+	}
+
+	report, err := j.Generate(ctx)
+	if err != nil {
+		j.logger.WithError(err).Error("Failed to generate operator report")
+		return
+	}
+	j.Deliver(ctx, report)
+}
+
+// Generate gathers a report, compares it with the previous one and stores it
+func (j *OperatorReportJob) Generate(ctx context.Context) (*models.OperatorReport, error) {
+	previous, err := db.GetLatestOperatorReport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous report: %w", err)
+	}
+
+	now := time.Now().UTC()
+	report := &models.OperatorReport{
+		PeriodStart: now.Add(-j.config.Reports.Interval),
+		PeriodEnd:   now,
+		Data: models.OperatorReportData{
+			Tables:             []models.TableSize{},
+			Hypertables:        []models.HypertableChunks{},
+			SlowestEndpoints:   []models.EndpointLatency{},
+			TopErrorCodes:      []models.ErrorCodeCount{},
+			PendingDNSCleanups: []models.PendingDNSRecord{},
+			Problems:           []string{},
+		},
+	}
+	if previous != nil {
+		report.PeriodStart = previous.PeriodEnd
+	}
+	data := &report.Data
+	problem := func(section string, err error) {
+		j.logger.WithError(err).WithField("section", section).Warn("Failed to gather operator report section")
+		data.Problems = append(data.Problems, fmt.Sprintf("%s: %v", section, err))
+	}
+
+	if size, err := db.GetDatabaseSize(); err != nil {
+		problem("database_bytes", err)
+	} else {
+		data.DatabaseBytes = size
+	}
+	if tables, err := db.GetTableSizes(); err != nil {
+		problem("tables", err)
+	} else {
+		if len(tables) > reportTopTables {
+			tables = tables[:reportTopTables]
+		}
+		data.Tables = tables
+	}
+	if hypertables, err := db.GetHypertableChunks(); err != nil {
+		problem("hypertables", err)
+	} else {
+		data.Hypertables = hypertables
+	}
+	j.gatherEndpoints(data, problem)
+	j.gatherDNSCleanups(ctx, data, problem)
+
+	if previous != nil {
+		compareReports(data, &previous.Data)
+	}
+
+	if err := db.CreateOperatorReport(report); err != nil {
+		return nil, fmt.Errorf("failed to store report: %w", err)
+	}
+	j.logger.WithFields(logrus.Fields{
+		"report_id":            report.ID,
+		"database_bytes":       data.DatabaseBytes,
+		"pending_dns_cleanups": len(data.PendingDNSCleanups),
+		"problems":             len(data.Problems),
+	}).Info("Generated operator report")
+	return report, nil
+}
+
+// gatherEndpoints adds the slowest endpoints and most frequent error codes since the
+// previous report of this replica
+func (j *OperatorReportJob) gatherEndpoints(data *models.OperatorReportData, problem func(string, error)) {
+	snapshot, err := metrics.TakeSnapshot()
+	if err != nil {
+		problem("endpoints", err)
+		return
+	}
+	j.mu.Lock()
+	diff := snapshot.Since(j.snapshot)
+	data.EndpointsSince = j.snapshotAt.UTC()
+	j.snapshot, j.snapshotAt = snapshot, time.Now()
+	j.mu.Unlock()
+
+	for _, stats := range diff.Endpoints {
+		if stats.Requests == 0 {
+			continue
+		}
+		latency := models.EndpointLatency{
+			Method:       stats.Method,
+			Route:        stats.Route,
+			Requests:     stats.Requests,
+			ServerErrors: stats.ServerErrors,
+			MeanMS:       math.Round(stats.MeanSeconds()*1000*10) / 10,
+		}
+		if p95 := stats.P95Seconds(); !math.IsInf(p95, 1) {
+			p95MS := p95 * 1000
+			latency.P95MS = &p95MS
+		}
+		data.SlowestEndpoints = append(data.SlowestEndpoints, latency)
+	}
+	sort.Slice(data.SlowestEndpoints, func(i, j int) bool {
+		return data.SlowestEndpoints[i].MeanMS > data.SlowestEndpoints[j].MeanMS
+	})
+	if len(data.SlowestEndpoints) > reportTopEndpoints {
+		data.SlowestEndpoints = data.SlowestEndpoints[:reportTopEndpoints]
+	}
+
+	for code, count := range diff.ErrorCodes {
+		data.TopErrorCodes = append(data.TopErrorCodes, models.ErrorCodeCount{Code: code, Count: count})
+	}
+	sort.Slice(data.TopErrorCodes, func(i, j int) bool {
+		if data.TopErrorCodes[i].Count != data.TopErrorCodes[j].Count {
+			return data.TopErrorCodes[i].Count > data.TopErrorCodes[j].Count
+		}
+		return data.TopErrorCodes[i].Code < data.TopErrorCodes[j].Code
+	})
+	if len(data.TopErrorCodes) > reportTopErrors {
+		data.TopErrorCodes = data.TopErrorCodes[:reportTopErrors]
+	}
+}
+
+// gatherDNSCleanups adds the DNS records left over from deleted instances or previous containers
+func (j *OperatorReportJob) gatherDNSCleanups(ctx context.Context, data *models.OperatorReportData, problem func(string, error)) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	records, err := j.containerManager.StaleDNSRecords(ctx)
+	if errors.Is(err, container.ErrDNSListingUnsupported) {
+		return
+	}
+	data.DNSCleanupsSupported = true
+	if err != nil {
+		problem("pending_dns_cleanups", err)
+		return
+	}
+	for _, record := range records {
+		data.PendingDNSCleanups = append(data.PendingDNSCleanups, models.PendingDNSRecord{Name: record.Name, Answer: record.Answer})
+	}
+}
+
+// compareReports adds the growth of the database, its tables and hypertable chunks since the
+// previous report
+func compareReports(data, previous *models.OperatorReportData) {
+	if data.DatabaseBytes > 0 && previous.DatabaseBytes > 0 {
+		growth := data.DatabaseBytes - previous.DatabaseBytes
+		data.DatabaseGrowthBytes = &growth
+	}
+	previousTables := map[string]int64{}
+	for _, table := range previous.Tables {
+		previousTables[table.Table] = table.Bytes
+	}
+	for i := range data.Tables {
+		if bytes, ok := previousTables[data.Tables[i].Table]; ok {
+			growth := data.Tables[i].Bytes - bytes
+			data.Tables[i].GrowthBytes = &growth
+		}
+	}
+	previousChunks := map[string]int{}
+	for _, hypertable := range previous.Hypertables {
+		previousChunks[hypertable.Hypertable] = hypertable.Chunks
+	}
+	for i := range data.Hypertables {
+		if chunks, ok := previousChunks[data.Hypertables[i].Hypertable]; ok {
+			change := data.Hypertables[i].Chunks - chunks
+			data.Hypertables[i].ChunkChange = &change
+		}
+	}
+}
+
+// Deliver sends a report by email and webhook, as configured, and records the outcome
+func (j *OperatorReportJob) Deliver(ctx context.Context, report *models.OperatorReport) {
+	var delivered, failures []string
+	if j.mailer != nil && len(j.config.Reports.EmailTo) > 0 {
+		subject := fmt.Sprintf("Operator report %s", report.PeriodEnd.Format("2006-01-02"))
+		if err := j.mailer.Send(j.config.Reports.EmailTo, subject, FormatOperatorReport(report)); err != nil {
+			failures = append(failures, "email: "+err.Error())
+		} else {
+			delivered = append(delivered, "email")
+		}
+	}
+	if j.config.Reports.WebhookURL != "" {
+		if err := j.postWebhook(ctx, report); err != nil {
+			failures = append(failures, "webhook: "+err.Error())
+		} else {
+			delivered = append(delivered, "webhook")
+		}
+	}
+
+	report.DeliveredTo = strings.Join(delivered, ",")
+	report.DeliveryError = strings.Join(failures, "; ")
+	if len(failures) > 0 {
+		j.logger.WithField("report_id", report.ID).WithField("errors", report.DeliveryError).Warn("Failed to deliver operator report")
+	}
+	if err := db.SetOperatorReportDelivery(report.ID, report.DeliveredTo, report.DeliveryError); err != nil {
+		j.logger.WithError(err).WithField("report_id", report.ID).Error("Failed to record operator report delivery")
+	}
+}
+
+// postWebhook posts a report as JSON, signed with an HMAC-SHA256 of the body in the
+// X-LaunchStack-Signature header
+func (j *OperatorReportJob) postWebhook(ctx context.Context, report *models.OperatorReport) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"type":   "operator_report.created",
+		"report": report.ToPublicResponse(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.config.Reports.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if j.config.Reports.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(j.config.Reports.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-LaunchStack-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("report webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// FormatOperatorReport renders a report as plain text, for email
+func FormatOperatorReport(report *models.OperatorReport) string {
+	data := report.Data
+	var b strings.Builder
+	fmt.Fprintf(&b, "Operator report for %s to %s\n\n", report.PeriodStart.Format(time.RFC3339), report.PeriodEnd.Format(time.RFC3339))
+
+	fmt.Fprintf(&b, "Database size: %s%s\n\n", formatReportBytes(data.DatabaseBytes), formatGrowth(data.DatabaseGrowthBytes))
+
+	b.WriteString("Largest tables:\n")
+	for _, table := range data.Tables {
+		fmt.Fprintf(&b, "  %-32s %10s%s  (~%d rows)\n", table.Table, formatReportBytes(table.Bytes), formatGrowth(table.GrowthBytes), table.Rows)
+	}
+
+	if len(data.Hypertables) > 0 {
+		b.WriteString("\nHypertable chunks:\n")
+		for _, hypertable := range data.Hypertables {
+			change := ""
+			if hypertable.ChunkChange != nil {
+				change = fmt.Sprintf(" (%+d)", *hypertable.ChunkChange)
+			}
+			fmt.Fprintf(&b, "  %-32s %d chunks, %d compressed%s\n", hypertable.Hypertable, hypertable.Chunks, hypertable.CompressedChunks, change)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nSlowest endpoints since %s:\n", data.EndpointsSince.Format(time.RFC3339))
+	if len(data.SlowestEndpoints) == 0 {
+		b.WriteString("  No requests\n")
+	}
+	for _, endpoint := range data.SlowestEndpoints {
+		p95 := "> largest bucket"
+		if endpoint.P95MS != nil {
+			p95 = fmt.Sprintf("<= %g ms", *endpoint.P95MS)
+		}
+		fmt.Fprintf(&b, "  %-7s %-48s mean %.1f ms, p95 %s, %d requests, %d server errors\n",
+			endpoint.Method, endpoint.Route, endpoint.MeanMS, p95, endpoint.Requests, endpoint.ServerErrors)
+	}
+
+	b.WriteString("\nTop error codes:\n")
+	if len(data.TopErrorCodes) == 0 {
+		b.WriteString("  No errors\n")
+	}
+	for _, code := range data.TopErrorCodes {
+		fmt.Fprintf(&b, "  %-40s %d\n", code.Code, code.Count)
+	}
+
+	b.WriteString("\nPending DNS cleanups:\n")
+	switch {
+	case !data.DNSCleanupsSupported:
+		b.WriteString("  Not available with this DNS provider\n")
+	case len(data.PendingDNSCleanups) == 0:
+		b.WriteString("  None\n")
+	}
+	for _, record := range data.PendingDNSCleanups {
+		fmt.Fprintf(&b, "  %s -> %s\n", record.Name, record.Answer)
+	}
+
+	if len(data.Problems) > 0 {
+		b.WriteString("\nSections that could not be gathered:\n")
+		for _, problem := range data.Problems {
+			fmt.Fprintf(&b, "  %s\n", problem)
+		}
+	}
+	return b.String()
+}
+
+// formatReportBytes formats a size in bytes with a binary unit
+func formatReportBytes(bytes int64) string {
+	const unit = 1024
+	value := math.Abs(float64(bytes))
+	if value < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	exp := 0
+	for value >= unit*unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	sign := ""
+	if bytes < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%.1f %ciB", sign, value/unit, "KMGTP"[exp])
+}
+
+// formatGrowth formats the growth of a size since the previous report, if known
+func formatGrowth(growth *int64) string {
+	if growth == nil {
+		return ""
+	}
+	if *growth >= 0 {
+		return " (+" + formatReportBytes(*growth) + ")"
+	}
+	return " (" + formatReportBytes(*growth) + ")"
+}
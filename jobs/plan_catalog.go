@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/launchstack/backend/db"
+	"github.com/sirupsen/logrus"
+)
+
+// PlanCatalogRefresher reloads the plan catalog on every replica, so that changes made by an
+// admin through another replica apply without a restart
+type PlanCatalogRefresher struct {
+	interval time.Duration
+	logger   *logrus.Logger
+}
+
+// NewPlanCatalogRefresher creates a new plan catalog refresher
+func NewPlanCatalogRefresher(interval time.Duration, logger *logrus.Logger) *PlanCatalogRefresher {
+	return &PlanCatalogRefresher{
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start reloads the catalog on the interval until the context is cancelled
+func (r *PlanCatalogRefresher) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := db.LoadPlanCatalog(); err != nil {
+				// Keep serving the plans loaded last
+				r.logger.WithError(err).Warn("Failed to reload plan catalog")
+			}
+		}
+	}
+}
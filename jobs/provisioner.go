@@ -0,0 +1,246 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/proxy"
+	"github.com/sirupsen/logrus"
+)
+
+// Provisioner creates the resources of new instances on the job queue, so that instance
+// creation requests return immediately and their progress can be followed step by step
+type Provisioner struct {
+	queue   *Queue
+	manager container.Manager
+	proxy   proxy.Provider
+	config  *config.Config
+	logger  *logrus.Logger
+}
+
+// NewProvisioner creates a new provisioner and registers it with the job queue; the proxy
+// provider may be nil when no reverse proxy is configured
+func NewProvisioner(queue *Queue, manager container.Manager, proxyProvider proxy.Provider, cfg *config.Config, logger *logrus.Logger) *Provisioner {
+	p := &Provisioner{
+		queue:   queue,
+		manager: manager,
+		proxy:   proxyProvider,
+		config:  cfg,
+		logger:  logger,
+	}
+	queue.Register(models.JobCreateInstance, p.provision)
+	return p
+}
+
+// Submit saves a prepared instance with its provisioning record and queues the job that provisions it
+func (p *Provisioner) Submit(instance *models.Instance, provisioning *models.ProvisioningJob) error {
+	job, err := models.NewInstanceJob(models.JobCreateInstance, instance, nil)
+	if err != nil {
+		return err
+	}
+	p.queue.prepare(job)
+	if err := db.CreateInstanceWithProvisioningJob(instance, provisioning, job); err != nil {
+		return err
+	}
+	p.queue.Notify()
+	return nil
+}
+
+// Retry provisions an instance whose provisioning failed again, returning its provisioning
+// job. Failed attempts removed the resources they created, so the new attempt starts over
+// from the first step. Returns db.ErrProvisioningNotFailed unless provisioning failed.
+func (p *Provisioner) Retry(instance *models.Instance) (*models.ProvisioningJob, error) {
+	provisioning, err := db.GetProvisioningJobByInstanceID(instance.ID)
+	if err != nil {
+		return nil, err
+	}
+	if provisioning.Status != models.ProvisioningFailed || instance.Status != models.StatusError {
+		return nil, db.ErrProvisioningNotFailed
+	}
+	job, err := models.NewInstanceJob(models.JobCreateInstance, instance, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.queue.prepare(job)
+
+	previous := instance.Status
+	instance.Status = models.StatusPending
+	provisioning.Status = models.ProvisioningPending
+	provisioning.Error = ""
+	provisioning.StartedAt = nil
+	provisioning.FinishedAt = nil
+	provisioning.Steps = models.PendingProvisioningSteps()
+	if err := db.RetryProvisioningJob(instance, previous, provisioning, job); err != nil {
+		return nil, err
+	}
+	p.queue.Notify()
+	p.logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"attempts":    provisioning.Attempts,
+	}).Info("Retrying failed provisioning")
+	return provisioning, nil
+}
+
+// provision runs one attempt at provisioning an instance and records its outcome on the
+// provisioning record and, once the job succeeds or runs out of attempts, on the instance
+func (p *Provisioner) provision(ctx context.Context, queueJob *models.Job) error {
+	logger := p.logger.WithField("instance_id", *queueJob.InstanceID)
+
+	job, err := db.GetProvisioningJobByInstanceID(*queueJob.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to load provisioning job: %w", err)
+	}
+	if job.IsFinished() {
+		return nil
+	}
+	instance, err := db.GetInstanceByID(*queueJob.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to load instance to provision: %w", err)
+	}
+	user, err := db.GetUserByID(instance.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load owner of instance to provision: %w", err)
+	}
+
+	// Every attempt starts over from the first step
+	now := time.Now()
+	job.Status = models.ProvisioningRunning
+	job.StartedAt = &now
+	job.Error = ""
+	job.Attempts++
+	job.Steps = models.PendingProvisioningSteps()
+	if err := db.UpdateProvisioningJob(job); err != nil {
+		return fmt.Errorf("failed to mark provisioning job as running: %w", err)
+	}
+	logger.Info("Provisioning instance")
+
+	tracker := &provisioningTracker{job: job, logger: logger}
+	provisionCtx, cancel := context.WithTimeout(ctx, p.config.Provisioning.Timeout)
+	err = p.manager.ProvisionInstance(provisionCtx, user, instance, tracker)
+	if err == nil {
+		err = p.checkProxyRoute(provisionCtx, instance, tracker)
+		if err != nil {
+			tracker.StepStarted(models.StepCleanup)
+			tracker.StepFinished(models.StepCleanup, p.manager.DeprovisionInstance(provisionCtx, instance))
+		}
+	}
+	cancel()
+
+	// Partial resources were removed, so a failed attempt can simply be retried
+	if err != nil && !finalAttempt(ctx, queueJob) {
+		job.Status = models.ProvisioningPending
+		job.Error = fmt.Sprintf("attempt %d of %d failed, retrying: %v", queueJob.Attempts, queueJob.MaxAttempts, err)
+		if err := db.UpdateProvisioningJob(job); err != nil {
+			logger.WithError(err).Error("Failed to save provisioning job result")
+		}
+		return err
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err != nil {
+		logger.WithError(err).Error("Failed to provision instance")
+		job.Status = models.ProvisioningFailed
+		job.Error = err.Error()
+		instance.Status = models.StatusError
+	} else {
+		job.Status = models.ProvisioningSucceeded
+		instance.Status = models.StatusRunning
+	}
+
+	if err := db.UpdateInstance(instance); err != nil {
+		logger.WithError(err).Error("Failed to save provisioned instance")
+	}
+	if err := db.UpdateProvisioningJob(job); err != nil {
+		logger.WithError(err).Error("Failed to save provisioning job result")
+	}
+
+	if job.Status == models.ProvisioningFailed {
+		p.recordEvent(*instance, models.EventProvisioningFailed, models.EventLevelError,
+			fmt.Sprintf("Provisioning failed and its partial resources were removed: %s", job.Error))
+		return err
+	}
+	logger.WithField("duration", finished.Sub(now)).Info("Instance provisioned")
+	return nil
+}
+
+// checkProxyRoute verifies that the reverse proxy serves the new instance's domain,
+// skipping the step when no proxy is configured
+func (p *Provisioner) checkProxyRoute(ctx context.Context, instance *models.Instance, tracker *provisioningTracker) error {
+	if p.proxy == nil {
+		tracker.StepSkipped(models.StepProxy)
+		return nil
+	}
+
+	tracker.StepStarted(models.StepProxy)
+	routeErr, err := p.proxy.RouteError(ctx, instance.URL)
+	if err == nil && routeErr != "" {
+		err = fmt.Errorf("reverse proxy does not serve %s: %s", instance.URL, routeErr)
+	} else if err != nil {
+		err = fmt.Errorf("failed to query %s: %w", p.proxy.Name(), err)
+	}
+	tracker.StepFinished(models.StepProxy, err)
+	return err
+}
+
+// recordEvent stores an instance event for the instance owner
+func (p *Provisioner) recordEvent(instance models.Instance, eventType models.InstanceEventType, level models.EventLevel, message string) {
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       eventType,
+		Level:      level,
+		Message:    message,
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		p.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record instance event")
+	}
+}
+
+// provisioningTracker saves the progress of each provisioning step on the job
+type provisioningTracker struct {
+	job    *models.ProvisioningJob
+	logger *logrus.Entry
+}
+
+// StepStarted marks a step as running
+func (t *provisioningTracker) StepStarted(name models.ProvisioningStepName) {
+	now := time.Now()
+	step := t.job.Step(name)
+	step.Status = models.ProvisioningRunning
+	step.StartedAt = &now
+	t.save(step)
+}
+
+// StepFinished marks a step as succeeded or failed
+func (t *provisioningTracker) StepFinished(name models.ProvisioningStepName, err error) {
+	now := time.Now()
+	step := t.job.Step(name)
+	step.Status = models.ProvisioningSucceeded
+	step.FinishedAt = &now
+	if err != nil {
+		step.Status = models.ProvisioningFailed
+		step.Error = truncate(err.Error(), 1000)
+	}
+	t.save(step)
+}
+
+// StepSkipped marks a step that does not apply as skipped
+func (t *provisioningTracker) StepSkipped(name models.ProvisioningStepName) {
+	step := t.job.Step(name)
+	step.Status = models.ProvisioningSkipped
+	t.save(step)
+}
+
+// save stores the job's progress with the record of the step that changed; failures only
+// cost visibility, so provisioning continues
+func (t *provisioningTracker) save(step *models.ProvisioningStep) {
+	if err := db.SaveProvisioningStep(t.job, models.NewProvisioningStepRecord(t.job, *step)); err != nil {
+		t.logger.WithError(err).Warn("Failed to save provisioning progress")
+	}
+}
@@ -0,0 +1,222 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler performs one attempt of a job. Returning an error schedules a retry unless the
+// job is out of attempts or the error is marked Permanent.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// permanentError wraps an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as one that retrying cannot fix, failing the job right away
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Queue runs long-running operations from the persistent jobs table on a pool of workers,
+// retrying failed attempts with exponential backoff
+type Queue struct {
+	config   *config.Config
+	logger   *logrus.Logger
+	handlers map[models.JobType]Handler
+	wake     chan struct{}
+}
+
+// NewQueue creates a new job queue; handlers must be registered before it is started
+func NewQueue(cfg *config.Config, logger *logrus.Logger) *Queue {
+	return &Queue{
+		config:   cfg,
+		logger:   logger,
+		handlers: map[models.JobType]Handler{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for a job type
+func (q *Queue) Register(jobType models.JobType, handler Handler) {
+	q.handlers[jobType] = handler
+}
+
+// Enqueue stores a job for the workers to run as soon as possible
+func (q *Queue) Enqueue(job *models.Job) error {
+	q.prepare(job)
+	if err := db.CreateJob(job); err != nil {
+		return err
+	}
+	q.Notify()
+	return nil
+}
+
+// prepare fills in the scheduling defaults of a new job
+func (q *Queue) prepare(job *models.Job) {
+	job.Status = models.JobQueued
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = q.config.Jobs.MaxAttempts
+	}
+	if job.Payload == "" {
+		job.Payload = "{}"
+	}
+}
+
+// Notify wakes an idle worker, e.g. after a job was created in a database transaction
+func (q *Queue) Notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the workers until the context is cancelled, releasing jobs abandoned by
+// workers that stopped in the middle of an attempt
+func (q *Queue) Start(ctx context.Context) {
+	q.logger.Infof("Starting %d job workers", q.config.Jobs.Workers)
+	for i := 0; i < q.config.Jobs.Workers; i++ {
+		go q.work(ctx)
+	}
+
+	ticker := time.NewTicker(q.config.Jobs.Timeout)
+	defer ticker.Stop()
+
+	q.requeueStale()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.requeueStale()
+		}
+	}
+}
+
+// requeueStale returns jobs that have been running for longer than any attempt may take to the queue
+func (q *Queue) requeueStale() {
+	requeued, err := db.RequeueStaleJobs(time.Now().Add(-q.config.Jobs.Timeout - time.Minute))
+	if err != nil {
+		q.logger.WithError(err).Error("Failed to requeue abandoned jobs")
+		return
+	}
+	if requeued > 0 {
+		q.logger.WithField("jobs", requeued).Warn("Requeued jobs abandoned by their worker")
+		q.Notify()
+	}
+}
+
+// work runs due jobs one at a time, waiting for new ones when the queue is empty
+func (q *Queue) work(ctx context.Context) {
+	types := make([]models.JobType, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+
+	for {
+		job, err := db.ClaimNextJob(types)
+		if err != nil {
+			q.logger.WithError(err).Error("Failed to claim job")
+		}
+		if job != nil {
+			q.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-time.After(q.config.Jobs.PollInterval):
+		}
+	}
+}
+
+// run performs one attempt of a job and records its outcome
+func (q *Queue) run(ctx context.Context, job *models.Job) {
+	logger := q.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"attempt":  job.Attempts,
+	})
+	if job.InstanceID != nil {
+		logger = logger.WithField("instance_id", *job.InstanceID)
+	}
+	logger.Info("Running job")
+
+	attemptCtx, cancel := context.WithTimeout(ctx, q.config.Jobs.Timeout)
+	err := q.handlers[job.Type](attemptCtx, job)
+	cancel()
+
+	job.LockedAt = nil
+	now := time.Now()
+	var permanent *permanentError
+	switch {
+	case err == nil:
+		job.Status = models.JobSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+		logger.Info("Job succeeded")
+	case ctx.Err() != nil:
+		// Interrupted by shutdown; the attempt does not count
+		job.Status = models.JobQueued
+		job.Attempts--
+		job.RunAt = now
+		logger.WithError(err).Warn("Job interrupted by shutdown, requeued")
+	case errors.As(err, &permanent) || job.IsLastAttempt():
+		job.Status = models.JobFailed
+		job.LastError = truncate(err.Error(), 1000)
+		job.FinishedAt = &now
+		logger.WithError(err).Error("Job failed")
+	default:
+		delay := q.retryDelay(job.Attempts)
+		job.Status = models.JobQueued
+		job.LastError = truncate(err.Error(), 1000)
+		job.RunAt = now.Add(delay)
+		logger.WithError(err).Warnf("Job attempt failed, retrying in %v", delay)
+	}
+
+	if err := db.UpdateJob(job); err != nil {
+		logger.WithError(err).Error("Failed to save job outcome")
+	}
+}
+
+// finalAttempt checks if a failure of the job's current attempt is final, so its handler should
+// record the failure; attempts interrupted by shutdown do not count
+func finalAttempt(ctx context.Context, job *models.Job) bool {
+	return job.IsLastAttempt() && !errors.Is(ctx.Err(), context.Canceled)
+}
+
+// retryDelay returns the exponential backoff after the given number of failed attempts
+func (q *Queue) retryDelay(attempts int) time.Duration {
+	delay := q.config.Jobs.RetryBaseDelay
+	for i := 1; i < attempts && delay < q.config.Jobs.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > q.config.Jobs.RetryMaxDelay {
+		delay = q.config.Jobs.RetryMaxDelay
+	}
+	return delay
+}
+
+// truncate shortens a message to fit a column
+func truncate(message string, length int) string {
+	if len(message) <= length {
+		return message
+	}
+	return fmt.Sprintf("%s...", message[:length-3])
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchstack/backend/models"
+)
+
+// remoteProbeClient is used to reach remote probe agents, which themselves give up on an instance after 5 seconds
+var remoteProbeClient = &http.Client{Timeout: 15 * time.Second}
+
+// remoteProbeResponse is the answer of a remote probe agent
+type remoteProbeResponse struct {
+	Reachable bool   `json:"reachable"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error"`
+}
+
+// probeRemoteRegions asks every configured remote probe agent, in parallel, whether the
+// instance's public health endpoint answers from its location. Agents that cannot be
+// reached say nothing about the instance and are left out of the results.
+func (m *HealthMonitor) probeRemoteRegions(ctx context.Context, instance models.Instance) []models.ProbeResult {
+	if len(m.config.Health.RemoteProbes) == 0 || instance.URL == "" {
+		return nil
+	}
+	target := instance.GetURL(m.config.Server.Domain) + m.manager.Templates().ForInstance(&instance).HealthPath
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make([]models.ProbeResult, 0, len(m.config.Health.RemoteProbes))
+	for region, agentURL := range m.config.Health.RemoteProbes {
+		wg.Add(1)
+		go func(region, agentURL string) {
+			defer wg.Done()
+			result, err := m.probeRemote(ctx, region, agentURL, target)
+			if err != nil {
+				m.logger.WithError(err).WithField("region", region).Warn("Failed to reach remote probe agent")
+				return
+			}
+			result.InstanceID = instance.ID
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(region, agentURL)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Region < results[j].Region })
+	return results
+}
+
+// probeRemote asks a single remote probe agent to probe the target URL
+func (m *HealthMonitor) probeRemote(ctx context.Context, region, agentURL, target string) (models.ProbeResult, error) {
+	body, _ := json.Marshal(map[string]string{"url": target})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, agentURL+"/api/v1/health/probe", bytes.NewReader(body))
+	if err != nil {
+		return models.ProbeResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Probe-Token", m.config.Health.ProbeToken)
+
+	resp, err := remoteProbeClient.Do(req)
+	if err != nil {
+		return models.ProbeResult{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.ProbeResult{}, fmt.Errorf("probe agent returned status %d", resp.StatusCode)
+	}
+
+	var probe remoteProbeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&probe); err != nil {
+		return models.ProbeResult{}, fmt.Errorf("failed to decode probe agent response: %w", err)
+	}
+
+	return models.ProbeResult{
+		Region:    region,
+		Reachable: probe.Reachable,
+		LatencyMS: probe.LatencyMS,
+		Error:     probe.Error,
+		CheckedAt: time.Now(),
+	}, nil
+}
+
+// describeOutageScope explains whether an instance that failed its local probe can still
+// be reached from the remote probe locations, so that a network problem on this host is
+// not mistaken for the instance being down everywhere
+func describeOutageScope(remote []models.ProbeResult) string {
+	var reachable []string
+	for _, result := range remote {
+		if result.Reachable {
+			reachable = append(reachable, result.Region)
+		}
+	}
+
+	switch {
+	case len(remote) == 0:
+		return ""
+	case len(reachable) == 0:
+		return fmt.Sprintf("unreachable from all %d remote probe locations", len(remote))
+	case len(reachable) == len(remote):
+		return fmt.Sprintf("still reachable from %s, so this is likely a network issue local to this host", strings.Join(reachable, ", "))
+	default:
+		return fmt.Sprintf("reachable from %s only, so this is likely a regional network issue", strings.Join(reachable, ", "))
+	}
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// SecretReencryptor encrypts the values of encrypted columns that are stored in plaintext, from
+// before the column was encrypted or by replicas still running an older version, or with a
+// previous key after the key was rotated
+type SecretReencryptor struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewSecretReencryptor creates a new secret re-encryptor
+func NewSecretReencryptor(cfg *config.Config, logger *logrus.Logger) *SecretReencryptor {
+	return &SecretReencryptor{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Start re-encrypts right away and then on the configured interval until the context is
+// cancelled
+func (r *SecretReencryptor) Start(ctx context.Context) {
+	interval := r.config.Encryption.ReencryptInterval
+	r.logger.Infof("Starting secret re-encryption every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("secret_reencryptor", interval)
+	singleton := lease.NewSingleton("secret_reencryptor", interval, r.config, r.logger)
+
+	for {
+		if singleton.Acquire() {
+			loop.Run(r.Reencrypt)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reencrypt re-encrypts the stale values of every encrypted column
+func (r *SecretReencryptor) Reencrypt() {
+	for _, column := range db.EncryptedColumns {
+		logger := r.logger.WithFields(logrus.Fields{
+			"table":  column.Table,
+			"column": column.Column,
+		})
+		rewritten, failed, err := db.ReencryptColumn(column)
+		if err != nil {
+			logger.WithError(err).Error("Failed to re-encrypt column")
+			continue
+		}
+		if failed > 0 {
+			logger.WithField("values", failed).Error("Failed to decrypt values for re-encryption, their key may be missing from ENCRYPTION_PREVIOUS_KEYS")
+		}
+		if rewritten > 0 {
+			logger.WithField("values", rewritten).Info("Re-encrypted values with the current key")
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// SpendingCapMonitor tells users when this month's metered charges reached their spending
+// cap, from when on actions adding metered usage are refused. Each user is told once a month.
+type SpendingCapMonitor struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewSpendingCapMonitor creates a new spending cap monitor
+func NewSpendingCapMonitor(cfg *config.Config, logger *logrus.Logger) *SpendingCapMonitor {
+	return &SpendingCapMonitor{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Start checks the caps on the configured interval until the context is cancelled
+func (m *SpendingCapMonitor) Start(ctx context.Context) {
+	interval := m.config.Billing.SpendingCheckInterval
+	m.logger.Infof("Starting spending cap checks every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("spending_cap_monitor", interval)
+	singleton := lease.NewSingleton("spending_cap_monitor", interval, m.config, m.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(m.CheckAll)
+			}
+		}
+	}
+}
+
+// CheckAll notifies the users who reached their spending cap this month and were not told yet
+func (m *SpendingCapMonitor) CheckAll() {
+	users, err := db.GetUsersWithSpendingCap()
+	if err != nil {
+		m.logger.WithError(err).Error("Failed to get users for spending cap check")
+		return
+	}
+
+	prices := models.UsagePrices(m.config.Billing.UsagePrices)
+	for _, user := range users {
+		spending, err := db.GetUserSpending(user, prices)
+		if err != nil {
+			m.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to get spending")
+			continue
+		}
+		if !spending.Reached() {
+			continue
+		}
+
+		first, err := db.MarkSpendingCapNotified(user.ID, spending.Period)
+		if err != nil {
+			m.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to record spending cap notification")
+			continue
+		}
+		if !first {
+			continue
+		}
+
+		m.logger.WithFields(logrus.Fields{
+			"user_id":      user.ID,
+			"charges":      spending.Charges,
+			"spending_cap": spending.Cap,
+		}).Info("User reached spending cap")
+
+		message := fmt.Sprintf("Your usage charges this month reached your spending cap of $%.2f. Running instances keep running, but new instances cannot be created or started until next month or until you raise the cap.", float64(spending.Cap)/100)
+		if spending.Lifted {
+			message = fmt.Sprintf("Your usage charges this month reached your spending cap of $%.2f. The cap was lifted for this month, so your instances are not restricted.", float64(spending.Cap)/100)
+		}
+		notification := &models.Notification{
+			UserID:  user.ID,
+			Type:    models.NotificationSpendingCapReached,
+			Level:   models.EventLevelWarning,
+			Title:   "Spending cap reached",
+			Message: message,
+		}
+		if err := db.CreateNotification(notification); err != nil {
+			m.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to create notification")
+		}
+		event := models.NewUsageWebhookEvent(user.ID, nil, models.UsageThresholdSpendingCap, spending.Cap, spending.Charges)
+		if err := db.QueueWebhookEvent(event, nil); err != nil {
+			m.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to queue spending cap webhook event")
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/payments"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSubscriptionSyncRunning is returned when a subscription sync is started while another
+// one runs on this replica
+var ErrSubscriptionSyncRunning = errors.New("a subscription sync is already running")
+
+// SubscriptionEventHandler applies a subscription event the way the payment provider's
+// webhooks are applied
+type SubscriptionEventHandler func(event payments.WebhookEvent) error
+
+// SubscriptionSyncResult counts the subscriptions a sync compared with the payment provider
+type SubscriptionSyncResult struct {
+	Checked   int `json:"checked"`
+	Corrected int `json:"corrected"`
+	Failed    int `json:"failed"`
+}
+
+// SubscriptionSync compares the subscriptions of users with the payment provider on the
+// configured interval and corrects the status, plan and period end that missed webhooks left
+// out of date. Corrections are applied as subscription update events, so they are journaled,
+// notified and enforced like the webhooks they replace.
+type SubscriptionSync struct {
+	provider payments.Provider
+	handler  SubscriptionEventHandler
+	config   *config.Config
+	logger   *logrus.Logger
+
+	running sync.Mutex
+}
+
+// NewSubscriptionSync creates a new subscription sync
+func NewSubscriptionSync(provider payments.Provider, handler SubscriptionEventHandler, cfg *config.Config, logger *logrus.Logger) *SubscriptionSync {
+	return &SubscriptionSync{
+		provider: provider,
+		handler:  handler,
+		config:   cfg,
+		logger:   logger,
+	}
+}
+
+// Start syncs subscriptions on the configured interval until the context is cancelled; an
+// interval of 0 leaves syncs to admins
+func (s *SubscriptionSync) Start(ctx context.Context) {
+	interval := s.config.Billing.SubscriptionSyncInterval
+	if interval <= 0 {
+		s.logger.Info("Subscription sync interval is 0, subscriptions are only synced on demand")
+		return
+	}
+	s.logger.Infof("Starting %s subscription sync every %v", s.provider.Name(), interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("subscription_sync", interval)
+	singleton := lease.NewSingleton("subscription_sync", interval, s.config, s.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() {
+					if _, err := s.Sync(ctx); err != nil && !errors.Is(err, ErrSubscriptionSyncRunning) {
+						s.logger.WithError(err).Error("Failed to sync subscriptions")
+					}
+				})
+			}
+		}
+	}
+}
+
+// Sync compares every subscription of the payment provider that has not expired with the
+// provider and corrects the ones that drifted
+func (s *SubscriptionSync) Sync(ctx context.Context) (SubscriptionSyncResult, error) {
+	var result SubscriptionSyncResult
+	if !s.running.TryLock() {
+		return result, ErrSubscriptionSyncRunning
+	}
+	defer s.running.Unlock()
+
+	users, err := db.GetUsersWithSubscription(s.provider.Name())
+	if err != nil {
+		return result, err
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		result.Checked++
+
+		corrected, err := s.syncUser(ctx, user)
+		if err != nil {
+			result.Failed++
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":         user.ID,
+				"subscription_id": user.SubscriptionID,
+			}).Warn("Failed to sync subscription")
+			continue
+		}
+		if corrected {
+			result.Corrected++
+		}
+	}
+
+	metrics.ObserveSubscriptionSync(result.Checked-result.Corrected-result.Failed, result.Corrected, result.Failed)
+	s.logger.WithFields(logrus.Fields{
+		"checked":   result.Checked,
+		"corrected": result.Corrected,
+		"failed":    result.Failed,
+	}).Info("Synced subscriptions with the payment provider")
+	return result, nil
+}
+
+// syncUser compares a user's subscription with the provider and applies what differs,
+// reporting whether anything was corrected
+func (s *SubscriptionSync) syncUser(ctx context.Context, user models.User) (bool, error) {
+	subscription, err := s.provider.GetSubscription(ctx, user.SubscriptionID)
+	if err != nil {
+		return false, err
+	}
+
+	event := payments.WebhookEvent{
+		Type:           payments.EventSubscriptionUpdated,
+		SubscriptionID: user.SubscriptionID,
+		Status:         user.SubscriptionStatus,
+	}
+	var drift []string
+	if subscription.Status != user.SubscriptionStatus && syncableStatus(user.SubscriptionStatus, subscription.Status) {
+		event.Status = subscription.Status
+		drift = append(drift, "status")
+	}
+	if subscription.Plan != "" && subscription.Plan != user.Plan {
+		event.Plan = subscription.Plan
+		drift = append(drift, "plan")
+	}
+	if !subscription.PeriodEnd.IsZero() && !subscription.PeriodEnd.Truncate(time.Second).Equal(user.CurrentPeriodEnd.Truncate(time.Second)) {
+		event.PeriodEnd = subscription.PeriodEnd
+		drift = append(drift, "period_end")
+	}
+	if len(drift) == 0 {
+		return false, nil
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":         user.ID,
+		"subscription_id": user.SubscriptionID,
+		"drift":           drift,
+		"status":          subscription.Status,
+		"previous_status": user.SubscriptionStatus,
+	}).Warn("Correcting subscription that drifted from the payment provider")
+	if err := s.handler(event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// syncableStatus checks if a user's subscription status may be replaced by the provider's.
+// Statuses the provider reports that have no meaning here, such as a subscription waiting for
+// approval, are left alone, and so are trials the provider reports as active, since PayPal
+// reports trialing subscriptions as active.
+func syncableStatus(current, reported models.SubscriptionStatus) bool {
+	switch reported {
+	case models.StatusTrial, models.StatusCanceled, models.StatusExpired, models.StatusPastDue:
+		return true
+	case models.StatusActive:
+		return current != models.StatusTrial
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// TrashReaper deletes trashed instances for good once their retention ends, removing their
+// containers, volumes and DNS records on the job queue
+type TrashReaper struct {
+	instanceJobs *InstanceJobs
+	config       *config.Config
+	logger       *logrus.Logger
+}
+
+// NewTrashReaper creates a new trash reaper
+func NewTrashReaper(instanceJobs *InstanceJobs, cfg *config.Config, logger *logrus.Logger) *TrashReaper {
+	return &TrashReaper{
+		instanceJobs: instanceJobs,
+		config:       cfg,
+		logger:       logger,
+	}
+}
+
+// Start reaps the trash on the configured interval until the context is cancelled
+func (r *TrashReaper) Start(ctx context.Context) {
+	interval := r.config.Trash.ReapInterval
+	r.logger.Infof("Starting trash reaper every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("trash_reaper", interval)
+	singleton := lease.NewSingleton("trash_reaper", interval, r.config, r.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(r.Reap)
+			}
+		}
+	}
+}
+
+// Reap queues the deletion of every trashed instance whose retention ended. An instance stays
+// in the trash, to be retried, if its deletion cannot be queued.
+func (r *TrashReaper) Reap() {
+	instances, err := db.GetExpiredTrashedInstances(time.Now())
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to get expired trashed instances")
+		return
+	}
+
+	for i := range instances {
+		instance := &instances[i]
+		logger := r.logger.WithField("instance_id", instance.ID)
+
+		instance.Status = models.StatusDeleting
+		if err := db.UpdateInstance(instance); err != nil {
+			logger.WithError(err).Error("Failed to mark trashed instance as deleting")
+			continue
+		}
+		if _, err := r.instanceJobs.Delete(instance); err != nil {
+			logger.WithError(err).Error("Failed to queue deletion of trashed instance")
+			instance.Status = models.StatusTrashed
+			if err := db.UpdateInstance(instance); err != nil {
+				logger.WithError(err).Error("Failed to return instance to trash")
+			}
+			continue
+		}
+		logger.Info("Queued deletion of instance whose trash retention ended")
+	}
+}
@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// PlanChangeHandler applies the limits of a user's new plan to their instances
+type PlanChangeHandler func(user models.User)
+
+// TrialMonitor reminds users on the configured days before their trial ends, and moves users
+// whose trial ended without a subscription to the free plan. Trials of provider subscriptions
+// turn into paid subscriptions with the provider, so they are only reminded.
+type TrialMonitor struct {
+	planChanged PlanChangeHandler
+	config      *config.Config
+	logger      *logrus.Logger
+}
+
+// NewTrialMonitor creates a new trial monitor. planChanged is called for users moved to the
+// free plan.
+func NewTrialMonitor(planChanged PlanChangeHandler, cfg *config.Config, logger *logrus.Logger) *TrialMonitor {
+	return &TrialMonitor{
+		planChanged: planChanged,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// Start checks trials on the configured interval until the context is cancelled
+func (m *TrialMonitor) Start(ctx context.Context) {
+	interval := m.config.Trials.CheckInterval
+	m.logger.Infof("Starting trial checks every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("trial_monitor", interval)
+	singleton := lease.NewSingleton("trial_monitor", interval, m.config, m.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(m.CheckAll)
+			}
+		}
+	}
+}
+
+// CheckAll reminds the users whose trial ends soon and ends the trials that ran out
+func (m *TrialMonitor) CheckAll() {
+	users, err := db.GetTrialUsers()
+	if err != nil {
+		m.logger.WithError(err).Error("Failed to get users for trial check")
+		return
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		if user.CurrentPeriodEnd.IsZero() || user.IsAdmin() {
+			continue
+		}
+		if !now.Before(user.CurrentPeriodEnd) {
+			if user.SubscriptionID == "" {
+				m.endTrial(user, now)
+			}
+			continue
+		}
+		m.remind(user, now)
+	}
+}
+
+// remind tells a user their trial ends soon once the remaining time reaches a reminder day,
+// unless they were already reminded since. Extended trials are reminded again before their
+// new end.
+func (m *TrialMonitor) remind(user models.User, now time.Time) {
+	left := user.CurrentPeriodEnd.Sub(now)
+	due := 0
+	for _, days := range m.config.Trials.ReminderDays {
+		if left <= time.Duration(days)*24*time.Hour && (due == 0 || days < due) {
+			due = days
+		}
+	}
+	if due == 0 {
+		return
+	}
+
+	since := user.CurrentPeriodEnd.Add(-time.Duration(due) * 24 * time.Hour)
+	first, err := db.MarkTrialReminded(user.ID, since, now)
+	if err != nil {
+		m.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to record trial reminder")
+		return
+	}
+	if !first {
+		return
+	}
+
+	daysLeft := int(math.Ceil(left.Hours() / 24))
+	title := fmt.Sprintf("Your trial ends in %d days", daysLeft)
+	if daysLeft == 1 {
+		title = "Your trial ends in 1 day"
+	}
+	endsOn := user.CurrentPeriodEnd.UTC().Format("January 2, 2006")
+	message := fmt.Sprintf("Your %s trial ends on %s. Subscribe to keep the %s plan; otherwise your account moves to the free plan and its limits apply to your instances.",
+		planName(user.Plan), endsOn, planName(user.Plan))
+	if user.SubscriptionID != "" {
+		message = fmt.Sprintf("Your %s trial ends on %s, when the first payment of your subscription is charged.", planName(user.Plan), endsOn)
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"user_id":   user.ID,
+		"plan":      user.Plan,
+		"days_left": daysLeft,
+	}).Info("Reminded user that their trial ends")
+	metrics.ObserveTrialEvent("reminded")
+	m.notify(user, models.NotificationTrialEnding, models.EventLevelInfo, title, message)
+}
+
+// endTrial moves a user whose trial ran out without a subscription to the free plan
+func (m *TrialMonitor) endTrial(user models.User, now time.Time) {
+	ended, err := db.EndExpiredTrial(user, now)
+	if err != nil {
+		m.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to end trial")
+		return
+	}
+	if !ended {
+		return
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"plan":    user.Plan,
+	}).Info("Trial ended, moved user to the free plan")
+	metrics.ObserveTrialEvent("ended")
+	m.notify(user, models.NotificationTrialEnded, models.EventLevelWarning, "Your trial has ended",
+		fmt.Sprintf("Your %s trial has ended and your account moved to the free plan, whose limits now apply to your instances. Subscribe to get the %s plan back.",
+			planName(user.Plan), planName(user.Plan)))
+
+	user.Plan = models.PlanFree
+	user.SubscriptionStatus = ""
+	if m.planChanged != nil {
+		m.planChanged(user)
+	}
+}
+
+// notify adds a notification about the user's trial to their notification center
+func (m *TrialMonitor) notify(user models.User, notificationType models.NotificationType, level models.EventLevel, title, message string) {
+	notification := &models.Notification{
+		UserID:  user.ID,
+		Type:    notificationType,
+		Level:   level,
+		Title:   title,
+		Message: truncate(message, 1000),
+	}
+	if err := db.CreateNotification(notification); err != nil {
+		m.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to create notification")
+	}
+}
+
+// planName returns the display name of a plan
+func planName(plan models.SubscriptionPlan) string {
+	if p, ok := models.GetPlan(plan); ok {
+		return p.DisplayName
+	}
+	return string(plan)
+}
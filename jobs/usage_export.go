@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// UsageSink receives the usage records of closed billing periods, so the payment
+// layer can charge metered usage
+type UsageSink interface {
+	ExportUsage(ctx context.Context, records []models.UsageRecord) error
+}
+
+// UsageExportJob hands the usage records of closed months to a usage sink
+type UsageExportJob struct {
+	sink     UsageSink
+	interval  time.Duration
+	logger    *logrus.Logger
+	singleton *lease.Singleton
+}
+
+// NewUsageExportJob creates a new usage export job
+func NewUsageExportJob(sink UsageSink, cfg *config.Config, logger *logrus.Logger) *UsageExportJob {
+	return &UsageExportJob{
+		sink:      sink,
+		interval:  cfg.Billing.UsageExportInterval,
+		logger:    logger,
+		singleton: lease.NewSingleton("usage_export", cfg.Billing.UsageExportInterval, cfg, logger),
+	}
+}
+
+// Start exports closed periods on the configured interval until the context is cancelled
+func (j *UsageExportJob) Start(ctx context.Context) {
+	j.logger.Infof("Starting usage export every %v", j.interval)
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	if j.singleton.Acquire() {
+		j.Run(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if j.singleton.Acquire() {
+				j.Run(ctx)
+			}
+		}
+	}
+}
+
+// Run exports every closed usage record that has not been exported yet
+func (j *UsageExportJob) Run(ctx context.Context) {
+	records, err := db.GetUnexportedUsageRecords(models.UsagePeriod(time.Now()))
+	if err != nil {
+		j.logger.WithError(err).Error("Failed to fetch usage records for export")
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	if err := j.sink.ExportUsage(ctx, records); err != nil {
+		j.logger.WithError(err).Error("Failed to export usage records")
+		return
+	}
+
+	ids := make([]uuid.UUID, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	if err := db.MarkUsageRecordsExported(ids); err != nil {
+		j.logger.WithError(err).Error("Failed to mark usage records as exported")
+		return
+	}
+
+	j.logger.WithField("records", len(records)).Info("Exported usage records")
+}
+
+// WebhookUsageSink posts usage records to the payment layer as JSON, signed with an
+// HMAC-SHA256 of the body in the X-LaunchStack-Signature header
+type WebhookUsageSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookUsageSink creates a usage sink from the billing configuration, or returns
+// nil if no usage webhook is configured
+func NewWebhookUsageSink(cfg *config.Config) *WebhookUsageSink {
+	if cfg.Billing.UsageWebhookURL == "" {
+		return nil
+	}
+	return &WebhookUsageSink{
+		url:    cfg.Billing.UsageWebhookURL,
+		secret: cfg.Billing.UsageWebhookSecret,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ExportUsage posts the records in a single request
+func (s *WebhookUsageSink) ExportUsage(ctx context.Context, records []models.UsageRecord) error {
+	usage := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		usage[i] = record.ToPublicResponse()
+		usage[i]["id"] = record.ID
+		usage[i]["user_id"] = record.UserID
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"type":  "usage.period_closed",
+		"usage": usage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode usage records: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write(body)
+		req.Header.Set("X-LaunchStack-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send usage records: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("usage webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// networkCounters are the cumulative container network counters of the previous sample
+type networkCounters struct {
+	in  int64
+	out int64
+}
+
+// UsageMeter turns resource monitoring samples into monthly usage records for billing, and
+// notes the instances whose inbound traffic shows they are in use
+type UsageMeter struct {
+	mu           sync.Mutex
+	last         map[uuid.UUID]networkCounters
+	trafficBytes int64
+	logger       *logrus.Logger
+}
+
+// NewUsageMeter creates a new usage meter
+func NewUsageMeter(cfg *config.Config, logger *logrus.Logger) *UsageMeter {
+	return &UsageMeter{
+		last:         make(map[uuid.UUID]networkCounters),
+		trafficBytes: cfg.Sleep.TrafficBytes,
+		logger:       logger,
+	}
+}
+
+// Record meters one resource sample of an instance, which is taken to cover the
+// monitoring interval that ended when it was collected
+func (m *UsageMeter) Record(instance models.Instance, usage *models.ResourceUsage, interval time.Duration) {
+	in, out := m.networkDelta(instance.ID, usage.NetworkIn, usage.NetworkOut)
+
+	record := &models.UsageRecord{
+		UserID:          instance.UserID,
+		Period:          models.UsagePeriod(usage.Timestamp),
+		CPUSeconds:      usage.CPUUsage / 100 * interval.Seconds(),
+		MemoryMBHours:   float64(usage.MemoryUsage) / 1024 / 1024 * interval.Hours(),
+		NetworkInBytes:  in,
+		NetworkOutBytes: out,
+	}
+	if err := db.AddUsage(record); err != nil {
+		m.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to meter instance usage")
+	}
+
+	// Health probes alone stay below the threshold; requests to n8n's editor or webhooks
+	// and responses to its outgoing calls do not
+	if in >= m.trafficBytes {
+		if err := db.RecordInstanceActivity(instance.ID, usage.Timestamp); err != nil {
+			m.logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record instance activity")
+		}
+	}
+}
+
+// Forget drops the network counters of an instance that is no longer monitored
+func (m *UsageMeter) Forget(instanceID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.last, instanceID)
+}
+
+// networkDelta returns the traffic since the previous sample of an instance. Container
+// counters are cumulative and reset when the container restarts; the first sample after
+// startup only sets the baseline.
+func (m *UsageMeter) networkDelta(instanceID uuid.UUID, in, out int64) (int64, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, seen := m.last[instanceID]
+	m.last[instanceID] = networkCounters{in: in, out: out}
+	if !seen {
+		return 0, 0
+	}
+
+	deltaIn, deltaOut := in-previous.in, out-previous.out
+	if deltaIn < 0 {
+		deltaIn = in
+	}
+	if deltaOut < 0 {
+		deltaOut = out
+	}
+	return deltaIn, deltaOut
+}
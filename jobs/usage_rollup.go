@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/sirupsen/logrus"
+)
+
+// rollupBackfillDays is how many past days are rolled up again on startup
+const rollupBackfillDays = 7
+
+// rollupRunOffset is how long after midnight UTC the nightly rollup runs,
+// giving late resource samples for the previous day time to land
+const rollupRunOffset = 10 * time.Minute
+
+// UsageRollupJob writes per-user, per-day usage rollups every night
+type UsageRollupJob struct {
+	logger    *logrus.Logger
+	singleton *lease.Singleton
+}
+
+// NewUsageRollupJob creates a new usage rollup job
+func NewUsageRollupJob(cfg *config.Config, logger *logrus.Logger) *UsageRollupJob {
+	return &UsageRollupJob{
+		logger:    logger,
+		singleton: lease.NewSingleton("usage_rollup", 24*time.Hour, cfg, logger),
+	}
+}
+
+// Start backfills recent days and then rolls up the previous day every night
+// until the context is cancelled
+func (j *UsageRollupJob) Start(ctx context.Context) {
+	if j.singleton.Acquire() {
+		now := time.Now().UTC()
+		for i := rollupBackfillDays; i >= 1; i-- {
+			j.RunForDay(now.AddDate(0, 0, -i))
+		}
+	}
+
+	for {
+		next := nextRollupTime(time.Now().UTC())
+		j.logger.Infof("Next usage rollup scheduled for %s", next.Format(time.RFC3339))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+			if j.singleton.Acquire() {
+				j.RunForDay(next.AddDate(0, 0, -1))
+			}
+		}
+	}
+}
+
+// RunForDay rolls up usage for a single day
+func (j *UsageRollupJob) RunForDay(day time.Time) {
+	count, err := db.RollupDailyUsage(day)
+	if err != nil {
+		j.logger.WithError(err).WithField("day", day.Format("2006-01-02")).Error("Failed to roll up daily usage")
+		return
+	}
+
+	j.logger.WithFields(logrus.Fields{
+		"day":   day.Format("2006-01-02"),
+		"users": count,
+	}).Info("Daily usage rolled up")
+}
+
+// nextRollupTime returns the next nightly run time after now
+func nextRollupTime(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(rollupRunOffset)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,279 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/i18n"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrWaitlistEntryChanged is returned when a waitlist entry was cancelled, claimed or admitted
+// by someone else while it was being acted on
+var ErrWaitlistEntryChanged = errors.New("the waitlist entry was changed concurrently")
+
+// errInstanceLimitReached fails waitlisted requests of users who have since used up their instance allocation
+var errInstanceLimitReached = errors.New("instance limit reached")
+
+// Waitlist admits instance requests that found the host at capacity, in the order they
+// joined, once capacity frees up. Requests are provisioned right away or, if auto
+// provisioning is disabled, capacity is reserved for their user to claim.
+type Waitlist struct {
+	manager     container.Manager
+	provisioner *Provisioner
+	config      *config.Config
+	logger      *logrus.Logger
+	client      *http.Client
+}
+
+// NewWaitlist creates a new waitlist that provisions admitted requests with the provisioner
+func NewWaitlist(manager container.Manager, provisioner *Provisioner, cfg *config.Config, logger *logrus.Logger) *Waitlist {
+	return &Waitlist{
+		manager:     manager,
+		provisioner: provisioner,
+		config:      cfg,
+		logger:      logger,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Start processes the waitlist on the configured interval until the context is cancelled
+func (w *Waitlist) Start(ctx context.Context) {
+	w.logger.Infof("Starting waitlist processing every %v", w.config.Waitlist.CheckInterval)
+	ticker := time.NewTicker(w.config.Waitlist.CheckInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("waitlist", w.config.Waitlist.CheckInterval)
+	singleton := lease.NewSingleton("waitlist", w.config.Waitlist.CheckInterval, w.config, w.logger)
+
+	if singleton.Acquire() {
+		loop.Run(func() { w.Process(ctx) })
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(func() { w.Process(ctx) })
+			}
+		}
+	}
+}
+
+// Process releases reservations that were not claimed in time and admits waiting requests
+// in order, stopping at the first one the host has no room for
+func (w *Waitlist) Process(ctx context.Context) {
+	expired, err := db.ExpireWaitlistReservations(time.Now())
+	if err != nil {
+		w.logger.WithError(err).Error("Failed to expire waitlist reservations")
+	} else if expired > 0 {
+		w.logger.WithField("reservations", expired).Info("Expired unclaimed waitlist reservations")
+	}
+
+	entries, err := db.GetWaitlistEntriesByStatus(models.WaitlistWaiting)
+	if err != nil {
+		w.logger.WithError(err).Error("Failed to fetch waitlist")
+		return
+	}
+	for i := range entries {
+		if ctx.Err() != nil || !w.admit(ctx, &entries[i]) {
+			return
+		}
+	}
+}
+
+// admit provisions a waiting request, or reserves capacity for it, reporting whether the
+// requests behind it may be admitted as well
+func (w *Waitlist) admit(ctx context.Context, entry *models.WaitlistEntry) bool {
+	logger := w.logger.WithFields(logrus.Fields{
+		"waitlist_entry_id": entry.ID,
+		"user_id":           entry.UserID,
+	})
+
+	user, err := db.GetUserByID(entry.UserID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to load owner of waitlisted request")
+		return true
+	}
+
+	if w.config.Waitlist.AutoProvision {
+		instance, _, err := w.Provision(ctx, &user, entry, models.WaitlistWaiting)
+		switch {
+		case errors.Is(err, container.ErrHostAtCapacity):
+			return false
+		case errors.Is(err, ErrWaitlistEntryChanged):
+			return true
+		case err != nil:
+			logger.WithError(err).Warn("Failed to provision waitlisted request")
+		default:
+			logger.WithField("instance_id", instance.ID).Info("Provisioning waitlisted request")
+		}
+		if entry.Status != models.WaitlistWaiting {
+			w.notify(ctx, &user, entry)
+		}
+		return true
+	}
+
+	capacity, err := container.LoadCapacity(w.config)
+	if err != nil {
+		logger.WithError(err).Error("Failed to check capacity for waitlisted request")
+		return false
+	}
+	allocated, err := db.GetAllocatedResources(uuid.Nil)
+	if err != nil {
+		logger.WithError(err).Error("Failed to check capacity for waitlisted request")
+		return false
+	}
+	if !container.HasCapacity(capacity, allocated, entry.CPULimit, entry.MemoryLimit) {
+		return false
+	}
+
+	reservedUntil := time.Now().Add(w.config.Waitlist.ReservationPeriod)
+	entry.Status = models.WaitlistReserved
+	entry.ReservedUntil = &reservedUntil
+	reserved, err := db.TransitionWaitlistEntry(entry, models.WaitlistWaiting)
+	if err != nil {
+		logger.WithError(err).Error("Failed to reserve capacity for waitlisted request")
+		return false
+	}
+	if reserved {
+		logger.WithField("reserved_until", reservedUntil).Info("Reserved capacity for waitlisted request")
+		w.notify(ctx, &user, entry)
+	}
+	return true
+}
+
+// Provision creates the instance of a waitlisted request that has the given status and queues
+// its provisioning. Requests that can no longer be fulfilled, e.g. because the user reached
+// their instance limit, are marked as failed; ErrHostAtCapacity and db.ErrHostTaken leave
+// them unchanged.
+func (w *Waitlist) Provision(ctx context.Context, user *models.User, entry *models.WaitlistEntry, from models.WaitlistStatus) (*models.Instance, *models.ProvisioningJob, error) {
+	if from == models.WaitlistReserved {
+		ctx = container.WithReservation(ctx, entry.ID)
+	}
+
+	count, err := db.CountInstancesByUserID(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var instance *models.Instance
+	if int(count) >= user.GetInstancesLimit() {
+		err = errInstanceLimitReached
+	} else {
+		instance, err = w.manager.PrepareInstance(ctx, *user, entry.InstanceRequest())
+	}
+	if errors.Is(err, container.ErrHostAtCapacity) || errors.Is(err, db.ErrHostTaken) {
+		return nil, nil, err
+	}
+	if err != nil {
+		w.fail(entry, from, err)
+		return nil, nil, err
+	}
+
+	reservedUntil := entry.ReservedUntil
+	entry.Status = models.WaitlistProvisioned
+	entry.InstanceID = &instance.ID
+	entry.ReservedUntil = nil
+	admitted, err := db.TransitionWaitlistEntry(entry, from)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !admitted {
+		return nil, nil, ErrWaitlistEntryChanged
+	}
+
+	job := models.NewProvisioningJob(instance, "")
+	if err := w.provisioner.Submit(instance, job); err != nil {
+		// Put the request back so it is admitted again
+		entry.Status = from
+		entry.InstanceID = nil
+		entry.ReservedUntil = reservedUntil
+		if _, revertErr := db.TransitionWaitlistEntry(entry, models.WaitlistProvisioned); revertErr != nil {
+			w.logger.WithError(revertErr).WithField("waitlist_entry_id", entry.ID).Error("Failed to put waitlisted request back in line")
+		}
+		return nil, nil, fmt.Errorf("failed to save instance: %w", err)
+	}
+	return instance, job, nil
+}
+
+// fail marks a waitlisted request that can no longer be fulfilled as failed
+func (w *Waitlist) fail(entry *models.WaitlistEntry, from models.WaitlistStatus, reason error) {
+	entry.Status = models.WaitlistFailed
+	entry.Error = truncate(reason.Error(), 1000)
+	entry.ReservedUntil = nil
+	if _, err := db.TransitionWaitlistEntry(entry, from); err != nil {
+		w.logger.WithError(err).WithField("waitlist_entry_id", entry.ID).Error("Failed to mark waitlisted request as failed")
+	}
+}
+
+// notify tells the configured waitlist webhook that a waitlisted request was provisioned, got
+// a reservation or failed, so the user can be emailed. The subject and message are in the
+// user's language. The payload is signed with an HMAC-SHA256 of the body in the
+// X-LaunchStack-Signature header.
+func (w *Waitlist) notify(ctx context.Context, user *models.User, entry *models.WaitlistEntry) {
+	if w.config.Waitlist.WebhookURL == "" {
+		return
+	}
+	logger := w.logger.WithField("waitlist_entry_id", entry.ID)
+
+	locale, ok := i18n.Parse(user.Language)
+	if !ok {
+		locale = i18n.Default
+	}
+	template := "notification.waitlist_" + string(entry.Status)
+	args := []interface{}{entry.Name}
+	if entry.Status == models.WaitlistReserved && entry.ReservedUntil != nil {
+		args = append(args, entry.ReservedUntil.UTC().Format("2006-01-02 15:04 MST"))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"type":     "waitlist." + string(entry.Status),
+		"user_id":  user.ID,
+		"email":    user.Email,
+		"language": locale,
+		"subject":  i18n.Message(locale, template+".subject", args[0]),
+		"message":  i18n.Message(locale, template+".body", args...),
+		"entry":    entry.ToPublicResponse(0),
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to encode waitlist notification")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.Waitlist.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		logger.WithError(err).Error("Failed to create waitlist notification")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.config.Waitlist.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(w.config.Waitlist.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-LaunchStack-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to send waitlist notification")
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.WithField("status", resp.StatusCode).Warn("Waitlist webhook rejected notification")
+	}
+}
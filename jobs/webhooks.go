@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// webhookPruneInterval is how often old delivery attempts are removed from the deliveries log
+const webhookPruneInterval = 24 * time.Hour
+
+// errNonPublicAddress is returned when a webhook endpoint resolves to an address of the host's
+// own or private networks
+var errNonPublicAddress = errors.New("webhook endpoint resolves to a non-public address")
+
+// cgnatRange is the carrier-grade NAT range, which net.IP does not count as private
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicAddress checks if an address is outside the loopback, private, link-local,
+// carrier-grade NAT and unspecified ranges, so a webhook endpoint may be reached at it
+func IsPublicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() || cgnatRange.Contains(ip))
+}
+
+// rejectNonPublicAddress is a net.Dialer Control function refusing to connect to addresses
+// that are not public. It sees the address DNS resolved to, so host names pointing at the
+// internal network are refused as well, even when they are rebound after being registered.
+func rejectNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicAddress(ip) {
+		return fmt.Errorf("%w: %s", errNonPublicAddress, host)
+	}
+	return nil
+}
+
+// webhookTransport returns the transport of webhook deliveries. Outside development it only
+// connects to public addresses, directly rather than through a proxy, so the status codes in
+// the deliveries log cannot be used to scan the internal network.
+func webhookTransport(cfg *config.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Server.Environment == "development" {
+		return transport
+	}
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   rejectNonPublicAddress,
+	}
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// Webhooks delivers instance, backup and usage events to the webhook endpoints of users on the
+// job queue, so deliveries are retried with backoff until the endpoint accepts them. Every
+// attempt is logged for the endpoint's deliveries log.
+type Webhooks struct {
+	config *config.Config
+	logger *logrus.Logger
+	client *http.Client
+}
+
+// NewWebhooks creates the webhook delivery handler and registers it with the job queue
+func NewWebhooks(queue *Queue, cfg *config.Config, logger *logrus.Logger) *Webhooks {
+	w := &Webhooks{
+		config: cfg,
+		logger: logger,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: webhookTransport(cfg),
+			// Endpoints must answer themselves rather than send the event elsewhere
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	queue.Register(models.JobDeliverWebhook, w.deliver)
+	return w
+}
+
+// Start removes delivery attempts older than the retention from the deliveries log daily,
+// until the context is cancelled
+func (w *Webhooks) Start(ctx context.Context) {
+	ticker := time.NewTicker(webhookPruneInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("webhook_delivery_pruner", webhookPruneInterval)
+	singleton := lease.NewSingleton("webhook_delivery_pruner", webhookPruneInterval, w.config, w.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(w.prune)
+			}
+		}
+	}
+}
+
+// prune deletes delivery attempts older than the retention
+func (w *Webhooks) prune() {
+	deleted, err := db.PruneWebhookDeliveries(time.Now().Add(-w.config.Webhooks.DeliveryRetention))
+	if err != nil {
+		w.logger.WithError(err).Error("Failed to prune webhook deliveries")
+		return
+	}
+	w.logger.WithField("deleted", deleted).Debug("Pruned old webhook deliveries")
+}
+
+// deliver runs one attempt at delivering a queued event
+func (w *Webhooks) deliver(ctx context.Context, job *models.Job) error {
+	var payload models.WebhookDeliveryPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return Permanent(fmt.Errorf("invalid webhook delivery payload: %w", err))
+	}
+
+	endpoint, err := db.GetWebhookEndpointByID(payload.EndpointID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Permanent(errors.New("the webhook endpoint was deleted"))
+	}
+	if err != nil {
+		return err
+	}
+	if !endpoint.Active {
+		return Permanent(errors.New("the webhook endpoint was disabled"))
+	}
+
+	event := payload.Event
+	event.InstanceURL = (&models.Instance{URL: payload.InstanceSubdomain}).GetURL(w.config.Server.Domain)
+	_, err = w.send(ctx, endpoint, &event, job.Attempts)
+	return err
+}
+
+// Send posts an event to an endpoint right away, as a test delivery. It returns the HTTP
+// status of the response, or 0 if there was none.
+func (w *Webhooks) Send(ctx context.Context, endpoint *models.WebhookEndpoint, event *models.WebhookEvent) (int, error) {
+	return w.send(ctx, endpoint, event, 0)
+}
+
+// send posts an event to an endpoint and records the outcome on the endpoint and in its
+// deliveries log. The event is signed with an HMAC-SHA256 of the body in the
+// X-LaunchStack-Signature header. An endpoint answering 410 Gone is not sent the event again.
+func (w *Webhooks) send(ctx context.Context, endpoint *models.WebhookEndpoint, event *models.WebhookEvent, attempt int) (int, error) {
+	logger := w.logger.WithFields(logrus.Fields{
+		"webhook_endpoint_id": endpoint.ID,
+		"event_id":            event.ID,
+		"event_type":          event.Type,
+	})
+
+	started := time.Now()
+	status, err := w.post(ctx, endpoint, event)
+	message := ""
+	if err != nil {
+		message = truncate(err.Error(), 1000)
+		logger.WithError(err).Warn("Webhook delivery failed")
+	} else {
+		logger.Debug("Delivered webhook event")
+	}
+	if recordErr := db.RecordWebhookDelivery(endpoint.ID, status, message, time.Now()); recordErr != nil {
+		logger.WithError(recordErr).Warn("Failed to record webhook delivery")
+	}
+	delivery := &models.WebhookDelivery{
+		EndpointID:     endpoint.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Test:           event.Test,
+		Attempt:        attempt,
+		ResponseStatus: status,
+		Error:          message,
+		DurationMS:     time.Since(started).Milliseconds(),
+	}
+	if recordErr := db.CreateWebhookDelivery(delivery); recordErr != nil {
+		logger.WithError(recordErr).Warn("Failed to log webhook delivery")
+	}
+
+	if status == http.StatusGone {
+		return status, Permanent(err)
+	}
+	return status, err
+}
+
+// post sends the signed event body, treating any answer but a 2xx as a failure
+func (w *Webhooks) post(ctx context.Context, endpoint *models.WebhookEndpoint, event *models.WebhookEvent) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, Permanent(fmt.Errorf("failed to encode webhook event: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, Permanent(fmt.Errorf("failed to create webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LaunchStack-Webhooks/1")
+	req.Header.Set("X-LaunchStack-Event", string(event.Type))
+	req.Header.Set("X-LaunchStack-Delivery", event.ID.String())
+	req.Header.Set("X-LaunchStack-Signature", endpoint.Sign(body))
+
+	resp, err := w.client.Do(req)
+	if errors.Is(err, errNonPublicAddress) {
+		return 0, Permanent(err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// executionPruneInterval is how often workflow executions past their retention are deleted
+const executionPruneInterval = 24 * time.Hour
+
+// ExecutionPruner deletes the workflow executions reported by instances once their retention ends
+type ExecutionPruner struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewExecutionPruner creates a new workflow execution pruner
+func NewExecutionPruner(cfg *config.Config, logger *logrus.Logger) *ExecutionPruner {
+	return &ExecutionPruner{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Start prunes workflow executions daily until the context is cancelled
+func (p *ExecutionPruner) Start(ctx context.Context) {
+	p.logger.Infof("Starting workflow execution pruner, keeping executions for %v", p.config.N8N.ExecutionRetention)
+	ticker := time.NewTicker(executionPruneInterval)
+	defer ticker.Stop()
+	loop := metrics.NewLoop("workflow_execution_pruner", executionPruneInterval)
+	singleton := lease.NewSingleton("workflow_execution_pruner", executionPruneInterval, p.config, p.logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if singleton.Acquire() {
+				loop.Run(p.Prune)
+			}
+		}
+	}
+}
+
+// Prune deletes workflow executions older than the retention
+func (p *ExecutionPruner) Prune() {
+	deleted, err := db.PruneWorkflowExecutions(time.Now().Add(-p.config.N8N.ExecutionRetention))
+	if err != nil {
+		p.logger.WithError(err).Error("Failed to prune workflow executions")
+		return
+	}
+	p.logger.WithField("deleted", deleted).Debug("Pruned old workflow executions")
+}
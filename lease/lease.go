@@ -0,0 +1,58 @@
+package lease
+
+import (
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Singleton makes a background loop run on only one backend replica at a time. The replica
+// holding the loop's lease runs it and renews the lease on every run; the others skip their
+// runs until the lease lapses. It is not safe for concurrent use; each loop runs in a
+// single goroutine.
+type Singleton struct {
+	name   string
+	holder string
+	ttl    time.Duration
+	logger *logrus.Logger
+	held   bool
+}
+
+// NewSingleton creates the lease of a loop that runs every interval. The lease lasts the
+// interval plus the configured grace period, so it is renewed before it lapses.
+func NewSingleton(name string, interval time.Duration, cfg *config.Config, logger *logrus.Logger) *Singleton {
+	return &Singleton{
+		name:   name,
+		holder: cfg.Leases.ReplicaID,
+		ttl:    interval + cfg.Leases.GracePeriod,
+		logger: logger,
+	}
+}
+
+// Acquire takes or renews the lease, reporting whether this replica should run the loop now.
+// The loop is skipped when the lease cannot be checked, since another replica may hold it.
+func (s *Singleton) Acquire() bool {
+	acquired, err := db.AcquireLease(s.name, s.holder, s.ttl.Seconds())
+	if err != nil {
+		s.logger.WithError(err).WithField("lease", s.name).Error("Failed to acquire lease")
+		return false
+	}
+
+	if acquired != s.held {
+		s.held = acquired
+		logger := s.logger.WithFields(logrus.Fields{"lease": s.name, "replica_id": s.holder})
+		if acquired {
+			logger.Info("Acquired lease, running loop on this replica")
+		} else {
+			logger.Warn("Lost lease to another replica")
+		}
+	}
+	return acquired
+}
+
+// ReleaseAll releases the leases of this replica, e.g. on shutdown
+func ReleaseAll(cfg *config.Config) error {
+	return db.ReleaseLeases(cfg.Leases.ReplicaID)
+}
@@ -2,58 +2,43 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"net/http"
+	"os/signal"
 	"strings"
-	"time"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/launchstack/backend/config"
 	"github.com/launchstack/backend/container"
 	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/db/migrations"
+	"github.com/launchstack/backend/grpcapi"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/lease"
+	"github.com/launchstack/backend/metrics"
 	"github.com/launchstack/backend/middleware"
 	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/payments"
+	"github.com/launchstack/backend/proxy"
 	"github.com/launchstack/backend/routes"
+	"github.com/launchstack/backend/siem"
+	"github.com/launchstack/backend/storage"
 	"github.com/sirupsen/logrus"
 )
 
-// getCORSOrigins gets the CORS origins from environment
-func getCORSOrigins(logger *logrus.Logger) []string {
-	// Get CORS origins from environment or use default
-	corsOriginEnv := os.Getenv("CORS_ORIGINS")
-	var origins []string
-	
-	if corsOriginEnv != "" {
-		origins = strings.Split(corsOriginEnv, ",")
-	} else {
-		// Default origins
-		origins = []string{
-			"http://localhost:3000",
-			"https://app.launchstack.io",
-		}
-		logger.Warn("CORS_ORIGINS environment variable not set, using default origins")
-	}
-	
-	logger.Infof("CORS origins loaded from environment: %v", origins)
-	return origins
-}
-
-// initializeDatabase initializes the database connection and runs migrations
-func initializeDatabase(logger *logrus.Logger) error {
+// initializeDatabase initializes the database connection, which applies or verifies the
+// migrations as DB_MIGRATE says
+func initializeDatabase(cfg *config.Config, logger *logrus.Logger) error {
 	// Initialize database connection
 	logger.Info("Connecting to database...")
-	if err := db.InitDB(); err != nil {
+	if err := db.InitDB(cfg); err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	
-	// Run database migrations using the smart migration system
-	logger.Info("Checking if migrations need to be run...")
-	if err := db.RunMigrationsWithLogger(logger); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
-	
 	// Verify migrations by checking if tables exist
 	logger.Info("Verifying database schema...")
 	if err := verifyDatabaseSchema(logger); err != nil {
@@ -86,8 +71,12 @@ func main() {
 		FullTimestamp: true,
 	})
 	
+	// Keep secrets out of log fields, including those of the database layer
+	logger.AddHook(middleware.RedactionHook{})
+	db.Logger = logger
+	
 	// Load environment variables
-	if err := godotenv.Load(); err != nil {
+	if err := config.LoadEnvFile(); err != nil {
 		log.Println("Warning: .env file not found, using environment variables")
 	}
 	
@@ -96,6 +85,9 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to load configuration: %v", err)
 	}
+	for _, problem := range cfg.Problems() {
+		logger.WithField("problem", problem).Warn("Invalid configuration, starting anyway because CONFIG_FAIL_FAST is disabled")
+	}
 	
 	// Set log level based on configuration
 	logLevel, err := logrus.ParseLevel(cfg.Monitoring.LogLevel)
@@ -109,21 +101,63 @@ func main() {
 	logger.SetLevel(logLevel)
 	
 	// Initialize database
-	if err := initializeDatabase(logger); err != nil {
+	if err := initializeDatabase(cfg, logger); err != nil {
 		logger.Fatalf("Database initialization failed: %v", err)
 	}
 	
-	// Get CORS origins directly from environment
-	corsOrigins := getCORSOrigins(logger)
+	// Limits and prices of the plans are read from the catalog in the database
+	if err := db.LoadPlanCatalog(); err != nil {
+		logger.WithError(err).Warn("Failed to load plan catalog, using the default plans")
+	}
+	if err := models.ConfigurePlanTrials(cfg.Trials.Plans, cfg.Trials.MaxExtensionDays, cfg.Trials.OfferDays); err != nil {
+		logger.Fatalf("Invalid TRIAL_PLANS: %v", err)
+	}
+	if err := models.ConfigureSignupTrial(cfg.Trials.SignupPlan); err != nil {
+		logger.Fatalf("Invalid TRIAL_SIGNUP_PLAN: %v", err)
+	}
+	
+	// Downsample, compress and expire resource usage samples with TimescaleDB
+	err = migrations.ConfigureResourceUsageStorage(db.DB, migrations.ResourceUsagePolicy{
+		Retention:       cfg.Monitoring.Retention,
+		CompressAfter:   cfg.Monitoring.CompressAfter,
+		HourlyRetention: cfg.Monitoring.HourlyRetention,
+	})
+	if errors.Is(err, migrations.ErrTimescaleUnavailable) {
+		logger.Warn("TimescaleDB is not installed, resource usage samples are kept without downsampling or retention")
+	} else if err != nil {
+		logger.WithError(err).Warn("Failed to configure resource usage retention")
+	}
+	
+	// Reject changes to the billing journal in the database itself
+	if err := migrations.ProtectBillingJournal(db.DB); err != nil {
+		logger.WithError(err).Warn("Failed to make the billing journal append-only")
+	}
+	
+	// Export the database connection pool stats
+	if cfg.Metrics.Enabled {
+		sqlDB, err := db.DB.DB()
+		if err == nil {
+			err = metrics.RegisterDB(sqlDB)
+		}
+		if err != nil {
+			logger.WithError(err).Warn("Failed to export database pool metrics")
+		}
+	}
+	
+	if cfg.CORS.AllowAll {
+		logger.Warn("CORS_ORIGINS allows every origin; list the frontend origins instead")
+	}
 	
 	// Create container manager based on the configuration
 	var containerManager container.Manager
+	var dockerClient container.DockerClient
 	if cfg.Docker.Host != "" {
 		// Create Docker client
-		dockerClient, err := container.NewDockerClient(cfg.Docker.Host)
+		dockerClient, err = container.NewDockerClient(cfg)
 		if err != nil {
-			logger.WithError(err).Fatal("Failed to create Docker client")
+			logger.WithError(err).Fatal("Failed to connect to Docker; check DOCKER_HOST and the DOCKER_TLS_* settings")
 		}
+		logger.WithField("docker_host", cfg.Docker.Host).Info("Connected to Docker")
 		
 		// Create Docker container manager
 		containerManager = container.NewManager(dockerClient, cfg, logger)
@@ -131,48 +165,182 @@ func main() {
 		// Fall back to mock container manager
 		containerManager = container.NewMockManager(logger, cfg)
 	}
+	if cfg.Metrics.Enabled {
+		containerManager = container.Instrument(containerManager)
+	}
 	
-	// Start resource monitoring in a background goroutine
-	go func() {
-		logger.Infof("Starting resource usage monitoring every %v", cfg.Monitoring.Interval)
-		ticker := time.NewTicker(cfg.Monitoring.Interval)
-		defer ticker.Stop()
+	// Cancelled on SIGINT/SIGTERM to stop background workers and begin shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	
+	// Drop broken database connections and reconnect when the database goes away
+	go db.NewConnectionMonitor(cfg, logger).Start(ctx)
+	
+	// Stream container stats of running instances, recording them and metering usage for billing
+	usageMeter := jobs.NewUsageMeter(cfg, logger)
+	usageWriter := db.NewResourceUsageWriter(cfg.Monitoring.WriteBatchSize, cfg.Monitoring.WriteFlushInterval, logger)
+	go usageWriter.Start(ctx)
+	statsCollector := container.NewStatsCollector(containerManager, usageWriter, usageMeter, cfg, logger)
+	go statsCollector.Start(ctx)
+	
+	// Record the Docker host's resources for overcommit admission control and alert on its utilization
+	if dockerClient != nil {
+		go jobs.NewHostMonitor(dockerClient, cfg, logger).Start(ctx)
 		
-		for {
-			select {
-			case <-ticker.C:
-				// Get all active instances
-				var instances []models.Instance
-				if result := db.DB.Where("status != ?", models.StatusDeleted).Find(&instances); result.Error != nil {
-					logger.WithError(result.Error).Error("Failed to fetch instances for resource monitoring")
-					continue
-				}
-				
-				// Collect stats for each instance
-				for _, instance := range instances {
-					go func(inst models.Instance) {
-						ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-						defer cancel()
-						
-						_, err := containerManager.GetInstanceStats(ctx, inst.ID)
-						if err != nil {
-							logger.WithFields(logrus.Fields{
-								"instance_id": inst.ID,
-								"error":      err.Error(),
-							}).Warn("Failed to collect stats for instance")
-						}
-					}(instance)
-				}
-			}
+		// Keep the images of the templates pulled so instances are created without waiting for a pull
+		go container.NewImageCache(containerManager, cfg, logger).Start(ctx)
+	}
+	
+	// Pick up plan changes made through other replicas
+	go jobs.NewPlanCatalogRefresher(cfg.Plans.RefreshInterval, logger).Start(ctx)
+	
+	// Apply settings that can change without a restart when the configuration is reloaded, on
+	// SIGHUP or through the admin API
+	configWatcher := config.NewWatcher(logger)
+	configWatcher.Subscribe("log_level", func(cfg *config.Config) error {
+		level, err := logrus.ParseLevel(cfg.Monitoring.LogLevel)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %q", cfg.Monitoring.LogLevel)
 		}
-	}()
+		logger.SetLevel(level)
+		return nil
+	})
+	configWatcher.Subscribe("monitoring_interval", func(cfg *config.Config) error {
+		statsCollector.SetInterval(cfg.Monitoring.Interval)
+		return nil
+	})
+	configWatcher.Subscribe("plan_catalog", func(*config.Config) error {
+		return db.LoadPlanCatalog()
+	})
+	go configWatcher.Start(ctx)
+	
+	// Start storage quota enforcement in a background goroutine
+	go container.NewStorageMonitor(containerManager, cfg, logger).Start(ctx)
+	
+	// Move instances created before per-user networks were enabled onto their user's network
+	go container.NewNetworkIsolator(containerManager, cfg, logger).Start(ctx)
+	
+	// Write the egress rules of instances again after their containers restart
+	go container.NewEgressEnforcer(containerManager, cfg, logger).Start(ctx)
+	
+	// Encrypt secrets stored in plaintext or with a previous key with the current key
+	go jobs.NewSecretReencryptor(cfg, logger).Start(ctx)
+	
+	// Suspend instances of lapsed trials and failed payments, and resume them once paid
+	billingEnforcer := jobs.NewBillingEnforcer(containerManager, cfg, logger)
+	go billingEnforcer.Start(ctx)
+	
+	// Tell users when their metered charges reach their spending cap
+	go jobs.NewSpendingCapMonitor(cfg, logger).Start(ctx)
+	
+	// Remind users before their trial ends and move trials that ended unpaid to the free plan
+	go jobs.NewTrialMonitor(routes.PlanChangeHandler(containerManager, logger), cfg, logger).Start(ctx)
+	
+	// Check that the billing journal still balances and agrees with payments and plans
+	go jobs.NewBillingJournalAuditor(cfg, logger).Start(ctx)
+	
+	// Start nightly usage rollups in a background goroutine
+	go jobs.NewUsageRollupJob(cfg, logger).Start(ctx)
+	
+	// Hand closed months of metered usage to the payment layer, if a usage webhook is configured
+	if usageSink := jobs.NewWebhookUsageSink(cfg); usageSink != nil {
+		go jobs.NewUsageExportJob(usageSink, cfg, logger).Start(ctx)
+	}
+	
+	// Track instance TLS certificates through the reverse proxy, if one is configured
+	proxyProvider, err := proxy.NewProvider(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to configure reverse proxy provider")
+	}
+	if proxyProvider != nil {
+		go jobs.NewCertificateMonitor(proxyProvider, cfg, logger).Start(ctx)
+	}
+	
+	// Take payments and manage subscriptions with the configured provider, unless payments are
+	// mocked in development mode
+	var paymentProvider payments.Provider
+	if !cfg.PayPal.DisablePayments || cfg.Server.Environment != "development" {
+		paymentProvider, err = payments.NewProvider(cfg)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to configure payment provider")
+		}
+	}
+	
+	// Run instance provisioning, deletion, upgrades, rollbacks, backups, webhook deliveries and expensive
+	// Clerk webhook events on the persistent job queue, verifying the proxy route of new instances when a proxy is configured
+	jobQueue := jobs.NewQueue(cfg, logger)
+	provisioner := jobs.NewProvisioner(jobQueue, containerManager, proxyProvider, cfg, logger)
+	instanceJobs := jobs.NewInstanceJobs(jobQueue, containerManager, logger)
+	webhooks := jobs.NewWebhooks(jobQueue, cfg, logger)
+	clerkWebhooks := jobs.NewClerkWebhooks(jobQueue, routes.ClerkEventHandler(instanceJobs, paymentProvider, logger), cfg, logger)
+	objectStore, err := storage.New(cfg)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to configure object storage")
+	}
+	backupRunner := jobs.NewBackups(jobQueue, containerManager, objectStore, cfg, logger)
+	go jobQueue.Start(ctx)
+	
+	// Prune the delivery log of users' webhook endpoints
+	go webhooks.Start(ctx)
+	
+	// Prune the Clerk webhook events kept to recognise redeliveries
+	go clerkWebhooks.Start(ctx)
+	
+	// Delete trashed instances for good once their retention ends
+	go jobs.NewTrashReaper(instanceJobs, cfg, logger).Start(ctx)
+	
+	// Purge the data of accounts deleted in Clerk once their grace period ends
+	go jobs.NewAccountPurger(objectStore, cfg, logger).Start(ctx)
+	
+	// Delete workflow executions reported by instances once their retention ends
+	go jobs.NewExecutionPruner(cfg, logger).Start(ctx)
+	
+	// Queue backups of instances whose backup schedule is due
+	go backupRunner.Start(ctx)
+	
+	// Probe n8n inside running instances, restarting ones that stop responding and rolling back
+	// recent upgrades that broke them
+	go jobs.NewHealthMonitor(containerManager, instanceJobs, cfg, logger).Start(ctx)
+	
+	// Summarize database growth, slow endpoints, errors and pending DNS cleanups for operators
+	operatorReports := jobs.NewOperatorReportJob(containerManager, cfg, logger)
+	go operatorReports.Start(ctx)
+	
+	// Admit requests waiting for capacity as it frees up
+	waitlist := jobs.NewWaitlist(containerManager, provisioner, cfg, logger)
+	go waitlist.Start(ctx)
+	
+	// Put idle instances of the sleeping plans to sleep; requests to their URLs wake them
+	sleeper := jobs.NewInstanceSleeper(containerManager, cfg, logger)
+	go sleeper.Start(ctx)
+	
+	// Ship audit and access logs to the SIEM, if one is configured
+	siemExporter, err := siem.NewExporter(cfg, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to configure SIEM export")
+	}
+	if siemExporter != nil {
+		siemExporter.Start()
+	}
 	
 	// Initialize router
 	router := gin.Default()
 	
 	// Add middleware
 	router.Use(middleware.LoggerMiddleware(logger))
-	router.Use(middleware.CORSMiddleware(corsOrigins))
+	router.Use(middleware.ReplicaMiddleware(cfg.Leases.ReplicaID))
+	if siemExporter != nil {
+		router.Use(middleware.SIEMMiddleware(siemExporter))
+	}
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.MetricsMiddleware())
+	}
+	router.Use(middleware.CORSMiddleware(cfg, configWatcher))
+	// Turns errors handlers abort with into responses, after metrics and SIEM so they see the code
+	router.Use(middleware.ErrorHandler(logger))
+	router.NoRoute(middleware.NotFoundHandler())
+	router.Use(middleware.BodyLimit(cfg))
+	binding.EnableDecoderDisallowUnknownFields = cfg.Requests.StrictJSON
 	router.Use(middleware.AuthMiddleware(cfg.Clerk.SecretKey, logger, cfg))
 	
 	// Log configuration for debugging
@@ -195,10 +363,10 @@ func main() {
 	instanceRoutes := router.Group("/instances")
 	{
 		instanceRoutes.GET("", routes.GetInstances(containerManager))
-		instanceRoutes.POST("", routes.CreateInstance(containerManager))
+		instanceRoutes.POST("", routes.CreateInstance(containerManager, provisioner))
 		instanceRoutes.GET("/:id", routes.GetInstance(containerManager))
 		instanceRoutes.PUT("/:id", routes.UpdateInstance(containerManager))
-		instanceRoutes.DELETE("/:id", routes.DeleteInstance(containerManager))
+		instanceRoutes.DELETE("/:id", routes.DeleteInstance(cfg, containerManager, instanceJobs))
 		instanceRoutes.POST("/:id/start", routes.StartInstance(containerManager))
 		instanceRoutes.POST("/:id/stop", routes.StopInstance(containerManager))
 		instanceRoutes.POST("/:id/restart", routes.RestartInstance(containerManager))
@@ -206,17 +374,33 @@ func main() {
 		instanceRoutes.GET("/:id/stats/history", routes.GetInstanceHistoricalStats())
 	}
 	
-	routes.RegisterAllRoutes(router, cfg, containerManager, logger)
+	routes.RegisterAllRoutes(router, cfg, containerManager, provisioner, instanceJobs, waitlist, sleeper, backupRunner, objectStore, webhooks, clerkWebhooks, logger)
 	
 	// Register Clerk webhook routes
-	routes.RegisterClerkWebhookRoutes(router, cfg, logger)
+	routes.RegisterClerkWebhookRoutes(router, cfg, clerkWebhooks, logger)
 	
 	// Register mock payment routes if in development mode with payments disabled
-	if cfg.PayPal.DisablePayments && cfg.Server.Environment == "development" {
+	if paymentProvider == nil {
 		logger.Info("Registering mock payment routes for development mode")
 		routes.RegisterMockPaymentRoutes(router, logger)
+	} else {
+		routes.RegisterPaymentRoutes(router, paymentProvider, containerManager, billingEnforcer, logger)
+		
+		// Correct subscriptions whose payment webhooks were missed, on schedule or on demand
+		subscriptionSync := jobs.NewSubscriptionSync(paymentProvider, routes.SubscriptionEventHandler(paymentProvider, containerManager, billingEnforcer, logger), cfg, logger)
+		go subscriptionSync.Start(ctx)
+		routes.RegisterSubscriptionSyncRoutes(router, subscriptionSync, logger)
 	}
 	
+	// Register trial extensions, which extend provider subscriptions' trials with the provider
+	routes.RegisterTrialRoutes(router, cfg, paymentProvider, billingEnforcer, logger)
+	
+	// Register the operator report routes, which can generate a report on demand
+	routes.RegisterOperatorReportRoutes(router, operatorReports, logger)
+	
+	// Register the configuration reload route
+	routes.RegisterConfigReloadRoutes(router, configWatcher, logger)
+	
 	// Log all registered routes
 	for _, routeInfo := range router.Routes() {
 		logger.Infof("Registered route: %s %s", routeInfo.Method, routeInfo.Path)
@@ -224,8 +408,77 @@ func main() {
 	
 	// Start server
 	port := fmt.Sprintf(":%d", cfg.Server.Port)
-	logger.Infof("Starting server on port %s...", port)
-	if err := router.Run(port); err != nil {
-		logger.Fatalf("Failed to start server: %v", err)
+	server := &http.Server{
+		Addr:    port,
+		Handler: router,
+	}
+	
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Infof("Starting server on port %s...", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	
+	// Serve the internal container API for worker nodes and operator tools on its own port
+	var grpcServer *grpcapi.Server
+	if cfg.GRPC.Enabled {
+		grpcServer, err = grpcapi.NewServer(containerManager, cfg, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to configure internal gRPC API")
+		}
+		go func() {
+			if err := grpcServer.ListenAndServe(); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
+	
+	// Wait for a shutdown signal or a server failure
+	select {
+	case err := <-serverErr:
+		logger.Errorf("Server failed: %v", err)
+		stop()
+	case <-ctx.Done():
+		logger.Info("Shutdown signal received, draining in-flight requests...")
+	}
+	
+	// Drain in-flight requests, bounded by the shutdown timeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Server shutdown did not complete cleanly: %v", err)
+	} else {
+		logger.Info("HTTP server stopped")
 	}
+	if grpcServer != nil {
+		grpcServer.Shutdown(shutdownCtx)
+		logger.Info("gRPC server stopped")
+	}
+	
+	// Flush remaining audit events now that no more requests are being served
+	if err := siemExporter.Close(shutdownCtx); err != nil {
+		logger.Warnf("Failed to flush SIEM events: %v", err)
+	}
+	
+	// Write the resource usage samples still buffered
+	usageWriter.Flush()
+	
+	// Hand singleton background loops over to other replicas without waiting for leases to lapse
+	if err := lease.ReleaseAll(cfg); err != nil {
+		logger.Warnf("Failed to release leases: %v", err)
+	}
+	
+	// Close external connections
+	if dockerClient != nil {
+		if err := dockerClient.Close(); err != nil {
+			logger.Warnf("Failed to close Docker client: %v", err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		logger.Warnf("Failed to close database connection: %v", err)
+	}
+	
+	logger.Info("Shutdown complete")
 } 
\ No newline at end of file
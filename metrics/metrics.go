@@ -0,0 +1,272 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// namespace prefixes the names of all LaunchStack metrics
+const namespace = "launchstack"
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	apiErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_errors_total",
+		Help:      "Error responses by error code, or by status code for responses without one.",
+	}, []string{"code"})
+
+	containerOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "container_operation_duration_seconds",
+		Help:      "Duration of container manager operations.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"operation", "result"})
+
+	loopLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "loop_lag_seconds",
+		Help:      "How much later than its interval a background loop last ran.",
+	}, []string{"loop"})
+
+	loopDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "loop_duration_seconds",
+		Help:      "Duration of background loop runs.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+	}, []string{"loop"})
+
+	loopLastRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "loop_last_run_timestamp_seconds",
+		Help:      "Unix time a background loop last finished a run.",
+	}, []string{"loop"})
+
+	statsStreams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stats_streams",
+		Help:      "Open container stats streams of the resource usage collector.",
+	})
+
+	statsStaleInstances = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stats_stale_instances",
+		Help:      "Running instances without a new stats sample at the last collection.",
+	})
+
+	statsStreamRestarts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stats_stream_restarts_total",
+		Help:      "Container stats streams reopened after they ended or went stale.",
+	})
+
+	statsWriteBuffer = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stats_write_buffer_samples",
+		Help:      "Resource usage samples buffered for the next batched insert.",
+	})
+
+	statsDroppedSamples = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stats_dropped_samples_total",
+		Help:      "Resource usage samples dropped because database writes fell behind.",
+	})
+
+	statsWriteDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "stats_write_duration_seconds",
+		Help:      "Duration of batched resource usage inserts.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	billingJournalDiscrepancies = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "billing_journal_discrepancies",
+		Help:      "Problems found by the last billing journal integrity check.",
+	})
+
+	hostUtilization = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "host_utilization_ratio",
+		Help:      "Share of a Docker host's physical CPU or memory used by instances.",
+	}, []string{"host", "resource"})
+
+	hostAllocation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "host_allocation_ratio",
+		Help:      "Resources allocated to instances as a multiple of a Docker host's physical CPU or memory.",
+	}, []string{"host", "resource"})
+
+	subscriptionSyncChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "subscription_sync_checks_total",
+		Help:      "Subscriptions compared with the payment provider by result: in_sync, corrected or failed.",
+	}, []string{"result"})
+
+	userCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_user_cache_lookups_total",
+		Help:      "Lookups of authenticated users in the user cache by result, hit or miss.",
+	}, []string{"result"})
+
+	trialEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trial_events_total",
+		Help:      "Trial lifecycle events by event: reminded or ended.",
+	}, []string{"event"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		httpRequestDuration,
+		httpRequests,
+		apiErrors,
+		containerOperationDuration,
+		loopLag,
+		loopDuration,
+		loopLastRun,
+		statsStreams,
+		statsStaleInstances,
+		statsStreamRestarts,
+		statsWriteBuffer,
+		statsDroppedSamples,
+		statsWriteDuration,
+		billingJournalDiscrepancies,
+		hostUtilization,
+		hostAllocation,
+		subscriptionSyncChecks,
+		userCacheLookups,
+		trialEvents,
+	)
+}
+
+// RegisterDB exports the connection pool stats of the database
+func RegisterDB(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// ObserveHTTPRequest records a handled HTTP request; route is the route pattern, not the path,
+// so instance IDs do not create a series each
+func ObserveHTTPRequest(method, route, status string, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+	httpRequests.WithLabelValues(method, route, status).Inc()
+}
+
+// ObserveAPIError counts an error response by its error code
+func ObserveAPIError(code string) {
+	apiErrors.WithLabelValues(code).Inc()
+}
+
+// ObserveContainerOperation records how long a container manager operation took since start
+func ObserveContainerOperation(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	containerOperationDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+// Loop tracks the runs of a background loop that is meant to run every interval. It is not
+// safe for concurrent use; each loop runs in a single goroutine.
+type Loop struct {
+	name     string
+	interval time.Duration
+	last     time.Time
+}
+
+// NewLoop creates the tracker of a background loop
+func NewLoop(name string, interval time.Duration) *Loop {
+	return &Loop{name: name, interval: interval}
+}
+
+// Run runs one iteration of the loop, recording how late it started and how long it took
+func (l *Loop) Run(run func()) {
+	start := time.Now()
+	if !l.last.IsZero() {
+		lag := start.Sub(l.last) - l.interval
+		if lag < 0 {
+			lag = 0
+		}
+		loopLag.WithLabelValues(l.name).Set(lag.Seconds())
+	}
+	l.last = start
+
+	run()
+
+	loopDuration.WithLabelValues(l.name).Observe(time.Since(start).Seconds())
+	loopLastRun.WithLabelValues(l.name).SetToCurrentTime()
+}
+
+// ObserveStatsCollection records the state of the resource usage collector after a collection
+func ObserveStatsCollection(streams, stale, buffered int) {
+	statsStreams.Set(float64(streams))
+	statsStaleInstances.Set(float64(stale))
+	statsWriteBuffer.Set(float64(buffered))
+}
+
+// StatsStreamRestarted counts a container stats stream that was reopened
+func StatsStreamRestarted() {
+	statsStreamRestarts.Inc()
+}
+
+// StatsSamplesDropped counts resource usage samples that were not written
+func StatsSamplesDropped(samples int) {
+	statsDroppedSamples.Add(float64(samples))
+}
+
+// ObserveStatsWrite records how long a successful batched resource usage insert took since start
+func ObserveStatsWrite(start time.Time) {
+	statsWriteDuration.Observe(time.Since(start).Seconds())
+}
+
+// ObserveBillingJournalCheck records the number of problems a billing journal check found
+func ObserveBillingJournalCheck(discrepancies int) {
+	billingJournalDiscrepancies.Set(float64(discrepancies))
+}
+
+// ObserveHostUtilization records the share of a host's physical CPU and memory in use, and
+// what is allocated as a multiple of it
+func ObserveHostUtilization(host string, cpuUsed, memoryUsed, cpuAllocated, memoryAllocated float64) {
+	hostUtilization.WithLabelValues(host, "cpu").Set(cpuUsed)
+	hostUtilization.WithLabelValues(host, "memory").Set(memoryUsed)
+	hostAllocation.WithLabelValues(host, "cpu").Set(cpuAllocated)
+	hostAllocation.WithLabelValues(host, "memory").Set(memoryAllocated)
+}
+
+// ObserveSubscriptionSync counts the subscriptions a subscription sync found in sync, corrected,
+// or failed to check
+func ObserveSubscriptionSync(inSync, corrected, failed int) {
+	subscriptionSyncChecks.WithLabelValues("in_sync").Add(float64(inSync))
+	subscriptionSyncChecks.WithLabelValues("corrected").Add(float64(corrected))
+	subscriptionSyncChecks.WithLabelValues("failed").Add(float64(failed))
+}
+
+// ObserveUserCacheLookup counts a lookup of an authenticated user in the user cache
+func ObserveUserCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	userCacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveTrialEvent counts a user reminded that their trial ends, or moved to the free plan
+// when it ended
+func ObserveTrialEvent(event string) {
+	trialEvents.WithLabelValues(event).Inc()
+}
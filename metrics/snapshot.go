@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// EndpointStats are the requests of a route counted since the process started
+type EndpointStats struct {
+	Method       string
+	Route        string
+	Requests     uint64
+	ServerErrors uint64             // responses with a 5xx status
+	TotalSeconds float64            // summed duration of the requests
+	Buckets      map[float64]uint64 // requests taking at most each bucket's seconds
+}
+
+// Snapshot holds the request and error counters of this process at one point in time
+type Snapshot struct {
+	Endpoints  map[string]EndpointStats // by "METHOD route"
+	ErrorCodes map[string]uint64
+}
+
+// TakeSnapshot reads the current request and error counters
+func TakeSnapshot() (Snapshot, error) {
+	snapshot := Snapshot{
+		Endpoints:  map[string]EndpointStats{},
+		ErrorCodes: map[string]uint64{},
+	}
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return snapshot, err
+	}
+
+	for _, family := range families {
+		switch family.GetName() {
+		case namespace + "_http_request_duration_seconds":
+			for _, metric := range family.GetMetric() {
+				labels := metricLabels(metric)
+				key := labels["method"] + " " + labels["route"]
+				stats := snapshot.Endpoints[key]
+				stats.Method, stats.Route = labels["method"], labels["route"]
+				histogram := metric.GetHistogram()
+				stats.Requests = histogram.GetSampleCount()
+				stats.TotalSeconds = histogram.GetSampleSum()
+				stats.Buckets = map[float64]uint64{}
+				for _, bucket := range histogram.GetBucket() {
+					stats.Buckets[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+				}
+				snapshot.Endpoints[key] = stats
+			}
+		case namespace + "_http_requests_total":
+			for _, metric := range family.GetMetric() {
+				labels := metricLabels(metric)
+				if status, _ := strconv.Atoi(labels["status"]); status < 500 {
+					continue
+				}
+				key := labels["method"] + " " + labels["route"]
+				stats := snapshot.Endpoints[key]
+				stats.Method, stats.Route = labels["method"], labels["route"]
+				stats.ServerErrors += uint64(metric.GetCounter().GetValue())
+				snapshot.Endpoints[key] = stats
+			}
+		case namespace + "_api_errors_total":
+			for _, metric := range family.GetMetric() {
+				snapshot.ErrorCodes[metricLabels(metric)["code"]] = uint64(metric.GetCounter().GetValue())
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// Since returns the counts added after an earlier snapshot. Counters that went down, as after
+// a restart, are counted from zero.
+func (s Snapshot) Since(earlier Snapshot) Snapshot {
+	diff := Snapshot{
+		Endpoints:  map[string]EndpointStats{},
+		ErrorCodes: map[string]uint64{},
+	}
+	for key, stats := range s.Endpoints {
+		before, ok := earlier.Endpoints[key]
+		if !ok || before.Requests > stats.Requests || before.ServerErrors > stats.ServerErrors {
+			diff.Endpoints[key] = stats
+			continue
+		}
+		delta := EndpointStats{
+			Method:       stats.Method,
+			Route:        stats.Route,
+			Requests:     stats.Requests - before.Requests,
+			ServerErrors: stats.ServerErrors - before.ServerErrors,
+			TotalSeconds: stats.TotalSeconds - before.TotalSeconds,
+			Buckets:      map[float64]uint64{},
+		}
+		for bound, count := range stats.Buckets {
+			delta.Buckets[bound] = count - before.Buckets[bound]
+		}
+		diff.Endpoints[key] = delta
+	}
+	for code, count := range s.ErrorCodes {
+		if before := earlier.ErrorCodes[code]; before <= count {
+			count -= before
+		}
+		if count > 0 {
+			diff.ErrorCodes[code] = count
+		}
+	}
+	return diff
+}
+
+// MeanSeconds is the average duration of the requests
+func (e EndpointStats) MeanSeconds() float64 {
+	if e.Requests == 0 {
+		return 0
+	}
+	return e.TotalSeconds / float64(e.Requests)
+}
+
+// P95Seconds is the upper bound of the histogram bucket holding the 95th percentile request,
+// or +Inf if it took longer than the largest bucket
+func (e EndpointStats) P95Seconds() float64 {
+	if e.Requests == 0 {
+		return 0
+	}
+	bounds := make([]float64, 0, len(e.Buckets))
+	for bound := range e.Buckets {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+	target := uint64(math.Ceil(float64(e.Requests) * 0.95))
+	for _, bound := range bounds {
+		if e.Buckets[bound] >= target {
+			return bound
+		}
+	}
+	return math.Inf(1)
+}
+
+// metricLabels returns the labels of a metric by name
+func metricLabels(metric *dto.Metric) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range metric.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
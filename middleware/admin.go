@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin rejects requests from users without the admin role
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorBody(c, "user_not_found"))
+			c.Abort()
+			return
+		}
+
+		if !user.IsAdmin() {
+			c.JSON(http.StatusForbidden, ErrorBody(c, "admin_required"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireReseller rejects requests from users without the reseller role
+func RequireReseller() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorBody(c, "user_not_found"))
+			c.Abort()
+			return
+		}
+
+		if !user.IsReseller() {
+			c.JSON(http.StatusForbidden, ErrorBody(c, "reseller_required"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
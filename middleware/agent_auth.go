@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// agentTokenAudience keeps agent tokens from being accepted anywhere else the signing key might be used
+const agentTokenAudience = "launchstack-agent"
+
+// AgentClaims are the claims of a host agent token; the subject is the agent ID and the
+// token ID is the jti checked against the revocation list
+type AgentClaims struct {
+	Host string `json:"host"`
+	jwt.RegisteredClaims
+}
+
+// IssueAgentToken signs a new short-lived token scoped to an agent's host and records it so
+// it can be revoked. Records of tokens that expired a day ago are pruned along the way.
+func IssueAgentToken(cfg *config.Config, agent *models.HostAgent) (string, *models.AgentToken, error) {
+	now := time.Now()
+	record := &models.AgentToken{
+		ID:        uuid.New(),
+		AgentID:   agent.ID,
+		ExpiresAt: now.Add(cfg.Agents.TokenTTL),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, AgentClaims{
+		Host: agent.Host,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        record.ID.String(),
+			Subject:   agent.ID.String(),
+			Audience:  jwt.ClaimStrings{agentTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
+		},
+	})
+	signed, err := token.SignedString(cfg.Agents.TokenSecret)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign agent token: %w", err)
+	}
+
+	if err := db.CreateAgentToken(record); err != nil {
+		return "", nil, err
+	}
+	if _, err := db.DeleteExpiredAgentTokens(now.Add(-24 * time.Hour)); err != nil {
+		logrus.WithError(err).Warn("Failed to prune expired agent tokens")
+	}
+	return signed, record, nil
+}
+
+// parseAgentToken verifies an agent token's signature, audience and lifetime
+func parseAgentToken(cfg *config.Config, tokenString string) (*AgentClaims, error) {
+	claims := &AgentClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return cfg.Agents.TokenSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if !claims.VerifyAudience(agentTokenAudience, true) || claims.ExpiresAt == nil {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// AgentAuthMiddleware authenticates host agents by their bearer token. Tokens must be signed
+// with the agent signing key, unexpired, not on the revocation list, and belong to an agent
+// that is still registered for the host in the token.
+func AgentAuthMiddleware(cfg *config.Config, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		unauthorized := func(reason string) {
+			logger.WithFields(logrus.Fields{
+				"path":      c.Request.URL.Path,
+				"client_ip": c.ClientIP(),
+			}).Warn("Host agent authentication failed: " + reason)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			c.Abort()
+		}
+
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			unauthorized("missing bearer token")
+			return
+		}
+
+		claims, err := parseAgentToken(cfg, strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			unauthorized("invalid token")
+			return
+		}
+		tokenID, err := uuid.Parse(claims.ID)
+		if err != nil {
+			unauthorized("invalid token ID")
+			return
+		}
+		agentID, err := uuid.Parse(claims.Subject)
+		if err != nil {
+			unauthorized("invalid subject")
+			return
+		}
+
+		record, err := db.GetAgentTokenByID(tokenID)
+		if err != nil || record.AgentID != agentID || !record.IsValid(time.Now()) {
+			unauthorized("token revoked")
+			return
+		}
+
+		agent, err := db.GetHostAgentByID(agentID)
+		if err != nil || agent.IsRevoked() || agent.Host != claims.Host {
+			unauthorized("agent revoked")
+			return
+		}
+
+		c.Set("agent", *agent)
+		c.Set("agentToken", *record)
+		c.Next()
+	}
+}
+
+// GetAgentFromContext retrieves the authenticated host agent from the gin context
+func GetAgentFromContext(c *gin.Context) (models.HostAgent, error) {
+	agent, exists := c.Get("agent")
+	if !exists {
+		return models.HostAgent{}, errors.New("agent not found in context")
+	}
+	return agent.(models.HostAgent), nil
+}
+
+// GetAgentTokenFromContext retrieves the record of the token the host agent authenticated with
+func GetAgentTokenFromContext(c *gin.Context) (models.AgentToken, error) {
+	token, exists := c.Get("agentToken")
+	if !exists {
+		return models.AgentToken{}, errors.New("agent token not found in context")
+	}
+	return token.(models.AgentToken), nil
+}
@@ -23,10 +23,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// ImpersonateHeader is the header admins use to act on behalf of another user
+const ImpersonateHeader = "X-Impersonate-User"
+
 var (
 	jwksURL     string
 	jwks        *keyfunc.JWKS
-	jwksOnce    sync.Once
+	jwksMu      sync.Mutex
+	jwksRetryAt time.Time
 	jwksRefresh time.Duration = 12 * time.Hour
 )
 
@@ -35,8 +39,12 @@ func initJWKS(clerkInstanceID string, logger *logrus.Logger) error {
 	jwksURL = fmt.Sprintf("https://%s.clerk.accounts.dev/.well-known/jwks.json", clerkInstanceID)
 	logger.Infof("Initializing JWKS from %s", jwksURL)
 	
+	// Every replica keeps its own copy of the keys. A token signed with a key this replica has
+	// not seen yet triggers a refresh, so rotated keys work without a restart on all replicas.
 	options := keyfunc.Options{
-		RefreshInterval: jwksRefresh,
+		RefreshInterval:   jwksRefresh,
+		RefreshUnknownKID: true,
+		RefreshRateLimit:  time.Minute,
 		RefreshErrorHandler: func(err error) {
 			logger.Errorf("Error refreshing JWKS: %v", err)
 		},
@@ -53,18 +61,36 @@ func initJWKS(clerkInstanceID string, logger *logrus.Logger) error {
 	return nil
 }
 
+// getJWKS returns the JWKS from Clerk, initializing it on first use. A failed initialization
+// is retried at most once a minute, so a replica started while Clerk was unreachable recovers
+// on its own.
+func getJWKS(clerkInstanceID string, logger *logrus.Logger) (*keyfunc.JWKS, error) {
+	jwksMu.Lock()
+	defer jwksMu.Unlock()
+	
+	if jwks != nil {
+		return jwks, nil
+	}
+	if time.Now().Before(jwksRetryAt) {
+		return nil, errors.New("JWKS is not available")
+	}
+	if err := initJWKS(clerkInstanceID, logger); err != nil {
+		jwksRetryAt = time.Now().Add(time.Minute)
+		return nil, err
+	}
+	return jwks, nil
+}
+
 // AuthMiddleware validates the JWT token and adds the user to the context
 func AuthMiddleware(clerkSecretKey string, logger *logrus.Logger, cfg *config.Config) gin.HandlerFunc {
 	// Extract Clerk instance ID from the domain
 	// The format is usually "something.clerk.accounts.dev"
 	clerkInstanceID := strings.Split(cfg.Clerk.Issuer, ".")[0]
 	
-	// Initialize JWKS once
-	jwksOnce.Do(func() {
-		if err := initJWKS(clerkInstanceID, logger); err != nil {
-			logger.Errorf("Failed to initialize JWKS: %v", err)
-		}
-	})
+	// Initialize JWKS up front; requests retry if this fails
+	if _, err := getJWKS(clerkInstanceID, logger); err != nil {
+		logger.Errorf("Failed to initialize JWKS: %v", err)
+	}
 	
 	return func(c *gin.Context) {
 		// Skip authentication for public endpoints
@@ -83,6 +109,13 @@ func AuthMiddleware(clerkSecretKey string, logger *logrus.Logger, cfg *config.Co
 			return
 		}
 
+		// Signed API key requests carry the key ID instead of the key
+		if strings.HasPrefix(authHeader, SignatureScheme+" ") {
+			keyID := strings.TrimSpace(strings.TrimPrefix(authHeader, SignatureScheme+" "))
+			authenticateSignedRequest(c, keyID, cfg.APIKeys.SignatureMaxSkew, logger)
+			return
+		}
+		
 		// Check if it's a Bearer token
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
@@ -95,6 +128,12 @@ func AuthMiddleware(clerkSecretKey string, logger *logrus.Logger, cfg *config.Co
 		// Get the token
 		tokenString := parts[1]
 		
+		// API keys authenticate programmatic clients without a Clerk session
+		if strings.HasPrefix(tokenString, models.APIKeyPrefix) {
+			authenticateAPIKey(c, tokenString, logger)
+			return
+		}
+		
 		// Parse and validate the token
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			// Validate the algorithm
@@ -134,7 +173,11 @@ func AuthMiddleware(clerkSecretKey string, logger *logrus.Logger, cfg *config.Co
 			}
 			
 			// Get the key from JWKS for normal tokens
-			return jwks.Keyfunc(token)
+			keys, err := getJWKS(clerkInstanceID, logger)
+			if err != nil {
+				return nil, err
+			}
+			return keys.Keyfunc(token)
 		})
 		
 		if err != nil {
@@ -180,22 +223,56 @@ func AuthMiddleware(clerkSecretKey string, logger *logrus.Logger, cfg *config.Co
 		// Log successful token validation
 		logger.WithField("clerk_user_id", clerkUserID).Info("Token validated successfully")
 		
-		// Get user from database
-		user, err := db.FindUserByClerkID(clerkUserID)
+		// Get user from the user cache, or the database
+		user, err := db.FindCachedUserByClerkID(clerkUserID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				// User not found - could happen if they signed up but webhook hasn't processed yet
 				logger.WithField("clerk_user_id", clerkUserID).Warn("User not found in database")
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+				c.JSON(http.StatusUnauthorized, ErrorBody(c, "user_not_found"))
 			} else {
 				// Database error
 				logger.WithError(err).Error("Database error when fetching user")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				c.JSON(http.StatusInternalServerError, ErrorBody(c, "internal_error"))
 			}
 			c.Abort()
 			return
 		}
 
+		// Admins can act as another user for support via the impersonation header
+		if targetID := c.GetHeader(ImpersonateHeader); targetID != "" {
+			if !user.IsAdmin() {
+				logger.WithField("user_id", user.ID.String()).Warn("Non-admin user attempted impersonation")
+				c.JSON(http.StatusForbidden, gin.H{"error": "Impersonation requires admin role"})
+				c.Abort()
+				return
+			}
+			
+			impersonatedID, err := uuid.Parse(targetID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid impersonation user ID"})
+				c.Abort()
+				return
+			}
+			
+			impersonated, err := db.GetUserByID(impersonatedID)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Impersonated user not found"})
+				c.Abort()
+				return
+			}
+			
+			logger.WithFields(logrus.Fields{
+				"admin_id":        user.ID.String(),
+				"impersonated_id": impersonated.ID.String(),
+				"path":            c.Request.URL.Path,
+				"method":          c.Request.Method,
+			}).Warn("Admin is impersonating user")
+			
+			c.Set("impersonator", user)
+			user = impersonated
+		}
+
 		// Add user to context
 		c.Set("userID", user.ID)
 		c.Set("user", user)
@@ -216,13 +293,24 @@ func isPublicEndpoint(path string) bool {
 	publicPaths := []string{
 		"/api/v1/health",
 		"/api/v1/health/",
+		"/api/v1/health/probe",
 		"/health",
+		"/metrics", // guarded by METRICS_TOKEN instead
+		"/api/v1/branding",
+		"/api/v1/plans",
+		"/api/v1/templates",
+		"/api/v1/openapi.json",
+		"/api/v1/docs",
 		"/api/v1/auth/webhook",
 		"/api/v1/auth/webhook/",
 		"/api/v1/webhooks/clerk",
 		"/api/v1/webhooks/clerk/",
 		"/api/v1/webhooks/paypal",
 		"/api/v1/webhooks/paypal/",
+		"/api/v1/webhooks/stripe",
+		"/api/v1/webhooks/stripe/",
+		"/api/v1/webhooks/n8n", // signed with N8N_WEBHOOK_SECRET instead
+		"/api/v1/webhooks/n8n/",
 	}
 	
 	for _, publicPath := range publicPaths {
@@ -231,7 +319,33 @@ func isPublicEndpoint(path string) bool {
 		}
 	}
 	
-	return false
+	// Host agents authenticate with their own tokens, see AgentAuthMiddleware
+	if strings.HasPrefix(path, "/api/v1/agents/") {
+		return true
+	}
+
+	// Signed download URLs of locally stored objects carry their own authorization
+	if strings.HasPrefix(path, "/api/v1/storage/objects/") {
+		return true
+	}
+
+	// Browsers cannot set headers on WebSockets, so admin terminals connect with a single-use ticket
+	if strings.HasPrefix(path, "/api/v1/terminal/") {
+		return true
+	}
+
+	// Status badges are embedded in READMEs with signed URLs
+	if strings.HasPrefix(path, "/badge/") {
+		return true
+	}
+
+	// Called by the reverse proxy, which presents PROXY_INTERNAL_TOKEN instead
+	if strings.HasPrefix(path, "/api/v1/internal/") {
+		return true
+	}
+
+	// Served to visitors of instance URLs by the reverse proxy
+	return strings.HasPrefix(path, "/api/v1/instance-status-page/")
 }
 
 // LoggerMiddleware adds a logger to the gin context
@@ -245,40 +359,6 @@ func LoggerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 	}
 }
 
-// CORSMiddleware handles CORS settings
-func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		
-		// Log the origin for debugging
-		logger, exists := c.Get("logger")
-		if exists && logger != nil {
-			log := logger.(*logrus.Logger)
-			log.Infof("Received request with Origin: %s", origin)
-		}
-		
-		// Allow the specific requesting origin (most permissive valid approach)
-		if origin != "" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		} else {
-			// Fallback when no origin is provided
-			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-		
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		// Handle pre-flight OPTIONS request
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
-		
-		c.Next()
-	}
-}
-
 // GetUserFromContext gets the user from the gin context
 func GetUserFromContext(c *gin.Context) (models.User, error) {
 	user, exists := c.Get("user")
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/apierror"
+	"github.com/launchstack/backend/config"
+)
+
+// bodyPolicy is how large a request body may be and whether it must be JSON
+type bodyPolicy struct {
+	maxBytes int64
+	anyType  bool // false: only JSON bodies are accepted
+}
+
+// BodyLimit rejects request bodies larger than their route class allows with 413, and
+// bodies that are not JSON where JSON is expected with 415. Bodies without a Content-Length
+// are cut off at the limit while they are read. Requests without a body pass through.
+func BodyLimit(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		policy := requestBodyPolicy(cfg, c.Request.Method, c.Request.URL.Path)
+		if c.Request.ContentLength > policy.maxBytes {
+			apierror.Abort(c, apierror.New(apierror.RequestTooLarge, policy.maxBytes/1024).With("max_bytes", policy.maxBytes))
+			return
+		}
+		if !policy.anyType && !isJSONContentType(c.ContentType()) {
+			apierror.Abort(c, apierror.New(apierror.UnsupportedMediaType))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, policy.maxBytes)
+		c.Next()
+	}
+}
+
+// requestBodyPolicy returns the body policy of a route class: uploads to instances, webhooks
+// sent to the platform, and every other API request
+func requestBodyPolicy(cfg *config.Config, method, path string) bodyPolicy {
+	upload := int64(cfg.Files.MaxSizeMB) << 20
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	// /api/v1/instances/:id/files/*path and /api/v1/instances/:id/workflows/import
+	if len(segments) >= 5 && segments[0] == "api" && segments[1] == "v1" && segments[2] == "instances" {
+		if segments[4] == "files" && method == http.MethodPut {
+			return bodyPolicy{maxBytes: upload, anyType: true}
+		}
+		if len(segments) == 6 && segments[4] == "workflows" && segments[5] == "import" {
+			return bodyPolicy{maxBytes: upload}
+		}
+	}
+
+	if isWebhookPath(path) {
+		return bodyPolicy{maxBytes: int64(cfg.Requests.WebhookMaxBodyKB) << 10}
+	}
+	return bodyPolicy{maxBytes: int64(cfg.Requests.MaxBodyKB) << 10}
+}
+
+// isWebhookPath reports whether a path receives webhooks from Clerk, payment providers or
+// instances
+func isWebhookPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	return strings.HasPrefix(path, "/api/v1/webhooks/") || path == "/api/v1/auth/webhook" || path == "/api/auth/webhook"
+}
+
+// isJSONContentType reports whether a media type is JSON, e.g. application/json or
+// application/merge-patch+json
+func isJSONContentType(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
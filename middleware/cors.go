@@ -1,3 +1,112 @@
 package middleware
 
-// CORSMiddleware is now defined in auth.go 
\ No newline at end of file
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/sirupsen/logrus"
+)
+
+// CORSMiddleware lets browsers call the API from the origins in CORS_ORIGINS. Requests from
+// other origins get no CORS headers, so browsers keep pages from reading the responses, and
+// in strict mode are rejected with 403 so they have no effect either. Requests without an
+// Origin header, such as those of API clients, and same-origin requests are not affected.
+// The origins and strict mode are replaced when the watcher reloads the configuration.
+func CORSMiddleware(cfg *config.Config, watcher *config.Watcher) gin.HandlerFunc {
+	var policy atomic.Pointer[corsPolicy]
+	policy.Store(newCORSPolicy(cfg))
+	if watcher != nil {
+		watcher.Subscribe("cors", func(cfg *config.Config) error {
+			policy.Store(newCORSPolicy(cfg))
+			return nil
+		})
+	}
+
+	return func(c *gin.Context) {
+		cors := policy.Load()
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+
+		switch {
+		case originListed(cors.origins, origin):
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		case cors.allowAll:
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		case sameOrigin(c.Request, origin):
+			c.Next()
+			return
+		default:
+			if logger, ok := c.Get("logger"); ok {
+				logger.(*logrus.Logger).WithFields(logrus.Fields{
+					"origin": origin,
+					"method": c.Request.Method,
+					"path":   c.Request.URL.Path,
+					"strict": cors.strict,
+				}).Warn("Request from an origin not allowed by CORS_ORIGINS")
+			}
+			if cors.strict {
+				c.AbortWithStatusJSON(http.StatusForbidden, ErrorBody(c, "cors_origin_denied"))
+				return
+			}
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, Accept-Language, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Impersonate-User, If-None-Match, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, ETag")
+
+		// Handle pre-flight OPTIONS request
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// corsPolicy is the CORS configuration in effect
+type corsPolicy struct {
+	allowAll bool
+	origins  []config.OriginPattern
+	strict   bool
+}
+
+// newCORSPolicy takes the CORS settings of a configuration
+func newCORSPolicy(cfg *config.Config) *corsPolicy {
+	return &corsPolicy{
+		allowAll: cfg.CORS.AllowAll,
+		origins:  cfg.CORS.Origins,
+		strict:   cfg.CORS.Strict,
+	}
+}
+
+// originListed checks if an origin matches one of the allowed origin patterns
+func originListed(patterns []config.OriginPattern, origin string) bool {
+	for _, pattern := range patterns {
+		if pattern.Matches(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin checks if an Origin header names the host the request was sent to, as browsers
+// also send it on same-origin requests such as those of the Swagger UI
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && u.Host == r.Host
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/models"
+)
+
+// RequireEntitlement rejects requests from users whose plan does not include the given feature.
+// Returns 402 when an upgrade is needed and 403 when the subscription is no longer active.
+func RequireEntitlement(feature models.Feature) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorBody(c, "user_not_found"))
+			c.Abort()
+			return
+		}
+
+		if !user.HasFeature(feature) {
+			c.JSON(http.StatusPaymentRequired, entitlementError(c, user, feature, "feature_not_in_plan"))
+			c.Abort()
+			return
+		}
+
+		if user.SubscriptionStatus == models.StatusExpired {
+			c.JSON(http.StatusForbidden, entitlementError(c, user, feature, "subscription_inactive"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// entitlementError builds the payload returned when a feature is not available to the user
+func entitlementError(c *gin.Context, user models.User, feature models.Feature, code string) gin.H {
+	body := ErrorBody(c, code)
+	body["feature"] = feature
+	body["current_plan"] = user.Plan
+	body["subscription_status"] = user.SubscriptionStatus
+	body["upgrade_plans"] = models.PlansWithFeature(feature)
+	return body
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/apierror"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorHandler writes the response of requests stopped with apierror.Abort, and of requests
+// that panicked. Internal errors are logged with their cause and answered with a generic
+// message, so raw errors never reach clients. It must run after the metrics and SIEM
+// middleware so they see the error code.
+func ErrorHandler(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.WithFields(logrus.Fields{
+					"method": c.Request.Method,
+					"path":   c.Request.URL.Path,
+					"stack":  string(debug.Stack()),
+				}).Errorf("Recovered from panic: %v", recovered)
+				if !c.Writer.Written() {
+					respondWithError(c, apierror.New(apierror.InternalError))
+				}
+				c.Abort()
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := apierror.From(c.Errors.Last().Err)
+		if err.Status >= http.StatusInternalServerError {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"code":   err.Code,
+			}).Error("Request failed")
+		}
+		respondWithError(c, err)
+	}
+}
+
+// respondWithError writes the response of an API error: its code, the message in the locale
+// of the request and its fields
+func respondWithError(c *gin.Context, err *apierror.Error) {
+	body := ErrorBody(c, string(err.Code), err.Args...)
+	for key, value := range err.Fields {
+		if key != "error" && key != "code" {
+			body[key] = value
+		}
+	}
+	c.JSON(err.Status, body)
+}
+
+// NotFoundHandler answers requests to unknown routes with the error envelope
+func NotFoundHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apierror.Abort(c, apierror.New(apierror.RouteNotFound))
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireInternalToken only lets the reverse proxy through to internal endpoints, presenting
+// the token as a bearer token or, for callers like Caddy's on-demand TLS ask that cannot set
+// headers, in the token query parameter. Internal endpoints are disabled while the token is empty.
+func RequireInternalToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Internal API is not enabled"})
+			c.Abort()
+			return
+		}
+		presented, expected := c.Query("token"), token
+		if header := c.GetHeader("Authorization"); header != "" {
+			presented, expected = header, "Bearer "+token
+		}
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(expected)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid internal token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
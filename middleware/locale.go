@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/i18n"
+	"github.com/launchstack/backend/models"
+)
+
+// errorCodeKey is the context key of the error code of a request's response, for metrics
+const errorCodeKey = "error_code"
+
+// GetLocale returns the locale user-facing messages of a request are given in: the user's
+// language preference when signed in and set, otherwise the Accept-Language header
+func GetLocale(c *gin.Context) i18n.Locale {
+	if user, ok := c.Get("user"); ok {
+		if u, ok := user.(models.User); ok {
+			if locale, ok := i18n.Parse(u.Language); ok {
+				return locale
+			}
+		}
+	}
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// ErrorBody builds an error response with a machine-readable code and its message in the
+// locale of the request
+func ErrorBody(c *gin.Context, code string, args ...interface{}) gin.H {
+	locale := GetLocale(c)
+	c.Set(errorCodeKey, code)
+	c.Header("Content-Language", string(locale))
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return gin.H{"error": i18n.Message(locale, code, args...), "code": code}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/metrics"
+)
+
+// MetricsMiddleware records the duration and status of every request by route, and the error
+// code of error responses
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.ObserveHTTPRequest(c.Request.Method, route, status, time.Since(start))
+		if c.Writer.Status() >= http.StatusBadRequest {
+			code := c.GetString(errorCodeKey)
+			if code == "" {
+				code = "http_" + status
+			}
+			metrics.ObserveAPIError(code)
+		}
+	}
+}
+
+// RequireMetricsToken only lets scrapers presenting the metrics token through; every request
+// passes when the token is empty
+func RequireMetricsToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateWindow counts the requests of one client in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit allows each client IP at most limit requests per window on the routes it is used
+// on, answering further requests with 429 and a Retry-After header. Counts are kept in memory,
+// so every replica limits on its own.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var mu sync.Mutex
+	clients := map[string]*rateWindow{}
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// Forget clients whose window has passed, so the map does not grow without bound
+		if now.Sub(lastSweep) > window {
+			for key, client := range clients {
+				if now.Sub(client.start) >= window {
+					delete(clients, key)
+				}
+			}
+			lastSweep = now
+		}
+		client, ok := clients[ip]
+		if !ok || now.Sub(client.start) >= window {
+			client = &rateWindow{start: now}
+			clients[ip] = client
+		}
+		client.count++
+		exceeded := client.count > limit
+		retryAfter := client.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if exceeded {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+			return
+		}
+		c.Next()
+	}
+}
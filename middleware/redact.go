@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RedactionHook redacts secret log fields, such as tokens and passwords, and masks payment
+// provider identifiers before log entries are written. The values of the platform's own
+// secrets are redacted from messages and string fields too.
+type RedactionHook struct{}
+
+// Levels returns the log levels the hook applies to
+func (RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire redacts the fields of a log entry
+func (RedactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = models.RedactSecretValues(entry.Message)
+	for key, value := range entry.Data {
+		value = models.RedactField(key, value)
+		switch v := value.(type) {
+		case string:
+			value = models.RedactSecretValues(v)
+		case error:
+			if redacted := models.RedactSecretValues(v.Error()); redacted != v.Error() {
+				value = redacted
+			}
+		}
+		entry.Data[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicaHeader names the backend replica that handled a request
+const ReplicaHeader = "X-Replica-ID"
+
+// ReplicaMiddleware adds the replica ID to every response, so requests can be traced to a
+// replica when several run behind a load balancer
+func ReplicaMiddleware(replicaID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(ReplicaHeader, replicaID)
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/siem"
+)
+
+// SIEMMiddleware ships an access log event for every request, and an audit event for
+// every state-changing or impersonated request, to the configured SIEM
+func SIEMMiddleware(exporter *siem.Exporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		fields := map[string]interface{}{
+			"method":      c.Request.Method,
+			"path":        c.Request.URL.Path,
+			"route":       c.FullPath(),
+			"status":      c.Writer.Status(),
+			"latency_ms":  time.Since(start).Milliseconds(),
+			"client_ip":   c.ClientIP(),
+			"user_agent":  c.Request.UserAgent(),
+			"request_len": c.Request.ContentLength,
+		}
+		if userID, err := GetUserIDFromContext(c); err == nil {
+			fields["user_id"] = userID
+		}
+		if impersonator, exists := c.Get("impersonator"); exists {
+			if admin, ok := impersonator.(models.User); ok {
+				fields["impersonator_id"] = admin.ID
+			}
+		}
+		if apiKey, exists := c.Get("apiKey"); exists {
+			if key, ok := apiKey.(models.APIKey); ok {
+				fields["api_key_id"] = key.ID
+			}
+		}
+		if agent, err := GetAgentFromContext(c); err == nil {
+			fields["agent_id"] = agent.ID
+			fields["agent_host"] = agent.Host
+		}
+
+		exporter.Emit(siem.EventAccess, fields)
+
+		if isAuditable(c) {
+			audit := make(map[string]interface{}, len(fields)+1)
+			for k, v := range fields {
+				audit[k] = v
+			}
+			audit["action"] = c.Request.Method + " " + c.FullPath()
+			exporter.Emit(siem.EventAudit, audit)
+		}
+	}
+}
+
+// isAuditable checks if a request changed state or was made while impersonating a user
+func isAuditable(c *gin.Context) bool {
+	if _, impersonating := c.Get("impersonator"); impersonating {
+		return true
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RequireSpendingHeadroom rejects actions that add metered usage, such as starting
+// instances, with 402 once the user's charges this month reached their spending cap. The cap
+// applies again from the next month or when an admin lifts it for the current one.
+func RequireSpendingHeadroom(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorBody(c, "user_not_found"))
+			c.Abort()
+			return
+		}
+		if user.SpendingCap <= 0 {
+			c.Next()
+			return
+		}
+
+		spending, err := db.GetUserSpending(user, models.UsagePrices(cfg.Billing.UsagePrices))
+		if err != nil {
+			// The cap guards against surprise bills, not abuse, so it fails open
+			c.MustGet("logger").(*logrus.Logger).WithError(err).WithField("user_id", user.ID).Warn("Failed to check spending cap")
+			c.Next()
+			return
+		}
+		if spending.Blocked() {
+			c.JSON(http.StatusPaymentRequired, SpendingCapError(c, spending))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SpendingCapError builds the payload returned when an action is refused for the spending cap
+func SpendingCapError(c *gin.Context, spending models.Spending) gin.H {
+	body := ErrorBody(c, "spending_cap_reached")
+	body["spending"] = spending.ToPublicResponse()
+	return body
+}
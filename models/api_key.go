@@ -0,0 +1,118 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix marks bearer tokens that are API keys rather than Clerk JWTs
+const APIKeyPrefix = "lsk_"
+
+// SigningSecretPrefix marks the secret used to sign requests made with an API key
+const SigningSecretPrefix = "lss_"
+
+// APIKey is a long-lived credential for programmatic access.
+// Only a hash of the key is stored; the plaintext is shown once on creation.
+type APIKey struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
+	ProjectID  *uuid.UUID     `gorm:"type:uuid;index" json:"project_id,omitempty"`
+	Name       string         `gorm:"size:255" json:"name"`
+	KeyHash    string         `gorm:"size:64;uniqueIndex" json:"-"`
+	KeyPrefix  string         `gorm:"size:16" json:"key_prefix"`
+	SigningSecret    EncryptedString `gorm:"size:255" json:"-"`
+	RequireSignature bool     `gorm:"default:false" json:"require_signature"` // Mutations must be signed rather than sent with the bearer key
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName sets the table name for the APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// BeforeCreate hook is called before creating a new API key
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// GenerateAPIKey creates a new random API key and returns the plaintext key alongside
+// the record to store
+func GenerateAPIKey(userID uuid.UUID, projectID *uuid.UUID, name string) (string, *APIKey, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	key := APIKeyPrefix + hex.EncodeToString(raw)
+
+	return key, &APIKey{
+		UserID:    userID,
+		ProjectID: projectID,
+		Name:      name,
+		KeyHash:   HashAPIKey(key),
+		KeyPrefix: key[:len(APIKeyPrefix)+8],
+	}, nil
+}
+
+// EnableSigning generates a request signing secret for the key and requires mutations
+// to be signed with it. The secret is returned so it can be shown once on creation.
+func (k *APIKey) EnableSigning() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	secret := SigningSecretPrefix + hex.EncodeToString(raw)
+	k.SigningSecret = EncryptedString(secret)
+	k.RequireSignature = true
+	return secret, nil
+}
+
+// SignAPIRequest returns the hex HMAC-SHA256 signature of a request. The signed string is
+// the method, path with query, timestamp, nonce and hex SHA-256 of the body, joined by newlines.
+func SignAPIRequest(secret, method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	payload := strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashAPIKey returns the stored hash of a plaintext API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ToPublicResponse returns a public representation of the API key for API responses
+func (k *APIKey) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":           k.ID,
+		"name":         k.Name,
+		"project_id":   k.ProjectID,
+		"key_prefix":   k.KeyPrefix,
+		"require_signature": k.RequireSignature,
+		"last_used_at": k.LastUsedAt,
+		"created_at":   k.CreatedAt,
+	}
+}
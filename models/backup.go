@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BackupStatus defines the state of a backup run
+type BackupStatus string
+
+const (
+	BackupQueued    BackupStatus = "queued"
+	BackupRunning   BackupStatus = "running"
+	BackupSucceeded BackupStatus = "succeeded"
+	BackupFailed    BackupStatus = "failed"  // out of attempts; nothing was stored
+	BackupExpired   BackupStatus = "expired" // removed from storage by the retention policy
+)
+
+// BackupTrigger tells what started a backup
+type BackupTrigger string
+
+const (
+	BackupManual    BackupTrigger = "manual"
+	BackupScheduled BackupTrigger = "scheduled"
+)
+
+// Backup is one run backing up an instance's volumes to the backup store
+type Backup struct {
+	ID         uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	InstanceID uuid.UUID     `gorm:"type:uuid;index;not null" json:"instance_id"`
+	UserID     uuid.UUID     `gorm:"type:uuid;index;not null" json:"user_id"`
+	Trigger    BackupTrigger `gorm:"size:20;not null" json:"trigger"`
+	Status     BackupStatus  `gorm:"size:20;index;not null" json:"status"`
+	Target     string        `gorm:"size:20" json:"target"` // store the archive was written to: local or s3
+	Key        string        `gorm:"size:255" json:"key"`   // location of the archive in the store
+	SizeBytes  int64         `json:"size_bytes"`
+	Error      string        `gorm:"size:1000" json:"error,omitempty"`
+	JobID      *uuid.UUID    `gorm:"type:uuid" json:"job_id,omitempty"`
+	StartedAt  *time.Time    `json:"started_at,omitempty"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// TableName sets the table name for the Backup model
+func (Backup) TableName() string {
+	return "backups"
+}
+
+// BeforeCreate hook is called before creating a new backup
+func (b *Backup) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// ArchiveKey returns the location of the backup's archive in object storage
+func (b *Backup) ArchiveKey() string {
+	return "backups/" + b.InstanceID.String() + "/" + b.CreatedAt.UTC().Format("20060102T150405Z") + "-" + b.ID.String() + ".tar.gz"
+}
+
+// ToPublicResponse returns a public representation of the backup for API responses
+func (b *Backup) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          b.ID,
+		"instance_id": b.InstanceID,
+		"trigger":     b.Trigger,
+		"status":      b.Status,
+		"target":      b.Target,
+		"key":         b.Key,
+		"size_bytes":  b.SizeBytes,
+		"error":       b.Error,
+		"job_id":      b.JobID,
+		"started_at":  b.StartedAt,
+		"finished_at": b.FinishedAt,
+		"created_at":  b.CreatedAt,
+	}
+}
+
+// BackupSchedule runs backups of an instance automatically and keeps the newest of them
+type BackupSchedule struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	InstanceID uuid.UUID  `gorm:"type:uuid;uniqueIndex;not null" json:"instance_id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	Schedule   string     `gorm:"size:100;not null" json:"schedule"` // hourly, daily, weekly or a cron expression, in UTC
+	Retention  int        `gorm:"not null" json:"retention"`         // scheduled backups kept; older ones are removed
+	Enabled    bool       `gorm:"default:true" json:"enabled"`
+	NextRunAt  *time.Time `gorm:"index" json:"next_run_at"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the BackupSchedule model
+func (BackupSchedule) TableName() string {
+	return "backup_schedules"
+}
+
+// BeforeCreate hook is called before creating a new backup schedule
+func (s *BackupSchedule) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the schedule for API responses
+func (s *BackupSchedule) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"instance_id": s.InstanceID,
+		"schedule":    s.Schedule,
+		"retention":   s.Retention,
+		"enabled":     s.Enabled,
+		"next_run_at": s.NextRunAt,
+		"last_run_at": s.LastRunAt,
+		"created_at":  s.CreatedAt,
+		"updated_at":  s.UpdatedAt,
+	}
+}
+
+// BackupPayload is the payload of a job backing up an instance
+type BackupPayload struct {
+	BackupID uuid.UUID `json:"backup_id"`
+}
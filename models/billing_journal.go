@@ -0,0 +1,193 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BillingEntryKind is the billing change a journal transaction records
+type BillingEntryKind string
+
+const (
+	BillingOpening    BillingEntryKind = "opening" // plan a user had when the journal first saw them
+	BillingCharge     BillingEntryKind = "charge"
+	BillingRefund     BillingEntryKind = "refund"
+	BillingCredit     BillingEntryKind = "credit"
+	BillingPlanChange BillingEntryKind = "plan_change"
+	BillingTrialGrant BillingEntryKind = "trial_grant"
+)
+
+// Journal accounts. Money is kept in the payment's currency, plans in "plan" units with the
+// current plan's account at 1, and trials in "day" units.
+const (
+	AccountCash           = "cash"            // collected by the payment provider
+	AccountRevenue        = "revenue"         // earned from subscriptions
+	AccountCustomerCredit = "customer_credit" // owed to the customer, settled on a later invoice
+	AccountPlanOpening    = "plan_opening"    // balances the opening plan of a user
+	AccountTrialDays      = "trial_days"      // trial days granted to the customer
+	AccountTrialGrants    = "trial_grants"    // balances granted trial days
+
+	CurrencyPlan = "plan"
+	CurrencyDay  = "day"
+)
+
+// PlanAccount returns the journal account of a plan
+func PlanAccount(plan SubscriptionPlan) string {
+	return "plan:" + string(plan)
+}
+
+// BillingEntry is one line of the append-only billing journal. The entries of a transaction
+// balance to zero per currency, and every entry's hash covers the previous entry's hash, so
+// edits and deletions are detected by the integrity check.
+type BillingEntry struct {
+	ID            uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	Sequence      int64            `gorm:"uniqueIndex;not null" json:"sequence"`
+	TransactionID uuid.UUID        `gorm:"type:uuid;index;not null" json:"transaction_id"`
+	Reference     string           `gorm:"size:255;index;not null" json:"reference"` // what was recorded, e.g. "payment:<id>:charge"; recorded once
+	Kind          BillingEntryKind `gorm:"size:20;not null" json:"kind"`
+	UserID        uuid.UUID        `gorm:"type:uuid;index;not null" json:"user_id"`
+	PaymentID     *uuid.UUID       `gorm:"type:uuid;index" json:"payment_id,omitempty"`
+	Account       string           `gorm:"size:50;not null" json:"account"`
+	Amount        int64            `gorm:"not null" json:"amount"` // in cents for money; positive debits, negative credits
+	Currency      string           `gorm:"size:8;not null" json:"currency"`
+	Description   string           `json:"description"`
+	PrevHash      string           `gorm:"size:64" json:"-"`
+	Hash          string           `gorm:"size:64;not null" json:"-"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// TableName sets the table name for the BillingEntry model
+func (BillingEntry) TableName() string {
+	return "billing_entries"
+}
+
+// BeforeCreate hook is called before creating a new billing entry
+func (e *BillingEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// ComputeHash returns the hash chaining this entry to the previous one
+func (e *BillingEntry) ComputeHash() string {
+	paymentID := ""
+	if e.PaymentID != nil {
+		paymentID = e.PaymentID.String()
+	}
+	payload := strings.Join([]string{
+		e.PrevHash,
+		fmt.Sprint(e.Sequence),
+		e.TransactionID.String(),
+		e.Reference,
+		string(e.Kind),
+		e.UserID.String(),
+		paymentID,
+		e.Account,
+		fmt.Sprint(e.Amount),
+		e.Currency,
+		e.Description,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// ToPublicResponse returns the entry as shown to admins
+func (e *BillingEntry) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":             e.ID,
+		"sequence":       e.Sequence,
+		"transaction_id": e.TransactionID,
+		"reference":      e.Reference,
+		"kind":           e.Kind,
+		"user_id":        e.UserID,
+		"payment_id":     e.PaymentID,
+		"account":        e.Account,
+		"amount":         e.Amount,
+		"currency":       e.Currency,
+		"description":    e.Description,
+		"created_at":     e.CreatedAt,
+	}
+}
+
+// BillingLine is one side of a billing transaction
+type BillingLine struct {
+	Account  string
+	Amount   int64
+	Currency string
+}
+
+// BillingTransaction is a billing change to record in the journal
+type BillingTransaction struct {
+	Kind        BillingEntryKind
+	Reference   string
+	UserID      uuid.UUID
+	PaymentID   *uuid.UUID
+	Description string
+	Lines       []BillingLine
+}
+
+// Validate checks that the transaction has at least two lines and balances per currency
+func (t *BillingTransaction) Validate() error {
+	if t.Reference == "" {
+		return fmt.Errorf("billing transaction has no reference")
+	}
+	if len(t.Lines) < 2 {
+		return fmt.Errorf("billing transaction %s needs at least two lines", t.Reference)
+	}
+	balances := make(map[string]int64)
+	for _, line := range t.Lines {
+		if line.Account == "" || line.Currency == "" {
+			return fmt.Errorf("billing transaction %s has a line without account or currency", t.Reference)
+		}
+		balances[line.Currency] += line.Amount
+	}
+	for currency, balance := range balances {
+		if balance != 0 {
+			return fmt.Errorf("billing transaction %s is off by %d %s", t.Reference, balance, currency)
+		}
+	}
+	return nil
+}
+
+// PaymentMismatch is a payment whose status or amount disagrees with the journal
+type PaymentMismatch struct {
+	PaymentID uuid.UUID     `json:"payment_id"`
+	Status    PaymentStatus `json:"status"`
+	Amount    int64         `json:"amount"`
+	Charged   int64         `json:"charged"`  // charged according to the journal
+	Refunded  int64         `json:"refunded"` // refunded according to the journal
+}
+
+// PlanMismatch is a user whose plan disagrees with the plan the journal ends on
+type PlanMismatch struct {
+	UserID      uuid.UUID        `json:"user_id"`
+	Plan        SubscriptionPlan `json:"plan"`
+	JournalPlan SubscriptionPlan `json:"journal_plan"` // empty when no plan account is at 1
+}
+
+// BillingJournalReport is the result of a billing journal integrity check
+type BillingJournalReport struct {
+	CheckedAt              time.Time         `json:"checked_at"`
+	Entries                int64             `json:"entries"`
+	UnbalancedTransactions []uuid.UUID       `json:"unbalanced_transactions"`
+	BrokenChainAt          *int64            `json:"broken_chain_at,omitempty"` // sequence of the first entry whose hash does not match
+	PaymentMismatches      []PaymentMismatch `json:"payment_mismatches"`
+	PlanMismatches         []PlanMismatch    `json:"plan_mismatches"`
+}
+
+// Discrepancies returns how many problems the check found
+func (r *BillingJournalReport) Discrepancies() int {
+	count := len(r.UnbalancedTransactions) + len(r.PaymentMismatches) + len(r.PlanMismatches)
+	if r.BrokenChainAt != nil {
+		count++
+	}
+	return count
+}
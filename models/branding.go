@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// BrandingSettingsID is the primary key of the single branding settings row
+const BrandingSettingsID = 1
+
+// Branding holds the white-label settings served to the frontend.
+// Empty fields fall back to the deployment's configured defaults.
+type Branding struct {
+	ID             uint      `gorm:"primaryKey" json:"-"`
+	ProductName    string    `gorm:"size:255" json:"product_name"`
+	Domain         string    `gorm:"size:255" json:"domain"`
+	SupportEmail   string    `gorm:"size:255" json:"support_email"`
+	LogoURL        string    `gorm:"size:1000" json:"logo_url"`
+	PrimaryColor   string    `gorm:"size:7" json:"primary_color"`
+	SecondaryColor string    `gorm:"size:7" json:"secondary_color"`
+	AccentColor    string    `gorm:"size:7" json:"accent_color"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the Branding model
+func (Branding) TableName() string {
+	return "branding_settings"
+}
+
+// Merge overrides the branding with every non-empty field of another branding
+func (b *Branding) Merge(override Branding) {
+	if override.ProductName != "" {
+		b.ProductName = override.ProductName
+	}
+	if override.Domain != "" {
+		b.Domain = override.Domain
+	}
+	if override.SupportEmail != "" {
+		b.SupportEmail = override.SupportEmail
+	}
+	if override.LogoURL != "" {
+		b.LogoURL = override.LogoURL
+	}
+	if override.PrimaryColor != "" {
+		b.PrimaryColor = override.PrimaryColor
+	}
+	if override.SecondaryColor != "" {
+		b.SecondaryColor = override.SecondaryColor
+	}
+	if override.AccentColor != "" {
+		b.AccentColor = override.AccentColor
+	}
+	if !override.UpdatedAt.IsZero() {
+		b.UpdatedAt = override.UpdatedAt
+	}
+}
+
+// ToPublicResponse returns a public representation of the branding for API responses
+func (b *Branding) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"product_name":  b.ProductName,
+		"domain":        b.Domain,
+		"support_email": b.SupportEmail,
+		"logo_url":      b.LogoURL,
+		"colors": map[string]string{
+			"primary":   b.PrimaryColor,
+			"secondary": b.SecondaryColor,
+			"accent":    b.AccentColor,
+		},
+	}
+}
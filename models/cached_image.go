@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CachedImageStatus is the outcome of the last pre-pull of an image on a host
+type CachedImageStatus string
+
+const (
+	CachedImageReady  CachedImageStatus = "ready"
+	CachedImageFailed CachedImageStatus = "failed" // the last pull failed; an older copy may still be present
+)
+
+// CachedImage is an image kept pulled on a Docker host so instances are created without
+// waiting for a pull
+type CachedImage struct {
+	ID           uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	HostName     string            `gorm:"size:255;uniqueIndex:idx_cached_images_host_image" json:"host_name"` // Host name reported by the Docker engine
+	Image        string            `gorm:"size:255;uniqueIndex:idx_cached_images_host_image" json:"image"`
+	Status       CachedImageStatus `gorm:"type:varchar(20)" json:"status"`
+	ImageID      string            `json:"image_id"`   // local ID of the image last pulled
+	Digest       string            `json:"digest"`     // registry digest of the image last pulled
+	SizeBytes    int64             `json:"size_bytes"`
+	PullDuration int64             `json:"pull_duration_ms"`
+	Error        string            `json:"error,omitempty"`
+	PulledAt     *time.Time        `json:"pulled_at"` // last successful pull
+	CheckedAt    time.Time         `json:"checked_at"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// TableName sets the table name for the CachedImage model
+func (CachedImage) TableName() string {
+	return "cached_images"
+}
+
+// BeforeCreate hook is called before creating a new cached image
+func (i *CachedImage) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the cached image for API responses
+func (i *CachedImage) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":               i.ID,
+		"host_name":        i.HostName,
+		"image":            i.Image,
+		"status":           i.Status,
+		"image_id":         i.ImageID,
+		"digest":           i.Digest,
+		"size_bytes":       i.SizeBytes,
+		"pull_duration_ms": i.PullDuration,
+		"error":            i.Error,
+		"pulled_at":        i.PulledAt,
+		"checked_at":       i.CheckedAt,
+	}
+}
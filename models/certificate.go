@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CertificateStatus defines the state of an instance's TLS certificate
+type CertificateStatus string
+
+const (
+	CertificatePending  CertificateStatus = "pending"
+	CertificateIssued   CertificateStatus = "issued"
+	CertificateRenewing CertificateStatus = "renewing"
+	CertificateFailed   CertificateStatus = "failed"
+)
+
+// InstanceCertificate holds the last known TLS certificate status of an instance URL
+type InstanceCertificate struct {
+	ID         uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID uuid.UUID         `gorm:"type:uuid;uniqueIndex" json:"instance_id"`
+	Domain     string            `gorm:"size:255" json:"domain"`
+	Status     CertificateStatus `gorm:"type:varchar(20);default:'pending'" json:"status"`
+	Issuer     string            `gorm:"size:255" json:"issuer"`
+	NotBefore  *time.Time        `json:"not_before,omitempty"`
+	NotAfter   *time.Time        `json:"not_after,omitempty"`
+	Error      string            `gorm:"size:1000" json:"error,omitempty"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+
+	// Relationships
+	Instance Instance `gorm:"foreignKey:InstanceID" json:"-"`
+}
+
+// TableName sets the table name for the InstanceCertificate model
+func (InstanceCertificate) TableName() string {
+	return "instance_certificates"
+}
+
+// BeforeCreate hook is called before creating a new certificate record
+func (c *InstanceCertificate) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the certificate for API responses
+func (c *InstanceCertificate) ToPublicResponse() map[string]interface{} {
+	response := map[string]interface{}{
+		"domain":     c.Domain,
+		"status":     c.Status,
+		"issuer":     c.Issuer,
+		"not_before": c.NotBefore,
+		"not_after":  c.NotAfter,
+		"error":      c.Error,
+		"checked_at": c.CheckedAt,
+	}
+	if c.NotAfter != nil {
+		response["days_remaining"] = int(time.Until(*c.NotAfter).Hours() / 24)
+	}
+	return response
+}
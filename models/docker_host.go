@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DockerHost is a Docker host running instances, with its physical resources as reported by
+// the Docker engine and the overcommit policy admission control applies to them
+type DockerHost struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name              string     `gorm:"size:255;uniqueIndex" json:"name"` // Host name reported by the Docker engine
+	CPUCores          int        `json:"cpu_cores"`
+	MemoryMB          int64      `json:"memory_mb"`
+	CPUOvercommit     float64    `json:"cpu_overcommit"`           // CPU allocatable as a multiple of the cores; 0 does not limit allocation
+	MemoryOvercommit  float64    `json:"memory_overcommit"`        // Memory allocatable as a multiple of the physical memory; 0 does not limit allocation
+	UtilizationAlert  float64    `json:"utilization_alert"`        // Share of physical CPU or memory in use that raises an alert
+	CPUUtilization    float64    `json:"cpu_utilization"`          // Share of the cores used by instances at the last check
+	MemoryUtilization float64    `json:"memory_utilization"`       // Share of the physical memory used by instances at the last check
+	AlertingSince     *time.Time `json:"alerting_since,omitempty"` // Set while utilization is above the alert threshold
+	LastSeenAt        time.Time  `json:"last_seen_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the DockerHost model
+func (DockerHost) TableName() string {
+	return "docker_hosts"
+}
+
+// BeforeCreate hook is called before creating a new Docker host
+func (h *DockerHost) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+// AllocatableCPU returns the CPU cores instances may be given in total on the host, or 0 if
+// its policy does not limit CPU
+func (h *DockerHost) AllocatableCPU() float64 {
+	return float64(h.CPUCores) * h.CPUOvercommit
+}
+
+// AllocatableMemoryMB returns the memory instances may be given in total on the host, or 0 if
+// its policy does not limit memory
+func (h *DockerHost) AllocatableMemoryMB() int64 {
+	return int64(float64(h.MemoryMB) * h.MemoryOvercommit)
+}
+
+// ToPublicResponse returns a public representation of the host for API responses
+func (h *DockerHost) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                    h.ID,
+		"name":                  h.Name,
+		"cpu_cores":             h.CPUCores,
+		"memory_mb":             h.MemoryMB,
+		"cpu_overcommit":        h.CPUOvercommit,
+		"memory_overcommit":     h.MemoryOvercommit,
+		"allocatable_cpu":       h.AllocatableCPU(),
+		"allocatable_memory_mb": h.AllocatableMemoryMB(),
+		"utilization_alert":     h.UtilizationAlert,
+		"cpu_utilization":       h.CPUUtilization,
+		"memory_utilization":    h.MemoryUtilization,
+		"alerting":              h.AlertingSince != nil,
+		"alerting_since":        h.AlertingSince,
+		"last_seen_at":          h.LastSeenAt,
+		"created_at":            h.CreatedAt,
+	}
+}
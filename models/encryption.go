@@ -0,0 +1,211 @@
+package models
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// encryptedPrefix starts values encrypted with a key of the keyring. It is followed by the ID
+// of the key and the base64 encoded nonce and ciphertext: enc:<key id>:<sealed>.
+const encryptedPrefix = "enc:"
+
+// ErrNoEncryptionKey is returned when values are encrypted before the keyring is configured
+var ErrNoEncryptionKey = errors.New("no encryption key is configured")
+
+// keyring holds the keys sensitive values are encrypted with at rest. The current key
+// encrypts; previous keys only decrypt, until the values they encrypted are re-encrypted.
+var keyring = struct {
+	sync.RWMutex
+	currentID string
+	keys      map[string][]byte // by key ID
+	order     []string          // key IDs, current first
+}{keys: map[string][]byte{}}
+
+// EncryptionKeyID returns the ID a key is recorded with in the values it encrypts
+func EncryptionKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// SetEncryptionKeys configures the keyring with the key that encrypts and the previous keys
+// that still decrypt. It is called once at startup.
+func SetEncryptionKeys(current []byte, previous ...[]byte) error {
+	keys := map[string][]byte{}
+	var order []string
+	for _, key := range append([][]byte{current}, previous...) {
+		if len(key) != 32 {
+			return fmt.Errorf("encryption keys must be 32 bytes, got %d", len(key))
+		}
+		id := EncryptionKeyID(key)
+		if _, ok := keys[id]; !ok {
+			keys[id] = key
+			order = append(order, id)
+		}
+	}
+
+	keyring.Lock()
+	defer keyring.Unlock()
+	keyring.currentID = order[0]
+	keyring.keys = keys
+	keyring.order = order
+	return nil
+}
+
+// CurrentEncryptionKeyID returns the ID of the key new values are encrypted with, or "" when
+// the keyring is not configured
+func CurrentEncryptionKeyID() string {
+	keyring.RLock()
+	defer keyring.RUnlock()
+	return keyring.currentID
+}
+
+// EncryptedValuePrefix returns the prefix of values encrypted with the current key
+func EncryptedValuePrefix() string {
+	return encryptedPrefix + CurrentEncryptionKeyID() + ":"
+}
+
+// Encrypt encrypts a value with the current key of the keyring
+func Encrypt(plaintext string) (string, error) {
+	keyring.RLock()
+	id, key := keyring.currentID, keyring.keys[keyring.currentID]
+	keyring.RUnlock()
+	if key == nil {
+		return "", ErrNoEncryptionKey
+	}
+
+	sealed, err := EncryptSecret(key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + id + ":" + sealed, nil
+}
+
+// Decrypt decrypts a value encrypted with a key of the keyring. Values without a key ID were
+// encrypted by EncryptSecret before there was a keyring, and are tried with every key.
+func Decrypt(value string) (string, error) {
+	keyring.RLock()
+	defer keyring.RUnlock()
+
+	if !IsEncrypted(value) {
+		for _, id := range keyring.order {
+			if plaintext, err := DecryptSecret(keyring.keys[id], value); err == nil {
+				return plaintext, nil
+			}
+		}
+		return "", errors.New("failed to decrypt secret with any encryption key")
+	}
+	id, sealed, _ := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	key, ok := keyring.keys[id]
+	if !ok {
+		return "", fmt.Errorf("secret is encrypted with unknown key %s", id)
+	}
+	return DecryptSecret(key, sealed)
+}
+
+// IsEncrypted checks if a value was encrypted with a key of the keyring
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Reencrypt encrypts a stored value with the current key. Values that are not encrypted with
+// a key of the keyring are plaintext if the column was not encrypted before, and otherwise
+// legacy values of EncryptSecret.
+func Reencrypt(value string, plaintext bool) (string, error) {
+	if IsEncrypted(value) || !plaintext {
+		decrypted, err := Decrypt(value)
+		if err != nil {
+			return "", err
+		}
+		value = decrypted
+	}
+	return Encrypt(value)
+}
+
+// EncryptedString is a string column encrypted at rest with the keyring. Values stored before
+// the column was encrypted are read as plaintext until they are re-encrypted.
+type EncryptedString string
+
+// Value implements driver.Valuer, encrypting the value with the current key
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	return Encrypt(string(s))
+}
+
+// Scan implements sql.Scanner, decrypting the stored value
+func (s *EncryptedString) Scan(value interface{}) error {
+	var stored string
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return errors.New("unsupported type for EncryptedString")
+	}
+	if !IsEncrypted(stored) {
+		*s = EncryptedString(stored)
+		return nil
+	}
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// EncryptSecret encrypts a secret for storage with AES-256-GCM, returning the nonce and
+// ciphertext base64 encoded
+func EncryptSecret(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret
+func DecryptSecret(key []byte, encrypted string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted secret: too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// newGCM creates an AES-GCM cipher for a 32 byte key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
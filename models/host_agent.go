@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// HostAgent is an agent running on a host that authenticates to the backend with short-lived,
+// host-scoped tokens rather than a shared secret
+type HostAgent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Host          string     `gorm:"size:255;uniqueIndex" json:"host"`
+	Version       string     `gorm:"size:50" json:"version,omitempty"`
+	LastCheckInAt *time.Time `json:"last_check_in_at,omitempty"`
+	LastCheckInIP string     `gorm:"size:45" json:"last_check_in_ip,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"` // Revoked agents cannot authenticate or get new tokens
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the HostAgent model
+func (HostAgent) TableName() string {
+	return "host_agents"
+}
+
+// BeforeCreate hook is called before creating a new host agent
+func (a *HostAgent) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsRevoked checks if the agent was revoked
+func (a *HostAgent) IsRevoked() bool {
+	return a.RevokedAt != nil
+}
+
+// ToPublicResponse returns a public representation of the agent for API responses
+func (a *HostAgent) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":               a.ID,
+		"host":             a.Host,
+		"version":          a.Version,
+		"last_check_in_at": a.LastCheckInAt,
+		"last_check_in_ip": a.LastCheckInIP,
+		"revoked":          a.IsRevoked(),
+		"revoked_at":       a.RevokedAt,
+		"created_at":       a.CreatedAt,
+	}
+}
+
+// AgentToken records a token issued to a host agent. The token itself is a signed JWT and is
+// never stored; the record is looked up by its ID (the jti claim) so the token can be revoked
+// before it expires.
+type AgentToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	AgentID   uuid.UUID  `gorm:"type:uuid;index" json:"agent_id"`
+	ExpiresAt time.Time  `gorm:"index" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relationships
+	Agent HostAgent `gorm:"foreignKey:AgentID" json:"-"`
+}
+
+// TableName sets the table name for the AgentToken model
+func (AgentToken) TableName() string {
+	return "agent_tokens"
+}
+
+// BeforeCreate hook is called before creating a new agent token
+func (t *AgentToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsValid checks if the token is neither revoked nor expired
+func (t *AgentToken) IsValid(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// ToPublicResponse returns a public representation of the token record for API responses
+func (t *AgentToken) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":         t.ID,
+		"agent_id":   t.AgentID,
+		"expires_at": t.ExpiresAt,
+		"revoked_at": t.RevokedAt,
+		"created_at": t.CreatedAt,
+	}
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,7 +14,9 @@ import (
 type ServiceType string
 
 const (
-	ServiceN8N ServiceType = "n8n"
+	ServiceN8N         ServiceType = "n8n"
+	ServiceUptimeKuma  ServiceType = "uptime-kuma"
+	ServiceNocoDB      ServiceType = "nocodb"
 )
 
 // InstanceStatus defines the status of an n8n instance
@@ -24,24 +28,56 @@ const (
 	StatusError    InstanceStatus = "error"
 	StatusPending  InstanceStatus = "pending"
 	StatusDeleted  InstanceStatus = "deleted"
+	StatusSuspended InstanceStatus = "suspended" // Stopped by the platform, e.g. for exceeding a quota
+	StatusUpgrading InstanceStatus = "upgrading" // Being recreated with a new n8n version
+	StatusDeleting InstanceStatus = "deleting" // Queued for deletion
+	StatusTrashed  InstanceStatus = "trashed" // Deleted by its user and stopped; restorable until PurgeAt
+	StatusSleeping InstanceStatus = "sleeping" // Stopped by the platform after being idle; started again by a request to its URL
 	InstanceStatusExpired InstanceStatus = "expired" // When payment fails and instance is pending deletion
 )
 
+// LatestImageTag is the image tag of instances that follow n8n releases automatically
+const LatestImageTag = "latest"
+
+// Reasons an instance can be suspended
+const (
+	SuspendReasonStorage = "storage_limit_exceeded"
+	SuspendReasonBilling = "billing_lapsed"
+)
+
 // Instance represents a user's n8n instance
 type Instance struct {
 	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID        uuid.UUID       `gorm:"type:uuid" json:"user_id"`
+	ProjectID     *uuid.UUID      `gorm:"type:uuid;index" json:"project_id,omitempty"`
+	OrganizationID *uuid.UUID     `gorm:"type:uuid;index" json:"organization_id,omitempty"` // Shared with the members of the organization
 	Name          string          `gorm:"size:255;not null" json:"name"`
 	Description   string          `gorm:"size:1000" json:"description"`
+	ServiceType   ServiceType     `gorm:"size:50;not null;default:'n8n'" json:"service_type"` // Template the container is created from
 	Status        InstanceStatus  `gorm:"size:50;not null" json:"status"`
-	Host          string          `gorm:"size:255" json:"host"`
+	Host          string          `gorm:"size:255;uniqueIndex:idx_instances_host,where:deleted_at IS NULL AND host <> ''" json:"host"` // Subdomain, unique among instances that are not deleted
 	Port          int             `json:"port"`
 	URL           string          `gorm:"size:255" json:"url"`
 	CPULimit      float64         `json:"cpu_limit"`
 	MemoryLimit   int             `json:"memory_limit"` // in MB
 	StorageLimit  int             `json:"storage_limit"` // in GB
+	CPUSet        string          `gorm:"size:255" json:"cpu_set,omitempty"` // Dedicated host CPUs, e.g. "8-9"; empty when not pinned
+	ResourceOverride bool         `gorm:"default:false" json:"resource_override"` // CPU and memory were set through the resources endpoint rather than by the plan
 	ContainerID   string          `gorm:"size:255" json:"container_id"`
 	IPAddress     string          `gorm:"size:50" json:"ip_address"`
+	ImageTag      string          `gorm:"size:100;default:'latest'" json:"image_tag"` // n8n version; "latest" is auto-updated
+	ImageDigest   string          `gorm:"size:255" json:"image_digest,omitempty"` // Registry digest the container runs, e.g. "n8nio/n8n@sha256:..."
+	PreviousImageTag    string    `gorm:"size:100" json:"previous_image_tag,omitempty"` // Version before the last upgrade; empty when there is nothing to roll back to
+	PreviousImageDigest string    `gorm:"size:255" json:"previous_image_digest,omitempty"`
+	UpgradedAt    *time.Time      `json:"upgraded_at,omitempty"` // Start of the rollback window
+	SuspendedReason string        `gorm:"size:100" json:"suspended_reason,omitempty"`
+	StorageWarnedAt *time.Time    `json:"storage_warned_at,omitempty"`
+	StorageUsage  int64           `gorm:"default:0" json:"storage_usage"` // Volume usage in bytes, last measured by the storage monitor
+	HealthFailures int            `gorm:"default:0" json:"health_failures"` // Consecutive failed n8n health probes
+	LastActivityAt *time.Time     `json:"last_activity_at,omitempty"` // Latest workflow execution or HTTP traffic, or start by its user
+	TrashedAt     *time.Time      `json:"trashed_at,omitempty"`
+	PurgeAt       *time.Time      `gorm:"index" json:"purge_at,omitempty"` // When a trashed instance and its volumes are deleted for good
+	ProvisioningSpec *ProvisioningSpec `gorm:"type:jsonb;<-:create" json:"provisioning_spec,omitempty"` // Immutable creation spec
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt  `gorm:"index" json:"-"`
@@ -68,18 +104,106 @@ func (i *Instance) BeforeCreate(tx *gorm.DB) error {
 func (i *Instance) ToPublicResponse() map[string]interface{} {
 	return map[string]interface{}{
 		"id":           i.ID,
+		"project_id":   i.ProjectID,
+		"organization_id": i.OrganizationID,
 		"name":         i.Name,
 		"description":  i.Description,
+		"service_type": i.Service(),
 		"status":       i.Status,
 		"url":          i.URL,
 		"cpu_limit":    i.CPULimit,
 		"memory_limit": i.MemoryLimit,
 		"storage_limit": i.StorageLimit,
+		"storage_usage": i.StorageUsage,
+		"cpu_set":      i.CPUSet,
+		"resource_override": i.ResourceOverride,
+		"suspended_reason": i.SuspendedReason,
+		"image_tag":    i.ImageTag,
+		"image_digest": i.ImageDigest,
+		"previous_image_tag": i.PreviousImageTag,
+		"upgraded_at":  i.UpgradedAt,
+		"health_failures": i.HealthFailures,
+		"last_activity_at": i.LastActivityAt,
+		"trashed_at":   i.TrashedAt,
+		"purge_at":     i.PurgeAt,
 		"created_at":   i.CreatedAt,
 		"updated_at":   i.UpdatedAt,
 	}
 }
 
+// imageTagPattern matches valid Docker image tags
+var imageTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// ValidImageTag checks if a string is a valid Docker image tag
+func ValidImageTag(tag string) bool {
+	return imageTagPattern.MatchString(tag)
+}
+
+// Bounds of the length of an instance name
+const (
+	MinInstanceNameLength = 3
+	MaxInstanceNameLength = 63
+)
+
+// nameSlugSeparator matches the runs of characters replaced by a hyphen in name slugs
+var nameSlugSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+
+// instanceNameCharset matches the characters instance names may contain. Container names and
+// subdomains are derived from them, so they are limited to ASCII letters, digits, spaces,
+// hyphens, underscores and periods.
+var instanceNameCharset = regexp.MustCompile(`^[A-Za-z0-9 _.-]+$`)
+
+// ValidateInstanceName checks a display name against the naming rules, returning an
+// *InstanceNameError describing the first rule it breaks
+func ValidateInstanceName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return &InstanceNameError{Reason: NameRequired}
+	}
+	if len(name) < MinInstanceNameLength {
+		return &InstanceNameError{Reason: NameTooShort, Limit: MinInstanceNameLength}
+	}
+	if len(name) > MaxInstanceNameLength {
+		return &InstanceNameError{Reason: NameTooLong, Limit: MaxInstanceNameLength}
+	}
+	if !instanceNameCharset.MatchString(name) {
+		return &InstanceNameError{Reason: NameInvalidCharacters}
+	}
+	slug := InstanceNameSlug(name)
+	if slug == "" {
+		return &InstanceNameError{Reason: NameNoAlphanumeric}
+	}
+	if reservedInstanceNames[slug] {
+		return &InstanceNameError{Reason: NameReserved, Word: slug}
+	}
+	for _, word := range strings.Split(slug, "-") {
+		if blockedNameWords[word] {
+			return &InstanceNameError{Reason: NameBlocked, Word: word}
+		}
+	}
+	return nil
+}
+
+// InstanceNameSlug returns the lower-case, hyphenated form of a name used in container
+// names. Names with the same slug are treated as duplicates.
+func InstanceNameSlug(name string) string {
+	slug := nameSlugSeparator.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(slug, "-")
+}
+
+// IsPinned checks if the instance runs a fixed n8n version instead of following releases
+func (i *Instance) IsPinned() bool {
+	return i.ImageTag != "" && i.ImageTag != LatestImageTag
+}
+
+// Service returns the service the instance runs; records created without one run n8n
+func (i *Instance) Service() ServiceType {
+	if i.ServiceType == "" {
+		return ServiceN8N
+	}
+	return i.ServiceType
+}
+
 // GetURL returns the full URL to access the instance
 func (i *Instance) GetURL(domain string) string {
 	if i.URL == "" {
@@ -98,6 +222,11 @@ func (i *Instance) GetDataVolumeName() string {
 	return fmt.Sprintf("n8n-data-%s", i.ID.String())
 }
 
+// IsSuspended checks if the instance has been suspended by the platform
+func (i *Instance) IsSuspended() bool {
+	return i.Status == StatusSuspended
+}
+
 // CanStart checks if the instance can be started
 func (i *Instance) CanStart() bool {
 	return i.Status == StatusStopped
@@ -108,6 +237,16 @@ func (i *Instance) CanStop() bool {
 	return i.Status == StatusRunning
 }
 
+// IsSleeping checks if the instance was stopped for being idle
+func (i *Instance) IsSleeping() bool {
+	return i.Status == StatusSleeping
+}
+
+// IsTrashed checks if the instance was deleted by its user and can still be restored
+func (i *Instance) IsTrashed() bool {
+	return i.Status == StatusTrashed
+}
+
 // CanDelete checks if the instance can be deleted
 func (i *Instance) CanDelete() bool {
 	return i.Status != StatusDeleted
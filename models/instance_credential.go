@@ -0,0 +1,103 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InstanceCredential stores the basic auth login of an instance's n8n editor and the secret
+// the instance signs its n8n webhook events with. Both are encrypted at rest with the
+// keyring; only the password is returned to the instance owner.
+type InstanceCredential struct {
+	ID                     uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID             uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"instance_id"`
+	Username               string     `gorm:"size:255" json:"username"`
+	EncryptedPassword      string     `gorm:"size:255" json:"-"`
+	EncryptedWebhookSecret string     `gorm:"size:255" json:"-"` // empty for instances created before they had their own secret
+	RotatedAt              *time.Time `json:"rotated_at,omitempty"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the InstanceCredential model
+func (InstanceCredential) TableName() string {
+	return "instance_credentials"
+}
+
+// BeforeCreate hook is called before creating new instance credentials
+func (c *InstanceCredential) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// GenerateInstancePassword creates a random basic auth password
+func GenerateInstancePassword() (string, error) {
+	raw := make([]byte, 18)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// GenerateInstanceWebhookSecret creates a random secret for an instance's n8n webhook events
+func GenerateInstanceWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// SetPassword encrypts and stores a new password
+func (c *InstanceCredential) SetPassword(password string) error {
+	encrypted, err := Encrypt(password)
+	if err != nil {
+		return err
+	}
+	c.EncryptedPassword = encrypted
+	return nil
+}
+
+// Password decrypts the stored password
+func (c *InstanceCredential) Password() (string, error) {
+	return Decrypt(c.EncryptedPassword)
+}
+
+// HasWebhookSecret checks if the instance has its own webhook secret
+func (c *InstanceCredential) HasWebhookSecret() bool {
+	return c.EncryptedWebhookSecret != ""
+}
+
+// SetWebhookSecret encrypts and stores a new webhook secret
+func (c *InstanceCredential) SetWebhookSecret(secret string) error {
+	encrypted, err := Encrypt(secret)
+	if err != nil {
+		return err
+	}
+	c.EncryptedWebhookSecret = encrypted
+	return nil
+}
+
+// WebhookSecret decrypts the stored webhook secret
+func (c *InstanceCredential) WebhookSecret() (string, error) {
+	return Decrypt(c.EncryptedWebhookSecret)
+}
+
+// ToPublicResponse returns the credentials with the decrypted password for their owner
+func (c *InstanceCredential) ToPublicResponse(password string) map[string]interface{} {
+	return map[string]interface{}{
+		"instance_id": c.InstanceID,
+		"username":    c.Username,
+		"password":    password,
+		"rotated_at":  c.RotatedAt,
+		"created_at":  c.CreatedAt,
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InstanceEventType identifies what happened to an instance
+type InstanceEventType string
+
+const (
+	EventCertificateFailed  InstanceEventType = "certificate_failed"
+	EventCertificateIssued  InstanceEventType = "certificate_issued"
+	EventUpgradeSucceeded   InstanceEventType = "upgrade_succeeded"
+	EventUpgradeFailed      InstanceEventType = "upgrade_failed"
+	EventRollbackSucceeded  InstanceEventType = "rollback_succeeded"
+	EventRollbackFailed     InstanceEventType = "rollback_failed"
+	EventHealthFailed       InstanceEventType = "health_failed"
+	EventHealthRecovered    InstanceEventType = "health_recovered"
+	EventAutoRestarted      InstanceEventType = "auto_restarted"
+	EventResourcesUpdated   InstanceEventType = "resources_updated"
+	EventLegacyImported     InstanceEventType = "legacy_imported"
+	EventSuspended          InstanceEventType = "suspended"
+	EventResumed            InstanceEventType = "resumed"
+	EventProvisioningFailed InstanceEventType = "provisioning_failed"
+	EventCredentialsRotated InstanceEventType = "credentials_rotated"
+	EventSlept              InstanceEventType = "slept"
+	EventWoken              InstanceEventType = "woken"
+	EventTerminalOpened     InstanceEventType = "terminal_opened"
+)
+
+// EventLevel defines how important an instance event is
+type EventLevel string
+
+const (
+	EventLevelInfo    EventLevel = "info"
+	EventLevelWarning EventLevel = "warning"
+	EventLevelError   EventLevel = "error"
+)
+
+// InstanceEvent records a notable change on an instance for the user to see
+type InstanceEvent struct {
+	ID         uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID uuid.UUID         `gorm:"type:uuid;index" json:"instance_id"`
+	UserID     uuid.UUID         `gorm:"type:uuid;index" json:"user_id"`
+	Type       InstanceEventType `gorm:"type:varchar(50)" json:"type"`
+	Level      EventLevel        `gorm:"type:varchar(20)" json:"level"`
+	Message    string            `gorm:"size:1000" json:"message"`
+	CreatedAt  time.Time         `gorm:"index" json:"created_at"`
+}
+
+// TableName sets the table name for the InstanceEvent model
+func (InstanceEvent) TableName() string {
+	return "instance_events"
+}
+
+// BeforeCreate hook is called before creating a new instance event
+func (e *InstanceEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the event for API responses
+func (e *InstanceEvent) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":         e.ID,
+		"type":       e.Type,
+		"level":      e.Level,
+		"message":    e.Message,
+		"created_at": e.CreatedAt,
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import "fmt"
+
+// InstanceNameReason identifies the naming rule an instance name breaks
+type InstanceNameReason string
+
+const (
+	NameRequired          InstanceNameReason = "required"
+	NameTooShort          InstanceNameReason = "too_short"
+	NameTooLong           InstanceNameReason = "too_long"
+	NameInvalidCharacters InstanceNameReason = "invalid_characters"
+	NameNoAlphanumeric    InstanceNameReason = "no_alphanumeric"
+	NameReserved          InstanceNameReason = "reserved"
+	NameBlocked           InstanceNameReason = "blocked"
+)
+
+// InstanceNameError describes why an instance name was rejected
+type InstanceNameError struct {
+	Reason InstanceNameReason
+	Limit  int    // length bound of NameTooShort and NameTooLong
+	Word   string // offending word of NameReserved and NameBlocked
+}
+
+// Error describes the broken rule in English; API responses use Code and Args instead
+func (e *InstanceNameError) Error() string {
+	switch e.Reason {
+	case NameRequired:
+		return "name is required"
+	case NameTooShort:
+		return fmt.Sprintf("name must be at least %d characters", e.Limit)
+	case NameTooLong:
+		return fmt.Sprintf("name must be at most %d characters", e.Limit)
+	case NameInvalidCharacters:
+		return "name may only contain letters, digits, spaces, hyphens, underscores and periods"
+	case NameNoAlphanumeric:
+		return "name must contain at least one letter or digit"
+	case NameReserved:
+		return fmt.Sprintf("%q is reserved", e.Word)
+	default:
+		return "name contains a word that is not allowed"
+	}
+}
+
+// Code returns the message code of the error in the i18n catalogs
+func (e *InstanceNameError) Code() string {
+	return "instance_name_" + string(e.Reason)
+}
+
+// Args returns the arguments of the error's message
+func (e *InstanceNameError) Args() []interface{} {
+	switch e.Reason {
+	case NameTooShort, NameTooLong:
+		return []interface{}{e.Limit}
+	case NameReserved:
+		return []interface{}{e.Word}
+	}
+	return nil
+}
+
+// reservedInstanceNames are the name slugs that could pass for the platform's own services
+// or subdomains
+var reservedInstanceNames = map[string]bool{
+	"admin": true, "administrator": true, "api": true, "app": true, "auth": true,
+	"billing": true, "cdn": true, "console": true, "dashboard": true, "dns": true,
+	"docs": true, "ftp": true, "grafana": true, "help": true, "launchstack": true,
+	"localhost": true, "login": true, "mail": true, "metrics": true, "n8n": true,
+	"ns1": true, "ns2": true, "official": true, "prometheus": true, "root": true,
+	"smtp": true, "status": true, "support": true, "system": true, "traefik": true,
+	"webhook": true, "webhooks": true, "www": true,
+}
+
+// blockedNameWords are profane or abusive words no word of a name slug may be
+var blockedNameWords = map[string]bool{
+	"asshole": true, "bastard": true, "bitch": true, "cunt": true, "dick": true,
+	"fag": true, "faggot": true, "fuck": true, "fucker": true, "fucking": true,
+	"motherfucker": true, "nazi": true, "nigga": true, "nigger": true, "porn": true,
+	"pussy": true, "retard": true, "shit": true, "slut": true, "whore": true,
+}
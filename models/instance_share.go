@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareAccess defines what a user an instance is shared with can do with it
+type ShareAccess string
+
+const (
+	ShareReadOnly ShareAccess = "read_only" // sees the instance, its status, metrics and events
+	ShareOperator ShareAccess = "operator"  // also starts, stops, restarts and wakes it
+)
+
+// Valid checks if the access is one of the share accesses
+func (a ShareAccess) Valid() bool {
+	return a == ShareReadOnly || a == ShareOperator
+}
+
+// AtLeast checks if the access allows everything another access allows
+func (a ShareAccess) AtLeast(other ShareAccess) bool {
+	return a == other || a == ShareOperator && other == ShareReadOnly
+}
+
+// InstanceShare gives another registered user access to an instance without making them a
+// member of an organization
+type InstanceShare struct {
+	ID          uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID  uuid.UUID   `gorm:"type:uuid;uniqueIndex:idx_instance_shares_instance_user" json:"instance_id"`
+	UserID      uuid.UUID   `gorm:"type:uuid;uniqueIndex:idx_instance_shares_instance_user;index" json:"user_id"`
+	Access      ShareAccess `gorm:"type:varchar(20);not null" json:"access"`
+	CreatedByID uuid.UUID   `gorm:"type:uuid" json:"created_by_id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	// Relationships
+	User     User     `gorm:"foreignKey:UserID" json:"-"`
+	Instance Instance `gorm:"foreignKey:InstanceID" json:"-"`
+}
+
+// TableName sets the table name for the InstanceShare model
+func (InstanceShare) TableName() string {
+	return "instance_shares"
+}
+
+// BeforeCreate hook is called before creating a new share
+func (s *InstanceShare) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the share for API responses, with the
+// email and name of the user it is for when the user is loaded
+func (s *InstanceShare) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"instance_id": s.InstanceID,
+		"user_id":     s.UserID,
+		"email":       s.User.Email,
+		"first_name":  s.User.FirstName,
+		"last_name":   s.User.LastName,
+		"access":      s.Access,
+		"created_at":  s.CreatedAt,
+		"updated_at":  s.UpdatedAt,
+	}
+}
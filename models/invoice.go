@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvoiceNumberPrefix starts the number of every invoice, followed by the year it was issued
+// and a sequence number, e.g. INV-2026-000042
+const InvoiceNumberPrefix = "INV-"
+
+// Invoice is the invoice of a completed payment. It keeps what it was issued for and to whom,
+// so it reads the same when the user changes their name or their account is purged; like the
+// billing journal it is kept for accounting.
+type Invoice struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Number       string    `gorm:"size:20;not null;uniqueIndex" json:"number"`
+	PaymentID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"payment_id"`
+	UserID       uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	Amount       int       `json:"amount"` // in cents
+	Currency     string    `gorm:"type:varchar(3)" json:"currency"`
+	Description  string    `json:"description"`
+	BillingName  string    `json:"billing_name"`
+	BillingEmail string    `json:"billing_email"`
+	Provider     string    `gorm:"type:varchar(20)" json:"provider,omitempty"`
+	IssuedAt     time.Time `json:"issued_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the Invoice model
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// BeforeCreate hook is called before creating a new invoice
+func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// InvoicePath is where the invoice of a payment is downloaded from the API
+func InvoicePath(paymentID uuid.UUID) string {
+	return fmt.Sprintf("/api/v1/payments/%s/invoice", paymentID)
+}
+
+// NewInvoice creates the invoice of a completed payment to a user, without its number
+func NewInvoice(payment *Payment, user User) *Invoice {
+	name := user.FirstName
+	if user.LastName != "" {
+		if name != "" {
+			name += " "
+		}
+		name += user.LastName
+	}
+	if name == "" {
+		name = user.Username
+	}
+
+	description := payment.Description
+	if description == "" {
+		description = "Subscription payment"
+	}
+	return &Invoice{
+		PaymentID:    payment.ID,
+		UserID:       payment.UserID,
+		Amount:       payment.Amount,
+		Currency:     payment.Currency,
+		Description:  description,
+		BillingName:  name,
+		BillingEmail: user.Email,
+		Provider:     payment.Provider,
+		IssuedAt:     payment.UpdatedAt,
+	}
+}
+
+// ToPublicResponse returns a public representation of the invoice for API responses
+func (i *Invoice) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":            i.ID,
+		"number":        i.Number,
+		"payment_id":    i.PaymentID,
+		"amount":        float64(i.Amount) / 100,
+		"currency":      i.Currency,
+		"description":   i.Description,
+		"billing_name":  i.BillingName,
+		"billing_email": i.BillingEmail,
+		"issued_at":     i.IssuedAt,
+	}
+}
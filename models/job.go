@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobType identifies the operation a background job performs
+type JobType string
+
+const (
+	JobCreateInstance   JobType = "instance.create"
+	JobDeleteInstance   JobType = "instance.delete"
+	JobUpgradeInstance  JobType = "instance.upgrade"
+	JobRollbackInstance JobType = "instance.rollback"
+	JobBackupInstance   JobType = "instance.backup"
+	JobDeliverWebhook   JobType = "webhook.deliver" // Sends an instance event to a webhook endpoint
+	JobProcessWebhook   JobType = "webhook.process" // Applies a webhook event received from Clerk
+)
+
+// JobStatus defines the state of a background job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued" // Waiting for its first attempt or a retry
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed" // Out of attempts, or failed with an error retrying cannot fix
+)
+
+// Job is a long-running operation executed by the job queue's workers
+type Job struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Type        JobType    `gorm:"type:varchar(50);index" json:"type"`
+	UserID      *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	InstanceID  *uuid.UUID `gorm:"type:uuid;index" json:"instance_id,omitempty"`
+	Payload     string     `gorm:"type:jsonb" json:"-"`
+	Status      JobStatus  `gorm:"type:varchar(20);index:idx_jobs_status_run_at" json:"status"`
+	RunAt       time.Time  `gorm:"index:idx_jobs_status_run_at" json:"run_at"` // When the next attempt is due
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	LastError   string     `gorm:"size:1000" json:"last_error,omitempty"`
+	LockedAt    *time.Time `json:"-"` // When a worker claimed the job; stale locks are released
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the Job model
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// BeforeCreate hook is called before creating a new job
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewInstanceJob creates a queued job operating on an instance, storing the payload as JSON
+func NewInstanceJob(jobType JobType, instance *Instance, payload interface{}) (*Job, error) {
+	data := []byte("{}")
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	return &Job{
+		Type:       jobType,
+		UserID:     &instance.UserID,
+		InstanceID: &instance.ID,
+		Payload:    string(data),
+		Status:     JobQueued,
+	}, nil
+}
+
+// DecodePayload reads the job's JSON payload into v
+func (j *Job) DecodePayload(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// IsLastAttempt checks if a failure of the current attempt is final
+func (j *Job) IsLastAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// ToPublicResponse returns a public representation of the job for API responses
+func (j *Job) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":           j.ID,
+		"type":         j.Type,
+		"instance_id":  j.InstanceID,
+		"status":       j.Status,
+		"attempts":     j.Attempts,
+		"max_attempts": j.MaxAttempts,
+		"last_error":   j.LastError,
+		"run_at":       j.RunAt,
+		"created_at":   j.CreatedAt,
+		"started_at":   j.StartedAt,
+		"finished_at":  j.FinishedAt,
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Lease records which backend replica runs a singleton background loop. A replica holds the
+// lease until it expires, renewing it every time the loop runs; once it lapses, e.g.
+// because the replica stopped, another replica takes it over.
+type Lease struct {
+	Name       string    `gorm:"primaryKey;size:100" json:"name"`
+	Holder     string    `gorm:"size:255;not null" json:"holder"` // replica ID of the current or last holder
+	AcquiredAt time.Time `json:"acquired_at"`                     // when the holder took the lease over
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `gorm:"index" json:"expires_at"`
+}
+
+// TableName sets the table name for the Lease model
+func (Lease) TableName() string {
+	return "leases"
+}
+
+// IsHeld reports whether the lease has not expired yet
+func (l *Lease) IsHeld(now time.Time) bool {
+	return l.ExpiresAt.After(now)
+}
+
+// ToPublicResponse returns the lease as shown to admins
+func (l *Lease) ToPublicResponse(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        l.Name,
+		"holder":      l.Holder,
+		"held":        l.IsHeld(now),
+		"acquired_at": l.AcquiredAt,
+		"renewed_at":  l.RenewedAt,
+		"expires_at":  l.ExpiresAt,
+	}
+}
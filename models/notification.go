@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationType identifies what a notification tells the user about
+type NotificationType string
+
+const (
+	NotificationInstanceUnhealthy    NotificationType = "instance_unhealthy"
+	NotificationInstanceRestarted    NotificationType = "instance_restarted"
+	NotificationInstanceSuspended    NotificationType = "instance_suspended"
+	NotificationInstanceResumed      NotificationType = "instance_resumed"
+	NotificationBackupSucceeded      NotificationType = "backup_succeeded"
+	NotificationBackupFailed         NotificationType = "backup_failed"
+	NotificationSubscriptionCanceled NotificationType = "subscription_canceled"
+	NotificationSpendingCapReached   NotificationType = "spending_cap_reached"
+	NotificationTrialEnding          NotificationType = "trial_ending"
+	NotificationTrialEnded           NotificationType = "trial_ended"
+	NotificationOrgInvitation        NotificationType = "org_invitation"
+	NotificationInstanceShared       NotificationType = "instance_shared"
+	NotificationHostUtilizationHigh  NotificationType = "host_utilization_high" // Sent to admins
+	NotificationHostUtilizationOK    NotificationType = "host_utilization_ok"   // Sent to admins
+)
+
+// Notification is a message for the notification center of the frontend. It stays unread
+// until the user reads it.
+type Notification struct {
+	ID         uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID        `gorm:"type:uuid;index:idx_notifications_user_created;not null" json:"user_id"`
+	InstanceID *uuid.UUID       `gorm:"type:uuid;index" json:"instance_id,omitempty"`
+	Type       NotificationType `gorm:"type:varchar(50);not null" json:"type"`
+	Level      EventLevel       `gorm:"type:varchar(20);not null" json:"level"`
+	Title      string           `gorm:"size:200" json:"title"`
+	Message    string           `gorm:"size:1000" json:"message"`
+	ReadAt     *time.Time       `json:"read_at,omitempty"`
+	CreatedAt  time.Time        `gorm:"index:idx_notifications_user_created" json:"created_at"`
+}
+
+// TableName sets the table name for the Notification model
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeCreate hook is called before creating a new notification
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the notification for API responses
+func (n *Notification) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          n.ID,
+		"instance_id": n.InstanceID,
+		"type":        n.Type,
+		"level":       n.Level,
+		"title":       n.Title,
+		"message":     n.Message,
+		"read":        n.ReadAt != nil,
+		"read_at":     n.ReadAt,
+		"created_at":  n.CreatedAt,
+	}
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TableSize is the disk space of a database table, including its indexes and TOAST data
+type TableSize struct {
+	Table       string `json:"table"`
+	Bytes       int64  `json:"bytes"`
+	Rows        int64  `json:"rows"`                   // estimate of the live rows
+	GrowthBytes *int64 `json:"growth_bytes,omitempty"` // since the previous report
+}
+
+// HypertableChunks counts the chunks of a TimescaleDB hypertable
+type HypertableChunks struct {
+	Hypertable       string `json:"hypertable"`
+	Chunks           int    `json:"chunks"`
+	CompressedChunks int    `json:"compressed_chunks"`
+	ChunkChange      *int   `json:"chunk_change,omitempty"` // since the previous report
+}
+
+// EndpointLatency summarizes the requests of a route
+type EndpointLatency struct {
+	Method       string   `json:"method"`
+	Route        string   `json:"route"`
+	Requests     uint64   `json:"requests"`
+	ServerErrors uint64   `json:"server_errors"`
+	MeanMS       float64  `json:"mean_ms"`
+	P95MS        *float64 `json:"p95_ms"` // bucket bound; null when above the largest bucket
+}
+
+// ErrorCodeCount counts the error responses with an error code
+type ErrorCodeCount struct {
+	Code  string `json:"code"`
+	Count uint64 `json:"count"`
+}
+
+// PendingDNSRecord is a DNS record left over from a deleted instance or a previous container
+type PendingDNSRecord struct {
+	Name   string `json:"name"`
+	Answer string `json:"answer"`
+}
+
+// OperatorReportData is the content of an operator report. Sections that could not be
+// gathered are named in Problems and left empty.
+type OperatorReportData struct {
+	DatabaseBytes        int64              `json:"database_bytes"`
+	DatabaseGrowthBytes  *int64             `json:"database_growth_bytes,omitempty"`
+	Tables               []TableSize        `json:"tables"`
+	Hypertables          []HypertableChunks `json:"hypertables"`
+	EndpointsSince       time.Time          `json:"endpoints_since"`
+	SlowestEndpoints     []EndpointLatency  `json:"slowest_endpoints"`
+	TopErrorCodes        []ErrorCodeCount   `json:"top_error_codes"`
+	DNSCleanupsSupported bool               `json:"dns_cleanups_supported"`
+	PendingDNSCleanups   []PendingDNSRecord `json:"pending_dns_cleanups"`
+	Problems             []string           `json:"problems"`
+}
+
+// Value stores the report data as JSON
+func (d OperatorReportData) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan reads the report data from its JSON column
+func (d *OperatorReportData) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported operator report data type")
+	}
+	return json.Unmarshal(data, d)
+}
+
+// OperatorReport is a periodic summary of the platform's database growth, slow endpoints,
+// errors and pending DNS cleanups, kept to compare reports over time
+type OperatorReport struct {
+	ID            uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PeriodStart   time.Time          `json:"period_start"`
+	PeriodEnd     time.Time          `gorm:"index" json:"period_end"`
+	Data          OperatorReportData `gorm:"type:jsonb" json:"data"`
+	DeliveredTo   string             `gorm:"size:255" json:"delivered_to"` // comma-separated channels, e.g. "email,webhook"
+	DeliveryError string             `gorm:"type:text" json:"delivery_error,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// TableName sets the table name for the OperatorReport model
+func (OperatorReport) TableName() string {
+	return "operator_reports"
+}
+
+// BeforeCreate hook is called before creating a new operator report
+func (r *OperatorReport) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the operator report for API responses
+func (r *OperatorReport) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":             r.ID,
+		"period_start":   r.PeriodStart,
+		"period_end":     r.PeriodEnd,
+		"data":           r.Data,
+		"delivered_to":   r.DeliveredTo,
+		"delivery_error": r.DeliveryError,
+		"created_at":     r.CreatedAt,
+	}
+}
+
+// ToSummaryResponse returns the operator report without its tables and lists, for listings
+func (r *OperatorReport) ToSummaryResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                    r.ID,
+		"period_start":          r.PeriodStart,
+		"period_end":            r.PeriodEnd,
+		"database_bytes":        r.Data.DatabaseBytes,
+		"database_growth_bytes": r.Data.DatabaseGrowthBytes,
+		"pending_dns_cleanups":  len(r.Data.PendingDNSCleanups),
+		"problems":              len(r.Data.Problems),
+		"delivered_to":          r.DeliveredTo,
+		"created_at":            r.CreatedAt,
+	}
+}
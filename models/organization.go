@@ -0,0 +1,183 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrgRole defines what a member can do in an organization
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"  // manages the organization, its members and its instances
+	OrgRoleAdmin  OrgRole = "admin"  // manages members other than owners, and the instances
+	OrgRoleMember OrgRole = "member" // creates and operates instances, but cannot delete or reconfigure them
+	OrgRoleViewer OrgRole = "viewer" // sees the instances and their status
+)
+
+// orgRoleRanks orders the roles by what they allow
+var orgRoleRanks = map[OrgRole]int{
+	OrgRoleViewer: 1,
+	OrgRoleMember: 2,
+	OrgRoleAdmin:  3,
+	OrgRoleOwner:  4,
+}
+
+// Valid checks if the role is one of the organization roles
+func (r OrgRole) Valid() bool {
+	_, ok := orgRoleRanks[r]
+	return ok
+}
+
+// AtLeast checks if the role allows everything another role allows
+func (r OrgRole) AtLeast(other OrgRole) bool {
+	return orgRoleRanks[r] >= orgRoleRanks[other]
+}
+
+// Organization is a team whose members share its instances according to their roles.
+// Instances stay billed to, and count towards the limits of, the member who created them.
+type Organization struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name       string         `gorm:"size:255;not null" json:"name"`
+	ClerkOrgID *string        `gorm:"size:255;uniqueIndex" json:"clerk_org_id,omitempty"` // Set on organizations mirrored from Clerk
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName sets the table name for the Organization model
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// BeforeCreate hook is called before creating a new organization
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the organization for API responses,
+// with the role of the member viewing it
+func (o *Organization) ToPublicResponse(role OrgRole) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         o.ID,
+		"name":       o.Name,
+		"clerk":      o.ClerkOrgID != nil,
+		"role":       role,
+		"created_at": o.CreatedAt,
+		"updated_at": o.UpdatedAt,
+	}
+}
+
+// OrgMembership gives a user a role in an organization
+type OrgMembership struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_org_memberships_org_user" json:"organization_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_org_memberships_org_user;index" json:"user_id"`
+	Role           OrgRole   `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relationships
+	User         User         `gorm:"foreignKey:UserID" json:"-"`
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+}
+
+// TableName sets the table name for the OrgMembership model
+func (OrgMembership) TableName() string {
+	return "org_memberships"
+}
+
+// BeforeCreate hook is called before creating a new membership
+func (m *OrgMembership) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the membership for API responses,
+// with the member's email and name when the user is loaded
+func (m *OrgMembership) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":    m.UserID,
+		"email":      m.User.Email,
+		"first_name": m.User.FirstName,
+		"last_name":  m.User.LastName,
+		"role":       m.Role,
+		"created_at": m.CreatedAt,
+	}
+}
+
+// OrgInvitationTTL is how long an invitation to an organization can be accepted
+const OrgInvitationTTL = 7 * 24 * time.Hour
+
+// OrgInvitation invites whoever signs in with an email address to join an organization
+type OrgInvitation struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index" json:"organization_id"`
+	Email          string     `gorm:"size:255;index;not null" json:"email"` // lowercased
+	Role           OrgRole    `gorm:"type:varchar(20);not null" json:"role"`
+	InvitedByID    uuid.UUID  `gorm:"type:uuid" json:"invited_by_id"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Relationships
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+}
+
+// TableName sets the table name for the OrgInvitation model
+func (OrgInvitation) TableName() string {
+	return "org_invitations"
+}
+
+// BeforeCreate hook is called before creating a new invitation
+func (i *OrgInvitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewOrgInvitation invites an email address to an organization with a role
+func NewOrgInvitation(organizationID uuid.UUID, email string, role OrgRole, invitedBy uuid.UUID) *OrgInvitation {
+	return &OrgInvitation{
+		OrganizationID: organizationID,
+		Email:          NormalizeEmail(email),
+		Role:           role,
+		InvitedByID:    invitedBy,
+		ExpiresAt:      time.Now().Add(OrgInvitationTTL),
+	}
+}
+
+// Pending checks if the invitation can still be accepted
+func (i *OrgInvitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// ToPublicResponse returns a public representation of the invitation for API responses,
+// with the organization's name when it is loaded
+func (i *OrgInvitation) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                i.ID,
+		"organization_id":   i.OrganizationID,
+		"organization_name": i.Organization.Name,
+		"email":             i.Email,
+		"role":              i.Role,
+		"expires_at":        i.ExpiresAt,
+		"accepted_at":       i.AcceptedAt,
+		"created_at":        i.CreatedAt,
+	}
+}
+
+// NormalizeEmail lowercases an email address and trims surrounding spaces, so addresses
+// compare equal however they were typed
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
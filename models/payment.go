@@ -34,6 +34,9 @@ type Payment struct {
 	Status          PaymentStatus `gorm:"type:varchar(20)" json:"status"`
 	PayPalPaymentID string        `json:"paypal_payment_id,omitempty"`
 	PayPalOrderID   string        `json:"paypal_order_id,omitempty"`
+	Provider           string     `gorm:"type:varchar(20)" json:"provider,omitempty"`
+	ProviderCheckoutID string     `gorm:"index" json:"provider_checkout_id,omitempty"` // PayPal order or Stripe checkout session
+	ProviderPaymentID  string     `json:"provider_payment_id,omitempty"`                // PayPal capture or Stripe invoice
 	InvoiceURL      string        `json:"invoice_url,omitempty"`
 	Description     string        `json:"description"`
 	Metadata        string        `gorm:"type:jsonb" json:"metadata,omitempty"`
@@ -70,20 +73,17 @@ func (p *Payment) ToPublicResponse() map[string]interface{} {
 	}
 }
 
-// GetPlanPrice returns the price for a plan and billing period
+// GetPlanPrice returns the price of a plan of the catalog for a billing period, in dollars.
+// Plans that are not in the catalog cost nothing.
 func GetPlanPrice(plan SubscriptionPlan, billingPeriod BillingPeriod) float64 {
-	// Monthly prices
-	prices := map[SubscriptionPlan]float64{
-		PlanStarter: 2.0,
-		PlanPro:     29.0,
+	catalogPlan, ok := GetPlan(plan)
+	if !ok {
+		return 0
 	}
-
-	// Apply yearly discount (2 months free)
 	if billingPeriod == BillingYearly {
-		return prices[plan] * 10 // 12 months - 2 months free
+		return float64(catalogPlan.YearlyPrice) / 100
 	}
-
-	return prices[plan]
+	return float64(catalogPlan.MonthlyPrice) / 100
 }
 
 // NewPayment creates a new payment record
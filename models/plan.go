@@ -0,0 +1,297 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Feature identifies a premium capability that is gated by subscription plan
+type Feature string
+
+const (
+	FeatureBackups           Feature = "backups"
+	FeatureCPUPinning        Feature = "cpu_pinning"
+	FeatureResourceOverrides Feature = "resource_overrides"
+	// FeaturePrivateNetworkAccess lets instances reach private address ranges when egress is
+	// restricted. The platform's own hosts stay blocked.
+	FeaturePrivateNetworkAccess Feature = "private_network_access"
+)
+
+// KnownFeatures lists every feature plans can include
+var KnownFeatures = []Feature{FeatureBackups, FeatureCPUPinning, FeatureResourceOverrides, FeaturePrivateNetworkAccess}
+
+// PlanFeatureList is the features of a plan, stored as a JSON array
+type PlanFeatureList []Feature
+
+// Value implements driver.Valuer for storing the features as JSON
+func (f PlanFeatureList) Value() (driver.Value, error) {
+	if f == nil {
+		f = PlanFeatureList{}
+	}
+	return json.Marshal(f)
+}
+
+// Scan implements sql.Scanner for reading the features from JSON
+func (f *PlanFeatureList) Scan(value interface{}) error {
+	if value == nil {
+		*f = PlanFeatureList{}
+		return nil
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for PlanFeatureList")
+	}
+	return json.Unmarshal(data, f)
+}
+
+// Plan is a subscription plan of the catalog with its limits, features and prices. Plans are
+// stored in the database and kept in memory, see SetPlanCatalog.
+type Plan struct {
+	Name         SubscriptionPlan `gorm:"type:varchar(20);primaryKey" json:"name"`
+	DisplayName  string           `gorm:"size:100" json:"display_name"`
+	Description  string           `gorm:"size:500" json:"description"`
+	MonthlyPrice int              `json:"monthly_price"` // in cents
+	YearlyPrice  int              `json:"yearly_price"`  // in cents
+	Currency     string           `gorm:"type:varchar(3);default:'usd'" json:"currency"`
+	MaxInstances int              `json:"max_instances"`
+	CPULimit     float64          `json:"cpu_limit"`
+	MemoryLimit  int              `json:"memory_limit"`  // in MB
+	StorageLimit int              `json:"storage_limit"` // in GB
+	Features     PlanFeatureList  `gorm:"type:jsonb" json:"features"`
+	Public       bool             `gorm:"default:true" json:"public"` // Listed by GET /api/v1/plans
+	SortOrder    int              `json:"sort_order"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// TableName sets the table name for the Plan model
+func (Plan) TableName() string {
+	return "plans"
+}
+
+// Paid checks if the plan is sold
+func (p *Plan) Paid() bool {
+	return p.MonthlyPrice > 0
+}
+
+// HasFeature checks if the plan includes the given feature
+func (p *Plan) HasFeature(feature Feature) bool {
+	for _, f := range p.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// ToPublicResponse returns a public representation of the plan for API responses
+func (p *Plan) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"name":          p.Name,
+		"display_name":  p.DisplayName,
+		"description":   p.Description,
+		"monthly_price": float64(p.MonthlyPrice) / 100, // Convert cents to dollars
+		"yearly_price":  float64(p.YearlyPrice) / 100,
+		"currency":      p.Currency,
+		"max_instances": p.MaxInstances,
+		"cpu_limit":     p.CPULimit,
+		"memory_limit":  p.MemoryLimit,
+		"storage_limit": p.StorageLimit,
+		"features":      p.Features,
+		"trial_days":    PlanTrials[p.Name].Days,
+	}
+}
+
+// DefaultPlans returns the catalog a new database is seeded with
+func DefaultPlans() []Plan {
+	return []Plan{
+		{Name: PlanFree, DisplayName: "Free", Description: "One small instance to try n8n",
+			Currency: "usd", MaxInstances: 1, CPULimit: 0.5, MemoryLimit: 512, StorageLimit: 1,
+			Features: PlanFeatureList{}, Public: true, SortOrder: 0},
+		{Name: PlanStarter, DisplayName: "Starter", Description: "One instance for personal automations",
+			MonthlyPrice: 200, YearlyPrice: 2000, Currency: "usd", MaxInstances: 1, CPULimit: 0.5, MemoryLimit: 512, StorageLimit: 1,
+			Features: PlanFeatureList{}, Public: true, SortOrder: 1},
+		{Name: PlanPro, DisplayName: "Pro", Description: "Up to ten larger instances with backups",
+			MonthlyPrice: 2900, YearlyPrice: 29000, Currency: "usd", MaxInstances: 10, CPULimit: 1.0, MemoryLimit: 1024, StorageLimit: 20,
+			Features: PlanFeatureList{FeatureBackups, FeatureCPUPinning, FeatureResourceOverrides}, Public: true, SortOrder: 2},
+	}
+}
+
+// planCatalog holds the plans in memory, so that limits are checked without a query
+var planCatalog = struct {
+	sync.RWMutex
+	plans map[SubscriptionPlan]Plan
+}{plans: plansByName(DefaultPlans())}
+
+// plansByName indexes plans by their name
+func plansByName(plans []Plan) map[SubscriptionPlan]Plan {
+	byName := make(map[SubscriptionPlan]Plan, len(plans))
+	for _, plan := range plans {
+		byName[plan.Name] = plan
+	}
+	return byName
+}
+
+// SetPlanCatalog replaces the plans in memory with the ones loaded from the database
+func SetPlanCatalog(plans []Plan) {
+	byName := plansByName(plans)
+	planCatalog.Lock()
+	planCatalog.plans = byName
+	planCatalog.Unlock()
+}
+
+// GetPlan returns a plan of the catalog
+func GetPlan(name SubscriptionPlan) (Plan, bool) {
+	planCatalog.RLock()
+	defer planCatalog.RUnlock()
+	plan, ok := planCatalog.plans[name]
+	return plan, ok
+}
+
+// ListPlans returns the plans of the catalog in their sort order
+func ListPlans() []Plan {
+	planCatalog.RLock()
+	plans := make([]Plan, 0, len(planCatalog.plans))
+	for _, plan := range planCatalog.plans {
+		plans = append(plans, plan)
+	}
+	planCatalog.RUnlock()
+
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].SortOrder != plans[j].SortOrder {
+			return plans[i].SortOrder < plans[j].SortOrder
+		}
+		return plans[i].Name < plans[j].Name
+	})
+	return plans
+}
+
+// planOrFree returns a plan of the catalog, or the free plan for plans that are not in it
+func planOrFree(name SubscriptionPlan) Plan {
+	if plan, ok := GetPlan(name); ok {
+		return plan
+	}
+	if plan, ok := GetPlan(PlanFree); ok {
+		return plan
+	}
+	return DefaultPlans()[0]
+}
+
+// TrialPolicy is the trial a plan offers and how it may be extended
+type TrialPolicy struct {
+	Days             int // length of a new trial
+	MaxExtensionDays int // most days a single admin extension may add
+	OfferDays        int // days of the one-time extension users can claim themselves; 0 offers none
+}
+
+// PlanTrials lists the plans that offer a trial. Plans missing here have none.
+var PlanTrials = map[SubscriptionPlan]TrialPolicy{
+	PlanStarter: {Days: 7, MaxExtensionDays: 30, OfferDays: 3},
+	PlanPro:     {Days: 7, MaxExtensionDays: 30, OfferDays: 3},
+}
+
+// ConfigurePlanTrials replaces the trials of the catalog with the configured ones, given as
+// trial lengths in days by plan. It is called once at startup.
+func ConfigurePlanTrials(plans map[string]int, maxExtensionDays, offerDays int) error {
+	trials := map[SubscriptionPlan]TrialPolicy{}
+	for name, days := range plans {
+		plan := SubscriptionPlan(name)
+		if _, ok := GetPlan(plan); !ok {
+			return fmt.Errorf("unknown plan %q", name)
+		}
+		trials[plan] = TrialPolicy{Days: days, MaxExtensionDays: maxExtensionDays, OfferDays: offerDays}
+	}
+	PlanTrials = trials
+	return nil
+}
+
+// SignupTrialPlan is the plan whose trial new users start on; empty starts them on the free plan
+var SignupTrialPlan = PlanStarter
+
+// ConfigureSignupTrial sets the plan whose trial new users start on. It is called once at
+// startup, after the trials are configured.
+func ConfigureSignupTrial(plan string) error {
+	if plan == "" {
+		SignupTrialPlan = ""
+		return nil
+	}
+	if _, ok := PlanTrial(SubscriptionPlan(plan)); !ok {
+		return fmt.Errorf("plan %q offers no trial", plan)
+	}
+	SignupTrialPlan = SubscriptionPlan(plan)
+	return nil
+}
+
+// PlanTrial returns the trial policy of a plan and whether the plan offers a trial
+func PlanTrial(plan SubscriptionPlan) (TrialPolicy, bool) {
+	policy, ok := PlanTrials[plan]
+	return policy, ok && policy.Days > 0
+}
+
+// InstanceSize is the resource allocation of an instance on a plan
+type InstanceSize struct {
+	Name         string
+	Plan         SubscriptionPlan
+	CPULimit     float64
+	MemoryLimit  int // in MB
+	StorageLimit int // in GB
+}
+
+// InstanceSizes lists the instance sizes of the paid plans, smallest first
+func InstanceSizes() []InstanceSize {
+	sizes := []InstanceSize{}
+	for _, plan := range ListPlans() {
+		if !plan.Paid() {
+			continue
+		}
+		sizes = append(sizes, InstanceSize{
+			Name:         string(plan.Name),
+			Plan:         plan.Name,
+			CPULimit:     plan.CPULimit,
+			MemoryLimit:  plan.MemoryLimit,
+			StorageLimit: plan.StorageLimit,
+		})
+	}
+	return sizes
+}
+
+// PlanHasFeature checks if a plan includes the given feature
+func PlanHasFeature(plan SubscriptionPlan, feature Feature) bool {
+	catalogPlan, ok := GetPlan(plan)
+	return ok && catalogPlan.HasFeature(feature)
+}
+
+// PlanFeatures returns the features included in a plan
+func PlanFeatures(plan SubscriptionPlan) []Feature {
+	catalogPlan, ok := GetPlan(plan)
+	if !ok {
+		return []Feature{}
+	}
+	return catalogPlan.Features
+}
+
+// PlansWithFeature returns the plans that include the given feature
+func PlansWithFeature(feature Feature) []SubscriptionPlan {
+	plans := []SubscriptionPlan{}
+	for _, plan := range ListPlans() {
+		if plan.HasFeature(feature) {
+			plans = append(plans, plan.Name)
+		}
+	}
+	return plans
+}
+
+// HasFeature checks if the user's plan includes the given feature
+func (u *User) HasFeature(feature Feature) bool {
+	return PlanHasFeature(u.Plan, feature)
+}
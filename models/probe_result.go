@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProbeResult records whether an instance was reachable from one probe location
+type ProbeResult struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID uuid.UUID `gorm:"type:uuid;index:idx_probe_results_instance_checked" json:"instance_id"`
+	Region     string    `gorm:"size:100" json:"region"`
+	Reachable  bool      `json:"reachable"`
+	LatencyMS  int64     `json:"latency_ms"`
+	Error      string    `gorm:"size:500" json:"error,omitempty"`
+	CheckedAt  time.Time `gorm:"index:idx_probe_results_instance_checked" json:"checked_at"`
+}
+
+// TableName sets the table name for the ProbeResult model
+func (ProbeResult) TableName() string {
+	return "probe_results"
+}
+
+// BeforeCreate hook is called before creating a new probe result
+func (r *ProbeResult) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the probe result for API responses
+func (r *ProbeResult) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"region":     r.Region,
+		"reachable":  r.Reachable,
+		"latency_ms": r.LatencyMS,
+		"error":      r.Error,
+		"checked_at": r.CheckedAt,
+	}
+}
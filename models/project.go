@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Project groups a user's instances and holds defaults applied to new instances in it
+type Project struct {
+	ID                  uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID              uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
+	Name                string         `gorm:"size:255;not null" json:"name"`
+	Description         string         `gorm:"size:1000" json:"description"`
+	DefaultCPULimit     float64        `json:"default_cpu_limit"`     // 0 uses the plan limit
+	DefaultMemoryLimit  int            `json:"default_memory_limit"`  // in MB, 0 uses the plan limit
+	DefaultStorageLimit int            `json:"default_storage_limit"` // in GB, 0 uses the plan limit
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User      User       `gorm:"foreignKey:UserID" json:"-"`
+	Instances []Instance `gorm:"foreignKey:ProjectID" json:"-"`
+}
+
+// TableName sets the table name for the Project model
+func (Project) TableName() string {
+	return "projects"
+}
+
+// BeforeCreate hook is called before creating a new project
+func (p *Project) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// ApplyDefaults fills the resource limits of a new instance from the project defaults,
+// never exceeding the limits of the user's plan
+func (p *Project) ApplyDefaults(instance *Instance, user User) {
+	if p.DefaultCPULimit > 0 && p.DefaultCPULimit <= user.GetCPULimit() {
+		instance.CPULimit = p.DefaultCPULimit
+	}
+	if p.DefaultMemoryLimit > 0 && p.DefaultMemoryLimit <= user.GetMemoryLimit() {
+		instance.MemoryLimit = p.DefaultMemoryLimit
+	}
+	if p.DefaultStorageLimit > 0 && p.DefaultStorageLimit <= user.GetStorageLimit() {
+		instance.StorageLimit = p.DefaultStorageLimit
+	}
+}
+
+// ToPublicResponse returns a public representation of the project for API responses
+func (p *Project) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                    p.ID,
+		"name":                  p.Name,
+		"description":           p.Description,
+		"default_cpu_limit":     p.DefaultCPULimit,
+		"default_memory_limit":  p.DefaultMemoryLimit,
+		"default_storage_limit": p.DefaultStorageLimit,
+		"created_at":            p.CreatedAt,
+		"updated_at":            p.UpdatedAt,
+	}
+}
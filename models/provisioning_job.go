@@ -0,0 +1,155 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProvisioningStatus defines the progress of an instance's provisioning, or of one of its steps
+type ProvisioningStatus string
+
+const (
+	ProvisioningPending   ProvisioningStatus = "pending"
+	ProvisioningRunning   ProvisioningStatus = "running"
+	ProvisioningSucceeded ProvisioningStatus = "succeeded"
+	ProvisioningFailed    ProvisioningStatus = "failed"
+	ProvisioningSkipped   ProvisioningStatus = "skipped"
+)
+
+// ProvisioningStepName identifies a step of provisioning an instance
+type ProvisioningStepName string
+
+const (
+	StepCreateVolumes   ProvisioningStepName = "create_volumes"
+	StepPullImage       ProvisioningStepName = "pull_image"
+	StepCreateContainer ProvisioningStepName = "create_container"
+	StepStartContainer  ProvisioningStepName = "start_container"
+	StepDNS             ProvisioningStepName = "dns"
+	StepHealthCheck     ProvisioningStepName = "health_check"
+	StepProxy           ProvisioningStepName = "proxy"
+	StepCleanup         ProvisioningStepName = "cleanup" // only run by failed attempts, removing what they created
+)
+
+// ProvisioningStepNames lists the provisioning steps in the order they run
+var ProvisioningStepNames = []ProvisioningStepName{
+	StepCreateVolumes, StepPullImage, StepCreateContainer, StepStartContainer, StepDNS, StepHealthCheck, StepProxy,
+}
+
+// ProvisioningStep records the progress of a single provisioning step
+type ProvisioningStep struct {
+	Name       ProvisioningStepName `json:"name"`
+	Status     ProvisioningStatus   `json:"status"`
+	Error      string               `json:"error,omitempty"`
+	StartedAt  *time.Time           `json:"started_at,omitempty"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+}
+
+// ProvisioningSteps is the list of steps stored as JSON on a provisioning job
+type ProvisioningSteps []ProvisioningStep
+
+// Value stores the steps as JSON
+func (s ProvisioningSteps) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the steps from their JSON column
+func (s *ProvisioningSteps) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported provisioning steps type")
+	}
+	return json.Unmarshal(data, s)
+}
+
+// ProvisioningJob tracks the asynchronous provisioning of a new instance
+type ProvisioningJob struct {
+	ID             uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID     uuid.UUID          `gorm:"type:uuid;uniqueIndex" json:"instance_id"`
+	JobID          *uuid.UUID         `gorm:"type:uuid" json:"job_id,omitempty"` // Queue job running the provisioning
+	UserID         uuid.UUID          `gorm:"type:uuid;uniqueIndex:idx_provisioning_jobs_idempotency,where:idempotency_key <> ''" json:"user_id"`
+	IdempotencyKey string             `gorm:"size:255;uniqueIndex:idx_provisioning_jobs_idempotency,where:idempotency_key <> ''" json:"-"` // From the Idempotency-Key header
+	Status         ProvisioningStatus `gorm:"type:varchar(20);index" json:"status"`
+	Attempts       int                `gorm:"not null;default:0" json:"attempts"` // Provisioning runs so far, including retries requested after failing
+	Steps          ProvisioningSteps  `gorm:"type:jsonb" json:"steps"`            // Steps of the latest attempt; provisioning_steps has every attempt
+	Error          string             `gorm:"size:1000" json:"error,omitempty"`
+	StartedAt      *time.Time         `json:"started_at,omitempty"`
+	FinishedAt     *time.Time         `json:"finished_at,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// TableName sets the table name for the ProvisioningJob model
+func (ProvisioningJob) TableName() string {
+	return "provisioning_jobs"
+}
+
+// BeforeCreate hook is called before creating a new provisioning job
+func (j *ProvisioningJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewProvisioningJob creates a pending provisioning job with every step pending
+func NewProvisioningJob(instance *Instance, idempotencyKey string) *ProvisioningJob {
+	return &ProvisioningJob{
+		InstanceID:     instance.ID,
+		UserID:         instance.UserID,
+		IdempotencyKey: idempotencyKey,
+		Status:         ProvisioningPending,
+		Steps:          PendingProvisioningSteps(),
+	}
+}
+
+// PendingProvisioningSteps returns every provisioning step as pending, as they are before an
+// attempt starts
+func PendingProvisioningSteps() ProvisioningSteps {
+	steps := make(ProvisioningSteps, 0, len(ProvisioningStepNames))
+	for _, name := range ProvisioningStepNames {
+		steps = append(steps, ProvisioningStep{Name: name, Status: ProvisioningPending})
+	}
+	return steps
+}
+
+// IsFinished checks if provisioning has succeeded or failed
+func (j *ProvisioningJob) IsFinished() bool {
+	return j.Status == ProvisioningSucceeded || j.Status == ProvisioningFailed
+}
+
+// Step returns the progress of the named step
+func (j *ProvisioningJob) Step(name ProvisioningStepName) *ProvisioningStep {
+	for i := range j.Steps {
+		if j.Steps[i].Name == name {
+			return &j.Steps[i]
+		}
+	}
+	j.Steps = append(j.Steps, ProvisioningStep{Name: name, Status: ProvisioningPending})
+	return &j.Steps[len(j.Steps)-1]
+}
+
+// ToPublicResponse returns a public representation of the provisioning job for API responses
+func (j *ProvisioningJob) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          j.ID,
+		"instance_id": j.InstanceID,
+		"job_id":      j.JobID,
+		"status":      j.Status,
+		"attempts":    j.Attempts,
+		"steps":       j.Steps,
+		"error":       j.Error,
+		"created_at":  j.CreatedAt,
+		"started_at":  j.StartedAt,
+		"finished_at": j.FinishedAt,
+	}
+}
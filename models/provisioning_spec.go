@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProvisioningSpecVersion is bumped when the layout of ProvisioningSpec changes
+const ProvisioningSpecVersion = 1
+
+// ProvisioningSpec is the fully resolved request an instance was created from. It is
+// written once on creation and never updated, so it serves as the authoritative spec for
+// recreating or cloning the instance and as the baseline for config drift detection.
+type ProvisioningSpec struct {
+	SpecVersion  int               `json:"spec_version"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description,omitempty"`
+	ProjectID    *uuid.UUID        `json:"project_id,omitempty"`
+	Template     string            `json:"template,omitempty"`
+	Region       string            `json:"region,omitempty"`
+	Image        string            `json:"image"`
+	ImageTag     string            `json:"image_tag"`
+	CPULimit     float64           `json:"cpu_limit"`
+	MemoryLimit  int               `json:"memory_limit"`  // in MB
+	StorageLimit int               `json:"storage_limit"` // in GB
+	Env          map[string]string `json:"env"`           // Secret values are redacted
+	Plan         SubscriptionPlan  `json:"plan"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// Value stores the spec as JSON
+func (s ProvisioningSpec) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the spec from its JSON column
+func (s *ProvisioningSpec) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported provisioning spec type")
+	}
+	return json.Unmarshal(data, s)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProvisioningStepRecord records one run of a provisioning step in one attempt at
+// provisioning an instance. Unlike the steps on the provisioning job, which only show the
+// latest attempt, the records of failed attempts and their cleanup are kept, so it stays
+// visible which resources an attempt created and whether they were removed again.
+type ProvisioningStepRecord struct {
+	ID                uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ProvisioningJobID uuid.UUID            `gorm:"type:uuid;uniqueIndex:idx_provisioning_steps_attempt_step" json:"provisioning_job_id"`
+	InstanceID        uuid.UUID            `gorm:"type:uuid;index" json:"instance_id"`
+	Attempt           int                  `gorm:"uniqueIndex:idx_provisioning_steps_attempt_step" json:"attempt"`
+	Name              ProvisioningStepName `gorm:"size:50;uniqueIndex:idx_provisioning_steps_attempt_step" json:"name"`
+	Status            ProvisioningStatus   `gorm:"type:varchar(20)" json:"status"`
+	Error             string               `gorm:"size:1000" json:"error,omitempty"`
+	StartedAt         *time.Time           `json:"started_at,omitempty"`
+	FinishedAt        *time.Time           `json:"finished_at,omitempty"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+}
+
+// TableName sets the table name for the ProvisioningStepRecord model
+func (ProvisioningStepRecord) TableName() string {
+	return "provisioning_steps"
+}
+
+// BeforeCreate hook is called before creating a new provisioning step record
+func (r *ProvisioningStepRecord) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewProvisioningStepRecord records the state of a step of a provisioning job's current attempt
+func NewProvisioningStepRecord(job *ProvisioningJob, step ProvisioningStep) *ProvisioningStepRecord {
+	return &ProvisioningStepRecord{
+		ProvisioningJobID: job.ID,
+		InstanceID:        job.InstanceID,
+		Attempt:           job.Attempts,
+		Name:              step.Name,
+		Status:            step.Status,
+		Error:             step.Error,
+		StartedAt:         step.StartedAt,
+		FinishedAt:        step.FinishedAt,
+	}
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// RedactedValue replaces secret values in API responses, provisioning specs and logs
+const RedactedValue = "[redacted]"
+
+// secretNameWords mark field, header and environment variable names whose values are secret
+var secretNameWords = map[string]bool{
+	"PASSWORD":      true,
+	"PASSWD":        true,
+	"SECRET":        true,
+	"TOKEN":         true,
+	"HASH":          true,
+	"AUTHORIZATION": true,
+	"COOKIE":        true,
+}
+
+// internalIdentifierNames are payment provider identifiers. Only the subscription endpoints
+// return them, to their owner; logs keep just enough of them to correlate webhook events.
+var internalIdentifierNames = map[string]bool{
+	"SUBSCRIPTION_ID":    true,
+	"PAYPAL_CUSTOMER_ID": true,
+	"CUSTOMER_ID":        true,
+}
+
+// minSecretValueLength keeps short values, which would match ordinary text, from being
+// registered as secrets
+const minSecretValueLength = 6
+
+// secretValues are the values of the platform's own secrets, e.g. its database password,
+// redacted wherever they appear in log messages
+var secretValues struct {
+	sync.RWMutex
+	replacer *strings.Replacer
+	values   []string
+}
+
+// nameWords splits a snake_case, kebab-case or UPPER_CASE name into upper case words
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// IsSecretName checks if a field, header or environment variable name holds a secret, such
+// as a password, token, hash or key (e.g. "password_hash", "Authorization", "ENCRYPTION_KEY")
+func IsSecretName(name string) bool {
+	words := nameWords(name)
+	for _, word := range words {
+		if secretNameWords[word] {
+			return true
+		}
+	}
+	return len(words) > 0 && words[len(words)-1] == "KEY"
+}
+
+// IsInternalIdentifierName checks if a field name holds a payment provider identifier
+func IsInternalIdentifierName(name string) bool {
+	return internalIdentifierNames[strings.Join(nameWords(name), "_")]
+}
+
+// MaskIdentifier hides all but the last four characters of an identifier
+func MaskIdentifier(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return "..." + value[len(value)-4:]
+}
+
+// RedactField returns the value of a named log or response field with secrets redacted and
+// provider identifiers masked; other values are returned unchanged
+func RedactField(name string, value interface{}) interface{} {
+	switch {
+	case IsSecretName(name):
+		return RedactedValue
+	case IsInternalIdentifierName(name):
+		return MaskIdentifier(fmt.Sprint(value))
+	}
+	return value
+}
+
+// RegisterSecretValue registers the value of a secret of the platform, such as a password
+// loaded from its configuration, to be redacted by RedactSecretValues
+func RegisterSecretValue(value string) {
+	if len(value) < minSecretValueLength {
+		return
+	}
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	for _, registered := range secretValues.values {
+		if registered == value {
+			return
+		}
+	}
+	secretValues.values = append(secretValues.values, value)
+	// Longer values first, so a secret containing another is redacted whole
+	sort.Slice(secretValues.values, func(i, j int) bool {
+		return len(secretValues.values[i]) > len(secretValues.values[j])
+	})
+	pairs := make([]string, 0, 2*len(secretValues.values))
+	for _, registered := range secretValues.values {
+		pairs = append(pairs, registered, RedactedValue)
+	}
+	secretValues.replacer = strings.NewReplacer(pairs...)
+}
+
+// RedactSecretValues replaces the registered secret values in a string
+func RedactSecretValues(s string) string {
+	secretValues.RLock()
+	replacer := secretValues.replacer
+	secretValues.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// RedactEnv converts KEY=value environment entries to a map, redacting secret values
+func RedactEnv(env []string) map[string]string {
+	redacted := make(map[string]string, len(env))
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		if IsSecretName(name) {
+			value = RedactedValue
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// RedactJSON redacts the secret fields of a JSON document, at any depth, for logging.
+// Data that is not JSON is returned unchanged.
+func RedactJSON(data []byte) []byte {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return data
+	}
+	redacted, err := json.Marshal(redactJSONValue(document))
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+// redactJSONValue redacts the secret fields of a decoded JSON value
+func redactJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if IsSecretName(key) || IsInternalIdentifierName(key) {
+				v[key] = RedactField(key, field)
+			} else {
+				v[key] = redactJSONValue(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSONValue(v[i])
+		}
+	}
+	return value
+}
+
+// FindSecretFields serializes a value as an API response would and returns the paths of
+// fields that expose a secret or provider identifier, e.g. "user.password_hash". Flags such
+// as "password_enabled" and empty values are allowed, as are redacted ones.
+func FindSecretFields(value interface{}) ([]string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	findSecretFields(document, "", &paths)
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// findSecretFields walks a decoded JSON value, collecting the paths of exposed secret fields
+func findSecretFields(value interface{}, path string, paths *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			if (IsSecretName(key) || IsInternalIdentifierName(key)) && exposesValue(field) {
+				*paths = append(*paths, fieldPath)
+				continue
+			}
+			findSecretFields(field, fieldPath, paths)
+		}
+	case []interface{}:
+		for i, item := range v {
+			findSecretFields(item, fmt.Sprintf("%s[%d]", path, i), paths)
+		}
+	}
+}
+
+// exposesValue checks if a decoded JSON field value could reveal a secret
+func exposesValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil, bool:
+		return false
+	case string:
+		return v != "" && v != RedactedValue && !strings.HasPrefix(v, "...")
+	}
+	return true
+}
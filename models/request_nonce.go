@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// RequestNonce records the nonce of an accepted signed API request so it cannot be replayed
+// against any replica. Nonces are kept until the signature timestamp window has passed,
+// after which the timestamp check rejects a replay on its own.
+type RequestNonce struct {
+	Key       string    `gorm:"primaryKey;size:200" json:"key"` // API key ID and nonce
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+}
+
+// TableName sets the table name for the RequestNonce model
+func (RequestNonce) TableName() string {
+	return "request_nonces"
+}
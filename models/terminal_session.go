@@ -0,0 +1,122 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// TerminalTranscriptLimit is the most admin input kept in a terminal session's transcript
+	TerminalTranscriptLimit = 64 * 1024
+	// TerminalTicketTTL is how long a terminal ticket can be used to connect
+	TerminalTicketTTL = 30 * time.Second
+)
+
+// TerminalSessionStatus is the stage of an admin terminal session
+type TerminalSessionStatus string
+
+const (
+	TerminalSessionPending TerminalSessionStatus = "pending" // ticket issued, not connected yet
+	TerminalSessionOpen    TerminalSessionStatus = "open"
+	TerminalSessionClosed  TerminalSessionStatus = "closed"
+)
+
+// TerminalSession records an admin's terminal session in an instance's container: who opened
+// it and why, what they typed, and how it ended. Sessions are opened with a single-use ticket
+// whose hash is stored here.
+type TerminalSession struct {
+	ID                  uuid.UUID             `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID          uuid.UUID             `gorm:"type:uuid;index" json:"instance_id"`
+	AdminID             uuid.UUID             `gorm:"type:uuid;index" json:"admin_id"`
+	TicketHash          string                `gorm:"size:64;uniqueIndex" json:"-"`
+	Command             string                `json:"command"` // the shell run in the container
+	Reason              string                `gorm:"size:500" json:"reason"`
+	Status              TerminalSessionStatus `gorm:"type:varchar(20)" json:"status"`
+	ClientIP            string                `gorm:"size:45" json:"client_ip"`
+	Transcript          string                `json:"-"` // admin input, up to TerminalTranscriptLimit bytes
+	TranscriptTruncated bool                  `json:"transcript_truncated"`
+	InputBytes          int64                 `json:"input_bytes"`
+	OutputBytes         int64                 `json:"output_bytes"`
+	ExitCode            *int                  `json:"exit_code"`
+	Error               string                `json:"error,omitempty"`
+	ExpiresAt           time.Time             `json:"-"` // when an unused ticket stops working
+	StartedAt           *time.Time            `json:"started_at"`
+	EndedAt             *time.Time            `json:"ended_at"`
+	CreatedAt           time.Time             `gorm:"index" json:"created_at"`
+}
+
+// TableName sets the table name for the TerminalSession model
+func (TerminalSession) TableName() string {
+	return "terminal_sessions"
+}
+
+// BeforeCreate hook is called before creating a new terminal session
+func (s *TerminalSession) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewTerminalSession creates a pending session of an admin running shell in an instance's
+// container and returns the single-use ticket that connects to it alongside the record to store
+func NewTerminalSession(instanceID, adminID uuid.UUID, shell, reason string) (string, *TerminalSession, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	ticket := hex.EncodeToString(raw)
+
+	return ticket, &TerminalSession{
+		InstanceID: instanceID,
+		AdminID:    adminID,
+		TicketHash: HashTerminalTicket(ticket),
+		Command:    shell,
+		Reason:     reason,
+		Status:     TerminalSessionPending,
+		ExpiresAt:  time.Now().Add(TerminalTicketTTL),
+	}, nil
+}
+
+// HashTerminalTicket returns the stored hash of a terminal ticket
+func HashTerminalTicket(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordInput adds admin input to the session's transcript, up to TerminalTranscriptLimit
+func (s *TerminalSession) RecordInput(data []byte) {
+	s.InputBytes += int64(len(data))
+	room := TerminalTranscriptLimit - len(s.Transcript)
+	if len(data) > room {
+		data = data[:max(room, 0)]
+		s.TranscriptTruncated = true
+	}
+	s.Transcript += string(data)
+}
+
+// ToPublicResponse returns the session as listed to admins, without its transcript
+func (s *TerminalSession) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                   s.ID,
+		"instance_id":          s.InstanceID,
+		"admin_id":             s.AdminID,
+		"command":              s.Command,
+		"reason":               s.Reason,
+		"status":               s.Status,
+		"client_ip":            s.ClientIP,
+		"transcript_truncated": s.TranscriptTruncated,
+		"input_bytes":          s.InputBytes,
+		"output_bytes":         s.OutputBytes,
+		"exit_code":            s.ExitCode,
+		"error":                s.Error,
+		"started_at":           s.StartedAt,
+		"ended_at":             s.EndedAt,
+		"created_at":           s.CreatedAt,
+	}
+}
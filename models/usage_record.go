@@ -0,0 +1,126 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UsageRecord holds a user's metered resource usage for a calendar month, used for
+// usage-based billing. It is accumulated from every resource monitoring sample.
+type UsageRecord struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_usage_record_user_period" json:"user_id"`
+	Period          time.Time  `gorm:"type:date;uniqueIndex:idx_usage_record_user_period" json:"period"` // First day of the month, UTC
+	CPUSeconds      float64    `json:"cpu_seconds"`
+	MemoryMBHours   float64    `json:"memory_mb_hours"`
+	NetworkInBytes  int64      `json:"network_in_bytes"`
+	NetworkOutBytes int64      `json:"network_out_bytes"`
+	ExportedAt      *time.Time `json:"exported_at,omitempty"` // When the closed month was handed to the payment layer
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the UsageRecord model
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
+
+// BeforeCreate hook is called before creating a new usage record
+func (u *UsageRecord) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+// UsagePeriod returns the usage period, the first day of the UTC month, that t falls in
+func UsagePeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ToPublicResponse returns a public representation of the usage record for API responses
+func (u *UsageRecord) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"period":            u.Period.Format("2006-01"),
+		"cpu_seconds":       u.CPUSeconds,
+		"memory_mb_hours":   u.MemoryMBHours,
+		"network_in_bytes":  u.NetworkInBytes,
+		"network_in":        formatBytes(u.NetworkInBytes),
+		"network_out_bytes": u.NetworkOutBytes,
+		"network_out":       formatBytes(u.NetworkOutBytes),
+		"closed":            u.Period.AddDate(0, 1, 0).Before(time.Now()),
+	}
+}
+
+// UsagePrices are the prices of metered usage in cents per unit
+type UsagePrices struct {
+	CPUHour      float64
+	MemoryGBHour float64
+	NetworkOutGB float64
+}
+
+// Charges returns the price of the record's usage in cents
+func (u *UsageRecord) Charges(prices UsagePrices) int64 {
+	cents := u.CPUSeconds/3600*prices.CPUHour +
+		u.MemoryMBHours/1024*prices.MemoryGBHour +
+		float64(u.NetworkOutBytes)/(1<<30)*prices.NetworkOutGB
+	return int64(math.Round(cents))
+}
+
+// Spending is a user's metered charges in a usage period measured against their spending cap
+type Spending struct {
+	Period  time.Time
+	Charges int64 // in cents
+	Cap     int64 // in cents; 0 when the user has no cap
+	Lifted  bool  // an admin lifted the cap for the period
+}
+
+// NewSpending returns a user's spending for the period of a usage record
+func NewSpending(user User, record UsageRecord, prices UsagePrices) Spending {
+	spending := Spending{
+		Period:  record.Period,
+		Charges: record.Charges(prices),
+		Cap:     user.SpendingCap,
+	}
+	if user.SpendingCapLiftedFor != nil {
+		spending.Lifted = user.SpendingCapLiftedFor.Equal(record.Period)
+	}
+	return spending
+}
+
+// Reached checks if the charges reached the cap
+func (s Spending) Reached() bool {
+	return s.Cap > 0 && s.Charges >= s.Cap
+}
+
+// Blocked checks if actions adding metered usage are refused: the cap is reached and was not
+// lifted for the period
+func (s Spending) Blocked() bool {
+	return s.Reached() && !s.Lifted
+}
+
+// ToPublicResponse returns a public representation of the spending for API responses
+func (s Spending) ToPublicResponse() map[string]interface{} {
+	response := map[string]interface{}{
+		"period":       s.Period.Format("2006-01"),
+		"charges":      s.Charges,
+		"spending_cap": nil,
+		"remaining":    nil,
+		"reached":      s.Reached(),
+		"lifted":       s.Lifted,
+		"blocked":      s.Blocked(),
+	}
+	if s.Cap > 0 {
+		response["spending_cap"] = s.Cap
+		remaining := s.Cap - s.Charges
+		if remaining < 0 {
+			remaining = 0
+		}
+		response["remaining"] = remaining
+	}
+	return response
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UsageRollup holds a user's aggregated resource usage for a single day
+type UsageRollup struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_usage_rollup_user_day" json:"user_id"`
+	Day           time.Time `gorm:"type:date;uniqueIndex:idx_usage_rollup_user_day" json:"day"`
+	InstanceHours float64   `json:"instance_hours"`
+	CPUHours      float64   `json:"cpu_hours"`
+	EgressBytes   int64     `json:"egress_bytes"`
+	IngressBytes  int64     `json:"ingress_bytes"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the UsageRollup model
+func (UsageRollup) TableName() string {
+	return "usage_rollups"
+}
+
+// BeforeCreate hook is called before creating a new usage rollup
+func (u *UsageRollup) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+// ToPublicResponse returns a public representation of the usage rollup for API responses
+func (u *UsageRollup) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"day":            u.Day.Format("2006-01-02"),
+		"instance_hours": u.InstanceHours,
+		"cpu_hours":      u.CPUHours,
+		"egress_bytes":   u.EgressBytes,
+		"egress":         formatBytes(u.EgressBytes),
+		"ingress_bytes":  u.IngressBytes,
+		"ingress":        formatBytes(u.IngressBytes),
+	}
+}
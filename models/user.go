@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,8 +25,21 @@ const (
 	StatusActive    SubscriptionStatus = "active"
 	StatusCanceled  SubscriptionStatus = "canceled"
 	StatusExpired   SubscriptionStatus = "expired"
+	StatusPastDue   SubscriptionStatus = "past_due" // A renewal payment failed and is being retried
 )
 
+// UserRole defines the user's platform role
+type UserRole string
+
+const (
+	RoleUser     UserRole = "user"
+	RoleAdmin    UserRole = "admin"
+	RoleReseller UserRole = "reseller"
+)
+
+// PendingClerkIDPrefix marks accounts created by a reseller that have not signed in yet
+const PendingClerkIDPrefix = "pending_"
+
 // User represents a user in the system
 type User struct {
 	ID            uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -36,10 +50,19 @@ type User struct {
 	FirstName     string          `json:"first_name"`
 	LastName      string          `json:"last_name"`
 	Plan          SubscriptionPlan `gorm:"type:varchar(20);default:'free'" json:"plan"`
-	PayPalCustomerID string       `json:"paypal_customer_id,omitempty"`
-	SubscriptionID   string       `json:"subscription_id,omitempty"`
+	Role          UserRole        `gorm:"type:varchar(20);default:'user'" json:"role"`
+	ResellerID    *uuid.UUID      `gorm:"type:uuid;index" json:"reseller_id,omitempty"` // Set on sub-accounts
+	InstanceQuota int             `json:"instance_quota"` // Resellers: pool size; sub-accounts: allocation from the pool
+	Language      string          `gorm:"type:varchar(10)" json:"language,omitempty"` // Preferred locale of messages; Accept-Language is used when empty
+	PayPalCustomerID string       `json:"-"` // Provider identifiers are only returned by the subscription endpoints
+	SubscriptionID   string       `json:"-"`
+	SubscriptionProvider string   `gorm:"type:varchar(20)" json:"subscription_provider,omitempty"` // Payment provider managing the subscription
 	SubscriptionStatus SubscriptionStatus `gorm:"type:varchar(50)" json:"subscription_status,omitempty"`
 	CurrentPeriodEnd time.Time    `json:"current_period_end,omitempty"`
+	SpendingCap   int64           `gorm:"default:0" json:"spending_cap"` // Monthly cap on metered charges in cents; 0 for none
+	SpendingCapLiftedFor *time.Time `gorm:"type:date" json:"spending_cap_lifted_for,omitempty"` // Usage period an admin lifted the cap for
+	SpendingCapNotifiedFor *time.Time `gorm:"type:date" json:"-"` // Usage period the user was told the cap was reached in
+	TrialRemindedAt *time.Time    `json:"-"` // When the user was last reminded that their trial ends
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt  `gorm:"index" json:"-"`
@@ -62,30 +85,57 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// IsAdmin checks if the user is a platform operator
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsReseller checks if the user can manage sub-accounts
+func (u *User) IsReseller() bool {
+	return u.Role == RoleReseller
+}
+
+// IsSubAccount checks if the user is managed and billed by a reseller
+func (u *User) IsSubAccount() bool {
+	return u.ResellerID != nil
+}
+
+// IsPendingSignIn checks if the account was created by a reseller and not yet claimed through Clerk
+func (u *User) IsPendingSignIn() bool {
+	return strings.HasPrefix(u.ClerkUserID, PendingClerkIDPrefix)
+}
+
+// BillingUserID returns the user that is billed for this user's usage
+func (u *User) BillingUserID() uuid.UUID {
+	if u.ResellerID != nil {
+		return *u.ResellerID
+	}
+	return u.ID
+}
+
+// AfterSave hook keeps sub-accounts on their reseller's subscription, since
+// billing is consolidated to the reseller
+func (u *User) AfterSave(tx *gorm.DB) error {
+	if !u.IsReseller() {
+		return nil
+	}
+	return tx.Model(&User{}).Where("reseller_id = ?", u.ID).Updates(map[string]interface{}{
+		"plan":                u.Plan,
+		"subscription_status": u.SubscriptionStatus,
+		"current_period_end":  u.CurrentPeriodEnd,
+	}).Error
+}
+
 // GetPlanResourceLimits returns the resource limits for the user's plan
 func (u *User) GetPlanResourceLimits() map[string]interface{} {
-	limits := make(map[string]interface{})
-	
-	switch u.Plan {
-	case PlanFree, PlanStarter:
-		limits["max_instances"] = 1
-		limits["cpu_limit"] = 0.5
-		limits["memory_limit"] = 512 // MB
-		limits["storage_limit"] = 1  // GB
-	case PlanPro:
-		limits["max_instances"] = 10
-		limits["cpu_limit"] = 1.0
-		limits["memory_limit"] = 1024 // MB
-		limits["storage_limit"] = 20  // GB
-	default:
-		// Default to free plan limits
-		limits["max_instances"] = 1
-		limits["cpu_limit"] = 0.5
-		limits["memory_limit"] = 512 // MB
-		limits["storage_limit"] = 1  // GB
+	// Plans missing from the catalog get the free plan's limits
+	plan := planOrFree(u.Plan)
+	return map[string]interface{}{
+		"max_instances": plan.MaxInstances,
+		"cpu_limit":     plan.CPULimit,
+		"memory_limit":  plan.MemoryLimit,  // MB
+		"storage_limit": plan.StorageLimit, // GB
 	}
-	
-	return limits
 }
 
 // GetInstancesLimit returns the maximum number of instances a user can create based on their plan
@@ -95,50 +145,27 @@ func (u *User) GetInstancesLimit() int {
 		return 100 // Allow many instances for testing
 	}
 
-	switch u.Plan {
-	case PlanFree, PlanStarter:
-		return 1
-	case PlanPro:
-		return 10
-	default:
-		return 1 // Default to free plan
+	// Sub-accounts get the allocation their reseller gave them
+	if u.IsSubAccount() {
+		return u.InstanceQuota
 	}
+
+	return planOrFree(u.Plan).MaxInstances
 }
 
 // GetCPULimit returns the CPU limit per instance based on subscription plan
 func (u *User) GetCPULimit() float64 {
-	switch u.Plan {
-	case PlanFree, PlanStarter:
-		return 0.5
-	case PlanPro:
-		return 1.0
-	default:
-		return 0.5 // Default to free plan
-	}
+	return planOrFree(u.Plan).CPULimit
 }
 
 // GetMemoryLimit returns the memory limit per instance in MB based on subscription plan
 func (u *User) GetMemoryLimit() int {
-	switch u.Plan {
-	case PlanFree, PlanStarter:
-		return 512
-	case PlanPro:
-		return 1024
-	default:
-		return 512 // Default to free plan
-	}
+	return planOrFree(u.Plan).MemoryLimit
 }
 
 // GetStorageLimit returns the storage limit per instance in GB based on subscription plan
 func (u *User) GetStorageLimit() int {
-	switch u.Plan {
-	case PlanFree, PlanStarter:
-		return 1
-	case PlanPro:
-		return 20
-	default:
-		return 1 // Default to free plan
-	}
+	return planOrFree(u.Plan).StorageLimit
 }
 
 // IsTrialActive checks if the user's trial is active
@@ -162,16 +189,73 @@ func (u *User) TrialDaysLeft() int {
 	return daysLeft
 }
 
-// StartTrial starts the user's trial period
+// BillingLapsed checks if the user's trial or paid period has run out without payment,
+// allowing grace after the end of the period before instances are suspended. Trials without a
+// subscription never lapse, since they move to the free plan when they end.
+func (u *User) BillingLapsed(now time.Time, grace time.Duration) bool {
+	if u.IsAdmin() {
+		return false
+	}
+	switch u.SubscriptionStatus {
+	case StatusExpired:
+		return true
+	case StatusTrial:
+		return u.SubscriptionID != "" && !u.CurrentPeriodEnd.IsZero() && now.After(u.CurrentPeriodEnd.Add(grace))
+	case StatusPastDue, StatusCanceled:
+		return !u.CurrentPeriodEnd.IsZero() && now.After(u.CurrentPeriodEnd.Add(grace))
+	default:
+		return false
+	}
+}
+
+// StartTrial starts the trial of the user's plan; plans without a trial are left alone
 func (u *User) StartTrial() {
-	now := time.Now()
-	trialDays := 7 // Both plans have 7-day trial period
-	endDate := now.AddDate(0, 0, trialDays)
-	
+	policy, ok := PlanTrial(u.Plan)
+	if !ok {
+		return
+	}
+	endDate := time.Now().AddDate(0, 0, policy.Days)
+
 	u.CurrentPeriodEnd = endDate
 	u.SubscriptionStatus = StatusTrial
 }
 
+// StartSignupTrial starts a new user on the trial of the sign-up trial plan, if one is configured
+func (u *User) StartSignupTrial() {
+	if _, ok := PlanTrial(SignupTrialPlan); !ok {
+		return
+	}
+	u.Plan = SignupTrialPlan
+	u.StartTrial()
+}
+
+// TrialState describes a user's trial for API responses
+type TrialState struct {
+	Active       bool             `json:"active"`
+	Plan         SubscriptionPlan `json:"plan,omitempty"`
+	EndsAt       *time.Time       `json:"ends_at,omitempty"`
+	DaysLeft     int              `json:"days_left"`
+	DowngradesTo SubscriptionPlan `json:"downgrades_to,omitempty"` // Plan the user moves to when a trial without a subscription ends
+}
+
+// TrialState returns the state of the user's trial
+func (u *User) TrialState() TrialState {
+	if u.SubscriptionStatus != StatusTrial {
+		return TrialState{}
+	}
+	endsAt := u.CurrentPeriodEnd
+	state := TrialState{
+		Active:   u.IsTrialActive(),
+		Plan:     u.Plan,
+		EndsAt:   &endsAt,
+		DaysLeft: u.TrialDaysLeft(),
+	}
+	if u.SubscriptionID == "" {
+		state.DowngradesTo = PlanFree
+	}
+	return state
+}
+
 // ToPublicResponse returns a public representation of the user for API responses
 func (u *User) ToPublicResponse() map[string]interface{} {
 	return map[string]interface{}{
@@ -179,9 +263,12 @@ func (u *User) ToPublicResponse() map[string]interface{} {
 		"email":              u.Email,
 		"first_name":         u.FirstName,
 		"last_name":          u.LastName,
+		"language":           u.Language,
 		"plan":               u.Plan,
 		"subscription_status": u.SubscriptionStatus,
 		"current_period_end": u.CurrentPeriodEnd,
 		"instances_limit":    u.GetInstancesLimit(),
+		"features":           PlanFeatures(u.Plan),
+		"spending_cap":       u.SpendingCap,
 	}
 } 
\ No newline at end of file
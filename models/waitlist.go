@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WaitlistStatus defines the state of a waitlisted instance request
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting     WaitlistStatus = "waiting"     // In line for capacity
+	WaitlistReserved    WaitlistStatus = "reserved"    // Capacity is held until reserved_until for the user to claim
+	WaitlistProvisioned WaitlistStatus = "provisioned" // The instance was created
+	WaitlistCancelled   WaitlistStatus = "cancelled"
+	WaitlistExpired     WaitlistStatus = "expired" // The reservation was not claimed in time
+	WaitlistFailed      WaitlistStatus = "failed"  // The request could no longer be fulfilled, see error
+)
+
+// WaitlistEntry is an instance creation request that found the host at capacity and waits
+// in line for capacity to free up
+type WaitlistEntry struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
+	ProjectID      *uuid.UUID     `gorm:"type:uuid" json:"project_id,omitempty"`
+	OrganizationID *uuid.UUID     `gorm:"type:uuid" json:"organization_id,omitempty"`
+	Name           string         `gorm:"size:255" json:"name"`
+	Description    string         `gorm:"size:1000" json:"description"`
+	ServiceType    ServiceType    `gorm:"size:50;not null;default:'n8n'" json:"service_type"`
+	ImageTag       string         `gorm:"size:100" json:"image_tag,omitempty"`
+	CPULimit       float64        `json:"cpu_limit"`
+	MemoryLimit    int            `json:"memory_limit"`
+	StorageLimit   int            `json:"storage_limit"`
+	Status         WaitlistStatus `gorm:"type:varchar(20);index:idx_waitlist_status_created_at" json:"status"`
+	ReservedUntil  *time.Time     `json:"reserved_until,omitempty"`
+	InstanceID     *uuid.UUID     `gorm:"type:uuid" json:"instance_id,omitempty"`
+	Error          string         `gorm:"size:1000" json:"error,omitempty"`
+	CreatedAt      time.Time      `gorm:"index:idx_waitlist_status_created_at" json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// TableName sets the table name for the WaitlistEntry model
+func (WaitlistEntry) TableName() string {
+	return "waitlist_entries"
+}
+
+// BeforeCreate hook is called before creating a new waitlist entry
+func (e *WaitlistEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsActive checks if the entry is still waiting for, or holding, capacity
+func (e *WaitlistEntry) IsActive() bool {
+	return e.Status == WaitlistWaiting || e.Status == WaitlistReserved
+}
+
+// InstanceRequest returns the instance creation request the entry was made for
+func (e *WaitlistEntry) InstanceRequest() Instance {
+	return Instance{
+		ProjectID:      e.ProjectID,
+		OrganizationID: e.OrganizationID,
+		Name:           e.Name,
+		Description:    e.Description,
+		ServiceType:    e.ServiceType,
+		ImageTag:       e.ImageTag,
+		CPULimit:       e.CPULimit,
+		MemoryLimit:    e.MemoryLimit,
+		StorageLimit:   e.StorageLimit,
+	}
+}
+
+// ToPublicResponse returns a public representation of the entry for API responses; position
+// is the entry's place in line, or 0 if it is no longer waiting
+func (e *WaitlistEntry) ToPublicResponse(position int64) map[string]interface{} {
+	response := map[string]interface{}{
+		"id":              e.ID,
+		"project_id":      e.ProjectID,
+		"organization_id": e.OrganizationID,
+		"name":            e.Name,
+		"description":     e.Description,
+		"service_type":    e.ServiceType,
+		"image_tag":       e.ImageTag,
+		"cpu_limit":       e.CPULimit,
+		"memory_limit":    e.MemoryLimit,
+		"storage_limit":   e.StorageLimit,
+		"status":          e.Status,
+		"reserved_until":  e.ReservedUntil,
+		"instance_id":     e.InstanceID,
+		"error":           e.Error,
+		"created_at":      e.CreatedAt,
+	}
+	if position > 0 {
+		response["position"] = position
+	}
+	return response
+}
@@ -0,0 +1,358 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookSecretPrefix marks the secret that signs the events sent to a webhook endpoint
+const WebhookSecretPrefix = "whsec_"
+
+// WebhookSchemaVersion is the version of the event payload. Fields are only ever added to a
+// version; renaming or removing one requires a new version.
+const WebhookSchemaVersion = 1
+
+// WebhookDeliveryAttempts is how often the delivery of an event is attempted before it is dropped
+const WebhookDeliveryAttempts = 10
+
+// WebhookEventType identifies what happened in an event sent to webhook endpoints
+type WebhookEventType string
+
+// WebhookInstanceCreated is sent when an instance is created; every later status change of
+// the instance is sent as instance.<status>
+const WebhookInstanceCreated WebhookEventType = "instance.created"
+
+// Events of backups and usage, sent besides the instance status events
+const (
+	WebhookBackupCompleted        WebhookEventType = "backup.completed"
+	WebhookBackupFailed           WebhookEventType = "backup.failed"
+	WebhookUsageThresholdExceeded WebhookEventType = "usage.threshold_exceeded"
+)
+
+// Usage thresholds a usage.threshold_exceeded event can report
+const (
+	UsageThresholdStorage     = "storage"      // an instance's volumes reached the storage warning threshold
+	UsageThresholdSpendingCap = "spending_cap" // the month's metered charges reached the user's spending cap
+)
+
+// WebhookEventTypes lists every event type endpoints can subscribe to
+var WebhookEventTypes = []WebhookEventType{
+	WebhookInstanceCreated,
+	InstanceStatusEvent(StatusPending),
+	InstanceStatusEvent(StatusRunning),
+	InstanceStatusEvent(StatusStopped),
+	InstanceStatusEvent(StatusError),
+	InstanceStatusEvent(StatusSuspended),
+	InstanceStatusEvent(StatusUpgrading),
+	InstanceStatusEvent(StatusSleeping),
+	InstanceStatusEvent(StatusTrashed),
+	InstanceStatusEvent(StatusDeleting),
+	InstanceStatusEvent(StatusDeleted),
+	InstanceStatusEvent(InstanceStatusExpired),
+	WebhookBackupCompleted,
+	WebhookBackupFailed,
+	WebhookUsageThresholdExceeded,
+}
+
+// InstanceStatusEvent returns the event type sent when an instance changes to a status
+func InstanceStatusEvent(status InstanceStatus) WebhookEventType {
+	return WebhookEventType("instance." + string(status))
+}
+
+// IsWebhookEventType checks if endpoints can subscribe to an event type
+func IsWebhookEventType(eventType WebhookEventType) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint is a URL of the user's, e.g. a Zapier or n8n webhook trigger, that is sent
+// signed events when their instances change state, back up or cross a usage threshold
+type WebhookEndpoint struct {
+	ID                 uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID             uuid.UUID       `gorm:"type:uuid;index" json:"user_id"`
+	InstanceID         *uuid.UUID      `gorm:"type:uuid;index" json:"instance_id,omitempty"` // Only sent events of this instance; nil for all events of the user
+	URL                string          `gorm:"size:2048" json:"url"`
+	Description        string          `gorm:"size:255" json:"description"`
+	Events             string          `gorm:"size:1000" json:"events"` // Comma-separated event types; empty subscribes to all
+	Secret             EncryptedString `gorm:"size:255" json:"-"`
+	Active             bool            `gorm:"default:true" json:"active"`
+	LastDeliveryAt     *time.Time      `json:"last_delivery_at,omitempty"`
+	LastDeliveryStatus int             `json:"last_delivery_status,omitempty"` // HTTP status of the last delivery; 0 if it got no response
+	LastDeliveryError  string          `gorm:"size:1000" json:"last_delivery_error,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// TableName sets the table name for the WebhookEndpoint model
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// BeforeCreate hook is called before creating a new webhook endpoint
+func (e *WebhookEndpoint) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// GenerateSecret sets a new random signing secret for the endpoint and returns it, so it can
+// be shown once
+func (e *WebhookEndpoint) GenerateSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	secret := WebhookSecretPrefix + hex.EncodeToString(raw)
+	e.Secret = EncryptedString(secret)
+	return secret, nil
+}
+
+// EventTypes returns the event types the endpoint subscribes to, or nil if it subscribes to all
+func (e *WebhookEndpoint) EventTypes() []WebhookEventType {
+	if e.Events == "" {
+		return nil
+	}
+	parts := strings.Split(e.Events, ",")
+	types := make([]WebhookEventType, len(parts))
+	for i, part := range parts {
+		types[i] = WebhookEventType(part)
+	}
+	return types
+}
+
+// SetEventTypes sets the event types the endpoint subscribes to; none subscribes to all
+func (e *WebhookEndpoint) SetEventTypes(types []WebhookEventType) {
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = string(t)
+	}
+	e.Events = strings.Join(parts, ",")
+}
+
+// Subscribes checks if the endpoint is sent events of a type
+func (e *WebhookEndpoint) Subscribes(eventType WebhookEventType) bool {
+	types := e.EventTypes()
+	if types == nil {
+		return true
+	}
+	for _, t := range types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the hex HMAC-SHA256 signature of an event body sent to the endpoint
+func (e *WebhookEndpoint) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(e.Secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ToPublicResponse returns a public representation of the endpoint for API responses
+func (e *WebhookEndpoint) ToPublicResponse() map[string]interface{} {
+	events := e.EventTypes()
+	if events == nil {
+		events = []WebhookEventType{}
+	}
+	return map[string]interface{}{
+		"id":                   e.ID,
+		"instance_id":          e.InstanceID,
+		"url":                  e.URL,
+		"description":          e.Description,
+		"events":               events,
+		"active":               e.Active,
+		"last_delivery_at":     e.LastDeliveryAt,
+		"last_delivery_status": e.LastDeliveryStatus,
+		"last_delivery_error":  e.LastDeliveryError,
+		"created_at":           e.CreatedAt,
+		"updated_at":           e.UpdatedAt,
+	}
+}
+
+// WebhookEvent is the payload sent to webhook endpoints. It is flat, with no nested objects
+// or nulls, so automation platforms can map its fields directly.
+type WebhookEvent struct {
+	ID                uuid.UUID        `json:"id"`
+	Type              WebhookEventType `json:"type"`
+	SchemaVersion     int              `json:"schema_version"`
+	OccurredAt        time.Time        `json:"occurred_at"`
+	Test              bool             `json:"test"`
+	UserID            uuid.UUID        `json:"user_id"`
+	InstanceID        uuid.UUID        `json:"instance_id"`
+	InstanceName      string           `json:"instance_name"`
+	InstanceURL       string           `json:"instance_url"`
+	InstanceStatus    InstanceStatus   `json:"instance_status"`
+	PreviousStatus    InstanceStatus   `json:"previous_status"`
+	ProjectID         string           `json:"project_id"`
+	ImageTag          string           `json:"image_tag"`
+	CPULimit          float64          `json:"cpu_limit"`
+	MemoryLimit       int              `json:"memory_limit"`
+	StorageLimit      int              `json:"storage_limit"`
+	SuspendedReason   string           `json:"suspended_reason"`
+	InstanceCreatedAt time.Time        `json:"instance_created_at"`
+	BackupID          string           `json:"backup_id"`         // backup.* events
+	BackupTrigger     string           `json:"backup_trigger"`    // "manual" or "scheduled"
+	BackupSizeBytes   int64            `json:"backup_size_bytes"` // size of the archive of a completed backup
+	BackupError       string           `json:"backup_error"`
+	Threshold         string           `json:"threshold"`       // usage.threshold_exceeded: "storage" or "spending_cap"
+	ThresholdLimit    int64            `json:"threshold_limit"` // bytes of storage, or cents of spending
+	ThresholdUsage    int64            `json:"threshold_usage"`
+}
+
+// NewInstanceWebhookEvent describes an event of an instance as it is now. The instance URL is
+// filled in on delivery, since it depends on the configured domain.
+func NewInstanceWebhookEvent(eventType WebhookEventType, instance *Instance, previous InstanceStatus) *WebhookEvent {
+	event := &WebhookEvent{
+		ID:                uuid.New(),
+		Type:              eventType,
+		SchemaVersion:     WebhookSchemaVersion,
+		OccurredAt:        time.Now().UTC(),
+		UserID:            instance.UserID,
+		InstanceID:        instance.ID,
+		InstanceName:      instance.Name,
+		InstanceStatus:    instance.Status,
+		PreviousStatus:    previous,
+		ImageTag:          instance.ImageTag,
+		CPULimit:          instance.CPULimit,
+		MemoryLimit:       instance.MemoryLimit,
+		StorageLimit:      instance.StorageLimit,
+		SuspendedReason:   instance.SuspendedReason,
+		InstanceCreatedAt: instance.CreatedAt,
+	}
+	if instance.ProjectID != nil {
+		event.ProjectID = instance.ProjectID.String()
+	}
+	return event
+}
+
+// NewBackupWebhookEvent describes the outcome of a finished backup of an instance
+func NewBackupWebhookEvent(instance *Instance, backup *Backup) *WebhookEvent {
+	eventType := WebhookBackupCompleted
+	if backup.Status == BackupFailed {
+		eventType = WebhookBackupFailed
+	}
+	event := NewInstanceWebhookEvent(eventType, instance, "")
+	event.BackupID = backup.ID.String()
+	event.BackupTrigger = string(backup.Trigger)
+	event.BackupSizeBytes = backup.SizeBytes
+	event.BackupError = backup.Error
+	return event
+}
+
+// NewUsageWebhookEvent describes a usage threshold a user crossed, for one of their instances
+// or, if instance is nil, for their whole account
+func NewUsageWebhookEvent(userID uuid.UUID, instance *Instance, threshold string, limit, usage int64) *WebhookEvent {
+	event := &WebhookEvent{
+		ID:            uuid.New(),
+		Type:          WebhookUsageThresholdExceeded,
+		SchemaVersion: WebhookSchemaVersion,
+		OccurredAt:    time.Now().UTC(),
+		UserID:        userID,
+	}
+	if instance != nil {
+		event = NewInstanceWebhookEvent(WebhookUsageThresholdExceeded, instance, "")
+	}
+	event.Threshold = threshold
+	event.ThresholdLimit = limit
+	event.ThresholdUsage = usage
+	return event
+}
+
+// WebhookDeliveryPayload is the payload of a job delivering an event to an endpoint
+type WebhookDeliveryPayload struct {
+	EndpointID        uuid.UUID    `json:"endpoint_id"`
+	Event             WebhookEvent `json:"event"`
+	InstanceSubdomain string       `json:"instance_subdomain"`
+}
+
+// NewWebhookDeliveryJob creates a queued job delivering an event to an endpoint. The
+// subdomain of the event's instance, if it has one, completes its URL on delivery.
+func NewWebhookDeliveryJob(endpoint *WebhookEndpoint, event *WebhookEvent, instanceSubdomain string) (*Job, error) {
+	data, err := json.Marshal(WebhookDeliveryPayload{
+		EndpointID:        endpoint.ID,
+		Event:             *event,
+		InstanceSubdomain: instanceSubdomain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{
+		Type:        JobDeliverWebhook,
+		UserID:      &endpoint.UserID,
+		Payload:     string(data),
+		Status:      JobQueued,
+		RunAt:       time.Now(),
+		MaxAttempts: WebhookDeliveryAttempts,
+	}
+	if event.InstanceID != uuid.Nil {
+		instanceID := event.InstanceID
+		job.InstanceID = &instanceID
+	}
+	return job, nil
+}
+
+// WebhookDelivery records an attempt at delivering an event to an endpoint, for the endpoint's
+// deliveries log
+type WebhookDelivery struct {
+	ID             uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EndpointID     uuid.UUID        `gorm:"type:uuid;index:idx_webhook_deliveries_endpoint_created" json:"endpoint_id"`
+	EventID        uuid.UUID        `gorm:"type:uuid" json:"event_id"`
+	EventType      WebhookEventType `gorm:"size:100" json:"event_type"`
+	Test           bool             `json:"test"`
+	Attempt        int              `json:"attempt"`         // attempt of the queued delivery; 0 for test deliveries
+	ResponseStatus int              `json:"response_status"` // 0 if the endpoint gave no response
+	Error          string           `gorm:"size:1000" json:"error,omitempty"`
+	DurationMS     int64            `json:"duration_ms"`
+	CreatedAt      time.Time        `gorm:"index:idx_webhook_deliveries_endpoint_created;index" json:"created_at"`
+}
+
+// TableName sets the table name for the WebhookDelivery model
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// BeforeCreate hook is called before creating a new webhook delivery
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// Succeeded checks if the endpoint accepted the delivery
+func (d *WebhookDelivery) Succeeded() bool {
+	return d.Error == "" && d.ResponseStatus >= 200 && d.ResponseStatus < 300
+}
+
+// ToPublicResponse returns a public representation of the delivery for API responses
+func (d *WebhookDelivery) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":              d.ID,
+		"endpoint_id":     d.EndpointID,
+		"event_id":        d.EventID,
+		"event_type":      d.EventType,
+		"test":            d.Test,
+		"attempt":         d.Attempt,
+		"succeeded":       d.Succeeded(),
+		"response_status": d.ResponseStatus,
+		"error":           d.Error,
+		"duration_ms":     d.DurationMS,
+		"created_at":      d.CreatedAt,
+	}
+}
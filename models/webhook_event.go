@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookSourceClerk is the source of webhook events sent by Clerk
+const WebhookSourceClerk = "clerk"
+
+// WebhookEventStatus is the processing state of a received webhook event
+type WebhookEventStatus string
+
+const (
+	WebhookEventProcessing WebhookEventStatus = "processing" // being applied while the delivery is answered
+	WebhookEventQueued     WebhookEventStatus = "queued"     // waiting to be applied by the job queue
+	WebhookEventProcessed  WebhookEventStatus = "processed"
+	WebhookEventFailed     WebhookEventStatus = "failed"
+)
+
+// ReceivedWebhookEvent is a webhook delivery received from an external service. Redeliveries
+// of an event share its delivery ID, so they are recognised and not applied twice.
+type ReceivedWebhookEvent struct {
+	ID          uuid.UUID          `gorm:"type:uuid;primary_key" json:"id"`
+	Source      string             `gorm:"size:20;not null;uniqueIndex:idx_webhook_events_delivery,where:delivery_id <> ''" json:"source"`
+	DeliveryID  string             `gorm:"size:255;uniqueIndex:idx_webhook_events_delivery,where:delivery_id <> ''" json:"delivery_id"` // e.g. Clerk's svix-id header
+	Type        string             `gorm:"size:100;index" json:"type"`
+	Status      WebhookEventStatus `gorm:"size:20;index" json:"status"`
+	Payload     string             `gorm:"type:jsonb" json:"-"`
+	Attempts    int                `json:"attempts"`
+	Error       string             `gorm:"size:1000" json:"error,omitempty"`
+	JobID       *uuid.UUID         `gorm:"type:uuid" json:"job_id,omitempty"` // job applying the event when it is not applied inline
+	ProcessedAt *time.Time         `json:"processed_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// TableName sets the table name for the ReceivedWebhookEvent model
+func (ReceivedWebhookEvent) TableName() string {
+	return "webhook_events"
+}
+
+// BeforeCreate hook is called before creating a new received webhook event
+func (e *ReceivedWebhookEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// WebhookProcessingPayload is the payload of a job applying a received webhook event
+type WebhookProcessingPayload struct {
+	EventID uuid.UUID `json:"event_id"`
+}
+
+// NewWebhookProcessingJob creates a queued job applying a received webhook event
+func NewWebhookProcessingJob(event *ReceivedWebhookEvent) (*Job, error) {
+	data, err := json.Marshal(WebhookProcessingPayload{EventID: event.ID})
+	if err != nil {
+		return nil, err
+	}
+	return &Job{
+		Type:    JobProcessWebhook,
+		Payload: string(data),
+		Status:  JobQueued,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WorkflowBundleVersion is the format version of workflow bundles this platform writes
+const WorkflowBundleVersion = 1
+
+// MaxBundleWorkflows is the most workflows a bundle may hold on import
+const MaxBundleWorkflows = 500
+
+// WorkflowBundle is a portable export of the workflows of an n8n instance. Workflows are kept
+// as n8n returns them; credentials are referenced by name and ID but never included.
+type WorkflowBundle struct {
+	Version    int               `json:"version"`
+	InstanceID string            `json:"instance_id,omitempty"` // instance the bundle was exported from
+	ImageTag   string            `json:"image_tag,omitempty"`   // n8n version it was exported from
+	ExportedAt time.Time         `json:"exported_at"`
+	Workflows  []json.RawMessage `json:"workflows"`
+}
+
+// ImportedWorkflow is the outcome of importing one workflow of a bundle
+type ImportedWorkflow struct {
+	Name  string `json:"name"`
+	ID    string `json:"id,omitempty"`    // ID n8n assigned to the imported workflow
+	Error string `json:"error,omitempty"` // why the workflow was not imported
+}
+
+// WorkflowImportResult is the outcome of importing a workflow bundle
+type WorkflowImportResult struct {
+	Imported  int                `json:"imported"`
+	Failed    int                `json:"failed"`
+	Workflows []ImportedWorkflow `json:"workflows"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkflowExecutionStatus represents the outcome of a workflow execution
+type WorkflowExecutionStatus string
+
+const (
+	ExecutionRunning   WorkflowExecutionStatus = "running"
+	ExecutionSucceeded WorkflowExecutionStatus = "succeeded"
+	ExecutionFailed    WorkflowExecutionStatus = "failed"
+)
+
+// WorkflowExecution is a run of a workflow on an instance, recorded from the workflow events
+// the instance sends to the n8n webhook
+type WorkflowExecution struct {
+	ID           uuid.UUID               `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	InstanceID   uuid.UUID               `gorm:"type:uuid;uniqueIndex:idx_workflow_executions_key;index:idx_workflow_executions_instance_created" json:"instance_id"`
+	WorkflowID   string                  `gorm:"size:100;uniqueIndex:idx_workflow_executions_key" json:"workflow_id"`
+	ExecutionID  string                  `gorm:"size:100;uniqueIndex:idx_workflow_executions_key" json:"execution_id"`
+	WorkflowName string                  `gorm:"size:255" json:"workflow_name"`
+	Mode         string                  `gorm:"size:50" json:"mode"` // how n8n started it, e.g. "webhook", "trigger" or "manual"
+	Status       WorkflowExecutionStatus `gorm:"size:20" json:"status"`
+	Error        string                  `gorm:"size:1000" json:"error,omitempty"`
+	StartedAt    *time.Time              `json:"started_at"`
+	FinishedAt   *time.Time              `json:"finished_at"`
+	DurationMS   *int64                  `json:"duration_ms"` // set once both the start and the end are known
+	CreatedAt    time.Time               `gorm:"index:idx_workflow_executions_instance_created;index" json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// TableName sets the table name for the WorkflowExecution model
+func (WorkflowExecution) TableName() string {
+	return "workflow_executions"
+}
+
+// BeforeCreate hook is called before creating a new workflow execution
+func (e *WorkflowExecution) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsFinished checks if the execution succeeded or failed
+func (e *WorkflowExecution) IsFinished() bool {
+	return e.Status == ExecutionSucceeded || e.Status == ExecutionFailed
+}
+
+// Merge applies what an event tells about the execution. Events can arrive out of order, so
+// a finished execution is not set back to running by a late started event.
+func (e *WorkflowExecution) Merge(event *WorkflowExecution) {
+	if event.WorkflowName != "" {
+		e.WorkflowName = event.WorkflowName
+	}
+	if event.Mode != "" {
+		e.Mode = event.Mode
+	}
+	if event.StartedAt != nil && (e.StartedAt == nil || event.StartedAt.Before(*e.StartedAt)) {
+		e.StartedAt = event.StartedAt
+	}
+	if event.IsFinished() {
+		e.Status = event.Status
+		e.Error = event.Error
+		e.FinishedAt = event.FinishedAt
+	} else if !e.IsFinished() {
+		e.Status = ExecutionRunning
+	}
+
+	if e.StartedAt != nil && e.FinishedAt != nil {
+		duration := e.FinishedAt.Sub(*e.StartedAt).Milliseconds()
+		if duration < 0 {
+			duration = 0
+		}
+		e.DurationMS = &duration
+	}
+}
+
+// ToPublicResponse returns a public representation of the execution for API responses
+func (e *WorkflowExecution) ToPublicResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"id":            e.ID,
+		"workflow_id":   e.WorkflowID,
+		"workflow_name": e.WorkflowName,
+		"execution_id":  e.ExecutionID,
+		"mode":          e.Mode,
+		"status":        e.Status,
+		"error":         e.Error,
+		"started_at":    e.StartedAt,
+		"finished_at":   e.FinishedAt,
+		"duration_ms":   e.DurationMS,
+	}
+}
+
+// WorkflowExecutionStats summarizes the executions of an instance, or of one of its workflows,
+// over a time window. Durations only count finished executions whose start is known.
+type WorkflowExecutionStats struct {
+	WorkflowID    string   `json:"workflow_id,omitempty"`
+	WorkflowName  string   `json:"workflow_name,omitempty"`
+	Total         int64    `json:"total"`
+	Succeeded     int64    `json:"succeeded"`
+	Failed        int64    `json:"failed"`
+	Running       int64    `json:"running"`
+	SuccessRate   *float64 `gorm:"-" json:"success_rate"` // percent of finished executions; null without any
+	AvgDurationMS *float64 `json:"avg_duration_ms"`
+	P95DurationMS *float64 `json:"p95_duration_ms"`
+	MaxDurationMS *int64   `json:"max_duration_ms"`
+}
+
+// SetSuccessRate computes the success rate from the counts
+func (s *WorkflowExecutionStats) SetSuccessRate() {
+	if finished := s.Succeeded + s.Failed; finished > 0 {
+		rate := float64(s.Succeeded) / float64(finished) * 100
+		s.SuccessRate = &rate
+	}
+}
@@ -0,0 +1,383 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/models"
+)
+
+// PayPalProvider takes payments through PayPal orders and manages PayPal billing subscriptions
+type PayPalProvider struct {
+	config  *config.Config
+	baseURL string
+	client  *http.Client
+}
+
+// payPalTokenResponse represents the response from PayPal OAuth token endpoint
+type payPalTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// payPalLinkResponse represents a PayPal API response that links to follow-up actions
+type payPalLinkResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Links  []struct {
+		Href   string `json:"href"`
+		Rel    string `json:"rel"`
+		Method string `json:"method"`
+	} `json:"links"`
+}
+
+// approveURL returns the link where the buyer approves the order or subscription change
+func (r payPalLinkResponse) approveURL() string {
+	for _, link := range r.Links {
+		if link.Rel == "approve" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// Name returns the provider name
+func (p *PayPalProvider) Name() string {
+	return "paypal"
+}
+
+// getAccessToken gets an access token from PayPal API
+func (p *PayPalProvider) getAccessToken(ctx context.Context) (string, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", p.config.PayPal.APIKey, p.config.PayPal.Secret)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/oauth2/token", strings.NewReader("grant_type=client_credentials"))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", auth))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get access token: %s", string(body))
+	}
+
+	var tokenResp payPalTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", err
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// do sends an authenticated request to the PayPal API and returns the response body,
+// failing unless PayPal answers with the expected status
+func (p *PayPalProvider) do(ctx context.Context, method, path string, payload interface{}, expectedStatus int, header http.Header) ([]byte, error) {
+	token, err := p.getAccessToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate with PayPal: %w", err)
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to communicate with PayPal: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != expectedStatus {
+		return nil, fmt.Errorf("PayPal error: %s", string(body))
+	}
+	return body, nil
+}
+
+// CreateCheckout creates a PayPal order for the first month of a plan
+func (p *PayPalProvider) CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	// The first month is charged at the catalog's price
+	amount := models.GetPlanPrice(req.Plan, models.BillingMonthly)
+	if amount <= 0 {
+		return nil, ErrPlanNotConfigured
+	}
+
+	order := map[string]interface{}{
+		"intent": "CAPTURE",
+		"purchase_units": []map[string]interface{}{
+			{
+				"amount": map[string]interface{}{
+					"currency_code": "USD",
+					"value":         fmt.Sprintf("%.2f", amount),
+				},
+				"description": fmt.Sprintf("LaunchStack %s Plan Subscription", req.Plan),
+			},
+		},
+		"application_context": map[string]interface{}{
+			"return_url": req.SuccessURL,
+			"cancel_url": req.CancelURL,
+		},
+	}
+
+	// Add user ID to PayPal request for webhook correlation
+	header := http.Header{}
+	header.Set("PayPal-Request-Id", req.UserID.String())
+
+	body, err := p.do(ctx, http.MethodPost, "/v2/checkout/orders", order, http.StatusCreated, header)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderResp payPalLinkResponse
+	if err := json.Unmarshal(body, &orderResp); err != nil {
+		return nil, fmt.Errorf("failed to parse PayPal response: %w", err)
+	}
+	checkoutURL := orderResp.approveURL()
+	if checkoutURL == "" {
+		return nil, fmt.Errorf("no checkout URL found in PayPal response")
+	}
+
+	return &Checkout{
+		ID:       orderResp.ID,
+		URL:      checkoutURL,
+		Amount:   int(amount * 100),
+		Currency: "usd",
+	}, nil
+}
+
+// CancelSubscription cancels a PayPal billing subscription
+func (p *PayPalProvider) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	_, err := p.do(ctx, http.MethodPost, fmt.Sprintf("/v1/billing/subscriptions/%s/cancel", subscriptionID),
+		map[string]string{"reason": "Customer requested cancellation"}, http.StatusNoContent, nil)
+	return err
+}
+
+// ChangeSubscription revises a PayPal subscription to the billing plan of another plan.
+// PayPal asks the buyer to approve revisions that increase the price.
+func (p *PayPalProvider) ChangeSubscription(ctx context.Context, subscriptionID string, plan models.SubscriptionPlan) (*SubscriptionChange, error) {
+	planID := p.config.PayPal.StarterPlanID
+	if plan == models.PlanPro {
+		planID = p.config.PayPal.ProPlanID
+	}
+	if planID == "" {
+		return nil, ErrPlanNotConfigured
+	}
+
+	revision := map[string]interface{}{
+		"plan_id": planID,
+		"application_context": map[string]interface{}{
+			"brand_name":  "LaunchStack",
+			"user_action": "SUBSCRIBE_NOW",
+			"return_url":  fmt.Sprintf("%s/billing?changed=true", p.config.Server.FrontendURL),
+			"cancel_url":  fmt.Sprintf("%s/billing", p.config.Server.FrontendURL),
+		},
+	}
+	body, err := p.do(ctx, http.MethodPost, fmt.Sprintf("/v1/billing/subscriptions/%s/revise", subscriptionID), revision, http.StatusOK, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisionResp payPalLinkResponse
+	json.Unmarshal(body, &revisionResp)
+	return &SubscriptionChange{ApprovalURL: revisionResp.approveURL()}, nil
+}
+
+// ExtendTrial is not supported for PayPal, whose billing plans fix the trial of a
+// subscription when it is created
+func (p *PayPalProvider) ExtendTrial(ctx context.Context, subscriptionID string, end time.Time) error {
+	return ErrTrialExtensionUnsupported
+}
+
+// GetSubscription retrieves a PayPal billing subscription. Its period ends at the next billing
+// time, which PayPal leaves out once the subscription is cancelled.
+func (p *PayPalProvider) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	subscription, _, err := p.getSubscription(ctx, subscriptionID)
+	return subscription, err
+}
+
+// getSubscription retrieves a PayPal billing subscription and the custom ID it was created
+// with, the ID of the user. An active subscription whose last renewal payments failed is
+// past due.
+func (p *PayPalProvider) getSubscription(ctx context.Context, subscriptionID string) (*Subscription, string, error) {
+	body, err := p.do(ctx, http.MethodGet, fmt.Sprintf("/v1/billing/subscriptions/%s", url.PathEscape(subscriptionID)), nil, http.StatusOK, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var subscription struct {
+		ID          string `json:"id"`
+		Status      string `json:"status"`
+		PlanID      string `json:"plan_id"`
+		CustomID    string `json:"custom_id"`
+		BillingInfo struct {
+			NextBillingTime     string `json:"next_billing_time"`
+			FailedPaymentsCount int    `json:"failed_payments_count"`
+		} `json:"billing_info"`
+	}
+	if err := json.Unmarshal(body, &subscription); err != nil {
+		return nil, "", fmt.Errorf("failed to parse PayPal subscription: %w", err)
+	}
+
+	result := &Subscription{ID: subscription.ID, Status: payPalSubscriptionStatus(subscription.Status)}
+	if result.Status == models.StatusActive && subscription.BillingInfo.FailedPaymentsCount > 0 {
+		result.Status = models.StatusPastDue
+	}
+	switch {
+	case subscription.PlanID == "":
+	case subscription.PlanID == p.config.PayPal.StarterPlanID:
+		result.Plan = models.PlanStarter
+	case subscription.PlanID == p.config.PayPal.ProPlanID:
+		result.Plan = models.PlanPro
+	}
+	if subscription.BillingInfo.NextBillingTime != "" {
+		if result.PeriodEnd, err = time.Parse(time.RFC3339, subscription.BillingInfo.NextBillingTime); err != nil {
+			return nil, "", fmt.Errorf("invalid PayPal next billing time: %w", err)
+		}
+	}
+	return result, subscription.CustomID, nil
+}
+
+// HandleWebhook parses a PayPal webhook event
+func (p *PayPalProvider) HandleWebhook(ctx context.Context, header http.Header, body []byte) ([]WebhookEvent, error) {
+	var event struct {
+		EventType string                 `json:"event_type"`
+		Resource  map[string]interface{} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid JSON payload: %w", err)
+	}
+	if event.EventType == "" {
+		return nil, fmt.Errorf("missing event type")
+	}
+
+	resourceID, _ := event.Resource["id"].(string)
+	status, _ := event.Resource["status"].(string)
+
+	switch event.EventType {
+	case "PAYMENT.CAPTURE.COMPLETED":
+		orderID, _ := event.Resource["parent_payment"].(string)
+		if resourceID == "" || status != "COMPLETED" {
+			return nil, fmt.Errorf("missing payment ID or status not completed")
+		}
+		return []WebhookEvent{{Type: EventPaymentSucceeded, CheckoutID: orderID, PaymentID: resourceID}}, nil
+
+	case "PAYMENT.CAPTURE.REFUNDED":
+		// The resource is the refund; its "up" link points at the refunded capture
+		captureID := ""
+		links, _ := event.Resource["links"].([]interface{})
+		for _, link := range links {
+			l, _ := link.(map[string]interface{})
+			if rel, _ := l["rel"].(string); rel == "up" {
+				href, _ := l["href"].(string)
+				captureID = href[strings.LastIndex(href, "/")+1:]
+			}
+		}
+		amount, _ := event.Resource["amount"].(map[string]interface{})
+		value, _ := amount["value"].(string)
+		dollars, err := strconv.ParseFloat(value, 64)
+		if resourceID == "" || captureID == "" || err != nil {
+			return nil, fmt.Errorf("missing refund details")
+		}
+		return []WebhookEvent{{
+			Type:      EventPaymentRefunded,
+			PaymentID: captureID,
+			RefundID:  resourceID,
+			Amount:    int(math.Round(dollars * 100)),
+		}}, nil
+
+	case "BILLING.SUBSCRIPTION.CREATED", "BILLING.SUBSCRIPTION.UPDATED", "BILLING.SUBSCRIPTION.PAYMENT.FAILED", "BILLING.SUBSCRIPTION.CANCELLED":
+		if resourceID == "" {
+			return nil, fmt.Errorf("missing subscription ID")
+		}
+		return p.subscriptionEvents(ctx, event.EventType, resourceID)
+	}
+
+	return nil, nil
+}
+
+// subscriptionEvents translates a PayPal subscription webhook. PayPal webhooks are not signed,
+// so the subscription is fetched from PayPal and only the state PayPal reports is applied; a
+// forged event can at most repeat it.
+func (p *PayPalProvider) subscriptionEvents(ctx context.Context, eventType, subscriptionID string) ([]WebhookEvent, error) {
+	subscription, customID, err := p.getSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch PayPal subscription %s: %w", subscriptionID, err)
+	}
+
+	switch {
+	case eventType == "BILLING.SUBSCRIPTION.CREATED":
+		userID, err := uuid.Parse(customID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user ID: %w", err)
+		}
+		periodEnd := subscription.PeriodEnd
+		if periodEnd.IsZero() {
+			periodEnd = time.Now().AddDate(0, 1, 0) // Assuming monthly subscription
+		}
+		return []WebhookEvent{{
+			Type:           EventSubscriptionCreated,
+			SubscriptionID: subscription.ID,
+			UserID:         userID,
+			Status:         subscription.Status,
+			PeriodEnd:      periodEnd,
+		}}, nil
+
+	case subscription.Status == models.StatusCanceled:
+		return []WebhookEvent{{Type: EventSubscriptionCancelled, SubscriptionID: subscription.ID}}, nil
+
+	default:
+		return []WebhookEvent{{Type: EventSubscriptionUpdated, SubscriptionID: subscription.ID, Status: subscription.Status}}, nil
+	}
+}
+
+// payPalSubscriptionStatus maps a PayPal subscription status to the user's subscription status
+func payPalSubscriptionStatus(status string) models.SubscriptionStatus {
+	switch status {
+	case "ACTIVE":
+		return models.StatusActive
+	case "SUSPENDED":
+		// PayPal suspends subscriptions once renewal payments keep failing
+		return models.StatusPastDue
+	case "CANCELLED":
+		return models.StatusCanceled
+	case "EXPIRED":
+		return models.StatusExpired
+	default:
+		return models.SubscriptionStatus(strings.ToLower(status))
+	}
+}
@@ -0,0 +1,124 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/models"
+)
+
+var (
+	// ErrPlanNotConfigured is returned when the provider has no product set up for a plan
+	ErrPlanNotConfigured = errors.New("plan is not configured for this payment provider")
+	// ErrInvalidSignature is returned when a webhook cannot be verified as coming from the provider
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrTrialExtensionUnsupported is returned by providers whose subscriptions cannot have their trial extended
+	ErrTrialExtensionUnsupported = errors.New("payment provider cannot extend trials")
+)
+
+// Provider takes payments and manages subscriptions with an external payment processor
+type Provider interface {
+	// Name returns the provider name, which is also the path of its webhook endpoint
+	Name() string
+	// CreateCheckout starts a checkout for a plan and returns where to send the user to pay
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error)
+	// CancelSubscription cancels a subscription at the end of the current billing period
+	CancelSubscription(ctx context.Context, subscriptionID string) error
+	// ChangeSubscription moves a subscription to another plan
+	ChangeSubscription(ctx context.Context, subscriptionID string, plan models.SubscriptionPlan) (*SubscriptionChange, error)
+	// ExtendTrial moves the end of a trialing subscription's trial to end
+	ExtendTrial(ctx context.Context, subscriptionID string, end time.Time) error
+	// GetSubscription retrieves the current state of a subscription, to correct what missed
+	// webhooks left out of date
+	GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error)
+	// HandleWebhook verifies and parses a webhook delivery into the events it describes;
+	// deliveries that need no action yield no events
+	HandleWebhook(ctx context.Context, header http.Header, body []byte) ([]WebhookEvent, error)
+}
+
+// NewProvider creates the provider for the configured payment processor
+func NewProvider(cfg *config.Config) (Provider, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch cfg.Payments.Provider {
+	case "paypal":
+		baseURL := "https://api-m.sandbox.paypal.com"
+		if cfg.PayPal.Mode == "production" {
+			baseURL = "https://api-m.paypal.com"
+		}
+		return &PayPalProvider{config: cfg, baseURL: baseURL, client: client}, nil
+	case "stripe":
+		if cfg.Stripe.SecretKey == "" {
+			return nil, fmt.Errorf("STRIPE_SECRET_KEY is required for the stripe payment provider")
+		}
+		return &StripeProvider{config: cfg, baseURL: "https://api.stripe.com", client: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported payment provider %q", cfg.Payments.Provider)
+	}
+}
+
+// CheckoutRequest describes the plan a user wants to pay for
+type CheckoutRequest struct {
+	UserID     uuid.UUID
+	Email      string
+	Plan       models.SubscriptionPlan
+	TrialDays  int // trial before the first charge; providers without trials ignore it
+	SuccessURL string
+	CancelURL  string
+}
+
+// Checkout is a checkout started with the provider
+type Checkout struct {
+	ID       string // provider reference that webhooks use to identify the checkout
+	URL      string // page where the user completes the payment
+	Amount   int    // in cents
+	Currency string
+}
+
+// SubscriptionChange is the result of moving a subscription to another plan
+type SubscriptionChange struct {
+	// ApprovalURL is set when the user has to confirm the change with the provider
+	ApprovalURL string
+	// ProratedByProvider is true when the provider charges or credits the difference for
+	// the rest of the billing period itself
+	ProratedByProvider bool
+}
+
+// Subscription is the state of a subscription with the provider
+type Subscription struct {
+	ID        string
+	Status    models.SubscriptionStatus
+	Plan      models.SubscriptionPlan // empty when the provider's plan is not one of ours
+	PeriodEnd time.Time               // zero when the provider reports no upcoming renewal
+}
+
+// WebhookEventType identifies what a webhook event reports
+type WebhookEventType string
+
+const (
+	EventPaymentSucceeded      WebhookEventType = "payment_succeeded"
+	EventPaymentRefunded       WebhookEventType = "payment_refunded"
+	EventSubscriptionCreated   WebhookEventType = "subscription_created"
+	EventSubscriptionUpdated   WebhookEventType = "subscription_updated"
+	EventSubscriptionCancelled WebhookEventType = "subscription_cancelled"
+)
+
+// WebhookEvent is a provider webhook translated into what it means for LaunchStack.
+// Fields the provider did not send are left empty.
+type WebhookEvent struct {
+	Type           WebhookEventType
+	CheckoutID     string
+	PaymentID      string
+	RefundID       string
+	Amount         int // refunded amount in cents
+	SubscriptionID string
+	UserID         uuid.UUID
+	Plan           models.SubscriptionPlan
+	Status         models.SubscriptionStatus
+	PeriodEnd      time.Time
+}
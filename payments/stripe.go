@@ -0,0 +1,399 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/models"
+)
+
+// stripeSignatureTolerance is how old a signed Stripe webhook may be before it is rejected as a replay
+const stripeSignatureTolerance = 5 * time.Minute
+
+// StripeProvider takes payments through Stripe Checkout and manages Stripe subscriptions
+type StripeProvider struct {
+	config  *config.Config
+	baseURL string
+	client  *http.Client
+}
+
+// stripeSubscription is the subset of a Stripe subscription object that LaunchStack uses
+type stripeSubscription struct {
+	ID                string            `json:"id"`
+	Status            string            `json:"status"`
+	CurrentPeriodEnd  int64             `json:"current_period_end"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	Metadata          map[string]string `json:"metadata"`
+	Items             struct {
+		Data []struct {
+			ID    string `json:"id"`
+			Price struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// Name returns the provider name
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+// priceID returns the Stripe price of a plan
+func (p *StripeProvider) priceID(plan models.SubscriptionPlan) string {
+	switch plan {
+	case models.PlanStarter:
+		return p.config.Stripe.StarterPriceID
+	case models.PlanPro:
+		return p.config.Stripe.ProPriceID
+	}
+	return ""
+}
+
+// planForPrice returns the plan sold at a Stripe price
+func (p *StripeProvider) planForPrice(priceID string) models.SubscriptionPlan {
+	switch {
+	case priceID == "":
+		return ""
+	case priceID == p.config.Stripe.StarterPriceID:
+		return models.PlanStarter
+	case priceID == p.config.Stripe.ProPriceID:
+		return models.PlanPro
+	}
+	return ""
+}
+
+// do sends a form-encoded request to the Stripe API and decodes the JSON response into out
+func (p *StripeProvider) do(ctx context.Context, method, path string, form url.Values, out interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.config.Stripe.SecretKey, "")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to communicate with Stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		var stripeErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &stripeErr) == nil && stripeErr.Error.Message != "" {
+			return fmt.Errorf("Stripe error: %s", stripeErr.Error.Message)
+		}
+		return fmt.Errorf("Stripe error: status %d", resp.StatusCode)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse Stripe response: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateCheckout creates a Stripe Checkout session that starts a subscription to the plan's price
+func (p *StripeProvider) CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	priceID := p.priceID(req.Plan)
+	if priceID == "" {
+		return nil, ErrPlanNotConfigured
+	}
+
+	var price struct {
+		UnitAmount int    `json:"unit_amount"`
+		Currency   string `json:"currency"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/v1/prices/"+priceID, nil, &price); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("mode", "subscription")
+	form.Set("line_items[0][price]", priceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("success_url", req.SuccessURL)
+	form.Set("cancel_url", req.CancelURL)
+	form.Set("client_reference_id", req.UserID.String())
+	form.Set("metadata[plan]", string(req.Plan))
+	form.Set("subscription_data[metadata][user_id]", req.UserID.String())
+	if req.Email != "" {
+		form.Set("customer_email", req.Email)
+	}
+	if req.TrialDays > 0 {
+		form.Set("subscription_data[trial_period_days]", strconv.Itoa(req.TrialDays))
+	}
+
+	var session struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/v1/checkout/sessions", form, &session); err != nil {
+		return nil, err
+	}
+
+	return &Checkout{
+		ID:       session.ID,
+		URL:      session.URL,
+		Amount:   price.UnitAmount,
+		Currency: price.Currency,
+	}, nil
+}
+
+// CancelSubscription cancels a Stripe subscription at the end of the current billing period
+func (p *StripeProvider) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	form := url.Values{}
+	form.Set("cancel_at_period_end", "true")
+	return p.do(ctx, http.MethodPost, "/v1/subscriptions/"+subscriptionID, form, nil)
+}
+
+// ChangeSubscription swaps the price of a Stripe subscription. Stripe prorates the change
+// on the next invoice.
+func (p *StripeProvider) ChangeSubscription(ctx context.Context, subscriptionID string, plan models.SubscriptionPlan) (*SubscriptionChange, error) {
+	priceID := p.priceID(plan)
+	if priceID == "" {
+		return nil, ErrPlanNotConfigured
+	}
+
+	var subscription stripeSubscription
+	if err := p.do(ctx, http.MethodGet, "/v1/subscriptions/"+subscriptionID, nil, &subscription); err != nil {
+		return nil, err
+	}
+	if len(subscription.Items.Data) == 0 {
+		return nil, fmt.Errorf("Stripe subscription %s has no items", subscriptionID)
+	}
+
+	form := url.Values{}
+	form.Set("items[0][id]", subscription.Items.Data[0].ID)
+	form.Set("items[0][price]", priceID)
+	form.Set("proration_behavior", "create_prorations")
+	if err := p.do(ctx, http.MethodPost, "/v1/subscriptions/"+subscriptionID, form, nil); err != nil {
+		return nil, err
+	}
+
+	return &SubscriptionChange{ProratedByProvider: true}, nil
+}
+
+// ExtendTrial moves the trial end of a trialing Stripe subscription without prorating
+func (p *StripeProvider) ExtendTrial(ctx context.Context, subscriptionID string, end time.Time) error {
+	form := url.Values{}
+	form.Set("trial_end", strconv.FormatInt(end.Unix(), 10))
+	form.Set("proration_behavior", "none")
+	return p.do(ctx, http.MethodPost, "/v1/subscriptions/"+subscriptionID, form, nil)
+}
+
+// GetSubscription retrieves a Stripe subscription. Subscriptions cancelled at the end of the
+// period are reported as canceled, as their webhooks are.
+func (p *StripeProvider) GetSubscription(ctx context.Context, subscriptionID string) (*Subscription, error) {
+	var subscription stripeSubscription
+	if err := p.do(ctx, http.MethodGet, "/v1/subscriptions/"+subscriptionID, nil, &subscription); err != nil {
+		return nil, err
+	}
+
+	result := &Subscription{ID: subscription.ID, Status: stripeSubscriptionStatus(subscription.Status)}
+	if subscription.CurrentPeriodEnd > 0 {
+		result.PeriodEnd = time.Unix(subscription.CurrentPeriodEnd, 0)
+	}
+	if subscription.CancelAtPeriodEnd {
+		result.Status = models.StatusCanceled
+	}
+	if len(subscription.Items.Data) > 0 {
+		result.Plan = p.planForPrice(subscription.Items.Data[0].Price.ID)
+	}
+	return result, nil
+}
+
+// HandleWebhook verifies the Stripe-Signature header and parses a Stripe event
+func (p *StripeProvider) HandleWebhook(ctx context.Context, header http.Header, body []byte) ([]WebhookEvent, error) {
+	if err := verifyStripeSignature(header.Get("Stripe-Signature"), body, p.config.Stripe.WebhookSecret, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid JSON payload: %w", err)
+	}
+
+	switch event.Type {
+	case "checkout.session.completed":
+		var session struct {
+			ID                string            `json:"id"`
+			ClientReferenceID string            `json:"client_reference_id"`
+			Subscription      string            `json:"subscription"`
+			Invoice           string            `json:"invoice"`
+			PaymentStatus     string            `json:"payment_status"`
+			Metadata          map[string]string `json:"metadata"`
+		}
+		if err := json.Unmarshal(event.Data.Object, &session); err != nil {
+			return nil, fmt.Errorf("invalid checkout session: %w", err)
+		}
+		userID, err := uuid.Parse(session.ClientReferenceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user ID: %w", err)
+		}
+
+		var events []WebhookEvent
+		if session.PaymentStatus == "paid" {
+			events = append(events, WebhookEvent{Type: EventPaymentSucceeded, CheckoutID: session.ID, PaymentID: session.Invoice})
+		}
+		if session.Subscription != "" {
+			events = append(events, WebhookEvent{
+				Type:           EventSubscriptionCreated,
+				SubscriptionID: session.Subscription,
+				UserID:         userID,
+				Plan:           models.SubscriptionPlan(session.Metadata["plan"]),
+				Status:         models.StatusActive,
+				PeriodEnd:      time.Now().AddDate(0, 1, 0), // Corrected by the subscription's own update event
+			})
+		}
+		return events, nil
+
+	case "charge.refunded":
+		var charge struct {
+			ID             string `json:"id"`
+			Invoice        string `json:"invoice"`
+			AmountRefunded int    `json:"amount_refunded"`
+			Refunds        struct {
+				Data []struct {
+					ID     string `json:"id"`
+					Amount int    `json:"amount"`
+				} `json:"data"`
+			} `json:"refunds"`
+		}
+		if err := json.Unmarshal(event.Data.Object, &charge); err != nil {
+			return nil, fmt.Errorf("invalid charge: %w", err)
+		}
+		if charge.Invoice == "" {
+			return nil, nil
+		}
+
+		// Each refund is reported separately so partial refunds are recorded once each
+		var events []WebhookEvent
+		for _, refund := range charge.Refunds.Data {
+			events = append(events, WebhookEvent{Type: EventPaymentRefunded, PaymentID: charge.Invoice, RefundID: refund.ID, Amount: refund.Amount})
+		}
+		if len(events) == 0 && charge.AmountRefunded > 0 {
+			events = append(events, WebhookEvent{Type: EventPaymentRefunded, PaymentID: charge.Invoice, RefundID: charge.ID, Amount: charge.AmountRefunded})
+		}
+		return events, nil
+
+	case "customer.subscription.updated":
+		var subscription stripeSubscription
+		if err := json.Unmarshal(event.Data.Object, &subscription); err != nil {
+			return nil, fmt.Errorf("invalid subscription: %w", err)
+		}
+		updated := WebhookEvent{
+			Type:           EventSubscriptionUpdated,
+			SubscriptionID: subscription.ID,
+			Status:         stripeSubscriptionStatus(subscription.Status),
+			PeriodEnd:      time.Unix(subscription.CurrentPeriodEnd, 0),
+		}
+		// Subscriptions cancelled at the end of the period stay active on Stripe until then
+		if subscription.CancelAtPeriodEnd {
+			updated.Status = models.StatusCanceled
+		}
+		if len(subscription.Items.Data) > 0 {
+			updated.Plan = p.planForPrice(subscription.Items.Data[0].Price.ID)
+		}
+		return []WebhookEvent{updated}, nil
+
+	case "customer.subscription.deleted":
+		var subscription stripeSubscription
+		if err := json.Unmarshal(event.Data.Object, &subscription); err != nil {
+			return nil, fmt.Errorf("invalid subscription: %w", err)
+		}
+		return []WebhookEvent{{Type: EventSubscriptionCancelled, SubscriptionID: subscription.ID}}, nil
+	}
+
+	return nil, nil
+}
+
+// verifyStripeSignature checks a Stripe-Signature header of the form "t=<unix>,v1=<hex>",
+// which signs "<t>.<body>" with the endpoint's webhook secret
+func verifyStripeSignature(header string, body []byte, secret string, now time.Time) error {
+	if secret == "" || header == "" {
+		return ErrInvalidSignature
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// stripeSubscriptionStatus maps a Stripe subscription status to the user's subscription status
+func stripeSubscriptionStatus(status string) models.SubscriptionStatus {
+	switch status {
+	case "active":
+		return models.StatusActive
+	case "trialing":
+		return models.StatusTrial
+	case "past_due", "unpaid":
+		return models.StatusPastDue
+	case "canceled":
+		return models.StatusCanceled
+	case "incomplete_expired":
+		return models.StatusExpired
+	default:
+		return models.SubscriptionStatus(status)
+	}
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CaddyProvider checks domains against the Caddy admin API
+type CaddyProvider struct {
+	adminURL string
+	client   *http.Client
+}
+
+// caddyServer is the subset of a Caddy HTTP server config needed to find routed hosts
+type caddyServer struct {
+	Routes []struct {
+		Match []struct {
+			Host []string `json:"host"`
+		} `json:"match"`
+	} `json:"routes"`
+}
+
+// Name returns the provider name
+func (p *CaddyProvider) Name() string {
+	return "caddy"
+}
+
+// RouteError reports an error when no Caddy route serves the domain, since Caddy only
+// manages certificates for hosts it routes
+func (p *CaddyProvider) RouteError(ctx context.Context, domain string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.adminURL+"/config/apps/http/servers", nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("caddy admin API responded with status %d", resp.StatusCode)
+	}
+
+	var servers map[string]caddyServer
+	if err := json.NewDecoder(resp.Body).Decode(&servers); err != nil {
+		return "", fmt.Errorf("failed to decode caddy config: %w", err)
+	}
+
+	for _, server := range servers {
+		for _, route := range server.Routes {
+			for _, match := range route.Match {
+				for _, host := range match.Host {
+					if hostMatches(host, domain) {
+						return "", nil
+					}
+				}
+			}
+		}
+	}
+
+	return fmt.Sprintf("no caddy route is configured for %s", domain), nil
+}
+
+// hostMatches checks a domain against a host pattern that may start with a wildcard label
+func hostMatches(pattern, domain string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return strings.HasSuffix(domain, suffix) && !strings.Contains(strings.TrimSuffix(domain, suffix), ".")
+	}
+	return strings.EqualFold(pattern, domain)
+}
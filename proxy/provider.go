@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/models"
+)
+
+// Provider queries the reverse proxy that terminates TLS for instance URLs
+type Provider interface {
+	// Name returns the provider name for logging
+	Name() string
+	// RouteError returns the problem the proxy reports for a domain, or "" if it is served normally
+	RouteError(ctx context.Context, domain string) (string, error)
+}
+
+// NewProvider creates the provider for the configured proxy, or returns nil when none is configured
+func NewProvider(cfg *config.Config) (Provider, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch cfg.Proxy.Provider {
+	case "":
+		return nil, nil
+	case "caddy":
+		return &CaddyProvider{adminURL: strings.TrimSuffix(cfg.Proxy.APIURL, "/"), client: client}, nil
+	case "traefik":
+		return &TraefikProvider{apiURL: strings.TrimSuffix(cfg.Proxy.APIURL, "/"), client: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy provider %q", cfg.Proxy.Provider)
+	}
+}
+
+// CertificateInfo describes the certificate currently served for a domain
+type CertificateInfo struct {
+	Status    models.CertificateStatus
+	Issuer    string
+	NotBefore *time.Time
+	NotAfter  *time.Time
+	Error     string
+}
+
+// CertificateChecker combines the proxy's view of a domain with the certificate it actually serves
+type CertificateChecker struct {
+	provider    Provider
+	renewWindow time.Duration
+}
+
+// NewCertificateChecker creates a checker. Certificates expiring within renewWindow are reported as renewing.
+func NewCertificateChecker(provider Provider, renewWindow time.Duration) *CertificateChecker {
+	return &CertificateChecker{provider: provider, renewWindow: renewWindow}
+}
+
+// Check determines the certificate status of a domain
+func (c *CertificateChecker) Check(ctx context.Context, domain string) CertificateInfo {
+	routeErr, err := c.provider.RouteError(ctx, domain)
+	if err != nil {
+		return CertificateInfo{Status: models.CertificateFailed, Error: fmt.Sprintf("failed to query %s: %v", c.provider.Name(), err)}
+	}
+
+	cert, verifyErr, err := fetchCertificate(ctx, domain)
+	if err != nil {
+		// Nothing is served yet; the proxy may still be obtaining the certificate
+		if routeErr != "" {
+			return CertificateInfo{Status: models.CertificateFailed, Error: routeErr}
+		}
+		return CertificateInfo{Status: models.CertificatePending, Error: err.Error()}
+	}
+
+	info := CertificateInfo{
+		Issuer:    cert.Issuer.CommonName,
+		NotBefore: &cert.NotBefore,
+		NotAfter:  &cert.NotAfter,
+	}
+
+	switch {
+	case routeErr != "":
+		info.Status = models.CertificateFailed
+		info.Error = routeErr
+	case time.Now().After(cert.NotAfter):
+		info.Status = models.CertificateFailed
+		info.Error = "certificate has expired"
+	case verifyErr != nil:
+		// A self-signed placeholder is served until the real certificate is issued
+		info.Status = models.CertificatePending
+		info.Error = verifyErr.Error()
+	case time.Until(cert.NotAfter) < c.renewWindow:
+		info.Status = models.CertificateRenewing
+	default:
+		info.Status = models.CertificateIssued
+	}
+
+	return info
+}
+
+// fetchCertificate connects to the domain over TLS and returns the leaf certificate,
+// along with the verification error if it is not trusted for the domain
+func fetchCertificate(ctx context.Context, domain string) (*x509.Certificate, error, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 5 * time.Second},
+		// Verified below so that untrusted certificates can still be inspected
+		Config: &tls.Config{ServerName: domain, InsecureSkipVerify: true},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(domain, "443"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, nil, errors.New("no certificate presented")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, verifyErr := certs[0].Verify(x509.VerifyOptions{DNSName: domain, Intermediates: intermediates})
+
+	return certs[0], verifyErr, nil
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraefikProvider checks domains against the Traefik API
+type TraefikProvider struct {
+	apiURL string
+	client *http.Client
+}
+
+// traefikRouter is the subset of a Traefik HTTP router needed to check TLS
+type traefikRouter struct {
+	Name   string      `json:"name"`
+	Rule   string      `json:"rule"`
+	Status string      `json:"status"`
+	Error  []string    `json:"error"`
+	TLS    interface{} `json:"tls"`
+}
+
+// Name returns the provider name
+func (p *TraefikProvider) Name() string {
+	return "traefik"
+}
+
+// RouteError reports the errors Traefik has for the router serving the domain
+func (p *TraefikProvider) RouteError(ctx context.Context, domain string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+"/api/http/routers?per_page=10000", nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("traefik API responded with status %d", resp.StatusCode)
+	}
+
+	var routers []traefikRouter
+	if err := json.NewDecoder(resp.Body).Decode(&routers); err != nil {
+		return "", fmt.Errorf("failed to decode traefik routers: %w", err)
+	}
+
+	for _, router := range routers {
+		if !strings.Contains(router.Rule, "`"+domain+"`") {
+			continue
+		}
+		if router.TLS == nil {
+			continue
+		}
+		if router.Status != "enabled" || len(router.Error) > 0 {
+			return fmt.Sprintf("traefik router %s is %s: %s", router.Name, router.Status, strings.Join(router.Error, "; ")), nil
+		}
+		return "", nil
+	}
+
+	return fmt.Sprintf("no traefik TLS router is configured for %s", domain), nil
+}
@@ -0,0 +1,671 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RegisterAdminRoutes registers operator-only routes
+func RegisterAdminRoutes(router *gin.Engine, cfg *config.Config, containerManager container.Manager, backups *jobs.Backups, logger *logrus.Logger) {
+	v1AdminRoutes := router.Group("/api/v1/admin")
+	v1AdminRoutes.Use(middleware.RequireAdmin())
+
+	v1AdminRoutes.GET("/users", AdminListUsers())
+	v1AdminRoutes.POST("/users/:id/impersonate", AdminImpersonateUser())
+	v1AdminRoutes.PUT("/users/:id/reseller", AdminSetReseller())
+	v1AdminRoutes.PUT("/users/:id/plan", AdminSetPlan(containerManager))
+	v1AdminRoutes.GET("/users/:id/plan/preview", AdminPreviewPlanChange(cfg))
+	v1AdminRoutes.GET("/instances", AdminListInstances())
+	v1AdminRoutes.GET("/instances/:id/spec", AdminGetInstanceSpec())
+	v1AdminRoutes.PATCH("/instances/:id/resources", AdminUpdateInstanceResources(cfg, containerManager))
+	v1AdminRoutes.POST("/instances/:id/stop", AdminStopInstance(containerManager))
+	v1AdminRoutes.DELETE("/instances/:id", AdminDeleteInstance(containerManager))
+	v1AdminRoutes.POST("/instances/:id/reconcile", AdminReconcileInstance(containerManager))
+	v1AdminRoutes.POST("/instances/:id/backups", AdminCreateInstanceBackup(backups))
+	v1AdminRoutes.GET("/instances/:id/logs", AdminStreamInstanceLogs(containerManager))
+	v1AdminRoutes.POST("/instances/:id/terminal", AdminOpenTerminal(cfg))
+	v1AdminRoutes.GET("/terminal-sessions", AdminListTerminalSessions())
+	v1AdminRoutes.GET("/terminal-sessions/:id", AdminGetTerminalSession())
+	v1AdminRoutes.GET("/stats", AdminPlatformStats())
+	v1AdminRoutes.GET("/branding", AdminGetBranding(cfg))
+	v1AdminRoutes.PUT("/branding", AdminUpdateBranding(cfg))
+	v1AdminRoutes.GET("/agents", AdminListAgents())
+	v1AdminRoutes.POST("/agents", AdminRegisterAgent(cfg))
+	v1AdminRoutes.POST("/agents/:id/token", AdminIssueAgentToken(cfg))
+	v1AdminRoutes.DELETE("/agents/:id/tokens/:token_id", AdminRevokeAgentToken())
+	v1AdminRoutes.DELETE("/agents/:id", AdminRevokeAgent())
+	v1AdminRoutes.GET("/leases", AdminListLeases(cfg))
+	v1AdminRoutes.GET("/hosts", AdminListHosts(cfg))
+	v1AdminRoutes.PUT("/hosts/:id", AdminUpdateHostPolicy())
+	v1AdminRoutes.GET("/images", AdminListCachedImages(cfg))
+	v1AdminRoutes.GET("/encryption", AdminGetEncryptionStatus(cfg))
+	v1AdminRoutes.GET("/config/redacted", AdminGetRedactedConfig(cfg))
+	v1AdminRoutes.GET("/billing/journal", AdminListBillingEntries())
+	v1AdminRoutes.POST("/billing/journal/verify", AdminVerifyBillingJournal())
+
+	// Terminal WebSockets are authorized by the ticket AdminOpenTerminal issued
+	router.GET("/api/v1/terminal/:ticket", ConnectTerminal(cfg, containerManager))
+}
+
+// userListQuery is what user listings can be sorted and filtered by
+var userListQuery = listQuery{
+	sorts: map[string]string{
+		"email":      "email",
+		"plan":       "plan",
+		"created_at": "created_at",
+	},
+	filters: map[string]string{
+		"plan": "plan",
+		"role": "role",
+	},
+	defaultSort: "-created_at",
+}
+
+// AdminListUsers returns a page of users with their instance counts, with the number of users
+// matching the filters in the X-Total-Count header
+func AdminListUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, ok := bindListOptions(c, userListQuery)
+		if !ok {
+			return
+		}
+
+		users, total, err := db.ListUsers(opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get users"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(users))
+		for i, user := range users {
+			count, err := db.CountInstancesByUserID(user.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+				return
+			}
+
+			response[i] = user.ToPublicResponse()
+			response[i]["role"] = user.Role
+			response[i]["instance_count"] = count
+			response[i]["created_at"] = user.CreatedAt
+		}
+
+		setTotalCount(c, total)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AdminImpersonateUser validates an impersonation target and returns the header to use
+func AdminImpersonateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		admin, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		user, err := db.GetUserByID(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"admin_id":        admin.ID,
+			"impersonated_id": user.ID,
+		}).Warn("Admin started impersonation session")
+
+		c.JSON(http.StatusOK, gin.H{
+			"header": middleware.ImpersonateHeader,
+			"value":  user.ID,
+			"user":   user.ToPublicResponse(),
+		})
+	}
+}
+
+// AdminSetReseller grants the reseller role to a user with the given instance pool,
+// or revokes it when the pool is zero
+func AdminSetReseller() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req QuotaRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		user, err := db.GetUserByID(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+		if user.IsSubAccount() || user.IsAdmin() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-accounts and admins cannot be resellers"})
+			return
+		}
+
+		allocated, err := db.SumAllocatedQuota(user.ID, uuid.Nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pool allocation"})
+			return
+		}
+		if req.InstanceQuota < allocated {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":     "Pool is smaller than the quota already allocated to sub-accounts",
+				"allocated": allocated,
+			})
+			return
+		}
+
+		user.InstanceQuota = req.InstanceQuota
+		user.Role = models.RoleReseller
+		if req.InstanceQuota == 0 {
+			user.Role = models.RoleUser
+		}
+		if err := db.UpdateUser(&user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":       user.ID,
+			"instance_pool": user.InstanceQuota,
+		}).Warn("Admin updated reseller pool")
+
+		response := user.ToPublicResponse()
+		response["role"] = user.Role
+		response["instance_pool"] = user.InstanceQuota
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// PlanRequest represents a request to change a user's subscription plan
+type PlanRequest struct {
+	Plan models.SubscriptionPlan `json:"plan" binding:"required"`
+}
+
+// AdminSetPlan changes a user's plan and applies the new plan's resource limits to
+// their existing instances
+func AdminSetPlan(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req PlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if _, ok := models.GetPlan(req.Plan); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown plan"})
+			return
+		}
+
+		user, err := db.GetUserByID(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+		if user.IsSubAccount() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-accounts follow their reseller's plan"})
+			return
+		}
+
+		previous := user.Plan
+		user.Plan = req.Plan
+		err = db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&user).Error; err != nil {
+				return err
+			}
+			_, err := db.RecordPlanChange(tx, user.ID, previous, user.Plan, fmt.Sprintf("Admin changed plan from %s to %s", previous, user.Plan))
+			return err
+		})
+		if err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to update user plan")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+			return
+		}
+		db.InvalidateCachedUserByID(user.ID)
+
+		logger.WithFields(logrus.Fields{
+			"user_id":       user.ID,
+			"previous_plan": previous,
+			"plan":          user.Plan,
+		}).Warn("Admin changed user plan")
+
+		// Resizing may recreate containers, so it runs after the response
+		go applyPlanLimits(containerManager, user, logger)
+
+		c.JSON(http.StatusOK, user.ToPublicResponse())
+	}
+}
+
+// planInstances returns the instances that follow a user's plan: their own and, for
+// resellers, those of their sub-accounts
+func planInstances(user models.User, logger *logrus.Logger) ([]models.Instance, error) {
+	userIDs := []uuid.UUID{user.ID}
+	if user.IsReseller() {
+		subAccounts, err := db.GetSubAccounts(user.ID)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to get sub-accounts for plan change")
+		}
+		for _, subAccount := range subAccounts {
+			userIDs = append(userIDs, subAccount.ID)
+		}
+	}
+	return db.GetInstancesByUserIDs(userIDs)
+}
+
+// planTargetLimits returns the CPU and memory limits an instance gets on the user's plan,
+// keeping lower defaults of the instance's project. Instances whose resources were overridden
+// keep their limits, lowered to the plan's if above them. Projects are cached across calls.
+func planTargetLimits(user models.User, instance models.Instance, projects map[uuid.UUID]*models.Project) models.Instance {
+	target := models.Instance{CPULimit: user.GetCPULimit(), MemoryLimit: user.GetMemoryLimit()}
+	if instance.ResourceOverride {
+		target.CPULimit = math.Min(target.CPULimit, instance.CPULimit)
+		if instance.MemoryLimit < target.MemoryLimit {
+			target.MemoryLimit = instance.MemoryLimit
+		}
+		return target
+	}
+	if instance.ProjectID != nil {
+		project, cached := projects[*instance.ProjectID]
+		if !cached {
+			project, _ = db.GetProjectByID(*instance.ProjectID)
+			projects[*instance.ProjectID] = project
+		}
+		if project != nil {
+			project.ApplyDefaults(&target, user)
+		}
+	}
+	return target
+}
+
+// applyPlanLimits moves every instance of a user, and of their sub-accounts, to the
+// CPU and memory limits of the user's current plan, keeping lower project defaults
+func applyPlanLimits(containerManager container.Manager, user models.User, logger *logrus.Logger) {
+	instances, err := planInstances(user, logger)
+	if err != nil {
+		logger.WithError(err).WithField("user_id", user.ID).Error("Failed to get instances for plan change")
+		return
+	}
+
+	projects := map[uuid.UUID]*models.Project{}
+	for _, instance := range instances {
+		if instance.Status == models.StatusDeleted || instance.Status == models.StatusUpgrading {
+			continue
+		}
+
+		target := planTargetLimits(user, instance, projects)
+		if target.CPULimit == instance.CPULimit && target.MemoryLimit == instance.MemoryLimit {
+			continue
+		}
+
+		instanceLogger := logger.WithField("instance_id", instance.ID)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		recreated, err := containerManager.ResizeInstance(ctx, instance.ID, target.CPULimit, target.MemoryLimit)
+		cancel()
+		if err != nil {
+			instanceLogger.WithError(err).Error("Failed to apply plan resource limits")
+		}
+		recordResourceChange(instance, target.CPULimit, target.MemoryLimit, recreated, err, instanceLogger)
+	}
+}
+
+// PlanChangeHandler applies the limits of a user's new plan to their instances for jobs that
+// change plans
+func PlanChangeHandler(containerManager container.Manager, logger *logrus.Logger) jobs.PlanChangeHandler {
+	return func(user models.User) {
+		applyPlanLimits(containerManager, user, logger)
+	}
+}
+
+// recordResourceChange records the outcome of changing an instance's CPU and memory limits
+// as an instance event
+func recordResourceChange(instance models.Instance, cpuLimit float64, memoryLimitMB int, recreated bool, resizeErr error, logger *logrus.Entry) {
+	event := &models.InstanceEvent{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Type:       models.EventResourcesUpdated,
+		Level:      models.EventLevelInfo,
+		Message: fmt.Sprintf("Resource limits changed from %.2g CPU / %d MB to %.2g CPU / %d MB without a restart",
+			instance.CPULimit, instance.MemoryLimit, cpuLimit, memoryLimitMB),
+	}
+	switch {
+	case resizeErr != nil:
+		event.Level = models.EventLevelError
+		event.Message = fmt.Sprintf("Failed to change resource limits to %.2g CPU / %d MB: %v", cpuLimit, memoryLimitMB, resizeErr)
+	case recreated:
+		event.Level = models.EventLevelWarning
+		event.Message = fmt.Sprintf("Resource limits changed from %.2g CPU / %d MB to %.2g CPU / %d MB; the container was recreated",
+			instance.CPULimit, instance.MemoryLimit, cpuLimit, memoryLimitMB)
+	}
+	if err := db.CreateInstanceEvent(event); err != nil {
+		logger.WithError(err).Warn("Failed to record resource change event")
+	}
+}
+
+// AdminListInstances returns a page of the instances of all users, with the number of
+// instances matching the filters in the X-Total-Count header
+func AdminListInstances() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, ok := bindListOptions(c, instanceListQuery)
+		if !ok {
+			return
+		}
+
+		instances, total, err := db.ListAllInstances(opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(instances))
+		for i, instance := range instances {
+			response[i] = instance.ToPublicResponse()
+			response[i]["user_id"] = instance.UserID
+			response[i]["container_id"] = instance.ContainerID
+			response[i]["ip_address"] = instance.IPAddress
+		}
+
+		setTotalCount(c, total)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AdminGetInstanceSpec returns the resolved request any instance was created from, for support
+func AdminGetInstanceSpec() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+
+		instance, err := db.GetInstanceByID(instanceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+		if instance.ProvisioningSpec == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No provisioning spec recorded for this instance"})
+			return
+		}
+
+		c.JSON(http.StatusOK, instance.ProvisioningSpec)
+	}
+}
+
+// AdminStopInstance force stops any user's instance
+func AdminStopInstance(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+
+		instance, err := db.GetInstanceByID(instanceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+
+		if err := containerManager.StopInstance(context.Background(), instanceID); err != nil {
+			logger.WithError(err).Error("Admin failed to stop instance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop instance"})
+			return
+		}
+
+		instance.Status = models.StatusStopped
+		if err := db.UpdateInstance(instance); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
+			return
+		}
+
+		logger.WithField("instance_id", instanceID).Warn("Instance force stopped by admin")
+		c.JSON(http.StatusOK, gin.H{"message": "Instance stopped successfully"})
+	}
+}
+
+// AdminDeleteInstance force deletes any user's instance
+func AdminDeleteInstance(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+
+		if _, err := db.GetInstanceByID(instanceID); err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+
+		if err := containerManager.DeleteInstance(context.Background(), instanceID); err != nil {
+			logger.WithError(err).Error("Admin failed to delete instance container")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete instance container"})
+			return
+		}
+
+		if err := db.DeleteInstance(instanceID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete instance from database"})
+			return
+		}
+
+		logger.WithField("instance_id", instanceID).Warn("Instance force deleted by admin")
+		c.JSON(http.StatusOK, gin.H{"message": "Instance deleted successfully"})
+	}
+}
+
+// AdminReconcileInstance brings the container of any user's instance in line with its
+// recorded status, returning the changes made
+func AdminReconcileInstance(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+		if _, err := db.GetInstanceByID(instanceID); err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+		defer cancel()
+		changes, err := containerManager.ReconcileInstance(ctx, instanceID)
+		if err != nil {
+			if errors.Is(err, container.ErrInstanceBusy) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "changes": changes})
+				return
+			}
+			logger.WithError(err).WithField("instance_id", instanceID).Error("Admin failed to reconcile instance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to reconcile instance: %v", err), "changes": changes})
+			return
+		}
+
+		instance, err := db.GetInstanceByID(instanceID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instance"})
+			return
+		}
+		logger.WithFields(logrus.Fields{"instance_id": instanceID, "changes": changes}).Warn("Instance reconciled by admin")
+		c.JSON(http.StatusOK, gin.H{"instance": instance.ToPublicResponse(), "changes": changes})
+	}
+}
+
+// AdminCreateInstanceBackup queues a manual backup of any user's instance, whatever their plan
+func AdminCreateInstanceBackup(runner *jobs.Backups) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+		instance, err := db.GetInstanceByID(instanceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+		createBackup(c, runner, instance, logger)
+	}
+}
+
+// AdminStreamInstanceLogs returns the last lines of the container output of any user's instance
+// as plain text and, with follow=true, keeps streaming new lines until the client disconnects
+func AdminStreamInstanceLogs(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+		tail := 100
+		if value := c.Query("tail"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 || parsed > 10000 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "tail must be between 0 and 10000"})
+				return
+			}
+			tail = parsed
+		}
+		follow := c.Query("follow") == "true"
+		if _, err := db.GetInstanceByID(instanceID); err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Cache-Control", "no-store")
+		w := &flushWriter{c: c}
+		if err := containerManager.StreamInstanceLogs(c.Request.Context(), instanceID, tail, follow, w); err != nil {
+			logger.WithError(err).WithField("instance_id", instanceID).Error("Failed to stream instance logs")
+			// Once lines were sent the status is out, so the stream just ends
+			if !w.written {
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read instance logs"})
+			}
+			return
+		}
+		if !w.written {
+			c.Status(http.StatusOK)
+		}
+	}
+}
+
+// flushWriter sends every write to the client right away, so followed logs arrive as they
+// are written
+type flushWriter struct {
+	c       *gin.Context
+	written bool
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	w.written = true
+	n, err := w.c.Writer.Write(p)
+	w.c.Writer.Flush()
+	return n, err
+}
+
+// AdminPlatformStats returns platform-wide user, instance and resource aggregates
+func AdminPlatformStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		usersByPlan, err := db.CountUsersByPlan()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users"})
+			return
+		}
+
+		instancesByStatus, err := db.CountInstancesByStatus()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+			return
+		}
+
+		resources, err := db.GetPlatformResourceSummary(time.Hour)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate resource usage"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"users_by_plan":       usersByPlan,
+			"instances_by_status": instancesByStatus,
+			"resources":           resources,
+			"usage_period":        "1h",
+		})
+	}
+}
+
+// AdminListLeases returns which replica runs each singleton background loop
+func AdminListLeases(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		leases, err := db.GetLeases()
+		if err != nil {
+			logger.WithError(err).Error("Failed to get leases")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leases"})
+			return
+		}
+
+		now := time.Now()
+		response := make([]map[string]interface{}, len(leases))
+		for i := range leases {
+			response[i] = leases[i].ToPublicResponse(now)
+			response[i]["this_replica"] = leases[i].Holder == cfg.Leases.ReplicaID
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"replica_id": cfg.Leases.ReplicaID,
+			"leases":     response,
+		})
+	}
+}
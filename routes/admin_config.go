@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminGetRedactedConfig returns the configuration the backend is running with, secrets
+// redacted, and the validation problems it started with when CONFIG_FAIL_FAST is disabled
+func AdminGetRedactedConfig(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		problems := cfg.Problems()
+		if problems == nil {
+			problems = []string{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"config":   cfg.Redacted(),
+			"problems": problems,
+		})
+	}
+}
+
+// RegisterConfigReloadRoutes registers the route that reloads the configuration
+func RegisterConfigReloadRoutes(router *gin.Engine, watcher *config.Watcher, logger *logrus.Logger) {
+	v1AdminRoutes := router.Group("/api/v1/admin")
+	v1AdminRoutes.Use(middleware.RequireAdmin())
+	v1AdminRoutes.POST("/config/reload", AdminReloadConfig(watcher))
+}
+
+// AdminReloadConfig reloads the configuration of the replica serving the request and applies
+// the settings that can change without a restart. A configuration that fails to load or
+// validate is rejected and nothing changes.
+func AdminReloadConfig(watcher *config.Watcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		result, err := watcher.Reload()
+		if err != nil {
+			var invalid *config.ValidationError
+			problems := []string{err.Error()}
+			if errors.As(err, &invalid) {
+				problems = invalid.Problems
+			}
+			logger.WithError(err).Warn("Rejected configuration reload")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid configuration", "problems": problems})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"applied": result.Applied,
+			"failed":  len(result.Failed),
+		}).Info("Reloaded configuration through the admin API")
+		c.JSON(http.StatusOK, result)
+	}
+}
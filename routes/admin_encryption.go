@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminGetEncryptionStatus reports the keys of the keyring and, for each encrypted column, how
+// many values are not encrypted with the current key yet. A previous key can be dropped from
+// ENCRYPTION_PREVIOUS_KEYS once no column has stale values.
+func AdminGetEncryptionStatus(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		current := models.CurrentEncryptionKeyID()
+		previous := []string{}
+		seen := map[string]bool{current: true}
+		for _, key := range cfg.Encryption.PreviousKeys {
+			if id := models.EncryptionKeyID(key); !seen[id] {
+				seen[id] = true
+				previous = append(previous, id)
+			}
+		}
+
+		columns := make([]gin.H, len(db.EncryptedColumns))
+		for i, column := range db.EncryptedColumns {
+			stale, err := db.CountStaleEncryptedValues(column)
+			if err != nil {
+				logger.WithError(err).Error("Failed to count stale encrypted values")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get encryption status"})
+				return
+			}
+			columns[i] = gin.H{
+				"table":  column.Table,
+				"column": column.Column,
+				"stale":  stale,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"current_key_id":     current,
+			"previous_key_ids":   previous,
+			"columns":            columns,
+			"reencrypt_interval": cfg.Encryption.ReencryptInterval.String(),
+		})
+	}
+}
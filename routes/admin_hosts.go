@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// HostPolicyRequest represents a request to tune a Docker host's overcommit policy. Ratios of
+// 0 stop limiting allocation by the host's physical resources.
+type HostPolicyRequest struct {
+	CPUOvercommit    *float64 `json:"cpu_overcommit" binding:"omitempty,min=0,max=20"`
+	MemoryOvercommit *float64 `json:"memory_overcommit" binding:"omitempty,min=0,max=20"`
+	UtilizationAlert *float64 `json:"utilization_alert" binding:"omitempty,gt=0,max=1"`
+}
+
+// AdminListHosts returns the Docker hosts with their overcommit policies and utilization, and
+// the capacity admission control applies across the active hosts
+func AdminListHosts(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		hosts, err := db.GetDockerHosts()
+		if err != nil {
+			logger.WithError(err).Error("Failed to get docker hosts")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get hosts"})
+			return
+		}
+		capacity, err := container.LoadCapacity(cfg)
+		if err != nil {
+			logger.WithError(err).Error("Failed to load host capacity")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get hosts"})
+			return
+		}
+		allocated, err := db.GetAllocatedResources(uuid.Nil)
+		if err != nil {
+			logger.WithError(err).Error("Failed to sum allocated resources")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get hosts"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(hosts))
+		for i, host := range hosts {
+			response[i] = host.ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"hosts": response,
+			"capacity": gin.H{
+				"max_cpu":       capacity.MaxCPU,
+				"max_memory_mb": capacity.MaxMemoryMB,
+				"max_instances": capacity.MaxInstances,
+			},
+			"allocated": gin.H{
+				"cpu":       allocated.CPU,
+				"memory_mb": allocated.MemoryMB,
+				"instances": allocated.Instances,
+			},
+		})
+	}
+}
+
+// AdminUpdateHostPolicy changes the overcommit ratios and alert threshold of a Docker host.
+// New ratios apply to admission right away; instances already running are not affected.
+func AdminUpdateHostPolicy() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		hostID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid host ID"})
+			return
+		}
+
+		var req HostPolicyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		host, err := db.GetDockerHost(hostID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Host not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get host"})
+			return
+		}
+
+		if req.CPUOvercommit != nil {
+			host.CPUOvercommit = *req.CPUOvercommit
+		}
+		if req.MemoryOvercommit != nil {
+			host.MemoryOvercommit = *req.MemoryOvercommit
+		}
+		if req.UtilizationAlert != nil {
+			host.UtilizationAlert = *req.UtilizationAlert
+		}
+		if err := db.UpdateDockerHostPolicy(host); err != nil {
+			logger.WithError(err).WithField("host", host.Name).Error("Failed to update host policy")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update host"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"host":              host.Name,
+			"cpu_overcommit":    host.CPUOvercommit,
+			"memory_overcommit": host.MemoryOvercommit,
+			"utilization_alert": host.UtilizationAlert,
+		}).Warn("Admin changed host overcommit policy")
+
+		c.JSON(http.StatusOK, host.ToPublicResponse())
+	}
+}
+
+// AdminListCachedImages returns the images kept pulled on each Docker host with the outcome of
+// their last pull. Images not checked for two intervals are marked stale, as their host no
+// longer pre-pulls them.
+func AdminListCachedImages(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		images, err := db.GetCachedImages()
+		if err != nil {
+			logger.WithError(err).Error("Failed to get cached images")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cached images"})
+			return
+		}
+
+		staleBefore := time.Now().Add(-2 * cfg.Images.PrePullInterval)
+		response := make([]map[string]interface{}, len(images))
+		for i, image := range images {
+			response[i] = image.ToPublicResponse()
+			response[i]["stale"] = image.CheckedAt.Before(staleBefore)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"images":           response,
+			"prepull_interval": cfg.Images.PrePullInterval.String(),
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Enforcement actions a plan change would take on an instance
+const (
+	planActionResize         = "resize"            // CPU and memory limits change
+	planActionUnpin          = "unpin_cpus"        // dedicated CPUs are released on resize
+	planActionMemoryPressure = "memory_over_limit" // recent memory usage exceeds the new limit
+	planActionSuspendStorage = "suspend"           // storage usage is over the new limit
+	planActionResumeStorage  = "resume"            // suspended for storage, but under the new limit
+	planActionStorageWarning = "storage_warning"   // storage usage nears the new limit
+)
+
+const (
+	megabyte = 1024 * 1024
+	gigabyte = 1024 * megabyte
+)
+
+// planLimits returns the per-user and per-instance limits of a user's plan
+func planLimits(user models.User) gin.H {
+	return gin.H{
+		"max_instances": user.GetInstancesLimit(),
+		"cpu_limit":     user.GetCPULimit(),
+		"memory_limit":  user.GetMemoryLimit(),
+		"storage_limit": user.GetStorageLimit(),
+	}
+}
+
+// featureDifference returns the features of one plan that another does not have
+func featureDifference(plan, other models.SubscriptionPlan) []models.Feature {
+	features := []models.Feature{}
+	for _, feature := range models.PlanFeatures(plan) {
+		if !models.PlanHasFeature(other, feature) {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// AdminPreviewPlanChange simulates moving a user to another plan and reports what the change
+// would do: new limits, lost features, the instances that would be resized, unpinned or
+// suspended, and the prorated charge of a self-service change. Nothing is changed and the
+// user is not impersonated, so the preview can be discussed with the customer before the
+// change is applied with PUT /admin/users/:id/plan.
+func AdminPreviewPlanChange(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		plan := models.SubscriptionPlan(c.Query("plan"))
+		if _, ok := models.GetPlan(plan); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown plan"})
+			return
+		}
+
+		user, err := db.GetUserByID(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+		if user.IsSubAccount() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-accounts follow their reseller's plan"})
+			return
+		}
+
+		instances, err := planInstances(user, logger)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to get instances for plan preview")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+
+		target := user
+		target.Plan = plan
+		storageLimit := int64(target.GetStorageLimit()) * gigabyte
+
+		actions := []gin.H{}
+		var owned int
+		projects := map[uuid.UUID]*models.Project{}
+		for _, instance := range instances {
+			if instance.Status == models.StatusDeleted {
+				continue
+			}
+			if instance.UserID == user.ID {
+				owned++
+			}
+			action := func(kind, message string) gin.H {
+				a := gin.H{
+					"instance_id":   instance.ID,
+					"instance_name": instance.Name,
+					"user_id":       instance.UserID,
+					"action":        kind,
+					"message":       message,
+				}
+				actions = append(actions, a)
+				return a
+			}
+
+			limits := planTargetLimits(target, instance, projects)
+			if instance.Status != models.StatusUpgrading &&
+				(limits.CPULimit != instance.CPULimit || limits.MemoryLimit != instance.MemoryLimit) {
+				resize := action(planActionResize, fmt.Sprintf("Resource limits change from %.2g CPU / %d MB to %.2g CPU / %d MB",
+					instance.CPULimit, instance.MemoryLimit, limits.CPULimit, limits.MemoryLimit))
+				resize["from"] = gin.H{"cpu_limit": instance.CPULimit, "memory_limit": instance.MemoryLimit}
+				resize["to"] = gin.H{"cpu_limit": limits.CPULimit, "memory_limit": limits.MemoryLimit}
+
+				if instance.CPUSet != "" && !target.HasFeature(models.FeatureCPUPinning) {
+					action(planActionUnpin, fmt.Sprintf("Dedicated CPUs %s are released and the instance moves to the shared CPUs", instance.CPUSet))
+				}
+			}
+
+			usage, err := db.GetLatestResourceUsage(instance.ID)
+			if err != nil {
+				continue
+			}
+			if limits.MemoryLimit > 0 && usage.MemoryUsage > int64(limits.MemoryLimit)*megabyte {
+				action(planActionMemoryPressure, fmt.Sprintf("Recent memory usage of %d MB exceeds the new limit of %d MB; n8n may run out of memory",
+					usage.MemoryUsage/megabyte, limits.MemoryLimit))
+			}
+			if usage.DiskUsage <= 0 || storageLimit <= 0 {
+				continue
+			}
+			storageSuspended := instance.IsSuspended() && instance.SuspendedReason == models.SuspendReasonStorage
+			switch {
+			case usage.DiskUsage >= storageLimit && !instance.IsSuspended():
+				action(planActionSuspendStorage, fmt.Sprintf("Storage usage of %.1f GB exceeds the new limit of %d GB; the instance would be suspended",
+					float64(usage.DiskUsage)/gigabyte, target.GetStorageLimit()))
+			case usage.DiskUsage < storageLimit && storageSuspended:
+				action(planActionResumeStorage, fmt.Sprintf("Storage usage of %.1f GB is under the new limit of %d GB; the instance would be resumed",
+					float64(usage.DiskUsage)/gigabyte, target.GetStorageLimit()))
+			case float64(usage.DiskUsage) >= float64(storageLimit)*cfg.Storage.WarnThreshold && !instance.IsSuspended():
+				action(planActionStorageWarning, fmt.Sprintf("Storage usage of %.1f GB is close to the new limit of %d GB",
+					float64(usage.DiskUsage)/gigabyte, target.GetStorageLimit()))
+			}
+		}
+
+		exceeded := []gin.H{}
+		if owned > target.GetInstancesLimit() {
+			exceeded = append(exceeded, gin.H{
+				"limit":   "max_instances",
+				"usage":   owned,
+				"allowed": target.GetInstancesLimit(),
+				"message": fmt.Sprintf("The user has %d instances but the %s plan allows %d; existing instances keep running, but no new ones can be created",
+					owned, target.Plan, target.GetInstancesLimit()),
+			})
+		}
+
+		// Self-service changes of active subscriptions are prorated; admin changes are not charged
+		proration := gin.H{
+			"amount":               0.0,
+			"currency":             "usd",
+			"prorated_by_provider": cfg.Payments.Provider == "stripe",
+			"period_end":           user.CurrentPeriodEnd,
+		}
+		if user.SubscriptionStatus == models.StatusActive && user.Plan != target.Plan {
+			proration["amount"] = float64(prorationAmount(user, user.Plan, target.Plan, time.Now())) / 100
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id": user.ID,
+			"plan":    target.Plan,
+			"actions": len(actions),
+		}).Info("Admin previewed plan change")
+
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run":          true,
+			"user_id":          user.ID,
+			"current_plan":     user.Plan,
+			"plan":             target.Plan,
+			"current_limits":   planLimits(user),
+			"limits":           planLimits(target),
+			"features_added":   featureDifference(target.Plan, user.Plan),
+			"features_removed": featureDifference(user.Plan, target.Plan),
+			"limits_exceeded":  exceeded,
+			"actions":          actions,
+			"proration":        proration,
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AgentCheckInRequest is sent by host agents when they check in
+type AgentCheckInRequest struct {
+	Version string `json:"version" binding:"max=50"`
+}
+
+// AgentRegistrationRequest represents an admin request to register the agent of a host
+type AgentRegistrationRequest struct {
+	Host string `json:"host" binding:"required,max=255"`
+}
+
+// RegisterAgentRoutes registers the routes host agents call with their tokens
+func RegisterAgentRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	agentRoutes := router.Group("/api/v1/agents")
+	agentRoutes.Use(middleware.AgentAuthMiddleware(cfg, logger))
+
+	agentRoutes.POST("/check-in", AgentCheckIn())
+	agentRoutes.POST("/token", RotateAgentToken(cfg))
+}
+
+// agentTokenResponse returns a newly issued agent token; it is only shown once
+func agentTokenResponse(token string, record *models.AgentToken) gin.H {
+	return gin.H{
+		"token":      token,
+		"token_id":   record.ID,
+		"expires_at": record.ExpiresAt,
+	}
+}
+
+// AgentCheckIn records that a host agent is alive, along with its version
+func AgentCheckIn() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		agent, err := middleware.GetAgentFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			return
+		}
+
+		// Newer agents may report fields this backend does not know yet, so unknown fields are
+		// ignored even when REQUEST_STRICT_JSON is on
+		var req AgentCheckInRequest
+		err = json.NewDecoder(c.Request.Body).Decode(&req)
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&req)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		now := time.Now()
+		if err := db.RecordHostAgentCheckIn(agent.ID, c.ClientIP(), req.Version, now); err != nil {
+			logger.WithError(err).WithField("agent_id", agent.ID).Error("Failed to record host agent check-in")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record check-in"})
+			return
+		}
+
+		token, _ := middleware.GetAgentTokenFromContext(c)
+		c.JSON(http.StatusOK, gin.H{
+			"agent_id":         agent.ID,
+			"host":             agent.Host,
+			"checked_in_at":    now,
+			"token_expires_at": token.ExpiresAt,
+		})
+	}
+}
+
+// RotateAgentToken issues a host agent a fresh token and revokes the one it authenticated
+// with, so agents can keep their token short-lived without a shared secret
+func RotateAgentToken(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		agent, err := middleware.GetAgentFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			return
+		}
+		current, err := middleware.GetAgentTokenFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			return
+		}
+
+		// Revoke first so a leaked token cannot be used to mint a chain of replacements
+		rotated, err := db.RevokeAgentToken(agent.ID, current.ID, time.Now())
+		if err != nil {
+			logger.WithError(err).WithField("agent_id", agent.ID).Error("Failed to revoke rotated agent token")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate token"})
+			return
+		}
+		if !rotated {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent token"})
+			return
+		}
+
+		token, record, err := middleware.IssueAgentToken(cfg, &agent)
+		if err != nil {
+			logger.WithError(err).WithField("agent_id", agent.ID).Error("Failed to issue agent token")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate token"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"agent_id": agent.ID,
+			"host":     agent.Host,
+			"token_id": record.ID,
+		}).Info("Host agent rotated its token")
+
+		c.JSON(http.StatusOK, agentTokenResponse(token, record))
+	}
+}
+
+// loadHostAgent fetches the host agent in the :id param, writing the error response and
+// returning nil if there is none
+func loadHostAgent(c *gin.Context) *models.HostAgent {
+	agentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		return nil
+	}
+
+	agent, err := db.GetHostAgentByID(agentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+		return nil
+	}
+	return agent
+}
+
+// AdminListAgents returns the registered host agents with their last check-in and unexpired tokens
+func AdminListAgents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		agents, err := db.GetAllHostAgents()
+		if err != nil {
+			logger.WithError(err).Error("Failed to get host agents")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agents"})
+			return
+		}
+
+		now := time.Now()
+		response := make([]map[string]interface{}, len(agents))
+		for i := range agents {
+			response[i] = agents[i].ToPublicResponse()
+
+			tokens, err := db.GetActiveAgentTokens(agents[i].ID, now)
+			if err != nil {
+				logger.WithError(err).WithField("agent_id", agents[i].ID).Warn("Failed to get agent tokens")
+			}
+			active := make([]map[string]interface{}, len(tokens))
+			for j := range tokens {
+				active[j] = tokens[j].ToPublicResponse()
+			}
+			response[i]["active_tokens"] = active
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AdminRegisterAgent registers the agent of a host and issues its first token. Registering a
+// host whose agent was revoked restores the agent.
+func AdminRegisterAgent(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		var req AgentRegistrationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		host := strings.ToLower(strings.TrimSpace(req.Host))
+		if host == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Host is required"})
+			return
+		}
+
+		status := http.StatusCreated
+		agent, err := db.GetHostAgentByHost(host)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			agent = &models.HostAgent{Host: host}
+			if err := db.CreateHostAgent(agent); err != nil {
+				logger.WithError(err).Error("Failed to register host agent")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register agent"})
+				return
+			}
+		case err != nil:
+			logger.WithError(err).Error("Failed to look up host agent")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register agent"})
+			return
+		case !agent.IsRevoked():
+			c.JSON(http.StatusConflict, gin.H{"error": "An agent is already registered for this host", "agent_id": agent.ID})
+			return
+		default:
+			if err := db.RestoreHostAgent(agent.ID); err != nil {
+				logger.WithError(err).Error("Failed to restore host agent")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register agent"})
+				return
+			}
+			agent.RevokedAt = nil
+			status = http.StatusOK
+		}
+
+		token, record, err := middleware.IssueAgentToken(cfg, agent)
+		if err != nil {
+			logger.WithError(err).WithField("agent_id", agent.ID).Error("Failed to issue agent token")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"agent_id": agent.ID,
+			"host":     agent.Host,
+		}).Info("Registered host agent")
+
+		response := agentTokenResponse(token, record)
+		response["agent"] = agent.ToPublicResponse()
+		c.JSON(status, response)
+	}
+}
+
+// AdminIssueAgentToken issues a new token to a host agent, e.g. to bootstrap an agent whose
+// token expired before it could rotate it
+func AdminIssueAgentToken(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		agent := loadHostAgent(c)
+		if agent == nil {
+			return
+		}
+		if agent.IsRevoked() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Agent is revoked"})
+			return
+		}
+
+		token, record, err := middleware.IssueAgentToken(cfg, agent)
+		if err != nil {
+			logger.WithError(err).WithField("agent_id", agent.ID).Error("Failed to issue agent token")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"agent_id": agent.ID,
+			"token_id": record.ID,
+		}).Info("Issued host agent token")
+
+		c.JSON(http.StatusCreated, agentTokenResponse(token, record))
+	}
+}
+
+// AdminRevokeAgentToken puts one token of a host agent on the revocation list
+func AdminRevokeAgentToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		agent := loadHostAgent(c)
+		if agent == nil {
+			return
+		}
+		tokenID, err := uuid.Parse(c.Param("token_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+			return
+		}
+
+		revoked, err := db.RevokeAgentToken(agent.ID, tokenID, time.Now())
+		if err != nil {
+			logger.WithError(err).Error("Failed to revoke agent token")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+			return
+		}
+		if !revoked {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Token not found or already revoked"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"agent_id": agent.ID,
+			"token_id": tokenID,
+		}).Info("Revoked host agent token")
+
+		c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+	}
+}
+
+// AdminRevokeAgent revokes a host agent and all of its tokens
+func AdminRevokeAgent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		agent := loadHostAgent(c)
+		if agent == nil {
+			return
+		}
+
+		if err := db.RevokeHostAgent(agent.ID, time.Now()); err != nil {
+			logger.WithError(err).Error("Failed to revoke host agent")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke agent"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"agent_id": agent.ID,
+			"host":     agent.Host,
+		}).Info("Revoked host agent")
+
+		c.JSON(http.StatusOK, gin.H{"message": "Agent revoked"})
+	}
+}
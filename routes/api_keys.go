@@ -0,0 +1,160 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterAPIKeyRoutes registers routes for managing the current user's API keys
+func RegisterAPIKeyRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	v1APIKeyRoutes := router.Group("/api/v1/api-keys")
+
+	v1APIKeyRoutes.GET("", GetAPIKeys())
+	v1APIKeyRoutes.POST("", CreateAPIKey())
+	v1APIKeyRoutes.DELETE("/:id", RevokeAPIKey())
+}
+
+// rejectAPIKeyAuth blocks requests authenticated with an API key, so a leaked key cannot be
+// used to mint or revoke keys
+func rejectAPIKeyAuth(c *gin.Context) bool {
+	if middleware.IsAPIKeyRequest(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot manage API keys"})
+		return true
+	}
+	return false
+}
+
+// issueAPIKey creates an API key from the request body and writes the response, which is the
+// only one to include the plaintext key and signing secret. It writes the error response and
+// returns nil when the key could not be created.
+func issueAPIKey(c *gin.Context, userID uuid.UUID, projectID *uuid.UUID, logger *logrus.Logger) *models.APIKey {
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return nil
+	}
+
+	plaintext, key, err := models.GenerateAPIKey(userID, projectID, req.Name)
+	if err != nil {
+		logger.WithError(err).Error("Failed to generate API key")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return nil
+	}
+
+	var signingSecret string
+	if req.Signed {
+		if signingSecret, err = key.EnableSigning(); err != nil {
+			logger.WithError(err).Error("Failed to generate API key signing secret")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+			return nil
+		}
+	}
+
+	if err := db.CreateAPIKey(key); err != nil {
+		logger.WithError(err).Error("Failed to store API key")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return nil
+	}
+
+	response := key.ToPublicResponse()
+	response["key"] = plaintext
+	if signingSecret != "" {
+		response["signing_secret"] = signingSecret
+	}
+	c.JSON(http.StatusCreated, response)
+	return key
+}
+
+// GetAPIKeys returns all API keys of the current user, including project-scoped ones
+func GetAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectAPIKeyAuth(c) {
+			return
+		}
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		keys, err := db.GetAPIKeysByUserID(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(keys))
+		for i, key := range keys {
+			response[i] = key.ToPublicResponse()
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateAPIKey creates an API key with access to the whole account.
+// The plaintext key and signing secret are only returned in this response.
+func CreateAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectAPIKeyAuth(c) {
+			return
+		}
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		if key := issueAPIKey(c, userID, nil, logger); key != nil {
+			logger.WithFields(logrus.Fields{
+				"user_id":    userID,
+				"api_key_id": key.ID,
+			}).Info("Created API key")
+		}
+	}
+}
+
+// RevokeAPIKey revokes one of the current user's API keys
+func RevokeAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectAPIKeyAuth(c) {
+			return
+		}
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		keyID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+			return
+		}
+
+		key, err := db.GetAPIKeyByID(keyID)
+		if err != nil || key.UserID != userID {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "api_key_not_found"))
+			return
+		}
+
+		if err := db.RevokeAPIKey(key.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+	}
+}
@@ -8,19 +8,22 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
 	svix "github.com/svix/svix-webhooks/go"
 )
 
 // ClerkWebhookHandler handles Clerk webhook events
-func ClerkWebhookHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerFunc {
+func ClerkWebhookHandler(cfg *config.Config, clerkWebhooks *jobs.ClerkWebhooks, logger *logrus.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger.Infof("Received webhook request to path: %s", c.Request.URL.Path)
 		
 		// Log headers for debugging
 		logger.Info("Request headers:")
 		for key, values := range c.Request.Header {
-			logger.Infof("  %s: %s", key, strings.Join(values, ", "))
+			logger.Infof("  %s: %v", key, models.RedactField(key, strings.Join(values, ", ")))
 		}
 		
 		// Read and store the request body so we can verify signature and then process it
@@ -28,12 +31,12 @@ func ClerkWebhookHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerF
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
 			logger.Errorf("Error reading webhook body: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_body"))
 			return
 		}
 		
-		// Log the request body for debugging
-		logger.Infof("Request body: %s", string(body))
+		// Log the request body for debugging, without any secrets it contains
+		logger.Infof("Request body: %s", string(models.RedactJSON(body)))
 		
 		// Restore the request body for further processing
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
@@ -84,14 +87,7 @@ func ClerkWebhookHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerF
 			}
 		}
 		
-		// Process the webhook event
-		if err := ProcessWebhookEvent(body, logger); err != nil {
-			logger.Errorf("Error processing webhook event: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"})
-			return
-		}
-		
-		// Return a success response
-		c.JSON(http.StatusOK, gin.H{"message": "Webhook processed successfully"})
+		// Apply the webhook event, or acknowledge it and apply it in the background
+		receiveClerkWebhook(c, clerkWebhooks, body, logger)
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,245 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/backups"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// maxBackupRetention limits how many scheduled backups a schedule keeps
+const maxBackupRetention = 100
+
+// backupScheduleRequest is the body of a request setting an instance's backup schedule
+type backupScheduleRequest struct {
+	Schedule  string `json:"schedule" binding:"required"`
+	Retention *int   `json:"retention"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+// GetInstanceBackups lists the latest backups of an instance with the status of each run
+func GetInstanceBackups() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		limit := 50
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 200 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+				return
+			}
+			limit = parsed
+		}
+
+		list, err := db.GetBackupsByInstanceID(instance.ID, limit)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to get backups")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get backups"})
+			return
+		}
+		response := make([]map[string]interface{}, len(list))
+		for i := range list {
+			response[i] = list[i].ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, gin.H{"backups": response})
+	}
+}
+
+// CreateInstanceBackup queues a manual backup of an instance's volumes. Manual backups are
+// never removed by a schedule's retention.
+func CreateInstanceBackup(runner *jobs.Backups) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+		createBackup(c, runner, instance, logger)
+	}
+}
+
+// createBackup queues a manual backup of an instance unless it has no container yet or a
+// backup of it is still running
+func createBackup(c *gin.Context, runner *jobs.Backups, instance *models.Instance, logger *logrus.Logger) {
+	if instance.ContainerID == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Instance has no container to back up yet", "status": instance.Status})
+		return
+	}
+	active, err := db.HasActiveBackup(instance.ID)
+	if err != nil {
+		logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to check for an unfinished backup")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create backup"})
+		return
+	}
+	if active {
+		c.JSON(http.StatusConflict, gin.H{"error": "A backup of this instance is already in progress"})
+		return
+	}
+
+	backup, err := runner.Create(instance, models.BackupManual)
+	if err != nil {
+		logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to create backup")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create backup"})
+		return
+	}
+	c.JSON(http.StatusAccepted, backup.ToPublicResponse())
+}
+
+// GetBackupDownload returns a presigned URL downloading the archive of a succeeded backup
+func GetBackupDownload(objectStore storage.ObjectStore, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+		backupID, err := uuid.Parse(c.Param("backup_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid backup ID"})
+			return
+		}
+		backup, err := db.GetBackupByID(backupID)
+		if err != nil || backup.InstanceID != instance.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Backup not found"})
+			return
+		}
+		if backup.Status != models.BackupSucceeded {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only succeeded backups can be downloaded", "status": backup.Status})
+			return
+		}
+		if backup.Target != objectStore.Name() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Backup is kept in a storage backend that is no longer configured", "target": backup.Target})
+			return
+		}
+
+		response, err := presignedDownload(objectStore, cfg, backup.Key)
+		if err != nil {
+			logger.WithError(err).WithField("backup_id", backup.ID).Error("Failed to sign backup download URL")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create download URL"})
+			return
+		}
+		response["backup_id"] = backup.ID
+		response["size_bytes"] = backup.SizeBytes
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetInstanceBackupSchedule returns the backup schedule of an instance
+func GetInstanceBackupSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+		schedule, err := db.GetBackupSchedule(instance.ID)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to get backup schedule")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get backup schedule"})
+			return
+		}
+		if schedule == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Instance has no backup schedule"})
+			return
+		}
+		c.JSON(http.StatusOK, schedule.ToPublicResponse())
+	}
+}
+
+// SetInstanceBackupSchedule creates or replaces the backup schedule of an instance. The
+// schedule is hourly, daily, weekly or a five-field cron expression evaluated in UTC.
+func SetInstanceBackupSchedule(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		var req backupScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		parsed, err := backups.ParseSchedule(req.Schedule)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule: " + err.Error()})
+			return
+		}
+		next := parsed.Next(time.Now())
+		if next.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule: it never runs"})
+			return
+		}
+
+		schedule := &models.BackupSchedule{
+			InstanceID: instance.ID,
+			UserID:     instance.UserID,
+			Schedule:   req.Schedule,
+			Retention:  cfg.Backups.DefaultRetention,
+			Enabled:    true,
+			NextRunAt:  &next,
+		}
+		if req.Retention != nil {
+			if *req.Retention < 1 || *req.Retention > maxBackupRetention {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "retention must be between 1 and " + strconv.Itoa(maxBackupRetention)})
+				return
+			}
+			schedule.Retention = *req.Retention
+		}
+		if req.Enabled != nil {
+			schedule.Enabled = *req.Enabled
+		}
+
+		if err := db.SaveBackupSchedule(schedule); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to save backup schedule")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save backup schedule"})
+			return
+		}
+		saved, err := db.GetBackupSchedule(instance.ID)
+		if err != nil || saved == nil {
+			c.JSON(http.StatusOK, schedule.ToPublicResponse())
+			return
+		}
+		c.JSON(http.StatusOK, saved.ToPublicResponse())
+	}
+}
+
+// DeleteInstanceBackupSchedule stops the scheduled backups of an instance; existing backups
+// are kept
+func DeleteInstanceBackupSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+		if err := db.DeleteBackupSchedule(instance.ID); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to delete backup schedule")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete backup schedule"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Backup schedule deleted"})
+	}
+}
@@ -0,0 +1,212 @@
+package routes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Badge colors, as used by shields.io
+const (
+	badgeGreen = "#4c1"
+	badgeRed   = "#e05d44"
+	badgeGrey  = "#9f9f9f"
+	badgeBlue  = "#007ec6"
+)
+
+// badgeWindow is the health check history a badge's uptime is computed over
+const badgeWindow = 24 * time.Hour
+
+// renderedBadge is a badge SVG kept in the badge cache until expiresAt
+type renderedBadge struct {
+	svg       []byte
+	etag      string
+	expiresAt time.Time
+}
+
+// badgeCache holds the rendered badges of instances, so embedding a badge in a busy README
+// does not query the health check history on every view
+type badgeCache struct {
+	mu     sync.Mutex
+	badges map[uuid.UUID]renderedBadge
+}
+
+// get returns the cached badge of an instance if it has not expired
+func (bc *badgeCache) get(instanceID uuid.UUID) (renderedBadge, bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	badge, ok := bc.badges[instanceID]
+	if !ok || time.Now().After(badge.expiresAt) {
+		return renderedBadge{}, false
+	}
+	return badge, true
+}
+
+// put caches the badge of an instance, dropping expired badges along the way
+func (bc *badgeCache) put(instanceID uuid.UUID, badge renderedBadge) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	now := time.Now()
+	for id, cached := range bc.badges {
+		if now.After(cached.expiresAt) {
+			delete(bc.badges, id)
+		}
+	}
+	bc.badges[instanceID] = badge
+}
+
+// RegisterBadgeRoutes registers the public status badge of instances. Badge URLs carry a token
+// signed with BADGE_SIGNING_KEY, so instance IDs alone cannot be used to look up statuses.
+func RegisterBadgeRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	cache := &badgeCache{badges: map[uuid.UUID]renderedBadge{}}
+	router.GET("/badge/:instanceId/status.svg", middleware.RateLimit(cfg.Badges.RateLimit, time.Minute), GetStatusBadge(cfg, cache))
+}
+
+// badgeToken returns the token of an instance's badge URL
+func badgeToken(cfg *config.Config, instanceID uuid.UUID) string {
+	mac := hmac.New(sha256.New, cfg.Badges.SigningKey)
+	mac.Write([]byte(instanceID.String()))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// badgeURL returns the public URL of an instance's status badge
+func badgeURL(cfg *config.Config, instanceID uuid.UUID) string {
+	return fmt.Sprintf("%s/badge/%s/status.svg?token=%s", cfg.Server.BackendURL, instanceID, badgeToken(cfg, instanceID))
+}
+
+// GetStatusBadge serves an SVG badge with an instance's status and its uptime over the last
+// 24 hours of health checks. Unknown instances and invalid tokens get the same 404.
+func GetStatusBadge(cfg *config.Config, cache *badgeCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instanceID, err := uuid.Parse(c.Param("instanceId"))
+		if err != nil || !hmac.Equal([]byte(c.Query("token")), []byte(badgeToken(cfg, instanceID))) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Badge not found"})
+			return
+		}
+
+		badge, ok := cache.get(instanceID)
+		if !ok {
+			instance, err := db.GetInstanceByID(instanceID)
+			if err != nil || instance.Status == models.StatusDeleted || instance.Status == models.StatusDeleting || instance.Status == models.StatusTrashed {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Badge not found"})
+				return
+			}
+			results, err := db.GetProbeResults(instance.ID, time.Now().Add(-badgeWindow))
+			if err != nil {
+				logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to get probe results for status badge")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render badge"})
+				return
+			}
+
+			message, color := badgeStatus(instance.Status, results)
+			svg := badgeSVG("n8n", message, color)
+			sum := sha256.Sum256(svg)
+			badge = renderedBadge{
+				svg:       svg,
+				etag:      `"` + hex.EncodeToString(sum[:8]) + `"`,
+				expiresAt: time.Now().Add(cfg.Badges.CacheTTL),
+			}
+			cache.put(instanceID, badge)
+		}
+
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(cfg.Badges.CacheTTL.Seconds())))
+		c.Header("ETag", badge.etag)
+		if c.GetHeader("If-None-Match") == badge.etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", badge.svg)
+	}
+}
+
+// badgeStatus returns the message and color of a badge. Running instances show their latest
+// health check and the share of reachable checks; others show their status.
+func badgeStatus(status models.InstanceStatus, results []models.ProbeResult) (string, string) {
+	switch status {
+	case models.StatusStopped, models.StatusSleeping, models.StatusSuspended, models.InstanceStatusExpired:
+		return string(status), badgeGrey
+	case models.StatusPending, models.StatusUpgrading:
+		return string(status), badgeBlue
+	case models.StatusError:
+		return "down", badgeRed
+	}
+
+	if len(results) == 0 {
+		return "up", badgeGreen
+	}
+	reachable := 0
+	for _, result := range results {
+		if result.Reachable {
+			reachable++
+		}
+	}
+	uptime := "100%"
+	if reachable < len(results) {
+		uptime = fmt.Sprintf("%.2f%%", float64(reachable)/float64(len(results))*100)
+	}
+	// Results are ordered oldest first
+	if !results[len(results)-1].Reachable {
+		return "down | " + uptime, badgeRed
+	}
+	return "up | " + uptime, badgeGreen
+}
+
+// badgeTextWidth estimates the rendered width of badge text in 11px Verdana
+func badgeTextWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
+
+// badgeSVG renders a flat two-part badge in the style of shields.io
+func badgeSVG(label, message, color string) []byte {
+	labelWidth := badgeTextWidth(label)
+	messageWidth := badgeTextWidth(message)
+	width := labelWidth + messageWidth
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[7]d" y="14">%[4]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[8]d" y="14">%[5]s</text>
+</g>
+</svg>
+`, width, labelWidth, messageWidth, label, message, color, labelWidth/2, labelWidth+messageWidth/2))
+}
+
+// GetInstanceBadge returns the public status badge URL of an instance and a Markdown snippet
+// embedding it
+func GetInstanceBadge(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		url := badgeURL(cfg, instance.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"instance_id": instance.ID,
+			"url":         url,
+			"markdown":    fmt.Sprintf("![%s status](%s)", instance.Name, url),
+		})
+	}
+}
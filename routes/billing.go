@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// maxBillingHistoryMonths caps how many months of metered usage can be requested at once
+const maxBillingHistoryMonths = 36
+
+// RegisterUsageRoutes registers metered usage routes
+func RegisterUsageRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	v1UsageRoutes := router.Group("/api/v1/usage")
+	v1UsageRoutes.GET("/billing", GetBillingUsage(cfg))
+	v1UsageRoutes.GET("/billing/", GetBillingUsage(cfg))
+	v1UsageRoutes.GET("/summary", GetResourceUsageSummary())
+}
+
+// GetBillingUsage returns the current user's metered usage for the current month and the
+// months before it, with this month's charges against their spending cap
+func GetBillingUsage(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		months := 12
+		if value := c.Query("months"); value != "" {
+			months, err = strconv.Atoi(value)
+			if err != nil || months < 1 || months > maxBillingHistoryMonths {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "months must be between 1 and 36"})
+				return
+			}
+		}
+
+		current := models.UsagePeriod(time.Now())
+		from := current.AddDate(0, -(months - 1), 0)
+		records, err := db.GetUsageRecords(user.ID, from, current.AddDate(0, 1, 0))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get usage records"})
+			return
+		}
+
+		// Months without any metered usage are reported as zero
+		currentUsage := models.UsageRecord{UserID: user.ID, Period: current}
+		history := make([]map[string]interface{}, 0, len(records))
+		for i := range records {
+			if records[i].Period.Equal(current) {
+				currentUsage = records[i]
+				continue
+			}
+			history = append(history, records[i].ToPublicResponse())
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"current":  currentUsage.ToPublicResponse(),
+			"history":  history,
+			"spending": models.NewSpending(user, currentUsage, models.UsagePrices(cfg.Billing.UsagePrices)).ToPublicResponse(),
+		})
+	}
+}
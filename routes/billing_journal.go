@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/metrics"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminListBillingEntries returns the newest billing journal entries, optionally of the
+// user given in the user_id query parameter
+func AdminListBillingEntries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userID *uuid.UUID
+		if raw := c.Query("user_id"); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+				return
+			}
+			userID = &id
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit <= 0 || limit > 1000 {
+			limit = 100
+		}
+
+		entries, err := db.GetBillingEntries(userID, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get billing journal"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(entries))
+		for i := range entries {
+			response[i] = entries[i].ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AdminVerifyBillingJournal runs the billing journal integrity check and returns its report
+func AdminVerifyBillingJournal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		report, err := db.VerifyBillingJournal()
+		if err != nil {
+			logger.WithError(err).Error("Failed to check the billing journal")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check the billing journal"})
+			return
+		}
+		metrics.ObserveBillingJournalCheck(report.Discrepancies())
+
+		c.JSON(http.StatusOK, billingJournalReportResponse(report))
+	}
+}
+
+// billingJournalReportResponse returns a journal check report with its verdict
+func billingJournalReportResponse(report *models.BillingJournalReport) gin.H {
+	return gin.H{
+		"ok":                      report.Discrepancies() == 0,
+		"checked_at":              report.CheckedAt,
+		"entries":                 report.Entries,
+		"unbalanced_transactions": report.UnbalancedTransactions,
+		"broken_chain_at":         report.BrokenChainAt,
+		"payment_mismatches":      report.PaymentMismatches,
+		"plan_mismatches":         report.PlanMismatches,
+	}
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// BrandingRequest represents a request to update the white-label settings.
+// Empty fields reset to the deployment's configured default.
+type BrandingRequest struct {
+	ProductName    string `json:"product_name" binding:"max=255"`
+	Domain         string `json:"domain" binding:"omitempty,fqdn"`
+	SupportEmail   string `json:"support_email" binding:"omitempty,email"`
+	LogoURL        string `json:"logo_url" binding:"omitempty,url"`
+	PrimaryColor   string `json:"primary_color" binding:"omitempty,hexcolor"`
+	SecondaryColor string `json:"secondary_color" binding:"omitempty,hexcolor"`
+	AccentColor    string `json:"accent_color" binding:"omitempty,hexcolor"`
+}
+
+// RegisterBrandingRoutes registers the public branding route
+func RegisterBrandingRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	router.GET("/api/v1/branding", GetBranding(cfg))
+}
+
+// defaultBranding returns the branding configured for this deployment
+func defaultBranding(cfg *config.Config) models.Branding {
+	return models.Branding{
+		ProductName:    cfg.Branding.ProductName,
+		Domain:         cfg.Server.Domain,
+		SupportEmail:   cfg.Branding.SupportEmail,
+		LogoURL:        cfg.Branding.LogoURL,
+		PrimaryColor:   cfg.Branding.PrimaryColor,
+		SecondaryColor: cfg.Branding.SecondaryColor,
+		AccentColor:    cfg.Branding.AccentColor,
+	}
+}
+
+// resolveBranding returns the configured branding with any stored overrides applied
+func resolveBranding(cfg *config.Config) (models.Branding, *models.Branding, error) {
+	branding := defaultBranding(cfg)
+
+	overrides, err := db.GetBrandingOverrides()
+	if err != nil {
+		return branding, nil, err
+	}
+	branding.Merge(*overrides)
+
+	return branding, overrides, nil
+}
+
+// GetBranding returns the product name, domain, support email and colors for the frontend
+func GetBranding(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		branding, _, err := resolveBranding(cfg)
+		if err != nil {
+			// Serve the configured defaults rather than breaking the frontend
+			logger.WithError(err).Warn("Failed to load branding overrides, using defaults")
+		}
+
+		c.JSON(http.StatusOK, branding.ToPublicResponse())
+	}
+}
+
+// AdminGetBranding returns the effective branding along with the stored overrides
+func AdminGetBranding(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		branding, overrides, err := resolveBranding(cfg)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get branding settings"})
+			return
+		}
+
+		defaults := defaultBranding(cfg)
+		c.JSON(http.StatusOK, gin.H{
+			"branding":  branding.ToPublicResponse(),
+			"overrides": overrides,
+			"defaults":  defaults.ToPublicResponse(),
+		})
+	}
+}
+
+// AdminUpdateBranding replaces the stored branding overrides
+func AdminUpdateBranding(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		var req BrandingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		overrides := models.Branding{
+			ProductName:    req.ProductName,
+			Domain:         req.Domain,
+			SupportEmail:   req.SupportEmail,
+			LogoURL:        req.LogoURL,
+			PrimaryColor:   req.PrimaryColor,
+			SecondaryColor: req.SecondaryColor,
+			AccentColor:    req.AccentColor,
+		}
+		if err := db.SaveBrandingOverrides(&overrides); err != nil {
+			logger.WithError(err).Error("Failed to save branding settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save branding settings"})
+			return
+		}
+
+		logger.Info("Branding settings updated by admin")
+
+		branding := defaultBranding(cfg)
+		branding.Merge(overrides)
+		c.JSON(http.StatusOK, branding.ToPublicResponse())
+	}
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Whether an instance of a size could be created right now: yes, no because of the user's
+// plan or quota, or waitlist while the host is full
+const (
+	capacityYes      = "yes"
+	capacityNo       = "no"
+	capacityWaitlist = "waitlist"
+)
+
+// capacityOption tells whether the user could create an instance of a size in a region
+type capacityOption struct {
+	Size         string                    `json:"size"`
+	Region       string                    `json:"region"`
+	CPULimit     float64                   `json:"cpu_limit"`
+	MemoryLimit  int                       `json:"memory_limit"`
+	StorageLimit int                       `json:"storage_limit"`
+	Availability string                    `json:"availability"`
+	Reason       string                    `json:"reason,omitempty"` // no_allocation, instance_limit, plan or host_capacity
+	Message      string                    `json:"message,omitempty"`
+	UpgradePlans []models.SubscriptionPlan `json:"upgrade_plans,omitempty"`
+}
+
+// RegisterCapacityRoutes registers the route reporting which instances the user could create
+func RegisterCapacityRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	router.GET("/api/v1/capacity", GetCapacity(cfg))
+}
+
+// GetCapacity reports for each instance size whether the current user could create an
+// instance of it right now, and if not why, so the frontend can explain a disabled create
+// button instead of failing after submit. It applies the same checks as creating an instance.
+func GetCapacity(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		count, err := db.CountInstancesByUserID(user.ID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to count instances for capacity check")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check capacity"})
+			return
+		}
+		capacity, err := container.LoadCapacity(cfg)
+		if err != nil {
+			logger.WithError(err).Error("Failed to load host capacity for capacity check")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check capacity"})
+			return
+		}
+		allocated, err := db.GetAllocatedResources(uuid.Nil)
+		if err != nil {
+			logger.WithError(err).Error("Failed to sum allocated resources for capacity check")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check capacity"})
+			return
+		}
+
+		limit := user.GetInstancesLimit()
+		canCreate := false
+		options := []capacityOption{}
+		for _, size := range models.InstanceSizes() {
+			option := capacityOption{
+				Size:         size.Name,
+				Region:       cfg.Health.Region,
+				CPULimit:     size.CPULimit,
+				MemoryLimit:  size.MemoryLimit,
+				StorageLimit: size.StorageLimit,
+				Availability: capacityYes,
+			}
+			switch {
+			case limit <= 0:
+				option.Availability = capacityNo
+				option.Reason = "no_allocation"
+				option.Message = "Your account has no instance allocation"
+			case int(count) >= limit:
+				option.Availability = capacityNo
+				option.Reason = "instance_limit"
+				option.Message = fmt.Sprintf("You have reached your limit of %d instances", limit)
+			case size.CPULimit > user.GetCPULimit() || size.MemoryLimit > user.GetMemoryLimit() || size.StorageLimit > user.GetStorageLimit():
+				option.Availability = capacityNo
+				option.Reason = "plan"
+				option.Message = "Your plan does not include this instance size"
+				option.UpgradePlans = []models.SubscriptionPlan{size.Plan}
+			case !container.HasCapacity(capacity, allocated, size.CPULimit, size.MemoryLimit):
+				option.Availability = capacityWaitlist
+				option.Reason = "host_capacity"
+				option.Message = "No capacity is available for this size right now, please try again later"
+			default:
+				canCreate = true
+			}
+			options = append(options, option)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"can_create": canCreate,
+			"instances": gin.H{
+				"used":  count,
+				"limit": limit,
+			},
+			"options": options,
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/apierror"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"gorm.io/gorm"
+)
+
+// loadInstance fetches the instance in the :id param and checks the current user may act on
+// it with the permissions of role, see authorizeInstance. It writes the error response and
+// returns nil when the instance is not accessible.
+func loadInstance(c *gin.Context, role models.OrgRole) *models.Instance {
+	instance := fetchInstance(c)
+	if instance == nil || !authorizeInstance(c, instance, role) {
+		return nil
+	}
+	return instance
+}
+
+// loadOperableInstance fetches the instance in the :id param and checks the current user may
+// start, stop, restart or wake it, see authorizeInstanceOperation
+func loadOperableInstance(c *gin.Context) *models.Instance {
+	instance := fetchInstance(c)
+	if instance == nil || !authorizeInstanceOperation(c, instance) {
+		return nil
+	}
+	return instance
+}
+
+// fetchInstance fetches the instance in the :id param. It aborts the request with an error
+// and returns nil when the instance does not exist.
+func fetchInstance(c *gin.Context) *models.Instance {
+	instanceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.InvalidInstanceID))
+		return nil
+	}
+
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.New(apierror.InstanceNotFound))
+			return nil
+		}
+		apierror.Abort(c, fmt.Errorf("failed to fetch instance: %w", err))
+		return nil
+	}
+	return instance
+}
+
+// GetInstanceCertificate returns the TLS certificate status of an instance URL
+func GetInstanceCertificate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		cert, err := db.GetCertificateByInstanceID(instance.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get certificate status"})
+			return
+		}
+
+		// Not checked yet, e.g. a freshly created instance
+		if cert == nil {
+			cert = &models.InstanceCertificate{Domain: instance.URL, Status: models.CertificatePending}
+		}
+
+		c.JSON(http.StatusOK, cert.ToPublicResponse())
+	}
+}
+
+// GetInstanceEvents returns the most recent events of an instance
+func GetInstanceEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		events, err := db.GetInstanceEvents(instance.ID, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instance events"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(events))
+		for i, event := range events {
+			response[i] = event.ToPublicResponse()
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
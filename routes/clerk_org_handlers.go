@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ClerkOrganizationData represents organization data in a Clerk webhook
+type ClerkOrganizationData struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedBy string `json:"created_by"`
+}
+
+// ClerkOrgMembershipData represents organization membership data in a Clerk webhook
+type ClerkOrgMembershipData struct {
+	Role           string                `json:"role"`
+	Organization   ClerkOrganizationData `json:"organization"`
+	PublicUserData struct {
+		UserID string `json:"user_id"`
+	} `json:"public_user_data"`
+}
+
+// clerkOrgRole maps the role of a Clerk organization membership, e.g. "org:admin", to an
+// organization role; custom Clerk roles named after one are mapped to it, others to member
+func clerkOrgRole(role string) models.OrgRole {
+	mapped := models.OrgRole(strings.TrimPrefix(role, "org:"))
+	if mapped.Valid() {
+		return mapped
+	}
+	return models.OrgRoleMember
+}
+
+// handleOrganizationSaved processes organization.created and organization.updated events,
+// mirroring the organization and making its creator its owner
+func handleOrganizationSaved(data json.RawMessage, logger *logrus.Logger) error {
+	var orgData ClerkOrganizationData
+	if err := json.Unmarshal(data, &orgData); err != nil {
+		return fmt.Errorf("failed to parse organization data: %w", err)
+	}
+	if orgData.ID == "" {
+		return fmt.Errorf("missing organization ID in organization data")
+	}
+
+	org, err := saveClerkOrganization(orgData)
+	if err != nil {
+		return err
+	}
+	if orgData.CreatedBy == "" {
+		return nil
+	}
+
+	// The creator may be applied before Clerk's user.created event, which is retried
+	creator, err := db.FindUserByClerkID(orgData.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to find creator of organization: %w", err)
+	}
+	if _, err := db.GetOrgMembership(org.ID, creator.ID); err == nil {
+		return nil
+	}
+	if err := db.SaveOrgMembership(&models.OrgMembership{OrganizationID: org.ID, UserID: creator.ID, Role: models.OrgRoleOwner}); err != nil {
+		return fmt.Errorf("failed to add creator to organization: %w", err)
+	}
+	logger.Infof("Mirrored Clerk organization %s as %s", orgData.ID, org.ID)
+	return nil
+}
+
+// saveClerkOrganization creates or renames the organization mirroring a Clerk organization
+func saveClerkOrganization(orgData ClerkOrganizationData) (*models.Organization, error) {
+	org, err := db.GetOrganizationByClerkID(orgData.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		clerkOrgID := orgData.ID
+		org = &models.Organization{Name: orgData.Name, ClerkOrgID: &clerkOrgID}
+		if err := db.DB.Create(org).Error; err != nil {
+			return nil, fmt.Errorf("failed to create organization: %w", err)
+		}
+		return org, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organization: %w", err)
+	}
+	if orgData.Name != "" && orgData.Name != org.Name {
+		org.Name = orgData.Name
+		if err := db.UpdateOrganization(org); err != nil {
+			return nil, err
+		}
+	}
+	return org, nil
+}
+
+// handleOrganizationDeleted processes organization.deleted events. The organization's
+// instances are kept by the members who created them.
+func handleOrganizationDeleted(data json.RawMessage, logger *logrus.Logger) error {
+	var orgData ClerkOrganizationData
+	if err := json.Unmarshal(data, &orgData); err != nil {
+		return fmt.Errorf("failed to parse deleted organization data: %w", err)
+	}
+
+	org, err := db.GetOrganizationByClerkID(orgData.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Warnf("Organization with Clerk ID %s not found in database, nothing to delete", orgData.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find organization: %w", err)
+	}
+
+	if err := db.DetachOrganizationInstances(org.ID); err != nil {
+		return err
+	}
+	if err := db.DeleteOrganization(org.ID); err != nil {
+		return err
+	}
+	logger.Infof("Deleted organization %s mirroring Clerk organization %s", org.ID, orgData.ID)
+	return nil
+}
+
+// handleOrgMembershipSaved processes organizationMembership.created and
+// organizationMembership.updated events. Owners keep their role while they are Clerk admins.
+func handleOrgMembershipSaved(data json.RawMessage, logger *logrus.Logger) error {
+	var membershipData ClerkOrgMembershipData
+	if err := json.Unmarshal(data, &membershipData); err != nil {
+		return fmt.Errorf("failed to parse organization membership data: %w", err)
+	}
+
+	org, err := saveClerkOrganization(membershipData.Organization)
+	if err != nil {
+		return err
+	}
+	user, err := db.FindUserByClerkID(membershipData.PublicUserData.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to find member of organization: %w", err)
+	}
+
+	role := clerkOrgRole(membershipData.Role)
+	membership, err := db.GetOrgMembership(org.ID, user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		membership = &models.OrgMembership{OrganizationID: org.ID, UserID: user.ID}
+	} else if err != nil {
+		return fmt.Errorf("failed to find organization membership: %w", err)
+	} else if membership.Role == models.OrgRoleOwner && role == models.OrgRoleAdmin {
+		return nil
+	}
+
+	membership.Role = role
+	if err := db.SaveOrgMembership(membership); err != nil {
+		return fmt.Errorf("failed to save organization membership: %w", err)
+	}
+	logger.Infof("User %s is %s of organization %s", user.ID, role, org.ID)
+	return nil
+}
+
+// handleOrgMembershipDeleted processes organizationMembership.deleted events, removing the
+// member even if they were the organization's last owner
+func handleOrgMembershipDeleted(data json.RawMessage, logger *logrus.Logger) error {
+	var membershipData ClerkOrgMembershipData
+	if err := json.Unmarshal(data, &membershipData); err != nil {
+		return fmt.Errorf("failed to parse organization membership data: %w", err)
+	}
+
+	org, err := db.GetOrganizationByClerkID(membershipData.Organization.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find organization: %w", err)
+	}
+	user, err := db.FindUserByClerkID(membershipData.PublicUserData.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find member of organization: %w", err)
+	}
+
+	if err := db.DeleteOrgMembership(org.ID, user.ID); err != nil {
+		return err
+	}
+	logger.Infof("Removed user %s from organization %s", user.ID, org.ID)
+	return nil
+}
@@ -2,25 +2,29 @@ package routes
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
 	svix "github.com/svix/svix-webhooks/go"
 )
 
 // WebhookHandler handles incoming Clerk webhook events
-func WebhookHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerFunc {
+func WebhookHandler(cfg *config.Config, clerkWebhooks *jobs.ClerkWebhooks, logger *logrus.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger.Infof("Received webhook request to path: %s", c.Request.URL.Path)
 		
 		// Log headers for debugging
 		logger.Info("Request headers:")
 		for key, values := range c.Request.Header {
-			logger.Infof("  %s: %s", key, strings.Join(values, ", "))
+			logger.Infof("  %s: %v", key, models.RedactField(key, strings.Join(values, ", ")))
 		}
 		
 		// Read and store the request body so we can verify signature and then process it
@@ -28,12 +32,12 @@ func WebhookHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerFunc {
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
 			logger.Errorf("Error reading webhook body: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_body"))
 			return
 		}
 		
-		// Log the request body for debugging
-		logger.Infof("Request body: %s", string(body))
+		// Log the request body for debugging, without any secrets it contains
+		logger.Infof("Request body: %s", string(models.RedactJSON(body)))
 		
 		// Restore the request body for further processing
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
@@ -84,26 +88,43 @@ func WebhookHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerFunc {
 			}
 		}
 		
-		// Process the webhook event
-		if err := ProcessWebhookEvent(body, logger); err != nil {
-			logger.Errorf("Error processing webhook event: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"})
-			return
-		}
-		
-		// Return a success response
-		c.JSON(http.StatusOK, gin.H{"message": "Webhook processed successfully"})
+		// Apply the webhook event, or acknowledge it and apply it in the background
+		receiveClerkWebhook(c, clerkWebhooks, body, logger)
+	}
+}
+
+// receiveClerkWebhook hands a verified Clerk webhook delivery to the processor and answers it:
+// 200 once its event is applied, or 202 when the event is applied in the background
+func receiveClerkWebhook(c *gin.Context, clerkWebhooks *jobs.ClerkWebhooks, body []byte, logger *logrus.Logger) {
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		logger.Errorf("Failed to parse webhook event: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_body"))
+		return
+	}
+	
+	// Redeliveries of an event carry the same svix-id
+	status, err := clerkWebhooks.Receive(c.GetHeader("svix-id"), event.Type, body)
+	if err != nil {
+		logger.Errorf("Error processing webhook event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"})
+		return
+	}
+	if status != models.WebhookEventProcessed {
+		c.JSON(http.StatusAccepted, gin.H{"message": "Webhook accepted", "status": status})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook processed successfully"})
 }
 
 // RegisterClerkWebhookRoutes registers all webhook routes
-func RegisterClerkWebhookRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+func RegisterClerkWebhookRoutes(router *gin.Engine, cfg *config.Config, clerkWebhooks *jobs.ClerkWebhooks, logger *logrus.Logger) {
 	logger.Info("Registering Clerk webhook routes")
 	
 	// Register the webhook handler under the /api/v1/webhooks/clerk path
 	webhookGroup := router.Group("/api/v1/webhooks")
 	{
-		webhookGroup.POST("/clerk", WebhookHandler(cfg, logger))
+		webhookGroup.POST("/clerk", WebhookHandler(cfg, clerkWebhooks, logger))
 	}
 	
 	logger.Info("Clerk webhook routes registered successfully")
@@ -1,16 +1,20 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
 	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/payments"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -46,8 +50,17 @@ type ClerkEmailAddress struct {
 	} `json:"verification"`
 }
 
+// ClerkEventHandler returns the handler applying Clerk webhook events, deleting the instances
+// of deleted users with instanceJobs and canceling their subscriptions with provider, which is
+// nil when payments are disabled
+func ClerkEventHandler(instanceJobs *jobs.InstanceJobs, provider payments.Provider, logger *logrus.Logger) jobs.ClerkEventHandler {
+	return func(ctx context.Context, body []byte) error {
+		return ProcessWebhookEvent(ctx, body, instanceJobs, provider, logger)
+	}
+}
+
 // ProcessWebhookEvent processes different Clerk webhook events
-func ProcessWebhookEvent(eventBody []byte, logger *logrus.Logger) error {
+func ProcessWebhookEvent(ctx context.Context, eventBody []byte, instanceJobs *jobs.InstanceJobs, provider payments.Provider, logger *logrus.Logger) error {
 	var event WebhookEvent
 	if err := json.Unmarshal(eventBody, &event); err != nil {
 		logger.Errorf("Failed to parse webhook event: %v", err)
@@ -89,7 +102,15 @@ func ProcessWebhookEvent(eventBody []byte, logger *logrus.Logger) error {
 	case "user.updated":
 		return handleUserUpdated(event.Data, logger)
 	case "user.deleted":
-		return handleUserDeleted(event.Data, logger)
+		return handleUserDeleted(ctx, event.Data, instanceJobs, provider, logger)
+	case "organization.created", "organization.updated":
+		return handleOrganizationSaved(event.Data, logger)
+	case "organization.deleted":
+		return handleOrganizationDeleted(event.Data, logger)
+	case "organizationMembership.created", "organizationMembership.updated":
+		return handleOrgMembershipSaved(event.Data, logger)
+	case "organizationMembership.deleted":
+		return handleOrgMembershipDeleted(event.Data, logger)
 	default:
 		logger.Infof("Unhandled event type: %s", event.Type)
 		return nil
@@ -151,6 +172,23 @@ func handleUserCreated(data json.RawMessage, logger *logrus.Logger) error {
 		return nil
 	}
 
+	// Link accounts created by a reseller to the Clerk user on first sign-up
+	if pendingUser, err := db.FindPendingUserByEmail(primaryEmail); err == nil {
+		pendingUser.ClerkUserID = userData.ID
+		if userData.FirstName != "" {
+			pendingUser.FirstName = userData.FirstName
+		}
+		if userData.LastName != "" {
+			pendingUser.LastName = userData.LastName
+		}
+		if err := db.DB.Save(&pendingUser).Error; err != nil {
+			logger.Errorf("Failed to link sub-account to Clerk user: %v", err)
+			return err
+		}
+		logger.Infof("Linked sub-account %s to Clerk ID %s", pendingUser.ID, userData.ID)
+		return nil
+	}
+
 	// Create a new user in our database
 	user := &models.User{
 		ID:            uuid.New(),
@@ -164,18 +202,37 @@ func handleUserCreated(data json.RawMessage, logger *logrus.Logger) error {
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
+	// New users start on the trial of the sign-up trial plan, if one is configured
+	user.StartSignupTrial()
 
 	// Log the data we're about to save
 	logger.Infof("Creating new user from Clerk: ID=%s, Email=%s, Name=%s %s, Username=%s", 
 		user.ClerkUserID, user.Email, user.FirstName, user.LastName, user.Username)
 
-	// Save to database
-	if err := db.DB.Create(user).Error; err != nil {
+	// Save to database; a concurrent event for the same user may have created it meanwhile
+	created, err := db.CreateClerkUser(user)
+	if err != nil {
 		logger.Errorf("Failed to create user in database: %v", err)
 		return err
 	}
+	if !created {
+		logger.Warnf("User with Clerk ID %s was created concurrently, skipping creation", userData.ID)
+		return nil
+	}
 
 	logger.Infof("Created new user in database: ID=%s, Clerk ID=%s", user.ID, user.ClerkUserID)
+
+	// Journal the trial, so the user is not offered another one at checkout
+	if user.SubscriptionStatus == models.StatusTrial {
+		days := int64(math.Ceil(time.Until(user.CurrentPeriodEnd).Hours() / 24))
+		reference := fmt.Sprintf("user:%s:signup_trial", user.ID)
+		description := fmt.Sprintf("%d day %s trial on sign-up", days, user.Plan)
+		if _, err := db.RecordTrialGrant(db.DB, user.ID, days, reference, description); err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to journal sign-up trial")
+		} else {
+			logger.Infof("Started %d day %s trial for user %s", days, user.Plan, user.ID)
+		}
+	}
 	return nil
 }
 
@@ -213,7 +270,15 @@ func handleUserUpdated(data json.RawMessage, logger *logrus.Logger) error {
 		
 		// If user doesn't exist, create them (treating this as a user.created event)
 		logger.Infof("User not found, creating instead: %s", userData.ID)
-		return handleUserCreated(data, logger)
+		if err := handleUserCreated(data, logger); err != nil {
+			return err
+		}
+
+		// A concurrent user.created event may have created the user from older data, so the
+		// update is applied to whichever user now exists
+		if err := db.DB.Where("clerk_user_id = ?", userData.ID).First(&user).Error; err != nil {
+			return err
+		}
 	}
 
 	// Log the update
@@ -244,13 +309,16 @@ func handleUserUpdated(data json.RawMessage, logger *logrus.Logger) error {
 		logger.Errorf("Failed to update user in database: %v", err)
 		return err
 	}
+	db.InvalidateCachedUser(user.ClerkUserID)
 
 	logger.Infof("Updated user in database: ID=%s, Clerk ID=%s", user.ID, user.ClerkUserID)
 	return nil
 }
 
-// handleUserDeleted processes user.deleted events
-func handleUserDeleted(data json.RawMessage, logger *logrus.Logger) error {
+// handleUserDeleted processes user.deleted events, canceling the user's subscription and
+// deleting their instances before soft-deleting them. The AccountPurger deletes the rest of
+// their data once the grace period of ACCOUNT_DELETION_GRACE_PERIOD ends.
+func handleUserDeleted(ctx context.Context, data json.RawMessage, instanceJobs *jobs.InstanceJobs, provider payments.Provider, logger *logrus.Logger) error {
 	// For user.deleted events, the data structure is different
 	var deletedUserData struct {
 		ID      string `json:"id"`
@@ -295,16 +363,97 @@ func handleUserDeleted(data json.RawMessage, logger *logrus.Logger) error {
 		return result.Error
 	}
 
+	// Stop billing and tear down the user's instances first, so a retry still finds the user
+	// to finish them
+	if err := cancelDeletedUserSubscription(ctx, &user, provider, logger); err != nil {
+		return err
+	}
+	if err := deleteUserInstances(user.ID, instanceJobs, logger); err != nil {
+		return err
+	}
+
 	// Soft delete the user
 	if err := db.DB.Delete(&user).Error; err != nil {
 		logger.Errorf("Failed to delete user from database: %v", err)
 		return err
 	}
+	db.InvalidateCachedUser(user.ClerkUserID)
 
 	logger.Infof("Successfully deleted user: ID=%s, Clerk ID=%s", user.ID, user.ClerkUserID)
 	return nil
 }
 
+// cancelDeletedUserSubscription cancels the subscription of a deleted user with the payment
+// provider managing it and records it as canceled. Subscriptions of another provider than the
+// configured one are left to be canceled by hand.
+func cancelDeletedUserSubscription(ctx context.Context, user *models.User, provider payments.Provider, logger *logrus.Logger) error {
+	if user.SubscriptionID == "" || user.SubscriptionStatus == models.StatusCanceled || user.SubscriptionStatus == models.StatusExpired {
+		return nil
+	}
+	entry := logger.WithFields(logrus.Fields{"user_id": user.ID, "subscription_id": user.SubscriptionID})
+
+	// Subscriptions created before provider support was added are PayPal subscriptions
+	subscriptionProvider := user.SubscriptionProvider
+	if subscriptionProvider == "" {
+		subscriptionProvider = "paypal"
+	}
+	if provider == nil || subscriptionProvider != provider.Name() {
+		entry.WithField("provider", subscriptionProvider).Warn("Subscription of deleted user is not managed by the configured payment provider, cancel it by hand")
+		return nil
+	}
+
+	if err := provider.CancelSubscription(ctx, user.SubscriptionID); err != nil {
+		return fmt.Errorf("failed to cancel subscription of deleted user: %w", err)
+	}
+	user.SubscriptionStatus = models.StatusCanceled
+	if err := db.DB.Model(user).Update("subscription_status", models.StatusCanceled).Error; err != nil {
+		return fmt.Errorf("failed to record canceled subscription: %w", err)
+	}
+	db.InvalidateCachedUser(user.ClerkUserID)
+	entry.Info("Canceled subscription of deleted user")
+	return nil
+}
+
+// deleteUserInstances queues the deletion of every instance of a user. Instances in the middle
+// of being provisioned or upgraded are left for a retry once that finishes.
+func deleteUserInstances(userID uuid.UUID, instanceJobs *jobs.InstanceJobs, logger *logrus.Logger) error {
+	instances, err := db.GetInstancesByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get instances of deleted user: %w", err)
+	}
+
+	busy := 0
+	for i := range instances {
+		instance := &instances[i]
+		switch instance.Status {
+		case models.StatusDeleting:
+			continue
+		case models.StatusPending, models.StatusUpgrading:
+			busy++
+			continue
+		}
+
+		previousStatus := instance.Status
+		instance.Status = models.StatusDeleting
+		if err := db.UpdateInstance(instance); err != nil {
+			return fmt.Errorf("failed to update instance status: %w", err)
+		}
+		if _, err := instanceJobs.Delete(instance); err != nil {
+			instance.Status = previousStatus
+			if err := db.UpdateInstance(instance); err != nil {
+				logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to restore instance status")
+			}
+			return fmt.Errorf("failed to queue instance deletion: %w", err)
+		}
+		logger.WithField("instance_id", instance.ID).Info("Queued deletion of deleted user's instance")
+	}
+
+	if busy > 0 {
+		return fmt.Errorf("%d instances of the deleted user are being provisioned or upgraded", busy)
+	}
+	return nil
+}
+
 // generateUsername creates a username from the user's first name or email
 func generateUsername(email, firstName, lastName string) string {
 	// First choice: use firstName if available
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportInstanceCompose returns a docker-compose.yml equivalent of an instance for running it
+// outside the platform, with a download link of its newest backup as the data to bring along.
+// With format=yaml only the file is sent, as an attachment.
+func ExportInstanceCompose(containerManager container.Manager, objectStore storage.ObjectStore, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		template := containerManager.Templates().ForInstance(instance)
+		compose := container.ComposeFile(template, instance, time.Now())
+		c.Header("Cache-Control", "no-store")
+		if c.Query("format") == "yaml" {
+			c.Header("Content-Disposition", "attachment; filename=\"docker-compose.yml\"")
+			c.Data(http.StatusOK, "application/yaml", compose)
+			return
+		}
+
+		backup, err := db.GetLatestSucceededBackup(instance.ID, objectStore.Name())
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to get latest backup for compose export")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export instance"})
+			return
+		}
+		var data gin.H
+		if backup != nil {
+			data, err = presignedDownload(objectStore, cfg, backup.Key)
+			if err != nil {
+				logger.WithError(err).WithField("backup_id", backup.ID).Error("Failed to sign backup download URL")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create download URL"})
+				return
+			}
+			data["backup_id"] = backup.ID
+			data["backup_created_at"] = backup.CreatedAt
+			data["size_bytes"] = backup.SizeBytes
+		}
+
+		_, secrets := container.ComposeEnv(instance)
+		instructions := []string{
+			"Save compose as docker-compose.yml in an empty directory.",
+			"Download data_download.download_url into the same directory and extract it with: tar -xzf <archive>.tar.gz",
+		}
+		if template.Workflows {
+			instructions = append(instructions,
+				"Give n8n's user ownership of the extracted directories: sudo chown -R 1000:1000 data files",
+				"Set N8N_HOST, N8N_PROTOCOL and WEBHOOK_URL in a .env file to the address the instance will be reached at.")
+		}
+		if len(secrets) > 0 {
+			instructions = append(instructions, "Provide the secret variables in the .env file; the basic auth password is returned by GET /api/v1/instances/"+instance.ID.String()+"/credentials.")
+		}
+		instructions = append(instructions, "Start "+template.Name+" with: docker compose up -d")
+		if backup == nil {
+			instructions[1] = "Create a backup with POST /api/v1/instances/" + instance.ID.String() + "/backups and export again once it succeeded, to download the instance's data."
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"instance_id":      instance.ID,
+			"image":            template.ImageFor(instance.ImageTag),
+			"compose":          string(compose),
+			"secret_variables": secrets,
+			"data_download":    data,
+			"instructions":     instructions,
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/qrcode"
+	"github.com/sirupsen/logrus"
+)
+
+// qrCodeScale is the size of a QR code module in pixels
+const qrCodeScale = 8
+
+// GetInstanceConnectionInfo returns what clients need to connect to an instance: its URL, the
+// basic auth user, the base URLs of its webhooks and hints for n8n's public API. With qr=true
+// a QR code PNG of the URL is included for opening the instance on a phone. The password is
+// left out; it is returned by GET /instances/:id/credentials.
+func GetInstanceConnectionInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+		if instance.URL == "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Instance has no URL yet", "status": instance.Status})
+			return
+		}
+
+		// Instances created before credentials were stored use their host as the user
+		username := instance.Host
+		if credential, err := db.GetInstanceCredential(instance.ID); err == nil {
+			username = credential.Username
+		}
+
+		url := "https://" + strings.TrimSuffix(instance.URL, "/")
+		info := gin.H{
+			"instance_id":           instance.ID,
+			"name":                  instance.Name,
+			"status":                instance.Status,
+			"url":                   url,
+			"basic_auth_user":       username,
+			"webhook_base_url":      url + "/webhook/",
+			"webhook_test_base_url": url + "/webhook-test/",
+			"api": gin.H{
+				"base_url":             url + "/api/v1",
+				"auth_header":          "X-N8N-API-KEY",
+				"key_hint":             "Create an API key in n8n under Settings > n8n API",
+				"docs_url":             "https://docs.n8n.io/api/",
+				"credentials_endpoint": "/api/v1/instances/" + instance.ID.String() + "/credentials",
+			},
+		}
+
+		if c.Query("qr") == "true" {
+			code, err := qrcode.Encode(url)
+			var image []byte
+			if err == nil {
+				image, err = code.PNG(qrCodeScale)
+			}
+			if err != nil {
+				logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to generate QR code")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+				return
+			}
+			info["qr_code"] = "data:image/png;base64," + base64.StdEncoding.EncodeToString(image)
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, info)
+	}
+}
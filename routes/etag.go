@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionETag returns a strong ETag identifying a response by the values it is built from.
+// Values are formatted with %v, so pointers must be dereferenced or implement fmt.Stringer.
+func versionETag(values ...interface{}) string {
+	h := sha256.New()
+	for _, value := range values {
+		fmt.Fprintf(h, "%v\n", value)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:8]) + `"`
+}
+
+// notModified sets the ETag of a response and, if the client's If-None-Match header has it
+// already, responds with 304 Not Modified and returns true. Clients must revalidate every time,
+// as the response can change at any moment.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		// Weak comparison, as proxies may weaken ETags when compressing responses
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
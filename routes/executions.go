@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// GetInstanceExecutions returns the workflow executions an instance reported over a time
+// window, with success and failure counts and duration stats in total and per workflow
+func GetInstanceExecutions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+		if err != nil || hours <= 0 || hours > 720 {
+			hours = 24
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		workflowID := c.Query("workflow_id")
+		since := time.Now().Add(-time.Duration(hours) * time.Hour)
+
+		stats, workflows, err := db.GetWorkflowExecutionStats(instance.ID, workflowID, since)
+		if err != nil {
+			logger.WithError(err).Error("Failed to get workflow execution stats")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get workflow executions"})
+			return
+		}
+		executions, err := db.GetWorkflowExecutions(instance.ID, workflowID, since, limit)
+		if err != nil {
+			logger.WithError(err).Error("Failed to get workflow executions")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get workflow executions"})
+			return
+		}
+
+		recent := make([]map[string]interface{}, len(executions))
+		for i := range executions {
+			recent[i] = executions[i].ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"instance_id":  instance.ID,
+			"window_hours": hours,
+			"stats":        stats,
+			"workflows":    workflows,
+			"executions":   recent,
+		})
+	}
+}
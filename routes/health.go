@@ -1,12 +1,16 @@
 package routes
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"net/url"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
 	"github.com/launchstack/backend/db"
 	"github.com/sirupsen/logrus"
 )
@@ -23,6 +27,7 @@ type HealthResponse struct {
 	Database    struct {
 		Status       string        `json:"status"`
 		ResponseTime time.Duration `json:"response_time_ms"`
+		Pool         *db.PoolStats `json:"pool,omitempty"`
 	} `json:"database"`
 	System struct {
 		MemoryUsage float64 `json:"memory_usage_mb"`
@@ -48,14 +53,17 @@ func HealthCheck(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		// Check database connection with timing
-		dbStartTime := time.Now()
-		if err := db.DB.Exec("SELECT 1").Error; err != nil {
+		pingTime, err := db.Ping(c.Request.Context())
+		if err != nil {
 			response.Database.Status = "error"
 			response.Status = "degraded"
 		} else {
 			response.Database.Status = "ok"
 		}
-		response.Database.ResponseTime = time.Since(dbStartTime).Round(time.Millisecond)
+		response.Database.ResponseTime = pingTime.Round(time.Millisecond)
+		if pool, err := db.GetPoolStats(); err == nil {
+			response.Database.Pool = &pool
+		}
 
 		// Get system metrics
 		var memStats runtime.MemStats
@@ -94,16 +102,20 @@ func HealthCheckHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerFu
 			Timestamp:   time.Now(),
 		}
 
-		// Check database connection with timing
-		dbStartTime := time.Now()
-		if err := db.DB.Exec("SELECT 1").Error; err != nil {
+		// Check database connection with timing; the ping is bounded, so an exhausted pool or
+		// an unreachable server reports an error instead of hanging the check
+		pingTime, err := db.Ping(c.Request.Context())
+		if err != nil {
 			response.Database.Status = "error"
 			response.Status = "degraded"
 			logger.Errorf("Health check - database connection failed: %v", err)
 		} else {
 			response.Database.Status = "ok"
 		}
-		response.Database.ResponseTime = time.Since(dbStartTime).Round(time.Millisecond)
+		response.Database.ResponseTime = pingTime.Round(time.Millisecond)
+		if pool, err := db.GetPoolStats(); err == nil {
+			response.Database.Pool = &pool
+		}
 
 		// Get system metrics
 		var memStats runtime.MemStats
@@ -125,4 +137,49 @@ func HealthCheckHandler(cfg *config.Config, logger *logrus.Logger) gin.HandlerFu
 		logger.Infof("Health check executed: status=%s, response_time=%s", response.Status, response.ResponseTime)
 		c.JSON(statusCode, response)
 	}
-}
\ No newline at end of file
+}
+// ProbeRequest represents a request from another LaunchStack host to probe an instance
+type ProbeRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// ProbeHandler lets this host act as a remote probe agent for the health monitors of other
+// hosts, reporting whether an instance's public health endpoint answers from here
+func ProbeHandler(cfg *config.Config, containerManager container.Manager, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Health.ProbeToken == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Probe agent is not enabled"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Probe-Token")), []byte(cfg.Health.ProbeToken)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid probe token"})
+			return
+		}
+
+		var req ProbeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		// Only instance health endpoints may be probed, so the agent cannot be used to reach arbitrary hosts
+		target, err := url.Parse(req.URL)
+		if err != nil || (target.Scheme != "https" && target.Scheme != "http") ||
+			!strings.HasSuffix(target.Hostname(), "."+cfg.Server.Domain) || !containerManager.Templates().IsHealthPath(target.Path) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "URL must be an instance health endpoint"})
+			return
+		}
+
+		latency, err := container.ProbeURL(c.Request.Context(), target.String())
+		response := gin.H{
+			"region":     cfg.Health.Region,
+			"reachable":  err == nil,
+			"latency_ms": latency.Milliseconds(),
+		}
+		if err != nil {
+			logger.WithField("url", target.String()).Debugf("Remote probe failed: %v", err)
+			response["error"] = err.Error()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
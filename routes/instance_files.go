@@ -0,0 +1,200 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// maxFileListEntries is the most entries a directory listing of a files volume returns
+const maxFileListEntries = 1000
+
+// GetInstanceFile lists a directory of an instance's files volume, or downloads a file of it,
+// depending on what the path in the URL is
+func GetInstanceFile(cfg *config.Config, containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadFilesInstance(c)
+		if instance == nil {
+			return
+		}
+
+		entry, err := containerManager.StatInstanceFile(c.Request.Context(), instance.ID, c.Param("path"))
+		if err != nil {
+			respondFileError(c, logger, instance, err)
+			return
+		}
+
+		switch entry.Type {
+		case "directory":
+			entries, truncated, err := containerManager.ListInstanceFiles(c.Request.Context(), instance.ID, entry.Path, maxFileListEntries)
+			if err != nil {
+				respondFileError(c, logger, instance, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"path":      entry.Path,
+				"entries":   entries,
+				"truncated": truncated,
+			})
+			return
+		case "symlink":
+			c.JSON(http.StatusConflict, gin.H{"error": "Symlinks cannot be downloaded"})
+			return
+		}
+
+		if entry.Size > int64(cfg.Files.MaxSizeMB)*megabyte {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Files larger than %d MB cannot be downloaded", cfg.Files.MaxSizeMB)})
+			return
+		}
+
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", entry.Name))
+		c.Header("Content-Length", fmt.Sprint(entry.Size))
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Cache-Control", "no-store")
+		if err := containerManager.ReadInstanceFile(c.Request.Context(), instance.ID, entry.Path, c.Writer); err != nil {
+			// Once content was sent the status is out, so the download just ends
+			if !c.Writer.Written() {
+				c.Header("Content-Disposition", "")
+				c.Header("Content-Length", "")
+				respondFileError(c, logger, instance, err)
+				return
+			}
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to download instance file")
+		}
+	}
+}
+
+// PutInstanceFile uploads the request body as a file of an instance's files volume, replacing
+// the file at the path and creating missing directories. The body needs a Content-Length, and
+// must fit the instance's storage limit.
+func PutInstanceFile(cfg *config.Config, containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadFilesInstance(c)
+		if instance == nil {
+			return
+		}
+
+		size := c.Request.ContentLength
+		if size < 0 {
+			c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required"})
+			return
+		}
+		if size > int64(cfg.Files.MaxSizeMB)*megabyte {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Files larger than %d MB cannot be uploaded", cfg.Files.MaxSizeMB)})
+			return
+		}
+		if instance.StorageLimit > 0 {
+			usage, err := db.GetLatestResourceUsage(instance.ID)
+			if err == nil && usage.DiskUsage+size > int64(instance.StorageLimit)*gigabyte {
+				c.JSON(http.StatusInsufficientStorage, gin.H{"error": fmt.Sprintf("The file does not fit the instance's storage limit of %d GB", instance.StorageLimit)})
+				return
+			}
+		}
+
+		_, err := containerManager.StatInstanceFile(c.Request.Context(), instance.ID, c.Param("path"))
+		created := errors.Is(err, container.ErrFileNotFound)
+		if err != nil && !created {
+			respondFileError(c, logger, instance, err)
+			return
+		}
+
+		if err := containerManager.WriteInstanceFile(c.Request.Context(), instance.ID, c.Param("path"), c.Request.Body, size); err != nil {
+			respondFileError(c, logger, instance, err)
+			return
+		}
+		entry, err := containerManager.StatInstanceFile(c.Request.Context(), instance.ID, c.Param("path"))
+		if err != nil {
+			respondFileError(c, logger, instance, err)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"instance_id": instance.ID,
+			"path":        entry.Path,
+			"size":        entry.Size,
+		}).Info("Uploaded instance file")
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, entry)
+	}
+}
+
+// DeleteInstanceFile removes a file or directory tree of a running instance's files volume
+func DeleteInstanceFile(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadFilesInstance(c)
+		if instance == nil {
+			return
+		}
+		// Files are removed by a command run in the container
+		if instance.Status != models.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only running instances can delete files", "status": instance.Status})
+			return
+		}
+		if path, err := container.CleanFilePath(c.Param("path")); err == nil && path == "/" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The root of the files volume cannot be deleted"})
+			return
+		}
+
+		if err := containerManager.DeleteInstanceFile(c.Request.Context(), instance.ID, c.Param("path")); err != nil {
+			respondFileError(c, logger, instance, err)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"instance_id": instance.ID,
+			"path":        c.Param("path"),
+		}).Info("Deleted instance file")
+		c.JSON(http.StatusOK, gin.H{"message": "File deleted"})
+	}
+}
+
+// loadFilesInstance fetches the instance in the :id param for the file browser, which needs
+// the member role and a container
+func loadFilesInstance(c *gin.Context) *models.Instance {
+	instance := loadInstance(c, models.OrgRoleMember)
+	if instance == nil {
+		return nil
+	}
+	if instance.ContainerID == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Instance has no container", "status": instance.Status})
+		return nil
+	}
+	return instance
+}
+
+// respondFileError writes the response to a failed file browser operation
+func respondFileError(c *gin.Context, logger *logrus.Logger, instance *models.Instance, err error) {
+	switch {
+	case errors.Is(err, container.ErrServiceUnsupported):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, "service_unsupported"))
+	case errors.Is(err, container.ErrFileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+	case errors.Is(err, container.ErrInvalidFilePath):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file path"})
+	case errors.Is(err, container.ErrIsDirectory):
+		c.JSON(http.StatusConflict, gin.H{"error": "Path is a directory"})
+	case errors.Is(err, container.ErrNotDirectory):
+		c.JSON(http.StatusConflict, gin.H{"error": "Path is not a directory"})
+	default:
+		logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to access instance files")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to access the instance's files"})
+	}
+}
@@ -0,0 +1,187 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Smallest resource limits n8n runs with
+const (
+	minInstanceCPU      = 0.1
+	minInstanceMemoryMB = 256
+)
+
+// InstanceResourcesRequest represents a change of an instance's CPU and memory limits. Omitted
+// limits are kept; reset returns the instance to the limits of its owner's plan.
+type InstanceResourcesRequest struct {
+	CPULimit    *float64 `json:"cpu_limit"`
+	MemoryLimit *int     `json:"memory_limit"` // in MB
+	Reset       bool     `json:"reset"`
+}
+
+// UpdateInstanceResources changes the CPU and memory limits of the current user's instance,
+// up to the per-instance limits of their plan. Requires the resource_overrides feature.
+func UpdateInstanceResources(cfg *config.Config, containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		resizeInstanceResources(c, cfg, containerManager, user, instance, user.GetCPULimit(), user.GetMemoryLimit(), true)
+	}
+}
+
+// AdminUpdateInstanceResources changes the CPU and memory limits of any instance. The limits of
+// the owner's plan do not apply, only the host capacity.
+func AdminUpdateInstanceResources(cfg *config.Config, containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+		instance, err := db.GetInstanceByID(instanceID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching instance"})
+			return
+		}
+		owner, err := db.GetUserByID(instance.UserID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		resizeInstanceResources(c, cfg, containerManager, owner, instance, 0, 0, false)
+	}
+}
+
+// resizeInstanceResources applies a resources request to an instance owned by owner. Limits
+// above maxCPU or maxMemoryMB are refused unless they are 0, and with capSpending so are
+// increases once the owner reached their spending cap. The running container is updated in
+// place and only recreated if the runtime rejects that.
+func resizeInstanceResources(c *gin.Context, cfg *config.Config, containerManager container.Manager, owner models.User, instance *models.Instance, maxCPU float64, maxMemoryMB int, capSpending bool) {
+	logger := c.MustGet("logger").(*logrus.Logger)
+
+	var req InstanceResourcesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if !req.Reset && req.CPULimit == nil && req.MemoryLimit == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set cpu_limit, memory_limit or reset"})
+		return
+	}
+
+	switch instance.Status {
+	case models.StatusTrashed, models.StatusDeleting, models.StatusDeleted, models.StatusUpgrading:
+		c.JSON(http.StatusConflict, gin.H{"error": "Instance resources cannot be changed now", "status": instance.Status})
+		return
+	}
+
+	cpuLimit, memoryLimit := instance.CPULimit, instance.MemoryLimit
+	if req.Reset {
+		plain := *instance
+		plain.ResourceOverride = false
+		target := planTargetLimits(owner, plain, map[uuid.UUID]*models.Project{})
+		cpuLimit, memoryLimit = target.CPULimit, target.MemoryLimit
+	} else {
+		if req.CPULimit != nil {
+			cpuLimit = *req.CPULimit
+		}
+		if req.MemoryLimit != nil {
+			memoryLimit = *req.MemoryLimit
+		}
+		if cpuLimit < minInstanceCPU || (maxCPU > 0 && cpuLimit > maxCPU) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": resourceRangeError("cpu_limit", minInstanceCPU, maxCPU), "max_cpu_limit": maxCPU})
+			return
+		}
+		if memoryLimit < minInstanceMemoryMB || (maxMemoryMB > 0 && memoryLimit > maxMemoryMB) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": resourceRangeError("memory_limit", minInstanceMemoryMB, float64(maxMemoryMB)), "max_memory_limit": maxMemoryMB})
+			return
+		}
+	}
+
+	if capSpending && owner.SpendingCap > 0 && (cpuLimit > instance.CPULimit || memoryLimit > instance.MemoryLimit) {
+		spending, err := db.GetUserSpending(owner, models.UsagePrices(cfg.Billing.UsagePrices))
+		if err != nil {
+			logger.WithError(err).WithField("user_id", owner.ID).Warn("Failed to check spending cap")
+		} else if spending.Blocked() {
+			c.JSON(http.StatusPaymentRequired, middleware.SpendingCapError(c, spending))
+			return
+		}
+	}
+
+	if cpuLimit != instance.CPULimit || memoryLimit != instance.MemoryLimit {
+		err := container.CheckResize(cfg, instance, cpuLimit, memoryLimit)
+		if errors.Is(err, container.ErrHostAtCapacity) {
+			c.JSON(http.StatusServiceUnavailable, middleware.ErrorBody(c, "host_at_capacity"))
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to check capacity for resize")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change instance resources"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		recreated, err := containerManager.ResizeInstance(ctx, instance.ID, cpuLimit, memoryLimit)
+		cancel()
+		instanceLogger := logger.WithField("instance_id", instance.ID)
+		recordResourceChange(*instance, cpuLimit, memoryLimit, recreated, err, instanceLogger)
+		if err != nil {
+			instanceLogger.WithError(err).Error("Failed to change instance resources")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change instance resources"})
+			return
+		}
+		instanceLogger.WithFields(logrus.Fields{
+			"cpu_limit":    cpuLimit,
+			"memory_limit": memoryLimit,
+			"recreated":    recreated,
+		}).Info("Instance resources changed")
+	}
+
+	if err := db.SetInstanceResourceOverride(instance.ID, !req.Reset); err != nil {
+		logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to record resource override")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change instance resources"})
+		return
+	}
+
+	updated, err := db.GetInstanceByID(instance.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching instance"})
+		return
+	}
+	c.JSON(http.StatusOK, updated.ToPublicResponse())
+}
+
+// resourceRangeError describes the allowed range of a resource limit; max is 0 when unbounded
+func resourceRangeError(field string, min, max float64) string {
+	if max > 0 {
+		return fmt.Sprintf("%s must be between %g and %g", field, min, max)
+	}
+	return fmt.Sprintf("%s must be at least %g", field, min)
+}
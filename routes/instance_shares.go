@@ -0,0 +1,223 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// InstanceShareRequest represents a request to share an instance with another user
+type InstanceShareRequest struct {
+	Email  string             `json:"email" binding:"required,email"`
+	Access models.ShareAccess `json:"access" binding:"required"`
+}
+
+// loadSharableInstance fetches the instance in the :id param and checks the current user owns
+// it, as only owners share their instances. It writes the error response and returns nil
+// otherwise.
+func loadSharableInstance(c *gin.Context) *models.Instance {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+		return nil
+	}
+	instance := fetchInstance(c)
+	if instance == nil {
+		return nil
+	}
+	if instance.UserID != userID {
+		// Users who may not see the instance get the usual error of authorizeInstance
+		if authorizeInstance(c, instance, models.OrgRoleViewer) {
+			c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "share_owner_only"))
+		}
+		return nil
+	}
+	return instance
+}
+
+// GetInstanceShares returns the users an instance is shared with and their access
+func GetInstanceShares() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadSharableInstance(c)
+		if instance == nil {
+			return
+		}
+
+		shares, err := db.GetInstanceShares(instance.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instance shares"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(shares))
+		for i, share := range shares {
+			response[i] = share.ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ShareInstance gives another registered user read-only or operator access to an instance,
+// or changes the access they have
+func ShareInstance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		instance := loadSharableInstance(c)
+		if instance == nil {
+			return
+		}
+
+		var req InstanceShareRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+		if !req.Access.Valid() {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_share_access"))
+			return
+		}
+
+		user, err := db.FindUserByEmail(req.Email)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "share_user_not_found"))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
+			return
+		}
+		if user.ID == instance.UserID {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "share_with_self"))
+			return
+		}
+
+		share, err := db.SaveInstanceShare(&models.InstanceShare{
+			InstanceID:  instance.ID,
+			UserID:      user.ID,
+			Access:      req.Access,
+			CreatedByID: instance.UserID,
+		})
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to share instance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share instance"})
+			return
+		}
+
+		notification := &models.Notification{
+			UserID:     user.ID,
+			InstanceID: &instance.ID,
+			Type:       models.NotificationInstanceShared,
+			Level:      models.EventLevelInfo,
+			Title:      fmt.Sprintf("Instance %s was shared with you", instance.Name),
+			Message:    fmt.Sprintf("You have %s access to the instance %s.", share.Access, instance.Name),
+		}
+		if err := db.CreateNotification(notification); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to notify user of instance share")
+		}
+
+		logger.WithFields(logrus.Fields{
+			"instance_id": instance.ID,
+			"user_id":     user.ID,
+			"access":      share.Access,
+		}).Info("Shared instance")
+		c.JSON(http.StatusCreated, share.ToPublicResponse())
+	}
+}
+
+// RevokeInstanceShare stops sharing an instance with a user. Owners revoke any share; users
+// an instance is shared with may give up their own access.
+func RevokeInstanceShare() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+		shareUserID, err := uuid.Parse(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+
+		var instance *models.Instance
+		if shareUserID == userID {
+			instance = fetchInstance(c)
+		} else {
+			instance = loadSharableInstance(c)
+		}
+		if instance == nil {
+			return
+		}
+
+		deleted, err := db.DeleteInstanceShare(instance.ID, shareUserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke instance share"})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "share_not_found"))
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"instance_id": instance.ID,
+			"user_id":     shareUserID,
+		}).Info("Revoked instance share")
+		c.JSON(http.StatusOK, gin.H{"message": "Instance share revoked successfully"})
+	}
+}
+
+// GetSharedInstances returns a page of the instances other users shared with the current
+// user, with the access they have, and the number of such instances in the X-Total-Count header
+func GetSharedInstances() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		opts, ok := bindListOptions(c, instanceListQuery)
+		if !ok {
+			return
+		}
+		instances, total, err := db.ListInstancesSharedWithUser(userID, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+		shares, err := db.GetInstanceSharesByUserID(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(instances))
+		for i, instance := range instances {
+			response[i] = instance.ToPublicResponse()
+			response[i]["access"] = shares[instance.ID].Access
+		}
+		setTotalCount(c, total)
+		c.JSON(http.StatusOK, response)
+	}
+}
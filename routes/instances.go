@@ -9,8 +9,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/launchstack/backend/apierror"
+	"github.com/launchstack/backend/config"
 	"github.com/launchstack/backend/container"
 	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
 	"github.com/launchstack/backend/middleware"
 	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
@@ -19,11 +22,44 @@ import (
 
 // InstanceRequest is the request body for creating/updating an instance
 type InstanceRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	ProjectID   *uuid.UUID `json:"project_id"` // Nil UUID removes the instance from its project
+	OrganizationID *uuid.UUID `json:"organization_id"` // organization to share a new instance with
+	ImageTag    string     `json:"image_tag"`  // n8n version to pin on creation, defaults to latest
+	ServiceType models.ServiceType `json:"service_type"` // template to create the instance from, defaults to n8n
+	Waitlist    bool       `json:"waitlist"`   // Wait in line if the host is at capacity, instead of failing
 }
 
-// GetInstances returns all instances for the current user
+// RenameRequest represents a request to rename an instance
+type RenameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpgradeRequest represents a request to move an instance to another n8n version
+type UpgradeRequest struct {
+	Version string `json:"version" binding:"required"`
+}
+
+// createAttempts bounds how often creating an instance is retried when its subdomain is
+// taken by another instance saved at the same time
+const createAttempts = 3
+
+// instanceListQuery is what instance listings can be sorted and filtered by
+var instanceListQuery = listQuery{
+	sorts: map[string]string{
+		"name":       "name",
+		"status":     "status",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	filters:     map[string]string{"status": "status"},
+	defaultSort: "created_at",
+}
+
+// GetInstances returns a page of the current user's instances, with the number of instances
+// matching the filters in the X-Total-Count header. Polling clients can send the ETag of the
+// last response in If-None-Match to get 304 Not Modified while no matching instance changed.
 func GetInstances(containerManager container.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get logger from context
@@ -34,20 +70,53 @@ func GetInstances(containerManager container.Manager) gin.HandlerFunc {
 		userID, err := middleware.GetUserIDFromContext(c)
 		if err != nil {
 			logger.WithError(err).Error("Failed to get user ID from context")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 		logger.WithField("user_id", userID).Info("Processing get instances request for user")
 
-		// Get instances from database
+		opts, ok := bindListOptions(c, instanceListQuery)
+		if !ok {
+			return
+		}
+
+		// The version is read before the instances, so a change in between leads to a new ETag
+		// on the next request rather than to a stale response under the current one
+		projectID, scoped := middleware.GetAPIKeyProjectID(c)
+		var version db.ListVersion
+		if scoped {
+			version, err = db.GetInstanceListVersionByProjectID(projectID, opts)
+		} else {
+			version, err = db.GetInstanceListVersionByUserID(userID, opts)
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to get instance list version")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+		if notModified(c, versionETag(userID, projectID, c.Request.URL.RawQuery, version)) {
+			setTotalCount(c, version.Count)
+			return
+		}
+
+		// Get instances from database, limited to the project for project-scoped API keys
 		logger.Info("Fetching instances from database")
-		instances, err := db.GetInstancesByUserID(userID)
+		var instances []models.Instance
+		var total int64
+		if scoped {
+			instances, total, err = db.ListInstancesByProjectID(projectID, opts)
+		} else {
+			instances, total, err = db.ListInstancesByUserID(userID, opts)
+		}
 		if err != nil {
 			logger.WithError(err).Error("Failed to get instances from database")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
 			return
 		}
-		logger.WithField("instance_count", len(instances)).Info("Successfully retrieved instances")
+		logger.WithFields(logrus.Fields{
+			"instance_count": len(instances),
+			"total":          total,
+		}).Info("Successfully retrieved instances")
 
 		// Convert to response format
 		logger.Info("Preparing response")
@@ -63,12 +132,15 @@ func GetInstances(containerManager container.Manager) gin.HandlerFunc {
 		}
 
 		logger.WithField("response_count", len(response)).Info("Returning instances to client")
+		setTotalCount(c, total)
 		c.JSON(http.StatusOK, response)
 	}
 }
 
-// CreateInstance creates a new instance
-func CreateInstance(containerManager container.Manager) gin.HandlerFunc {
+// CreateInstance records a new instance and queues its provisioning, responding with 202
+// while the instance is pending. Requests repeated with the same Idempotency-Key header
+// return the instance created by the first one.
+func CreateInstance(containerManager container.Manager, provisioner *jobs.Provisioner) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := c.MustGet("logger").(*logrus.Logger)
 		logger.Info("Received request to create a new instance")
@@ -77,7 +149,7 @@ func CreateInstance(containerManager container.Manager) gin.HandlerFunc {
 		user, err := middleware.GetUserFromContext(c)
 		if err != nil {
 			logger.WithError(err).Error("Failed to get user from context")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 		
@@ -92,7 +164,7 @@ func CreateInstance(containerManager container.Manager) gin.HandlerFunc {
 		var req InstanceRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			logger.WithError(err).Error("Invalid request body")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_body"))
 			return
 		}
 		logger.WithFields(logrus.Fields{
@@ -100,11 +172,22 @@ func CreateInstance(containerManager container.Manager) gin.HandlerFunc {
 			"description":   req.Description,
 		}).Info("Received instance creation parameters")
 
+		// A retried request returns the instance the original request created
+		idempotencyKey := c.GetHeader("Idempotency-Key")
+		if len(idempotencyKey) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+		if idempotencyKey != "" {
+			if respondWithExistingProvisioning(c, user.ID, idempotencyKey, logger) {
+				return
+			}
+		}
+
 		// Check if user has reached their instance limit
 		count, err := db.CountInstancesByUserID(user.ID)
 		if err != nil {
-			logger.WithError(err).Error("Failed to check instance count")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check instance count"})
+			apierror.Abort(c, fmt.Errorf("failed to check instance count: %w", err))
 			return
 		}
 
@@ -118,10 +201,16 @@ func CreateInstance(containerManager container.Manager) gin.HandlerFunc {
 				"current_count": count,
 				"limit":         user.GetInstancesLimit(),
 			}).Warn("Instance limit reached")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Instance limit reached",
-				"limit": user.GetInstancesLimit(),
-			})
+			apierror.Abort(c, apierror.New(apierror.InstanceLimitReached).With("limit", user.GetInstancesLimit()))
+			return
+		}
+
+		if err := models.ValidateInstanceName(req.Name); err != nil {
+			respondWithInvalidName(c, err)
+			return
+		}
+		if req.ImageTag != "" && !models.ValidImageTag(req.ImageTag) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image tag"})
 			return
 		}
 
@@ -129,34 +218,233 @@ func CreateInstance(containerManager container.Manager) gin.HandlerFunc {
 		instanceReq := models.Instance{
 			Name:        req.Name,
 			Description: req.Description,
+			ImageTag:    req.ImageTag,
+			ServiceType: req.ServiceType,
+		}
+
+		// Project-scoped API keys always create instances in their project
+		if projectID, scoped := middleware.GetAPIKeyProjectID(c); scoped {
+			req.ProjectID = &projectID
 		}
 
-		// Create the instance
-		logger.Info("Calling container manager to create instance")
-		instance, err := containerManager.CreateInstance(context.Background(), user, instanceReq)
+		// Apply the project's defaults when creating inside a project
+		if req.ProjectID != nil && *req.ProjectID != uuid.Nil {
+			project, err := db.GetProjectByID(*req.ProjectID)
+			if err != nil || project.UserID != user.ID {
+				c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "project_not_found"))
+				return
+			}
+			instanceReq.ProjectID = &project.ID
+			project.ApplyDefaults(&instanceReq, user)
+		}
+
+		// Share the instance with an organization the user may create instances in
+		if req.OrganizationID != nil {
+			if !authorizeOrgInstanceCreation(c, *req.OrganizationID, user.ID) {
+				return
+			}
+			instanceReq.OrganizationID = req.OrganizationID
+		}
+
+		// Build the instance record; its resources are created by the provisioner. Another
+		// instance may take the subdomain between preparing and saving, in which case the
+		// instance is prepared again with the next free one.
+		var instance *models.Instance
+		var job *models.ProvisioningJob
+		var submitErr error
+		for attempt := 1; ; attempt++ {
+			instance, err = containerManager.PrepareInstance(c.Request.Context(), user, instanceReq)
+			if err != nil {
+				break
+			}
+			job = models.NewProvisioningJob(instance, idempotencyKey)
+			submitErr = provisioner.Submit(instance, job)
+			if !errors.Is(submitErr, db.ErrHostTaken) || attempt == createAttempts {
+				break
+			}
+			logger.WithField("host", instance.Host).Warn("Subdomain was taken while creating the instance, retrying")
+		}
+		if errors.Is(err, container.ErrDuplicateInstanceName) {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_name_taken"))
+			return
+		}
+		if errors.Is(err, container.ErrUnknownService) {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "unknown_service_type"))
+			return
+		}
+		if errors.Is(err, container.ErrHostAtCapacity) {
+			if req.Waitlist {
+				joinWaitlist(c, user, instanceReq, count, logger)
+				return
+			}
+			body := middleware.ErrorBody(c, "host_at_capacity")
+			body["availability"] = capacityWaitlist
+			c.JSON(http.StatusServiceUnavailable, body)
+			return
+		}
+		if errors.Is(err, db.ErrHostTaken) || errors.Is(submitErr, db.ErrHostTaken) {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_subdomain_taken"))
+			return
+		}
 		if err != nil {
-			logger.WithError(err).Error("Failed to create instance")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create instance: " + err.Error()})
+			apierror.Abort(c, apierror.Wrap(fmt.Errorf("failed to prepare instance: %w", err), apierror.ProvisioningFailed))
+			return
+		}
+		if submitErr != nil {
+			// A concurrent request with the same key may have won the race
+			if idempotencyKey != "" && respondWithExistingProvisioning(c, user.ID, idempotencyKey, logger) {
+				return
+			}
+			apierror.Abort(c, apierror.Wrap(fmt.Errorf("failed to save instance: %w", submitErr), apierror.ProvisioningFailed))
 			return
 		}
+
 		logger.WithFields(logrus.Fields{
 			"instance_id":   instance.ID,
 			"instance_name": instance.Name,
-			"status":        instance.Status,
 			"url":           instance.URL,
-		}).Info("Instance created successfully by container manager")
+		}).Info("Instance queued for provisioning")
+
+		response := instance.ToPublicResponse()
+		response["provisioning"] = job.ToPublicResponse()
+		c.JSON(http.StatusAccepted, response)
+	}
+}
+
+// suspendedError is the error of operating a suspended instance. Instances suspended for a
+// lapsed payment are resumed once it is paid, so they answer payment_required.
+func suspendedError(instance *models.Instance) *apierror.Error {
+	code := apierror.InstanceSuspended
+	if instance.SuspendedReason == models.SuspendReasonBilling {
+		code = apierror.PaymentRequired
+	}
+	return apierror.New(code).With("reason", instance.SuspendedReason)
+}
+
+// respondWithInvalidName responds with 422 and the naming rule a requested instance name breaks
+func respondWithInvalidName(c *gin.Context, err error) {
+	var nameErr *models.InstanceNameError
+	if !errors.As(err, &nameErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid instance name: " + err.Error(), "field": "name"})
+		return
+	}
+	body := middleware.ErrorBody(c, nameErr.Code(), nameErr.Args()...)
+	body["field"] = "name"
+	body["reason"] = nameErr.Reason
+	c.JSON(http.StatusUnprocessableEntity, body)
+}
+
+// respondWithExistingProvisioning responds with the instance a user already created with an
+// idempotency key, reporting whether there was one
+func respondWithExistingProvisioning(c *gin.Context, userID uuid.UUID, idempotencyKey string, logger *logrus.Logger) bool {
+	job, err := db.GetProvisioningJobByIdempotencyKey(userID, idempotencyKey)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to look up instance by idempotency key")
+		return false
+	}
+	if job == nil {
+		return false
+	}
+	instance, err := db.GetInstanceByID(job.InstanceID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load instance created with idempotency key")
+		return false
+	}
+
+	logger.WithField("instance_id", instance.ID).Info("Returning instance created by an earlier request with the same idempotency key")
+	response := instance.ToPublicResponse()
+	response["provisioning"] = job.ToPublicResponse()
+	c.JSON(http.StatusAccepted, response)
+	return true
+}
+
+// GetInstanceProvisioning returns the progress of an instance's provisioning
+func GetInstanceProvisioning() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := middleware.GetUserIDFromContext(c); err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+
+		instance, err := db.GetInstanceByID(instanceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+		if !authorizeInstance(c, instance, models.OrgRoleViewer) {
+			return
+		}
+
+		// Instances created before asynchronous provisioning have no job
+		job, err := db.GetProvisioningJobByInstanceID(instanceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No provisioning record for this instance"})
+			return
+		}
+
+		c.JSON(http.StatusOK, job.ToPublicResponse())
+	}
+}
+
+// GetInstanceProvisioningSteps returns every step of every attempt at provisioning an
+// instance, including the cleanup of failed attempts
+func GetInstanceProvisioningSteps() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		job, err := db.GetProvisioningJobByInstanceID(instance.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No provisioning record for this instance"})
+			return
+		}
+		steps, err := db.GetProvisioningStepRecords(job.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch provisioning steps"})
+			return
+		}
 
-		// Save instance to database
-		logger.Info("Saving instance to database")
-		if err := db.CreateInstance(instance); err != nil {
-			logger.WithError(err).Error("Failed to save instance to database")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save instance"})
+		c.JSON(http.StatusOK, gin.H{
+			"provisioning_job_id": job.ID,
+			"attempts":            job.Attempts,
+			"steps":               steps,
+		})
+	}
+}
+
+// RetryInstanceProvisioning provisions an instance whose provisioning failed again
+func RetryInstanceProvisioning(provisioner *jobs.Provisioner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+
+		job, err := provisioner.Retry(instance)
+		if errors.Is(err, db.ErrProvisioningNotFailed) {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "provisioning_not_failed"))
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No provisioning record for this instance"})
+			return
+		}
+		if err != nil {
+			apierror.Abort(c, apierror.Wrap(fmt.Errorf("failed to retry provisioning of instance %s: %w", instance.ID, err), apierror.ProvisioningFailed))
 			return
 		}
-		logger.WithField("instance_id", instance.ID).Info("Instance saved to database")
 
-		c.JSON(http.StatusCreated, instance.ToPublicResponse())
-		logger.WithField("instance_id", instance.ID).Info("Instance creation completed successfully")
+		response := instance.ToPublicResponse()
+		response["provisioning"] = job.ToPublicResponse()
+		c.JSON(http.StatusAccepted, response)
 	}
 }
 
@@ -174,7 +462,7 @@ func GetInstance(containerManager container.Manager) gin.HandlerFunc {
 		userID, err := middleware.GetUserIDFromContext(c)
 		if err != nil {
 			logger.WithError(err).Error("Failed to get user ID from context")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 		logger.WithField("user_id", userID).Info("Processing get instance request for user")
@@ -182,7 +470,7 @@ func GetInstance(containerManager container.Manager) gin.HandlerFunc {
 		instanceID, err := uuid.Parse(instanceIDStr)
 		if err != nil {
 			logger.WithError(err).Error("Invalid instance ID format")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 
@@ -194,7 +482,7 @@ func GetInstance(containerManager container.Manager) gin.HandlerFunc {
 				"instance_id": instanceID,
 				"error":       err.Error(),
 			}).Error("Failed to fetch instance from database")
-			c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+			apierror.Abort(c, apierror.New(apierror.InstanceNotFound))
 			return
 		}
 		logger.WithFields(logrus.Fields{
@@ -203,13 +491,8 @@ func GetInstance(containerManager container.Manager) gin.HandlerFunc {
 			"status":        instance.Status,
 		}).Info("Successfully retrieved instance")
 
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			logger.WithFields(logrus.Fields{
-				"instance_user_id": instance.UserID,
-				"request_user_id":  userID,
-			}).Warn("User attempted to access instance they don't own")
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstance(c, instance, models.OrgRoleViewer) {
 			return
 		}
 
@@ -224,7 +507,7 @@ func UpdateInstance(containerManager container.Manager) gin.HandlerFunc {
 		// Get user ID from context
 		userID, err := middleware.GetUserIDFromContext(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
@@ -232,34 +515,67 @@ func UpdateInstance(containerManager container.Manager) gin.HandlerFunc {
 		instanceIDStr := c.Param("id")
 		instanceID, err := uuid.Parse(instanceIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 
 		// Get instance from database
 		instance, err := db.GetInstanceByID(instanceID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
 			return
 		}
 
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstance(c, instance, models.OrgRoleAdmin) {
 			return
 		}
 
 		// Parse request body
 		var req InstanceRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_body"))
 			return
 		}
 
+		// Renames go through the container manager so the naming policy and container name apply
+		if req.Name != instance.Name {
+			if err := models.ValidateInstanceName(req.Name); err != nil {
+				respondWithInvalidName(c, err)
+				return
+			}
+			instance, err = containerManager.RenameInstance(context.Background(), instance.ID, req.Name)
+			if errors.Is(err, container.ErrDuplicateInstanceName) {
+				c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_name_taken"))
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename instance"})
+				return
+			}
+		}
+
 		// Update instance properties
-		instance.Name = req.Name
 		instance.Description = req.Description
 
+		// Move the instance between projects; project-scoped API keys cannot
+		if req.ProjectID != nil {
+			if _, scoped := middleware.GetAPIKeyProjectID(c); scoped {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API key cannot move instances between projects"})
+				return
+			}
+			if *req.ProjectID == uuid.Nil {
+				instance.ProjectID = nil
+			} else {
+				project, err := db.GetProjectByID(*req.ProjectID)
+				if err != nil || project.UserID != userID {
+					c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "project_not_found"))
+					return
+				}
+				instance.ProjectID = &project.ID
+			}
+		}
+
 		// Save changes to database
 		if err := db.UpdateInstance(instance); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance"})
@@ -270,13 +586,16 @@ func UpdateInstance(containerManager container.Manager) gin.HandlerFunc {
 	}
 }
 
-// DeleteInstance deletes an instance
-func DeleteInstance(containerManager container.Manager) gin.HandlerFunc {
+// DeleteInstance moves an instance to the trash, where it is kept stopped with its volumes
+// for the trash retention period. Deleting a trashed instance, or any instance while the
+// trash is disabled, deletes it for good.
+func DeleteInstance(cfg *config.Config, containerManager container.Manager, instanceJobs *jobs.InstanceJobs) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
 		// Get user ID from context
-		userID, err := middleware.GetUserIDFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		if _, err := middleware.GetUserIDFromContext(c); err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
@@ -284,36 +603,130 @@ func DeleteInstance(containerManager container.Manager) gin.HandlerFunc {
 		instanceIDStr := c.Param("id")
 		instanceID, err := uuid.Parse(instanceIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 
 		// Get instance from database
 		instance, err := db.GetInstanceByID(instanceID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstance(c, instance, models.OrgRoleAdmin) {
 			return
 		}
 
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		// The provisioner removes partial resources itself if provisioning fails
+		switch instance.Status {
+		case models.StatusPending:
+			c.JSON(http.StatusConflict, gin.H{"error": "Instance is still being provisioned"})
+			return
+		case models.StatusUpgrading:
+			c.JSON(http.StatusConflict, gin.H{"error": "Instance is being upgraded"})
+			return
+		case models.StatusDeleting:
+			c.JSON(http.StatusConflict, gin.H{"error": "Instance is already being deleted"})
+			return
+		}
+
+		// Instances whose provisioning failed have nothing worth keeping
+		if cfg.Trash.Retention > 0 && !instance.IsTrashed() && instance.ContainerID != "" {
+			trashInstance(c, containerManager, instance, cfg.Trash.Retention, logger)
 			return
 		}
 
-		// Delete the container
-		if err := containerManager.DeleteInstance(context.Background(), instanceID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete instance container"})
+		previousStatus := instance.Status
+		instance.Status = models.StatusDeleting
+		if err := db.UpdateInstance(instance); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
+			return
+		}
+
+		job, err := instanceJobs.Delete(instance)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to queue instance deletion")
+			instance.Status = previousStatus
+			if err := db.UpdateInstance(instance); err != nil {
+				logger.WithError(err).Error("Failed to restore instance status")
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete instance"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Instance deletion started",
+			"job":     job.ToPublicResponse(),
+		})
+	}
+}
+
+// trashInstance stops an instance and moves it to the trash until its retention ends
+func trashInstance(c *gin.Context, containerManager container.Manager, instance *models.Instance, retention time.Duration, logger *logrus.Logger) {
+	switch instance.Status {
+	case models.StatusRunning:
+		if err := containerManager.StopInstance(context.Background(), instance.ID); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to stop instance moved to trash")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop instance"})
+			return
+		}
+	case models.StatusError:
+		// The container may be gone already; its volumes are what the trash keeps
+		if err := containerManager.StopInstance(context.Background(), instance.ID); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to stop errored instance moved to trash")
+		}
+	}
+
+	now := time.Now()
+	purgeAt := now.Add(retention)
+	instance.Status = models.StatusTrashed
+	instance.TrashedAt = &now
+	instance.PurgeAt = &purgeAt
+	if err := db.UpdateInstance(instance); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"instance_id": instance.ID,
+		"purge_at":    purgeAt,
+	}).Info("Instance moved to trash")
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Instance moved to trash",
+		"instance": instance.ToPublicResponse(),
+	})
+}
+
+// RestoreInstance takes an instance out of the trash, stopped. Instances suspended when they
+// were trashed stay suspended until the platform resumes them.
+func RestoreInstance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+		if !instance.IsTrashed() {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_not_trashed"))
 			return
 		}
 
-		// Delete from database
-		if err := db.DeleteInstance(instanceID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete instance from database"})
+		instance.Status = models.StatusStopped
+		if instance.SuspendedReason != "" {
+			instance.Status = models.StatusSuspended
+		}
+		instance.TrashedAt = nil
+		instance.PurgeAt = nil
+		if err := db.UpdateInstance(instance); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Instance deleted successfully"})
+		logger.WithField("instance_id", instance.ID).Info("Instance restored from trash")
+		c.JSON(http.StatusOK, instance.ToPublicResponse())
 	}
 }
 
@@ -321,9 +734,8 @@ func DeleteInstance(containerManager container.Manager) gin.HandlerFunc {
 func StartInstance(containerManager container.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context
-		userID, err := middleware.GetUserIDFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		if _, err := middleware.GetUserIDFromContext(c); err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
@@ -331,20 +743,31 @@ func StartInstance(containerManager container.Manager) gin.HandlerFunc {
 		instanceIDStr := c.Param("id")
 		instanceID, err := uuid.Parse(instanceIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 
 		// Get instance from database
 		instance, err := db.GetInstanceByID(instanceID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstanceOperation(c, instance) {
 			return
 		}
 
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		// Trashed instances are started again by restoring them
+		if instance.IsTrashed() {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_trashed"))
+			return
+		}
+
+		// Suspended instances are resumed by the platform once the cause is resolved
+		if instance.IsSuspended() {
+			apierror.Abort(c, suspendedError(instance))
 			return
 		}
 
@@ -360,8 +783,11 @@ func StartInstance(containerManager container.Manager) gin.HandlerFunc {
 			return
 		}
 
-		// Update instance status
+		// Update instance status; starting it counts as activity, so a sleeping plan's
+		// instance is not put back to sleep right away
+		now := time.Now()
 		instance.Status = models.StatusRunning
+		instance.LastActivityAt = &now
 		if err := db.UpdateInstance(instance); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
 			return
@@ -375,9 +801,8 @@ func StartInstance(containerManager container.Manager) gin.HandlerFunc {
 func StopInstance(containerManager container.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context
-		userID, err := middleware.GetUserIDFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		if _, err := middleware.GetUserIDFromContext(c); err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
@@ -385,20 +810,25 @@ func StopInstance(containerManager container.Manager) gin.HandlerFunc {
 		instanceIDStr := c.Param("id")
 		instanceID, err := uuid.Parse(instanceIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 
 		// Get instance from database
 		instance, err := db.GetInstanceByID(instanceID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
 			return
 		}
 
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstanceOperation(c, instance) {
+			return
+		}
+
+		// Trashed instances are already stopped
+		if instance.IsTrashed() {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_trashed"))
 			return
 		}
 
@@ -429,9 +859,8 @@ func StopInstance(containerManager container.Manager) gin.HandlerFunc {
 func RestartInstance(containerManager container.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user ID from context
-		userID, err := middleware.GetUserIDFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		if _, err := middleware.GetUserIDFromContext(c); err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
@@ -439,20 +868,31 @@ func RestartInstance(containerManager container.Manager) gin.HandlerFunc {
 		instanceIDStr := c.Param("id")
 		instanceID, err := uuid.Parse(instanceIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 
 		// Get instance from database
 		instance, err := db.GetInstanceByID(instanceID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
 			return
 		}
 
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstanceOperation(c, instance) {
+			return
+		}
+
+		// Trashed instances are started again by restoring them
+		if instance.IsTrashed() {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_trashed"))
+			return
+		}
+
+		// Suspended instances are resumed by the platform once the cause is resolved
+		if instance.IsSuspended() {
+			apierror.Abort(c, suspendedError(instance))
 			return
 		}
 
@@ -469,7 +909,9 @@ func RestartInstance(containerManager container.Manager) gin.HandlerFunc {
 		}
 
 		// Update instance status
+		now := time.Now()
 		instance.Status = models.StatusRunning
+		instance.LastActivityAt = &now
 		if err := db.UpdateInstance(instance); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
 			return
@@ -479,20 +921,274 @@ func RestartInstance(containerManager container.Manager) gin.HandlerFunc {
 	}
 }
 
+// WakeInstance starts an instance that was put to sleep for being idle and responds once it
+// runs. Visitors of its URL wake it as well, without waiting.
+func WakeInstance(sleeper *jobs.InstanceSleeper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadOperableInstance(c)
+		if instance == nil {
+			return
+		}
+		if !instance.IsSleeping() {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_not_sleeping"))
+			return
+		}
+
+		if err := sleeper.Wake(c.Request.Context(), instance.ID); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to wake instance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to wake instance"})
+			return
+		}
+
+		woken, err := db.GetInstanceByID(instance.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instance"})
+			return
+		}
+		c.JSON(http.StatusOK, woken.ToPublicResponse())
+	}
+}
+
+// GetInstanceSpec returns the resolved request an instance was created from
+func GetInstanceSpec() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		// Instances created before specs were recorded have none
+		if instance.ProvisioningSpec == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No provisioning spec recorded for this instance"})
+			return
+		}
+
+		c.JSON(http.StatusOK, instance.ProvisioningSpec)
+	}
+}
+
+// RenameInstance changes an instance's display name and renames its container to match
+func RenameInstance(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		var req RenameRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if err := models.ValidateInstanceName(req.Name); err != nil {
+			respondWithInvalidName(c, err)
+			return
+		}
+
+		renamed, err := containerManager.RenameInstance(context.Background(), instance.ID, req.Name)
+		if errors.Is(err, container.ErrDuplicateInstanceName) {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_name_taken"))
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to rename instance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename instance"})
+			return
+		}
+
+		c.JSON(http.StatusOK, renamed.ToPublicResponse())
+	}
+}
+
+// UpgradeInstance moves an instance to another n8n version. The rollout runs in the
+// background; its outcome is recorded as an instance event.
+func UpgradeInstance(instanceJobs *jobs.InstanceJobs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		var req UpgradeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if !models.ValidImageTag(req.Version) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return
+		}
+
+		// The health check needs the instance to be serving
+		if instance.Status != models.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only running instances can be upgraded", "status": instance.Status})
+			return
+		}
+
+		fromVersion := instance.ImageTag
+		instance.Status = models.StatusUpgrading
+		if err := db.UpdateInstance(instance); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
+			return
+		}
+
+		job, err := instanceJobs.Upgrade(instance, fromVersion, req.Version)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to queue instance upgrade")
+			instance.Status = models.StatusRunning
+			if err := db.UpdateInstance(instance); err != nil {
+				logger.WithError(err).Error("Failed to restore instance status")
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upgrade"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":      "Upgrade started",
+			"from_version": fromVersion,
+			"to_version":   req.Version,
+			"job":          job.ToPublicResponse(),
+		})
+	}
+}
+
+// RollbackInstance returns an instance to the n8n version and image digest it ran before its
+// last upgrade, within the rollback window. The rollout runs in the background; its outcome is
+// recorded as an instance event.
+func RollbackInstance(cfg *config.Config, instanceJobs *jobs.InstanceJobs) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		if instance.PreviousImageDigest == "" || instance.UpgradedAt == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Instance has no previous version to roll back to"})
+			return
+		}
+		rollbackUntil := instance.UpgradedAt.Add(cfg.N8N.RollbackWindow)
+		if time.Now().After(rollbackUntil) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The rollback window of the last upgrade has expired", "rollback_until": rollbackUntil})
+			return
+		}
+
+		// Rolling back is the way out of a broken upgrade, so unhealthy instances qualify too
+		if instance.Status != models.StatusRunning && instance.Status != models.StatusError {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only running or unhealthy instances can be rolled back", "status": instance.Status})
+			return
+		}
+
+		fromVersion, toVersion := instance.ImageTag, instance.PreviousImageTag
+		job, err := instanceJobs.Rollback(instance, false)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to queue instance rollback")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start rollback"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":      "Rollback started",
+			"from_version": fromVersion,
+			"to_version":   toVersion,
+			"image":        instance.PreviousImageDigest,
+			"job":          job.ToPublicResponse(),
+		})
+	}
+}
+
+// GetInstanceCredentials returns the basic auth login of an instance's n8n editor to its owner
+func GetInstanceCredentials(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+
+		credential, password, err := containerManager.GetInstanceCredentials(context.Background(), instance.ID)
+		if errors.Is(err, container.ErrNoCredentials) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No credentials are known for this instance"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to get instance credentials")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instance credentials"})
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, credential.ToPublicResponse(password))
+	}
+}
+
+// RotateInstanceCredentials replaces the basic auth password of an instance and restarts its
+// container with the new one. The previous password stops working once the request returns.
+func RotateInstanceCredentials(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		// The new container must pass its health check before the old one is removed
+		if instance.Status != models.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only running instances can rotate credentials", "status": instance.Status})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		credential, password, err := containerManager.RotateInstanceCredentials(ctx, instance.ID)
+		if errors.Is(err, container.ErrServiceUnsupported) {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "service_unsupported"))
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to rotate instance credentials")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate instance credentials"})
+			return
+		}
+
+		event := &models.InstanceEvent{
+			InstanceID: instance.ID,
+			UserID:     instance.UserID,
+			Type:       models.EventCredentialsRotated,
+			Level:      models.EventLevelInfo,
+			Message:    "Basic auth password rotated; the container was restarted",
+		}
+		if err := db.CreateInstanceEvent(event); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Warn("Failed to record credential rotation event")
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, credential.ToPublicResponse(password))
+	}
+}
+
 // GetInstanceStats returns resource usage stats for an instance
 func GetInstanceStats(containerManager container.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get instance ID from path
 		instanceID, err := uuid.Parse(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 		
 		// Get the user ID from context
-		userID, err := middleware.GetUserIDFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		if _, err := middleware.GetUserIDFromContext(c); err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 		
@@ -500,16 +1196,15 @@ func GetInstanceStats(containerManager container.Manager) gin.HandlerFunc {
 		instance, err := db.GetInstanceByID(instanceID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+				c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching instance"})
 			return
 		}
 		
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this instance"})
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstance(c, instance, models.OrgRoleViewer) {
 			return
 		}
 		
@@ -520,6 +1215,13 @@ func GetInstanceStats(containerManager container.Manager) gin.HandlerFunc {
 			return
 		}
 		
+		// Every sample is new, so only the measured values identify a response; an idle
+		// instance then returns 304 Not Modified until its usage changes
+		etag := versionETag(instanceID, stats.CPUUsage, stats.MemoryUsage, stats.MemoryLimit, stats.DiskUsage, stats.NetworkIn, stats.NetworkOut)
+		if notModified(c, etag) {
+			return
+		}
+		
 		// Return the stats
 		c.JSON(http.StatusOK, stats.FormatStats())
 	}
@@ -531,14 +1233,13 @@ func GetInstanceHistoricalStats() gin.HandlerFunc {
 		// Get instance ID from path
 		instanceID, err := uuid.Parse(c.Param("id"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
 			return
 		}
 		
 		// Get the user ID from context
-		userID, err := middleware.GetUserIDFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		if _, err := middleware.GetUserIDFromContext(c); err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 		
@@ -546,16 +1247,15 @@ func GetInstanceHistoricalStats() gin.HandlerFunc {
 		instance, err := db.GetInstanceByID(instanceID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+				c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching instance"})
 			return
 		}
 		
-		// Check if the instance belongs to the user
-		if instance.UserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this instance"})
+		// Check if the instance belongs to the user or is shared with them
+		if !authorizeInstance(c, instance, models.OrgRoleViewer) {
 			return
 		}
 		
@@ -577,6 +1277,16 @@ func GetInstanceHistoricalStats() gin.HandlerFunc {
 			period = time.Hour
 		}
 		
+		// The stats only change when a sample is taken or leaves the period
+		version, err := db.GetResourceUsageVersion(instanceID, time.Now().Add(-period))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error fetching metrics: %v", err)})
+			return
+		}
+		if notModified(c, versionETag(instanceID, period, instance.MemoryLimit, version)) {
+			return
+		}
+		
 		// For all periods, use the detailed historical data
 		// but format it according to frontend expectations
 		metrics, fetchErr := db.GetResourceUsageHistorical(instanceID, period, "auto")
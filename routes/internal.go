@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RegisterInternalRoutes registers the endpoints the reverse proxy calls, authenticated with
+// PROXY_INTERNAL_TOKEN
+func RegisterInternalRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	if cfg.Proxy.InternalToken == "" {
+		logger.Info("PROXY_INTERNAL_TOKEN is not set; internal proxy endpoints are disabled")
+	}
+
+	internalRoutes := router.Group("/api/v1/internal")
+	internalRoutes.Use(middleware.RequireInternalToken(cfg.Proxy.InternalToken))
+	internalRoutes.GET("/tls-check", TLSCheck(cfg))
+}
+
+// TLSCheck answers Caddy's on_demand_tls ask requests, responding 200 only for domains served
+// by a managed instance so certificates are never issued for hosts pointed at the proxy by
+// anyone else. Any other status makes Caddy refuse the certificate.
+func TLSCheck(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Query("domain"))), ".")
+		if domain == "" {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+
+		// Instances are served on a single label below the platform domain
+		suffix := "." + strings.ToLower(cfg.Server.Domain)
+		subdomain := strings.TrimSuffix(domain, suffix)
+		if subdomain == domain || subdomain == "" || strings.Contains(subdomain, ".") {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "tls_domain_not_managed"))
+			return
+		}
+
+		instance, err := db.GetInstanceByHost(subdomain)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "tls_domain_not_managed"))
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("domain", domain).Error("Failed to look up instance for TLS check")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up domain"})
+			return
+		}
+		if instance.Status == models.StatusTrashed || instance.Status == models.StatusDeleting || instance.Status == models.StatusDeleted {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "tls_domain_not_managed"))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"domain": domain, "instance_id": instance.ID})
+	}
+}
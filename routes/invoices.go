@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/invoice"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RegisterInvoiceRoutes registers the invoice downloads of payments. They are registered
+// whether payments are enabled or not, so past invoices stay available.
+func RegisterInvoiceRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	paymentRoutes := router.Group("/api/v1/payments")
+	paymentRoutes.GET("/:id/invoice", GetPaymentInvoice(cfg))
+}
+
+// GetPaymentInvoice downloads the invoice of one of the current user's completed payments as
+// a PDF. Payments completed before invoices were introduced are issued one on first download.
+func GetPaymentInvoice(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_authenticated"))
+			return
+		}
+		paymentID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_payment_id"))
+			return
+		}
+
+		payment, err := db.GetPaymentByID(user.ID, paymentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "payment_not_found"))
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to get payment")
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "internal_error"))
+			return
+		}
+		if payment.Status != models.PaymentStatusSucceeded && payment.Status != models.PaymentStatusRefunded {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "payment_not_completed"))
+			return
+		}
+
+		inv, err := db.GetInvoiceByPaymentID(payment.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = db.DB.Transaction(func(tx *gorm.DB) error {
+				inv, err = db.IssueInvoice(tx, payment, user)
+				return err
+			})
+		}
+		if err != nil {
+			logger.WithError(err).WithField("payment_id", payment.ID).Error("Failed to issue invoice")
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "internal_error"))
+			return
+		}
+
+		seller, _, err := resolveBranding(cfg)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to load branding overrides for invoice")
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", invoice.Filename(inv)))
+		c.Data(http.StatusOK, "application/pdf", invoice.Render(inv, seller, payment.Status))
+	}
+}
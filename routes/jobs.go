@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RegisterJobRoutes registers routes for following background jobs
+func RegisterJobRoutes(router *gin.Engine, logger *logrus.Logger) {
+	v1JobRoutes := router.Group("/api/v1/jobs")
+	v1JobRoutes.GET("/:id", GetJob())
+	v1JobRoutes.GET("/:id/", GetJob())
+}
+
+// GetJob returns the status of a background job started by the current user
+func GetJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		jobID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+
+		job, err := db.GetJobByID(jobID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "job_not_found"))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching job"})
+			return
+		}
+
+		// Admins may follow any job; other users only their own
+		if job.UserID == nil || *job.UserID != userID {
+			user, err := db.GetUserByID(userID)
+			if err != nil || !user.IsAdmin() {
+				c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "job_not_found"))
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, job.ToPublicResponse())
+	}
+}
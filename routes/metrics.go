@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterMetricsRoutes serves Prometheus metrics on /metrics, guarded by METRICS_TOKEN. Without
+// a token the endpoint is only served in development.
+func RegisterMetricsRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	if !cfg.Metrics.Enabled {
+		return
+	}
+	if cfg.Metrics.Token == "" {
+		if cfg.Server.Environment != "development" {
+			logger.Warn("METRICS_TOKEN is not set; /metrics is not served outside development")
+			return
+		}
+		logger.Warn("METRICS_TOKEN is not set; /metrics can be scraped without authentication")
+	}
+
+	router.GET("/metrics", middleware.RequireMetricsToken(cfg.Metrics.Token), gin.WrapH(promhttp.Handler()))
+}
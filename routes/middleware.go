@@ -4,7 +4,11 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/apierror"
 	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
 )
 
 // ContainerManagerMiddleware sets the container manager in the context
@@ -19,4 +23,29 @@ func ContainerManagerMiddleware(containerManager container.Manager) gin.HandlerF
 		c.Set("container_manager", containerManager)
 		c.Next()
 	}
-} 
\ No newline at end of file
+} 
+
+// ProjectScopeMiddleware limits project-scoped API keys to instances in their project
+func ProjectScopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		projectID, scoped := middleware.GetAPIKeyProjectID(c)
+		if !scoped || c.Param("id") == "" {
+			c.Next()
+			return
+		}
+		
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.InvalidInstanceID))
+			return
+		}
+		
+		instance, err := db.GetInstanceByID(instanceID)
+		if err != nil || instance.ProjectID == nil || *instance.ProjectID != projectID {
+			apierror.Abort(c, apierror.New(apierror.InstanceNotFound))
+			return
+		}
+		
+		c.Next()
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
 )
 
@@ -49,6 +51,9 @@ func RegisterMockPaymentRoutes(router *gin.Engine, logger *logrus.Logger) {
 		
 		paymentRoutes.POST("/subscriptions/:id/cancel", MockCancelSubscription)
 		paymentRoutes.POST("/subscriptions/:id/cancel/", MockCancelSubscription)
+		
+		paymentRoutes.POST("/subscriptions/:id/change", MockChangeSubscription)
+		paymentRoutes.POST("/subscriptions/:id/change/", MockChangeSubscription)
 	}
 
 	// Mock webhook route
@@ -66,7 +71,7 @@ func MockGetPayments(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_authenticated"))
 		return
 	}
 
@@ -97,7 +102,7 @@ func MockCreateCheckoutSession(c *gin.Context) {
 		CancelURL  string `json:"cancel_url"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
 		return
 	}
 
@@ -113,7 +118,7 @@ func MockGetSubscriptions(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_authenticated"))
 		return
 	}
 
@@ -126,7 +131,7 @@ func MockGetSubscriptions(c *gin.Context) {
 		"start_date":  time.Now().Add(-30 * 24 * time.Hour).Format(time.RFC3339),
 		"end_date":    time.Now().Add(335 * 24 * time.Hour).Format(time.RFC3339),
 		"auto_renew":  true,
-		"amount":      500, // $5.00 per month
+		"amount":      int(models.GetPlanPrice(models.PlanPro, models.BillingMonthly) * 100),
 		"currency":    "usd",
 		"description": "Pro Plan Subscription",
 	}
@@ -153,6 +158,32 @@ func MockCancelSubscription(c *gin.Context) {
 	})
 }
 
+// MockChangeSubscription mocks changing the plan of a subscription
+func MockChangeSubscription(c *gin.Context) {
+	// Get subscription ID from path
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subscription ID is required"})
+		return
+	}
+
+	var req struct {
+		Plan string `json:"plan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"message":          "Subscription plan changed successfully",
+		"plan":             req.Plan,
+		"proration_amount": 0,
+		"currency":         "usd",
+	})
+}
+
 // MockPayPalWebhook handles mock PayPal webhooks
 func MockPayPalWebhook(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
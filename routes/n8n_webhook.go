@@ -1,15 +1,23 @@
 package routes
 
 import (
-	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 // N8nWebhookRequest represents a webhook request from n8n
@@ -21,8 +29,32 @@ type N8nWebhookRequest struct {
 	ExecutionID string                 `json:"executionId,omitempty"`
 }
 
-// N8nWebhook handles webhook events from n8n instances
-func N8nWebhook(webhookSecret string, logger *logrus.Logger) gin.HandlerFunc {
+// RegisterN8nWebhookRoutes registers the webhook instances report their workflow executions to
+func RegisterN8nWebhookRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	webhookRoutes := router.Group("/api/v1/webhooks")
+	webhookRoutes.POST("/n8n", N8nWebhook(cfg, logger))
+	webhookRoutes.POST("/n8n/", N8nWebhook(cfg, logger))
+}
+
+// n8nWebhookSecret returns the secret the events of an instance are signed with: the
+// instance's own, or N8N_WEBHOOK_SECRET for instances created before they had one
+func n8nWebhookSecret(cfg *config.Config, instanceID uuid.UUID) (string, error) {
+	credential, err := db.GetInstanceCredential(instanceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return cfg.N8N.WebhookSecret, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !credential.HasWebhookSecret() {
+		return cfg.N8N.WebhookSecret, nil
+	}
+	return credential.WebhookSecret()
+}
+
+// N8nWebhook handles webhook events from n8n instances. Each event is verified with the
+// secret of the instance in its instanceId, so one instance cannot sign events of another.
+func N8nWebhook(cfg *config.Config, logger *logrus.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Read request body
 		body, err := io.ReadAll(c.Request.Body)
@@ -32,8 +64,24 @@ func N8nWebhook(webhookSecret string, logger *logrus.Logger) gin.HandlerFunc {
 			return
 		}
 
-		// Restore the request body for binding
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		// The instance the event claims to come from selects the secret it must be signed with
+		var webhook N8nWebhookRequest
+		if err := json.Unmarshal(body, &webhook); err != nil {
+			logger.WithError(err).Error("Failed to parse n8n webhook")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+			return
+		}
+		instanceID, err := uuid.Parse(webhook.InstanceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+			return
+		}
+		webhookSecret, err := n8nWebhookSecret(cfg, instanceID)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instanceID).Error("Failed to get n8n webhook secret")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify signature"})
+			return
+		}
 
 		// Verify webhook signature if secret is provided
 		if webhookSecret != "" {
@@ -57,14 +105,6 @@ func N8nWebhook(webhookSecret string, logger *logrus.Logger) gin.HandlerFunc {
 			}
 		}
 
-		// Parse webhook request
-		var webhook N8nWebhookRequest
-		if err := c.ShouldBindJSON(&webhook); err != nil {
-			logger.WithError(err).Error("Failed to parse n8n webhook")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
-			return
-		}
-
 		// Handle different event types
 		switch webhook.Event {
 		case "workflow.started":
@@ -85,32 +125,28 @@ func N8nWebhook(webhookSecret string, logger *logrus.Logger) gin.HandlerFunc {
 
 // handleWorkflowStarted handles workflow.started events
 func handleWorkflowStarted(c *gin.Context, webhook N8nWebhookRequest, logger *logrus.Logger) {
-	// In a real implementation, you would track workflow executions in your database
 	logger.WithFields(logrus.Fields{
 		"instance_id":  webhook.InstanceID,
 		"workflow_id":  webhook.WorkflowID,
 		"execution_id": webhook.ExecutionID,
 	}).Info("Workflow started")
 
-	c.JSON(http.StatusOK, gin.H{"status": "success"})
+	recordWorkflowExecution(c, webhook, models.ExecutionRunning, logger)
 }
 
 // handleWorkflowCompleted handles workflow.completed events
 func handleWorkflowCompleted(c *gin.Context, webhook N8nWebhookRequest, logger *logrus.Logger) {
-	// In a real implementation, you would update workflow execution status in your database
 	logger.WithFields(logrus.Fields{
 		"instance_id":  webhook.InstanceID,
 		"workflow_id":  webhook.WorkflowID,
 		"execution_id": webhook.ExecutionID,
 	}).Info("Workflow completed")
 
-	c.JSON(http.StatusOK, gin.H{"status": "success"})
+	recordWorkflowExecution(c, webhook, models.ExecutionSucceeded, logger)
 }
 
 // handleWorkflowFailed handles workflow.failed events
 func handleWorkflowFailed(c *gin.Context, webhook N8nWebhookRequest, logger *logrus.Logger) {
-	// In a real implementation, you would update workflow execution status in your database
-	// and potentially trigger notifications
 	logger.WithFields(logrus.Fields{
 		"instance_id":  webhook.InstanceID,
 		"workflow_id":  webhook.WorkflowID,
@@ -118,9 +154,109 @@ func handleWorkflowFailed(c *gin.Context, webhook N8nWebhookRequest, logger *log
 		"error":        webhook.Payload["error"],
 	}).Warn("Workflow failed")
 
+	recordWorkflowExecution(c, webhook, models.ExecutionFailed, logger)
+}
+
+// recordWorkflowExecution stores a workflow event as the execution it belongs to. The payload
+// can carry the workflowName, the execution mode, and startedAt and stoppedAt as RFC 3339
+// times; events without times are timed by their arrival.
+func recordWorkflowExecution(c *gin.Context, webhook N8nWebhookRequest, status models.WorkflowExecutionStatus, logger *logrus.Logger) {
+	instanceID, err := uuid.Parse(webhook.InstanceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instance ID"})
+		return
+	}
+	if webhook.WorkflowID == "" || webhook.ExecutionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing workflow or execution ID"})
+		return
+	}
+	if len(webhook.WorkflowID) > 100 || len(webhook.ExecutionID) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workflow or execution ID too long"})
+		return
+	}
+	if _, err := db.GetInstanceByID(instanceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Instance not found"})
+			return
+		}
+		logger.WithError(err).Error("Failed to get instance of workflow event")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record workflow execution"})
+		return
+	}
+
+	now := time.Now()
+	event := &models.WorkflowExecution{
+		InstanceID:   instanceID,
+		WorkflowID:   webhook.WorkflowID,
+		ExecutionID:  webhook.ExecutionID,
+		WorkflowName: truncateString(payloadString(webhook.Payload, "workflowName"), 255),
+		Mode:         truncateString(payloadString(webhook.Payload, "mode"), 50),
+		Status:       status,
+		StartedAt:    payloadTime(webhook.Payload, "startedAt"),
+	}
+	if status == models.ExecutionRunning {
+		if event.StartedAt == nil {
+			event.StartedAt = &now
+		}
+	} else {
+		event.FinishedAt = payloadTime(webhook.Payload, "stoppedAt")
+		if event.FinishedAt == nil {
+			event.FinishedAt = &now
+		}
+	}
+	if status == models.ExecutionFailed {
+		event.Error = truncateString(workflowError(webhook.Payload["error"]), 1000)
+	}
+
+	if _, err := db.RecordWorkflowExecution(event); err != nil {
+		logger.WithError(err).Error("Failed to record workflow execution")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record workflow execution"})
+		return
+	}
+	// Executions keep instances of sleeping plans awake
+	if err := db.RecordInstanceActivity(instanceID, now); err != nil {
+		logger.WithError(err).WithField("instance_id", instanceID).Warn("Failed to record instance activity")
+	}
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
+// payloadString returns a string field of an event payload, or "" if it has none
+func payloadString(payload map[string]interface{}, key string) string {
+	value, _ := payload[key].(string)
+	return value
+}
+
+// payloadTime returns an RFC 3339 time field of an event payload, or nil if it has none
+func payloadTime(payload map[string]interface{}, key string) *time.Time {
+	t, err := time.Parse(time.RFC3339, payloadString(payload, key))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// workflowError returns the message of the error of a failed execution, which n8n sends either
+// as a string or as an object with a message
+func workflowError(value interface{}) string {
+	switch e := value.(type) {
+	case string:
+		return e
+	case map[string]interface{}:
+		if message, ok := e["message"].(string); ok {
+			return message
+		}
+	}
+	return ""
+}
+
+// truncateString shortens a string to fit a column, dropping a character cut in half
+func truncateString(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return strings.ToValidUTF8(value[:length], "")
+}
+
 // handleInstanceStatus handles instance.status events
 func handleInstanceStatus(c *gin.Context, webhook N8nWebhookRequest, logger *logrus.Logger) {
 	// In a real implementation, you would update instance status in your database
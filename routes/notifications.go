@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RegisterNotificationRoutes registers the routes of the notification center
+func RegisterNotificationRoutes(router *gin.Engine, logger *logrus.Logger) {
+	v1NotificationRoutes := router.Group("/api/v1/notifications")
+	v1NotificationRoutes.GET("", GetNotifications())
+	v1NotificationRoutes.GET("/", GetNotifications())
+	v1NotificationRoutes.POST("/read-all", MarkAllNotificationsRead())
+	v1NotificationRoutes.POST("/:id/read", MarkNotificationRead())
+}
+
+// GetNotifications lists the current user's newest notifications with the number of unread
+// ones, for the notification bell. Pass unread=true to only list unread notifications.
+func GetNotifications() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		limit := 50
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 200 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+				return
+			}
+			limit = parsed
+		}
+
+		notifications, err := db.GetNotifications(userID, c.Query("unread") == "true", limit)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Failed to get notifications")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+			return
+		}
+		unread, err := db.CountUnreadNotifications(userID)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Failed to count unread notifications")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(notifications))
+		for i := range notifications {
+			response[i] = notifications[i].ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"notifications": response,
+			"unread_count":  unread,
+		})
+	}
+}
+
+// MarkNotificationRead marks one of the current user's notifications as read
+func MarkNotificationRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		notificationID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+			return
+		}
+
+		notification, err := db.GetNotification(notificationID, userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		if err == nil {
+			err = db.MarkNotificationRead(notification)
+		}
+		if err != nil {
+			logger.WithError(err).WithField("notification_id", notificationID).Error("Failed to mark notification as read")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+
+		c.JSON(http.StatusOK, notification.ToPublicResponse())
+	}
+}
+
+// MarkAllNotificationsRead marks every unread notification of the current user as read
+func MarkAllNotificationsRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		marked, err := db.MarkAllNotificationsRead(userID)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Failed to mark notifications as read")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"marked_read": marked})
+	}
+}
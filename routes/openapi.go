@@ -0,0 +1,176 @@
+package routes
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/sirupsen/logrus"
+)
+
+// openAPIDocument is the hand-maintained OpenAPI 3 description of the v1 API. Keep it in
+// step with the handlers; routes it misses are still listed, as undocumented operations.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// swaggerUIPage renders the OpenAPI document with Swagger UI
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>LaunchStack API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+<script>
+window.onload = function () {
+  window.ui = SwaggerUIBundle({ url: "/api/v1/openapi.json", dom_id: "#swagger-ui", persistAuthorization: true });
+};
+</script>
+</body>
+</html>
+`
+
+// apiV1Prefix is where the paths of the OpenAPI document are served from
+const apiV1Prefix = "/api/v1"
+
+// pathParamPattern matches gin path parameters such as ":id"
+var pathParamPattern = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+// RegisterDocsRoutes registers the OpenAPI document and the Swagger UI that renders it
+func RegisterDocsRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	router.GET("/api/v1/openapi.json", GetOpenAPIDocument(router))
+	router.GET("/api/v1/docs", GetSwaggerUI())
+}
+
+// GetOpenAPIDocument serves the OpenAPI document, completed with the routes registered on the
+// router that it does not describe yet. It is built on the first request, once every route
+// has been registered.
+func GetOpenAPIDocument(router *gin.Engine) gin.HandlerFunc {
+	var (
+		once     sync.Once
+		document []byte
+		buildErr error
+	)
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		once.Do(func() {
+			document, buildErr = buildOpenAPIDocument(router.Routes())
+		})
+		if buildErr != nil {
+			logger.WithError(buildErr).Error("Failed to build OpenAPI document")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build API documentation"})
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", document)
+	}
+}
+
+// GetSwaggerUI serves a Swagger UI page for the OpenAPI document
+func GetSwaggerUI() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+	}
+}
+
+// buildOpenAPIDocument adds the v1 routes missing from the embedded document to it
+func buildOpenAPIDocument(routes gin.RoutesInfo) ([]byte, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(openAPIDocument, &document); err != nil {
+		return nil, err
+	}
+	paths, _ := document["paths"].(map[string]interface{})
+	if paths == nil {
+		paths = map[string]interface{}{}
+		document["paths"] = paths
+	}
+
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, apiV1Prefix+"/") {
+			continue
+		}
+		// Routes are registered with and without a trailing slash; only one is documented
+		path := pathParamPattern.ReplaceAllString(strings.TrimSuffix(strings.TrimPrefix(route.Path, apiV1Prefix), "/"), "{$1}")
+		method := strings.ToLower(route.Method)
+		if documentedOperation(paths, path, method) {
+			continue
+		}
+
+		operation := map[string]interface{}{
+			"tags":        []string{"Undocumented"},
+			"summary":     handlerName(route.Handler),
+			"description": "Not described in the OpenAPI document yet.",
+			"responses": map[string]interface{}{
+				"default": map[string]interface{}{"description": "See docs/API.md"},
+			},
+		}
+		var parameters []map[string]interface{}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]string{"type": "string"},
+			})
+		}
+		if parameters != nil {
+			operation["parameters"] = parameters
+		}
+
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[method] = operation
+	}
+
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// documentedOperation checks if the document describes an operation on a path, either
+// literally or through a templated path such as "/webhooks/{provider}"
+func documentedOperation(paths map[string]interface{}, path, method string) bool {
+	segments := strings.Split(path, "/")
+	for documented, item := range paths {
+		operations, _ := item.(map[string]interface{})
+		if _, ok := operations[method]; !ok {
+			continue
+		}
+		documentedSegments := strings.Split(documented, "/")
+		if len(documentedSegments) != len(segments) {
+			continue
+		}
+		matches := true
+		for i, segment := range documentedSegments {
+			if segment != segments[i] && !strings.HasPrefix(segment, "{") {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerName shortens a handler's function name, e.g.
+// "github.com/launchstack/backend/routes.GetInstanceSpec.func1", to "GetInstanceSpec"
+func handlerName(name string) string {
+	parts := strings.Split(name, ".")
+	for len(parts) > 1 && strings.HasPrefix(parts[len(parts)-1], "func") {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[len(parts)-1]
+}
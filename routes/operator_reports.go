@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterOperatorReportRoutes registers the admin routes of operator reports
+func RegisterOperatorReportRoutes(router *gin.Engine, reports *jobs.OperatorReportJob, logger *logrus.Logger) {
+	v1AdminRoutes := router.Group("/api/v1/admin")
+	v1AdminRoutes.Use(middleware.RequireAdmin())
+	v1AdminRoutes.GET("/reports", AdminListOperatorReports())
+	v1AdminRoutes.GET("/reports/:id", AdminGetOperatorReport())
+	v1AdminRoutes.POST("/reports", AdminGenerateOperatorReport(reports))
+}
+
+// AdminListOperatorReports returns summaries of the newest operator reports, to follow trends
+func AdminListOperatorReports() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "12"))
+		if err != nil || limit <= 0 || limit > 100 {
+			limit = 12
+		}
+
+		reports, err := db.GetOperatorReports(limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get operator reports"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(reports))
+		for i := range reports {
+			response[i] = reports[i].ToSummaryResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AdminGetOperatorReport returns an operator report with all its sections
+func AdminGetOperatorReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+			return
+		}
+
+		report, err := db.GetOperatorReport(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Operator report not found"})
+			return
+		}
+		c.JSON(http.StatusOK, report.ToPublicResponse())
+	}
+}
+
+// AdminGenerateOperatorReport generates and delivers an operator report now, without waiting
+// for the schedule. The next scheduled report follows the interval from this one.
+func AdminGenerateOperatorReport(reports *jobs.OperatorReportJob) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		report, err := reports.Generate(c.Request.Context())
+		if err != nil {
+			logger.WithError(err).Error("Failed to generate operator report")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate operator report"})
+			return
+		}
+		reports.Deliver(c.Request.Context(), report)
+
+		c.JSON(http.StatusCreated, report.ToPublicResponse())
+	}
+}
@@ -0,0 +1,701 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OrganizationRequest represents a request to create or rename an organization
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// OrgMemberRequest represents a request to change the role of a member
+type OrgMemberRequest struct {
+	Role models.OrgRole `json:"role" binding:"required"`
+}
+
+// OrgInvitationRequest represents a request to invite someone to an organization
+type OrgInvitationRequest struct {
+	Email string         `json:"email" binding:"required,email"`
+	Role  models.OrgRole `json:"role" binding:"required"`
+}
+
+// InstanceOrganizationRequest represents a request to share an instance with an organization;
+// a null organization stops sharing it
+type InstanceOrganizationRequest struct {
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+// RegisterOrganizationRoutes registers the routes of organizations, their members and
+// invitations
+func RegisterOrganizationRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	v1OrgRoutes := router.Group("/api/v1/organizations")
+
+	v1OrgRoutes.GET("", GetOrganizations())
+	v1OrgRoutes.POST("", CreateOrganization())
+
+	// Invitations of the current user, by email address
+	v1OrgRoutes.GET("/invitations", GetMyOrgInvitations())
+	v1OrgRoutes.POST("/invitations/:invitation_id/accept", AcceptOrgInvitation())
+
+	v1OrgRoutes.GET("/:id", GetOrganization())
+	v1OrgRoutes.PUT("/:id", UpdateOrganization())
+	v1OrgRoutes.DELETE("/:id", DeleteOrganization())
+	v1OrgRoutes.GET("/:id/instances", GetOrganizationInstances())
+	v1OrgRoutes.GET("/:id/members", GetOrgMembers())
+	v1OrgRoutes.PUT("/:id/members/:user_id", UpdateOrgMember())
+	v1OrgRoutes.DELETE("/:id/members/:user_id", RemoveOrgMember())
+	v1OrgRoutes.GET("/:id/invitations", GetOrgInvitations())
+	v1OrgRoutes.POST("/:id/invitations", CreateOrgInvitation())
+	v1OrgRoutes.DELETE("/:id/invitations/:invitation_id", RevokeOrgInvitation())
+}
+
+// authorizeInstance checks that the current user may act on an instance with the permissions
+// of role: its owner may do anything, members of the organization it is shared with what
+// their role allows, and users it is shared with may view it. It writes the error response
+// and returns false otherwise.
+func authorizeInstance(c *gin.Context, instance *models.Instance, role models.OrgRole) bool {
+	var access models.ShareAccess
+	if role == models.OrgRoleViewer {
+		access = models.ShareReadOnly
+	}
+	return authorizeInstanceAccess(c, instance, role, access)
+}
+
+// authorizeInstanceOperation checks that the current user may start, stop, restart or wake an
+// instance, which organization members and users it is shared with as operators may do
+func authorizeInstanceOperation(c *gin.Context, instance *models.Instance) bool {
+	return authorizeInstanceAccess(c, instance, models.OrgRoleMember, models.ShareOperator)
+}
+
+// authorizeInstanceAccess checks that the current user owns an instance, is a member of its
+// organization with at least role, or has a share of it with at least access. Shares never
+// suffice when access is empty.
+func authorizeInstanceAccess(c *gin.Context, instance *models.Instance, role models.OrgRole, access models.ShareAccess) bool {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+		return false
+	}
+	if instance.UserID == userID {
+		return true
+	}
+
+	var membership *models.OrgMembership
+	if instance.OrganizationID != nil {
+		membership, err = db.GetOrgMembership(*instance.OrganizationID, userID)
+		if err == nil && membership.Role.AtLeast(role) {
+			return true
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check organization membership"})
+			return false
+		}
+	}
+
+	share, err := db.GetInstanceShare(instance.ID, userID)
+	if err == nil && access != "" && share.Access.AtLeast(access) {
+		return true
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check instance shares"})
+		return false
+	}
+
+	switch {
+	case membership != nil:
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "org_role_insufficient", role))
+	case share != nil:
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "share_access_insufficient"))
+	default:
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "instance_access_denied"))
+	}
+	return false
+}
+
+// loadOrganization fetches the organization in the :id param and checks the current user is
+// a member with at least role. It writes the error response and returns nil otherwise.
+func loadOrganization(c *gin.Context, role models.OrgRole) (*models.Organization, *models.OrgMembership) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+		return nil, nil
+	}
+	orgID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+		return nil, nil
+	}
+
+	// Non-members are told the organization does not exist
+	membership, err := db.GetOrgMembership(orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "organization_not_found"))
+		return nil, nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get organization"})
+		return nil, nil
+	}
+	org, err := db.GetOrganizationByID(orgID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "organization_not_found"))
+		return nil, nil
+	}
+
+	if !membership.Role.AtLeast(role) {
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "org_role_insufficient", role))
+		return nil, nil
+	}
+	return org, membership
+}
+
+// rejectClerkOrganization blocks managing the members of organizations mirrored from Clerk,
+// which follow their memberships in Clerk
+func rejectClerkOrganization(c *gin.Context, org *models.Organization) bool {
+	if org.ClerkOrgID != nil {
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, "org_managed_by_clerk"))
+		return true
+	}
+	return false
+}
+
+// bindOrgRole validates a role given in a request, which the current member must be allowed
+// to grant: admins grant every role but owner. It writes the error response and returns false
+// otherwise.
+func bindOrgRole(c *gin.Context, role models.OrgRole, membership *models.OrgMembership) bool {
+	if !role.Valid() {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_org_role"))
+		return false
+	}
+	if role == models.OrgRoleOwner && membership.Role != models.OrgRoleOwner {
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "org_role_insufficient", models.OrgRoleOwner))
+		return false
+	}
+	return true
+}
+
+// GetOrganizations returns the organizations the current user is a member of, with their role
+func GetOrganizations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		memberships, err := db.GetMembershipsByUserID(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get organizations"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(memberships))
+		for i, membership := range memberships {
+			response[i] = membership.Organization.ToPublicResponse(membership.Role)
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateOrganization creates an organization owned by the current user
+func CreateOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		var req OrganizationRequest
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+
+		org := &models.Organization{Name: strings.TrimSpace(req.Name)}
+		if err := db.CreateOrganization(org, userID); err != nil {
+			logger.WithError(err).Error("Failed to create organization")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{"organization_id": org.ID, "user_id": userID}).Info("Created organization")
+		c.JSON(http.StatusCreated, org.ToPublicResponse(models.OrgRoleOwner))
+	}
+}
+
+// GetOrganization returns an organization the current user is a member of
+func GetOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, membership := loadOrganization(c, models.OrgRoleViewer)
+		if org == nil {
+			return
+		}
+		c.JSON(http.StatusOK, org.ToPublicResponse(membership.Role))
+	}
+}
+
+// UpdateOrganization renames an organization; admins and owners may
+func UpdateOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		org, membership := loadOrganization(c, models.OrgRoleAdmin)
+		if org == nil {
+			return
+		}
+
+		var req OrganizationRequest
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+
+		org.Name = strings.TrimSpace(req.Name)
+		if err := db.UpdateOrganization(org); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update organization"})
+			return
+		}
+		c.JSON(http.StatusOK, org.ToPublicResponse(membership.Role))
+	}
+}
+
+// DeleteOrganization deletes an organization once no instances are shared with it; owners may
+func DeleteOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		org, _ := loadOrganization(c, models.OrgRoleOwner)
+		if org == nil {
+			return
+		}
+
+		if err := db.DeleteOrganization(org.ID); err != nil {
+			if errors.Is(err, db.ErrOrganizationHasInstances) {
+				c.JSON(http.StatusConflict, middleware.ErrorBody(c, "organization_has_instances"))
+				return
+			}
+			logger.WithError(err).WithField("organization_id", org.ID).Error("Failed to delete organization")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete organization"})
+			return
+		}
+
+		logger.WithField("organization_id", org.ID).Info("Deleted organization")
+		c.JSON(http.StatusOK, gin.H{"message": "Organization deleted successfully"})
+	}
+}
+
+// GetOrganizationInstances returns a page of the instances shared with an organization, with
+// the number of instances matching the filters in the X-Total-Count header
+func GetOrganizationInstances() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _ := loadOrganization(c, models.OrgRoleViewer)
+		if org == nil {
+			return
+		}
+
+		opts, ok := bindListOptions(c, instanceListQuery)
+		if !ok {
+			return
+		}
+		instances, total, err := db.ListInstancesByOrganizationID(org.ID, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(instances))
+		for i, instance := range instances {
+			response[i] = instance.ToPublicResponse()
+		}
+		setTotalCount(c, total)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetOrgMembers returns the members of an organization with their roles
+func GetOrgMembers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _ := loadOrganization(c, models.OrgRoleViewer)
+		if org == nil {
+			return
+		}
+
+		members, err := db.GetOrgMembers(org.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get organization members"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(members))
+		for i, member := range members {
+			response[i] = member.ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UpdateOrgMember changes the role of a member. Admins manage the members other than owners;
+// only owners make others owners or change an owner's role.
+func UpdateOrgMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		org, membership := loadOrganization(c, models.OrgRoleAdmin)
+		if org == nil || rejectClerkOrganization(c, org) {
+			return
+		}
+		member := loadOrgMember(c, org, membership)
+		if member == nil {
+			return
+		}
+
+		var req OrgMemberRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+		if !bindOrgRole(c, req.Role, membership) {
+			return
+		}
+
+		member.Role = req.Role
+		if err := db.SaveOrgMembership(member); err != nil {
+			if errors.Is(err, db.ErrLastOwner) {
+				c.JSON(http.StatusConflict, middleware.ErrorBody(c, "org_last_owner"))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+			return
+		}
+		c.JSON(http.StatusOK, member.ToPublicResponse())
+	}
+}
+
+// RemoveOrgMember removes a member from an organization. Members may leave themselves; admins
+// remove the members other than owners, and owners anyone but the last owner.
+func RemoveOrgMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		org, membership := loadOrganization(c, models.OrgRoleViewer)
+		if org == nil || rejectClerkOrganization(c, org) {
+			return
+		}
+
+		var member *models.OrgMembership
+		if c.Param("user_id") == membership.UserID.String() {
+			member = membership
+		} else {
+			if !membership.Role.AtLeast(models.OrgRoleAdmin) {
+				c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "org_role_insufficient", models.OrgRoleAdmin))
+				return
+			}
+			if member = loadOrgMember(c, org, membership); member == nil {
+				return
+			}
+		}
+
+		if err := db.RemoveOrgMember(org.ID, member.UserID); err != nil {
+			if errors.Is(err, db.ErrLastOwner) {
+				c.JSON(http.StatusConflict, middleware.ErrorBody(c, "org_last_owner"))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+	}
+}
+
+// loadOrgMember fetches the membership of the user in the :user_id param, which the current
+// member must be allowed to manage. It writes the error response and returns nil otherwise.
+func loadOrgMember(c *gin.Context, org *models.Organization, membership *models.OrgMembership) *models.OrgMembership {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+		return nil
+	}
+	member, err := db.GetOrgMembership(org.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "org_member_not_found"))
+		return nil
+	}
+	if member.Role == models.OrgRoleOwner && membership.Role != models.OrgRoleOwner {
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "org_role_insufficient", models.OrgRoleOwner))
+		return nil
+	}
+	return member
+}
+
+// GetOrgInvitations returns the invitations to an organization that can still be accepted
+func GetOrgInvitations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, _ := loadOrganization(c, models.OrgRoleAdmin)
+		if org == nil {
+			return
+		}
+
+		invitations, err := db.GetPendingOrgInvitations(org.ID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invitations"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(invitations))
+		for i, invitation := range invitations {
+			response[i] = invitation.ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateOrgInvitation invites an email address to an organization. Whoever signs in with the
+// address can accept it until it expires; users who already have an account are notified.
+func CreateOrgInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		org, membership := loadOrganization(c, models.OrgRoleAdmin)
+		if org == nil || rejectClerkOrganization(c, org) {
+			return
+		}
+
+		var req OrgInvitationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+		if !bindOrgRole(c, req.Role, membership) {
+			return
+		}
+
+		invitation := models.NewOrgInvitation(org.ID, req.Email, req.Role, membership.UserID)
+		invitation.Organization = *org
+		if err := db.CreateOrgInvitation(invitation); err != nil {
+			logger.WithError(err).WithField("organization_id", org.ID).Error("Failed to create invitation")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invitation"})
+			return
+		}
+		notifyInvitee(invitation, org, logger)
+
+		logger.WithFields(logrus.Fields{
+			"organization_id": org.ID,
+			"invitation_id":   invitation.ID,
+			"role":            invitation.Role,
+		}).Info("Invited to organization")
+		c.JSON(http.StatusCreated, invitation.ToPublicResponse())
+	}
+}
+
+// notifyInvitee tells the user with the invited email address about an invitation, if they
+// have an account. Failures are only logged.
+func notifyInvitee(invitation *models.OrgInvitation, org *models.Organization, logger *logrus.Logger) {
+	invitee, err := db.FindUserByEmail(invitation.Email)
+	if err != nil {
+		return
+	}
+	notification := &models.Notification{
+		UserID:  invitee.ID,
+		Type:    models.NotificationOrgInvitation,
+		Level:   models.EventLevelInfo,
+		Title:   fmt.Sprintf("Invitation to %s", org.Name),
+		Message: fmt.Sprintf("You were invited to join %s as %s. Accept the invitation to see its instances.", org.Name, invitation.Role),
+	}
+	if err := db.CreateNotification(notification); err != nil {
+		logger.WithError(err).WithField("invitation_id", invitation.ID).Warn("Failed to notify invitee")
+	}
+}
+
+// RevokeOrgInvitation revokes an invitation to an organization
+func RevokeOrgInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		org, _ := loadOrganization(c, models.OrgRoleAdmin)
+		if org == nil {
+			return
+		}
+
+		invitationID, err := uuid.Parse(c.Param("invitation_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+		invitation, err := db.GetOrgInvitationByID(invitationID)
+		if err != nil || invitation.OrganizationID != org.ID {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "org_invitation_not_found"))
+			return
+		}
+
+		if err := db.DeleteOrgInvitation(invitation.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke invitation"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked successfully"})
+	}
+}
+
+// GetMyOrgInvitations returns the invitations to the current user's email address that can
+// still be accepted
+func GetMyOrgInvitations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		invitations, err := db.GetPendingOrgInvitationsByEmail(user.Email, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invitations"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(invitations))
+		for i, invitation := range invitations {
+			response[i] = invitation.ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AcceptOrgInvitation makes the current user a member of the organization an invitation to
+// their email address is for
+func AcceptOrgInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectScopedAPIKey(c) {
+			return
+		}
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		invitationID, err := uuid.Parse(c.Param("invitation_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+		// Invitations to other addresses are not disclosed
+		invitation, err := db.GetOrgInvitationByID(invitationID)
+		if err != nil || invitation.Email != models.NormalizeEmail(user.Email) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "org_invitation_not_found"))
+			return
+		}
+
+		membership, err := db.AcceptOrgInvitation(invitation, user.ID)
+		if err != nil {
+			if errors.Is(err, db.ErrInvitationNotPending) {
+				c.JSON(http.StatusConflict, middleware.ErrorBody(c, "org_invitation_not_pending"))
+				return
+			}
+			logger.WithError(err).WithField("invitation_id", invitation.ID).Error("Failed to accept invitation")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"organization_id": invitation.OrganizationID,
+			"user_id":         user.ID,
+			"role":            membership.Role,
+		}).Info("Accepted invitation to organization")
+		c.JSON(http.StatusOK, invitation.Organization.ToPublicResponse(membership.Role))
+	}
+}
+
+// SetInstanceOrganization shares an instance with an organization or stops sharing it. Only
+// the instance's owner shares it, with an organization they may create instances in; admins
+// of the organization it is shared with may also stop sharing it.
+func SetInstanceOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleAdmin)
+		if instance == nil {
+			return
+		}
+
+		var req InstanceOrganizationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+
+		if req.OrganizationID != nil {
+			userID, _ := middleware.GetUserIDFromContext(c)
+			if instance.UserID != userID {
+				c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "instance_access_denied"))
+				return
+			}
+			if !authorizeOrgInstanceCreation(c, *req.OrganizationID, userID) {
+				return
+			}
+		}
+
+		if err := db.SetInstanceOrganization(instance.ID, req.OrganizationID); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to update instance organization")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance organization"})
+			return
+		}
+		instance.OrganizationID = req.OrganizationID
+
+		logger.WithFields(logrus.Fields{
+			"instance_id":     instance.ID,
+			"organization_id": req.OrganizationID,
+		}).Info("Changed organization of instance")
+		c.JSON(http.StatusOK, instance.ToPublicResponse())
+	}
+}
+
+// authorizeOrgInstanceCreation checks that a user may create instances in an organization,
+// i.e. is a member with at least the member role. It writes the error response and returns
+// false otherwise.
+func authorizeOrgInstanceCreation(c *gin.Context, orgID, userID uuid.UUID) bool {
+	membership, err := db.GetOrgMembership(orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "organization_not_found"))
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check organization membership"})
+		return false
+	}
+	if !membership.Role.AtLeast(models.OrgRoleMember) {
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, "org_role_insufficient", models.OrgRoleMember))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/db"
+)
+
+const (
+	// defaultListLimit is the page size of listings without a limit parameter
+	defaultListLimit = 100
+	// maxListLimit is the largest page size a listing returns
+	maxListLimit = 500
+	// totalCountHeader reports how many items of a listing match its filters
+	totalCountHeader = "X-Total-Count"
+)
+
+// listQuery describes what a listing can be sorted and filtered by. Sorts map the values of
+// the sort parameter to columns, filters map query parameters to columns.
+type listQuery struct {
+	sorts       map[string]string
+	filters     map[string]string
+	defaultSort string // a sort value, prefixed with "-" to sort in descending order
+}
+
+// bindListOptions reads the limit, offset, sort and q parameters and the filters of a listing,
+// responding with 400 and returning false if one of them is invalid
+func bindListOptions(c *gin.Context, query listQuery) (db.ListOptions, bool) {
+	opts := db.ListOptions{
+		Limit:   defaultListLimit,
+		Filters: map[string]string{},
+		Search:  strings.TrimSpace(c.Query("q")),
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxListLimit)})
+			return opts, false
+		}
+		opts.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return opts, false
+		}
+		opts.Offset = offset
+	}
+
+	sortParam := c.DefaultQuery("sort", query.defaultSort)
+	opts.Desc = strings.HasPrefix(sortParam, "-")
+	column, ok := query.sorts[strings.TrimPrefix(sortParam, "-")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of " + strings.Join(sortValues(query), ", ") + ", optionally prefixed with -"})
+		return opts, false
+	}
+	opts.Sort = column
+
+	for param, column := range query.filters {
+		if value := c.Query(param); value != "" {
+			opts.Filters[column] = value
+		}
+	}
+	return opts, true
+}
+
+// sortValues lists the values the sort parameter of a listing accepts, in alphabetical order
+func sortValues(query listQuery) []string {
+	values := make([]string, 0, len(query.sorts))
+	for value := range query.sorts {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// setTotalCount reports the number of items matching the filters of a listing
+func setTotalCount(c *gin.Context, total int64) {
+	c.Header(totalCountHeader, strconv.FormatInt(total, 10))
+}
@@ -0,0 +1,631 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/payments"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CheckoutRequest represents a request to pay for a plan
+type CheckoutRequest struct {
+	Plan       string `json:"plan"`
+	SuccessURL string `json:"success_url"`
+	CancelURL  string `json:"cancel_url"`
+}
+
+// CreateCheckoutSession creates a checkout session for subscription with the payment provider
+func CreateCheckoutSession(provider payments.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		// Get user ID from context (set by auth middleware)
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_authenticated"))
+			return
+		}
+
+		// Parse request body
+		var req CheckoutRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+
+		// Validate plan
+		if plan, ok := models.GetPlan(models.SubscriptionPlan(req.Plan)); !ok || !plan.Paid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan selected"})
+			return
+		}
+
+		// Find user in database
+		var user models.User
+		if err := db.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		// The plan's trial is only offered to users who never had one
+		trialDays := 0
+		if policy, ok := models.PlanTrial(models.SubscriptionPlan(req.Plan)); ok {
+			hadTrial, err := db.HasTrialGrant(user.ID)
+			if err != nil {
+				logger.WithError(err).WithField("user_id", user.ID).Error("Failed to check previous trials")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checkout"})
+				return
+			}
+			if !hadTrial {
+				trialDays = policy.Days
+			}
+		}
+
+		checkout, err := provider.CreateCheckout(c.Request.Context(), payments.CheckoutRequest{
+			UserID:     user.ID,
+			Email:      user.Email,
+			Plan:       models.SubscriptionPlan(req.Plan),
+			TrialDays:  trialDays,
+			SuccessURL: req.SuccessURL,
+			CancelURL:  req.CancelURL,
+		})
+		if errors.Is(err, payments.ErrPlanNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Plan is not available for purchase"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("provider", provider.Name()).Error("Failed to create checkout")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Create payment record in pending state
+		payment := models.Payment{
+			UserID:             user.ID,
+			Provider:           provider.Name(),
+			ProviderCheckoutID: checkout.ID,
+			Amount:             checkout.Amount,
+			Currency:           checkout.Currency,
+			Status:             models.PaymentStatusPending,
+			Description:        fmt.Sprintf("Subscription to %s plan", req.Plan),
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+		}
+
+		if err := db.DB.Create(&payment).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"checkout_url": checkout.URL,
+			"order_id":     checkout.ID,
+		})
+	}
+}
+
+// paymentListQuery is what payment listings can be sorted and filtered by
+var paymentListQuery = listQuery{
+	sorts: map[string]string{
+		"amount":     "amount",
+		"status":     "status",
+		"created_at": "created_at",
+	},
+	filters: map[string]string{
+		"status":   "status",
+		"provider": "provider",
+	},
+	defaultSort: "-created_at",
+}
+
+// GetPayments gets a page of the payment history of the current user, with the number of
+// payments matching the filters in the X-Total-Count header
+func GetPayments(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_authenticated"))
+		return
+	}
+
+	opts, ok := bindListOptions(c, paymentListQuery)
+	if !ok {
+		return
+	}
+
+	// Get payment history from database
+	payments, total, err := db.ListPaymentsByUserID(userID.(uuid.UUID), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payment history"})
+		return
+	}
+
+	// Convert to public response format
+	response := make([]map[string]interface{}, len(payments))
+	for i, payment := range payments {
+		response[i] = payment.ToPublicResponse()
+	}
+
+	setTotalCount(c, total)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSubscriptions gets subscription details for the current user
+func GetSubscriptions(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_authenticated"))
+		return
+	}
+
+	// Find user in database
+	var user models.User
+	if err := db.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+		return
+	}
+
+	// Check if user has an active subscription
+	if user.SubscriptionID == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"status": "no_subscription",
+			"plan":   user.Plan,
+		})
+		return
+	}
+
+	// Return subscription details
+	c.JSON(http.StatusOK, gin.H{
+		"id":                  user.SubscriptionID,
+		"plan":                user.Plan,
+		"status":              user.SubscriptionStatus,
+		"current_period_end":  user.CurrentPeriodEnd,
+		"cancel_at_period_end": user.SubscriptionStatus == models.StatusCanceled,
+	})
+}
+
+// loadOwnSubscriber finds the current user and checks that the subscription in the :id
+// param is theirs and is managed by the configured provider. It writes the error response
+// and returns nil when the subscription is not accessible.
+func loadOwnSubscriber(c *gin.Context, provider payments.Provider) *models.User {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_authenticated"))
+		return nil
+	}
+
+	// Get subscription ID from URL
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subscription ID is required"})
+		return nil
+	}
+
+	// Find user in database
+	var user models.User
+	if err := db.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+		return nil
+	}
+
+	// Verify that subscription belongs to user
+	if user.SubscriptionID != subscriptionID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to manage this subscription"})
+		return nil
+	}
+
+	// Subscriptions created before provider support was added are PayPal subscriptions
+	subscriptionProvider := user.SubscriptionProvider
+	if subscriptionProvider == "" {
+		subscriptionProvider = "paypal"
+	}
+	if subscriptionProvider != provider.Name() {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Subscription is managed by %s", subscriptionProvider)})
+		return nil
+	}
+
+	return &user
+}
+
+// CancelSubscription cancels the user's subscription
+func CancelSubscription(provider payments.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		user := loadOwnSubscriber(c, provider)
+		if user == nil {
+			return
+		}
+
+		if err := provider.CancelSubscription(c.Request.Context(), user.SubscriptionID); err != nil {
+			logger.WithError(err).WithField("provider", provider.Name()).Error("Failed to cancel subscription")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Update user subscription status
+		user.SubscriptionStatus = models.StatusCanceled
+		user.UpdatedAt = time.Now()
+
+		if err := db.DB.Save(user).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription status"})
+			return
+		}
+		db.InvalidateCachedUserByID(user.ID)
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Subscription will be canceled at the end of the current billing period",
+		})
+	}
+}
+
+// ChangeSubscriptionRequest represents a request to move a subscription to another plan
+type ChangeSubscriptionRequest struct {
+	Plan string `json:"plan" binding:"required"`
+}
+
+// ChangeSubscription moves the user's subscription to another plan. The provider moves the
+// subscription to the new plan's product, the prorated difference for the rest of the
+// current period is recorded as a payment unless the provider prorates itself, and the
+// new plan's resource limits are applied to the user's existing instances.
+func ChangeSubscription(provider payments.Provider, containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		var req ChangeSubscriptionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_format"))
+			return
+		}
+		plan := models.SubscriptionPlan(req.Plan)
+		if catalogPlan, ok := models.GetPlan(plan); !ok || !catalogPlan.Paid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan"})
+			return
+		}
+
+		user := loadOwnSubscriber(c, provider)
+		if user == nil {
+			return
+		}
+		if user.SubscriptionStatus != models.StatusActive {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only active subscriptions can change plans"})
+			return
+		}
+		if user.Plan == plan {
+			c.JSON(http.StatusConflict, gin.H{"error": "Subscription is already on this plan"})
+			return
+		}
+
+		change, err := provider.ChangeSubscription(c.Request.Context(), user.SubscriptionID, plan)
+		if errors.Is(err, payments.ErrPlanNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Plan changes are not configured"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("provider", provider.Name()).Error("Failed to change subscription plan")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		previous := user.Plan
+		proration := 0
+		if !change.ProratedByProvider {
+			proration = prorationAmount(*user, previous, plan, time.Now())
+		}
+
+		user.Plan = plan
+		user.UpdatedAt = time.Now()
+		err = db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(user).Error; err != nil {
+				return err
+			}
+			_, err := db.RecordPlanChange(tx, user.ID, previous, plan, fmt.Sprintf("Subscription changed from %s to %s plan", previous, plan))
+			return err
+		})
+		if err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to update subscription plan")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription plan"})
+			return
+		}
+		db.InvalidateCachedUserByID(user.ID)
+
+		if proration > 0 {
+			payment := models.Payment{
+				UserID:      user.ID,
+				Provider:    provider.Name(),
+				Amount:      proration,
+				Currency:    "usd",
+				Status:      models.PaymentStatusPending,
+				Description: fmt.Sprintf("Prorated upgrade from %s to %s plan", previous, plan),
+				Metadata:    fmt.Sprintf(`{"subscription_id": "%s"}`, user.SubscriptionID),
+			}
+			if err := db.DB.Create(&payment).Error; err != nil {
+				logger.WithError(err).WithField("user_id", user.ID).Error("Failed to record proration payment")
+			}
+		} else if proration < 0 {
+			// Downgrades are settled as a lower charge on the next renewal
+			reference := fmt.Sprintf("user:%s:proration:%s", user.ID, uuid.New())
+			description := fmt.Sprintf("Prorated downgrade from %s to %s plan", previous, plan)
+			if _, err := db.RecordCredit(db.DB, user.ID, int64(-proration), "usd", reference, description); err != nil {
+				logger.WithError(err).WithField("user_id", user.ID).Error("Failed to record proration credit")
+			}
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":          user.ID,
+			"subscription_id":  user.SubscriptionID,
+			"previous_plan":    previous,
+			"plan":             plan,
+			"proration_amount": proration,
+		}).Info("Subscription plan changed")
+
+		// Resizing may recreate containers, so it runs after the response
+		go applyPlanLimits(containerManager, *user, logger)
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":               "success",
+			"plan":                 user.Plan,
+			"proration_amount":     float64(proration) / 100,
+			"prorated_by_provider": change.ProratedByProvider,
+			"currency":             "usd",
+			"approval_url":         change.ApprovalURL,
+		})
+	}
+}
+
+// prorationAmount returns the difference in cents between two plans' monthly prices
+// for the part of the user's current billing period that is left. Downgrades yield a
+// negative amount, which the provider settles as a lower charge on the next renewal.
+func prorationAmount(user models.User, from, to models.SubscriptionPlan, now time.Time) int {
+	if user.CurrentPeriodEnd.IsZero() || !now.Before(user.CurrentPeriodEnd) {
+		return 0
+	}
+
+	periodStart := user.CurrentPeriodEnd.AddDate(0, -1, 0)
+	remaining := user.CurrentPeriodEnd.Sub(now).Seconds() / user.CurrentPeriodEnd.Sub(periodStart).Seconds()
+	if remaining > 1 {
+		remaining = 1
+	}
+
+	difference := models.GetPlanPrice(to, models.BillingMonthly) - models.GetPlanPrice(from, models.BillingMonthly)
+	return int(math.Round(difference * remaining * 100))
+}
+
+// PaymentWebhook handles webhook events from the payment provider
+func PaymentWebhook(provider payments.Provider, containerManager container.Manager, billingEnforcer *jobs.BillingEnforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		// Read request body
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+
+		events, err := provider.HandleWebhook(c.Request.Context(), c.Request.Header, body)
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			logger.WithField("provider", provider.Name()).Warn("Rejected payment webhook with invalid signature")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("provider", provider.Name()).Error("Invalid payment webhook")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event format"})
+			return
+		}
+
+		if len(events) == 0 {
+			// Acknowledge receipt of the webhook but take no action
+			c.JSON(http.StatusOK, gin.H{"status": "acknowledged"})
+			return
+		}
+
+		for _, event := range events {
+			if status, err := applyPaymentEvent(provider, containerManager, billingEnforcer, event, logger); err != nil {
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// applyPaymentEvent records a payment provider event on the payment or user it concerns,
+// returning the response status to use when it cannot be applied
+func applyPaymentEvent(provider payments.Provider, containerManager container.Manager, billingEnforcer *jobs.BillingEnforcer, event payments.WebhookEvent, logger *logrus.Logger) (int, error) {
+	if event.Type == payments.EventPaymentSucceeded {
+		// Payments created before provider support was added only have the PayPal order ID
+		var payment models.Payment
+		if err := db.DB.Where("provider_checkout_id = ? OR paypal_order_id = ?", event.CheckoutID, event.CheckoutID).First(&payment).Error; err != nil {
+			logger.WithError(err).Error("Failed to find payment record")
+			return http.StatusNotFound, fmt.Errorf("Payment record not found")
+		}
+
+		payment.Status = models.PaymentStatusSucceeded
+		payment.ProviderPaymentID = event.PaymentID
+		payment.UpdatedAt = time.Now()
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&payment).Error; err != nil {
+				return err
+			}
+			if _, err := db.RecordCharge(tx, &payment); err != nil {
+				return err
+			}
+			var user models.User
+			if err := tx.First(&user, "id = ?", payment.UserID).Error; err != nil {
+				return err
+			}
+			_, err := db.IssueInvoice(tx, &payment, user)
+			return err
+		})
+		if err != nil {
+			logger.WithError(err).Error("Failed to update payment record")
+			return http.StatusInternalServerError, fmt.Errorf("Failed to update payment")
+		}
+
+		logger.WithFields(logrus.Fields{
+			"payment_id":          payment.ID,
+			"provider_payment_id": event.PaymentID,
+		}).Info("Payment completed successfully")
+		return http.StatusOK, nil
+	}
+
+	if event.Type == payments.EventPaymentRefunded {
+		return applyRefund(event, logger)
+	}
+
+	var user models.User
+	var err error
+	if event.Type == payments.EventSubscriptionCreated {
+		err = db.DB.Where("id = ?", event.UserID).First(&user).Error
+	} else {
+		err = db.DB.Where("subscription_id = ?", event.SubscriptionID).First(&user).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.WithField("subscription_id", event.SubscriptionID).Error("Failed to find user for subscription event")
+		return http.StatusNotFound, fmt.Errorf("User not found")
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to find user for subscription event")
+		return http.StatusInternalServerError, fmt.Errorf("Failed to find user")
+	}
+
+	previousStatus := user.SubscriptionStatus
+	previousPlan := user.Plan
+	switch event.Type {
+	case payments.EventSubscriptionCreated:
+		user.SubscriptionID = event.SubscriptionID
+		user.SubscriptionProvider = provider.Name()
+		user.SubscriptionStatus = event.Status
+	case payments.EventSubscriptionUpdated:
+		user.SubscriptionStatus = event.Status
+	case payments.EventSubscriptionCancelled:
+		user.SubscriptionStatus = models.StatusCanceled
+	}
+	if !event.PeriodEnd.IsZero() {
+		user.CurrentPeriodEnd = event.PeriodEnd
+	}
+	planChanged := event.Plan != "" && event.Plan != user.Plan
+	if planChanged {
+		user.Plan = event.Plan
+	}
+	user.UpdatedAt = time.Now()
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return journalSubscriptionEvent(tx, user, previousPlan, previousStatus, event)
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to update user subscription")
+		return http.StatusInternalServerError, fmt.Errorf("Failed to update subscription")
+	}
+	db.InvalidateCachedUserByID(user.ID)
+
+	logger.WithFields(logrus.Fields{
+		"user_id":         user.ID,
+		"subscription_id": event.SubscriptionID,
+		"event":           event.Type,
+		"status":          user.SubscriptionStatus,
+	}).Info("Subscription updated from payment webhook")
+
+	if user.SubscriptionStatus == models.StatusCanceled && previousStatus != models.StatusCanceled {
+		notification := &models.Notification{
+			UserID:  user.ID,
+			Type:    models.NotificationSubscriptionCanceled,
+			Level:   models.EventLevelWarning,
+			Title:   "Subscription canceled",
+			Message: fmt.Sprintf("Your %s subscription was canceled. It stays active until %s.", user.Plan, user.CurrentPeriodEnd.Format("January 2, 2006")),
+		}
+		if err := db.CreateNotification(notification); err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to create notification")
+		}
+	}
+
+	// Plans can also change from the provider's own customer portal
+	if planChanged {
+		go applyPlanLimits(containerManager, user, logger)
+	}
+	// Resume instances suspended for billing as soon as the payment recovers
+	if user.SubscriptionStatus != previousStatus {
+		go billingEnforcer.CheckUser(context.Background(), user)
+	}
+	return http.StatusOK, nil
+}
+
+// journalSubscriptionEvent records the plan change and trial a subscription event brought
+// in the billing journal
+func journalSubscriptionEvent(tx *gorm.DB, user models.User, previousPlan models.SubscriptionPlan, previousStatus models.SubscriptionStatus, event payments.WebhookEvent) error {
+	if user.Plan != previousPlan {
+		description := fmt.Sprintf("Subscription %s moved from %s to %s plan", event.SubscriptionID, previousPlan, user.Plan)
+		if _, err := db.RecordPlanChange(tx, user.ID, previousPlan, user.Plan, description); err != nil {
+			return err
+		}
+	}
+	if user.SubscriptionStatus == models.StatusTrial && previousStatus != models.StatusTrial && !user.CurrentPeriodEnd.IsZero() {
+		days := int64(math.Ceil(time.Until(user.CurrentPeriodEnd).Hours() / 24))
+		if days > 0 {
+			reference := fmt.Sprintf("subscription:%s:trial", event.SubscriptionID)
+			description := fmt.Sprintf("%d day trial of subscription %s", days, event.SubscriptionID)
+			if _, err := db.RecordTrialGrant(tx, user.ID, days, reference, description); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyRefund records a refund reported by the payment provider on the refunded payment,
+// marking it refunded once it was refunded in full
+func applyRefund(event payments.WebhookEvent, logger *logrus.Logger) (int, error) {
+	var payment models.Payment
+	if err := db.DB.Where("provider_payment_id = ? OR paypal_payment_id = ?", event.PaymentID, event.PaymentID).First(&payment).Error; err != nil {
+		logger.WithError(err).WithField("provider_payment_id", event.PaymentID).Error("Failed to find refunded payment")
+		return http.StatusNotFound, fmt.Errorf("Payment record not found")
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := db.RecordRefund(tx, &payment, int64(event.Amount), event.RefundID); err != nil {
+			return err
+		}
+		refunded, err := db.GetRefundedAmount(tx, payment.ID)
+		if err != nil {
+			return err
+		}
+		if refunded >= int64(payment.Amount) && payment.Status != models.PaymentStatusRefunded {
+			payment.RefundPayment()
+			payment.UpdatedAt = time.Now()
+			return tx.Save(&payment).Error
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).WithField("payment_id", payment.ID).Error("Failed to record refund")
+		return http.StatusInternalServerError, fmt.Errorf("Failed to record refund")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"refund_id":  event.RefundID,
+		"amount":     event.Amount,
+	}).Info("Payment refunded")
+	return http.StatusOK, nil
+}
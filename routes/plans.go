@@ -0,0 +1,255 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// planNamePattern restricts plan names to what is safe in URLs and provider metadata
+var planNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,19}$`)
+
+// CatalogPlanRequest represents an admin's request to create or replace a plan of the catalog.
+// Prices are in cents; the yearly price defaults to ten months of the monthly one.
+type CatalogPlanRequest struct {
+	Name         string           `json:"name"`
+	DisplayName  string           `json:"display_name" binding:"required,max=100"`
+	Description  string           `json:"description" binding:"max=500"`
+	MonthlyPrice int              `json:"monthly_price" binding:"min=0"`
+	YearlyPrice  *int             `json:"yearly_price" binding:"omitempty,min=0"`
+	Currency     string           `json:"currency" binding:"omitempty,len=3"`
+	MaxInstances int              `json:"max_instances" binding:"required,min=1"`
+	CPULimit     float64          `json:"cpu_limit" binding:"required,gt=0"`
+	MemoryLimit  int              `json:"memory_limit" binding:"required,min=128"`
+	StorageLimit int              `json:"storage_limit" binding:"required,min=1"`
+	Features     []models.Feature `json:"features"`
+	Public       *bool            `json:"public"`
+	SortOrder    int              `json:"sort_order"`
+}
+
+// RegisterPlanRoutes registers the public plan catalog and its admin management routes
+func RegisterPlanRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	router.GET("/api/v1/plans", ListPlans())
+
+	v1AdminRoutes := router.Group("/api/v1/admin")
+	v1AdminRoutes.Use(middleware.RequireAdmin())
+	v1AdminRoutes.GET("/plans", AdminListPlans())
+	v1AdminRoutes.POST("/plans", AdminCreatePlan())
+	v1AdminRoutes.PUT("/plans/:name", AdminUpdatePlan())
+	v1AdminRoutes.DELETE("/plans/:name", AdminDeletePlan())
+}
+
+// ListPlans returns the public plans of the catalog for pricing pages
+func ListPlans() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response := []map[string]interface{}{}
+		for _, plan := range models.ListPlans() {
+			if plan.Public {
+				response = append(response, plan.ToPublicResponse())
+			}
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AdminListPlans returns every plan of the catalog with the number of users on it
+func AdminListPlans() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plans, err := db.GetPlans()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get plans"})
+			return
+		}
+		users, err := db.CountUsersByPlan()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(plans))
+		for i, plan := range plans {
+			response[i] = plan.ToPublicResponse()
+			response[i]["public"] = plan.Public
+			response[i]["sort_order"] = plan.SortOrder
+			response[i]["users"] = users[plan.Name]
+			response[i]["created_at"] = plan.CreatedAt
+			response[i]["updated_at"] = plan.UpdatedAt
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// apply validates the request and copies it onto a plan
+func (r *CatalogPlanRequest) apply(plan *models.Plan) error {
+	for _, feature := range r.Features {
+		known := false
+		for _, knownFeature := range models.KnownFeatures {
+			known = known || feature == knownFeature
+		}
+		if !known {
+			return errors.New("unknown feature " + string(feature))
+		}
+	}
+
+	plan.DisplayName = r.DisplayName
+	plan.Description = r.Description
+	plan.MonthlyPrice = r.MonthlyPrice
+	plan.YearlyPrice = r.MonthlyPrice * 10 // 12 months - 2 months free
+	if r.YearlyPrice != nil {
+		plan.YearlyPrice = *r.YearlyPrice
+	}
+	plan.Currency = "usd"
+	if r.Currency != "" {
+		plan.Currency = strings.ToLower(r.Currency)
+	}
+	plan.MaxInstances = r.MaxInstances
+	plan.CPULimit = r.CPULimit
+	plan.MemoryLimit = r.MemoryLimit
+	plan.StorageLimit = r.StorageLimit
+	plan.Features = models.PlanFeatureList(r.Features)
+	if plan.Features == nil {
+		plan.Features = models.PlanFeatureList{}
+	}
+	plan.Public = r.Public == nil || *r.Public
+	plan.SortOrder = r.SortOrder
+	return nil
+}
+
+// reloadPlanCatalog applies a change to the catalog on this replica right away; other
+// replicas pick it up on their next refresh
+func reloadPlanCatalog(logger *logrus.Logger) {
+	if err := db.LoadPlanCatalog(); err != nil {
+		logger.WithError(err).Warn("Failed to reload plan catalog")
+	}
+}
+
+// AdminCreatePlan adds a plan to the catalog. Users can buy it once the payment provider has
+// a product for it.
+func AdminCreatePlan() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		var req CatalogPlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		// "unlimited" is reserved for testing, see User.GetInstancesLimit
+		if !planNamePattern.MatchString(req.Name) || req.Name == "unlimited" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be up to 20 lowercase letters, digits, dashes and underscores"})
+			return
+		}
+
+		plan := models.Plan{Name: models.SubscriptionPlan(req.Name)}
+		if err := req.apply(&plan); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		err := db.CreatePlan(&plan)
+		if errors.Is(err, db.ErrPlanExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A plan with this name already exists"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("plan", plan.Name).Error("Failed to create plan")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create plan"})
+			return
+		}
+		reloadPlanCatalog(logger)
+
+		logger.WithField("plan", plan.Name).Warn("Admin created plan")
+		c.JSON(http.StatusCreated, plan.ToPublicResponse())
+	}
+}
+
+// AdminUpdatePlan replaces the limits, features and prices of a plan. New limits are checked
+// for new instances right away; existing instances keep their resources until they are
+// resized, e.g. with PUT /admin/users/:id/plan.
+func AdminUpdatePlan() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		plan, err := db.GetPlan(models.SubscriptionPlan(c.Param("name")))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get plan"})
+			return
+		}
+
+		var req CatalogPlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if req.Name != "" && req.Name != string(plan.Name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Plans cannot be renamed"})
+			return
+		}
+		if err := req.apply(plan); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.UpdatePlan(plan); err != nil {
+			logger.WithError(err).WithField("plan", plan.Name).Error("Failed to update plan")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update plan"})
+			return
+		}
+		reloadPlanCatalog(logger)
+
+		logger.WithFields(logrus.Fields{
+			"plan":          plan.Name,
+			"monthly_price": plan.MonthlyPrice,
+			"max_instances": plan.MaxInstances,
+			"cpu_limit":     plan.CPULimit,
+			"memory_limit":  plan.MemoryLimit,
+			"storage_limit": plan.StorageLimit,
+			"features":      plan.Features,
+		}).Warn("Admin updated plan")
+		c.JSON(http.StatusOK, plan.ToPublicResponse())
+	}
+}
+
+// AdminDeletePlan removes a plan from the catalog. The free plan new users start on, and
+// plans that users are still on, cannot be deleted.
+func AdminDeletePlan() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		name := models.SubscriptionPlan(c.Param("name"))
+		if name == models.PlanFree {
+			c.JSON(http.StatusConflict, gin.H{"error": "The free plan cannot be deleted"})
+			return
+		}
+
+		err := db.DeletePlan(name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
+			return
+		}
+		if errors.Is(err, db.ErrPlanInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Users are still on this plan; move them to another plan first"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("plan", name).Error("Failed to delete plan")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete plan"})
+			return
+		}
+		reloadPlanCatalog(logger)
+
+		logger.WithField("plan", name).Warn("Admin deleted plan")
+		c.JSON(http.StatusOK, gin.H{"message": "Plan deleted"})
+	}
+}
@@ -0,0 +1,374 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ProjectRequest represents a request to create or update a project
+type ProjectRequest struct {
+	Name                string  `json:"name" binding:"required"`
+	Description         string  `json:"description"`
+	DefaultCPULimit     float64 `json:"default_cpu_limit"`
+	DefaultMemoryLimit  int     `json:"default_memory_limit"`
+	DefaultStorageLimit int     `json:"default_storage_limit"`
+}
+
+// APIKeyRequest represents a request to create an API key
+type APIKeyRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Signed bool   `json:"signed"` // Require mutations to be HMAC-signed
+}
+
+// RegisterProjectRoutes registers project related routes
+func RegisterProjectRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	v1ProjectRoutes := router.Group("/api/v1/projects")
+
+	v1ProjectRoutes.GET("", GetProjects())
+	v1ProjectRoutes.POST("", CreateProject())
+	v1ProjectRoutes.GET("/:id", GetProject())
+	v1ProjectRoutes.PUT("/:id", UpdateProject())
+	v1ProjectRoutes.DELETE("/:id", DeleteProject())
+	v1ProjectRoutes.GET("/:id/instances", GetProjectInstances())
+	v1ProjectRoutes.GET("/:id/stats", GetProjectStats())
+	v1ProjectRoutes.GET("/:id/keys", GetProjectAPIKeys())
+	v1ProjectRoutes.POST("/:id/keys", CreateProjectAPIKey())
+	v1ProjectRoutes.DELETE("/:id/keys/:key_id", RevokeProjectAPIKey())
+}
+
+// loadProject fetches the project in the :id param and checks it belongs to the current user.
+// It writes the error response and returns nil when the project is not accessible.
+func loadProject(c *gin.Context) *models.Project {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+		return nil
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return nil
+	}
+
+	project, err := db.GetProjectByID(projectID)
+	if err != nil || project.UserID != userID {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "project_not_found"))
+		return nil
+	}
+
+	return project
+}
+
+// rejectScopedAPIKey blocks project-scoped API keys from account-level project management
+func rejectScopedAPIKey(c *gin.Context) bool {
+	if _, scoped := middleware.GetAPIKeyProjectID(c); scoped {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is not allowed to manage projects"})
+		return true
+	}
+	return false
+}
+
+// GetProjects returns all projects for the current user
+func GetProjects() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		projects, err := db.GetProjectsByUserID(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get projects"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(projects))
+		for i, project := range projects {
+			response[i] = project.ToPublicResponse()
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateProject creates a new project for the current user
+func CreateProject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		var req ProjectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		project := models.Project{UserID: userID}
+		applyProjectRequest(&project, req)
+
+		if err := db.CreateProject(&project); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, project.ToPublicResponse())
+	}
+}
+
+// GetProject returns a specific project
+func GetProject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		c.JSON(http.StatusOK, project.ToPublicResponse())
+	}
+}
+
+// UpdateProject updates a project's name, description and defaults
+func UpdateProject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		var req ProjectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		applyProjectRequest(project, req)
+
+		if err := db.UpdateProject(project); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+			return
+		}
+
+		c.JSON(http.StatusOK, project.ToPublicResponse())
+	}
+}
+
+// DeleteProject deletes a project; its instances are kept and detached from it
+func DeleteProject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		if err := db.DeleteProject(project.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+	}
+}
+
+// GetProjectInstances returns all instances in a project
+func GetProjectInstances() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		instances, err := db.GetInstancesByProjectID(project.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project instances"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(instances))
+		for i, instance := range instances {
+			response[i] = instance.ToPublicResponse()
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetProjectStats returns aggregated allocation and current usage for a project's instances
+func GetProjectStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		instances, err := db.GetInstancesByProjectID(project.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project instances"})
+			return
+		}
+
+		instancesByStatus := make(map[models.InstanceStatus]int)
+		var cpuAllocated float64
+		var memoryAllocated, storageAllocated int
+		var cpuUsage float64
+		var memoryUsage, diskUsage, networkIn, networkOut int64
+
+		for _, instance := range instances {
+			instancesByStatus[instance.Status]++
+			cpuAllocated += instance.CPULimit
+			memoryAllocated += instance.MemoryLimit
+			storageAllocated += instance.StorageLimit
+
+			// Current usage only counts instances that are actually running
+			if instance.Status != models.StatusRunning {
+				continue
+			}
+			usage, err := db.GetLatestResourceUsage(instance.ID)
+			if err != nil {
+				logger.WithError(err).WithField("instance_id", instance.ID).Debug("No resource usage recorded for instance")
+				continue
+			}
+			cpuUsage += usage.CPUUsage
+			memoryUsage += usage.MemoryUsage
+			diskUsage += usage.DiskUsage
+			networkIn += usage.NetworkIn
+			networkOut += usage.NetworkOut
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"project_id":          project.ID,
+			"instance_count":      len(instances),
+			"instances_by_status": instancesByStatus,
+			"allocated": gin.H{
+				"cpu_limit":     cpuAllocated,
+				"memory_limit":  memoryAllocated,
+				"storage_limit": storageAllocated,
+			},
+			"usage": gin.H{
+				"cpu_usage":    cpuUsage,
+				"memory_usage": memoryUsage,
+				"disk_usage":   diskUsage,
+				"network_in":   networkIn,
+				"network_out":  networkOut,
+			},
+		})
+	}
+}
+
+// GetProjectAPIKeys returns the API keys scoped to a project
+func GetProjectAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		keys, err := db.GetAPIKeysByProjectID(project.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get API keys"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(keys))
+		for i, key := range keys {
+			response[i] = key.ToPublicResponse()
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateProjectAPIKey creates an API key scoped to a project.
+// The plaintext key and signing secret are only returned in this response.
+func CreateProjectAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if rejectScopedAPIKey(c) {
+			return
+		}
+
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		if key := issueAPIKey(c, project.UserID, &project.ID, logger); key != nil {
+			logger.WithFields(logrus.Fields{
+				"project_id": project.ID,
+				"api_key_id": key.ID,
+			}).Info("Created project API key")
+		}
+	}
+}
+
+// RevokeProjectAPIKey revokes an API key scoped to a project
+func RevokeProjectAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rejectScopedAPIKey(c) {
+			return
+		}
+
+		project := loadProject(c)
+		if project == nil {
+			return
+		}
+
+		keyID, err := uuid.Parse(c.Param("key_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+			return
+		}
+
+		key, err := db.GetAPIKeyByID(keyID)
+		if err != nil || key.ProjectID == nil || *key.ProjectID != project.ID {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "api_key_not_found"))
+			return
+		}
+
+		if err := db.RevokeAPIKey(key.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+	}
+}
+
+// applyProjectRequest copies the request fields onto a project
+func applyProjectRequest(project *models.Project, req ProjectRequest) {
+	project.Name = req.Name
+	project.Description = req.Description
+	project.DefaultCPULimit = req.DefaultCPULimit
+	project.DefaultMemoryLimit = req.DefaultMemoryLimit
+	project.DefaultStorageLimit = req.DefaultStorageLimit
+}
@@ -0,0 +1,526 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SubAccountRequest represents a request to create a sub-account
+type SubAccountRequest struct {
+	Email         string `json:"email" binding:"required,email"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	InstanceQuota int    `json:"instance_quota" binding:"min=0"`
+}
+
+// QuotaRequest represents a request to change an instance quota
+type QuotaRequest struct {
+	InstanceQuota int `json:"instance_quota" binding:"min=0"`
+}
+
+// RegisterResellerRoutes registers routes for resellers to manage their sub-accounts
+func RegisterResellerRoutes(router *gin.Engine, cfg *config.Config, containerManager container.Manager, logger *logrus.Logger) {
+	v1ResellerRoutes := router.Group("/api/v1/reseller")
+	v1ResellerRoutes.Use(middleware.RequireReseller())
+
+	v1ResellerRoutes.GET("/pool", GetResellerPool())
+	v1ResellerRoutes.GET("/accounts", GetSubAccounts())
+	v1ResellerRoutes.POST("/accounts", CreateSubAccount())
+	v1ResellerRoutes.GET("/accounts/:id", GetSubAccount())
+	v1ResellerRoutes.PUT("/accounts/:id/quota", UpdateSubAccountQuota())
+	v1ResellerRoutes.DELETE("/accounts/:id", DeleteSubAccount())
+	v1ResellerRoutes.GET("/instances", GetSubAccountInstances())
+	v1ResellerRoutes.POST("/instances/:id/stop", ResellerStopInstance(containerManager))
+	v1ResellerRoutes.DELETE("/instances/:id", ResellerDeleteInstance(containerManager))
+	v1ResellerRoutes.GET("/usage", GetResellerUsage())
+}
+
+// loadSubAccount fetches the sub-account in the :id param for the current reseller.
+// It writes the error response and returns false when the account is not accessible.
+func loadSubAccount(c *gin.Context, reseller models.User) (models.User, bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return models.User{}, false
+	}
+
+	account, err := db.GetSubAccount(reseller.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return models.User{}, false
+	}
+
+	return account, true
+}
+
+// loadSubAccountInstance fetches the instance in the :id param, only if it belongs to
+// one of the current reseller's sub-accounts
+func loadSubAccountInstance(c *gin.Context, reseller models.User) (*models.Instance, bool) {
+	instanceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+		return nil, false
+	}
+
+	instance, err := db.GetInstanceByID(instanceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+		return nil, false
+	}
+
+	if _, err := db.GetSubAccount(reseller.ID, instance.UserID); err != nil {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+		return nil, false
+	}
+
+	return instance, true
+}
+
+// subAccountResponse returns a sub-account along with its instance usage
+func subAccountResponse(account models.User) (map[string]interface{}, error) {
+	count, err := db.CountInstancesByUserID(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := account.ToPublicResponse()
+	response["instance_quota"] = account.InstanceQuota
+	response["instance_count"] = count
+	response["pending_sign_in"] = account.IsPendingSignIn()
+	response["created_at"] = account.CreatedAt
+	return response, nil
+}
+
+// GetResellerPool returns the reseller's instance pool and how much of it is allocated
+func GetResellerPool() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		allocated, err := db.SumAllocatedQuota(reseller.ID, uuid.Nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pool allocation"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"instance_pool": reseller.InstanceQuota,
+			"allocated":     allocated,
+			"available":     reseller.InstanceQuota - allocated,
+		})
+	}
+}
+
+// GetSubAccounts returns all sub-accounts of the current reseller
+func GetSubAccounts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		accounts, err := db.GetSubAccounts(reseller.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get accounts"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(accounts))
+		for i, account := range accounts {
+			if response[i], err = subAccountResponse(account); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateSubAccount creates a sub-account with a quota drawn from the reseller's pool.
+// The account is linked to a Clerk user when someone signs up with its email.
+func CreateSubAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		var req SubAccountRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		allocated, err := db.SumAllocatedQuota(reseller.ID, uuid.Nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pool allocation"})
+			return
+		}
+		if allocated+req.InstanceQuota > reseller.InstanceQuota {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":     "Instance quota exceeds the remaining pool",
+				"available": reseller.InstanceQuota - allocated,
+			})
+			return
+		}
+
+		var existing models.User
+		if err := db.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "A user with this email already exists"})
+			return
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing users"})
+			return
+		}
+
+		// Sub-accounts share the reseller's subscription since billing is consolidated
+		account := models.User{
+			ID:                 uuid.New(),
+			ClerkUserID:        models.PendingClerkIDPrefix + uuid.New().String(),
+			Email:              req.Email,
+			Username:           generateUsername(req.Email, req.FirstName, req.LastName),
+			PasswordHash:       "OAUTH_USER_NO_PASSWORD_" + uuid.New().String(),
+			FirstName:          req.FirstName,
+			LastName:           req.LastName,
+			Plan:               reseller.Plan,
+			Role:               models.RoleUser,
+			ResellerID:         &reseller.ID,
+			InstanceQuota:      req.InstanceQuota,
+			SubscriptionStatus: reseller.SubscriptionStatus,
+			CurrentPeriodEnd:   reseller.CurrentPeriodEnd,
+		}
+		if err := db.CreateUser(&account); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"reseller_id":    reseller.ID,
+			"account_id":     account.ID,
+			"instance_quota": account.InstanceQuota,
+		}).Info("Reseller created sub-account")
+
+		response, err := subAccountResponse(account)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+			return
+		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// GetSubAccount returns a specific sub-account
+func GetSubAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		account, ok := loadSubAccount(c, reseller)
+		if !ok {
+			return
+		}
+
+		response, err := subAccountResponse(account)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UpdateSubAccountQuota resizes a sub-account's allocation from the reseller's pool
+func UpdateSubAccountQuota() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		account, ok := loadSubAccount(c, reseller)
+		if !ok {
+			return
+		}
+
+		var req QuotaRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		allocated, err := db.SumAllocatedQuota(reseller.ID, account.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pool allocation"})
+			return
+		}
+		if allocated+req.InstanceQuota > reseller.InstanceQuota {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":     "Instance quota exceeds the remaining pool",
+				"available": reseller.InstanceQuota - allocated,
+			})
+			return
+		}
+
+		count, err := db.CountInstancesByUserID(account.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+			return
+		}
+		if int(count) > req.InstanceQuota {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "Account has more instances than the requested quota",
+				"instance_count": count,
+			})
+			return
+		}
+
+		account.InstanceQuota = req.InstanceQuota
+		if err := db.UpdateUser(&account); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+			return
+		}
+
+		response, err := subAccountResponse(account)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// DeleteSubAccount deletes a sub-account that has no instances, returning its quota to the pool
+func DeleteSubAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		account, ok := loadSubAccount(c, reseller)
+		if !ok {
+			return
+		}
+
+		count, err := db.CountInstancesByUserID(account.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count instances"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Delete the account's instances first"})
+			return
+		}
+
+		if err := db.DeleteSubAccount(account.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+	}
+}
+
+// GetSubAccountInstances returns the instances of all the reseller's sub-accounts
+func GetSubAccountInstances() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		accounts, err := db.GetSubAccounts(reseller.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get accounts"})
+			return
+		}
+
+		userIDs := make([]uuid.UUID, len(accounts))
+		for i, account := range accounts {
+			userIDs[i] = account.ID
+		}
+
+		instances, err := db.GetInstancesByUserIDs(userIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(instances))
+		for i, instance := range instances {
+			response[i] = instance.ToPublicResponse()
+			response[i]["user_id"] = instance.UserID
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ResellerStopInstance stops an instance belonging to one of the reseller's sub-accounts
+func ResellerStopInstance(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		instance, ok := loadSubAccountInstance(c, reseller)
+		if !ok {
+			return
+		}
+
+		if err := containerManager.StopInstance(context.Background(), instance.ID); err != nil {
+			logger.WithError(err).Error("Reseller failed to stop instance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop instance"})
+			return
+		}
+
+		instance.Status = models.StatusStopped
+		if err := db.UpdateInstance(instance); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update instance status"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"reseller_id": reseller.ID,
+			"instance_id": instance.ID,
+		}).Info("Instance stopped by reseller")
+		c.JSON(http.StatusOK, gin.H{"message": "Instance stopped successfully"})
+	}
+}
+
+// ResellerDeleteInstance deletes an instance belonging to one of the reseller's sub-accounts
+func ResellerDeleteInstance(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		instance, ok := loadSubAccountInstance(c, reseller)
+		if !ok {
+			return
+		}
+
+		if err := containerManager.DeleteInstance(context.Background(), instance.ID); err != nil {
+			logger.WithError(err).Error("Reseller failed to delete instance container")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete instance container"})
+			return
+		}
+
+		if err := db.DeleteInstance(instance.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete instance from database"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"reseller_id": reseller.ID,
+			"instance_id": instance.ID,
+		}).Info("Instance deleted by reseller")
+		c.JSON(http.StatusOK, gin.H{"message": "Instance deleted successfully"})
+	}
+}
+
+// GetResellerUsage returns consolidated usage for the reseller and all sub-accounts,
+// which is what the reseller is billed for
+func GetResellerUsage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reseller, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		from, to, ok := parseUsageRange(c)
+		if !ok {
+			return
+		}
+
+		accounts, err := db.GetSubAccounts(reseller.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get accounts"})
+			return
+		}
+
+		userIDs := []uuid.UUID{reseller.ID}
+		for _, account := range accounts {
+			userIDs = append(userIDs, account.ID)
+		}
+
+		rollups, err := db.GetUsageRollupsForUsers(userIDs, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get usage"})
+			return
+		}
+
+		var totals models.UsageRollup
+		perAccount := make(map[uuid.UUID]*models.UsageRollup)
+		for _, rollup := range rollups {
+			account, exists := perAccount[rollup.UserID]
+			if !exists {
+				account = &models.UsageRollup{}
+				perAccount[rollup.UserID] = account
+			}
+			for _, sum := range []*models.UsageRollup{account, &totals} {
+				sum.InstanceHours += rollup.InstanceHours
+				sum.CPUHours += rollup.CPUHours
+				sum.EgressBytes += rollup.EgressBytes
+				sum.IngressBytes += rollup.IngressBytes
+			}
+		}
+
+		accountsUsage := make([]gin.H, 0, len(perAccount))
+		for userID, sum := range perAccount {
+			accountsUsage = append(accountsUsage, gin.H{
+				"user_id":        userID,
+				"instance_hours": sum.InstanceHours,
+				"cpu_hours":      sum.CPUHours,
+				"egress_bytes":   sum.EgressBytes,
+				"ingress_bytes":  sum.IngressBytes,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"from":     from.Format("2006-01-02"),
+			"to":       to.Format("2006-01-02"),
+			"accounts": accountsUsage,
+			"totals": gin.H{
+				"instance_hours": totals.InstanceHours,
+				"cpu_hours":      totals.CPUHours,
+				"egress_bytes":   totals.EgressBytes,
+				"ingress_bytes":  totals.IngressBytes,
+			},
+		})
+	}
+}
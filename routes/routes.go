@@ -1,23 +1,99 @@
 package routes
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/launchstack/backend/config"
 	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/payments"
+	"github.com/launchstack/backend/storage"
 	"github.com/sirupsen/logrus"
 )
 
 // RegisterAllRoutes registers all routes
-func RegisterAllRoutes(router *gin.Engine, cfg *config.Config, containerManager container.Manager, logger *logrus.Logger) {
+func RegisterAllRoutes(router *gin.Engine, cfg *config.Config, containerManager container.Manager, provisioner *jobs.Provisioner, instanceJobs *jobs.InstanceJobs, waitlist *jobs.Waitlist, sleeper *jobs.InstanceSleeper, backups *jobs.Backups, objectStore storage.ObjectStore, webhooks *jobs.Webhooks, clerkWebhooks *jobs.ClerkWebhooks, logger *logrus.Logger) {
 	// Register auth routes
-	RegisterAuthRoutes(router, cfg, logger)
+	RegisterAuthRoutes(router, cfg, clerkWebhooks, logger)
 	
 	// Register instance routes
-	RegisterInstanceRoutes(router, cfg, containerManager, logger)
+	RegisterInstanceRoutes(router, cfg, containerManager, provisioner, instanceJobs, waitlist, sleeper, backups, objectStore, logger)
+	
+	// Register background job routes
+	RegisterJobRoutes(router, logger)
 	
 	// Register user routes
 	RegisterUserRoutes(router, cfg, logger)
 	
+	// Register the notification center routes
+	RegisterNotificationRoutes(router, logger)
+	
+	// Register the plan catalog routes
+	RegisterPlanRoutes(router, cfg, logger)
+	
+	// Register the service template catalog routes
+	RegisterTemplateRoutes(router, containerManager, logger)
+	
+	// Register metered usage routes
+	RegisterUsageRoutes(router, cfg, logger)
+	
+	// Register the invoice downloads of payments
+	RegisterInvoiceRoutes(router, cfg, logger)
+	
+	// Register the spending cap routes
+	RegisterSpendingCapRoutes(router, cfg, logger)
+	
+	// Register project routes
+	RegisterProjectRoutes(router, cfg, logger)
+	
+	// Register organization routes, whose members share instances
+	RegisterOrganizationRoutes(router, cfg, logger)
+	
+	// Register API key routes
+	RegisterAPIKeyRoutes(router, cfg, logger)
+	
+	// Register webhook endpoint routes
+	RegisterWebhookEndpointRoutes(router, cfg, webhooks, logger)
+	
+	// Register the webhook instances report workflow executions to
+	RegisterN8nWebhookRoutes(router, cfg, logger)
+	
+	// Register branding routes
+	RegisterBrandingRoutes(router, cfg, logger)
+	
+	// Register signed downloads of locally stored backups and exports
+	RegisterStorageRoutes(router, objectStore, logger)
+	
+	// Register the status page served for instances that are down
+	RegisterStatusPageRoutes(router, cfg, sleeper, logger)
+	
+	// Register the embeddable status badges of instances
+	RegisterBadgeRoutes(router, cfg, logger)
+	
+	// Register reseller routes
+	RegisterResellerRoutes(router, cfg, containerManager, logger)
+	
+	// Register admin routes
+	RegisterAdminRoutes(router, cfg, containerManager, backups, logger)
+	
+	// Register host agent routes
+	RegisterAgentRoutes(router, cfg, logger)
+	
+	// Register the capacity route
+	RegisterCapacityRoutes(router, cfg, logger)
+	
+	// Register the Prometheus metrics endpoint
+	RegisterMetricsRoutes(router, cfg, logger)
+	
+	// Register the endpoints the reverse proxy calls, such as the on-demand TLS check
+	RegisterInternalRoutes(router, cfg, logger)
+	
+	// Register the OpenAPI document and Swagger UI
+	RegisterDocsRoutes(router, cfg, logger)
+	
 	// Register health check routes - redirect old paths to new /api/v1/ path
 	router.GET("/health", func(c *gin.Context) {
 		c.Redirect(301, "/api/v1/health")
@@ -26,10 +102,35 @@ func RegisterAllRoutes(router *gin.Engine, cfg *config.Config, containerManager
 	// Standard v1 health check endpoint
 	router.GET("/api/v1/health", HealthCheckHandler(cfg, logger))
 	router.GET("/api/v1/health/", HealthCheckHandler(cfg, logger))
+	
+	// Remote probe agent for the health monitors of other hosts
+	router.POST("/api/v1/health/probe", ProbeHandler(cfg, containerManager, logger))
+}
+
+// RegisterPaymentRoutes registers payment and subscription routes for the configured payment provider
+func RegisterPaymentRoutes(router *gin.Engine, provider payments.Provider, containerManager container.Manager, billingEnforcer *jobs.BillingEnforcer, logger *logrus.Logger) {
+	logger.WithField("provider", provider.Name()).Info("Registering payment routes")
+
+	paymentRoutes := router.Group("/api/v1/payments")
+	paymentRoutes.GET("", GetPayments)
+	paymentRoutes.GET("/", GetPayments)
+	paymentRoutes.POST("/checkout", CreateCheckoutSession(provider))
+	paymentRoutes.POST("/checkout/", CreateCheckoutSession(provider))
+	paymentRoutes.GET("/subscriptions", GetSubscriptions)
+	paymentRoutes.GET("/subscriptions/", GetSubscriptions)
+	paymentRoutes.POST("/subscriptions/:id/cancel", CancelSubscription(provider))
+	paymentRoutes.POST("/subscriptions/:id/cancel/", CancelSubscription(provider))
+	paymentRoutes.POST("/subscriptions/:id/change", ChangeSubscription(provider, containerManager))
+	paymentRoutes.POST("/subscriptions/:id/change/", ChangeSubscription(provider, containerManager))
+
+	// Each provider delivers its webhooks to its own path, e.g. /api/v1/webhooks/stripe
+	webhookRoutes := router.Group("/api/v1/webhooks")
+	webhookRoutes.POST("/"+provider.Name(), PaymentWebhook(provider, containerManager, billingEnforcer))
+	webhookRoutes.POST("/"+provider.Name()+"/", PaymentWebhook(provider, containerManager, billingEnforcer))
 }
 
 // RegisterAuthRoutes registers authentication-related routes
-func RegisterAuthRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+func RegisterAuthRoutes(router *gin.Engine, cfg *config.Config, clerkWebhooks *jobs.ClerkWebhooks, logger *logrus.Logger) {
 	// Register redirects for old routes
 	oldAuthRoutes := router.Group("/api/auth")
 	oldAuthRoutes.POST("/webhook", func(c *gin.Context) {
@@ -38,8 +139,8 @@ func RegisterAuthRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.L
 	
 	// Register v1 auth routes
 	v1AuthRoutes := router.Group("/api/v1/auth")
-	v1AuthRoutes.POST("/webhook", ClerkWebhookHandler(cfg, logger))
-	v1AuthRoutes.POST("/webhook/", ClerkWebhookHandler(cfg, logger))
+	v1AuthRoutes.POST("/webhook", ClerkWebhookHandler(cfg, clerkWebhooks, logger))
+	v1AuthRoutes.POST("/webhook/", ClerkWebhookHandler(cfg, clerkWebhooks, logger))
 }
 
 // RegisterUserRoutes registers user-related routes
@@ -59,10 +160,12 @@ func RegisterUserRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.L
 	v1UserRoutes.GET("/me/", GetCurrentUserHandler)
 	v1UserRoutes.PUT("/me", UpdateCurrentUserHandler)
 	v1UserRoutes.PUT("/me/", UpdateCurrentUserHandler)
+	v1UserRoutes.GET("/me/usage", GetUsageSummaryHandler)
+	v1UserRoutes.GET("/me/usage/", GetUsageSummaryHandler)
 }
 
 // RegisterInstanceRoutes registers instance-related routes
-func RegisterInstanceRoutes(router *gin.Engine, cfg *config.Config, containerManager container.Manager, logger *logrus.Logger) {
+func RegisterInstanceRoutes(router *gin.Engine, cfg *config.Config, containerManager container.Manager, provisioner *jobs.Provisioner, instanceJobs *jobs.InstanceJobs, waitlist *jobs.Waitlist, sleeper *jobs.InstanceSleeper, backups *jobs.Backups, objectStore storage.ObjectStore, logger *logrus.Logger) {
 	// Register redirects for old routes
 	oldInstanceRoutes := router.Group("/api/instances")
 	oldInstanceRoutes.GET("", func(c *gin.Context) {
@@ -103,27 +206,88 @@ func RegisterInstanceRoutes(router *gin.Engine, cfg *config.Config, containerMan
 	// Register v1 instance routes
 	v1InstanceRoutes := router.Group("/api/v1/instances")
 	v1InstanceRoutes.Use(ContainerManagerMiddleware(containerManager))
+	v1InstanceRoutes.Use(ProjectScopeMiddleware())
 	
 	// Register all v1 instance routes with proper handler functions
 	// Make sure to handle both with and without trailing slashes
 	v1InstanceRoutes.GET("", GetInstances(containerManager))
 	v1InstanceRoutes.GET("/", GetInstances(containerManager))
-	v1InstanceRoutes.POST("", CreateInstance(containerManager))
-	v1InstanceRoutes.POST("/", CreateInstance(containerManager))
+	v1InstanceRoutes.POST("", middleware.RequireSpendingHeadroom(cfg), CreateInstance(containerManager, provisioner))
+	v1InstanceRoutes.POST("/", middleware.RequireSpendingHeadroom(cfg), CreateInstance(containerManager, provisioner))
+	
+	// Requests waiting in line for capacity
+	v1InstanceRoutes.GET("/waitlist", GetWaitlist())
+	v1InstanceRoutes.DELETE("/waitlist/:entry_id", CancelWaitlistEntry())
+	v1InstanceRoutes.POST("/waitlist/:entry_id/claim", middleware.RequireSpendingHeadroom(cfg), ClaimWaitlistEntry(waitlist))
+	v1InstanceRoutes.GET("/shared", GetSharedInstances())
 	v1InstanceRoutes.GET("/:id", GetInstance(containerManager))
 	v1InstanceRoutes.GET("/:id/", GetInstance(containerManager))
-	v1InstanceRoutes.DELETE("/:id", DeleteInstance(containerManager))
-	v1InstanceRoutes.DELETE("/:id/", DeleteInstance(containerManager))
-	v1InstanceRoutes.POST("/:id/start", StartInstance(containerManager))
-	v1InstanceRoutes.POST("/:id/start/", StartInstance(containerManager))
+	v1InstanceRoutes.DELETE("/:id", DeleteInstance(cfg, containerManager, instanceJobs))
+	v1InstanceRoutes.DELETE("/:id/", DeleteInstance(cfg, containerManager, instanceJobs))
+	v1InstanceRoutes.POST("/:id/restore", RestoreInstance())
+	v1InstanceRoutes.POST("/:id/start", middleware.RequireSpendingHeadroom(cfg), StartInstance(containerManager))
+	v1InstanceRoutes.POST("/:id/start/", middleware.RequireSpendingHeadroom(cfg), StartInstance(containerManager))
 	v1InstanceRoutes.POST("/:id/stop", StopInstance(containerManager))
 	v1InstanceRoutes.POST("/:id/stop/", StopInstance(containerManager))
 	v1InstanceRoutes.POST("/:id/restart", RestartInstance(containerManager))
 	v1InstanceRoutes.POST("/:id/restart/", RestartInstance(containerManager))
+	v1InstanceRoutes.POST("/:id/wake", middleware.RequireSpendingHeadroom(cfg), WakeInstance(sleeper))
 	v1InstanceRoutes.GET("/:id/stats", GetInstanceStats(containerManager))
 	v1InstanceRoutes.GET("/:id/stats/", GetInstanceStats(containerManager))
 	
 	// Add the historical stats endpoint with the path expected by frontend
 	v1InstanceRoutes.GET("/:id/stats/history", GetInstanceHistoricalStats())
 	v1InstanceRoutes.GET("/:id/stats/history/", GetInstanceHistoricalStats())
+	
+	// Immutable creation spec and provisioning progress
+	v1InstanceRoutes.GET("/:id/spec", GetInstanceSpec())
+	v1InstanceRoutes.GET("/:id/provisioning", GetInstanceProvisioning())
+	v1InstanceRoutes.GET("/:id/provisioning/steps", GetInstanceProvisioningSteps())
+	v1InstanceRoutes.POST("/:id/provisioning/retry", middleware.RequireSpendingHeadroom(cfg), RetryInstanceProvisioning(provisioner))
+	
+	// Rename an instance and its container, and share it with an organization or other users
+	v1InstanceRoutes.POST("/:id/rename", RenameInstance(containerManager))
+	v1InstanceRoutes.PUT("/:id/organization", SetInstanceOrganization())
+	v1InstanceRoutes.GET("/:id/shares", GetInstanceShares())
+	v1InstanceRoutes.POST("/:id/shares", ShareInstance())
+	v1InstanceRoutes.DELETE("/:id/shares/:user_id", RevokeInstanceShare())
+	v1InstanceRoutes.PATCH("/:id/resources", middleware.RequireEntitlement(models.FeatureResourceOverrides), UpdateInstanceResources(cfg, containerManager))
+	
+	// Controlled n8n version upgrades
+	v1InstanceRoutes.POST("/:id/upgrade", UpgradeInstance(instanceJobs))
+	v1InstanceRoutes.POST("/:id/rollback", RollbackInstance(cfg, instanceJobs))
+	
+	// Basic auth login of the n8n editor
+	v1InstanceRoutes.GET("/:id/credentials", GetInstanceCredentials(containerManager))
+	v1InstanceRoutes.POST("/:id/credentials/rotate", RotateInstanceCredentials(containerManager))
+	v1InstanceRoutes.GET("/:id/connection-info", GetInstanceConnectionInfo())
+	v1InstanceRoutes.POST("/:id/verify-webhooks", middleware.RateLimit(10, time.Minute), VerifyInstanceWebhooks())
+	v1InstanceRoutes.GET("/:id/export/compose", ExportInstanceCompose(containerManager, objectStore, cfg))
+	v1InstanceRoutes.GET("/:id/workflows/export", ExportWorkflows(containerManager))
+	v1InstanceRoutes.POST("/:id/workflows/export", StoreWorkflowExport(containerManager, objectStore, cfg))
+	v1InstanceRoutes.POST("/:id/workflows/import", ImportWorkflows(containerManager))
+	
+	// Files volume browser, for files workflows read and write
+	v1InstanceRoutes.GET("/:id/files/*path", GetInstanceFile(cfg, containerManager))
+	v1InstanceRoutes.PUT("/:id/files/*path", PutInstanceFile(cfg, containerManager))
+	v1InstanceRoutes.DELETE("/:id/files/*path", DeleteInstanceFile(containerManager))
+	
+	// Backups of instance volumes; runs and schedules need a plan with backups
+	v1InstanceRoutes.GET("/:id/backups", GetInstanceBackups())
+	v1InstanceRoutes.POST("/:id/backups", middleware.RequireEntitlement(models.FeatureBackups), CreateInstanceBackup(backups))
+	v1InstanceRoutes.GET("/:id/backups/:backup_id/download", GetBackupDownload(objectStore, cfg))
+	v1InstanceRoutes.GET("/:id/backups/schedule", GetInstanceBackupSchedule())
+	v1InstanceRoutes.PUT("/:id/backups/schedule", middleware.RequireEntitlement(models.FeatureBackups), SetInstanceBackupSchedule(cfg))
+	v1InstanceRoutes.DELETE("/:id/backups/schedule", DeleteInstanceBackupSchedule())
+	
+	// TLS certificate status and instance events
+	v1InstanceRoutes.GET("/:id/certificate", GetInstanceCertificate())
+	v1InstanceRoutes.GET("/:id/events", GetInstanceEvents())
+	
+	// Per-region reachability from health probes
+	v1InstanceRoutes.GET("/:id/uptime", GetInstanceUptime(cfg))
+	
+	// Workflow executions reported by the instance
+	v1InstanceRoutes.GET("/:id/executions", GetInstanceExecutions())
+	v1InstanceRoutes.GET("/:id/badge", GetInstanceBadge(cfg))
 } 
\ No newline at end of file
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// SpendingCapRequest represents a user's monthly cap on metered charges
+type SpendingCapRequest struct {
+	SpendingCap *int64 `json:"spending_cap" binding:"required,min=0"` // in cents; 0 removes the cap
+}
+
+// AdminSpendingCapRequest represents an admin's change of a user's spending cap. Lift lets
+// the user past a reached cap until the end of the month; false enforces it again.
+type AdminSpendingCapRequest struct {
+	SpendingCap *int64 `json:"spending_cap" binding:"omitempty,min=0"`
+	Lift        *bool  `json:"lift"`
+}
+
+// RegisterSpendingCapRoutes registers the spending cap routes
+func RegisterSpendingCapRoutes(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
+	v1UserRoutes := router.Group("/api/v1/users")
+	v1UserRoutes.PUT("/me/spending-cap", SetSpendingCap(cfg))
+
+	v1AdminRoutes := router.Group("/api/v1/admin")
+	v1AdminRoutes.Use(middleware.RequireAdmin())
+	v1AdminRoutes.PUT("/users/:id/spending-cap", AdminSetSpendingCap(cfg))
+}
+
+// SetSpendingCap sets the current user's monthly cap on metered charges. Once this month's
+// charges reach it, starting and creating instances is refused.
+func SetSpendingCap(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		var req SpendingCapRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		if err := db.SetSpendingCap(user.ID, *req.SpendingCap); err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to set spending cap")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set spending cap"})
+			return
+		}
+		user.SpendingCap = *req.SpendingCap
+
+		logger.WithFields(logrus.Fields{
+			"user_id":      user.ID,
+			"spending_cap": user.SpendingCap,
+		}).Info("User set spending cap")
+
+		respondWithSpending(c, cfg, user, logger)
+	}
+}
+
+// AdminSetSpendingCap changes any user's spending cap, or lifts it for the current month so a
+// user who reached it can start instances again
+func AdminSetSpendingCap(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		admin, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req AdminSpendingCapRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if req.SpendingCap == nil && req.Lift == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Set spending_cap or lift"})
+			return
+		}
+
+		user, err := db.GetUserByID(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		if req.SpendingCap != nil {
+			if err := db.SetSpendingCap(user.ID, *req.SpendingCap); err != nil {
+				logger.WithError(err).WithField("user_id", user.ID).Error("Failed to set spending cap")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set spending cap"})
+				return
+			}
+			user.SpendingCap = *req.SpendingCap
+		}
+		if req.Lift != nil {
+			var period *time.Time
+			if *req.Lift {
+				current := models.UsagePeriod(time.Now())
+				period = &current
+			}
+			if err := db.SetSpendingCapLifted(user.ID, period); err != nil {
+				logger.WithError(err).WithField("user_id", user.ID).Error("Failed to lift spending cap")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set spending cap"})
+				return
+			}
+			user.SpendingCapLiftedFor = period
+		}
+
+		logger.WithFields(logrus.Fields{
+			"admin_id":     admin.ID,
+			"user_id":      user.ID,
+			"spending_cap": user.SpendingCap,
+			"lifted_for":   user.SpendingCapLiftedFor,
+		}).Warn("Admin changed user spending cap")
+
+		respondWithSpending(c, cfg, user, logger)
+	}
+}
+
+// respondWithSpending responds with a user's spending this month against their cap
+func respondWithSpending(c *gin.Context, cfg *config.Config, user models.User, logger *logrus.Logger) {
+	spending, err := db.GetUserSpending(user, models.UsagePrices(cfg.Billing.UsagePrices))
+	if err != nil {
+		logger.WithError(err).WithField("user_id", user.ID).Error("Failed to get spending")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get spending"})
+		return
+	}
+	c.JSON(http.StatusOK, spending.ToPublicResponse())
+}
@@ -0,0 +1,184 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// instanceStatusPageTemplate renders the page shown to visitors of an instance that is not serving
+var instanceStatusPageTemplate = template.Must(template.New("instance-status-page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if .RetryAfter}}<meta http-equiv="refresh" content="{{.RetryAfter}}">{{end}}
+<title>{{.Title}} - {{.Branding.ProductName}}</title>
+<style>
+body { margin: 0; min-height: 100vh; display: flex; align-items: center; justify-content: center; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; background: #f5f5f7; color: #1d1d1f; }
+main { max-width: 28rem; padding: 2.5rem; background: #fff; border-radius: 12px; box-shadow: 0 2px 12px rgba(0, 0, 0, 0.08); text-align: center; }
+img { max-height: 40px; margin-bottom: 1.5rem; }
+h1 { font-size: 1.4rem; margin: 0 0 0.75rem; }
+p { line-height: 1.5; color: #515154; }
+a.action { display: inline-block; margin-top: 1rem; padding: 0.6rem 1.4rem; border-radius: 8px; color: #fff; text-decoration: none; background: {{.Branding.PrimaryColor}}; }
+small { display: block; margin-top: 1.5rem; color: #86868b; }
+</style>
+</head>
+<body>
+<main>
+{{if .Branding.LogoURL}}<img src="{{.Branding.LogoURL}}" alt="{{.Branding.ProductName}}">{{end}}
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .ActionURL}}<a class="action" href="{{.ActionURL}}">{{.ActionLabel}}</a>{{end}}
+<small>{{.Host}}{{if .Branding.SupportEmail}} &middot; <a href="mailto:{{.Branding.SupportEmail}}">{{.Branding.SupportEmail}}</a>{{end}}</small>
+</main>
+</body>
+</html>
+`))
+
+// instanceStatusPage describes why an instance URL is not serving
+type instanceStatusPage struct {
+	Host        string
+	Status      models.InstanceStatus
+	Title       string
+	Message     string
+	ActionURL   string // Dashboard page where the owner can start the instance
+	ActionLabel string
+	RetryAfter  int // Seconds until the instance is expected to serve again, if it is on its way
+	Branding    models.Branding
+}
+
+// RegisterStatusPageRoutes registers the public status page the reverse proxy serves for
+// instances that are down, which also wakes sleeping instances
+func RegisterStatusPageRoutes(router *gin.Engine, cfg *config.Config, sleeper *jobs.InstanceSleeper, logger *logrus.Logger) {
+	router.GET("/api/v1/instance-status-page/:host", GetInstanceStatusPage(cfg, sleeper))
+}
+
+// instanceSubdomain reduces a requested host, e.g. "happy-panda.launchstack.io:443", to the
+// subdomain instances are stored under
+func instanceSubdomain(host, domain string) string {
+	host = strings.ToLower(host)
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return strings.TrimSuffix(host, "."+strings.ToLower(domain))
+}
+
+// describeInstanceStatus explains an instance's status to visitors of its URL
+func describeInstanceStatus(page *instanceStatusPage, instance *models.Instance, cfg *config.Config) {
+	dashboardURL := fmt.Sprintf("%s/instances/%s", cfg.Server.FrontendURL, instance.ID)
+
+	switch instance.Status {
+	case models.StatusPending:
+		page.Title = "This instance is being created"
+		page.Message = "It will be available in a moment. This page refreshes automatically."
+		page.RetryAfter = 15
+	case models.StatusSleeping:
+		page.Title = "This instance is waking up"
+		page.Message = "It was asleep after a period without activity and is starting again. This page refreshes automatically."
+		page.RetryAfter = 15
+	case models.StatusUpgrading:
+		page.Title = "This instance is being upgraded"
+		page.Message = "It will be back shortly with a new version. This page refreshes automatically."
+		page.RetryAfter = 30
+	case models.StatusStopped:
+		page.Title = "This instance is stopped"
+		page.Message = "Its owner can start it again from the dashboard."
+		page.ActionURL = dashboardURL
+		page.ActionLabel = "Start instance"
+	case models.StatusError:
+		page.Title = "This instance stopped unexpectedly"
+		page.Message = "Its owner can restart it from the dashboard."
+		page.ActionURL = dashboardURL
+		page.ActionLabel = "Restart instance"
+	case models.StatusSuspended, models.InstanceStatusExpired:
+		page.Title = "This instance is suspended"
+		page.Message = "Its owner can find out why and resume it from the dashboard."
+		page.ActionURL = dashboardURL
+		page.ActionLabel = "Open dashboard"
+	default:
+		// Running, but the proxy could not reach it: it is starting or being restarted
+		page.Title = "This instance is temporarily unavailable"
+		page.Message = "It is starting up or restarting. This page refreshes automatically."
+		page.RetryAfter = 10
+	}
+}
+
+// GetInstanceStatusPage explains to visitors of an instance URL why the instance is not
+// serving, as HTML or, when requested, JSON. The reverse proxy serves it instead of a bare
+// 502 when it cannot reach an instance's container, so the first request to a sleeping
+// instance wakes it.
+func GetInstanceStatusPage(cfg *config.Config, sleeper *jobs.InstanceSleeper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		branding, _, err := resolveBranding(cfg)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to load branding overrides for instance status page")
+		}
+		page := instanceStatusPage{Host: c.Param("host"), Branding: branding}
+
+		code := http.StatusServiceUnavailable
+		instance, err := db.GetInstanceByHost(instanceSubdomain(page.Host, cfg.Server.Domain))
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			code = http.StatusNotFound
+			page.Title = "Instance not found"
+			page.Message = "No instance is served at this address."
+		case err != nil:
+			logger.WithError(err).WithField("host", page.Host).Error("Failed to look up instance for status page")
+			page.Title = "This instance is temporarily unavailable"
+			page.Message = "Please try again in a few minutes."
+		case instance.Status == models.StatusTrashed || instance.Status == models.StatusDeleting || instance.Status == models.StatusDeleted:
+			code = http.StatusNotFound
+			page.Status = instance.Status
+			page.Title = "Instance not found"
+			page.Message = "The instance served at this address has been deleted."
+		default:
+			page.Status = instance.Status
+			describeInstanceStatus(&page, instance, cfg)
+			if instance.IsSleeping() {
+				sleeper.WakeInBackground(instance.ID)
+			}
+		}
+
+		c.Header("Cache-Control", "no-store")
+		if page.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(page.RetryAfter))
+		}
+
+		if c.Query("format") == "json" || c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+			c.JSON(code, gin.H{
+				"host":         page.Host,
+				"status":       page.Status,
+				"title":        page.Title,
+				"message":      page.Message,
+				"action_url":   page.ActionURL,
+				"action_label": page.ActionLabel,
+				"retry_after":  page.RetryAfter,
+			})
+			return
+		}
+
+		var html bytes.Buffer
+		if err := instanceStatusPageTemplate.Execute(&html, page); err != nil {
+			logger.WithError(err).Error("Failed to render instance status page")
+			c.String(code, "%s\n%s", page.Title, page.Message)
+			return
+		}
+		c.Data(code, "text/html; charset=utf-8", html.Bytes())
+	}
+}
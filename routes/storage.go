@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterStorageRoutes registers the route serving objects of the local store through the
+// signed URLs it hands out. S3 buckets serve their presigned URLs themselves.
+func RegisterStorageRoutes(router *gin.Engine, objectStore storage.ObjectStore, logger *logrus.Logger) {
+	local, ok := objectStore.(*storage.LocalStore)
+	if !ok {
+		return
+	}
+	router.GET(storage.LocalObjectsPath+"*key", ServeStoredObject(local))
+}
+
+// ServeStoredObject streams an object of the local store to anyone holding an unexpired URL
+// signed by it
+func ServeStoredObject(local *storage.LocalStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+		key := strings.TrimPrefix(c.Param("key"), "/")
+
+		if err := local.VerifyPresigned(key, c.Query("expires"), c.Query("signature")); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Download link is invalid or has expired"})
+			return
+		}
+
+		object, err := local.Open(c.Request.Context(), key)
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).WithField("key", key).Error("Failed to open stored object")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer object.Close()
+
+		c.Header("Content-Disposition", "attachment; filename=\""+path.Base(key)+"\"")
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		if _, err := io.Copy(c.Writer, object); err != nil {
+			logger.WithError(err).WithField("key", key).Warn("Failed to send stored object")
+		}
+	}
+}
+
+// presignedDownload returns a download URL of a stored object and when it expires
+func presignedDownload(objectStore storage.ObjectStore, cfg *config.Config, key string) (gin.H, error) {
+	url, err := objectStore.PresignGet(key, cfg.ObjectStorage.URLExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"download_url": url,
+		"expires_at":   time.Now().Add(cfg.ObjectStorage.URLExpiry).UTC(),
+	}, nil
+}
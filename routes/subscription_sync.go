@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/payments"
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterSubscriptionSyncRoutes registers the admin route that syncs subscriptions with the
+// payment provider
+func RegisterSubscriptionSyncRoutes(router *gin.Engine, subscriptionSync *jobs.SubscriptionSync, logger *logrus.Logger) {
+	v1AdminRoutes := router.Group("/api/v1/admin")
+	v1AdminRoutes.Use(middleware.RequireAdmin())
+	v1AdminRoutes.POST("/subscriptions/sync", AdminSyncSubscriptions(subscriptionSync))
+}
+
+// SubscriptionEventHandler applies the corrections of the subscription sync like the payment
+// provider's subscription webhooks
+func SubscriptionEventHandler(provider payments.Provider, containerManager container.Manager, billingEnforcer *jobs.BillingEnforcer, logger *logrus.Logger) jobs.SubscriptionEventHandler {
+	return func(event payments.WebhookEvent) error {
+		_, err := applyPaymentEvent(provider, containerManager, billingEnforcer, event, logger)
+		return err
+	}
+}
+
+// AdminSyncSubscriptions compares every subscription with the payment provider now and
+// corrects the ones that drifted, without waiting for the next scheduled sync
+func AdminSyncSubscriptions(subscriptionSync *jobs.SubscriptionSync) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		result, err := subscriptionSync.Sync(c.Request.Context())
+		if errors.Is(err, jobs.ErrSubscriptionSyncRunning) {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "subscription_sync_running"))
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to sync subscriptions")
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, "internal_error"))
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
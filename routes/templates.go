@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/container"
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterTemplateRoutes registers the catalog of services instances can run
+func RegisterTemplateRoutes(router *gin.Engine, containerManager container.Manager, logger *logrus.Logger) {
+	router.GET("/api/v1/templates", ListTemplates(containerManager))
+}
+
+// ListTemplates returns the templates of the services new instances can be created with, in
+// the order of SERVICE_TYPES. The service_type of a template is what POST /instances takes.
+func ListTemplates(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, containerManager.Templates().List())
+	}
+}
@@ -0,0 +1,363 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+// terminalMaxMessageBytes is the largest message an admin terminal accepts from the client
+const terminalMaxMessageBytes = 64 * 1024
+
+// terminalSessionListQuery is what terminal session listings can be sorted and filtered by
+var terminalSessionListQuery = listQuery{
+	sorts: map[string]string{
+		"created_at": "created_at",
+		"started_at": "started_at",
+	},
+	filters: map[string]string{
+		"instance_id": "instance_id",
+		"admin_id":    "admin_id",
+		"status":      "status",
+	},
+	defaultSort: "-created_at",
+}
+
+// OpenTerminalRequest is the request to open an admin terminal in an instance's container
+type OpenTerminalRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	Shell  string `json:"shell"` // defaults to /bin/sh
+}
+
+// terminalMessage is a message from the client of an admin terminal: typed input, or the new
+// size of the terminal
+type terminalMessage struct {
+	Type string `json:"type"` // input or resize
+	Data string `json:"data,omitempty"`
+	Cols uint   `json:"cols,omitempty"`
+	Rows uint   `json:"rows,omitempty"`
+}
+
+// AdminOpenTerminal issues a single-use ticket for a terminal session in a running instance's
+// container. The session is recorded with the admin's reason before anything runs, and the
+// ticket must be used within models.TerminalTicketTTL.
+func AdminOpenTerminal(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if !cfg.FeatureEnabled(config.FlagAdminTerminal) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admin terminal is not enabled"})
+			return
+		}
+
+		admin, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		instanceID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_instance_id"))
+			return
+		}
+
+		var req OpenTerminalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A reason for opening the terminal is required"})
+			return
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" || len(req.Reason) > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be between 1 and 500 characters"})
+			return
+		}
+		if req.Shell == "" {
+			req.Shell = "/bin/sh"
+		}
+		if !strings.HasPrefix(req.Shell, "/") || strings.ContainsAny(req.Shell, " \t\r\n") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "shell must be an absolute path"})
+			return
+		}
+
+		instance, err := db.GetInstanceByID(instanceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+			return
+		}
+		if instance.Status != models.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Instance must be running to open a terminal"})
+			return
+		}
+
+		ticket, session, err := models.NewTerminalSession(instance.ID, admin.ID, req.Shell, req.Reason)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open terminal"})
+			return
+		}
+		if err := db.CreateTerminalSession(session); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to record terminal session")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open terminal"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"admin_id":    admin.ID,
+			"instance_id": instance.ID,
+			"session_id":  session.ID,
+			"reason":      req.Reason,
+		}).Warn("Admin requested a terminal in an instance container")
+
+		c.JSON(http.StatusCreated, gin.H{
+			"session_id": session.ID,
+			"ticket":     ticket,
+			"url":        "/api/v1/terminal/" + ticket,
+			"expires_at": session.ExpiresAt,
+		})
+	}
+}
+
+// ConnectTerminal upgrades to a WebSocket bridged to a shell in an instance's container. The
+// single-use ticket in the path stands in for the Authorization header browsers cannot send
+// with WebSockets. The client sends JSON input and resize messages; the terminal output comes
+// back in binary frames, followed by an exit message when the shell ends.
+func ConnectTerminal(cfg *config.Config, containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		if !cfg.FeatureEnabled(config.FlagAdminTerminal) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admin terminal is not enabled"})
+			return
+		}
+
+		session, err := db.ClaimTerminalSession(models.HashTerminalTicket(c.Param("ticket")), c.ClientIP(), time.Now())
+		if errors.Is(err, db.ErrTerminalTicketInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Terminal ticket is invalid or expired"})
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to claim terminal session")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open terminal"})
+			return
+		}
+
+		// The admin may have lost their role or the instance stopped since the ticket was issued
+		fail := func(status int, message string) {
+			now := time.Now()
+			session.Status = models.TerminalSessionClosed
+			session.Error = message
+			session.EndedAt = &now
+			if err := db.FinishTerminalSession(session); err != nil {
+				logger.WithError(err).WithField("session_id", session.ID).Error("Failed to record terminal session")
+			}
+			c.JSON(status, gin.H{"error": message})
+		}
+		admin, err := db.GetUserByID(session.AdminID)
+		if err != nil || !admin.IsAdmin() {
+			fail(http.StatusForbidden, "Admin access required")
+			return
+		}
+		instance, err := db.GetInstanceByID(session.InstanceID)
+		if err != nil || instance.Status != models.StatusRunning {
+			fail(http.StatusConflict, "Instance must be running to open a terminal")
+			return
+		}
+
+		fields := logrus.Fields{
+			"admin_id":    session.AdminID,
+			"instance_id": session.InstanceID,
+			"session_id":  session.ID,
+			"client_ip":   session.ClientIP,
+		}
+		logger.WithFields(fields).Warn("Admin opened a terminal in an instance container")
+		event := &models.InstanceEvent{
+			InstanceID: instance.ID,
+			UserID:     instance.UserID,
+			Type:       models.EventTerminalOpened,
+			Level:      models.EventLevelWarning,
+			Message:    truncateString("An administrator opened a terminal in the instance's container: "+session.Reason, 1000),
+		}
+		if err := db.CreateInstanceEvent(event); err != nil {
+			logger.WithError(err).WithFields(fields).Warn("Failed to record instance event")
+		}
+
+		server := websocket.Server{
+			// The ticket authenticates the connection, so any origin may use it
+			Handshake: func(*websocket.Config, *http.Request) error { return nil },
+			Handler: func(ws *websocket.Conn) {
+				bridgeTerminal(ws, session, cfg, containerManager)
+			},
+		}
+		server.ServeHTTP(c.Writer, c.Request)
+		if session.Status != models.TerminalSessionClosed {
+			// The handshake failed, so the shell never ran
+			now := time.Now()
+			session.Status = models.TerminalSessionClosed
+			session.Error = "WebSocket handshake failed"
+			session.EndedAt = &now
+		}
+
+		if err := db.FinishTerminalSession(session); err != nil {
+			logger.WithError(err).WithFields(fields).Error("Failed to record terminal session")
+		}
+		fields["exit_code"] = session.ExitCode
+		fields["input_bytes"] = session.InputBytes
+		fields["output_bytes"] = session.OutputBytes
+		fields["error"] = session.Error
+		logger.WithFields(fields).Warn("Admin terminal closed")
+	}
+}
+
+// bridgeTerminal runs the session's shell in the instance's container with the WebSocket as
+// its terminal until the shell exits, the client leaves, it is idle for too long or reaches its
+// maximum duration, then fills in how the session ended
+func bridgeTerminal(ws *websocket.Conn, session *models.TerminalSession, cfg *config.Config, containerManager container.Manager) {
+	ws.MaxPayloadBytes = terminalMaxMessageBytes
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Terminal.MaxDuration)
+	defer cancel()
+
+	stdin, stdinWriter := io.Pipe()
+	resize := make(chan container.TerminalSize, 1)
+	var ended string // why the client side ended the session, if it did
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		defer stdinWriter.Close()
+		defer cancel()
+		for {
+			ws.SetReadDeadline(time.Now().Add(cfg.Terminal.IdleTimeout))
+			var msg terminalMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				var netErr net.Error
+				switch {
+				case ctx.Err() != nil:
+				case errors.As(err, &netErr) && netErr.Timeout():
+					ended = "idle timeout"
+				default:
+					ended = "client disconnected"
+				}
+				return
+			}
+			switch msg.Type {
+			case "input":
+				session.RecordInput([]byte(msg.Data))
+				if _, err := stdinWriter.Write([]byte(msg.Data)); err != nil {
+					return
+				}
+			case "resize":
+				select {
+				case <-resize: // only the latest size matters
+				default:
+				}
+				resize <- container.TerminalSize{Cols: msg.Cols, Rows: msg.Rows}
+			}
+		}
+	}()
+
+	output := &terminalOutput{ws: ws}
+	exitCode, err := containerManager.ExecInstance(ctx, session.InstanceID, container.ExecOptions{
+		Command: []string{session.Command},
+		Stdin:   stdin,
+		Stdout:  output,
+		Resize:  resize,
+	})
+	if err == nil && exitCode >= 0 {
+		websocket.JSON.Send(ws, gin.H{"type": "exit", "code": exitCode})
+	}
+	// Closing the connection ends the reader, so the transcript is complete once it is done.
+	// Cancelling first tells the reader the client did not leave on its own.
+	cancel()
+	ws.Close()
+	stdin.Close()
+	<-readerDone
+
+	now := time.Now()
+	session.Status = models.TerminalSessionClosed
+	session.EndedAt = &now
+	session.OutputBytes = output.written
+	if exitCode >= 0 {
+		session.ExitCode = &exitCode
+	}
+	switch {
+	case ended != "":
+		session.Error = ended
+	case errors.Is(err, context.DeadlineExceeded):
+		session.Error = fmt.Sprintf("reached the maximum duration of %v", cfg.Terminal.MaxDuration)
+	case err != nil:
+		session.Error = truncateString(err.Error(), 500)
+	}
+}
+
+// terminalOutput sends the output of a terminal to its WebSocket in binary frames
+type terminalOutput struct {
+	ws      *websocket.Conn
+	written int64
+}
+
+func (o *terminalOutput) Write(p []byte) (int, error) {
+	if err := websocket.Message.Send(o.ws, p); err != nil {
+		return 0, err
+	}
+	o.written += int64(len(p))
+	return len(p), nil
+}
+
+// AdminListTerminalSessions returns a page of admin terminal sessions without their
+// transcripts, with the number of sessions matching the filters in the X-Total-Count header
+func AdminListTerminalSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, ok := bindListOptions(c, terminalSessionListQuery)
+		if !ok {
+			return
+		}
+
+		sessions, total, err := db.ListTerminalSessions(opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get terminal sessions"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(sessions))
+		for i := range sessions {
+			response[i] = sessions[i].ToPublicResponse()
+		}
+		setTotalCount(c, total)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// AdminGetTerminalSession returns an admin terminal session with the input typed into it
+func AdminGetTerminalSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid terminal session ID"})
+			return
+		}
+
+		session, err := db.GetTerminalSession(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Terminal session not found"})
+			return
+		}
+
+		response := session.ToPublicResponse()
+		response["transcript"] = session.Transcript
+		c.JSON(http.StatusOK, response)
+	}
+}
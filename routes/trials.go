@@ -0,0 +1,271 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/payments"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// errTrialOfferClaimed is returned when a user already claimed their trial extension offer
+var errTrialOfferClaimed = errors.New("trial extension offer already claimed")
+
+// TrialExtensionRequest represents an admin's request to extend a user's trial
+type TrialExtensionRequest struct {
+	Days   int    `json:"days" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// RegisterTrialRoutes registers the trial extension routes. provider is nil when payments
+// are mocked, in which case only trials without a provider subscription can be extended.
+func RegisterTrialRoutes(router *gin.Engine, cfg *config.Config, provider payments.Provider, billingEnforcer *jobs.BillingEnforcer, logger *logrus.Logger) {
+	v1UserRoutes := router.Group("/api/v1/users")
+	v1UserRoutes.GET("/me/trial", GetTrial(cfg))
+	v1UserRoutes.POST("/me/trial/extend", ClaimTrialExtension(cfg, provider, billingEnforcer))
+
+	v1AdminRoutes := router.Group("/api/v1/admin")
+	v1AdminRoutes.Use(middleware.RequireAdmin())
+	v1AdminRoutes.POST("/users/:id/trial/extend", AdminExtendTrial(provider, billingEnforcer))
+}
+
+// trialOfferReference is the journal reference of a user's one-time trial extension
+func trialOfferReference(userID uuid.UUID) string {
+	return fmt.Sprintf("user:%s:trial_extension_offer", userID)
+}
+
+// trialOfferAvailable checks if a user can claim the self-serve trial extension: the offer is
+// switched on, the user is on a trial of a plan offering one, and has not claimed it yet
+func trialOfferAvailable(cfg *config.Config, user models.User) (bool, error) {
+	if !cfg.FeatureEnabled(config.FlagTrialExtensionOffer) || user.SubscriptionStatus != models.StatusTrial {
+		return false, nil
+	}
+	policy, ok := models.PlanTrial(user.Plan)
+	if !ok || policy.OfferDays <= 0 {
+		return false, nil
+	}
+	claimed, err := db.HasBillingReference(trialOfferReference(user.ID))
+	return !claimed, err
+}
+
+// GetTrial returns the current user's trial and whether the one-time extension offer can be
+// claimed
+func GetTrial(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		offerAvailable, err := trialOfferAvailable(cfg, user)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to check trial extension offer")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trial"})
+			return
+		}
+
+		policy, eligible := models.PlanTrial(user.Plan)
+		response := gin.H{
+			"plan":       user.Plan,
+			"eligible":   eligible,
+			"trial_days": policy.Days,
+			"active":     user.IsTrialActive(),
+			"days_left":  user.TrialDaysLeft(),
+			"offer":      nil,
+		}
+		if user.SubscriptionStatus == models.StatusTrial {
+			response["ends_at"] = user.CurrentPeriodEnd
+		}
+		if offerAvailable {
+			response["offer"] = gin.H{"days": policy.OfferDays}
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ClaimTrialExtension extends the current user's trial by the days of their plan's one-time
+// offer. The offer is switched on with the trial_extension_offer feature flag.
+func ClaimTrialExtension(cfg *config.Config, provider payments.Provider, billingEnforcer *jobs.BillingEnforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		available, err := trialOfferAvailable(cfg, user)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to check trial extension offer")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extend trial"})
+			return
+		}
+		if !available {
+			c.JSON(http.StatusConflict, gin.H{"error": "No trial extension is available"})
+			return
+		}
+
+		policy, _ := models.PlanTrial(user.Plan)
+		description := fmt.Sprintf("Claimed the one-time %d day trial extension", policy.OfferDays)
+		status, err := extendTrial(c.Request.Context(), provider, &user, policy.OfferDays, trialOfferReference(user.ID), description)
+		if status == http.StatusInternalServerError {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to extend trial")
+			c.JSON(status, gin.H{"error": "Failed to extend trial"})
+			return
+		}
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id": user.ID,
+			"days":    policy.OfferDays,
+			"ends_at": user.CurrentPeriodEnd,
+		}).Info("User claimed trial extension")
+
+		go billingEnforcer.CheckUser(context.Background(), user)
+
+		c.JSON(http.StatusOK, gin.H{
+			"days":    policy.OfferDays,
+			"ends_at": user.CurrentPeriodEnd,
+		})
+	}
+}
+
+// AdminExtendTrial extends a user's trial by up to the plan's TRIAL_MAX_EXTENSION_DAYS. The
+// reason is kept with the trial grant in the billing journal.
+func AdminExtendTrial(provider payments.Provider, billingEnforcer *jobs.BillingEnforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		admin, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req TrialExtensionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		user, err := db.GetUserByID(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+		if user.SubscriptionStatus != models.StatusTrial {
+			c.JSON(http.StatusConflict, gin.H{"error": "User is not on a trial", "subscription_status": user.SubscriptionStatus})
+			return
+		}
+		policy, ok := models.PlanTrial(user.Plan)
+		if !ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "User's plan does not offer a trial"})
+			return
+		}
+		if req.Days < 1 || req.Days > policy.MaxExtensionDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(policy.MaxExtensionDays)})
+			return
+		}
+
+		reference := fmt.Sprintf("user:%s:trial_extension:%s", user.ID, uuid.New())
+		description := fmt.Sprintf("Admin %s extended trial by %d days: %s", admin.Email, req.Days, req.Reason)
+		status, err := extendTrial(c.Request.Context(), provider, &user, req.Days, reference, description)
+		if status == http.StatusInternalServerError {
+			logger.WithError(err).WithField("user_id", user.ID).Error("Failed to extend trial")
+			c.JSON(status, gin.H{"error": "Failed to extend trial"})
+			return
+		}
+		if err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"admin_id": admin.ID,
+			"user_id":  user.ID,
+			"days":     req.Days,
+			"reason":   req.Reason,
+			"ends_at":  user.CurrentPeriodEnd,
+		}).Warn("Admin extended user trial")
+
+		go billingEnforcer.CheckUser(context.Background(), user)
+
+		response := user.ToPublicResponse()
+		response["trial_extended_by"] = req.Days
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// extendTrial adds days to a user's trial, counted from its end or from now if it already
+// ended. Trials of a provider subscription are extended with the provider first. The grant
+// is journaled under reference, so a reference already recorded is refused. It returns the
+// HTTP status of a failure; internal errors are returned as they are for logging.
+func extendTrial(ctx context.Context, provider payments.Provider, user *models.User, days int, reference, description string) (int, error) {
+	end := user.CurrentPeriodEnd
+	if now := time.Now(); end.Before(now) {
+		end = now
+	}
+	end = end.AddDate(0, 0, days)
+
+	if user.SubscriptionID != "" {
+		if provider == nil || provider.Name() != user.SubscriptionProvider {
+			return http.StatusConflict, fmt.Errorf("Subscription is managed by %s, which is not configured", user.SubscriptionProvider)
+		}
+		err := provider.ExtendTrial(ctx, user.SubscriptionID, end)
+		if errors.Is(err, payments.ErrTrialExtensionUnsupported) {
+			return http.StatusConflict, fmt.Errorf("Trials of %s subscriptions cannot be extended", provider.Name())
+		}
+		if err != nil {
+			return http.StatusBadGateway, fmt.Errorf("Failed to extend trial with %s: %w", provider.Name(), err)
+		}
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		recorded, err := db.RecordTrialGrant(tx, user.ID, int64(days), reference, description)
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			return errTrialOfferClaimed
+		}
+		user.CurrentPeriodEnd = end
+		user.UpdatedAt = time.Now()
+		return tx.Model(user).Updates(map[string]interface{}{
+			"current_period_end": user.CurrentPeriodEnd,
+			"updated_at":         user.UpdatedAt,
+		}).Error
+	})
+	if errors.Is(err, errTrialOfferClaimed) {
+		return http.StatusConflict, errors.New("Trial extension was already claimed")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	db.InvalidateCachedUserByID(user.ID)
+	return http.StatusOK, nil
+}
@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/models"
+)
+
+// regionUptime summarises the probe results of one probe location
+type regionUptime struct {
+	Region         string    `json:"region"`
+	Local          bool      `json:"local"`
+	Checks         int       `json:"checks"`
+	Reachable      int       `json:"reachable_checks"`
+	UptimePercent  float64   `json:"uptime_percent"`
+	AvgLatencyMS   int64     `json:"avg_latency_ms"`
+	LastReachable  bool      `json:"last_reachable"`
+	LastCheckedAt  time.Time `json:"last_checked_at"`
+	LastError      string    `json:"last_error,omitempty"`
+	latencyTotalMS int64
+}
+
+// GetInstanceUptime returns an instance's reachability over a time window, per probe location
+func GetInstanceUptime(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleViewer)
+		if instance == nil {
+			return
+		}
+
+		hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+		if err != nil || hours <= 0 || hours > 720 {
+			hours = 24
+		}
+
+		results, err := db.GetProbeResults(instance.ID, time.Now().Add(-time.Duration(hours)*time.Hour))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get probe results"})
+			return
+		}
+
+		byRegion := map[string]*regionUptime{}
+		for _, result := range results {
+			region, ok := byRegion[result.Region]
+			if !ok {
+				region = &regionUptime{Region: result.Region, Local: result.Region == cfg.Health.Region}
+				byRegion[result.Region] = region
+			}
+			region.Checks++
+			if result.Reachable {
+				region.Reachable++
+				region.latencyTotalMS += result.LatencyMS
+			}
+			// Results are ordered oldest first, so the last one seen is the latest
+			region.LastReachable = result.Reachable
+			region.LastCheckedAt = result.CheckedAt
+			region.LastError = result.Error
+		}
+
+		regions := make([]*regionUptime, 0, len(byRegion))
+		for _, region := range byRegion {
+			region.UptimePercent = float64(region.Reachable) / float64(region.Checks) * 100
+			if region.Reachable > 0 {
+				region.AvgLatencyMS = region.latencyTotalMS / int64(region.Reachable)
+			}
+			regions = append(regions, region)
+		}
+		sort.Slice(regions, func(i, j int) bool {
+			if regions[i].Local != regions[j].Local {
+				return regions[i].Local
+			}
+			return regions[i].Region < regions[j].Region
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"instance_id":  instance.ID,
+			"window_hours": hours,
+			"status":       uptimeStatus(regions),
+			"regions":      regions,
+		})
+	}
+}
+
+// uptimeStatus classifies the latest probe of every location: "up" and "down" when all
+// locations agree, "local_outage" when only this host cannot reach the instance, and
+// "partial_outage" when some remote locations cannot reach it either
+func uptimeStatus(regions []*regionUptime) string {
+	if len(regions) == 0 {
+		return "unknown"
+	}
+
+	reachable := 0
+	localReachable := true
+	for _, region := range regions {
+		if region.LastReachable {
+			reachable++
+		} else if region.Local {
+			localReachable = false
+		}
+	}
+
+	switch {
+	case reachable == len(regions):
+		return "up"
+	case reachable == 0:
+		return "down"
+	case !localReachable && reachable == len(regions)-1:
+		return "local_outage"
+	default:
+		return "partial_outage"
+	}
+}
@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// usageSummaryPeriods are the periods the usage summary can cover
+var usageSummaryPeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// instanceUsageSummary is the resource usage of one instance over the summary's period
+type instanceUsageSummary struct {
+	InstanceID uuid.UUID             `json:"instance_id"`
+	Name       string                `json:"name"`
+	Status     models.InstanceStatus `json:"status"`
+	db.ResourceSummary
+	UptimePercent *float64 `json:"uptime_percent"` // nil without health checks in the period
+	Checks        int64    `json:"checks"`
+}
+
+// accountUsageSummary is the resource usage of all of a user's instances over the summary's
+// period. Averages are the combined average use of the instances and maximums the highest
+// peak of any one of them.
+type accountUsageSummary struct {
+	Instances     int      `json:"instances"`
+	AvgCPU        float64  `json:"avg_cpu"`
+	MaxCPU        float64  `json:"max_cpu"`
+	AvgMemory     int64    `json:"avg_memory"`
+	MaxMemory     int64    `json:"max_memory"`
+	NetworkIn     int64    `json:"network_in"`
+	NetworkOut    int64    `json:"network_out"`
+	UptimePercent *float64 `json:"uptime_percent"`
+	Checks        int64    `json:"checks"`
+}
+
+// GetResourceUsageSummary returns the resource usage of each of the current user's instances
+// and of their whole account over the period in the period query parameter: 24h (default),
+// 7d, 30d or 90d
+func GetResourceUsageSummary() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		periodName := c.DefaultQuery("period", "24h")
+		period, ok := usageSummaryPeriods[periodName]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period must be one of 24h, 7d, 30d or 90d"})
+			return
+		}
+		to := time.Now()
+		from := to.Add(-period)
+
+		instances, err := db.GetInstancesByUserID(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
+			return
+		}
+		instanceIDs := make([]uuid.UUID, 0, len(instances))
+		for _, instance := range instances {
+			if instance.Status != models.StatusDeleted {
+				instanceIDs = append(instanceIDs, instance.ID)
+			}
+		}
+
+		summaries, err := db.GetResourceSummaries(instanceIDs, from)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Failed to summarise resource usage")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get usage summary"})
+			return
+		}
+		probes, err := db.GetProbeCounts(instanceIDs, from)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("Failed to count health checks")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get usage summary"})
+			return
+		}
+
+		account := accountUsageSummary{}
+		var reachable int64
+		perInstance := make([]instanceUsageSummary, 0, len(instanceIDs))
+		for _, instance := range instances {
+			if instance.Status == models.StatusDeleted {
+				continue
+			}
+			summary := summaries[instance.ID]
+			counts := probes[instance.ID]
+			perInstance = append(perInstance, instanceUsageSummary{
+				InstanceID:      instance.ID,
+				Name:            instance.Name,
+				Status:          instance.Status,
+				ResourceSummary: summary,
+				UptimePercent:   uptimePercent(counts.Reachable, counts.Checks),
+				Checks:          counts.Checks,
+			})
+
+			account.Instances++
+			account.AvgCPU += summary.AvgCPU
+			account.AvgMemory += summary.AvgMemory
+			account.MaxCPU = max(account.MaxCPU, summary.MaxCPU)
+			account.MaxMemory = max(account.MaxMemory, summary.MaxMemory)
+			account.NetworkIn += summary.NetworkIn
+			account.NetworkOut += summary.NetworkOut
+			account.Checks += counts.Checks
+			reachable += counts.Reachable
+		}
+		account.UptimePercent = uptimePercent(reachable, account.Checks)
+
+		c.JSON(http.StatusOK, gin.H{
+			"period":    periodName,
+			"from":      from,
+			"to":        to,
+			"account":   account,
+			"instances": perInstance,
+		})
+	}
+}
+
+// uptimePercent returns the share of health checks that reached an instance, or nil without
+// checks
+func uptimePercent(reachable, checks int64) *float64 {
+	if checks == 0 {
+		return nil
+	}
+	percent := float64(reachable) / float64(checks) * 100
+	return &percent
+}
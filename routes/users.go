@@ -2,17 +2,21 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/i18n"
 	"github.com/launchstack/backend/middleware"
 	"github.com/launchstack/backend/models"
 )
 
 // UserUpdateRequest represents the request to update a user
 type UserUpdateRequest struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Language  *string `json:"language"` // Locale of messages, e.g. "hi"; empty to follow Accept-Language
 }
 
 // GetCurrentUser returns the current authenticated user
@@ -20,7 +24,7 @@ func GetCurrentUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, err := middleware.GetUserFromContext(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
@@ -48,26 +52,30 @@ func UpdateCurrentUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, err := middleware.GetUserFromContext(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
 		var req UserUpdateRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_body"))
 			return
 		}
 
 		// Update user fields
+		updates := map[string]interface{}{}
 		if req.FirstName != "" {
 			user.FirstName = req.FirstName
+			updates["first_name"] = req.FirstName
 		}
 		if req.LastName != "" {
 			user.LastName = req.LastName
+			updates["last_name"] = req.LastName
 		}
 
-		// Save changes to database
-		if err := db.DB.Save(&user).Error; err != nil {
+		// Only the changed columns are written, as the user from the context may be cached and
+		// miss changes made elsewhere, e.g. to their plan
+		if err := updateUserColumns(user.ID, updates); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 			return
 		}
@@ -76,45 +84,13 @@ func UpdateCurrentUser() gin.HandlerFunc {
 	}
 }
 
-// GetUsageStats returns usage statistics for all user instances
-func GetUsageStats() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userID, err := middleware.GetUserIDFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-			return
-		}
-
-		// Get all instances for the user
-		instances, err := db.GetInstancesByUserID(userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get instances"})
-			return
-		}
-
-		// Placeholder for actual usage stats
-		// In a real implementation, you would query resource usage from the database
-		usageStats := make(map[string]interface{})
-		for _, instance := range instances {
-			usageStats[instance.ID.String()] = map[string]interface{}{
-				"cpu":     0.0,
-				"memory":  0,
-				"storage": 0,
-				"status":  instance.Status,
-			}
-		}
-
-		c.JSON(http.StatusOK, usageStats)
-	}
-}
-
 // GetInstanceUsage returns usage statistics for a specific instance
 func GetInstanceUsage() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check authentication but we don't need to use userID in this example
 		_, err := middleware.GetUserIDFromContext(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
 			return
 		}
 
@@ -168,8 +144,11 @@ func GetCurrentUserHandler(c *gin.Context) {
 		return
 	}
 	
-	// Return user data
-	c.JSON(http.StatusOK, user)
+	// Return user data with the state of their trial
+	c.JSON(http.StatusOK, struct {
+		models.User
+		Trial models.TrialState `json:"trial"`
+	}{user, user.TrialState()})
 }
 
 // UpdateCurrentUserHandler updates the current user
@@ -187,6 +166,117 @@ func UpdateCurrentUserHandler(c *gin.Context) {
 		return
 	}
 	
-	// For now, just return the user without updates
+	var req UserUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, "invalid_request_body"))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.FirstName != "" {
+		user.FirstName = req.FirstName
+		updates["first_name"] = req.FirstName
+	}
+	if req.LastName != "" {
+		user.LastName = req.LastName
+		updates["last_name"] = req.LastName
+	}
+	if req.Language != nil {
+		user.Language = ""
+		if *req.Language != "" {
+			locale, ok := i18n.Parse(*req.Language)
+			if !ok {
+				body := middleware.ErrorBody(c, "unsupported_language")
+				body["supported"] = i18n.Locales()
+				c.JSON(http.StatusBadRequest, body)
+				return
+			}
+			user.Language = string(locale)
+		}
+		updates["language"] = user.Language
+	}
+
+	if err := updateUserColumns(user.ID, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+
 	c.JSON(http.StatusOK, user)
-} 
\ No newline at end of file
+} 
+// GetUsageSummaryHandler returns the current user's daily usage rollups and totals
+// for the billing page. Defaults to the current calendar month.
+func GetUsageSummaryHandler(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+		return
+	}
+	
+	from, to, ok := parseUsageRange(c)
+	if !ok {
+		return
+	}
+	
+	rollups, err := db.GetUsageRollups(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get usage"})
+		return
+	}
+	
+	var totals models.UsageRollup
+	days := make([]map[string]interface{}, len(rollups))
+	for i, rollup := range rollups {
+		totals.InstanceHours += rollup.InstanceHours
+		totals.CPUHours += rollup.CPUHours
+		totals.EgressBytes += rollup.EgressBytes
+		totals.IngressBytes += rollup.IngressBytes
+		days[i] = rollup.ToPublicResponse()
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Format("2006-01-02"),
+		"to":   to.Format("2006-01-02"),
+		"days": days,
+		"totals": gin.H{
+			"instance_hours": totals.InstanceHours,
+			"cpu_hours":      totals.CPUHours,
+			"egress_bytes":   totals.EgressBytes,
+			"ingress_bytes":  totals.IngressBytes,
+		},
+	})
+}
+
+// parseUsageRange reads the from/to query parameters of usage endpoints, defaulting to
+// the current month. It writes the error response and returns false on invalid input.
+func parseUsageRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var err error
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return from, from, false
+		}
+	}
+	to := from.AddDate(0, 1, 0)
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return from, to, false
+		}
+	}
+	return from, to, true
+}
+
+// updateUserColumns writes only the given columns of a user, so a user loaded from the user
+// cache does not overwrite newer values of the others, and drops the cached user
+func updateUserColumns(userID uuid.UUID, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := db.DB.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+		return err
+	}
+	db.InvalidateCachedUserByID(userID)
+	return nil
+}
@@ -0,0 +1,225 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// joinWaitlist puts an instance request that found the host at capacity in line, responding
+// with 202 and its place in line. Waitlisted requests count towards the instance limit.
+func joinWaitlist(c *gin.Context, user models.User, instanceReq models.Instance, instanceCount int64, logger *logrus.Logger) {
+	active, err := db.GetActiveWaitlistEntriesByUserID(user.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to check waitlisted requests")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join waitlist"})
+		return
+	}
+	if int(instanceCount)+len(active) >= user.GetInstancesLimit() {
+		body := middleware.ErrorBody(c, "instance_limit_reached")
+		body["limit"] = user.GetInstancesLimit()
+		body["waitlisted"] = len(active)
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
+
+	cpuLimit, memoryLimit, storageLimit := container.ResolveResourceLimits(user, instanceReq)
+	entry := &models.WaitlistEntry{
+		UserID:         user.ID,
+		ProjectID:      instanceReq.ProjectID,
+		OrganizationID: instanceReq.OrganizationID,
+		Name:           instanceReq.Name,
+		Description:    instanceReq.Description,
+		ServiceType:    instanceReq.ServiceType,
+		ImageTag:       instanceReq.ImageTag,
+		CPULimit:       cpuLimit,
+		MemoryLimit:    memoryLimit,
+		StorageLimit:   storageLimit,
+		Status:         models.WaitlistWaiting,
+	}
+	if err := db.CreateWaitlistEntry(entry); err != nil {
+		logger.WithError(err).Error("Failed to save waitlist entry")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join waitlist"})
+		return
+	}
+
+	position, err := db.GetWaitlistPosition(entry)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get waitlist position")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"waitlist_entry_id": entry.ID,
+		"position":          position,
+	}).Info("Instance request joined the waitlist")
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "No capacity is available right now, the instance will be created once it is",
+		"waitlist": entry.ToPublicResponse(position),
+	})
+}
+
+// loadWaitlistEntry fetches the waitlist entry in the :entry_id param and checks it belongs to
+// the current user and, for project-scoped API keys, to their project. It writes the error
+// response and returns nil when the entry is not accessible.
+func loadWaitlistEntry(c *gin.Context) *models.WaitlistEntry {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+		return nil
+	}
+
+	entryID, err := uuid.Parse(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid waitlist entry ID"})
+		return nil
+	}
+
+	entry, err := db.GetWaitlistEntryByID(entryID)
+	if err != nil || entry.UserID != userID || !inAPIKeyProject(c, entry.ProjectID) {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "waitlist_entry_not_found"))
+		return nil
+	}
+	return entry
+}
+
+// inAPIKeyProject checks if a project-scoped API key may access something in the given project
+func inAPIKeyProject(c *gin.Context, projectID *uuid.UUID) bool {
+	scopedProjectID, scoped := middleware.GetAPIKeyProjectID(c)
+	return !scoped || (projectID != nil && *projectID == scopedProjectID)
+}
+
+// GetWaitlist returns the current user's instance requests that are waiting for capacity,
+// with their place in line, or that hold reserved capacity
+func GetWaitlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		entries, err := db.GetActiveWaitlistEntriesByUserID(userID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to get waitlist entries")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get waitlist"})
+			return
+		}
+
+		response := make([]map[string]interface{}, 0, len(entries))
+		for i := range entries {
+			entry := &entries[i]
+			if !inAPIKeyProject(c, entry.ProjectID) {
+				continue
+			}
+
+			var position int64
+			if entry.Status == models.WaitlistWaiting {
+				if position, err = db.GetWaitlistPosition(entry); err != nil {
+					logger.WithError(err).WithField("waitlist_entry_id", entry.ID).Warn("Failed to get waitlist position")
+				}
+			}
+			response = append(response, entry.ToPublicResponse(position))
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CancelWaitlistEntry takes an instance request off the waitlist, releasing any capacity reserved for it
+func CancelWaitlistEntry() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		entry := loadWaitlistEntry(c)
+		if entry == nil {
+			return
+		}
+		if !entry.IsActive() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Waitlist entry is no longer waiting", "status": entry.Status})
+			return
+		}
+
+		from := entry.Status
+		entry.Status = models.WaitlistCancelled
+		entry.ReservedUntil = nil
+		cancelled, err := db.TransitionWaitlistEntry(entry, from)
+		if err != nil {
+			logger.WithError(err).Error("Failed to cancel waitlist entry")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel waitlist entry"})
+			return
+		}
+		if !cancelled {
+			c.JSON(http.StatusConflict, gin.H{"error": "Waitlist entry is no longer waiting"})
+			return
+		}
+
+		logger.WithField("waitlist_entry_id", entry.ID).Info("Cancelled waitlist entry")
+		c.JSON(http.StatusOK, gin.H{"message": "Waitlist entry cancelled"})
+	}
+}
+
+// ClaimWaitlistEntry creates the instance of a waitlisted request using the capacity reserved
+// for it, responding like instance creation
+func ClaimWaitlistEntry(waitlist *jobs.Waitlist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		user, err := middleware.GetUserFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "user_not_found"))
+			return
+		}
+
+		entry := loadWaitlistEntry(c)
+		if entry == nil {
+			return
+		}
+		if entry.Status != models.WaitlistReserved || entry.ReservedUntil == nil || entry.ReservedUntil.Before(time.Now()) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Waitlist entry has no reserved capacity", "status": entry.Status})
+			return
+		}
+
+		instance, job, err := waitlist.Provision(c.Request.Context(), &user, entry, models.WaitlistReserved)
+		switch {
+		case errors.Is(err, jobs.ErrWaitlistEntryChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": "Waitlist entry has no reserved capacity"})
+			return
+		case errors.Is(err, container.ErrHostAtCapacity):
+			body := middleware.ErrorBody(c, "host_at_capacity")
+			body["availability"] = capacityWaitlist
+			c.JSON(http.StatusServiceUnavailable, body)
+			return
+		case errors.Is(err, db.ErrHostTaken):
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, "instance_subdomain_taken"))
+			return
+		case err != nil && entry.Status == models.WaitlistFailed:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to create instance: " + entry.Error})
+			return
+		case err != nil:
+			logger.WithError(err).Error("Failed to create instance of waitlist entry")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create instance"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"waitlist_entry_id": entry.ID,
+			"instance_id":       instance.ID,
+		}).Info("Claimed waitlist reservation")
+
+		response := instance.ToPublicResponse()
+		response["provisioning"] = job.ToPublicResponse()
+		c.JSON(http.StatusAccepted, response)
+	}
+}
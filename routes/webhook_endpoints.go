@@ -0,0 +1,431 @@
+package routes
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/db"
+	"github.com/launchstack/backend/jobs"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookEndpointRequest represents a request to create or update a webhook endpoint
+type WebhookEndpointRequest struct {
+	URL         string                    `json:"url" binding:"required,max=2048"`
+	Description string                    `json:"description" binding:"max=255"`
+	Events      []models.WebhookEventType `json:"events"`                // Empty subscribes to all events
+	InstanceID  *uuid.UUID                `json:"instance_id,omitempty"` // Only send events of this instance
+	Active      *bool                     `json:"active,omitempty"`      // Only used on update
+}
+
+// WebhookTestRequest selects the event type a test delivery is sent as
+type WebhookTestRequest struct {
+	Event models.WebhookEventType `json:"event" binding:"required"`
+}
+
+// RegisterWebhookEndpointRoutes registers routes for managing the current user's webhook endpoints
+func RegisterWebhookEndpointRoutes(router *gin.Engine, cfg *config.Config, webhooks *jobs.Webhooks, logger *logrus.Logger) {
+	v1WebhookRoutes := router.Group("/api/v1/webhook-endpoints")
+
+	v1WebhookRoutes.GET("", GetWebhookEndpoints())
+	v1WebhookRoutes.POST("", CreateWebhookEndpoint(cfg))
+	v1WebhookRoutes.GET("/events", GetWebhookEventTypes())
+	v1WebhookRoutes.GET("/sample", GetWebhookSample(cfg))
+	v1WebhookRoutes.PUT("/:id", UpdateWebhookEndpoint(cfg))
+	v1WebhookRoutes.DELETE("/:id", DeleteWebhookEndpoint())
+	v1WebhookRoutes.POST("/:id/test", TestWebhookEndpoint(cfg, webhooks))
+	v1WebhookRoutes.GET("/:id/deliveries", GetWebhookDeliveries())
+}
+
+// validateWebhookURL checks that events can be sent to a URL. Outside development it must use
+// HTTPS and may not point at the loopback or private networks of the host. Host names are not
+// resolved here; deliveries refuse non-public addresses when they connect.
+func validateWebhookURL(cfg *config.Config, rawURL string) string {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" || (target.Scheme != "https" && target.Scheme != "http") {
+		return "URL must be an absolute http or https URL"
+	}
+	if cfg.Server.Environment == "development" {
+		return ""
+	}
+	if target.Scheme != "https" {
+		return "URL must use https"
+	}
+
+	host := strings.ToLower(target.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, "."+cfg.Server.Domain) {
+		return "URL must point at a public host"
+	}
+	if ip := net.ParseIP(host); ip != nil && !jobs.IsPublicAddress(ip) {
+		return "URL must point at a public host"
+	}
+	return ""
+}
+
+// validateWebhookEvents checks that all event types can be subscribed to
+func validateWebhookEvents(events []models.WebhookEventType) string {
+	for _, event := range events {
+		if !models.IsWebhookEventType(event) {
+			return "Unknown event type: " + string(event)
+		}
+	}
+	return ""
+}
+
+// validateWebhookInstance checks that an endpoint can be scoped to an instance of the user
+func validateWebhookInstance(c *gin.Context, userID uuid.UUID, instanceID *uuid.UUID) bool {
+	if instanceID == nil {
+		return true
+	}
+	instance, err := db.GetInstanceByID(*instanceID)
+	if err != nil || instance.UserID != userID {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "instance_not_found"))
+		return false
+	}
+	return true
+}
+
+// loadWebhookEndpoint fetches the webhook endpoint in the :id param and checks it belongs to
+// the current user. It writes the error response and returns nil when it does not.
+func loadWebhookEndpoint(c *gin.Context) *models.WebhookEndpoint {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+		return nil
+	}
+
+	endpointID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook endpoint ID"})
+		return nil
+	}
+
+	endpoint, err := db.GetWebhookEndpointByID(endpointID)
+	if err != nil || endpoint.UserID != userID {
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, "webhook_endpoint_not_found"))
+		return nil
+	}
+	return endpoint
+}
+
+// sampleWebhookEvent returns an event of a type as it would be sent for the user's most
+// recently created instance, or for a made-up instance if they have none. Backup and usage
+// events are filled with made-up backups and usage.
+func sampleWebhookEvent(cfg *config.Config, userID uuid.UUID, eventType models.WebhookEventType) *models.WebhookEvent {
+	instance := &models.Instance{
+		ID:           uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+		UserID:       userID,
+		Name:         "My n8n",
+		URL:          "happy-panda",
+		ImageTag:     models.LatestImageTag,
+		CPULimit:     0.5,
+		MemoryLimit:  512,
+		StorageLimit: 1,
+		CreatedAt:    time.Now().UTC().Add(-24 * time.Hour),
+	}
+	if instances, err := db.GetInstancesByUserID(userID); err == nil && len(instances) > 0 {
+		instance = &instances[0]
+		for i := range instances {
+			if instances[i].CreatedAt.After(instance.CreatedAt) {
+				instance = &instances[i]
+			}
+		}
+	}
+
+	sample := *instance
+	var event *models.WebhookEvent
+	switch eventType {
+	case models.WebhookBackupCompleted, models.WebhookBackupFailed:
+		backup := &models.Backup{
+			ID:        uuid.MustParse("b23e4567-e89b-12d3-a456-426614174000"),
+			Trigger:   models.BackupScheduled,
+			Status:    models.BackupSucceeded,
+			SizeBytes: 48 * 1024 * 1024,
+		}
+		if eventType == models.WebhookBackupFailed {
+			backup.Status = models.BackupFailed
+			backup.SizeBytes = 0
+			backup.Error = "failed to archive instance data: context deadline exceeded"
+		}
+		event = models.NewBackupWebhookEvent(&sample, backup)
+	case models.WebhookUsageThresholdExceeded:
+		limit := int64(sample.StorageLimit) * 1024 * 1024 * 1024
+		event = models.NewUsageWebhookEvent(userID, &sample, models.UsageThresholdStorage, limit, limit*9/10)
+	default:
+		var previous models.InstanceStatus
+		if eventType == models.WebhookInstanceCreated {
+			sample.Status = models.StatusPending
+		} else {
+			sample.Status = models.InstanceStatus(strings.TrimPrefix(string(eventType), "instance."))
+			previous = models.StatusRunning
+			if sample.Status == models.StatusRunning {
+				previous = models.StatusStopped
+			}
+		}
+		if sample.Status == models.StatusSuspended && sample.SuspendedReason == "" {
+			sample.SuspendedReason = models.SuspendReasonStorage
+		}
+		event = models.NewInstanceWebhookEvent(eventType, &sample, previous)
+	}
+	event.InstanceURL = sample.GetURL(cfg.Server.Domain)
+	return event
+}
+
+// GetWebhookEndpoints returns the current user's webhook endpoints
+func GetWebhookEndpoints() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		endpoints, err := db.GetWebhookEndpointsByUserID(userID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to get webhook endpoints")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook endpoints"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(endpoints))
+		for i := range endpoints {
+			response[i] = endpoints[i].ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// CreateWebhookEndpoint adds a webhook endpoint for the current user. The response is the only
+// one to include the secret events are signed with.
+func CreateWebhookEndpoint(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		var req WebhookEndpointRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if message := validateWebhookURL(cfg, req.URL); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		if message := validateWebhookEvents(req.Events); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		if !validateWebhookInstance(c, userID, req.InstanceID) {
+			return
+		}
+
+		endpoint := &models.WebhookEndpoint{
+			UserID:      userID,
+			InstanceID:  req.InstanceID,
+			URL:         req.URL,
+			Description: req.Description,
+			Active:      true,
+		}
+		endpoint.SetEventTypes(req.Events)
+		secret, err := endpoint.GenerateSecret()
+		if err != nil {
+			logger.WithError(err).Error("Failed to generate webhook secret")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook endpoint"})
+			return
+		}
+		if err := db.CreateWebhookEndpoint(endpoint); err != nil {
+			logger.WithError(err).Error("Failed to save webhook endpoint")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook endpoint"})
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"user_id":             userID,
+			"webhook_endpoint_id": endpoint.ID,
+		}).Info("Created webhook endpoint")
+
+		response := endpoint.ToPublicResponse()
+		response["secret"] = secret
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// UpdateWebhookEndpoint changes the URL, description, events, instance or active state of a
+// webhook endpoint
+func UpdateWebhookEndpoint(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		endpoint := loadWebhookEndpoint(c)
+		if endpoint == nil {
+			return
+		}
+
+		var req WebhookEndpointRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if message := validateWebhookURL(cfg, req.URL); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		if message := validateWebhookEvents(req.Events); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		if !validateWebhookInstance(c, endpoint.UserID, req.InstanceID) {
+			return
+		}
+
+		endpoint.URL = req.URL
+		endpoint.Description = req.Description
+		endpoint.InstanceID = req.InstanceID
+		endpoint.SetEventTypes(req.Events)
+		if req.Active != nil {
+			endpoint.Active = *req.Active
+		}
+		if err := db.UpdateWebhookEndpoint(endpoint); err != nil {
+			logger.WithError(err).Error("Failed to update webhook endpoint")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook endpoint"})
+			return
+		}
+
+		c.JSON(http.StatusOK, endpoint.ToPublicResponse())
+	}
+}
+
+// DeleteWebhookEndpoint removes a webhook endpoint; events queued for it are dropped
+func DeleteWebhookEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		endpoint := loadWebhookEndpoint(c)
+		if endpoint == nil {
+			return
+		}
+
+		if err := db.DeleteWebhookEndpoint(endpoint.ID); err != nil {
+			logger.WithError(err).Error("Failed to delete webhook endpoint")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook endpoint"})
+			return
+		}
+
+		logger.WithField("webhook_endpoint_id", endpoint.ID).Info("Deleted webhook endpoint")
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook endpoint deleted"})
+	}
+}
+
+// GetWebhookEventTypes lists the event types webhook endpoints can subscribe to
+func GetWebhookEventTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"schema_version": models.WebhookSchemaVersion,
+			"events":         models.WebhookEventTypes,
+		})
+	}
+}
+
+// GetWebhookSample returns a sample payload of an event type, built from the current user's
+// latest instance, so automation platforms can map its fields before a real event occurs
+func GetWebhookSample(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := middleware.GetUserIDFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, "unauthorized"))
+			return
+		}
+
+		eventType := models.WebhookEventType(c.DefaultQuery("event", string(models.WebhookInstanceCreated)))
+		if !models.IsWebhookEventType(eventType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type: " + string(eventType)})
+			return
+		}
+
+		event := sampleWebhookEvent(cfg, userID, eventType)
+		event.Test = true
+		c.JSON(http.StatusOK, event)
+	}
+}
+
+// TestWebhookEndpoint sends a sample event of a type to a webhook endpoint right away and
+// reports how the endpoint answered. Test events have "test": true and are not retried.
+func TestWebhookEndpoint(cfg *config.Config, webhooks *jobs.Webhooks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint := loadWebhookEndpoint(c)
+		if endpoint == nil {
+			return
+		}
+
+		var req WebhookTestRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if !models.IsWebhookEventType(req.Event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type: " + string(req.Event)})
+			return
+		}
+
+		event := sampleWebhookEvent(cfg, endpoint.UserID, req.Event)
+		event.Test = true
+		status, err := webhooks.Send(c.Request.Context(), endpoint, event)
+
+		response := gin.H{
+			"delivered":       err == nil,
+			"response_status": status,
+			"event":           event,
+		}
+		if err != nil {
+			response["error"] = err.Error()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetWebhookDeliveries returns the latest attempts at delivering events to a webhook endpoint,
+// including test deliveries, newest first
+func GetWebhookDeliveries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		endpoint := loadWebhookEndpoint(c)
+		if endpoint == nil {
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		deliveries, err := db.GetWebhookDeliveries(endpoint.ID, limit)
+		if err != nil {
+			logger.WithError(err).Error("Failed to get webhook deliveries")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhook deliveries"})
+			return
+		}
+
+		response := make([]map[string]interface{}, len(deliveries))
+		for i := range deliveries {
+			response[i] = deliveries[i].ToPublicResponse()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
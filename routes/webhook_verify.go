@@ -0,0 +1,264 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/models"
+)
+
+// webhookCheckTimeout bounds each step of a webhook reachability check
+const webhookCheckTimeout = 10 * time.Second
+
+// webhookPathPattern restricts the webhook paths a check may call to n8n's path syntax
+var webhookPathPattern = regexp.MustCompile(`^[A-Za-z0-9_\-/:.]{1,200}$`)
+
+// VerifyWebhooksRequest represents a request to check an instance's webhooks from outside.
+// Without a path a webhook that does not exist is called, which no workflow handles.
+type VerifyWebhooksRequest struct {
+	Path   string `json:"path"`
+	Method string `json:"method" binding:"omitempty,oneof=GET POST PUT PATCH DELETE HEAD"`
+	Test   bool   `json:"test"` // call the /webhook-test/ URL of a workflow listening in the editor
+}
+
+// webhookCheckStep is the outcome of one step of a webhook reachability check
+type webhookCheckStep struct {
+	OK         bool   `json:"ok"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// dnsCheck reports how the instance's host name resolves
+type dnsCheck struct {
+	webhookCheckStep
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// tlsCheck reports the certificate the instance's host serves
+type tlsCheck struct {
+	webhookCheckStep
+	Subject  string     `json:"subject,omitempty"`
+	Issuer   string     `json:"issuer,omitempty"`
+	DNSNames []string   `json:"dns_names,omitempty"`
+	NotAfter *time.Time `json:"not_after,omitempty"`
+}
+
+// httpCheck reports the answer to the webhook call
+type httpCheck struct {
+	webhookCheckStep
+	URL        string `json:"url"`
+	Method     string `json:"method"`
+	StatusCode int    `json:"status_code,omitempty"`
+	ReachedN8N bool   `json:"reached_n8n"` // the answer came from n8n rather than the proxy
+}
+
+// VerifyInstanceWebhooks calls an instance's public webhook URL from the backend, through the
+// same DNS, TLS and proxy path external services use, and reports each step with hints for
+// the step that failed
+func VerifyInstanceWebhooks() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+		if instance.URL == "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Instance has no URL yet", "status": instance.Status})
+			return
+		}
+
+		var req VerifyWebhooksRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+				return
+			}
+		}
+		req.Path = strings.Trim(req.Path, "/")
+		if req.Path != "" && (!webhookPathPattern.MatchString(req.Path) || strings.Contains(req.Path, "..")) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "path must be a webhook path such as my-workflow or 6f1c2a/orders"})
+			return
+		}
+		if req.Method == "" {
+			req.Method = http.MethodGet
+		}
+
+		host := strings.TrimSuffix(instance.URL, "/")
+		prefix := "/webhook/"
+		if req.Test || req.Path == "" {
+			prefix = "/webhook-test/"
+		}
+		path := req.Path
+		if path == "" {
+			path = "launchstack-verify-" + randomSuffix()
+		}
+		webhookURL := "https://" + host + prefix + path
+
+		ctx := c.Request.Context()
+		dns := checkWebhookDNS(ctx, host)
+		tlsResult := tlsCheck{webhookCheckStep: webhookCheckStep{Skipped: true}}
+		httpResult := httpCheck{URL: webhookURL, Method: req.Method, webhookCheckStep: webhookCheckStep{Skipped: true}}
+		if dns.OK {
+			tlsResult = checkWebhookTLS(ctx, host)
+			httpResult = checkWebhookHTTP(ctx, req.Method, webhookURL, tlsResult.OK)
+		}
+
+		ok := dns.OK && tlsResult.OK && httpResult.OK
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, gin.H{
+			"instance_id": instance.ID,
+			"status":      instance.Status,
+			"webhook_url": webhookURL,
+			"ok":          ok,
+			"checks": gin.H{
+				"dns":  dns,
+				"tls":  tlsResult,
+				"http": httpResult,
+			},
+			"hints":      webhookHints(instance, req.Path != "", dns, tlsResult, httpResult),
+			"checked_at": time.Now(),
+		})
+	}
+}
+
+// randomSuffix returns a random hex string for webhook paths no workflow listens on
+func randomSuffix() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// checkWebhookDNS resolves the host name like an external caller would
+func checkWebhookDNS(ctx context.Context, host string) dnsCheck {
+	ctx, cancel := context.WithTimeout(ctx, webhookCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+	result := dnsCheck{webhookCheckStep: webhookCheckStep{DurationMS: time.Since(start).Milliseconds()}, Addresses: addresses}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = len(addresses) > 0
+	return result
+}
+
+// checkWebhookTLS makes a TLS handshake with the host and describes the certificate it serves.
+// A certificate that does not verify is read again without verification to describe it.
+func checkWebhookTLS(ctx context.Context, host string) tlsCheck {
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: webhookCheckTimeout}, Config: &tls.Config{ServerName: host}}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "443"))
+	result := tlsCheck{webhookCheckStep: webhookCheckStep{DurationMS: time.Since(start).Milliseconds()}}
+	if err != nil {
+		result.Error = err.Error()
+		var certErr *tls.CertificateVerificationError
+		if !errors.As(err, &certErr) {
+			return result
+		}
+		dialer.Config = &tls.Config{ServerName: host, InsecureSkipVerify: true}
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "443"))
+		if err != nil {
+			return result
+		}
+	} else {
+		result.OK = true
+	}
+	defer conn.Close()
+
+	certificates := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certificates) > 0 {
+		leaf := certificates[0]
+		result.Subject = leaf.Subject.CommonName
+		result.Issuer = leaf.Issuer.CommonName
+		result.DNSNames = leaf.DNSNames
+		result.NotAfter = &leaf.NotAfter
+	}
+	return result
+}
+
+// checkWebhookHTTP calls the webhook URL and tells whether n8n answered. n8n answers calls to
+// webhooks no workflow listens on with a 404 naming the webhook, which shows the whole path
+// works. A certificate that did not verify is skipped so the proxy's answer can be reported.
+func checkWebhookHTTP(ctx context.Context, method, url string, verified bool) httpCheck {
+	result := httpCheck{URL: url, Method: method}
+	ctx, cancel := context.WithTimeout(ctx, webhookCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", "LaunchStack-Webhook-Check/1.0")
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: !verified}},
+		// Report redirects, e.g. to a login page, instead of following them
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	result.StatusCode = resp.StatusCode
+	notRegistered := resp.StatusCode == http.StatusNotFound && strings.Contains(string(body), "webhook")
+	result.ReachedN8N = notRegistered || (resp.StatusCode < 500 && resp.StatusCode != http.StatusNotFound)
+	result.OK = verified && (notRegistered || (resp.StatusCode >= 200 && resp.StatusCode < 300))
+	if !result.OK && result.Error == "" {
+		result.Error = fmt.Sprintf("webhook returned status %d", resp.StatusCode)
+		if snippet := strings.TrimSpace(string(body)); snippet != "" && len(snippet) < 300 {
+			result.Error += ": " + snippet
+		}
+	}
+	return result
+}
+
+// webhookHints explains the first failed step of a check in terms of what usually causes it
+func webhookHints(instance *models.Instance, ownPath bool, dns dnsCheck, tlsResult tlsCheck, httpResult httpCheck) []string {
+	hints := []string{}
+	if instance.Status != models.StatusRunning {
+		hints = append(hints, fmt.Sprintf("The instance is %s; webhooks are only served while it is running.", instance.Status))
+	}
+
+	switch {
+	case !dns.OK:
+		hints = append(hints, "The host name does not resolve. DNS records of new instances can take a few minutes to propagate; if it persists, contact support.")
+	case !tlsResult.OK && tlsResult.NotAfter != nil && time.Now().After(*tlsResult.NotAfter):
+		hints = append(hints, "The TLS certificate has expired, so callers that verify certificates reject the webhook. See GET /api/v1/instances/"+instance.ID.String()+"/certificate.")
+	case !tlsResult.OK && tlsResult.Subject != "":
+		hints = append(hints, "The TLS certificate does not verify for this host name, e.g. because it is still being issued. See GET /api/v1/instances/"+instance.ID.String()+"/certificate.")
+	case !tlsResult.OK:
+		hints = append(hints, "No TLS connection could be made on port 443. The proxy may be down or a firewall may block the connection.")
+	case httpResult.StatusCode == http.StatusBadGateway || httpResult.StatusCode == http.StatusServiceUnavailable || httpResult.StatusCode == http.StatusGatewayTimeout:
+		hints = append(hints, "The proxy answered but could not reach n8n. Check that the instance is running and healthy.")
+	case httpResult.StatusCode == http.StatusUnauthorized:
+		hints = append(hints, "The webhook asked for credentials. Check the authentication set on the workflow's Webhook node.")
+	case ownPath && httpResult.StatusCode == http.StatusNotFound:
+		hints = append(hints, "n8n is reachable but no workflow listens on this path. Activate the workflow for /webhook/ URLs, or click \"Listen for test event\" in the editor and check again with test set for /webhook-test/ URLs.")
+	case httpResult.Error != "" && httpResult.StatusCode == 0:
+		hints = append(hints, "The request did not get an answer in time. The workflow may respond slowly, or the proxy may not forward the request.")
+	case httpResult.OK && !ownPath:
+		hints = append(hints, "Webhooks reach n8n from the internet. If a webhook still fails, check that its workflow is active and that the caller uses the production /webhook/ URL rather than /webhook-test/.")
+	}
+	return hints
+}
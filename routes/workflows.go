@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/launchstack/backend/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// maxWorkflowBundleBytes limits the size of an imported workflow bundle
+const maxWorkflowBundleBytes = 32 << 20
+
+// ExportWorkflows returns every workflow of an instance as a JSON bundle, read through the
+// instance's internal n8n API so its login is never handed out
+func ExportWorkflows(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+		if instance.Status != models.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only running instances can export workflows", "status": instance.Status})
+			return
+		}
+
+		bundle, err := containerManager.ExportWorkflows(context.Background(), instance.ID)
+		if err != nil {
+			respondWorkflowError(c, logger, instance, "export", err)
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", instance.Host+"-workflows.json"))
+		c.JSON(http.StatusOK, bundle)
+	}
+}
+
+// StoreWorkflowExport exports every workflow of an instance to object storage and returns a
+// presigned URL downloading the bundle, for bundles too large to pass through the browser
+func StoreWorkflowExport(containerManager container.Manager, objectStore storage.ObjectStore, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+		if instance.Status != models.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only running instances can export workflows", "status": instance.Status})
+			return
+		}
+
+		bundle, err := containerManager.ExportWorkflows(context.Background(), instance.ID)
+		if err != nil {
+			respondWorkflowError(c, logger, instance, "export", err)
+			return
+		}
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to encode workflow bundle")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store workflow export"})
+			return
+		}
+
+		key := fmt.Sprintf("exports/%s/%s-workflows.json", instance.ID, bundle.ExportedAt.Format("20060102T150405Z"))
+		if err := objectStore.Put(c.Request.Context(), key, bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to store workflow export")
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to store workflow export"})
+			return
+		}
+		response, err := presignedDownload(objectStore, cfg, key)
+		if err != nil {
+			logger.WithError(err).WithField("instance_id", instance.ID).Error("Failed to sign workflow export URL")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create download URL"})
+			return
+		}
+
+		response["key"] = key
+		response["workflows"] = len(bundle.Workflows)
+		response["size_bytes"] = len(data)
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// ImportWorkflows creates the workflows of a JSON bundle on an instance as new, inactive
+// workflows, e.g. to move them from another instance or restore a backup
+func ImportWorkflows(containerManager container.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := c.MustGet("logger").(*logrus.Logger)
+
+		instance := loadInstance(c, models.OrgRoleMember)
+		if instance == nil {
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWorkflowBundleBytes)
+		var bundle models.WorkflowBundle
+		if err := c.ShouldBindJSON(&bundle); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow bundle: " + err.Error()})
+			return
+		}
+		if bundle.Version != models.WorkflowBundleVersion {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported workflow bundle version %d", bundle.Version)})
+			return
+		}
+		if len(bundle.Workflows) == 0 || len(bundle.Workflows) > models.MaxBundleWorkflows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A workflow bundle must contain between 1 and %d workflows", models.MaxBundleWorkflows)})
+			return
+		}
+		if instance.Status != models.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Only running instances can import workflows", "status": instance.Status})
+			return
+		}
+
+		result, err := containerManager.ImportWorkflows(context.Background(), instance.ID, &bundle)
+		if err != nil {
+			respondWorkflowError(c, logger, instance, "import", err)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"instance_id": instance.ID,
+			"imported":    result.Imported,
+			"failed":      result.Failed,
+		}).Info("Imported workflow bundle")
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// respondWorkflowError answers a workflow export or import that could not talk to n8n
+func respondWorkflowError(c *gin.Context, logger *logrus.Logger, instance *models.Instance, operation string, err error) {
+	if errors.Is(err, container.ErrServiceUnsupported) {
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, "service_unsupported"))
+		return
+	}
+	if errors.Is(err, container.ErrNoCredentials) {
+		c.JSON(http.StatusConflict, gin.H{"error": "No credentials are known for this instance"})
+		return
+	}
+	logger.WithError(err).WithField("instance_id", instance.ID).Errorf("Failed to %s workflows", operation)
+	c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to %s workflows through n8n", operation)})
+}
@@ -14,6 +14,8 @@ function show_help {
     echo "  docker              - Test Docker integration"
     echo "  dns                 - Test DNS integration"
     echo "  cors                - Test CORS configuration"
+    echo "  secrets             - Check API responses and logs for exposed secrets"
+    echo "  replicas            - Run two replicas behind a round-robin proxy"
     echo "  all                 - Run all tests"
     echo ""
     echo "Examples:"
@@ -59,11 +61,20 @@ case "$1" in
         echo "Running CORS configuration test..."
         (cd tests/tools && go run test_cors.go)
         ;;
+    secrets)
+        echo "Running secret exposure check..."
+        (cd tests/tools && go run test_secret_fields.go)
+        ;;
+    replicas)
+        echo "Running multi-replica test..."
+        (cd tests/tools && go run test_replicas.go)
+        ;;
     all)
         echo "Running all tests..."
         (cd tests/tools && go run test_cors.go)
         (cd tests/tools && go run test_docker.go)
         (cd tests/tools && go run test_paypal_auth.go)
+        (cd tests/tools && go run test_secret_fields.go)
         (cd tests/scripts && ./test_clerk_webhook.sh)
         (cd tests/scripts && ./test_paypal_webhook.sh)
         ;;
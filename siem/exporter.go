@@ -0,0 +1,192 @@
+package siem
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchstack/backend/config"
+	"github.com/sirupsen/logrus"
+)
+
+// EventType distinguishes the kinds of log events shipped to the SIEM
+type EventType string
+
+const (
+	EventAudit  EventType = "audit"
+	EventAccess EventType = "access"
+)
+
+// Event is a single audit or access log entry
+type Event struct {
+	Type      EventType              `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Fields    map[string]interface{} `json:"fields"`
+}
+
+// Sink delivers a batch of events to an external system
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// Exporter buffers events and ships them to a sink in batches, retrying failed
+// deliveries. When the buffer is full new events are dropped rather than blocking
+// request handling.
+type Exporter struct {
+	sink   Sink
+	config *config.Config
+	logger *logrus.Logger
+
+	events  chan Event
+	done    chan struct{}
+	stopped chan struct{}
+	closed  atomic.Bool
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// NewExporter creates an exporter for the configured SIEM, or returns nil when export is disabled
+func NewExporter(cfg *config.Config, logger *logrus.Logger) (*Exporter, error) {
+	if cfg.SIEM.Type == "" {
+		return nil, nil
+	}
+
+	sink, err := newSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		sink:    sink,
+		config:  cfg,
+		logger:  logger,
+		events:  make(chan Event, cfg.SIEM.BufferSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}, nil
+}
+
+// newSink creates the sink for the configured SIEM type
+func newSink(cfg *config.Config) (Sink, error) {
+	switch cfg.SIEM.Type {
+	case "syslog":
+		return NewSyslogSink(cfg.SIEM.Network, cfg.SIEM.Endpoint), nil
+	case "splunk":
+		return NewSplunkSink(cfg.SIEM.Endpoint, cfg.SIEM.Token), nil
+	case "http":
+		return NewHTTPSink(cfg.SIEM.Endpoint, cfg.SIEM.Token), nil
+	default:
+		return nil, fmt.Errorf("unsupported SIEM export type %q", cfg.SIEM.Type)
+	}
+}
+
+// Emit queues an event for export without blocking
+func (e *Exporter) Emit(eventType EventType, fields map[string]interface{}) {
+	if e == nil || e.closed.Load() {
+		return
+	}
+	if eventType == EventAccess && !e.config.SIEM.IncludeAccessLogs {
+		return
+	}
+
+	select {
+	case e.events <- Event{Type: eventType, Timestamp: time.Now().UTC(), Fields: fields}:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// Start ships queued events in the background until Close is called
+func (e *Exporter) Start() {
+	e.logger.Infof("Exporting audit logs to %s SIEM at %s", e.config.SIEM.Type, e.config.SIEM.Endpoint)
+	go e.run()
+}
+
+// Close stops accepting events and flushes the ones already queued, bounded by ctx
+func (e *Exporter) Close(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+
+	e.once.Do(func() {
+		e.closed.Store(true)
+		close(e.done)
+	})
+
+	select {
+	case <-e.stopped:
+		return e.sink.Close()
+	case <-ctx.Done():
+		return fmt.Errorf("timed out flushing SIEM events: %w", ctx.Err())
+	}
+}
+
+// run collects events into batches and flushes them by size or interval
+func (e *Exporter) run() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(e.config.SIEM.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, e.config.SIEM.BatchSize)
+	for {
+		select {
+		case event := <-e.events:
+			batch = append(batch, event)
+			if len(batch) >= e.config.SIEM.BatchSize {
+				e.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.flush(batch)
+			batch = batch[:0]
+		case <-e.done:
+			// Drain whatever is still buffered before stopping
+			for {
+				select {
+				case event := <-e.events:
+					batch = append(batch, event)
+					if len(batch) >= e.config.SIEM.BatchSize {
+						e.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					e.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush sends a batch, retrying with exponential backoff before giving up on it
+func (e *Exporter) flush(batch []Event) {
+	if dropped := e.dropped.Swap(0); dropped > 0 {
+		e.logger.WithField("dropped", dropped).Warn("SIEM export buffer full, events were dropped")
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := e.sink.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+
+		if attempt >= e.config.SIEM.MaxRetries {
+			e.logger.WithError(err).WithField("events", len(batch)).Error("Failed to export events to SIEM, discarding batch")
+			return
+		}
+
+		e.logger.WithError(err).WithField("attempt", attempt+1).Warn("Failed to export events to SIEM, retrying")
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,182 @@
+package siem
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// SyslogSink writes events as RFC 5424 syslog messages over udp, tcp or tcp+tls
+type SyslogSink struct {
+	network  string
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogSink creates a syslog sink for the given network and address
+func NewSyslogSink(network, address string) *SyslogSink {
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{network: network, address: address, hostname: hostname}
+}
+
+// Send writes each event as a syslog message, reconnecting if the connection was lost
+func (s *SyslogSink) Send(ctx context.Context, events []Event) error {
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+
+	for _, event := range events {
+		if _, err := s.conn.Write(s.format(event)); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return fmt.Errorf("failed to write syslog message: %w", err)
+		}
+	}
+	return nil
+}
+
+// dial opens the connection to the syslog server
+func (s *SyslogSink) dial(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+
+	var err error
+	switch s.network {
+	case "tcp+tls":
+		tlsDialer := &tls.Dialer{NetDialer: dialer}
+		s.conn, err = tlsDialer.DialContext(ctx, "tcp", s.address)
+	default:
+		s.conn, err = dialer.DialContext(ctx, s.network, s.address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog server %s: %w", s.address, err)
+	}
+	return nil
+}
+
+// format renders an event as a newline terminated RFC 5424 message with a JSON body.
+// Audit events are logged at notice severity and access events at info, facility local0.
+func (s *SyslogSink) format(event Event) []byte {
+	priority := 16*8 + 6
+	if event.Type == EventAudit {
+		priority = 16*8 + 5
+	}
+
+	body, _ := json.Marshal(event)
+	return []byte(fmt.Sprintf("<%d>1 %s %s launchstack - %s - %s\n",
+		priority, event.Timestamp.Format(time.RFC3339Nano), s.hostname, event.Type, body))
+}
+
+// Close closes the syslog connection
+func (s *SyslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// SplunkSink posts events to a Splunk HTTP Event Collector
+type SplunkSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewSplunkSink creates a sink for the HEC event endpoint,
+// e.g. https://splunk.example.com:8088/services/collector/event
+func NewSplunkSink(url, token string) *SplunkSink {
+	return &SplunkSink{url: url, token: token, client: &http.Client{}}
+}
+
+// Send posts the batch as concatenated HEC events
+func (s *SplunkSink) Send(ctx context.Context, events []Event) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, event := range events {
+		err := encoder.Encode(map[string]interface{}{
+			"time":       float64(event.Timestamp.UnixNano()) / 1e9,
+			"source":     "launchstack",
+			"sourcetype": "launchstack:" + string(event.Type),
+			"event":      event.Fields,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	return postBatch(ctx, s.client, s.url, "Splunk "+s.token, &body)
+}
+
+// Close is a no-op for HTTP sinks
+func (s *SplunkSink) Close() error {
+	return nil
+}
+
+// HTTPSink posts events as a JSON array to a generic HTTPS collector
+type HTTPSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPSink creates a sink that posts to the given URL, authenticating with a bearer token if set
+func NewHTTPSink(url, token string) *HTTPSink {
+	return &HTTPSink{url: url, token: token, client: &http.Client{}}
+}
+
+// Send posts the batch as a JSON array
+func (s *HTTPSink) Send(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %w", err)
+	}
+
+	authorization := ""
+	if s.token != "" {
+		authorization = "Bearer " + s.token
+	}
+	return postBatch(ctx, s.client, s.url, authorization, bytes.NewReader(body))
+}
+
+// Close is a no-op for HTTP sinks
+func (s *HTTPSink) Close() error {
+	return nil
+}
+
+// postBatch posts a request body and treats any non-2xx response as a failure
+func postBatch(ctx context.Context, client *http.Client, url, authorization string, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/config"
+)
+
+// LocalObjectsPath is the API path serving objects of the local store through signed URLs
+const LocalObjectsPath = "/api/v1/storage/objects/"
+
+// ErrInvalidSignature is returned for a signed URL that was altered or has expired
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// LocalStore keeps objects in a directory on this host. Its presigned URLs point at the API,
+// which serves the object once it has checked the URL's HMAC signature.
+type LocalStore struct {
+	root       string
+	backendURL string
+	signingKey []byte
+}
+
+// NewLocalStore creates a store keeping objects under OBJECT_STORAGE_LOCAL_PATH
+func NewLocalStore(cfg *config.Config) *LocalStore {
+	return &LocalStore{
+		root:       cfg.ObjectStorage.LocalPath,
+		backendURL: strings.TrimRight(cfg.Server.BackendURL, "/"),
+		signingKey: cfg.ObjectStorage.SigningKey,
+	}
+}
+
+// Name identifies local objects
+func (s *LocalStore) Name() string {
+	return "local"
+}
+
+// path returns the file of a key, refusing keys that would leave the store's directory
+func (s *LocalStore) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || cleaned != "/"+key {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put writes an object to a temporary file and moves it in place once it is complete, so an
+// interrupted upload never leaves a truncated object under its key
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(target), ".partial-*")
+	if err != nil {
+		return fmt.Errorf("failed to create object file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write object file: %w", err)
+	}
+	if written != size {
+		return fmt.Errorf("object file has %d bytes, expected %d", written, size)
+	}
+	return os.Rename(file.Name(), target)
+}
+
+// Open opens the file of an object
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file of an object
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(target)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object file: %w", err)
+	}
+	return nil
+}
+
+// PresignGet returns a URL of the API serving the object until expiry has passed
+func (s *LocalStore) PresignGet(key string, expiry time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.signature(key, expires)},
+	}
+	return s.backendURL + LocalObjectsPath + key + "?" + query.Encode(), nil
+}
+
+// VerifyPresigned checks the expiry and signature of a URL returned by PresignGet
+func (s *LocalStore) VerifyPresigned(key, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, expires))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// signature returns the HMAC-SHA256 of a key and expiry time
+func (s *LocalStore) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
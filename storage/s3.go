@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/launchstack/backend/config"
+)
+
+// s3Client is shared by requests to the bucket; uploads of backups can take a while
+var s3Client = &http.Client{Timeout: 30 * time.Minute}
+
+// S3Store keeps objects in a bucket of an S3-compatible service. Requests are signed with
+// AWS Signature Version 4, which AWS, MinIO, Cloudflare R2 and others accept.
+type S3Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+}
+
+// NewS3Store creates a store for the bucket configured with the S3_* variables
+func NewS3Store(cfg *config.Config) *S3Store {
+	endpoint, err := url.Parse(cfg.ObjectStorage.S3Endpoint)
+	if err != nil || endpoint.Host == "" {
+		endpoint = &url.URL{Scheme: "https", Host: "s3.amazonaws.com"}
+	}
+	return &S3Store{
+		endpoint:  endpoint,
+		region:    cfg.ObjectStorage.S3Region,
+		bucket:    cfg.ObjectStorage.S3Bucket,
+		prefix:    cfg.ObjectStorage.S3Prefix,
+		accessKey: cfg.ObjectStorage.S3AccessKeyID,
+		secretKey: cfg.ObjectStorage.S3SecretKey,
+		pathStyle: cfg.ObjectStorage.S3PathStyle,
+	}
+}
+
+// Name identifies S3 objects
+func (s *S3Store) Name() string {
+	return "s3"
+}
+
+// Put uploads an object in a single request
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.do(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Open downloads an object; the caller closes the returned body
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	return resp.Body, nil
+}
+
+// Delete removes an object; S3 also answers 204 for objects that do not exist
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, http.StatusNoContent, http.StatusNotFound)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PresignGet returns a URL downloading an object, signed in its query string. S3 accepts
+// expiry times of up to seven days.
+func (s *S3Store) PresignGet(key string, expiry time.Duration) (string, error) {
+	if expiry > 7*24*time.Hour {
+		expiry = 7 * 24 * time.Hour
+	}
+	return s.presignGet(key, expiry, time.Now().UTC()), nil
+}
+
+// presignGet signs a download URL of an object at a point in time
+func (s *S3Store) presignGet(key string, expiry time.Duration, now time.Time) string {
+	target := s.objectURL(key)
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    s.accessKey + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.Itoa(int(expiry.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = encodeS3Value(name) + "=" + encodeS3Value(query[name])
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		target.EscapedPath(),
+		canonicalQuery,
+		"host:" + target.Host,
+		"",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	target.RawQuery = canonicalQuery + "&X-Amz-Signature=" + s.signature(now, scope, amzDate, canonicalRequest)
+	return target.String()
+}
+
+// objectURL returns the URL of an object, with the bucket in the host name or the path
+func (s *S3Store) objectURL(key string) url.URL {
+	objectPath := "/" + key
+	if s.prefix != "" {
+		objectPath = "/" + s.prefix + objectPath
+	}
+	target := *s.endpoint
+	if s.pathStyle {
+		target.Path = strings.TrimRight(target.Path, "/") + "/" + s.bucket + objectPath
+	} else {
+		target.Host = s.bucket + "." + target.Host
+		target.Path = strings.TrimRight(target.Path, "/") + objectPath
+	}
+	target.RawPath = encodeS3Path(target.Path)
+	return target
+}
+
+// newRequest creates a signed request for an object
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	target := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, time.Now().UTC())
+	return req, nil
+}
+
+// do sends a request and checks that it was answered with one of the expected statuses. The
+// caller closes the body of the returned response.
+func (s *S3Store) do(req *http.Request, expected ...int) (*http.Response, error) {
+	resp, err := s3Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s request failed: %w", req.Method, err)
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 %s request returned status %d: %s", req.Method, resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+// sign adds an AWS Signature Version 4 authorization header. The payload is left unsigned,
+// so archives can be streamed without hashing them first; TLS protects them in transit.
+func (s *S3Store) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:UNSIGNED-PAYLOAD",
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	signature := s.signature(now, scope, amzDate, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// signature signs a canonical request with a key derived from the secret key, date and region
+func (s *S3Store) signature(now time.Time, scope, amzDate, canonicalRequest string) string {
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// encodeS3Path percent-encodes every byte of a path except unreserved characters and slashes,
+// as Signature Version 4 expects
+func encodeS3Path(path string) string {
+	return encodeS3(path, true)
+}
+
+// encodeS3Value percent-encodes every byte of a query parameter except unreserved characters
+func encodeS3Value(value string) string {
+	return encodeS3(value, false)
+}
+
+// encodeS3 percent-encodes a string the way Signature Version 4 expects, keeping slashes if asked
+func encodeS3(path string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if (c == '/' && keepSlash) || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
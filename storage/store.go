@@ -0,0 +1,47 @@
+// Package storage keeps files the backend produces, such as instance backups and workflow
+// exports, in a local directory or an S3-compatible bucket
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/launchstack/backend/config"
+)
+
+// ErrNotFound is returned when no object exists under a key
+var ErrNotFound = errors.New("object not found")
+
+// ObjectStore keeps objects under slash-separated keys such as "backups/<instance id>/<file>"
+type ObjectStore interface {
+	// Name identifies the kind of store in records of stored objects, e.g. "local" or "s3"
+	Name() string
+
+	// Put stores size bytes read from r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Open reads the object under key; it returns ErrNotFound if there is none
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object under key; removing a missing object is not an error
+	Delete(ctx context.Context, key string) error
+
+	// PresignGet returns a URL that downloads the object under key without further
+	// authentication until it expires
+	PresignGet(key string, expiry time.Duration) (string, error)
+}
+
+// New creates the store selected with OBJECT_STORAGE_BACKEND
+func New(cfg *config.Config) (ObjectStore, error) {
+	switch cfg.ObjectStorage.Backend {
+	case "local":
+		return NewLocalStore(cfg), nil
+	case "s3":
+		return NewS3Store(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown object storage backend %q", cfg.ObjectStorage.Backend)
+	}
+}
@@ -3,9 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
-	"strings"
-	
+
 	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/config"
 )
 
 func main() {
@@ -14,40 +14,52 @@ func main() {
 	if err != nil {
 		fmt.Println("Error loading .env file, using environment variables")
 	}
-	
+
 	// Get CORS origins from environment
 	corsOrigins := os.Getenv("CORS_ORIGINS")
 	if corsOrigins == "" {
 		fmt.Println("❌ CORS_ORIGINS environment variable is not set")
 		os.Exit(1)
 	}
-	
-	// Parse CORS origins
-	origins := strings.Split(corsOrigins, ",")
-	
+
+	// Parse CORS origins the way the backend does
+	allowAll, origins, err := config.ParseCORSOrigins(corsOrigins)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
 	// Print CORS origins
 	fmt.Println("Configured CORS origins:")
+	if allowAll {
+		fmt.Println("*. every origin, without credentials")
+	}
 	for i, origin := range origins {
 		fmt.Printf("%d. %s\n", i+1, origin)
 	}
-	
-	// Check if dev.srvr.site is in the allowed origins
-	devSite := "https://dev.srvr.site"
-	found := false
-	for _, origin := range origins {
-		if origin == devSite {
-			found = true
-			break
-		}
+
+	// Check the origins given as arguments, or dev.srvr.site, against the allowed origins
+	checks := os.Args[1:]
+	if len(checks) == 0 {
+		checks = []string{"https://dev.srvr.site"}
 	}
-	
-	if found {
-		fmt.Printf("✅ %s is in the allowed origins\n", devSite)
-	} else {
-		fmt.Printf("❌ %s is NOT in the allowed origins\n", devSite)
+	for _, check := range checks {
+		found := allowAll
+		for _, origin := range origins {
+			if origin.Matches(check) {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			fmt.Printf("✅ %s is in the allowed origins\n", check)
+		} else {
+			fmt.Printf("❌ %s is NOT in the allowed origins\n", check)
+		}
 	}
-	
+
 	// Print testing instructions
 	fmt.Println("\nTo test CORS manually, you can use the following curl command:")
 	fmt.Println("curl -X OPTIONS -H \"Origin: https://dev.srvr.site\" -H \"Access-Control-Request-Method: GET\" -v http://10.1.1.79:8080/api/v1/health")
-} 
\ No newline at end of file
+}
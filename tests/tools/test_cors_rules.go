@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/middleware"
+)
+
+// Checks the CORS rules against tables of the risky cases: parsing of CORS_ORIGINS entries,
+// wildcard patterns against their apex and look-alike domains, ports, the "null" origin, and
+// the middleware's answers in strict and lenient mode, including same-origin requests.
+//
+// Run from tests/tools: go run test_cors_rules.go
+func main() {
+	failed := checkParsing()
+	failed = checkMatching() || failed
+	failed = checkMiddleware() || failed
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Println("PASS: CORS origins are parsed, matched and enforced as configured")
+}
+
+// checkParsing checks which CORS_ORIGINS entries are accepted and what they parse to
+func checkParsing() bool {
+	tests := []struct {
+		entry string
+		want  string // empty when the entry is invalid
+	}{
+		{"https://app.launchstack.io", "https://app.launchstack.io"},
+		{"app.launchstack.io", "https://app.launchstack.io"},
+		{"HTTPS://App.LaunchStack.io/", "https://app.launchstack.io"},
+		{"http://localhost:3000", "http://localhost:3000"},
+		{"https://*.launchstack.io", "https://*.launchstack.io"},
+		{"*.launchstack.io:8443", "https://*.launchstack.io:8443"},
+		{"https://app.*.io", ""},
+		{"https://*", ""},
+		{"ftp://launchstack.io", ""},
+		{"https://launchstack.io/app", ""},
+		{"https://launchstack.io?x=1", ""},
+		{"https://user@launchstack.io", ""},
+		{"", ""},
+	}
+
+	failed := false
+	for _, tt := range tests {
+		pattern, err := config.ParseOriginPattern(tt.entry)
+		got := ""
+		if err == nil {
+			got = pattern.String()
+		}
+		if got != tt.want {
+			fmt.Printf("❌ ParseOriginPattern(%q) = %q, want %q\n", tt.entry, got, tt.want)
+			failed = true
+		}
+	}
+
+	allowAll, origins, err := config.ParseCORSOrigins(" https://app.launchstack.io, *, ,*.launchstack.dev ")
+	if err != nil || !allowAll || len(origins) != 2 {
+		fmt.Printf("❌ ParseCORSOrigins with \"*\" = %v, %v, %v\n", allowAll, origins, err)
+		failed = true
+	}
+	if _, _, err := config.ParseCORSOrigins("https://app.launchstack.io,https://*"); err == nil {
+		fmt.Println("❌ ParseCORSOrigins accepted an invalid entry")
+		failed = true
+	}
+	return failed
+}
+
+// checkMatching checks which Origin headers the patterns allow
+func checkMatching() bool {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://app.launchstack.io", "https://app.launchstack.io", true},
+		{"https://app.launchstack.io", "https://APP.launchstack.io", true},
+		{"https://app.launchstack.io", "http://app.launchstack.io", false},
+		{"https://app.launchstack.io", "https://app.launchstack.io:8443", false},
+		{"https://app.launchstack.io", "https://app.launchstack.io.attacker.com", false},
+		{"https://app.launchstack.io", "https://evilapp.launchstack.io", false},
+		{"http://localhost:3000", "http://localhost:3000", true},
+		{"http://localhost:3000", "http://localhost:3001", false},
+		{"http://localhost:3000", "http://localhost", false},
+		{"https://*.launchstack.io", "https://app.launchstack.io", true},
+		{"https://*.launchstack.io", "https://a.b.launchstack.io", true},
+		{"https://*.launchstack.io", "https://launchstack.io", false},
+		{"https://*.launchstack.io", "https://evil.launchstack.io.attacker.com", false},
+		{"https://*.launchstack.io", "https://evillaunchstack.io", false},
+		{"https://*.launchstack.io", "http://app.launchstack.io", false},
+		{"https://*.launchstack.io", "https://app.launchstack.io:8443", false},
+		{"https://*.launchstack.io:8443", "https://app.launchstack.io:8443", true},
+		{"https://*.launchstack.io:8443", "https://app.launchstack.io", false},
+		{"https://*.launchstack.io", "null", false},
+		{"null", "null", false},
+		{"https://app.launchstack.io", "", false},
+	}
+
+	failed := false
+	for _, tt := range tests {
+		pattern, err := config.ParseOriginPattern(tt.pattern)
+		if err != nil {
+			fmt.Printf("❌ ParseOriginPattern(%q): %v\n", tt.pattern, err)
+			failed = true
+			continue
+		}
+		if got := pattern.Matches(tt.origin); got != tt.want {
+			fmt.Printf("❌ %s matching %q = %v, want %v\n", tt.pattern, tt.origin, got, tt.want)
+			failed = true
+		}
+	}
+	return failed
+}
+
+// checkMiddleware checks the status and CORS headers of requests through the middleware
+func checkMiddleware() bool {
+	gin.SetMode(gin.ReleaseMode)
+	tests := []struct {
+		name        string
+		origins     string
+		strict      bool
+		method      string
+		origin      string
+		host        string
+		wantStatus  int
+		wantAllowed string // expected Access-Control-Allow-Origin
+	}{
+		{"listed origin", "https://app.launchstack.io", true, http.MethodGet, "https://app.launchstack.io", "api.launchstack.io", http.StatusOK, "https://app.launchstack.io"},
+		{"listed preflight", "https://app.launchstack.io", true, http.MethodOptions, "https://app.launchstack.io", "api.launchstack.io", http.StatusNoContent, "https://app.launchstack.io"},
+		{"wildcard subdomain", "https://*.launchstack.io", true, http.MethodGet, "https://app.launchstack.io", "api.launchstack.io", http.StatusOK, "https://app.launchstack.io"},
+		{"look-alike domain, strict", "https://*.launchstack.io", true, http.MethodGet, "https://evil.launchstack.io.attacker.com", "api.launchstack.io", http.StatusForbidden, ""},
+		{"look-alike domain, lenient", "https://*.launchstack.io", false, http.MethodGet, "https://evil.launchstack.io.attacker.com", "api.launchstack.io", http.StatusOK, ""},
+		{"look-alike preflight, lenient", "https://*.launchstack.io", false, http.MethodOptions, "https://evil.launchstack.io.attacker.com", "api.launchstack.io", http.StatusNoContent, ""},
+		{"wrong port, strict", "https://app.launchstack.io", true, http.MethodGet, "https://app.launchstack.io:8443", "api.launchstack.io", http.StatusForbidden, ""},
+		{"null origin, strict", "https://app.launchstack.io", true, http.MethodPost, "null", "api.launchstack.io", http.StatusForbidden, ""},
+		{"null origin with every origin allowed", "*", false, http.MethodGet, "null", "api.launchstack.io", http.StatusOK, "*"},
+		{"same origin, strict", "https://app.launchstack.io", true, http.MethodPost, "https://api.launchstack.io", "api.launchstack.io", http.StatusOK, ""},
+		{"same host on another port, strict", "https://app.launchstack.io", true, http.MethodPost, "https://api.launchstack.io:8443", "api.launchstack.io", http.StatusForbidden, ""},
+		{"no origin, strict", "https://app.launchstack.io", true, http.MethodGet, "", "api.launchstack.io", http.StatusOK, ""},
+	}
+
+	failed := false
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		var err error
+		cfg.CORS.AllowAll, cfg.CORS.Origins, err = config.ParseCORSOrigins(tt.origins)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", tt.name, err)
+			failed = true
+			continue
+		}
+		cfg.CORS.Strict = tt.strict
+
+		router := gin.New()
+		router.Use(middleware.CORSMiddleware(cfg, nil))
+		router.Handle(tt.method, "/api/v1/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(tt.method, "/api/v1/health", nil)
+		req.Host = tt.host
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		allowed := rec.Header().Get("Access-Control-Allow-Origin")
+		if rec.Code != tt.wantStatus || allowed != tt.wantAllowed {
+			fmt.Printf("❌ %s: got %d with Access-Control-Allow-Origin %q, want %d with %q\n",
+				tt.name, rec.Code, allowed, tt.wantStatus, tt.wantAllowed)
+			failed = true
+		}
+	}
+	return failed
+}
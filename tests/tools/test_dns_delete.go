@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"github.com/launchstack/backend/config"
 	"github.com/launchstack/backend/container"
 	"github.com/sirupsen/logrus"
 )
@@ -16,8 +18,15 @@ func main() {
 		FullTimestamp: true,
 	})
 	
-	// Create DNS manager
-	dnsManager := container.NewDNSManager(logger)
+	// Create the AdGuard DNS provider from the ADGUARD_* variables
+	cfg := &config.Config{}
+	cfg.DNS.AdGuard.Host = os.Getenv("ADGUARD_HOST")
+	cfg.DNS.AdGuard.Username = os.Getenv("ADGUARD_USERNAME")
+	cfg.DNS.AdGuard.Password = os.Getenv("ADGUARD_PASSWORD")
+	cfg.DNS.AdGuard.Protocol = "https"
+	cfg.DNS.AdGuard.CLIPath = "./dns-cli"
+	dnsManager := container.NewAdGuardDNS(cfg, logger)
+	ctx := context.Background()
 	
 	// Check command-line arguments
 	if len(os.Args) < 2 {
@@ -34,14 +43,14 @@ func main() {
 	switch command {
 	case "list":
 		// List all DNS records
-		records, err := dnsManager.GetDNSRewrites()
+		records, err := dnsManager.ListRecords(ctx)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to list DNS records")
 		}
 		
 		fmt.Println("DNS Records:")
 		for _, record := range records {
-			fmt.Printf("Domain: %-30s Answer: %s\n", record.Domain, record.Answer)
+			fmt.Printf("Domain: %-30s Answer: %s\n", record.Name, record.Answer)
 		}
 		
 	case "add":
@@ -59,7 +68,7 @@ func main() {
 			"ip":     ip,
 		}).Info("Adding DNS record")
 		
-		err := dnsManager.AddDNSRewrite(domain, ip)
+		err := dnsManager.SetRecord(ctx, domain, ip)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to add DNS record")
 		}
@@ -72,7 +81,7 @@ func main() {
 		
 		logger.WithField("domain", domain).Info("Deleting DNS record")
 		
-		err := dnsManager.DeleteDNSRewrite(domain)
+		err := dnsManager.DeleteRecord(ctx, domain)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to delete DNS record")
 		}
@@ -27,7 +27,7 @@ func main() {
 
 	// Initialize Docker client
 	logger.Info("Initializing Docker client...")
-	dockerClient, err := container.NewDockerClient("")
+	dockerClient, err := container.NewDockerClient(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize Docker client: %v", err)
 	}
@@ -55,8 +55,11 @@ func main() {
 
 	// Create the instance
 	logger.Info("Creating test instance...")
-	instance, err := containerManager.CreateInstance(context.Background(), testUser, instanceReq)
+	instance, err := containerManager.PrepareInstance(context.Background(), testUser, instanceReq)
 	if err != nil {
+		log.Fatalf("Failed to prepare instance: %v", err)
+	}
+	if err := containerManager.ProvisionInstance(context.Background(), testUser, instance, logTracker{logger}); err != nil {
 		log.Fatalf("Failed to create instance: %v", err)
 	}
 	logger.WithFields(logrus.Fields{
@@ -107,4 +110,20 @@ func main() {
 
 	logger.Info("Test completed successfully")
 	fmt.Println("Test completed. Please check the logs for details.")
-} 
\ No newline at end of file
+} 
+// logTracker logs the progress of each provisioning step
+type logTracker struct {
+	logger *logrus.Logger
+}
+
+func (t logTracker) StepStarted(step models.ProvisioningStepName) {
+	t.logger.Infof("Provisioning step %s started", step)
+}
+
+func (t logTracker) StepFinished(step models.ProvisioningStepName, err error) {
+	if err != nil {
+		t.logger.WithError(err).Errorf("Provisioning step %s failed", step)
+		return
+	}
+	t.logger.Infof("Provisioning step %s finished", step)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -10,7 +11,10 @@ import (
 
 // Import our container package that contains the DNS manager
 // Update this import path if needed to match your project structure
-import "github.com/launchstack/backend/container"
+import (
+	"github.com/launchstack/backend/config"
+	"github.com/launchstack/backend/container"
+)
 
 func main() {
 	// Setup logger
@@ -20,8 +24,15 @@ func main() {
 		FullTimestamp: true,
 	})
 	
-	// Create DNS manager
-	dnsManager := container.NewDNSManager(logger)
+	// Create the AdGuard DNS provider from the ADGUARD_* variables
+	cfg := &config.Config{}
+	cfg.DNS.AdGuard.Host = os.Getenv("ADGUARD_HOST")
+	cfg.DNS.AdGuard.Username = os.Getenv("ADGUARD_USERNAME")
+	cfg.DNS.AdGuard.Password = os.Getenv("ADGUARD_PASSWORD")
+	cfg.DNS.AdGuard.Protocol = "https"
+	cfg.DNS.AdGuard.CLIPath = "./dns-cli"
+	dnsManager := container.NewAdGuardDNS(cfg, logger)
+	ctx := context.Background()
 	
 	// Check command-line arguments
 	if len(os.Args) < 2 {
@@ -41,14 +52,14 @@ func main() {
 	case "list":
 		// List all DNS records
 		fmt.Println("Listing all DNS records...")
-		records, err := dnsManager.GetDNSRewrites()
+		records, err := dnsManager.ListRecords(ctx)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to list DNS records")
 		}
 		
 		fmt.Println("DNS Records:")
 		for _, record := range records {
-			fmt.Printf("Domain: %-30s Answer: %s\n", record.Domain, record.Answer)
+			fmt.Printf("Domain: %-30s Answer: %s\n", record.Name, record.Answer)
 		}
 		
 	case "add":
@@ -66,7 +77,7 @@ func main() {
 			"ip":     ip,
 		}).Info("Adding DNS record")
 		
-		err := dnsManager.AddDNSRewrite(domain, ip)
+		err := dnsManager.SetRecord(ctx, domain, ip)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to add DNS record")
 		}
@@ -74,17 +85,17 @@ func main() {
 		logger.Info("DNS record added successfully")
 		
 		// Verify the record was added
-		records, err := dnsManager.GetDNSRewrites()
+		records, err := dnsManager.ListRecords(ctx)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to verify DNS record was added")
 		}
 		
 		found := false
 		for _, record := range records {
-			if record.Domain == domain {
+			if record.Name == domain {
 				found = true
 				logger.WithFields(logrus.Fields{
-					"domain": record.Domain,
+					"domain": record.Name,
 					"ip":     record.Answer,
 				}).Info("Verified DNS record was added")
 				break
@@ -105,12 +116,12 @@ func main() {
 		domain := os.Args[2]
 		
 		// Check if record exists first
-		existingRecord, err := dnsManager.FindDNSRewrite(domain)
+		existingRecord, err := dnsManager.FindRecord(ctx, domain)
 		if err != nil {
 			logger.WithError(err).Warn("Record not found before deletion attempt")
 		} else {
 			logger.WithFields(logrus.Fields{
-				"domain": existingRecord.Domain,
+				"domain": existingRecord.Name,
 				"ip":     existingRecord.Answer,
 			}).Info("Found DNS record to delete")
 		}
@@ -118,7 +129,7 @@ func main() {
 		// Delete the record with our improved approach
 		logger.WithField("domain", domain).Info("Deleting DNS record")
 		
-		err = dnsManager.DeleteDNSRewrite(domain)
+		err = dnsManager.DeleteRecord(ctx, domain)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to delete DNS record")
 		}
@@ -129,17 +140,17 @@ func main() {
 		time.Sleep(2 * time.Second)
 		
 		// Verify the record was deleted
-		records, err := dnsManager.GetDNSRewrites()
+		records, err := dnsManager.ListRecords(ctx)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to verify DNS record deletion")
 		}
 		
 		stillExists := false
 		for _, record := range records {
-			if record.Domain == domain {
+			if record.Name == domain {
 				stillExists = true
 				logger.WithFields(logrus.Fields{
-					"domain": record.Domain,
+					"domain": record.Name,
 					"ip":     record.Answer,
 				}).Warn("DNS record still exists after deletion")
 				break
@@ -163,7 +174,7 @@ func main() {
 			"ip":     testIP,
 		}).Info("Adding test DNS record")
 		
-		err := dnsManager.AddDNSRewrite(testDomain, testIP)
+		err := dnsManager.SetRecord(ctx, testDomain, testIP)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to add test DNS record")
 		}
@@ -174,17 +185,17 @@ func main() {
 		time.Sleep(2 * time.Second)
 		
 		// Verify the record was added
-		records, err := dnsManager.GetDNSRewrites()
+		records, err := dnsManager.ListRecords(ctx)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to verify test DNS record was added")
 		}
 		
 		found := false
 		for _, record := range records {
-			if record.Domain == testDomain {
+			if record.Name == testDomain {
 				found = true
 				logger.WithFields(logrus.Fields{
-					"domain": record.Domain,
+					"domain": record.Name,
 					"ip":     record.Answer,
 				}).Info("Verified test DNS record was added")
 				break
@@ -198,7 +209,7 @@ func main() {
 		// Delete the test record
 		logger.WithField("domain", testDomain).Info("Deleting test DNS record")
 		
-		err = dnsManager.DeleteDNSRewrite(testDomain)
+		err = dnsManager.DeleteRecord(ctx, testDomain)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to delete test DNS record")
 		}
@@ -209,17 +220,17 @@ func main() {
 		time.Sleep(2 * time.Second)
 		
 		// Verify the record was deleted
-		records, err = dnsManager.GetDNSRewrites()
+		records, err = dnsManager.ListRecords(ctx)
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to verify test DNS record deletion")
 		}
 		
 		stillExists := false
 		for _, record := range records {
-			if record.Domain == testDomain {
+			if record.Name == testDomain {
 				stillExists = true
 				logger.WithFields(logrus.Fields{
-					"domain": record.Domain,
+					"domain": record.Name,
 					"ip":     record.Answer,
 				}).Warn("Test DNS record still exists after deletion")
 				break
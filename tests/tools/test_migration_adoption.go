@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/db"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Checks that a database created by AutoMigrate before migrations were versioned is adopted:
+// a scratch schema in the database of DATABASE_URL, a postgres:// URL, is given the baseline
+// schema without schema_migrations, and two instances sharing a host. Migrating must then
+// apply every later migration, leave no column of the models missing, rename the duplicate
+// host and do nothing when run again. The scratch schema is dropped afterwards.
+//
+// Run from tests/tools: go run test_migration_adoption.go
+func main() {
+	godotenv.Load("../../.env")
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		fail("DATABASE_URL is required")
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	schema := "adoption_check_" + hex.EncodeToString(suffix)
+	admin, err := open(databaseURL, "")
+	if err != nil {
+		fail("connect: %v", err)
+	}
+	if err := admin.Exec(`CREATE SCHEMA "` + schema + `"`).Error; err != nil {
+		fail("create schema: %v", err)
+	}
+	err = checkAdoption(databaseURL, schema)
+	admin.Exec(`DROP SCHEMA "` + schema + `" CASCADE`)
+	if err != nil {
+		fail("%v", err)
+	}
+	fmt.Println("PASS: a database created before versioned migrations is adopted")
+}
+
+// checkAdoption creates the baseline-era schema and migrates it
+func checkAdoption(databaseURL, schema string) error {
+	var err error
+	db.DB, err = open(databaseURL, schema)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", schema, err)
+	}
+	if err := createBaseline(); err != nil {
+		return fmt.Errorf("create baseline schema: %w", err)
+	}
+	fmt.Printf("Created a baseline-era schema in %s\n", schema)
+
+	if err := db.Migrate(); err != nil {
+		return fmt.Errorf("adopt the baseline schema: %w", err)
+	}
+	if err := db.VerifyMigrations(); err != nil {
+		return fmt.Errorf("verify the adopted schema: %w", err)
+	}
+	var hosts []string
+	if err := db.DB.Raw(`SELECT "host" FROM "instances" ORDER BY "host"`).Scan(&hosts).Error; err != nil {
+		return fmt.Errorf("read hosts: %w", err)
+	}
+	if strings.Join(hosts, ",") != "shared,shared-2" {
+		return fmt.Errorf("expected the duplicate host to be renamed to shared-2, got %v", hosts)
+	}
+	if err := db.Migrate(); err != nil {
+		return fmt.Errorf("migrate the adopted schema again: %w", err)
+	}
+	return nil
+}
+
+// open connects to the database, with schema first on the search path if it is set
+func open(databaseURL, schema string) (*gorm.DB, error) {
+	if schema != "" {
+		u, err := url.Parse(databaseURL)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		query.Set("search_path", schema)
+		u.RawQuery = query.Encode()
+		databaseURL = u.String()
+	}
+	return gorm.Open(postgres.Open(databaseURL), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+}
+
+// createBaseline runs the Up section of the baseline migration without recording it, as
+// AutoMigrate left databases, and adds a user with two instances on the same host
+func createBaseline() error {
+	baseline, err := os.ReadFile("../../db/migrations/sql/00001_baseline.sql")
+	if err != nil {
+		return err
+	}
+	up := string(baseline)
+	up = up[strings.Index(up, "-- +goose Up"):strings.Index(up, "-- +goose Down")]
+	if err := db.DB.Exec(up).Error; err != nil {
+		return err
+	}
+
+	var userID string
+	err = db.DB.Raw(`INSERT INTO "users" ("email", "clerk_user_id", "created_at") VALUES ('adoption@example.com', 'user_adoption', now()) RETURNING "id"`).Scan(&userID).Error
+	if err != nil {
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		err := db.DB.Exec(`INSERT INTO "instances" ("user_id", "name", "status", "host", "url", "created_at") VALUES (?, ?, 'running', 'shared', 'https://shared.example.com', now() + ? * interval '1 second')`,
+			userID, fmt.Sprintf("instance-%d", i), i).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fail reports a failed check and exits
+func fail(format string, args ...interface{}) {
+	fmt.Printf("FAIL: "+format+"\n", args...)
+	os.Exit(1)
+}
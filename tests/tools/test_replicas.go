@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+)
+
+// Runs two backend replicas against the database configured in .env behind a round-robin
+// proxy, and checks that requests do not depend on which replica handles them:
+//
+//   - both replicas serve requests and report distinct replica IDs
+//   - a signed request cannot be replayed against the other replica (needs
+//     TEST_API_KEY_ID and TEST_API_KEY_SIGNING_SECRET)
+//   - reads with an API key give the same answer on both replicas (needs TEST_API_KEY)
+//   - both replicas agree on which replica holds each singleton lease (needs TEST_ADMIN_TOKEN)
+//
+// Run from tests/tools: go run test_replicas.go
+func main() {
+	godotenv.Load("../../.env")
+
+	binary, err := buildBackend()
+	if err != nil {
+		fail("build backend: %v", err)
+	}
+	defer os.RemoveAll(filepath.Dir(binary))
+
+	var upstreams []*url.URL
+	for _, name := range []string{"replica-a", "replica-b"} {
+		port, err := freePort()
+		if err != nil {
+			fail("find a free port: %v", err)
+		}
+		cmd, err := startReplica(binary, name, port)
+		if err != nil {
+			fail("start %s: %v", name, err)
+		}
+		defer cmd.Process.Kill()
+		upstreams = append(upstreams, &url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", port)})
+	}
+	for _, upstream := range upstreams {
+		if err := waitHealthy(upstream.String()); err != nil {
+			fail("%v", err)
+		}
+	}
+
+	proxy := roundRobin(upstreams)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fail("start proxy: %v", err)
+	}
+	go http.Serve(listener, proxy)
+	base := "http://" + listener.Addr().String()
+	fmt.Printf("Replicas %v behind %s\n", upstreams, base)
+
+	checkRoundRobin(base)
+	checkSignedReplay(base)
+	checkConsistentReads(base)
+	checkLeases(base)
+	fmt.Println("PASS: the backend is safe to run with multiple replicas")
+}
+
+// buildBackend compiles the backend into a temporary directory
+func buildBackend() (string, error) {
+	dir, err := os.MkdirTemp("", "launchstack-replicas")
+	if err != nil {
+		return "", err
+	}
+	binary := filepath.Join(dir, "launchstack")
+	cmd := exec.Command("go", "build", "-o", binary, "main.go")
+	cmd.Dir = "../.."
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return binary, cmd.Run()
+}
+
+// startReplica runs the backend on port with its own replica ID, logging to a file
+func startReplica(binary, name string, port int) (*exec.Cmd, error) {
+	logFile, err := os.Create(filepath.Join(filepath.Dir(binary), name+".log"))
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(binary)
+	cmd.Dir = "../.."
+	cmd.Env = append(os.Environ(), "PORT="+strconv.Itoa(port), "REPLICA_ID="+name)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	return cmd, cmd.Start()
+}
+
+// freePort returns a TCP port nothing listens on
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitHealthy waits for a replica's health check to pass
+func waitHealthy(base string) error {
+	deadline := time.Now().Add(60 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(base + "/api/v1/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("replica at %s did not become healthy", base)
+}
+
+// roundRobin returns a reverse proxy sending each request to the next upstream in turn
+func roundRobin(upstreams []*url.URL) *httputil.ReverseProxy {
+	var next uint64
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			upstream := upstreams[atomic.AddUint64(&next, 1)%uint64(len(upstreams))]
+			req.URL.Scheme = upstream.Scheme
+			req.URL.Host = upstream.Host
+		},
+	}
+}
+
+// checkRoundRobin verifies that consecutive requests are served by both replicas
+func checkRoundRobin(base string) {
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		resp, err := http.Get(base + "/api/v1/health")
+		if err != nil {
+			fail("health check through proxy: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			fail("health check through proxy returned %d", resp.StatusCode)
+		}
+		seen[resp.Header.Get(middleware.ReplicaHeader)] = true
+	}
+	if len(seen) != 2 {
+		fail("expected responses from 2 replicas, got %v", seen)
+	}
+	fmt.Println("ok: requests are spread over both replicas")
+}
+
+// checkSignedReplay sends the same signed request twice, once to each replica; the second
+// must be rejected as a replay
+func checkSignedReplay(base string) {
+	keyID, secret := os.Getenv("TEST_API_KEY_ID"), os.Getenv("TEST_API_KEY_SIGNING_SECRET")
+	if keyID == "" || secret == "" {
+		fmt.Println("skip: signed request replay (TEST_API_KEY_ID and TEST_API_KEY_SIGNING_SECRET not set)")
+		return
+	}
+
+	nonceBytes := make([]byte, 16)
+	rand.Read(nonceBytes)
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	path := "/api/v1/users/me"
+
+	var replicas []string
+	for attempt, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req, _ := http.NewRequest(http.MethodGet, base+path, nil)
+		req.Header.Set("Authorization", middleware.SignatureScheme+" "+keyID)
+		req.Header.Set(middleware.SignatureTimestampHeader, timestamp)
+		req.Header.Set(middleware.SignatureNonceHeader, nonce)
+		req.Header.Set(middleware.SignatureHeader, models.SignAPIRequest(secret, http.MethodGet, path, timestamp, nonce, nil))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fail("signed request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			fail("signed request %d returned %d, want %d", attempt+1, resp.StatusCode, want)
+		}
+		replicas = append(replicas, resp.Header.Get(middleware.ReplicaHeader))
+	}
+	if replicas[0] == replicas[1] {
+		fail("both signed requests went to %s", replicas[0])
+	}
+	fmt.Println("ok: a signed request cannot be replayed against another replica")
+}
+
+// checkConsistentReads reads the same resource from both replicas and compares the answers
+func checkConsistentReads(base string) {
+	apiKey := os.Getenv("TEST_API_KEY")
+	if apiKey == "" {
+		fmt.Println("skip: consistent reads (TEST_API_KEY not set)")
+		return
+	}
+
+	var bodies []string
+	for i := 0; i < 2; i++ {
+		status, body := getJSON(base+"/api/v1/instances", "Bearer "+apiKey)
+		if status != http.StatusOK {
+			fail("listing instances returned %d: %s", status, body)
+		}
+		bodies = append(bodies, string(body))
+	}
+	if bodies[0] != bodies[1] {
+		fail("replicas disagree on the instance list:\n%s\n%s", bodies[0], bodies[1])
+	}
+	fmt.Println("ok: both replicas give the same answer")
+}
+
+// checkLeases verifies that every singleton lease has one holder that both replicas agree on
+func checkLeases(base string) {
+	token := os.Getenv("TEST_ADMIN_TOKEN")
+	if token == "" {
+		fmt.Println("skip: lease ownership (TEST_ADMIN_TOKEN not set)")
+		return
+	}
+
+	holders := make([]map[string]string, 2)
+	for i := range holders {
+		status, body := getJSON(base+"/api/v1/admin/leases", "Bearer "+token)
+		if status != http.StatusOK {
+			fail("listing leases returned %d: %s", status, body)
+		}
+		var response struct {
+			Leases []struct {
+				Name   string `json:"name"`
+				Holder string `json:"holder"`
+				Held   bool   `json:"held"`
+			} `json:"leases"`
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			fail("decode leases: %v", err)
+		}
+		holders[i] = make(map[string]string)
+		for _, lease := range response.Leases {
+			if lease.Held {
+				holders[i][lease.Name] = lease.Holder
+			}
+		}
+	}
+	for name, holder := range holders[0] {
+		if other, ok := holders[1][name]; ok && other != holder {
+			fail("lease %s is held by %s and %s", name, holder, other)
+		}
+	}
+	fmt.Printf("ok: %d singleton leases each have a single holder\n", len(holders[0]))
+}
+
+// getJSON sends an authorized GET request and returns the status and body
+func getJSON(url, authorization string) (int, []byte) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", authorization)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fail("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+// fail reports a failed check and exits
+func fail(format string, args ...interface{}) {
+	fmt.Printf("FAIL: "+format+"\n", args...)
+	os.Exit(1)
+}
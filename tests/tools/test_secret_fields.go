@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/launchstack/backend/middleware"
+	"github.com/launchstack/backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Checks that no API response or log line exposes a secret: every ToPublicResponse output and
+// the JSON of every model is walked for fields on the secret denylist (see models.IsSecretName).
+func main() {
+	userID := uuid.New()
+	instanceID := uuid.New()
+
+	user := &models.User{
+		ID:               userID,
+		Email:            "user@example.com",
+		PasswordHash:     "$2a$10$abcdefghijklmnopqrstuv",
+		PayPalCustomerID: "PAYER-12345678",
+		SubscriptionID:   "I-12345678",
+		Plan:             models.PlanPro,
+	}
+	instance := &models.Instance{
+		ID:     instanceID,
+		UserID: userID,
+		Name:   "audit",
+		ProvisioningSpec: &models.ProvisioningSpec{
+			Env: models.RedactEnv([]string{
+				"N8N_HOST=audit.launchstack.io",
+				"N8N_BASIC_AUTH_PASSWORD=hunter22",
+				"N8N_ENCRYPTION_KEY=0123456789abcdef",
+			}),
+		},
+	}
+	_, apiKey, err := models.GenerateAPIKey(userID, nil, "audit")
+	if err != nil {
+		fmt.Printf("❌ Failed to create API key: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := apiKey.EnableSigning(); err != nil {
+		fmt.Printf("❌ Failed to enable request signing: %v\n", err)
+		os.Exit(1)
+	}
+	if err := models.SetEncryptionKeys(make([]byte, 32)); err != nil {
+		fmt.Printf("❌ Failed to configure encryption keys: %v\n", err)
+		os.Exit(1)
+	}
+	credential := &models.InstanceCredential{InstanceID: instanceID, Username: "audit"}
+	if err := credential.SetPassword("hunter22"); err != nil {
+		fmt.Printf("❌ Failed to encrypt credentials: %v\n", err)
+		os.Exit(1)
+	}
+	provisioning := models.NewProvisioningJob(instance, "idempotency-key")
+	job, err := models.NewInstanceJob(models.JobUpgradeInstance, instance, map[string]string{"to_version": "1.45.1"})
+	if err != nil {
+		fmt.Printf("❌ Failed to create job: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputs := map[string]interface{}{
+		"User.ToPublicResponse":                user.ToPublicResponse(),
+		"User":                                 user,
+		"Instance.ToPublicResponse":            instance.ToPublicResponse(),
+		"Instance":                             instance,
+		"APIKey.ToPublicResponse":              apiKey.ToPublicResponse(),
+		"APIKey":                               apiKey,
+		"Payment.ToPublicResponse":             (&models.Payment{UserID: userID, Metadata: `{"subscription_id": "I-12345678"}`}).ToPublicResponse(),
+		"Project.ToPublicResponse":             (&models.Project{UserID: userID}).ToPublicResponse(),
+		"Branding.ToPublicResponse":            (&models.Branding{}).ToPublicResponse(),
+		"InstanceCertificate.ToPublicResponse": (&models.InstanceCertificate{InstanceID: instanceID}).ToPublicResponse(),
+		"InstanceEvent.ToPublicResponse":       (&models.InstanceEvent{InstanceID: instanceID}).ToPublicResponse(),
+		"ProbeResult.ToPublicResponse":         (&models.ProbeResult{InstanceID: instanceID}).ToPublicResponse(),
+		"ResourceUsage.ToPublicResponse":       (&models.ResourceUsage{InstanceID: instanceID}).ToPublicResponse(),
+		"UsageRecord.ToPublicResponse":         (&models.UsageRecord{UserID: userID}).ToPublicResponse(),
+		"UsageRollup.ToPublicResponse":         (&models.UsageRollup{UserID: userID}).ToPublicResponse(),
+		"Spending.ToPublicResponse":            models.NewSpending(*user, models.UsageRecord{UserID: userID}, models.UsagePrices{}).ToPublicResponse(),
+		"ProvisioningJob.ToPublicResponse":     provisioning.ToPublicResponse(),
+		"ProvisioningJob":                      provisioning,
+		"Job.ToPublicResponse":                 job.ToPublicResponse(),
+		"Job":                                  job,
+		"InstanceCredential":                   credential,
+		"WaitlistEntry.ToPublicResponse":       (&models.WaitlistEntry{UserID: userID}).ToPublicResponse(1),
+		"HostAgent.ToPublicResponse":           (&models.HostAgent{Host: "eu-west-1.launchstack.io"}).ToPublicResponse(),
+		"AgentToken.ToPublicResponse":          (&models.AgentToken{}).ToPublicResponse(),
+		"WebhookEndpoint.ToPublicResponse":     (&models.WebhookEndpoint{UserID: userID, Secret: "whsec_0123456789abcdef"}).ToPublicResponse(),
+		"WebhookEvent":                         models.NewInstanceWebhookEvent(models.WebhookInstanceCreated, instance, ""),
+		"Lease.ToPublicResponse":               (&models.Lease{Name: "health_monitor"}).ToPublicResponse(time.Now()),
+		"BillingEntry.ToPublicResponse":        (&models.BillingEntry{UserID: userID, Reference: "payment:1:charge", Hash: "9f86d081884c7d65"}).ToPublicResponse(),
+		"Backup.ToPublicResponse":              (&models.Backup{InstanceID: instanceID, UserID: userID}).ToPublicResponse(),
+		"BackupSchedule.ToPublicResponse":      (&models.BackupSchedule{InstanceID: instanceID, UserID: userID}).ToPublicResponse(),
+		"Notification.ToPublicResponse":        (&models.Notification{InstanceID: &instanceID, UserID: userID}).ToPublicResponse(),
+		"Plan.ToPublicResponse":                (&models.Plan{Name: models.PlanPro}).ToPublicResponse(),
+		"DockerHost.ToPublicResponse":          (&models.DockerHost{Name: "docker-1"}).ToPublicResponse(),
+		"OperatorReport.ToPublicResponse":      (&models.OperatorReport{DeliveredTo: "email"}).ToPublicResponse(),
+		"WebhookDelivery.ToPublicResponse":     (&models.WebhookDelivery{}).ToPublicResponse(),
+		"WorkflowExecution.ToPublicResponse":   (&models.WorkflowExecution{}).ToPublicResponse(),
+		"Invoice.ToPublicResponse":             (&models.Invoice{UserID: userID}).ToPublicResponse(),
+		"TerminalSession.ToPublicResponse":     (&models.TerminalSession{}).ToPublicResponse(),
+		"CachedImage.ToPublicResponse":         (&models.CachedImage{HostName: "docker-1", Image: "n8nio/n8n:latest"}).ToPublicResponse(),
+	}
+
+	failed := false
+	for name, output := range outputs {
+		fields, err := models.FindSecretFields(output)
+		if err != nil {
+			fmt.Printf("❌ %s: failed to serialize: %v\n", name, err)
+			failed = true
+			continue
+		}
+		if len(fields) > 0 {
+			fmt.Printf("❌ %s exposes %s\n", name, strings.Join(fields, ", "))
+			failed = true
+			continue
+		}
+		fmt.Printf("✅ %s\n", name)
+	}
+
+	// Log fields are redacted by the hook the server installs on its logger
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.AddHook(middleware.RedactionHook{})
+	logger.WithFields(logrus.Fields{
+		"password":        "hunter22",
+		"signing_secret":  "lss_0123456789",
+		"subscription_id": "I-12345678",
+		"user_id":         userID,
+	}).Info("Audit log line")
+	body := models.RedactJSON([]byte(`{"data": {"password": "hunter22", "email_addresses": [{"verification": {"token": "abc"}}]}}`))
+	logger.Infof("Request body: %s", body)
+	// Configured secrets are redacted wherever they appear, e.g. in a connection error
+	models.RegisterSecretValue("db-pa55word")
+	logger.WithError(fmt.Errorf("connect to postgres://app:db-pa55word@db failed")).Error("Connection failed with db-pa55word")
+
+	for _, secret := range []string{"hunter22", "lss_0123456789", "I-12345678", `"abc"`, "db-pa55word"} {
+		if strings.Contains(buf.String(), secret) {
+			fmt.Printf("❌ Log output exposes %s\n", secret)
+			failed = true
+		}
+	}
+	if !strings.Contains(buf.String(), userID.String()) {
+		fmt.Println("❌ Log output lost a field that is not secret")
+		failed = true
+	}
+	if !failed {
+		fmt.Println("✅ Log output")
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Println("No secrets exposed")
+}
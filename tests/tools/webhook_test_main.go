@@ -71,7 +71,7 @@ func main() {
 	
 	// Run database migrations
 	logger.Info("Running database migrations...")
-	err = db.RunMigrations()
+	err = db.Migrate()
 	if err != nil {
 		logger.Fatalf("Failed to run migrations: %v", err)
 	}